@@ -375,6 +375,8 @@ type RestartOptions struct {
 	// Reload the services, if possible (i.e. if the App has a
 	// ReloadCommand, invoque it), instead of restarting.
 	Reload bool `json:"reload,omitempty"`
+	// At holds the restart until the given time, in RFC3339 format.
+	At string `json:"at,omitempty"`
 }
 
 // Restart services.
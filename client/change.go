@@ -35,10 +35,12 @@ type Change struct {
 	Status  string  `json:"status"`
 	Tasks   []*Task `json:"tasks,omitempty"`
 	Ready   bool    `json:"ready"`
+	Paused  bool    `json:"paused,omitempty"`
 	Err     string  `json:"err,omitempty"`
 
-	SpawnTime time.Time `json:"spawn-time,omitzero"`
-	ReadyTime time.Time `json:"ready-time,omitzero"`
+	SpawnTime     time.Time `json:"spawn-time,omitzero"`
+	ReadyTime     time.Time `json:"ready-time,omitzero"`
+	ScheduledTime time.Time `json:"scheduled-time,omitzero"`
 
 	data map[string]*json.RawMessage
 }
@@ -92,10 +94,31 @@ func (client *Client) Change(id string) (*Change, error) {
 
 // Abort attempts to abort a change that is in not yet ready.
 func (client *Client) Abort(id string) (*Change, error) {
+	return client.changeAction(id, "abort")
+}
+
+// Pause holds a change that is not yet ready at task boundaries, until it
+// is resumed.
+func (client *Client) Pause(id string) (*Change, error) {
+	return client.changeAction(id, "pause")
+}
+
+// Resume continues a paused change.
+func (client *Client) Resume(id string) (*Change, error) {
+	return client.changeAction(id, "resume")
+}
+
+// Retry runs again the tasks of a change that failed, if none were undone
+// yet and they can be run again.
+func (client *Client) Retry(id string) (*Change, error) {
+	return client.changeAction(id, "retry")
+}
+
+func (client *Client) changeAction(id, action string) (*Change, error) {
 	var postData struct {
 		Action string `json:"action"`
 	}
-	postData.Action = "abort"
+	postData.Action = action
 
 	var body bytes.Buffer
 	if err := json.NewEncoder(&body).Encode(postData); err != nil {
@@ -120,6 +143,8 @@ func (c ChangeSelector) String() string {
 		return "ready"
 	case ChangesAll:
 		return "all"
+	case ChangesArchived:
+		return "archived"
 	}
 
 	panic(fmt.Sprintf("unknown ChangeSelector %d", c))
@@ -128,12 +153,18 @@ func (c ChangeSelector) String() string {
 const (
 	ChangesInProgress ChangeSelector = 1 << iota
 	ChangesReady
+	// ChangesArchived selects the changes pruned from the system state
+	// and kept in the change history instead.
+	ChangesArchived
 	ChangesAll = ChangesReady | ChangesInProgress
 )
 
 type ChangesOptions struct {
 	SnapName string // if empty, no filtering by name is done
 	Selector ChangeSelector
+	// Since can only be used with ChangesArchived; if not zero, only
+	// changes that became ready from then on are returned.
+	Since time.Time
 }
 
 func (client *Client) Changes(opts *ChangesOptions) ([]*Change, error) {
@@ -145,6 +176,9 @@ func (client *Client) Changes(opts *ChangesOptions) ([]*Change, error) {
 		if opts.SnapName != "" {
 			query.Set("for", opts.SnapName)
 		}
+		if !opts.Since.IsZero() {
+			query.Set("since", opts.Since.Format(time.RFC3339))
+		}
 	}
 
 	var chgds []changeAndData
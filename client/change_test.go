@@ -20,7 +20,9 @@
 package client_test
 
 import (
+	"fmt"
 	"io"
+	"net/url"
 	"time"
 
 	"gopkg.in/check.v1"
@@ -135,6 +137,7 @@ func (cs *clientSuite) TestClientChangesString(c *check.C) {
 		client.ChangesAll:        "all",
 		client.ChangesReady:      "ready",
 		client.ChangesInProgress: "in-progress",
+		client.ChangesArchived:   "archived",
 	} {
 		c.Check(k.String(), check.Equals, v)
 	}
@@ -179,6 +182,42 @@ func (cs *clientSuite) TestClientChanges(c *check.C) {
 
 }
 
+func (cs *clientSuite) TestClientChangesArchived(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": [{
+  "id":   "uno",
+  "kind": "foo",
+  "summary": "...",
+  "status": "Done",
+  "ready": true,
+  "spawn-time": "2026-04-21T01:02:03Z",
+  "ready-time": "2026-04-21T01:02:04Z"
+}]}`
+
+	since := time.Date(2026, 4, 21, 1, 0, 0, 0, time.UTC)
+	chgs, err := cs.cli.Changes(&client.ChangesOptions{
+		Selector: client.ChangesArchived,
+		SnapName: "foo",
+		Since:    since,
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(chgs, check.DeepEquals, []*client.Change{{
+		ID:        "uno",
+		Kind:      "foo",
+		Summary:   "...",
+		Status:    "Done",
+		Ready:     true,
+		SpawnTime: time.Date(2026, 4, 21, 1, 2, 3, 0, time.UTC),
+		ReadyTime: time.Date(2026, 4, 21, 1, 2, 4, 0, time.UTC),
+	}})
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/changes")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"select": []string{"archived"},
+		"for":    []string{"foo"},
+		"since":  []string{"2026-04-21T01:00:00Z"},
+	})
+}
+
 func (cs *clientSuite) TestClientChangesData(c *check.C) {
 	cs.rsp = `{"type": "sync", "result": [{
   "id":   "uno",
@@ -232,3 +271,43 @@ func (cs *clientSuite) TestClientAbort(c *check.C) {
 
 	c.Assert(string(body), check.Equals, "{\"action\":\"abort\"}\n")
 }
+
+func (cs *clientSuite) TestClientChangeActions(c *check.C) {
+	for _, t := range []struct {
+		action string
+		do     func(id string) (*client.Change, error)
+		paused bool
+	}{
+		{"pause", cs.cli.Pause, true},
+		{"resume", cs.cli.Resume, false},
+		{"retry", cs.cli.Retry, false},
+	} {
+		cs.rsp = fmt.Sprintf(`{"type": "sync", "result": {
+  "id":   "uno",
+  "kind": "foo",
+  "summary": "...",
+  "status": "Do",
+  "ready": false,
+  "paused": %v,
+  "spawn-time": "2016-04-21T01:02:03Z"
+}}`, t.paused)
+
+		chg, err := t.do("uno")
+		c.Assert(err, check.IsNil)
+		c.Check(cs.req.Method, check.Equals, "POST")
+		c.Check(cs.req.URL.Path, check.Equals, "/v2/changes/uno")
+		c.Check(chg, check.DeepEquals, &client.Change{
+			ID:      "uno",
+			Kind:    "foo",
+			Summary: "...",
+			Status:  "Do",
+			Paused:  t.paused,
+
+			SpawnTime: time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC),
+		})
+
+		body, err := io.ReadAll(cs.req.Body)
+		c.Assert(err, check.IsNil)
+		c.Check(string(body), check.Equals, fmt.Sprintf("{\"action\":%q}\n", t.action))
+	}
+}
@@ -777,6 +777,43 @@ func (client *Client) DebugGet(aspect string, result interface{}, params map[str
 	return err
 }
 
+// DebugBundleMediaType is the media type used to identify debug bundles in
+// the API.
+const DebugBundleMediaType = "application/x.snapd.debug-bundle"
+
+// DebugBundle asks snapd to collect a debug bundle and returns a stream of
+// the compressed tar archive.
+func (client *Client) DebugBundle() (stream io.ReadCloser, err error) {
+	body, err := json.Marshal(debugAction{Action: "bundle"})
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := client.raw(context.Background(), "POST", "/v2/debug", nil, nil, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != 200 {
+		defer rsp.Body.Close()
+
+		var r response
+		dec := json.NewDecoder(rsp.Body)
+		if err := dec.Decode(&r); err == nil {
+			specificErr := r.err(client, rsp.StatusCode)
+			if specificErr != nil {
+				return nil, specificErr
+			}
+		}
+		return nil, fmt.Errorf("unexpected status code: %v", rsp.Status)
+	}
+	contentType := rsp.Header.Get("Content-Type")
+	if contentType != DebugBundleMediaType {
+		rsp.Body.Close()
+		return nil, fmt.Errorf("unexpected debug bundle content type %q", contentType)
+	}
+
+	return rsp.Body, nil
+}
+
 type SystemRecoveryKeysResponse struct {
 	RecoveryKey  string `json:"recovery-key"`
 	ReinstallKey string `json:"reinstall-key,omitempty"`
@@ -663,6 +663,39 @@ func (cs *clientSuite) TestDebugMigrateHome(c *C) {
 	c.Check(string(data), Equals, `{"action":"migrate-home","snaps":["foo","bar"]}`)
 }
 
+func (cs *clientSuite) TestDebugBundle(c *C) {
+	cs.rsp = "bundle-data"
+	cs.header = http.Header{"Content-Type": []string{client.DebugBundleMediaType}}
+
+	r, err := cs.cli.DebugBundle()
+	c.Assert(err, IsNil)
+	defer r.Close()
+	buf, err := io.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Check(string(buf), Equals, "bundle-data")
+
+	c.Check(cs.reqs, HasLen, 1)
+	c.Check(cs.reqs[0].Method, Equals, "POST")
+	c.Check(cs.reqs[0].URL.Path, Equals, "/v2/debug")
+	data, err := io.ReadAll(cs.reqs[0].Body)
+	c.Assert(err, IsNil)
+	c.Check(string(data), Equals, `{"action":"bundle"}`)
+}
+
+func (cs *clientSuite) TestDebugBundleErrors(c *C) {
+	cs.status = 403
+	cs.rsp = `{"type":"error","status-code":403,"result":{"message":"access denied","kind":"login-required"}}`
+	cs.header = http.Header{"Content-Type": []string{"application/json"}}
+	_, err := cs.cli.DebugBundle()
+	c.Check(err, ErrorMatches, "access denied")
+
+	cs.status = 200
+	cs.rsp = "not-a-bundle"
+	cs.header = http.Header{"Content-Type": []string{"text/plain"}}
+	_, err = cs.cli.DebugBundle()
+	c.Check(err, ErrorMatches, `unexpected debug bundle content type "text/plain"`)
+}
+
 type integrationSuite struct{}
 
 var _ = Suite(&integrationSuite{})
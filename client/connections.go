@@ -21,6 +21,7 @@ package client
 
 import (
 	"net/url"
+	"time"
 )
 
 // Connection describes a connection between a plug and a slot.
@@ -36,6 +37,9 @@ type Connection struct {
 	SlotAttrs map[string]interface{} `json:"slot-attrs,omitempty"`
 	// PlugAttrs is the list of attributes of the plug side of the connection.
 	PlugAttrs map[string]interface{} `json:"plug-attrs,omitempty"`
+	// Expires is the time at which a time-limited connection is
+	// automatically disconnected.
+	Expires time.Time `json:"expires,omitzero"`
 }
 
 // Connections contains information about connections, as well as related plugs
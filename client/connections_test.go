@@ -21,6 +21,7 @@ package client_test
 
 import (
 	"net/url"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -42,7 +43,8 @@ func (cs *clientSuite) TestClientConnectionsDefault(c *check.C) {
 					"slot": {"snap": "keyboard-lights", "slot": "capslock-led"},
 					"plug": {"snap": "canonical-pi2", "plug": "pin-13"},
 					"interface": "bool-file",
-					"gadget": true
+					"gadget": true,
+					"expires": "2026-10-17T12:00:00Z"
                                 }
 			],
 			"plugs": [
@@ -79,6 +81,7 @@ func (cs *clientSuite) TestClientConnectionsDefault(c *check.C) {
 				Slot:      client.SlotRef{Snap: "keyboard-lights", Name: "capslock-led"},
 				Interface: "bool-file",
 				Gadget:    true,
+				Expires:   time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
 			},
 		},
 		Plugs: []client.Plug{
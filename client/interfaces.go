@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // Plug represents the potential of a given snap to connect to a slot.
@@ -71,10 +72,11 @@ type Interface struct {
 
 // InterfaceAction represents an action performed on the interface system.
 type InterfaceAction struct {
-	Action string `json:"action"`
-	Forget bool   `json:"forget,omitempty"`
-	Plugs  []Plug `json:"plugs,omitempty"`
-	Slots  []Slot `json:"slots,omitempty"`
+	Action  string    `json:"action"`
+	Forget  bool      `json:"forget,omitempty"`
+	Plugs   []Plug    `json:"plugs,omitempty"`
+	Slots   []Slot    `json:"slots,omitempty"`
+	Expires time.Time `json:"expires,omitzero"`
 }
 
 // InterfaceOptions represents opt-in elements include in responses.
@@ -86,6 +88,13 @@ type InterfaceOptions struct {
 	Connected bool
 }
 
+// ConnectOptions represents extra options for connect op
+type ConnectOptions struct {
+	// Expires is the time at which the connection is automatically
+	// disconnected again. The connection does not expire if it is zero.
+	Expires time.Time
+}
+
 // DisconnectOptions represents extra options for disconnect op
 type DisconnectOptions struct {
 	Forget bool
@@ -130,12 +139,16 @@ func (client *Client) performInterfaceAction(sa *InterfaceAction) (changeID stri
 
 // Connect establishes a connection between a plug and a slot.
 // The plug and the slot must have the same interface.
-func (client *Client) Connect(plugSnapName, plugName, slotSnapName, slotName string) (changeID string, err error) {
-	return client.performInterfaceAction(&InterfaceAction{
+func (client *Client) Connect(plugSnapName, plugName, slotSnapName, slotName string, opts *ConnectOptions) (changeID string, err error) {
+	action := &InterfaceAction{
 		Action: "connect",
 		Plugs:  []Plug{{Snap: plugSnapName, Name: plugName}},
 		Slots:  []Slot{{Snap: slotSnapName, Name: slotName}},
-	})
+	}
+	if opts != nil {
+		action.Expires = opts.Expires
+	}
+	return client.performInterfaceAction(action)
 }
 
 // Disconnect breaks the connection between a plug and a slot.
@@ -21,6 +21,7 @@ package client_test
 
 import (
 	"encoding/json"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -157,7 +158,7 @@ func (cs *clientSuite) TestClientInterfacesMultiple(c *check.C) {
 }
 
 func (cs *clientSuite) TestClientConnectCallsEndpoint(c *check.C) {
-	cs.cli.Connect("producer", "plug", "consumer", "slot")
+	cs.cli.Connect("producer", "plug", "consumer", "slot", nil)
 	c.Check(cs.req.Method, check.Equals, "POST")
 	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces")
 }
@@ -170,7 +171,7 @@ func (cs *clientSuite) TestClientConnect(c *check.C) {
 		"result": { },
                 "change": "foo"
 	}`
-	id, err := cs.cli.Connect("producer", "plug", "consumer", "slot")
+	id, err := cs.cli.Connect("producer", "plug", "consumer", "slot", nil)
 	c.Assert(err, check.IsNil)
 	c.Check(id, check.Equals, "foo")
 	var body map[string]interface{}
@@ -194,6 +195,40 @@ func (cs *clientSuite) TestClientConnect(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientConnectExpires(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"result": { },
+		"change": "foo"
+	}`
+	expires := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	id, err := cs.cli.Connect("producer", "plug", "consumer", "slot", &client.ConnectOptions{Expires: expires})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "foo")
+	var body map[string]interface{}
+	decoder := json.NewDecoder(cs.req.Body)
+	err = decoder.Decode(&body)
+	c.Check(err, check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"action": "connect",
+		"plugs": []interface{}{
+			map[string]interface{}{
+				"snap": "producer",
+				"plug": "plug",
+			},
+		},
+		"slots": []interface{}{
+			map[string]interface{}{
+				"snap": "consumer",
+				"slot": "slot",
+			},
+		},
+		"expires": "2026-10-17T12:00:00Z",
+	})
+}
+
 func (cs *clientSuite) TestClientDisconnectCallsEndpoint(c *check.C) {
 	cs.cli.Disconnect("producer", "plug", "consumer", "slot", nil)
 	c.Check(cs.req.Method, check.Equals, "POST")
@@ -0,0 +1,139 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Manifest describes the snaps installed on a system and how they are set
+// up. Anything a manifest does not mention is left alone when it is applied.
+type Manifest struct {
+	Snaps          []*ManifestSnap          `json:"snaps,omitempty" yaml:"snaps,omitempty"`
+	Connections    []*ManifestConnection    `json:"connections,omitempty" yaml:"connections,omitempty"`
+	Quotas         []*ManifestQuota         `json:"quotas,omitempty" yaml:"quotas,omitempty"`
+	ValidationSets []*ManifestValidationSet `json:"validation-sets,omitempty" yaml:"validation-sets,omitempty"`
+}
+
+// ManifestSnap describes an installed snap.
+type ManifestSnap struct {
+	Name     string `json:"name" yaml:"name"`
+	Channel  string `json:"channel,omitempty" yaml:"channel,omitempty"`
+	Revision string `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Cohort   string `json:"cohort,omitempty" yaml:"cohort,omitempty"`
+	Classic  bool   `json:"classic,omitempty" yaml:"classic,omitempty"`
+	// Hold is either "forever" or the time, in RFC3339 format, until which
+	// refreshes of the snap are held.
+	Hold   string                 `json:"hold,omitempty" yaml:"hold,omitempty"`
+	Config map[string]interface{} `json:"config,omitempty" yaml:"config,omitempty"`
+	// Aliases maps the manual aliases of the snap to their app.
+	Aliases map[string]string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
+// ManifestConnection describes an interface connection made manually or, if
+// Disconnected is set, an automatic one that was disconnected.
+type ManifestConnection struct {
+	Plug         string `json:"plug" yaml:"plug"`
+	Slot         string `json:"slot" yaml:"slot"`
+	Disconnected bool   `json:"disconnected,omitempty" yaml:"disconnected,omitempty"`
+}
+
+// ManifestQuota describes a quota group.
+type ManifestQuota struct {
+	Name     string                 `json:"name" yaml:"name"`
+	Parent   string                 `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Snaps    []string               `json:"snaps,omitempty" yaml:"snaps,omitempty"`
+	Services []string               `json:"services,omitempty" yaml:"services,omitempty"`
+	Limits   map[string]interface{} `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// ManifestValidationSet describes a tracked validation set.
+type ManifestValidationSet struct {
+	AccountID string `json:"account-id" yaml:"account-id"`
+	Name      string `json:"name" yaml:"name"`
+	// Mode is either "monitor" or "enforce".
+	Mode     string `json:"mode" yaml:"mode"`
+	Sequence int    `json:"sequence,omitempty" yaml:"sequence,omitempty"`
+}
+
+// ManifestPlan describes what applying a manifest does to the system.
+type ManifestPlan struct {
+	Actions []string `json:"actions,omitempty"`
+	// Skipped are what cannot be done, along with why.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Manifest returns the manifest of the snaps installed on the system.
+func (client *Client) Manifest() (*Manifest, error) {
+	var m Manifest
+	if _, err := client.doSync("GET", "/v2/manifest", nil, nil, nil, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ApplyManifestOptions holds options for applying a manifest.
+type ApplyManifestOptions struct {
+	// DryRun is set to only get the plan, without changing anything.
+	DryRun bool
+}
+
+// ApplyManifest makes the system match the given manifest. It returns what
+// is planned for that and, unless doing a dry run, the ID of the change
+// doing it.
+func (client *Client) ApplyManifest(m *Manifest, opts *ApplyManifestOptions) (changeID string, plan *ManifestPlan, err error) {
+	if opts == nil {
+		opts = &ApplyManifestOptions{}
+	}
+	data := struct {
+		Action   string    `json:"action"`
+		Manifest *Manifest `json:"manifest"`
+		DryRun   bool      `json:"dry-run,omitempty"`
+	}{
+		Action:   "apply",
+		Manifest: m,
+		DryRun:   opts.DryRun,
+	}
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&data); err != nil {
+		return "", nil, err
+	}
+
+	plan = &ManifestPlan{}
+	if opts.DryRun {
+		if _, err := client.doSync("POST", "/v2/manifest", nil, nil, &body, plan); err != nil {
+			return "", nil, err
+		}
+		return "", plan, nil
+	}
+
+	result, changeID, err := client.doAsyncFull("POST", "/v2/manifest", nil, nil, &body, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(result) > 0 {
+		if err := json.Unmarshal(result, plan); err != nil {
+			return "", nil, fmt.Errorf("cannot unmarshal: %v", err)
+		}
+	}
+	return changeID, plan, nil
+}
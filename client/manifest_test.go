@@ -0,0 +1,102 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestManifest(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {
+			"snaps": [{"name": "foo", "channel": "latest/stable", "revision": "7", "config": {"bar": 1}}],
+			"connections": [{"plug": "foo:home", "slot": "core:home", "disconnected": true}],
+			"validation-sets": [{"account-id": "acc", "name": "set", "mode": "enforce", "sequence": 3}]
+		}
+	}`
+
+	m, err := cs.cli.Manifest()
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/manifest")
+	c.Check(m, check.DeepEquals, &client.Manifest{
+		Snaps: []*client.ManifestSnap{{
+			Name:     "foo",
+			Channel:  "latest/stable",
+			Revision: "7",
+			Config:   map[string]interface{}{"bar": json.Number("1")},
+		}},
+		Connections: []*client.ManifestConnection{{Plug: "foo:home", Slot: "core:home", Disconnected: true}},
+		ValidationSets: []*client.ManifestValidationSet{{
+			AccountID: "acc",
+			Name:      "set",
+			Mode:      "enforce",
+			Sequence:  3,
+		}},
+	})
+}
+
+func (cs *clientSuite) TestApplyManifest(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"type": "async",
+		"status-code": 202,
+		"change": "42",
+		"result": {"actions": ["Install \"foo\""], "skipped": ["Connect foo:home to core:home: snap \"foo\" is not installed yet"]}
+	}`
+
+	m := &client.Manifest{Snaps: []*client.ManifestSnap{{Name: "foo"}}}
+	chgID, plan, err := cs.cli.ApplyManifest(m, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "42")
+	c.Check(plan, check.DeepEquals, &client.ManifestPlan{
+		Actions: []string{`Install "foo"`},
+		Skipped: []string{`Connect foo:home to core:home: snap "foo" is not installed yet`},
+	})
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/manifest")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(string(body), check.Equals, `{"action":"apply","manifest":{"snaps":[{"name":"foo"}]}}`+"\n")
+}
+
+func (cs *clientSuite) TestApplyManifestDryRun(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"actions": ["Install \"foo\""]}
+	}`
+
+	m := &client.Manifest{Snaps: []*client.ManifestSnap{{Name: "foo"}}}
+	chgID, plan, err := cs.cli.ApplyManifest(m, &client.ApplyManifestOptions{DryRun: true})
+	c.Assert(err, check.IsNil)
+	c.Check(chgID, check.Equals, "")
+	c.Check(plan, check.DeepEquals, &client.ManifestPlan{Actions: []string{`Install "foo"`}})
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(string(body), check.Equals, `{"action":"apply","manifest":{"snaps":[{"name":"foo"}]},"dry-run":true}`+"\n")
+}
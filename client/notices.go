@@ -15,8 +15,16 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 type NotifyOptions struct {
@@ -61,4 +69,211 @@ type NoticeType string
 const (
 	// SnapRunInhibitNotice is recorded when "snap run" is inhibited due refresh.
 	SnapRunInhibitNotice NoticeType = "snap-run-inhibit"
+
+	// SnapLifecycleNotice is recorded when a snap is installed, refreshed,
+	// reverted or removed.
+	SnapLifecycleNotice NoticeType = "snap-lifecycle"
+
+	// InterfaceConnectionNotice is recorded for both snaps of an interface
+	// connection when it is made or removed.
+	InterfaceConnectionNotice NoticeType = "interface-connection"
+
+	// SnapConfigNotice is recorded when the configuration of a snap changes.
+	SnapConfigNotice NoticeType = "snap-config"
 )
+
+// Notice is an aggregated notice: the occurrences of notices of a given
+// type and key.
+type Notice struct {
+	ID            string            `json:"id"`
+	UserID        *uint32           `json:"user-id"`
+	Type          NoticeType        `json:"type"`
+	Key           string            `json:"key"`
+	FirstOccurred time.Time         `json:"first-occurred"`
+	LastOccurred  time.Time         `json:"last-occurred"`
+	LastRepeated  time.Time         `json:"last-repeated"`
+	Occurrences   int               `json:"occurrences"`
+	LastData      map[string]string `json:"last-data,omitempty"`
+	RepeatAfter   time.Duration     `json:"-"`
+	ExpireAfter   time.Duration     `json:"-"`
+}
+
+func (n *Notice) UnmarshalJSON(data []byte) error {
+	type plainNotice Notice
+	var jn struct {
+		*plainNotice
+		RepeatAfter string `json:"repeat-after"`
+		ExpireAfter string `json:"expire-after"`
+	}
+	jn.plainNotice = (*plainNotice)(n)
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return err
+	}
+	var err error
+	if jn.RepeatAfter != "" {
+		if n.RepeatAfter, err = time.ParseDuration(jn.RepeatAfter); err != nil {
+			return fmt.Errorf("invalid repeat-after duration: %v", err)
+		}
+	}
+	if jn.ExpireAfter != "" {
+		if n.ExpireAfter, err = time.ParseDuration(jn.ExpireAfter); err != nil {
+			return fmt.Errorf("invalid expire-after duration: %v", err)
+		}
+	}
+	return nil
+}
+
+// NoticesOptions hold the criteria the notices returned by Notices and
+// WatchNotices must meet.
+type NoticesOptions struct {
+	// Types, if not empty, includes only notices of one of these types.
+	Types []NoticeType
+	// Keys, if not empty, includes only notices with one of these keys.
+	Keys []string
+	// After, if set, includes only notices last repeated after this time.
+	After time.Time
+	// AllUsers includes the notices of all users, rather than only those
+	// of the current user and public notices. Only admins can use it.
+	AllUsers bool
+}
+
+func (opts *NoticesOptions) query() url.Values {
+	query := url.Values{}
+	if opts == nil {
+		return query
+	}
+	if len(opts.Types) > 0 {
+		types := make([]string, len(opts.Types))
+		for i, t := range opts.Types {
+			types[i] = string(t)
+		}
+		query.Set("types", strings.Join(types, ","))
+	}
+	if len(opts.Keys) > 0 {
+		query.Set("keys", strings.Join(opts.Keys, ","))
+	}
+	if !opts.After.IsZero() {
+		query.Set("after", opts.After.Format(time.RFC3339Nano))
+	}
+	if opts.AllUsers {
+		query.Set("users", "all")
+	}
+	return query
+}
+
+// Notices returns the notices that meet the given criteria, ordered by
+// the time they were last repeated.
+func (client *Client) Notices(opts *NoticesOptions) ([]*Notice, error) {
+	var notices []*Notice
+	if _, err := client.doSync("GET", "/v2/notices", opts.query(), nil, nil, &notices); err != nil {
+		return nil, err
+	}
+	return notices, nil
+}
+
+// watchNoticesRetryDelay is how long WatchNotices waits between attempts
+// to connect again to snapd when it cannot be reached.
+var watchNoticesRetryDelay = 3 * time.Second
+
+// WatchNotices streams the notices that meet the given criteria as they
+// occur, starting with those that already did, until the context is done.
+//
+// If the connection to snapd is lost, for instance while snapd restarts,
+// the stream is resumed after the last notice received. The returned
+// channel is closed when the context is done or snapd refuses to resume
+// the stream.
+func (client *Client) WatchNotices(ctx context.Context, opts *NoticesOptions) (<-chan Notice, error) {
+	query := opts.query()
+	query.Set("follow", "true")
+
+	rsp, err := client.followNotices(ctx, query, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Notice)
+	go func() {
+		defer close(ch)
+		lastEventID := ""
+		for {
+			lastEventID = readNoticeEvents(ctx, rsp.Body, lastEventID, ch)
+			rsp.Body.Close()
+			for {
+				if ctx.Err() != nil {
+					return
+				}
+				rsp, err = client.followNotices(ctx, query, lastEventID)
+				if _, ok := err.(ConnectionError); ok {
+					// snapd is not back yet
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(watchNoticesRetryDelay):
+					}
+					continue
+				}
+				if err != nil {
+					return
+				}
+				break
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (client *Client) followNotices(ctx context.Context, query url.Values, lastEventID string) (*http.Response, error) {
+	headers := map[string]string{"Accept": "text/event-stream"}
+	if lastEventID != "" {
+		headers["Last-Event-ID"] = lastEventID
+	}
+	rsp, err := client.raw(ctx, "GET", "/v2/notices", query, headers, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode != 200 {
+		defer rsp.Body.Close()
+		var r response
+		if err := decodeInto(rsp.Body, &r); err != nil {
+			return nil, err
+		}
+		return nil, r.err(client, rsp.StatusCode)
+	}
+	return rsp, nil
+}
+
+// readNoticeEvents sends the notices in the given stream of Server-Sent
+// Events to ch, returning the ID of the last event read.
+func readNoticeEvents(ctx context.Context, r io.Reader, lastEventID string, ch chan<- Notice) string {
+	var id, data string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// the end of an event
+			if data == "" {
+				continue
+			}
+			var notice Notice
+			if err := json.Unmarshal([]byte(data), &notice); err == nil {
+				select {
+				case ch <- notice:
+				case <-ctx.Done():
+					return lastEventID
+				}
+			}
+			if id != "" {
+				lastEventID = id
+			}
+			id, data = "", ""
+		case strings.HasPrefix(line, ":"):
+			// a comment, to keep the connection alive
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return lastEventID
+}
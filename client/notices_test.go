@@ -20,8 +20,11 @@
 package client_test
 
 import (
+	"context"
 	"encoding/json"
 	"io"
+	"net/url"
+	"time"
 
 	"github.com/snapcore/snapd/client"
 	. "gopkg.in/check.v1"
@@ -48,3 +51,99 @@ func (cs *clientSuite) TestNotify(c *C) {
 		"key":    "snap-name",
 	})
 }
+
+func (cs *clientSuite) TestNotices(c *C) {
+	cs.rsp = `{"type": "sync", "result": [{
+		"id": "1",
+		"user-id": 1000,
+		"type": "change-update",
+		"key": "42",
+		"first-occurred": "2026-01-02T03:04:05Z",
+		"last-occurred": "2026-01-02T03:04:06Z",
+		"last-repeated": "2026-01-02T03:04:07Z",
+		"occurrences": 2,
+		"last-data": {"kind": "install-snap"},
+		"expire-after": "168h0m0s"
+	}]}`
+	after := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	notices, err := cs.cli.Notices(&client.NoticesOptions{
+		Types:    []client.NoticeType{"change-update", "warning"},
+		Keys:     []string{"42"},
+		After:    after,
+		AllUsers: true,
+	})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/notices")
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{
+		"types": {"change-update,warning"},
+		"keys":  {"42"},
+		"after": {"2026-01-01T00:00:00Z"},
+		"users": {"all"},
+	})
+	uid := uint32(1000)
+	c.Check(notices, DeepEquals, []*client.Notice{{
+		ID:            "1",
+		UserID:        &uid,
+		Type:          "change-update",
+		Key:           "42",
+		FirstOccurred: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		LastOccurred:  time.Date(2026, 1, 2, 3, 4, 6, 0, time.UTC),
+		LastRepeated:  time.Date(2026, 1, 2, 3, 4, 7, 0, time.UTC),
+		Occurrences:   2,
+		LastData:      map[string]string{"kind": "install-snap"},
+		ExpireAfter:   168 * time.Hour,
+	}})
+}
+
+func (cs *clientSuite) TestWatchNotices(c *C) {
+	cs.rsps = []string{
+		`: keep-alive
+
+id: 2026-01-02T03:04:05Z
+event: notice
+data: {"id": "1", "type": "warning", "key": "foo"}
+
+id: 2026-01-02T03:04:06Z
+event: notice
+data: {"id": "2", "type": "warning", "key": "bar"}
+
+`,
+		// after reconnecting
+		`id: 2026-01-02T03:04:07Z
+event: notice
+data: {"id": "3", "type": "warning", "key": "baz"}
+
+`,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := cs.cli.WatchNotices(ctx, &client.NoticesOptions{Types: []client.NoticeType{"warning"}})
+	c.Assert(err, IsNil)
+
+	var keys []string
+	for notice := range ch {
+		keys = append(keys, notice.Key)
+		if len(keys) == 3 {
+			cancel()
+		}
+	}
+	c.Check(keys, DeepEquals, []string{"foo", "bar", "baz"})
+
+	c.Assert(len(cs.reqs) >= 2, Equals, true)
+	c.Check(cs.reqs[0].URL.Query(), DeepEquals, url.Values{
+		"types":  {"warning"},
+		"follow": {"true"},
+	})
+	c.Check(cs.reqs[0].Header.Get("Last-Event-ID"), Equals, "")
+	c.Check(cs.reqs[1].URL.Query(), DeepEquals, cs.reqs[0].URL.Query())
+	c.Check(cs.reqs[1].Header.Get("Last-Event-ID"), Equals, "2026-01-02T03:04:06Z")
+}
+
+func (cs *clientSuite) TestWatchNoticesError(c *C) {
+	cs.status = 403
+	cs.rsp = `{"type": "error", "status-code": 403, "result": {"message": "snap cannot access specified notice types"}}`
+	_, err := cs.cli.WatchNotices(context.Background(), nil)
+	c.Check(err, ErrorMatches, "snap cannot access specified notice types")
+}
@@ -0,0 +1,212 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// PromptingRule is a rule which determines how requests made by a snap
+// through an interface are handled without prompting the user.
+type PromptingRule struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	User      uint32    `json:"user"`
+	Snap      string    `json:"snap"`
+	Interface string    `json:"interface"`
+	// Origin is "admin" for rules which apply to all users, and "user"
+	// otherwise.
+	Origin      string                    `json:"origin"`
+	Constraints *PromptingRuleConstraints `json:"constraints"`
+	// HitCount is the number of requests the rule has matched, and
+	// LastMatched the time at which it last matched one.
+	HitCount    uint64    `json:"hit-count"`
+	LastMatched time.Time `json:"last-matched,omitzero"`
+}
+
+// PromptingRuleConstraints holds the path pattern of a rule and the outcome
+// of each permission it covers.
+type PromptingRuleConstraints struct {
+	PathPattern string                                   `json:"path-pattern"`
+	Permissions map[string]*PromptingRulePermissionEntry `json:"permissions"`
+}
+
+// PromptingRulePermissionEntry holds the outcome of a permission of a rule,
+// and for how long it applies.
+type PromptingRulePermissionEntry struct {
+	Outcome    string    `json:"outcome"`
+	Lifespan   string    `json:"lifespan"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// PromptingConstraints holds the path pattern and permissions of a new rule.
+type PromptingConstraints struct {
+	PathPattern string                               `json:"path-pattern"`
+	Permissions map[string]*PromptingPermissionEntry `json:"permissions"`
+}
+
+// PromptingPermissionEntry holds the outcome of a permission of a new rule,
+// and for how long it applies. Duration must be set if, and only if, the
+// lifespan is "timespan".
+type PromptingPermissionEntry struct {
+	Outcome  string `json:"outcome"`
+	Lifespan string `json:"lifespan"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// PromptingExportedRule is a prompting rule as exported from, and imported
+// into, the rules of a user.
+type PromptingExportedRule struct {
+	Snap        string                `json:"snap"`
+	Interface   string                `json:"interface"`
+	Constraints *PromptingConstraints `json:"constraints"`
+}
+
+// PromptingRulesOptions holds options for listing and modifying prompting
+// rules.
+type PromptingRulesOptions struct {
+	// Snap and Interface restrict the listed rules to the given snap and
+	// interface.
+	Snap      string
+	Interface string
+	// AllUsers is set to act on the rules defined by an administrator
+	// for all users, rather than those of the calling user.
+	AllUsers bool
+}
+
+func (opts *PromptingRulesOptions) query() url.Values {
+	q := url.Values{}
+	if opts == nil {
+		return q
+	}
+	if opts.Snap != "" {
+		q.Set("snap", opts.Snap)
+	}
+	if opts.Interface != "" {
+		q.Set("interface", opts.Interface)
+	}
+	if opts.AllUsers {
+		q.Set("all-users", "true")
+	}
+	return q
+}
+
+// PromptingRules returns the prompting rules which apply to the calling
+// user, including those defined for all users.
+func (client *Client) PromptingRules(opts *PromptingRulesOptions) ([]*PromptingRule, error) {
+	var rules []*PromptingRule
+	if _, err := client.doSync("GET", "/v2/interfaces/requests/rules", opts.query(), nil, nil, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// AddPromptingRule adds a prompting rule for the given snap and interface.
+// Only the AllUsers field of the options is used.
+func (client *Client) AddPromptingRule(snap, iface string, constraints *PromptingConstraints, opts *PromptingRulesOptions) (*PromptingRule, error) {
+	data := struct {
+		Action string `json:"action"`
+		Rule   struct {
+			Snap        string                `json:"snap"`
+			Interface   string                `json:"interface"`
+			Constraints *PromptingConstraints `json:"constraints"`
+		} `json:"rule"`
+	}{Action: "add"}
+	data.Rule.Snap = snap
+	data.Rule.Interface = iface
+	data.Rule.Constraints = constraints
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&data); err != nil {
+		return nil, err
+	}
+
+	var rule PromptingRule
+	if _, err := client.doSync("POST", "/v2/interfaces/requests/rules", allUsersQuery(opts), nil, &body, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ExportPromptingRules returns the prompting rules of the calling user, or
+// those defined for all users, in a form which can be imported again with
+// ImportPromptingRules.
+func (client *Client) ExportPromptingRules(opts *PromptingRulesOptions) ([]*PromptingExportedRule, error) {
+	q := opts.query()
+	q.Set("export", "true")
+	var rules []*PromptingExportedRule
+	if _, err := client.doSync("GET", "/v2/interfaces/requests/rules", q, nil, nil, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ImportPromptingRules imports the given prompting rules, as previously
+// exported with ExportPromptingRules, and returns the resulting rules. No
+// rule is imported if any of them is invalid or conflicts with an existing
+// rule. Only the AllUsers field of the options is used.
+func (client *Client) ImportPromptingRules(rules []*PromptingExportedRule, opts *PromptingRulesOptions) ([]*PromptingRule, error) {
+	if rules == nil {
+		rules = []*PromptingExportedRule{}
+	}
+	data := struct {
+		Action string                   `json:"action"`
+		Rules  []*PromptingExportedRule `json:"rules"`
+	}{
+		Action: "import",
+		Rules:  rules,
+	}
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&data); err != nil {
+		return nil, err
+	}
+
+	var imported []*PromptingRule
+	if _, err := client.doSync("POST", "/v2/interfaces/requests/rules", allUsersQuery(opts), nil, &body, &imported); err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
+// RemovePromptingRule removes the prompting rule with the given ID and
+// returns it. Only the AllUsers field of the options is used.
+func (client *Client) RemovePromptingRule(id string, opts *PromptingRulesOptions) (*PromptingRule, error) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(map[string]string{"action": "remove"}); err != nil {
+		return nil, err
+	}
+
+	var rule PromptingRule
+	path := fmt.Sprintf("/v2/interfaces/requests/rules/%s", url.PathEscape(id))
+	if _, err := client.doSync("POST", path, allUsersQuery(opts), nil, &body, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func allUsersQuery(opts *PromptingRulesOptions) url.Values {
+	if opts == nil || !opts.AllUsers {
+		return nil
+	}
+	return url.Values{"all-users": []string{"true"}}
+}
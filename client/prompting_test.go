@@ -0,0 +1,240 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestPromptingRules(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": [{
+			"id": "0000000000000002",
+			"timestamp": "2026-10-17T10:00:00Z",
+			"user": 4294967295,
+			"snap": "firefox",
+			"interface": "home",
+			"origin": "admin",
+			"constraints": {
+				"path-pattern": "/home/*/.ssh/**",
+				"permissions": {"read": {"outcome": "deny", "lifespan": "forever"}}
+			},
+			"hit-count": 3,
+			"last-matched": "2026-10-17T11:00:00Z"
+		}]
+	}`
+
+	rules, err := cs.cli.PromptingRules(&client.PromptingRulesOptions{Snap: "firefox", AllUsers: true})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"snap":      []string{"firefox"},
+		"all-users": []string{"true"},
+	})
+	c.Check(rules, check.DeepEquals, []*client.PromptingRule{{
+		ID:        "0000000000000002",
+		Timestamp: time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC),
+		User:      4294967295,
+		Snap:      "firefox",
+		Interface: "home",
+		Origin:    "admin",
+		Constraints: &client.PromptingRuleConstraints{
+			PathPattern: "/home/*/.ssh/**",
+			Permissions: map[string]*client.PromptingRulePermissionEntry{
+				"read": {Outcome: "deny", Lifespan: "forever"},
+			},
+		},
+		HitCount:    3,
+		LastMatched: time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC),
+	}})
+
+	_, err = cs.cli.PromptingRules(nil)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+}
+
+func (cs *clientSuite) TestAddPromptingRule(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"id": "0000000000000003", "user": 4294967295, "snap": "firefox", "interface": "home", "origin": "admin"}
+	}`
+
+	constraints := &client.PromptingConstraints{
+		PathPattern: "/home/*/.ssh/**",
+		Permissions: map[string]*client.PromptingPermissionEntry{
+			"read": {Outcome: "deny", Lifespan: "timespan", Duration: "1h"},
+		},
+	}
+	rule, err := cs.cli.AddPromptingRule("firefox", "home", constraints, &client.PromptingRulesOptions{AllUsers: true})
+	c.Assert(err, check.IsNil)
+	c.Check(rule.ID, check.Equals, "0000000000000003")
+	c.Check(rule.Origin, check.Equals, "admin")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+	c.Check(cs.req.URL.RawQuery, check.Equals, "all-users=true")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var data map[string]interface{}
+	c.Assert(json.Unmarshal(body, &data), check.IsNil)
+	c.Check(data, check.DeepEquals, map[string]interface{}{
+		"action": "add",
+		"rule": map[string]interface{}{
+			"snap":      "firefox",
+			"interface": "home",
+			"constraints": map[string]interface{}{
+				"path-pattern": "/home/*/.ssh/**",
+				"permissions": map[string]interface{}{
+					"read": map[string]interface{}{"outcome": "deny", "lifespan": "timespan", "duration": "1h"},
+				},
+			},
+		},
+	})
+}
+
+func (cs *clientSuite) TestExportPromptingRules(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": [{
+			"snap": "firefox",
+			"interface": "home",
+			"constraints": {
+				"path-pattern": "/home/test/Downloads/**",
+				"permissions": {"write": {"outcome": "allow", "lifespan": "timespan", "duration": "10m0s"}}
+			}
+		}]
+	}`
+
+	rules, err := cs.cli.ExportPromptingRules(&client.PromptingRulesOptions{Interface: "home"})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"interface": []string{"home"},
+		"export":    []string{"true"},
+	})
+	c.Check(rules, check.DeepEquals, []*client.PromptingExportedRule{{
+		Snap:      "firefox",
+		Interface: "home",
+		Constraints: &client.PromptingConstraints{
+			PathPattern: "/home/test/Downloads/**",
+			Permissions: map[string]*client.PromptingPermissionEntry{
+				"write": {Outcome: "allow", Lifespan: "timespan", Duration: "10m0s"},
+			},
+		},
+	}})
+}
+
+func (cs *clientSuite) TestImportPromptingRules(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": [{"id": "0000000000000004", "user": 1000, "snap": "firefox", "interface": "home", "origin": "user"}]
+	}`
+
+	rules := []*client.PromptingExportedRule{{
+		Snap:      "firefox",
+		Interface: "home",
+		Constraints: &client.PromptingConstraints{
+			PathPattern: "/home/test/Downloads/**",
+			Permissions: map[string]*client.PromptingPermissionEntry{
+				"write": {Outcome: "allow", Lifespan: "forever"},
+			},
+		},
+	}}
+	imported, err := cs.cli.ImportPromptingRules(rules, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(imported, check.HasLen, 1)
+	c.Check(imported[0].ID, check.Equals, "0000000000000004")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var data map[string]interface{}
+	c.Assert(json.Unmarshal(body, &data), check.IsNil)
+	c.Check(data, check.DeepEquals, map[string]interface{}{
+		"action": "import",
+		"rules": []interface{}{
+			map[string]interface{}{
+				"snap":      "firefox",
+				"interface": "home",
+				"constraints": map[string]interface{}{
+					"path-pattern": "/home/test/Downloads/**",
+					"permissions": map[string]interface{}{
+						"write": map[string]interface{}{"outcome": "allow", "lifespan": "forever"},
+					},
+				},
+			},
+		},
+	})
+
+	// An empty rule set is still sent as a list
+	_, err = cs.cli.ImportPromptingRules(nil, &client.PromptingRulesOptions{AllUsers: true})
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.RawQuery, check.Equals, "all-users=true")
+	body, err = io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(string(body), check.Equals, "{\"action\":\"import\",\"rules\":[]}\n")
+}
+
+func (cs *clientSuite) TestRemovePromptingRule(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"status-code": 200,
+		"result": {"id": "0000000000000003", "user": 1000, "snap": "firefox", "interface": "home", "origin": "user"}
+	}`
+
+	rule, err := cs.cli.RemovePromptingRule("0000000000000003", nil)
+	c.Assert(err, check.IsNil)
+	c.Check(rule.ID, check.Equals, "0000000000000003")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/interfaces/requests/rules/0000000000000003")
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(string(body), check.Equals, "{\"action\":\"remove\"}\n")
+}
+
+func (cs *clientSuite) TestPromptingRulesError(c *check.C) {
+	cs.status = 403
+	cs.rsp = `{
+		"type": "error",
+		"status-code": 403,
+		"result": {"message": "only admins may use the \"all-users\" parameter"}
+	}`
+
+	_, err := cs.cli.PromptingRules(&client.PromptingRulesOptions{AllUsers: true})
+	c.Check(err, check.ErrorMatches, `only admins may use the "all-users" parameter`)
+}
@@ -0,0 +1,116 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/snapcore/snapd/snap"
+)
+
+// RefreshSimulation is the report of what a refresh would do.
+type RefreshSimulation struct {
+	Snaps     []*SimulatedRefresh `json:"snaps"`
+	Skipped   []SimulatedSkip     `json:"skipped,omitempty"`
+	DiskSpace *SimulatedDiskSpace `json:"disk-space,omitempty"`
+}
+
+// SimulatedRefresh describes the refresh of one snap.
+type SimulatedRefresh struct {
+	Name            string        `json:"name"`
+	CurrentRevision snap.Revision `json:"current-revision"`
+	Revision        snap.Revision `json:"revision"`
+	Version         string        `json:"version,omitempty"`
+	Channel         string        `json:"channel,omitempty"`
+	DownloadSize    int64         `json:"download-size"`
+	DeltaSize       int64         `json:"delta-size,omitempty"`
+	Reboot          bool          `json:"reboot,omitempty"`
+	RunningApps     []string      `json:"running-apps,omitempty"`
+	GatingSnaps     []string      `json:"gating-snaps,omitempty"`
+	Conflict        string        `json:"conflict,omitempty"`
+	AutoConnect     []string      `json:"auto-connect,omitempty"`
+}
+
+// SimulatedSkip describes a snap that would not be refreshed.
+type SimulatedSkip struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// SimulatedDiskSpace is the result of the check for the disk space needed
+// by a refresh.
+type SimulatedDiskSpace struct {
+	Path     string `json:"path"`
+	Required uint64 `json:"required"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SimulateRefresh reports what refreshing the given snaps, or all of them
+// if none is given, would do without doing it. Options other than
+// ignore-running and transaction are only supported when refreshing a
+// single snap.
+func (client *Client) SimulateRefresh(names []string, options *SnapOptions) (*RefreshSimulation, error) {
+	if options == nil {
+		options = &SnapOptions{}
+	}
+
+	var path string
+	var action interface{}
+	if len(names) == 1 {
+		path = fmt.Sprintf("/v2/snaps/%s", names[0])
+		action = &struct {
+			actionData
+			Simulate bool `json:"simulate"`
+		}{
+			actionData: actionData{Action: "refresh", SnapOptions: options},
+			Simulate:   true,
+		}
+	} else {
+		path = "/v2/snaps"
+		action = &struct {
+			multiActionData
+			Simulate bool `json:"simulate"`
+		}{
+			multiActionData: multiActionData{
+				Action:        "refresh",
+				Snaps:         names,
+				Transaction:   options.Transaction,
+				IgnoreRunning: options.IgnoreRunning,
+			},
+			Simulate: true,
+		}
+	}
+
+	data, err := json.Marshal(action)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal snap action: %s", err)
+	}
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	var sim RefreshSimulation
+	if _, err := client.doSync("POST", path, nil, headers, bytes.NewBuffer(data), &sim); err != nil {
+		return nil, err
+	}
+	return &sim, nil
+}
@@ -0,0 +1,91 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/snap"
+)
+
+func (cs *clientSuite) TestSimulateRefreshOne(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"snaps": [{"name": "foo", "current-revision": "1", "revision": "2", "download-size": 1024, "delta-size": 100, "running-apps": ["app"], "auto-connect": ["foo:network core:network"]}],
+			"disk-space": {"path": "/var/lib/snapd", "required": 5243904}
+		}
+	}`
+	sim, err := cs.cli.SimulateRefresh([]string{"foo"}, &client.SnapOptions{Channel: "beta"})
+	c.Assert(err, check.IsNil)
+	c.Check(sim, check.DeepEquals, &client.RefreshSimulation{
+		Snaps: []*client.SimulatedRefresh{{
+			Name:            "foo",
+			CurrentRevision: snap.R(1),
+			Revision:        snap.R(2),
+			DownloadSize:    1024,
+			DeltaSize:       100,
+			RunningApps:     []string{"app"},
+			AutoConnect:     []string{"foo:network core:network"},
+		}},
+		DiskSpace: &client.SimulatedDiskSpace{Path: "/var/lib/snapd", Required: 5243904},
+	})
+
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps/foo")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var jsonBody map[string]interface{}
+	c.Assert(json.Unmarshal(body, &jsonBody), check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":   "refresh",
+		"channel":  "beta",
+		"simulate": true,
+	})
+}
+
+func (cs *clientSuite) TestSimulateRefreshMany(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": {
+			"snaps": [],
+			"skipped": [{"name": "foo", "reason": "no update available"}]
+		}
+	}`
+	sim, err := cs.cli.SimulateRefresh(nil, &client.SnapOptions{IgnoreRunning: true})
+	c.Assert(err, check.IsNil)
+	c.Check(sim.Snaps, check.HasLen, 0)
+	c.Check(sim.Skipped, check.DeepEquals, []client.SimulatedSkip{{Name: "foo", Reason: "no update available"}})
+
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snaps")
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	var jsonBody map[string]interface{}
+	c.Assert(json.Unmarshal(body, &jsonBody), check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action":         "refresh",
+		"ignore-running": true,
+		"simulate":       true,
+	})
+}
@@ -63,7 +63,10 @@ type SnapOptions struct {
 	ValidationSets   []string        `json:"validation-sets,omitempty"`
 	Time             string          `json:"time,omitempty"`
 	HoldLevel        string          `json:"hold-level,omitempty"`
+	At               string          `json:"at,omitempty"`
 	Users            []string        `json:"users,omitempty"`
+	// Secret to encrypt snapshots with, only used by "snapshot"
+	Secret []byte `json:"secret,omitempty"`
 }
 
 func writeFieldBool(mw *multipart.Writer, key string, val bool) error {
@@ -135,7 +138,9 @@ type multiActionData struct {
 	ValidationSets []string            `json:"validation-sets,omitempty"`
 	Time           string              `json:"time,omitempty"`
 	HoldLevel      string              `json:"hold-level,omitempty"`
+	At             string              `json:"at,omitempty"`
 	Components     map[string][]string `json:"components,omitempty"`
+	Secret         []byte              `json:"secret,omitempty"`
 }
 
 // Install adds the snap with the given name from the given channel (or
@@ -206,7 +211,21 @@ func (client *Client) Switch(name string, options *SnapOptions) (changeID string
 
 // SnapshotMany snapshots many snaps (all, if names empty) for many users (all, if users is empty).
 func (client *Client) SnapshotMany(names []string, users []string) (setID uint64, changeID string, err error) {
-	result, changeID, err := client.doMultiSnapActionFull("snapshot", names, nil, &SnapOptions{Users: users})
+	return client.snapshotMany(names, &SnapOptions{Users: users})
+}
+
+// EncryptedSnapshotMany is like SnapshotMany, but the data of the
+// snapshots is encrypted with a key derived from the given secret (a
+// passphrase, or the contents of a key file).
+func (client *Client) EncryptedSnapshotMany(names []string, users []string, secret []byte) (setID uint64, changeID string, err error) {
+	if len(secret) == 0 {
+		return 0, "", fmt.Errorf("cannot encrypt snapshot without a secret")
+	}
+	return client.snapshotMany(names, &SnapOptions{Users: users, Secret: secret})
+}
+
+func (client *Client) snapshotMany(names []string, opts *SnapOptions) (setID uint64, changeID string, err error) {
+	result, changeID, err := client.doMultiSnapActionFull("snapshot", names, nil, opts)
 	if err != nil {
 		return 0, "", err
 	}
@@ -269,6 +288,8 @@ func (client *Client) doMultiSnapActionFull(actionName string, snaps []string, c
 		action.ValidationSets = options.ValidationSets
 		action.Time = options.Time
 		action.HoldLevel = options.HoldLevel
+		action.At = options.At
+		action.Secret = options.Secret
 	}
 
 	data, err := json.Marshal(&action)
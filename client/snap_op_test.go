@@ -283,6 +283,24 @@ func (cs *clientSuite) TestClientMultiOpSnapIgnoreRunning(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientMultiOpSnapAt(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	_, err := cs.cli.RefreshMany([]string{pkgName}, nil, &client.SnapOptions{At: "2026-10-20T02:00:00Z"})
+	c.Assert(err, check.IsNil)
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	jsonBody := make(map[string]interface{})
+	c.Assert(json.Unmarshal(body, &jsonBody), check.IsNil)
+	c.Check(jsonBody["action"], check.Equals, "refresh")
+	c.Check(jsonBody["at"], check.Equals, "2026-10-20T02:00:00Z")
+}
+
 func (cs *clientSuite) TestClientMultiSnapshot(c *check.C) {
 	// Note body is essentially the same as TestClientMultiOpSnap; keep in sync
 	cs.status = 202
@@ -309,6 +327,36 @@ func (cs *clientSuite) TestClientMultiSnapshot(c *check.C) {
 	c.Check(changeID, check.Equals, "d728")
 }
 
+func (cs *clientSuite) TestClientMultiSnapshotEncrypted(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"result": {"set-id": 42},
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	setID, changeID, err := cs.cli.EncryptedSnapshotMany([]string{pkgName}, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+
+	body, err := io.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	jsonBody := make(map[string]interface{})
+	err = json.Unmarshal(body, &jsonBody)
+	c.Assert(err, check.IsNil)
+	c.Check(jsonBody["action"], check.Equals, "snapshot")
+	c.Check(jsonBody["snaps"], check.DeepEquals, []interface{}{pkgName})
+	c.Check(jsonBody["secret"], check.Equals, "czNjcjN0")
+	c.Check(jsonBody, check.HasLen, 3)
+	c.Check(setID, check.Equals, uint64(42))
+	c.Check(changeID, check.Equals, "d728")
+}
+
+func (cs *clientSuite) TestClientMultiSnapshotEncryptedNoSecret(c *check.C) {
+	_, _, err := cs.cli.EncryptedSnapshotMany([]string{pkgName}, nil, nil)
+	c.Assert(err, check.ErrorMatches, "cannot encrypt snapshot without a secret")
+	c.Check(cs.req, check.IsNil)
+}
+
 func (cs *clientSuite) TestClientOpInstallPath(c *check.C) {
 	cs.status = 202
 	cs.rsp = `{
@@ -28,6 +28,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -42,6 +43,7 @@ const SnapshotExportMediaType = "application/x.snapd.snapshot"
 var (
 	ErrSnapshotSetNotFound   = errors.New("no snapshot set with the given ID")
 	ErrSnapshotSnapsNotFound = errors.New("no snapshot for the requested snaps found in the set with the given ID")
+	ErrSnapshotEncrypted     = errors.New("snapshot is encrypted, a passphrase or key file is needed")
 )
 
 // A snapshotAction is used to request an operation on a snapshot.
@@ -50,6 +52,10 @@ type snapshotAction struct {
 	Action string   `json:"action"`
 	Snaps  []string `json:"snaps,omitempty"`
 	Users  []string `json:"users,omitempty"`
+	Paths  []string `json:"paths,omitempty"`
+	Secret []byte   `json:"secret,omitempty"`
+	// OtherSetID is the set to compare with in a diff
+	OtherSetID uint64 `json:"other-set,omitempty"`
 }
 
 // A Snapshot is a collection of archives with a simple metadata json file
@@ -73,7 +79,10 @@ type Snapshot struct {
 
 	// the hash of the archives' data, keyed by archive path
 	// (either 'archive.tgz' for the system archive, or
-	// user/<username>.tgz for each user)
+	// user/<username>.tgz for each user; snapshots stored as
+	// chunks use plain tar archives, 'archive.tar' and
+	// user/<username>.tar; for encrypted snapshots this is the
+	// hash of the encrypted data)
 	SHA3_384 map[string]string `json:"sha3-384"`
 	// the sum of the archive sizes
 	Size int64 `json:"size,omitempty"`
@@ -81,6 +90,9 @@ type Snapshot struct {
 	// dynamic snapshot options
 	Options *snap.SnapshotOptions `json:"options,omitempty"`
 
+	// how the archives were encrypted, if they were
+	Encryption *SnapshotEncryption `json:"encryption,omitempty"`
+
 	// if the snapshot failed to open this will be the reason why
 	Broken string `json:"broken,omitempty"`
 
@@ -91,6 +103,27 @@ type Snapshot struct {
 	Auto bool `json:"auto,omitempty"`
 }
 
+// SnapshotEncryption describes how the archives of an encrypted snapshot
+// were encrypted, and how to get the key to decrypt them from the
+// passphrase or key file the snapshot was saved with.
+type SnapshotEncryption struct {
+	// Cipher is the cipher the archives are encrypted with
+	Cipher string `json:"cipher"`
+	// KDF is the key derivation function used to derive the key
+	// from the secret, followed by its parameters
+	KDF     string `json:"kdf"`
+	Salt    []byte `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"`
+	Threads uint8  `json:"threads"`
+	// KeyCheck is used to tell whether a key is the right one
+	// without having to decrypt any of the data
+	KeyCheck string `json:"key-check"`
+	// Conf is the encrypted configuration of the snap, in place of
+	// the snapshot's Conf
+	Conf []byte `json:"conf,omitempty"`
+}
+
 // IsValid checks whether the snapshot is missing information that
 // should be there for a snapshot that's just been opened.
 func (sh *Snapshot) IsValid() bool {
@@ -113,6 +146,60 @@ func (sh *Snapshot) ContentHash() ([]byte, error) {
 	return h.Sum(nil), nil
 }
 
+// A SnapshotFile is a file or directory in the data of a snap in a
+// snapshot.
+type SnapshotFile struct {
+	// User is the user the data is of, empty for the system data
+	User string `json:"user,omitempty"`
+	// Path is relative to the data directory of the snap, so it's
+	// in "common" or the directory of the snapshot's revision
+	Path    string      `json:"path"`
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mtime"`
+	// Link is the target of symlinks
+	Link string `json:"link,omitempty"`
+	// SHA3_384 is the hash of the contents of regular files, only
+	// set when comparing snapshots
+	SHA3_384 string `json:"sha3-384,omitempty"`
+}
+
+// How something changed between two snapshot sets.
+const (
+	SnapshotAdded    = "added"
+	SnapshotRemoved  = "removed"
+	SnapshotModified = "modified"
+)
+
+// A SnapshotDiff is how the data of a snap changed between two snapshot
+// sets. A snap missing from one of the sets is compared as if it had no
+// configuration nor files there.
+type SnapshotDiff struct {
+	Snap   string                 `json:"snap"`
+	Config []SnapshotConfigChange `json:"config,omitempty"`
+	Files  []SnapshotFileChange   `json:"files,omitempty"`
+}
+
+// A SnapshotConfigChange is a configuration option that was added,
+// removed or modified; nested options are compared one by one, with their
+// dotted keys as in "snap get".
+type SnapshotConfigChange struct {
+	Key    string      `json:"key"`
+	Change string      `json:"change"`
+	Old    interface{} `json:"old,omitempty"`
+	New    interface{} `json:"new,omitempty"`
+}
+
+// A SnapshotFileChange is a file or directory that was added, removed or
+// modified (in its contents, mode or link target).
+type SnapshotFileChange struct {
+	User   string        `json:"user,omitempty"`
+	Path   string        `json:"path"`
+	Change string        `json:"change"`
+	Old    *SnapshotFile `json:"old,omitempty"`
+	New    *SnapshotFile `json:"new,omitempty"`
+}
+
 // A SnapshotSet is a set of snapshots created by a single "snap save".
 type SnapshotSet struct {
 	ID        uint64      `json:"id"`
@@ -182,6 +269,57 @@ func (client *Client) SnapshotSets(setID uint64, snapNames []string) ([]Snapshot
 	return snapshotSets, err
 }
 
+// SnapshotFiles lists the files and directories in the data of the given
+// snap in the snapshot set, limited to the data of the given users (if
+// non-empty) and that of the system.
+func (client *Client) SnapshotFiles(setID uint64, snapName string, users []string) ([]SnapshotFile, error) {
+	q := make(url.Values)
+	q.Add("snap", snapName)
+	if len(users) > 0 {
+		q.Add("users", strings.Join(users, ","))
+	}
+
+	var files []SnapshotFile
+	_, err := client.doSync("GET", fmt.Sprintf("/v2/snapshots/%d/files", setID), q, nil, nil, &files)
+	return files, err
+}
+
+// EncryptedSnapshotFiles is like SnapshotFiles, for snapshots that were
+// saved encrypted with the given secret (the passphrase, or the contents
+// of the key file).
+func (client *Client) EncryptedSnapshotFiles(setID uint64, snapName string, users []string, secret []byte) ([]SnapshotFile, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"snap":   snapName,
+		"users":  users,
+		"secret": secret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal snapshot files request: %v", err)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	var files []SnapshotFile
+	_, err = client.doSync("POST", fmt.Sprintf("/v2/snapshots/%d/files", setID), nil, headers, bytes.NewBuffer(data), &files)
+	return files, err
+}
+
+// SnapshotDiff compares the data of the given snaps (or of all of them) in
+// the snapshot set with that in the other one. The secret is needed if any
+// of the snapshots are encrypted. Once the change is done, the differences
+// are found in its "diffs" data.
+func (client *Client) SnapshotDiff(setID, otherSetID uint64, snaps []string, secret []byte) (changeID string, err error) {
+	return client.snapshotAction(&snapshotAction{
+		SetID:      setID,
+		Action:     "diff",
+		Snaps:      snaps,
+		OtherSetID: otherSetID,
+		Secret:     secret,
+	})
+}
+
 // ForgetSnapshots permanently removes the snapshot set, limited to the
 // given snaps (if non-empty).
 func (client *Client) ForgetSnapshots(setID uint64, snaps []string) (changeID string, err error) {
@@ -218,6 +356,42 @@ func (client *Client) RestoreSnapshots(setID uint64, snaps []string, users []str
 	})
 }
 
+// RestoreEncryptedSnapshots is like RestoreSnapshots, for snapshot sets
+// that were saved encrypted with the given secret (the passphrase, or
+// the contents of the key file).
+func (client *Client) RestoreEncryptedSnapshots(setID uint64, snaps []string, users []string, secret []byte) (changeID string, err error) {
+	return client.RestoreSnapshotsWithOptions(setID, snaps, users, &RestoreSnapshotOptions{Secret: secret})
+}
+
+// RestoreSnapshotOptions holds the options for RestoreSnapshotsWithOptions.
+type RestoreSnapshotOptions struct {
+	// Paths limits the restore to those files or directories, as
+	// listed by SnapshotFiles; only one snap can be restored then
+	Paths []string
+	// Secret is the passphrase or the contents of the key file
+	// encrypted snapshots were saved with
+	Secret []byte
+}
+
+// RestoreSnapshotsWithOptions is like RestoreSnapshots, with the given
+// options.
+func (client *Client) RestoreSnapshotsWithOptions(setID uint64, snaps []string, users []string, opts *RestoreSnapshotOptions) (changeID string, err error) {
+	if opts == nil {
+		opts = &RestoreSnapshotOptions{}
+	}
+	if len(opts.Paths) > 0 && len(snaps) != 1 {
+		return "", fmt.Errorf("cannot restore specific paths of other than exactly one snap")
+	}
+	return client.snapshotAction(&snapshotAction{
+		SetID:  setID,
+		Action: "restore",
+		Snaps:  snaps,
+		Users:  users,
+		Paths:  opts.Paths,
+		Secret: opts.Secret,
+	})
+}
+
 func (client *Client) snapshotAction(action *snapshotAction) (changeID string, err error) {
 	data, err := json.Marshal(action)
 	if err != nil {
@@ -21,9 +21,11 @@ package client_test
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -98,6 +100,71 @@ func (cs *clientSuite) TestClientSnapshotSets(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientEncryptedSnapshotFiles(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [{"path": "common", "size": 0, "mode": 2147484141, "mtime": "2026-03-02T10:00:00Z"}]
+}`
+	files, err := cs.cli.EncryptedSnapshotFiles(42, "foo", []string{"bob"}, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	c.Check(files, check.HasLen, 1)
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snapshots/42/files")
+	c.Check(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), check.IsNil)
+	c.Check(body, check.DeepEquals, map[string]interface{}{
+		"snap":   "foo",
+		"users":  []interface{}{"bob"},
+		"secret": "czNjcjN0",
+	})
+}
+
+func (cs *clientSuite) TestClientSnapshotFiles(c *check.C) {
+	cs.rsp = `{
+		"type": "sync",
+		"result": [
+			{"path": "common", "size": 0, "mode": 2147484141, "mtime": "2026-03-02T10:00:00Z"},
+			{"user": "bob", "path": "x1/config.toml", "size": 42, "mode": 420, "mtime": "2026-03-02T10:00:00Z"}
+		]
+}`
+	files, err := cs.cli.SnapshotFiles(42, "foo", []string{"bob", "alice"})
+	c.Assert(err, check.IsNil)
+	mtime := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	c.Check(files, check.DeepEquals, []client.SnapshotFile{
+		{Path: "common", Mode: os.ModeDir | 0755, ModTime: mtime},
+		{User: "bob", Path: "x1/config.toml", Size: 42, Mode: 0644, ModTime: mtime},
+	})
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snapshots/42/files")
+	c.Check(cs.req.URL.Query(), check.DeepEquals, url.Values{
+		"snap":  []string{"foo"},
+		"users": []string{"bob,alice"},
+	})
+}
+
+func (cs *clientSuite) TestClientSnapshotDiff(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"status-code": 202,
+		"type": "async",
+		"change": "1too3"
+	}`
+	id, err := cs.cli.SnapshotDiff(42, 43, []string{"foo"}, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "1too3")
+	c.Check(cs.req.Method, check.Equals, "POST")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/snapshots")
+	c.Assert(cs.req.Header.Get("Content-Type"), check.Equals, "application/json")
+	act, err := client.UnmarshalSnapshotAction(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(act.SetID, check.Equals, uint64(42))
+	c.Check(act.Action, check.Equals, "diff")
+	c.Check(act.Snaps, check.DeepEquals, []string{"foo"})
+	c.Check(act.OtherSetID, check.Equals, uint64(43))
+	c.Check(act.Secret, check.DeepEquals, []byte("s3cr3t"))
+}
+
 func (cs *clientSuite) testClientSnapshotActionFull(c *check.C, action string, users []string, f func() (string, error)) {
 	cs.status = 202
 	cs.rsp = `{
@@ -143,6 +210,58 @@ func (cs *clientSuite) TestClientRestoreSnapshots(c *check.C) {
 	cs.testClientSnapshotAction(c, "restore", cs.cli.RestoreSnapshots)
 }
 
+func (cs *clientSuite) TestClientRestoreEncryptedSnapshots(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"status-code": 202,
+		"type": "async",
+		"change": "1too3"
+	}`
+	id, err := cs.cli.RestoreEncryptedSnapshots(42, []string{"asnap"}, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "1too3")
+
+	act, err := client.UnmarshalSnapshotAction(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(act.SetID, check.Equals, uint64(42))
+	c.Check(act.Action, check.Equals, "restore")
+	c.Check(act.Snaps, check.DeepEquals, []string{"asnap"})
+	c.Check(act.Secret, check.DeepEquals, []byte("s3cr3t"))
+}
+
+func (cs *clientSuite) TestClientRestoreSnapshotsWithOptions(c *check.C) {
+	cs.status = 202
+	cs.rsp = `{
+		"status-code": 202,
+		"type": "async",
+		"change": "1too3"
+	}`
+	id, err := cs.cli.RestoreSnapshotsWithOptions(42, []string{"asnap"}, []string{"bob"}, &client.RestoreSnapshotOptions{
+		Paths:  []string{"common/db"},
+		Secret: []byte("s3cr3t"),
+	})
+	c.Assert(err, check.IsNil)
+	c.Check(id, check.Equals, "1too3")
+
+	act, err := client.UnmarshalSnapshotAction(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	c.Check(act.SetID, check.Equals, uint64(42))
+	c.Check(act.Action, check.Equals, "restore")
+	c.Check(act.Snaps, check.DeepEquals, []string{"asnap"})
+	c.Check(act.Users, check.DeepEquals, []string{"bob"})
+	c.Check(act.Paths, check.DeepEquals, []string{"common/db"})
+	c.Check(act.Secret, check.DeepEquals, []byte("s3cr3t"))
+}
+
+func (cs *clientSuite) TestClientRestoreSnapshotsPathsNeedOneSnap(c *check.C) {
+	opts := &client.RestoreSnapshotOptions{Paths: []string{"common/db"}}
+	for _, snaps := range [][]string{nil, {"asnap", "bsnap"}} {
+		_, err := cs.cli.RestoreSnapshotsWithOptions(42, snaps, nil, opts)
+		c.Check(err, check.ErrorMatches, "cannot restore specific paths of other than exactly one snap")
+	}
+	c.Check(cs.req, check.IsNil)
+}
+
 func (cs *clientSuite) TestClientExportSnapshotSpecificErr(c *check.C) {
 	content := `{"type":"error","status-code":400,"result":{"message":"boom","kind":"err-kind","value":"err-value"}}`
 	cs.contentLength = int64(len(content))
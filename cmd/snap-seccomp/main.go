@@ -1048,6 +1048,24 @@ func showSeccompLibraryVersion() error {
 	return nil
 }
 
+// syscallNames prints the names of the given syscall numbers of the given
+// dpkg architecture, one per line, or "?" for unknown numbers.
+func syscallNames(dpkgArch string, numbers []string) error {
+	scmpArch := DpkgArchToScmpArch(dpkgArch)
+	for _, number := range numbers {
+		nr, err := strconv.ParseInt(number, 10, 32)
+		if err != nil {
+			return fmt.Errorf("cannot parse syscall number %q: %v", number, err)
+		}
+		name, err := seccomp.ScmpSyscall(nr).GetNameByArch(scmpArch)
+		if err != nil {
+			name = "?"
+		}
+		fmt.Fprintln(os.Stdout, name)
+	}
+	return nil
+}
+
 func dump(what, prefix string) error {
 	f, err := os.Open(what)
 	if err != nil {
@@ -1121,6 +1139,12 @@ func main() {
 		what := os.Args[2]
 		prefix := os.Args[3]
 		err = dump(what, prefix)
+	case "syscall-names":
+		if len(os.Args) < 4 {
+			fmt.Println("syscall-names needs <dpkg-arch> and <number>...")
+			os.Exit(1)
+		}
+		err = syscallNames(os.Args[2], os.Args[3:])
 	default:
 		err = fmt.Errorf("unsupported argument %q", cmd)
 	}
@@ -20,9 +20,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 
@@ -34,6 +36,12 @@ var shortChangesHelp = i18n.G("List system changes")
 var shortTasksHelp = i18n.G("List a change's tasks")
 var longChangesHelp = i18n.G(`
 The changes command displays a summary of system changes performed recently.
+
+With --history, it displays instead the changes that were performed long
+enough ago to have been removed from the system state, as kept in the change
+history. --since then limits those to the changes that became ready from the
+given time on, given as a date (YYYY-MM-DD), a time in RFC 3339 format, or a
+duration before now (e.g. 720h).
 `)
 var longTasksHelp = i18n.G(`
 The tasks command displays a summary of tasks associated with an individual
@@ -43,6 +51,10 @@ change.
 type cmdChanges struct {
 	clientMixin
 	timeMixin
+	formatMixin
+	History    bool   `long:"history"`
+	Since      string `long:"since"`
+	Snap       string `long:"snap"`
 	Positional struct {
 		Snap string `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
@@ -51,14 +63,22 @@ type cmdChanges struct {
 type cmdTasks struct {
 	timeMixin
 	changeIDMixin
+	formatMixin
 }
 
 func init() {
 	addCommand("changes", shortChangesHelp, longChangesHelp,
-		func() flags.Commander { return &cmdChanges{} }, timeDescs, nil)
+		func() flags.Commander { return &cmdChanges{} }, timeDescs.also(formatDescs).also(map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"history": i18n.G("Show the changes kept in the change history"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"since": i18n.G("Only show the changes in the history that became ready from this time on"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"snap": i18n.G("Only show the changes about this snap"),
+		}), nil)
 	addCommand("tasks", shortTasksHelp, longTasksHelp,
 		func() flags.Commander { return &cmdTasks{} },
-		changeIDMixinOptDesc.also(timeDescs),
+		changeIDMixinOptDesc.also(timeDescs).also(formatDescs),
 		changeIDMixinArgDesc).alias = "change"
 }
 
@@ -96,23 +116,47 @@ func (c *cmdChanges) Execute(args []string) error {
 		return nil
 	}
 
+	if c.Since != "" && !c.History {
+		return errors.New(i18n.G("--since can only be used with --history"))
+	}
+	snapName := c.Positional.Snap
+	if c.Snap != "" {
+		if snapName != "" && snapName != c.Snap {
+			return errors.New(i18n.G("cannot use --snap with a different snap name argument"))
+		}
+		snapName = c.Snap
+	}
+
 	opts := client.ChangesOptions{
-		SnapName: c.Positional.Snap,
+		SnapName: snapName,
 		Selector: client.ChangesAll,
 	}
+	if c.History {
+		opts.Selector = client.ChangesArchived
+		if c.Since != "" {
+			since, err := parseSince(c.Since)
+			if err != nil {
+				return err
+			}
+			opts.Since = since
+		}
+	}
 
 	changes, err := queryChanges(c.client, &opts)
 	if err != nil {
 		return err
 	}
 
+	sort.Sort(changesByTime(changes))
+	if c.formatted() {
+		return c.writeFormatted(changes)
+	}
+
 	if len(changes) == 0 {
 		fmt.Fprintln(Stderr, i18n.G("no changes found"))
 		return nil
 	}
 
-	sort.Sort(changesByTime(changes))
-
 	w := tabWriter()
 
 	fmt.Fprint(w, i18n.G("ID\tStatus\tSpawn\tReady\tSummary\n"))
@@ -122,7 +166,12 @@ func (c *cmdChanges) Execute(args []string) error {
 		if chg.ReadyTime.IsZero() {
 			readyTime = "-"
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", chg.ID, chg.Status, spawnTime, readyTime, chg.Summary)
+		status := chg.Status
+		if !chg.ScheduledTime.IsZero() {
+			// TRANSLATORS: status of changes held until a given time
+			status = i18n.G("Scheduled")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", chg.ID, status, spawnTime, readyTime, chg.Summary)
 	}
 
 	w.Flush()
@@ -131,6 +180,21 @@ func (c *cmdChanges) Execute(args []string) error {
 	return nil
 }
 
+// parseSince parses a date, a time in RFC 3339 format or a duration before
+// now.
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return timeNow().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf(i18n.G("cannot parse --since %q: expected a date (YYYY-MM-DD), a time in RFC 3339 format or a duration"), s)
+}
+
 func (c *cmdTasks) Execute([]string) error {
 	chid, err := c.GetChangeID()
 	if err != nil {
@@ -159,6 +223,9 @@ func (c *cmdTasks) showChange(chid string) error {
 	if err != nil {
 		return err
 	}
+	if c.formatted() {
+		return c.writeFormatted(chg)
+	}
 
 	w := tabWriter()
 
@@ -178,6 +245,11 @@ func (c *cmdTasks) showChange(chid string) error {
 
 	w.Flush()
 
+	if !chg.ScheduledTime.IsZero() {
+		fmt.Fprintln(Stdout)
+		fmt.Fprintf(Stdout, i18n.G("Scheduled for %s.\n"), c.fmtTime(chg.ScheduledTime))
+	}
+
 	for _, t := range chg.Tasks {
 		if len(t.Log) == 0 {
 			continue
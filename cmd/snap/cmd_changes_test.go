@@ -20,12 +20,16 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/client"
 	snap "github.com/snapcore/snapd/cmd/snap"
 )
 
@@ -69,6 +73,26 @@ Do +2016-04-21T01:02:03Z +2016-04-21T01:02:04Z +some summary
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *SnapSuite) TestChangeFormat(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+		fmt.Fprintln(w, mockChangeJSON)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"tasks", "--format=json", "42"})
+	c.Assert(err, check.IsNil)
+	var chg client.Change
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &chg), check.IsNil)
+	c.Check(chg.ID, check.Equals, "uno")
+	c.Assert(chg.Tasks, check.HasLen, 1)
+	c.Check(chg.Tasks[0].Summary, check.Equals, "some summary")
+	s.ResetStdStreams()
+
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"tasks", "--format", "go-template={{range .Tasks}}{{.Status}}: {{.Summary}}\n{{end}}", "42"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "Do: some summary\n")
+}
+
 func (s *SnapSuite) TestChangeSimpleRebooting(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -250,3 +274,128 @@ func (s *SnapSuite) TestNoChanges(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Check(s.Stderr(), check.Equals, "no changes found\n")
 }
+
+func (s *SnapSuite) TestChangesScheduled(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		switch r.URL.Path {
+		case "/v2/changes":
+			fmt.Fprintln(w, `{"type": "sync", "result": [{
+  "id": "1",
+  "kind": "refresh-snap",
+  "summary": "Refresh \"foo\" snap",
+  "status": "Do",
+  "ready": false,
+  "spawn-time": "2026-10-17T01:02:03Z",
+  "scheduled-time": "2026-10-20T02:00:00Z"
+}]}`)
+		case "/v2/changes/1":
+			fmt.Fprintln(w, `{"type": "sync", "result": {
+  "id": "1",
+  "kind": "refresh-snap",
+  "summary": "Refresh \"foo\" snap",
+  "status": "Do",
+  "ready": false,
+  "spawn-time": "2026-10-17T01:02:03Z",
+  "scheduled-time": "2026-10-20T02:00:00Z",
+  "tasks": [{"kind": "bar", "summary": "some summary", "status": "Do", "progress": {"done": 0, "total": 1}, "spawn-time": "2026-10-17T01:02:03Z"}]
+}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"changes", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Matches, `(?ms)ID +Status +Spawn +Ready +Summary
+1 +Scheduled +2026-10-17T01:02:03Z +- +Refresh "foo" snap
+`)
+
+	s.stdout.Reset()
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"tasks", "--abs-time", "1"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Matches, `(?ms)Status +Spawn +Ready +Summary
+Do +2026-10-17T01:02:03Z +- +some summary
+
+Scheduled for 2026-10-20T02:00:00Z.
+`)
+}
+
+func (s *SnapSuite) TestChangesHistory(c *check.C) {
+	restore := snap.MockTimeNow(func() time.Time {
+		return time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)
+	})
+	defer restore()
+
+	for _, t := range []struct {
+		args  []string
+		query url.Values
+	}{
+		{[]string{"--history"}, url.Values{"select": {"archived"}}},
+		{[]string{"--history", "foo"}, url.Values{"select": {"archived"}, "for": {"foo"}}},
+		{[]string{"--history", "--snap", "foo"}, url.Values{"select": {"archived"}, "for": {"foo"}}},
+		{[]string{"--history", "--snap", "foo", "foo"}, url.Values{"select": {"archived"}, "for": {"foo"}}},
+		{[]string{"--history", "--since", "2026-04-01T10:00:00Z"}, url.Values{"select": {"archived"}, "since": {"2026-04-01T10:00:00Z"}}},
+		{[]string{"--history", "--since", "240h"}, url.Values{"select": {"archived"}, "since": {"2026-04-20T00:00:00Z"}}},
+		{[]string{"--snap", "foo"}, url.Values{"select": {"all"}, "for": {"foo"}}},
+	} {
+		s.stdout.Reset()
+		n := 0
+		s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch n {
+			case 0:
+				c.Check(r.Method, check.Equals, "GET")
+				c.Check(r.URL.Path, check.Equals, "/v2/changes")
+				c.Check(r.URL.Query(), check.DeepEquals, t.query, check.Commentf("%v", t.args))
+				fmt.Fprintln(w, `{"type": "sync", "result": [{
+  "id": "1",
+  "kind": "refresh-snap",
+  "summary": "Refresh \"foo\" snap",
+  "status": "Error",
+  "ready": true,
+  "spawn-time": "2026-04-21T01:02:03Z",
+  "ready-time": "2026-04-21T01:02:04Z"
+}]}`)
+			default:
+				c.Fatalf("expected to get 1 requests, now on %d", n+1)
+			}
+			n++
+		})
+		rest, err := snap.Parser(snap.Client()).ParseArgs(append([]string{"changes", "--abs-time"}, t.args...))
+		c.Assert(err, check.IsNil)
+		c.Assert(rest, check.DeepEquals, []string{})
+		c.Check(s.Stdout(), check.Matches, `(?ms)ID +Status +Spawn +Ready +Summary
+1 +Error +2026-04-21T01:02:03Z +2026-04-21T01:02:04Z +Refresh "foo" snap
+`)
+	}
+}
+
+func (s *SnapSuite) TestChangesHistorySinceDate(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		since, err := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+		c.Assert(err, check.IsNil)
+		c.Check(since.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)), check.Equals, true)
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"changes", "--history", "--since", "2026-04-01"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stderr(), check.Equals, "no changes found\n")
+}
+
+func (s *SnapSuite) TestChangesHistoryErrors(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--since", "240h"}, "--since can only be used with --history"},
+		{[]string{"--history", "--since", "yesterday"}, `cannot parse --since "yesterday": expected a date \(YYYY-MM-DD\), a time in RFC 3339 format or a duration`},
+		{[]string{"--history", "--since=-1h"}, `cannot parse --since "-1h": .*`},
+		{[]string{"--snap", "foo", "bar"}, "cannot use --snap with a different snap name argument"},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(append([]string{"changes"}, t.args...))
+		c.Check(err, check.ErrorMatches, t.err, check.Commentf("%v", t.args))
+	}
+}
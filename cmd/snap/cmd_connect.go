@@ -20,13 +20,20 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
 )
 
 type cmdConnect struct {
 	waitMixin
+	For         string `long:"for"`
+	Until       string `long:"until"`
 	Positionals struct {
 		PlugSpec connectPlugSpec `required:"yes"`
 		SlotSpec connectSlotSpec
@@ -52,12 +59,20 @@ $ snap connect <snap>:<plug>
 
 Connects the provided plug to the slot in the core snap with a name matching
 the plug name.
+
+With --for or --until, the connection is temporary: snapd disconnects it
+automatically after the given duration, or at the given time.
 `)
 
 func init() {
 	addCommand("connect", shortConnectHelp, longConnectHelp, func() flags.Commander {
 		return &cmdConnect{}
-	}, waitDescs, []argDesc{
+	}, waitDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"for": i18n.G("Disconnect automatically after the given duration, e.g. 2h"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"until": i18n.G("Disconnect automatically at the given time, in RFC 3339 format"),
+	}), []argDesc{
 		// TRANSLATORS: This needs to begin with < and end with >
 		{name: i18n.G("<snap>:<plug>")},
 		// TRANSLATORS: This needs to begin with < and end with >
@@ -77,7 +92,12 @@ func (x *cmdConnect) Execute(args []string) error {
 		x.Positionals.PlugSpec.Snap = ""
 	}
 
-	id, err := x.client.Connect(x.Positionals.PlugSpec.Snap, x.Positionals.PlugSpec.Name, x.Positionals.SlotSpec.Snap, x.Positionals.SlotSpec.Name)
+	opts, err := x.connectOptions()
+	if err != nil {
+		return err
+	}
+
+	id, err := x.client.Connect(x.Positionals.PlugSpec.Snap, x.Positionals.PlugSpec.Name, x.Positionals.SlotSpec.Snap, x.Positionals.SlotSpec.Name, opts)
 	if err != nil {
 		return err
 	}
@@ -91,3 +111,26 @@ func (x *cmdConnect) Execute(args []string) error {
 
 	return nil
 }
+
+func (x *cmdConnect) connectOptions() (*client.ConnectOptions, error) {
+	switch {
+	case x.For != "" && x.Until != "":
+		return nil, errors.New(i18n.G("cannot use --for and --until together"))
+	case x.For != "":
+		dur, err := time.ParseDuration(x.For)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.G("cannot parse --for duration: %v"), err)
+		}
+		if dur <= 0 {
+			return nil, fmt.Errorf(i18n.G("--for duration must be positive: %s"), x.For)
+		}
+		return &client.ConnectOptions{Expires: timeNow().Add(dur)}, nil
+	case x.Until != "":
+		expires, err := time.Parse(time.RFC3339, x.Until)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.G("cannot parse --until time: %v"), err)
+		}
+		return &client.ConnectOptions{Expires: expires}, nil
+	}
+	return nil, nil
+}
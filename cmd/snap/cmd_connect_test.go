@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 	. "gopkg.in/check.v1"
@@ -53,9 +54,16 @@ $ snap connect <snap>:<plug>
 Connects the provided plug to the slot in the core snap with a name matching
 the plug name.
 
+With --for or --until, the connection is temporary: snapd disconnects it
+automatically after the given duration, or at the given time.
+
 [connect command options]
       --no-wait          Do not wait for the operation to finish but just print
                          the change id.
+      --for=             Disconnect automatically after the given duration,
+                         e.g. 2h
+      --until=           Disconnect automatically at the given time, in RFC
+                         3339 format
 `
 	s.testSubCommandHelp(c, "connect", msg)
 }
@@ -94,6 +102,69 @@ func (s *SnapSuite) TestConnectExplicitEverything(c *C) {
 	c.Assert(rest, DeepEquals, []string{})
 }
 
+func (s *SnapSuite) TestConnectFor(c *C) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	restore := MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/interfaces":
+			c.Check(r.Method, Equals, "POST")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action": "connect",
+				"plugs": []interface{}{
+					map[string]interface{}{
+						"snap": "producer",
+						"plug": "plug",
+					},
+				},
+				"slots": []interface{}{
+					map[string]interface{}{
+						"snap": "consumer",
+						"slot": "slot",
+					},
+				},
+				"expires": "2026-10-17T12:00:00Z",
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "zzz"}`)
+		case "/v2/changes/zzz":
+			c.Check(r.Method, Equals, "GET")
+			fmt.Fprintln(w, `{"type":"sync", "result":{"ready": true, "status": "Done"}}`)
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"connect", "--for", "2h", "producer:plug", "consumer:slot"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+
+	rest, err = Parser(Client()).ParseArgs([]string{"connect", "--until", "2026-10-17T12:00:00Z", "producer:plug", "consumer:slot"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+}
+
+func (s *SnapSuite) TestConnectForUntilErrors(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to %q", r.URL.Path)
+	})
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--for", "2h", "--until", "2026-10-17T12:00:00Z"}, "cannot use --for and --until together"},
+		{[]string{"--for", "two hours"}, `cannot parse --for duration: .*`},
+		{[]string{"--for=-2h"}, `--for duration must be positive: -2h`},
+		{[]string{"--until", "tomorrow"}, `cannot parse --until time: .*`},
+	} {
+		args := append([]string{"connect"}, tc.args...)
+		args = append(args, "producer:plug", "consumer:slot")
+		_, err := Parser(Client()).ParseArgs(args)
+		c.Check(err, ErrorMatches, tc.err, Commentf("%v", tc.args))
+	}
+}
+
 func (s *SnapSuite) TestConnectExplicitPlugImplicitSlot(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
@@ -24,15 +24,18 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/strutil/quantity"
 )
 
 type cmdConnections struct {
 	clientMixin
+	formatMixin
 	All         bool `long:"all"`
 	Positionals struct {
 		Snap installedSnapName
@@ -57,9 +60,9 @@ snap.
 func init() {
 	addCommand("connections", shortConnectionsHelp, longConnectionsHelp, func() flags.Commander {
 		return &cmdConnections{}
-	}, map[string]string{
+	}, formatDescs.also(map[string]string{
 		"all": i18n.G("Show connected and unconnected plugs and slots"),
-	}, []argDesc{{
+	}), []argDesc{{
 		// TRANSLATORS: This needs to be wrapped in <>s.
 		name: "<snap>",
 		// TRANSLATORS: This should not start with a lowercase letter.
@@ -85,6 +88,7 @@ type connection struct {
 	interfaceDeterminant string
 	manual               bool
 	gadget               bool
+	expires              time.Time
 }
 
 func (cn connection) String() string {
@@ -95,6 +99,13 @@ func (cn connection) String() string {
 	if cn.gadget {
 		opts = append(opts, "gadget")
 	}
+	if !cn.expires.IsZero() {
+		if remaining := cn.expires.Sub(timeNow()); remaining > 0 {
+			opts = append(opts, "expires-in:"+quantity.FormatDuration(remaining.Seconds()))
+		} else {
+			opts = append(opts, "expired")
+		}
+	}
 	if len(opts) == 0 {
 		return "-"
 	}
@@ -159,6 +170,9 @@ func (x *cmdConnections) Execute(args []string) error {
 	if err != nil {
 		return err
 	}
+	if x.formatted() {
+		return x.writeFormatted(connections)
+	}
 	if len(connections.Plugs) == 0 && len(connections.Slots) == 0 {
 		return nil
 	}
@@ -170,6 +184,7 @@ func (x *cmdConnections) Execute(args []string) error {
 			slot:                 endpoint(conn.Slot.Snap, conn.Slot.Name),
 			manual:               conn.Manual,
 			gadget:               conn.Gadget,
+			expires:              conn.Expires,
 			interfaceName:        conn.Interface,
 			interfaceDeterminant: interfaceDeterminant(&conn),
 		})
@@ -24,6 +24,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	. "gopkg.in/check.v1"
 
@@ -277,6 +278,66 @@ func (s *SnapSuite) TestConnectionsSomeConnected(c *C) {
 	c.Assert(s.Stderr(), Equals, "")
 }
 
+func (s *SnapSuite) TestConnectionsExpiring(c *C) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	restore := MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	result := client.Connections{
+		Established: []client.Connection{
+			{
+				Plug:      client.PlugRef{Snap: "support-tool", Name: "ssh-keys"},
+				Slot:      client.SlotRef{Snap: "core", Name: "ssh-keys"},
+				Interface: "ssh-keys",
+				Manual:    true,
+				Expires:   now.Add(2 * time.Hour),
+			}, {
+				Plug:      client.PlugRef{Snap: "support-tool", Name: "network-observe"},
+				Slot:      client.SlotRef{Snap: "core", Name: "network-observe"},
+				Interface: "network-observe",
+				Manual:    true,
+				Expires:   now.Add(-time.Minute),
+			},
+		},
+		Plugs: []client.Plug{
+			{
+				Snap:      "support-tool",
+				Name:      "ssh-keys",
+				Interface: "ssh-keys",
+				Connections: []client.SlotRef{{
+					Snap: "core",
+					Name: "ssh-keys",
+				}},
+			}, {
+				Snap:      "support-tool",
+				Name:      "network-observe",
+				Interface: "network-observe",
+				Connections: []client.SlotRef{{
+					Snap: "core",
+					Name: "network-observe",
+				}},
+			},
+		},
+	}
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/connections")
+		EncodeResponseBody(c, w, map[string]interface{}{
+			"type":   "sync",
+			"result": result,
+		})
+	})
+	rest, err := Parser(Client()).ParseArgs([]string{"connections"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	expectedStdout := "" +
+		"Interface        Plug                          Slot              Notes\n" +
+		"network-observe  support-tool:network-observe  :network-observe  manual,expired\n" +
+		"ssh-keys         support-tool:ssh-keys         :ssh-keys         manual,expires-in:2h00m\n"
+	c.Assert(s.Stdout(), Equals, expectedStdout)
+	c.Assert(s.Stderr(), Equals, "")
+}
+
 func (s *SnapSuite) TestConnectionsSomeDisconnected(c *C) {
 	result := client.Connections{
 		Established: []client.Connection{
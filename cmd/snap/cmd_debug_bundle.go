@@ -0,0 +1,110 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdDebugBundle struct {
+	clientMixin
+
+	Output flags.Filename `short:"o" long:"output"`
+}
+
+var shortDebugBundleHelp = i18n.G("Collect debugging data about snapd into an archive")
+var longDebugBundleHelp = i18n.G(`
+The bundle command asks snapd to collect the data usually needed to debug a
+problem into a compressed tar archive: the state, the changes and their
+tasks, timings, the system-key, the generated AppArmor, seccomp and udev
+profiles, the modeenv, boot variables, recent snapd journal entries and the
+sandbox features.
+
+Macaroons, serial keys, user emails, confdb data and recovery keys are
+redacted from the state, changes and tasks before they are added to the
+archive.
+
+By default the archive is written to snapd-debug-<timestamp>.tar.gz in the
+current directory. Use --output - to write it to standard output.
+`)
+
+func init() {
+	addDebugCommand("bundle",
+		shortDebugBundleHelp,
+		longDebugBundleHelp,
+		func() flags.Commander {
+			return &cmdDebugBundle{}
+		}, map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"output": i18n.G("File to write the archive to"),
+		}, nil)
+}
+
+func (x *cmdDebugBundle) Execute(args []string) (err error) {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	r, err := x.client.DebugBundle()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	filename := string(x.Output)
+	if filename == "-" {
+		_, err := io.Copy(Stdout, r)
+		return err
+	}
+	if filename == "" {
+		filename = fmt.Sprintf("snapd-debug-%s.tar.gz", timeNow().UTC().Format("20060102T150405Z"))
+	}
+
+	f, err := os.OpenFile(filename+".part", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer func() {
+		if err != nil {
+			os.Remove(filename + ".part")
+		}
+	}()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf(i18n.G("cannot write debug bundle: %v"), err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf(i18n.G("cannot write debug bundle: %v"), err)
+	}
+	if err := os.Rename(filename+".part", filename); err != nil {
+		return err
+	}
+
+	// TRANSLATORS: the argument is a file name
+	fmt.Fprintf(Stdout, i18n.G("Debug bundle written to %q\n"), filename)
+	return nil
+}
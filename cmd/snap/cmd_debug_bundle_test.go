@@ -0,0 +1,99 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *SnapSuite) mockDebugBundleServer(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/debug")
+		data, err := io.ReadAll(r.Body)
+		c.Check(err, check.IsNil)
+		c.Check(string(data), check.Equals, `{"action":"bundle"}`)
+		w.Header().Set("Content-Type", client.DebugBundleMediaType)
+		fmt.Fprint(w, "bundle-data")
+	})
+}
+
+func (s *SnapSuite) TestDebugBundle(c *check.C) {
+	s.mockDebugBundleServer(c)
+	output := filepath.Join(c.MkDir(), "bundle.tar.gz")
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "bundle", "-o", output})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, fmt.Sprintf("Debug bundle written to %q\n", output))
+	c.Check(output, testutil.FileEquals, "bundle-data")
+	c.Check(output+".part", testutil.FileAbsent)
+}
+
+func (s *SnapSuite) TestDebugBundleDefaultOutput(c *check.C) {
+	s.mockDebugBundleServer(c)
+	restore := snap.MockTimeNow(func() time.Time {
+		return time.Date(2026, 10, 17, 12, 30, 0, 0, time.UTC)
+	})
+	defer restore()
+
+	dir := c.MkDir()
+	oldCwd, err := os.Getwd()
+	c.Assert(err, check.IsNil)
+	c.Assert(os.Chdir(dir), check.IsNil)
+	defer os.Chdir(oldCwd)
+
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"debug", "bundle"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "Debug bundle written to \"snapd-debug-20261017T123000Z.tar.gz\"\n")
+	c.Check(filepath.Join(dir, "snapd-debug-20261017T123000Z.tar.gz"), testutil.FileEquals, "bundle-data")
+}
+
+func (s *SnapSuite) TestDebugBundleStdout(c *check.C) {
+	s.mockDebugBundleServer(c)
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "bundle", "--output", "-"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "bundle-data")
+}
+
+func (s *SnapSuite) TestDebugBundleError(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(403)
+		fmt.Fprint(w, `{"type":"error","status-code":403,"result":{"message":"access denied","kind":"login-required"}}`)
+	})
+	output := filepath.Join(c.MkDir(), "bundle.tar.gz")
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "bundle", "-o", output})
+	c.Assert(err, check.ErrorMatches, "access denied")
+	c.Check(output, testutil.FileAbsent)
+	c.Check(output+".part", testutil.FileAbsent)
+}
@@ -0,0 +1,293 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/policy"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
+)
+
+type cmdDebugCheckPolicy struct {
+	Snap        flags.Filename `long:"snap" required:"yes"`
+	Declaration flags.Filename `long:"declaration" required:"yes"`
+	Model       flags.Filename `long:"model"`
+}
+
+var shortDebugCheckPolicyHelp = i18n.G("Check the interface policy for a snap offline")
+var longDebugCheckPolicyHelp = i18n.G(`
+The check-policy command checks the plugs and slots of the given snap file
+or snap.yaml against the builtin base declaration and the given
+snap-declaration, without contacting snapd or the store.
+
+Each plug is checked for installation, and for connection and
+auto-connection to the slot of its interface provided by the system, and
+each slot likewise to the plug of its interface provided by the system.
+Interfaces for which the system provides no slot or plug, such as content,
+are checked instead against one with the same attributes of another app
+snap without a snap-declaration. The result is "denied" if the installation
+or the connection is not allowed, "manual" if the connection is allowed but
+has to be made manually, and "auto-connect" if it is made automatically.
+
+The system provides different slots on classic and Ubuntu Core systems.
+With --model the kind of system and the brand constraints of the given
+model assertion are used, otherwise the system the command runs on is
+assumed. The assertions are not verified.
+`)
+
+func init() {
+	addDebugCommand("check-policy",
+		shortDebugCheckPolicyHelp,
+		longDebugCheckPolicyHelp,
+		func() flags.Commander {
+			return &cmdDebugCheckPolicy{}
+		}, map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"snap": i18n.G("Snap file or snap.yaml of the snap to check"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"declaration": i18n.G("File with the snap-declaration of the snap"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"model": i18n.G("File with the model assertion of the target system"),
+		}, nil)
+}
+
+// policyCheck is the outcome of checking a plug or slot of the snap against
+// the system.
+type policyCheck struct {
+	name      string
+	iface     string
+	candidate string
+	result    string
+	notes     string
+}
+
+func newPolicyCheck(name, iface string) *policyCheck {
+	return &policyCheck{name: name, iface: iface, candidate: "-", result: "-", notes: "-"}
+}
+
+func (x *cmdDebugCheckPolicy) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	// plug/slot sanitization is disabled (no-op) by default at the package
+	// level for "snap" command, the checks need the real one however
+	snap.SanitizePlugsSlots = builtin.SanitizePlugsSlots
+
+	info, err := readSnapInfoOffline(string(x.Snap))
+	if err != nil {
+		return fmt.Errorf("cannot read snap: %v", err)
+	}
+	snapDecl, err := readSnapDeclarationOffline(string(x.Declaration))
+	if err != nil {
+		return err
+	}
+	if snapDecl.SnapName() != info.SnapName() {
+		return fmt.Errorf(i18n.G("cannot check policy: snap-declaration is for snap %q, not %q"), snapDecl.SnapName(), info.SnapName())
+	}
+	info.SnapID = snapDecl.SnapID()
+
+	var model *asserts.Model
+	if x.Model != "" {
+		model, err = readModelOffline(string(x.Model))
+		if err != nil {
+			return err
+		}
+		// the on-classic constraints are checked against the kind of
+		// the running system
+		release.OnClassic = model.Classic()
+	}
+
+	system := implicitSystemSnapInfo(release.OnClassic)
+	appSet, err := interfaces.NewSnapAppSet(info, nil)
+	if err != nil {
+		return err
+	}
+	systemAppSet, err := interfaces.NewSnapAppSet(system, nil)
+	if err != nil {
+		return err
+	}
+	other := otherAppSnapInfo()
+	otherAppSet, err := interfaces.NewSnapAppSet(other, nil)
+	if err != nil {
+		return err
+	}
+
+	ic := policy.InstallCandidate{
+		Snap:            info,
+		SnapDeclaration: snapDecl,
+		BaseDeclaration: asserts.BuiltinBaseDeclaration(),
+		Model:           model,
+	}
+	var checks []*policyCheck
+	for _, name := range sortedKeys(info.Plugs) {
+		plug := info.Plugs[name]
+		check := newPolicyCheck(info.SnapName()+":"+plug.Name, plug.Interface)
+		checks = append(checks, check)
+		if err := ic.CheckPlug(plug); err != nil {
+			check.result, check.notes = "denied", err.Error()
+			continue
+		}
+		slot, slotAppSet := system.Slots[plug.Interface], systemAppSet
+		if slot == nil {
+			// not provided by the system, try a slot of an app snap
+			// with the same attributes as the plug, like content
+			slot = &snap.SlotInfo{Name: plug.Name, Snap: other, Interface: plug.Interface, Attrs: plug.Attrs}
+			slotAppSet = otherAppSet
+		}
+		check.candidate = slot.Snap.SnapName() + ":" + slot.Name
+		check.checkConnection(&policy.ConnectCandidate{
+			Plug:                interfaces.NewConnectedPlug(plug, appSet, nil, nil),
+			PlugSnapDeclaration: snapDecl,
+			Slot:                interfaces.NewConnectedSlot(slot, slotAppSet, nil, nil),
+			BaseDeclaration:     ic.BaseDeclaration,
+			Model:               model,
+		})
+	}
+	for _, name := range sortedKeys(info.Slots) {
+		slot := info.Slots[name]
+		check := newPolicyCheck(info.SnapName()+":"+slot.Name, slot.Interface)
+		checks = append(checks, check)
+		if err := ic.CheckSlot(slot); err != nil {
+			check.result, check.notes = "denied", err.Error()
+			continue
+		}
+		plug, plugAppSet := system.Plugs[slot.Interface], systemAppSet
+		if plug == nil {
+			// not provided by the system, try a plug of an app snap
+			// with the same attributes as the slot
+			plug = &snap.PlugInfo{Name: slot.Name, Snap: other, Interface: slot.Interface, Attrs: slot.Attrs}
+			plugAppSet = otherAppSet
+		}
+		check.candidate = plug.Snap.SnapName() + ":" + plug.Name
+		check.checkConnection(&policy.ConnectCandidate{
+			Plug:                interfaces.NewConnectedPlug(plug, plugAppSet, nil, nil),
+			Slot:                interfaces.NewConnectedSlot(slot, appSet, nil, nil),
+			SlotSnapDeclaration: snapDecl,
+			BaseDeclaration:     ic.BaseDeclaration,
+			Model:               model,
+		})
+	}
+
+	if len(checks) > 0 {
+		w := tabWriter()
+		fmt.Fprintln(w, i18n.G("Plug/slot\tInterface\tCandidate\tResult\tNotes"))
+		for _, check := range checks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", check.name, check.iface, check.candidate, check.result, check.notes)
+		}
+		w.Flush()
+	} else {
+		fmt.Fprintf(Stdout, i18n.G("Snap %q has no plugs or slots.\n"), info.SnapName())
+	}
+	if len(info.BadInterfaces) > 0 {
+		fmt.Fprintf(Stdout, "\n%s\n", snap.BadInterfacesSummary(info))
+	}
+	return nil
+}
+
+// checkConnection records whether the candidate connection is denied,
+// allowed manually or allowed to auto-connect.
+func (check *policyCheck) checkConnection(cc *policy.ConnectCandidate) {
+	if err := cc.Check(); err != nil {
+		check.result, check.notes = "denied", err.Error()
+		return
+	}
+	if _, err := cc.CheckAutoConnect(); err != nil {
+		check.result, check.notes = "manual", err.Error()
+		return
+	}
+	check.result = "auto-connect"
+}
+
+func readSnapDeclarationOffline(fn string) (*asserts.SnapDeclaration, error) {
+	as, err := readAssertionsOffline(fn)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range as {
+		if snapDecl, ok := a.(*asserts.SnapDeclaration); ok {
+			return snapDecl, nil
+		}
+	}
+	return nil, fmt.Errorf(i18n.G("cannot find a snap-declaration in %s"), fn)
+}
+
+func readModelOffline(fn string) (*asserts.Model, error) {
+	as, err := readAssertionsOffline(fn)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range as {
+		if model, ok := a.(*asserts.Model); ok {
+			return model, nil
+		}
+	}
+	return nil, fmt.Errorf(i18n.G("cannot find a model assertion in %s"), fn)
+}
+
+// implicitSystemSnapInfo returns the system snap with the slots and plugs of
+// the builtin interfaces which snapd adds to it on classic or core systems.
+func implicitSystemSnapInfo(classic bool) *snap.Info {
+	info := &snap.Info{
+		SuggestedName: "core",
+		Version:       "0",
+		SnapType:      snap.TypeOS,
+		Slots:         make(map[string]*snap.SlotInfo),
+		Plugs:         make(map[string]*snap.PlugInfo),
+	}
+	for _, iface := range builtin.Interfaces() {
+		si := interfaces.StaticInfoOf(iface)
+		name := iface.Name()
+		if (classic && si.ImplicitOnClassic) || (!classic && si.ImplicitOnCore) {
+			info.Slots[name] = &snap.SlotInfo{Name: name, Snap: info, Interface: name}
+		}
+		if (classic && si.ImplicitPlugOnClassic) || (!classic && si.ImplicitPlugOnCore) {
+			info.Plugs[name] = &snap.PlugInfo{Name: name, Snap: info, Interface: name}
+		}
+	}
+	return info
+}
+
+// otherAppSnapInfo returns the app snap standing in for the snaps providing
+// the other side of interfaces which the system does not provide.
+func otherAppSnapInfo() *snap.Info {
+	return &snap.Info{
+		SuggestedName: "other-snap",
+		Version:       "0",
+		SnapType:      snap.TypeApp,
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,161 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	snap "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/release"
+)
+
+const checkPolicySnapYaml = `name: consumer
+version: 1
+plugs:
+  cam:
+    interface: camera
+  home:
+  control:
+    interface: snapd-control
+  stuff:
+    interface: content
+    target: $SNAP/stuff
+  bogus:
+    interface: no-such-interface
+  nm:
+    interface: network-manager
+slots:
+  my-home:
+    interface: home
+  shared:
+    interface: content
+    content: shared
+    read: [$SNAP/shared]
+`
+
+func (s *SnapSuite) mockCheckPolicyFiles(c *check.C) (snapYaml, declFile, modelFile string) {
+	dir := c.MkDir()
+	snapYaml = filepath.Join(dir, "snap.yaml")
+	c.Assert(os.WriteFile(snapYaml, []byte(checkPolicySnapYaml), 0644), check.IsNil)
+
+	storeStack := assertstest.NewStoreStack("canonical", nil)
+	snapDecl, err := storeStack.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"format":       "1",
+		"series":       "16",
+		"snap-id":      "consumerididididididididididididi",
+		"snap-name":    "consumer",
+		"publisher-id": "canonical",
+		"plugs": map[string]interface{}{
+			"camera": map[string]interface{}{
+				"allow-auto-connection": "true",
+			},
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, check.IsNil)
+	declFile = filepath.Join(dir, "decl.assert")
+	c.Assert(os.WriteFile(declFile, asserts.Encode(snapDecl), 0644), check.IsNil)
+
+	model, err := storeStack.Sign(asserts.ModelType, map[string]interface{}{
+		"series":       "16",
+		"brand-id":     "canonical",
+		"model":        "pc",
+		"architecture": "amd64",
+		"gadget":       "pc",
+		"kernel":       "pc-kernel",
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, check.IsNil)
+	modelFile = filepath.Join(dir, "model.assert")
+	c.Assert(os.WriteFile(modelFile, asserts.Encode(model), 0644), check.IsNil)
+
+	return snapYaml, declFile, modelFile
+}
+
+func (s *SnapSuite) TestDebugCheckPolicy(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to snapd")
+	})
+	restore := release.MockOnClassic(true)
+	defer restore()
+
+	snapYaml, declFile, modelFile := s.mockCheckPolicyFiles(c)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "check-policy", "--snap", snapYaml, "--declaration", declFile})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `Plug/slot         Interface        Candidate             Result        Notes
+consumer:cam      camera           core:camera           auto-connect  -
+consumer:control  snapd-control    -                     denied        installation not allowed by "control" plug rule of interface "snapd-control"
+consumer:home     home             core:home             auto-connect  -
+consumer:nm       network-manager  core:network-manager  manual        auto-connection denied by slot rule of interface "network-manager"
+consumer:stuff    content          other-snap:stuff      manual        auto-connection not allowed by slot rule of interface "content"
+consumer:my-home  home             -                     denied        installation not allowed by "my-home" slot rule of interface "home"
+consumer:shared   content          other-snap:shared     manual        auto-connection not allowed by slot rule of interface "content"
+
+snap "consumer" has bad plugs or slots: bogus (unknown interface "no-such-interface")
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+
+	// on Ubuntu Core the home interface is not auto-connected and
+	// network-manager is provided by an app snap
+	s.ResetStdStreams()
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"debug", "check-policy", "--snap", snapYaml, "--declaration", declFile, "--model", modelFile})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `Plug/slot         Interface        Candidate          Result        Notes
+consumer:cam      camera           core:camera        auto-connect  -
+consumer:control  snapd-control    -                  denied        installation not allowed by "control" plug rule of interface "snapd-control"
+consumer:home     home             core:home          manual        auto-connection denied by slot rule of interface "home"
+consumer:nm       network-manager  other-snap:nm      denied        connection denied by slot rule of interface "network-manager"
+consumer:stuff    content          other-snap:stuff   manual        auto-connection not allowed by slot rule of interface "content"
+consumer:my-home  home             -                  denied        installation not allowed by "my-home" slot rule of interface "home"
+consumer:shared   content          other-snap:shared  manual        auto-connection not allowed by slot rule of interface "content"
+
+snap "consumer" has bad plugs or slots: bogus (unknown interface "no-such-interface")
+`)
+}
+
+func (s *SnapSuite) TestDebugCheckPolicyErrors(c *check.C) {
+	snapYaml, declFile, modelFile := s.mockCheckPolicyFiles(c)
+	otherYaml := filepath.Join(c.MkDir(), "snap.yaml")
+	c.Assert(os.WriteFile(otherYaml, []byte("name: other\nversion: 1\n"), 0644), check.IsNil)
+
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--snap", snapYaml}, `the required flag .*--declaration' was not specified`},
+		{[]string{"--snap", snapYaml, "--declaration", snapYaml}, `cannot decode assertions from .*`},
+		{[]string{"--snap", snapYaml, "--declaration", modelFile}, `cannot find a snap-declaration in .*`},
+		{[]string{"--snap", snapYaml, "--declaration", declFile, "--model", declFile}, `cannot find a model assertion in .*`},
+		{[]string{"--snap", otherYaml, "--declaration", declFile}, `cannot check policy: snap-declaration is for snap "consumer", not "other"`},
+		{[]string{"--snap", filepath.Join(c.MkDir(), "missing.snap"), "--declaration", declFile}, `cannot read snap: .*`},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(append([]string{"debug", "check-policy"}, t.args...))
+		c.Check(err, check.ErrorMatches, t.err, check.Commentf("%v", t.args))
+	}
+}
@@ -0,0 +1,356 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/policy"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snapfile"
+)
+
+type cmdDebugExplainConnection struct {
+	clientMixin
+
+	Assertions []flags.Filename `long:"assertions"`
+	PlugSnap   flags.Filename   `long:"plug-snap"`
+	SlotSnap   flags.Filename   `long:"slot-snap"`
+
+	Positionals struct {
+		Plug SnapAndName `required:"yes"`
+		Slot SnapAndName
+	} `positional-args:"true"`
+}
+
+var shortDebugExplainConnectionHelp = i18n.G("Explain the policy decision about a connection")
+var longDebugExplainConnectionHelp = i18n.G(`
+The explain-connection command shows whether the given plug can be
+connected, manually and automatically, to the given slot. For each it
+reports which rule of the base declaration or of a snap-declaration decided,
+and which of its constraints matched or why they did not.
+
+By default the snaps installed on the system and the assertions in the
+system assertion database are used. With --plug-snap, --slot-snap or
+--assertions the connection is explained offline, using the given snap files
+or snap.yaml files and the assertions (base-declaration, snap-declarations,
+model and store) in the given files, which are not verified. The builtin
+base declaration is used if none is given. A slot of the system snap is
+assumed if --slot-snap is not given.
+`)
+
+func init() {
+	addDebugCommand("explain-connection",
+		shortDebugExplainConnectionHelp,
+		longDebugExplainConnectionHelp,
+		func() flags.Commander {
+			return &cmdDebugExplainConnection{}
+		}, map[string]string{
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"assertions": i18n.G("File with assertions to use offline (can be repeated)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"plug-snap": i18n.G("Snap file or snap.yaml of the plug snap to use offline"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"slot-snap": i18n.G("Snap file or snap.yaml of the slot snap to use offline"),
+		}, []argDesc{
+			// TRANSLATORS: This needs to begin with < and end with >
+			{name: i18n.G("<snap>:<plug>")},
+			// TRANSLATORS: This needs to begin with < and end with >
+			{name: i18n.G("<snap>:<slot>")},
+		})
+}
+
+type connectionExplanation struct {
+	Plug       interfaces.PlugRef `json:"plug"`
+	Slot       interfaces.SlotRef `json:"slot"`
+	Interface  string             `json:"interface"`
+	Unasserted []string           `json:"unasserted,omitempty"`
+
+	Connection     *policy.Explanation `json:"connection"`
+	AutoConnection *policy.Explanation `json:"auto-connection"`
+}
+
+func (x *cmdDebugExplainConnection) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	plug, slot := x.Positionals.Plug, x.Positionals.Slot
+	if plug.Snap == "" || plug.Name == "" {
+		return fmt.Errorf(i18n.G("invalid plug %q (want snap:plug)"), plug.Snap+":"+plug.Name)
+	}
+
+	var expl *connectionExplanation
+	var err error
+	if len(x.Assertions) > 0 || x.PlugSnap != "" || x.SlotSnap != "" {
+		expl, err = x.explainOffline()
+	} else {
+		err = x.client.DebugGet("explain-connection", &expl, map[string]string{
+			"plug": plug.Snap + ":" + plug.Name,
+			"slot": slot.Snap + ":" + slot.Name,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(Stdout, "plug:       %s\n", expl.Plug)
+	fmt.Fprintf(Stdout, "slot:       %s\n", expl.Slot)
+	fmt.Fprintf(Stdout, "interface:  %s\n", expl.Interface)
+	displayPolicyExplanation(Stdout, expl.Connection)
+	displayPolicyExplanation(Stdout, expl.AutoConnection)
+	if len(expl.Unasserted) > 0 {
+		fmt.Fprintf(Stdout, i18n.G("\nManual connections are not checked against the policy for snaps without a snap-declaration: %s\n"), strings.Join(expl.Unasserted, ", "))
+	}
+	return nil
+}
+
+func displayPolicyExplanation(w io.Writer, expl *policy.Explanation) {
+	outcome := i18n.G("allowed")
+	if !expl.Allowed {
+		outcome = i18n.G("not allowed")
+	}
+	fmt.Fprintf(w, "\n%s: %s\n", expl.Kind, outcome)
+	if expl.Error != "" {
+		fmt.Fprintf(w, "  error: %s\n", expl.Error)
+	}
+	if expl.Declaration == "" {
+		fmt.Fprintf(w, "  rule: %s\n", i18n.G("none, allowed by default"))
+		return
+	}
+	source := expl.Declaration
+	if expl.Snap != "" {
+		source = fmt.Sprintf("%s of %q", expl.Declaration, expl.Snap)
+	}
+	fmt.Fprintf(w, "  rule: %s rule from the %s\n", expl.Side, source)
+	if expl.Alternative >= 0 {
+		fmt.Fprintf(w, "  decided by: %s, alternative %d\n", expl.DecidedBy, expl.Alternative+1)
+	} else {
+		fmt.Fprintf(w, "  decided by: %s, no alternative matched\n", expl.DecidedBy)
+	}
+	displayAlternatives(w, "deny-"+expl.Kind, expl.Deny)
+	displayAlternatives(w, "allow-"+expl.Kind, expl.Allow)
+}
+
+func displayAlternatives(w io.Writer, constraint string, alts []*policy.AlternativeResult) {
+	if len(alts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  %s:\n", constraint)
+	for i, alt := range alts {
+		match := "no match"
+		if alt.Matched {
+			match = "match"
+		}
+		if len(alt.Constraints) == 0 {
+			fmt.Fprintf(w, "    alternative %d: %s (%t)\n", i+1, match, alt.Matched)
+			continue
+		}
+		fmt.Fprintf(w, "    alternative %d: %s\n", i+1, match)
+		for _, c := range alt.Constraints {
+			result := "ok"
+			if c.Error != "" {
+				result = c.Error
+			}
+			fmt.Fprintf(w, "      %s: %s\n", c.Constraint, result)
+		}
+	}
+}
+
+func readSnapInfoOffline(path string) (*snap.Info, error) {
+	if strings.HasSuffix(path, ".snap") {
+		snapf, err := snapfile.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return snap.ReadInfoFromSnapFile(snapf, nil)
+	}
+	yaml, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return snap.InfoFromSnapYaml(yaml)
+}
+
+// readAssertionsOffline decodes the assertions in the given file, without
+// verifying them.
+func readAssertionsOffline(fn string) ([]asserts.Assertion, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read assertions: %v", err)
+	}
+	defer f.Close()
+
+	var as []asserts.Assertion
+	dec := asserts.NewDecoder(f)
+	for {
+		a, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			return as, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode assertions from %s: %v", fn, err)
+		}
+		as = append(as, a)
+	}
+}
+
+func isSystemSnapName(name string) bool {
+	switch name {
+	case "", "core", "snapd", "system":
+		return true
+	}
+	return false
+}
+
+func (x *cmdDebugExplainConnection) explainOffline() (*connectionExplanation, error) {
+	plug, slot := x.Positionals.Plug, x.Positionals.Slot
+
+	baseDecl := asserts.BuiltinBaseDeclaration()
+	snapDecls := make(map[string]*asserts.SnapDeclaration)
+	var model *asserts.Model
+	var store *asserts.Store
+	for _, fn := range x.Assertions {
+		as, err := readAssertionsOffline(string(fn))
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range as {
+			switch a := a.(type) {
+			case *asserts.BaseDeclaration:
+				baseDecl = a
+			case *asserts.SnapDeclaration:
+				snapDecls[a.SnapName()] = a
+			case *asserts.Model:
+				model = a
+			case *asserts.Store:
+				store = a
+			}
+		}
+	}
+
+	if x.PlugSnap == "" {
+		return nil, errors.New(i18n.G("cannot explain connection offline without --plug-snap"))
+	}
+	plugSnap, err := readSnapInfoOffline(string(x.PlugSnap))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read plug snap: %v", err)
+	}
+	if plugSnap.SnapName() != plug.Snap {
+		return nil, fmt.Errorf(i18n.G("cannot explain connection: plug snap is %q, not %q"), plugSnap.SnapName(), plug.Snap)
+	}
+	plugInfo := plugSnap.Plugs[plug.Name]
+	if plugInfo == nil {
+		return nil, fmt.Errorf(i18n.G("snap %q has no plug named %q"), plug.Snap, plug.Name)
+	}
+
+	var slotSnap *snap.Info
+	switch {
+	case x.SlotSnap != "":
+		slotSnap, err = readSnapInfoOffline(string(x.SlotSnap))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read slot snap: %v", err)
+		}
+		if slot.Snap != "" && slotSnap.SnapName() != slot.Snap {
+			return nil, fmt.Errorf(i18n.G("cannot explain connection: slot snap is %q, not %q"), slotSnap.SnapName(), slot.Snap)
+		}
+	case isSystemSnapName(slot.Snap):
+		// assume the implicit slot of the system snap
+		slotName := slot.Name
+		if slotName == "" {
+			slotName = plugInfo.Interface
+		}
+		slotSnap, err = snap.InfoFromSnapYaml([]byte(fmt.Sprintf("name: core\nversion: 0\ntype: os\nslots:\n  %s:\n    interface: %s\n", slotName, plugInfo.Interface)))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf(i18n.G("cannot explain connection offline without --slot-snap for snap %q"), slot.Snap)
+	}
+	slotInfo, err := offlineSlot(slotSnap, slot.Name, plugInfo.Interface)
+	if err != nil {
+		return nil, err
+	}
+
+	expl := &connectionExplanation{
+		Plug:      interfaces.PlugRef{Snap: plugSnap.InstanceName(), Name: plugInfo.Name},
+		Slot:      interfaces.SlotRef{Snap: slotSnap.InstanceName(), Name: slotInfo.Name},
+		Interface: plugInfo.Interface,
+	}
+	snapDeclaration := func(info *snap.Info) *asserts.SnapDeclaration {
+		snapDecl := snapDecls[info.SnapName()]
+		if snapDecl == nil {
+			expl.Unasserted = append(expl.Unasserted, info.InstanceName())
+			return nil
+		}
+		info.SnapID = snapDecl.SnapID()
+		return snapDecl
+	}
+	plugAppSet, err := interfaces.NewSnapAppSet(plugSnap, nil)
+	if err != nil {
+		return nil, err
+	}
+	slotAppSet, err := interfaces.NewSnapAppSet(slotSnap, nil)
+	if err != nil {
+		return nil, err
+	}
+	ic := policy.ConnectCandidate{
+		Plug:                interfaces.NewConnectedPlug(plugInfo, plugAppSet, nil, nil),
+		PlugSnapDeclaration: snapDeclaration(plugSnap),
+		Slot:                interfaces.NewConnectedSlot(slotInfo, slotAppSet, nil, nil),
+		SlotSnapDeclaration: snapDeclaration(slotSnap),
+		BaseDeclaration:     baseDecl,
+		Model:               model,
+		Store:               store,
+	}
+	expl.Connection = ic.ExplainConnect()
+	expl.AutoConnection = ic.ExplainAutoConnect()
+	return expl, nil
+}
+
+// offlineSlot finds the named slot, or the only slot of the interface if no
+// name is given.
+func offlineSlot(info *snap.Info, name, iface string) (*snap.SlotInfo, error) {
+	if name != "" {
+		slot := info.Slots[name]
+		if slot == nil {
+			return nil, fmt.Errorf(i18n.G("snap %q has no slot named %q"), info.InstanceName(), name)
+		}
+		return slot, nil
+	}
+	var candidates []*snap.SlotInfo
+	for _, slot := range info.Slots {
+		if slot.Interface == iface {
+			candidates = append(candidates, slot)
+		}
+	}
+	if len(candidates) != 1 {
+		return nil, fmt.Errorf(i18n.G("snap %q has %d %q interface slots, please specify the slot"), info.InstanceName(), len(candidates), iface)
+	}
+	return candidates[0], nil
+}
@@ -0,0 +1,176 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestDebugExplainConnection(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/debug")
+		c.Check(r.URL.Query(), check.DeepEquals, url.Values{
+			"aspect": {"explain-connection"},
+			"plug":   {"consumer:plug"},
+			"slot":   {":"},
+		})
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+"plug": {"snap": "consumer", "plug": "plug"},
+"slot": {"snap": "producer", "slot": "slot"},
+"interface": "test",
+"unasserted": ["producer"],
+"connection": {"kind": "connection", "allowed": true, "alternative": -1},
+"auto-connection": {"kind": "auto-connection", "allowed": false,
+  "error": "auto-connection not allowed by plug rule of interface \"test\" for \"consumer\" snap",
+  "declaration": "snap-declaration", "snap": "consumer", "side": "plug",
+  "decided-by": "allow-auto-connection", "alternative": -1,
+  "deny": [{"matched": false}],
+  "allow": [{"matched": false, "constraints": [{"constraint": "plug-attributes"}, {"constraint": "on-store", "error": "on-store mismatch"}]}]}
+}}`)
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "explain-connection", "consumer:plug"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, `plug:       consumer:plug
+slot:       producer:slot
+interface:  test
+
+connection: allowed
+  rule: none, allowed by default
+
+auto-connection: not allowed
+  error: auto-connection not allowed by plug rule of interface "test" for "consumer" snap
+  rule: plug rule from the snap-declaration of "consumer"
+  decided by: allow-auto-connection, no alternative matched
+  deny-auto-connection:
+    alternative 1: no match (false)
+  allow-auto-connection:
+    alternative 1: no match
+      plug-attributes: ok
+      on-store: on-store mismatch
+
+Manual connections are not checked against the policy for snaps without a snap-declaration: producer
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestDebugExplainConnectionOffline(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to snapd")
+	})
+
+	dir := c.MkDir()
+	plugSnap := filepath.Join(dir, "snap.yaml")
+	c.Assert(os.WriteFile(plugSnap, []byte("name: consumer\nversion: 1\nplugs:\n  cam:\n    interface: camera\n"), 0644), check.IsNil)
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"debug", "explain-connection", "--plug-snap", plugSnap, "consumer:cam"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `plug:       consumer:cam
+slot:       core:camera
+interface:  camera
+
+connection: allowed
+  rule: slot rule from the base-declaration
+  decided by: allow-connection, alternative 1
+  deny-connection:
+    alternative 1: no match (false)
+  allow-connection:
+    alternative 1: match (true)
+
+auto-connection: not allowed
+  error: auto-connection denied by slot rule of interface "camera"
+  rule: slot rule from the base-declaration
+  decided by: deny-auto-connection, alternative 1
+  deny-auto-connection:
+    alternative 1: match (true)
+  allow-auto-connection:
+    alternative 1: match (true)
+
+Manual connections are not checked against the policy for snaps without a snap-declaration: consumer, core
+`)
+
+	// a snap-declaration allowing the auto-connection
+	storeStack := assertstest.NewStoreStack("canonical", nil)
+	snapDecl, err := storeStack.Sign(asserts.SnapDeclarationType, map[string]interface{}{
+		"format":       "1",
+		"series":       "16",
+		"snap-id":      "consumerididididididididididididi",
+		"snap-name":    "consumer",
+		"publisher-id": "canonical",
+		"plugs": map[string]interface{}{
+			"camera": map[string]interface{}{
+				"allow-auto-connection": "true",
+			},
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, check.IsNil)
+	assertsFile := filepath.Join(dir, "decl.assert")
+	c.Assert(os.WriteFile(assertsFile, asserts.Encode(snapDecl), 0644), check.IsNil)
+
+	s.ResetStdStreams()
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"debug", "explain-connection", "--plug-snap", plugSnap, "--assertions", assertsFile, "consumer:cam", "system:camera"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Matches, `(?s).*
+auto-connection: allowed
+  rule: plug rule from the snap-declaration of "consumer"
+  decided by: allow-auto-connection, alternative 1
+.*
+Manual connections are not checked against the policy for snaps without a snap-declaration: core
+`)
+}
+
+func (s *SnapSuite) TestDebugExplainConnectionOfflineErrors(c *check.C) {
+	dir := c.MkDir()
+	plugSnap := filepath.Join(dir, "snap.yaml")
+	c.Assert(os.WriteFile(plugSnap, []byte("name: consumer\nversion: 1\nplugs:\n  cam:\n    interface: camera\n"), 0644), check.IsNil)
+
+	for _, t := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"consumer"}, `invalid plug "consumer:" \(want snap:plug\)`},
+		{[]string{"--assertions", plugSnap, "consumer:cam"}, `cannot decode assertions from .*`},
+		{[]string{"--slot-snap", plugSnap, "consumer:cam"}, `cannot explain connection offline without --plug-snap`},
+		{[]string{"--plug-snap", plugSnap, "other:cam"}, `cannot explain connection: plug snap is "consumer", not "other"`},
+		{[]string{"--plug-snap", plugSnap, "consumer:missing"}, `snap "consumer" has no plug named "missing"`},
+		{[]string{"--plug-snap", plugSnap, "consumer:cam", "producer:slot"}, `cannot explain connection offline without --slot-snap for snap "producer"`},
+		{[]string{"--plug-snap", plugSnap, "--slot-snap", plugSnap, "consumer:cam"}, `snap "consumer" has 0 "camera" interface slots, please specify the slot`},
+	} {
+		_, err := snap.Parser(snap.Client()).ParseArgs(append([]string{"debug", "explain-connection"}, t.args...))
+		c.Check(err, check.ErrorMatches, t.err, check.Commentf("%v", t.args))
+	}
+}
@@ -20,6 +20,7 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -63,7 +64,7 @@ type cmdDebugState struct {
 }
 
 var cmdDebugStateShortHelp = i18n.G("Inspect a snapd state file.")
-var cmdDebugStateLongHelp = i18n.G("Inspect a snapd state file, bypassing snapd API.")
+var cmdDebugStateLongHelp = i18n.G("Inspect a snapd state file, bypassing snapd API. The changes in the journal next to the state file, if any, are included.")
 
 type byChangeSpawnTime []*state.Change
 
@@ -75,13 +76,21 @@ func loadState(path string) (*state.State, error) {
 	if path == "" {
 		path = "state.json"
 	}
-	r, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("cannot read the state file: %s", err)
 	}
-	defer r.Close()
+	// snapd might have journaled changes it did not write to the
+	// state file yet
+	replayed, err := state.ReplayJournal(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the state file: %s", err)
+	}
+	if replayed != nil {
+		data = replayed
+	}
 
-	return state.ReadState(nil, r)
+	return state.ReadState(nil, bytes.NewReader(data))
 }
 
 func init() {
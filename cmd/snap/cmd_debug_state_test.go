@@ -20,6 +20,7 @@
 package main_test
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -28,6 +29,8 @@ import (
 	. "gopkg.in/check.v1"
 
 	main "github.com/snapcore/snapd/cmd/snap"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
 )
 
 var stateJSON = []byte(`
@@ -236,6 +239,36 @@ func (s *SnapSuite) TestDebugChanges(c *C) {
 	c.Check(s.Stderr(), Equals, "")
 }
 
+type journalBackend struct {
+	*state.Journal
+}
+
+func (journalBackend) EnsureBefore(d time.Duration) {}
+
+func (s *SnapSuite) TestDebugChangesJournaled(c *C) {
+	dir := c.MkDir()
+	stateFile := filepath.Join(dir, "test-state.json")
+	st, err := state.ReadState(journalBackend{state.NewJournal(stateFile)}, bytes.NewReader(stateJSON))
+	c.Assert(err, IsNil)
+	// the first checkpoint writes the state whole, the next ones go
+	// to the journal
+	for _, summary := range []string{"refresh b snap", "refresh c snap"} {
+		st.Lock()
+		st.NewChange("refresh-snap", summary)
+		st.Unlock()
+	}
+	c.Assert(stateFile, Not(testutil.FileContains), "refresh c snap")
+
+	rest, err := main.Parser(main.Client()).ParseArgs([]string{"debug", "state", "--abs-time", "--changes", stateFile})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Matches, `(?s).*install-snap +install a snap
+.*refresh-snap +refresh b snap
+.*refresh-snap +refresh c snap
+`)
+	c.Check(s.Stderr(), Equals, "")
+}
+
 func (s *SnapSuite) TestDebugChangesMissingState(c *C) {
 	_, err := main.Parser(main.Client()).ParseArgs([]string{"debug", "state", "--changes", "/missing-state.json"})
 	c.Check(err, ErrorMatches, "cannot read the state file: open /missing-state.json: no such file or directory")
@@ -20,6 +20,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"sort"
@@ -37,6 +38,7 @@ type cmdChangeTimings struct {
 	All        bool   `long:"all"`
 	StartupTag string `long:"startup" choice:"load-state" choice:"ifacemgr"`
 	Verbose    bool   `long:"verbose"`
+	Format     string `long:"format" default:"table" choice:"table" choice:"trace-event"`
 }
 
 func init() {
@@ -51,6 +53,8 @@ func init() {
 			"startup": i18n.G("Show timings for the startup of given subsystem (one of: load-state, ifacemgr)"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"verbose": i18n.G("Show more information"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"format": i18n.G("Output format (one of: table, trace-event); trace-event is the Chrome trace-event JSON format, as understood by Perfetto"),
 		}), changeIDMixinArgDesc)
 }
 
@@ -59,6 +63,7 @@ type Timing struct {
 	Label    string        `json:"label,omitempty"`
 	Summary  string        `json:"summary,omitempty"`
 	Duration time.Duration `json:"duration,omitempty"`
+	Start    time.Time     `json:"start,omitzero"`
 }
 
 func formatDuration(dur time.Duration) string {
@@ -205,6 +210,7 @@ type timingsData struct {
 	ChangeID       string        `json:"change-id"`
 	EnsureTimings  []Timing      `json:"ensure-timings,omitempty"`
 	StartupTimings []Timing      `json:"startup-timings,omitempty"`
+	StartTime      time.Time     `json:"start-time,omitzero"`
 	TotalDuration  time.Duration `json:"total-duration,omitempty"`
 	// ChangeTimings are indexed by task id
 	ChangeTimings map[string]changeTimings `json:"change-timings,omitempty"`
@@ -264,6 +270,10 @@ func (x *cmdChangeTimings) Execute(args []string) error {
 		return err
 	}
 
+	if x.Format == "trace-event" {
+		return x.writeTraceEvents(Stdout, timings)
+	}
+
 	w := tabWriter()
 	if x.Verbose {
 		fmt.Fprintf(w, "ID\tStatus\t%11s\t%11s\tLabel\tSummary\n", "Doing", "Undoing")
@@ -290,3 +300,135 @@ func (x *cmdChangeTimings) Execute(args []string) error {
 
 	return nil
 }
+
+// traceEvent is a single event of the Chrome trace-event format, with
+// the timestamp and duration in microseconds, see
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type traceEvent struct {
+	Name      string                 `json:"name"`
+	Cat       string                 `json:"cat,omitempty"`
+	Phase     string                 `json:"ph"`
+	Timestamp int64                  `json:"ts"`
+	Duration  int64                  `json:"dur,omitempty"`
+	Pid       int                    `json:"pid"`
+	Tid       int                    `json:"tid"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+}
+
+type traceEventsBuilder struct {
+	events  []traceEvent
+	lastTid int
+}
+
+// all the events come from snapd itself
+const traceEventPid = 1
+
+// thread adds a new track, named after the activity shown on it, and
+// returns its id.
+func (b *traceEventsBuilder) thread(name string) int {
+	b.lastTid++
+	b.events = append(b.events,
+		traceEvent{Name: "thread_name", Phase: "M", Pid: traceEventPid, Tid: b.lastTid, Args: map[string]interface{}{"name": name}},
+		traceEvent{Name: "thread_sort_index", Phase: "M", Pid: traceEventPid, Tid: b.lastTid, Args: map[string]interface{}{"sort_index": b.lastTid}})
+	return b.lastTid
+}
+
+func (b *traceEventsBuilder) span(tid int, cat, name string, start time.Time, dur time.Duration, args map[string]interface{}) {
+	b.events = append(b.events, traceEvent{
+		Name:      name,
+		Cat:       cat,
+		Phase:     "X",
+		Timestamp: start.UnixNano() / int64(time.Microsecond),
+		Duration:  int64(dur / time.Microsecond),
+		Pid:       traceEventPid,
+		Tid:       tid,
+		Args:      args,
+	})
+}
+
+// nested adds the spans measured within an activity. Their nesting is
+// conveyed by the times alone.
+func (b *traceEventsBuilder) nested(tid int, cat string, timings []Timing) {
+	for _, t := range timings {
+		if t.Start.IsZero() {
+			// recorded by a snapd that did not keep the start times
+			continue
+		}
+		spanCat := cat
+		if t.Label == "run-hook" {
+			spanCat = "hook"
+		}
+		b.span(tid, spanCat, t.Label, t.Start, t.Duration, map[string]interface{}{"summary": t.Summary})
+	}
+}
+
+// taskSpan returns the time span of the task. Tasks only record how long
+// their handlers ran and when they became ready, so any time spent waiting
+// in between runs is not accounted for.
+func taskSpan(t *changeTimings) (start time.Time, dur time.Duration, ok bool) {
+	dur = t.DoingTime + t.UndoingTime
+	if !t.ReadyTime.IsZero() {
+		return t.ReadyTime.Add(-dur), dur, true
+	}
+	// still running, it started at the latest with its first span
+	for _, timings := range [][]Timing{t.DoingTimings, t.UndoingTimings} {
+		for _, nested := range timings {
+			if !nested.Start.IsZero() && (start.IsZero() || nested.Start.Before(start)) {
+				start = nested.Start
+			}
+		}
+	}
+	return start, dur, !start.IsZero()
+}
+
+func (b *traceEventsBuilder) activity(cat, name string, td *timingsData, timings []Timing) {
+	if td.StartTime.IsZero() && len(timings) == 0 {
+		return
+	}
+	tid := b.thread(name)
+	if !td.StartTime.IsZero() {
+		b.span(tid, cat, name, td.StartTime, td.TotalDuration, nil)
+	}
+	b.nested(tid, cat, timings)
+}
+
+func (b *traceEventsBuilder) change(td *timingsData) {
+	for _, taskID := range sortTimingsTasks(td.ChangeTimings) {
+		t := td.ChangeTimings[taskID]
+		tid := b.thread(fmt.Sprintf("%s %s", taskID, t.Kind))
+		if start, dur, ok := taskSpan(&t); ok {
+			b.span(tid, "task", t.Kind, start, dur, map[string]interface{}{
+				"id":      taskID,
+				"status":  t.Status,
+				"lane":    t.Lane,
+				"summary": t.Summary,
+			})
+		}
+		b.nested(tid, "task", t.DoingTimings)
+		b.nested(tid, "task", t.UndoingTimings)
+	}
+}
+
+func (x *cmdChangeTimings) writeTraceEvents(w io.Writer, timings []*timingsData) error {
+	b := &traceEventsBuilder{
+		events: []traceEvent{{Name: "process_name", Phase: "M", Pid: traceEventPid, Args: map[string]interface{}{"name": "snapd"}}},
+	}
+	for _, td := range timings {
+		switch {
+		case x.StartupTag != "":
+			b.activity("startup", x.StartupTag, td, td.StartupTimings)
+		case x.EnsureTag != "":
+			b.activity("ensure", x.EnsureTag, td, td.EnsureTimings)
+		default:
+			// the ensure activity which created the change
+			b.activity("ensure", "ensure", td, td.EnsureTimings)
+		}
+		b.change(td)
+	}
+
+	enc := json.NewEncoder(w)
+	return enc.Encode(map[string]interface{}{
+		"traceEvents":     b.events,
+		"displayTimeUnit": "ms",
+	})
+}
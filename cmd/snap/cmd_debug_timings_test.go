@@ -20,6 +20,7 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -132,6 +133,9 @@ var timingsTests = []timingsCmdArgs{{
 		" ^                        8ms            -    baz summary\n" +
 		"ifacemgr                  9ms            -  \n" +
 		" ^                        9ms            -    baz summary\n\n",
+}, {
+	args:  "debug timings --format=foo 1",
+	error: "Invalid value `foo' for option `--format'. Allowed values are: table or trace-event",
 }, {
 	args: "debug timings 2",
 	stdout: "ID   Status        Doing      Undoing  Summary\n" +
@@ -196,6 +200,26 @@ func (s *SnapSuite) mockCmdTimingsAPI(c *C) {
 				{"change-id":"1", "change-timings":{
 					"41":{"undoing-time":210000000, "status": "Undone", "lane": 0, "ready-time": "2016-04-22T01:02:04Z", "kind": "baz", "summary": "lane 0 task bar summary"}
 				}}]}`)
+			case changeID == "3":
+				// with start times of the spans, and the ensure which created the change
+				fmt.Fprintln(w, `{"type":"sync","status-code":200,"status":"OK","result":[
+				{"change-id":"3",
+					"start-time": "2016-04-22T01:00:00Z",
+					"total-duration": 2000000,
+					"ensure-timings": [
+						{"label":"refresh-candidates", "summary": "query store", "duration": 1000000, "start": "2016-04-22T01:00:00.0005Z"}
+					],
+					"change-timings":{
+						"10":{"doing-time":1000000000, "status": "Done", "ready-time": "2016-04-22T01:00:02Z", "kind": "download-snap", "summary": "Download snap",
+							"doing-timings":[
+								{"label":"download", "summary": "download snap \"foo\"", "duration": 500000000, "start": "2016-04-22T01:00:01.2Z"},
+								{"level":1, "label":"old", "summary": "no start time", "duration": 1000000}
+							]},
+						"11":{"doing-time":300000000, "status": "Doing", "lane": 1, "kind": "run-hook", "summary": "Run install hook",
+							"doing-timings":[
+								{"label":"run-hook", "summary": "run hook \"install\" of snap \"foo\"", "duration": 200000000, "start": "2016-04-22T01:00:03Z"}
+							]}
+				}}]}`)
 			case ensure == "seed" && all == "false":
 				fmt.Fprintln(w, `{"type":"sync","status-code":200,"status":"OK","result":[
 					{"change-id":"1",
@@ -271,6 +295,54 @@ func (s *SnapSuite) mockCmdTimingsAPI(c *C) {
 	})
 }
 
+func (s *SnapSuite) TestGetDebugTimingsTraceEvent(c *C) {
+	s.mockCmdTimingsAPI(c)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"debug", "timings", "--format=trace-event", "3"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stderr(), Equals, "")
+
+	var trace map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &trace), IsNil)
+	c.Check(trace["displayTimeUnit"], Equals, "ms")
+
+	thread := func(tid int, name string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{"name": "thread_name", "ph": "M", "ts": 0.0, "pid": 1.0, "tid": float64(tid), "args": map[string]interface{}{"name": name}},
+			map[string]interface{}{"name": "thread_sort_index", "ph": "M", "ts": 0.0, "pid": 1.0, "tid": float64(tid), "args": map[string]interface{}{"sort_index": float64(tid)}},
+		}
+	}
+	span := func(tid int, cat, name string, ts, dur float64, args map[string]interface{}) map[string]interface{} {
+		ev := map[string]interface{}{"name": name, "cat": cat, "ph": "X", "ts": ts, "dur": dur, "pid": 1.0, "tid": float64(tid)}
+		if args != nil {
+			ev["args"] = args
+		}
+		return ev
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{"name": "process_name", "ph": "M", "ts": 0.0, "pid": 1.0, "tid": 0.0, "args": map[string]interface{}{"name": "snapd"}},
+	}
+	expected = append(expected, thread(1, "ensure")...)
+	expected = append(expected,
+		span(1, "ensure", "ensure", 1461286800000000, 2000, nil),
+		span(1, "ensure", "refresh-candidates", 1461286800000500, 1000, map[string]interface{}{"summary": "query store"}))
+	// tasks are sorted as in the table
+	expected = append(expected, thread(2, "11 run-hook")...)
+	expected = append(expected,
+		// still running, starts with its first span
+		span(2, "task", "run-hook", 1461286803000000, 300000, map[string]interface{}{
+			"id": "11", "status": "Doing", "lane": 1.0, "summary": "Run install hook"}),
+		span(2, "hook", "run-hook", 1461286803000000, 200000, map[string]interface{}{"summary": `run hook "install" of snap "foo"`}))
+	expected = append(expected, thread(3, "10 download-snap")...)
+	expected = append(expected,
+		span(3, "task", "download-snap", 1461286801000000, 1000000, map[string]interface{}{
+			"id": "10", "status": "Done", "lane": 0.0, "summary": "Download snap"}),
+		// the span without start time is omitted
+		span(3, "task", "download", 1461286801200000, 500000, map[string]interface{}{"summary": `download snap "foo"`}))
+	c.Check(trace["traceEvents"], DeepEquals, expected)
+}
+
 type TaskDef struct {
 	TaskID    string
 	Lane      int
@@ -210,17 +210,19 @@ var helpCategories = []helpCategory{
 		Description: i18n.G("slightly more advanced snap management"),
 		Commands:    []string{"refresh", "revert", "switch", "disable", "enable", "create-cohort"},
 	}, {
-		Label:       i18n.G("History"),
-		Description: i18n.G("manage system change transactions"),
-		Commands:    []string{"changes", "tasks", "abort", "watch"},
+		Label:           i18n.G("History"),
+		Description:     i18n.G("manage system change transactions"),
+		Commands:        []string{"changes", "tasks", "abort", "watch"},
+		AllOnlyCommands: []string{"pause", "resume", "retry"},
 	}, {
 		Label:       i18n.G("Daemons"),
 		Description: i18n.G("manage services"),
 		Commands:    []string{"services", "start", "stop", "restart", "logs"},
 	}, {
-		Label:       i18n.G("Permissions"),
-		Description: i18n.G("manage permissions"),
-		Commands:    []string{"connections", "interface", "connect", "disconnect"},
+		Label:           i18n.G("Permissions"),
+		Description:     i18n.G("manage permissions"),
+		Commands:        []string{"connections", "interface", "connect", "disconnect"},
+		AllOnlyCommands: []string{"prompting-rules"},
 	}, {
 		Label:       i18n.G("Configuration"),
 		Description: i18n.G("system administration and configuration"),
@@ -239,9 +241,10 @@ var helpCategories = []helpCategory{
 		Commands:        []string{"saved", "save", "check-snapshot", "restore", "forget"},
 		AllOnlyCommands: []string{"export-snapshot", "import-snapshot"},
 	}, {
-		Label:       i18n.G("Device"),
-		Description: i18n.G("manage device"),
-		Commands:    []string{"model", "remodel", "reboot", "recovery"},
+		Label:           i18n.G("Device"),
+		Description:     i18n.G("manage device"),
+		Commands:        []string{"model", "remodel", "reboot", "recovery"},
+		AllOnlyCommands: []string{"manifest"},
 	}, {
 		Label:       i18n.G("Warnings"),
 		Other:       true,
@@ -257,7 +260,7 @@ var helpCategories = []helpCategory{
 		Other:           true,
 		Description:     i18n.G("introspection and debugging of snapd"),
 		Commands:        []string{"version"},
-		AllOnlyCommands: []string{"debug"},
+		AllOnlyCommands: []string{"debug", "notices"},
 	}, {
 		Label:           i18n.G("Development"),
 		Description:     i18n.G("developer-oriented features"),
@@ -49,6 +49,7 @@ type infoCmd struct {
 	clientMixin
 	colorMixin
 	timeMixin
+	formatMixin
 
 	Verbose    bool `long:"verbose"`
 	Positional struct {
@@ -72,7 +73,7 @@ func init() {
 		longInfoHelp,
 		func() flags.Commander {
 			return &infoCmd{}
-		}, colorDescs.also(timeDescs).also(map[string]string{
+		}, colorDescs.also(timeDescs).also(formatDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"verbose": i18n.G("Include more details on the snap (expanded notes, base, etc.)"),
 		}), nil)
@@ -618,7 +619,48 @@ func (chInfos *channelInfos) dump(w io.Writer) {
 	}
 }
 
+// infoResult is what is written about each snap when an output format is
+// requested, as the snap found in the given file or, otherwise, as
+// installed and as found in the store.
+type infoResult struct {
+	Name  string       `json:"name"`
+	Path  string       `json:"path,omitempty"`
+	File  *client.Snap `json:"file,omitempty"`
+	Local *client.Snap `json:"local,omitempty"`
+	Store *client.Snap `json:"store,omitempty"`
+}
+
+func (x *infoCmd) writeFormattedInfo() error {
+	results := make([]*infoResult, 0, len(x.Positional.Snaps))
+	for _, snapName := range x.Positional.Snaps {
+		snapName := string(snapName)
+		res := &infoResult{Name: snapName}
+		if diskSnap, err := clientSnapFromPath(snapName); err == nil {
+			res.Name, res.Path, res.File = diskSnap.Name, norm(snapName), diskSnap
+		} else if snapName != "system" {
+			res.Store, _, _ = x.client.FindOne(snap.InstanceSnap(snapName))
+			res.Local, _, _ = x.client.Snap(snapName)
+		}
+		if res.File == nil && res.Local == nil && res.Store == nil {
+			if len(x.Positional.Snaps) == 1 {
+				return fmt.Errorf("no snap found for %q", snapName)
+			}
+			fmt.Fprintf(Stderr, i18n.G("warning: no snap found for %q\n"), snapName)
+			continue
+		}
+		results = append(results, res)
+	}
+	if len(results) == 0 {
+		return errors.New(i18n.G("no valid snaps given"))
+	}
+	return x.writeFormatted(results)
+}
+
 func (x *infoCmd) Execute([]string) error {
+	if x.formatted() {
+		return x.writeFormattedInfo()
+	}
+
 	termWidth, _ := termSize()
 	termWidth -= 3
 	if termWidth > 100 {
@@ -835,6 +835,25 @@ installed:    2.10 (1) 1kB disabled,blocked
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *infoSuite) TestInfoFormat(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/find" && r.URL.Query().Get("name") == "hello":
+			fmt.Fprint(w, mockInfoJSON)
+		case r.URL.Path == "/v2/snaps/hello":
+			fmt.Fprint(w, mockInfoJSONNoLicense)
+		default:
+			w.WriteHeader(404)
+			fmt.Fprintln(w, `{"type":"error","status-code":404,"status":"Not Found","result":{"message":"No.","kind":"snap-not-found"}}`)
+		}
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"info", "--format", "go-template={{range .}}{{.Name}}: {{.Local.Revision}} {{.Store.Summary}}\n{{end}}", "hello", "x"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, "hello: 100 The GNU Hello snap\n")
+	c.Check(s.Stderr(), check.Equals, "warning: no snap found for \"x\"\n")
+}
+
 func (s *infoSuite) TestInfoNotFound(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
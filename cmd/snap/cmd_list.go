@@ -48,11 +48,12 @@ type cmdList struct {
 
 	All bool `long:"all"`
 	colorMixin
+	formatMixin
 }
 
 func init() {
 	addCommand("list", shortListHelp, longListHelp, func() flags.Commander { return &cmdList{} },
-		colorDescs.also(map[string]string{
+		colorDescs.also(formatDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"all": i18n.G("Show all revisions"),
 		}), nil)
@@ -101,6 +102,9 @@ func (x *cmdList) Execute(args []string) error {
 	snaps, err := x.client.List(names, &client.ListOptions{All: x.All})
 	if err != nil {
 		if err == client.ErrNoSnapsInstalled {
+			if len(names) == 0 && x.formatted() {
+				return x.writeFormatted([]*client.Snap{})
+			}
 			if len(names) == 0 {
 				fmt.Fprintln(Stderr, i18n.G("No snaps are installed yet. Try 'snap install hello-world'."))
 				return nil
@@ -113,6 +117,9 @@ func (x *cmdList) Execute(args []string) error {
 		return ErrNoMatchingSnaps
 	}
 	sort.Sort(snapsByName(snaps))
+	if x.formatted() {
+		return x.writeFormatted(snaps)
+	}
 
 	esc := x.getEscapes()
 	w := tabWriter()
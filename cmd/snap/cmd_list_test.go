@@ -20,12 +20,16 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/client"
 	snap "github.com/snapcore/snapd/cmd/snap"
+	snaplib "github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
 )
 
 func (s *SnapSuite) TestListHelp(c *check.C) {
@@ -43,6 +47,8 @@ indicates that the publisher has been verified.
                                       some things. (default: auto)
       --unicode=[auto|never|always]   Use a little bit of Unicode to improve
                                       legibility. (default: auto)
+      --format=                       Write the output as json, yaml or with a
+                                      Go template (go-template=<template>)
 `
 	s.testSubCommandHelp(c, "list", msg)
 }
@@ -82,6 +88,57 @@ foo   4.2      17   potatoes  bar        blocked
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *SnapSuite) mockListOne(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "foo", "version": "4.2", "revision": 17, "tracking-channel": "potatoes"}]}`)
+	})
+}
+
+func (s *SnapSuite) TestListFormatJSON(c *check.C) {
+	s.mockListOne(c)
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--format=json"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	var snaps []*client.Snap
+	c.Assert(json.Unmarshal([]byte(s.Stdout()), &snaps), check.IsNil)
+	c.Assert(snaps, check.HasLen, 1)
+	c.Check(snaps[0].Name, check.Equals, "foo")
+	c.Check(snaps[0].Revision, check.Equals, snaplib.R(17))
+	c.Check(snaps[0].TrackingChannel, check.Equals, "potatoes")
+	c.Check(s.Stdout(), testutil.Contains, "[\n  {\n    \"id\": \"\",\n")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestListFormatYAML(c *check.C) {
+	s.mockListOne(c)
+	// the global option can be given before the command
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"--format=yaml", "list"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), testutil.Contains, "- ")
+	c.Check(s.Stdout(), testutil.Contains, "\n  name: foo\n")
+	c.Check(s.Stdout(), testutil.Contains, "\n  revision: \"17\"\n")
+	c.Check(s.Stdout(), testutil.Contains, "\n  tracking-channel: potatoes\n")
+}
+
+func (s *SnapSuite) TestListFormatTemplate(c *check.C) {
+	s.mockListOne(c)
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--format", `go-template={{range .}}{{.Name}} {{.Revision}}{{end}}`})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "foo 17\n")
+}
+
+func (s *SnapSuite) TestListFormatNoSnaps(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--format=json"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "[]\n")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
 func (s *SnapSuite) TestListAll(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
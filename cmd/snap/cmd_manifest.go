@@ -0,0 +1,190 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+	"gopkg.in/yaml.v3"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdManifest struct{}
+
+var shortManifestHelp = i18n.G("Export or apply system manifests")
+var longManifestHelp = i18n.G(`
+The manifest command contains sub-commands to export the snaps installed on
+the system, and how they are set up, to a manifest, and to make a system
+match such a manifest.
+`)
+
+var (
+	shortManifestExportHelp = i18n.G("Export the manifest of the system")
+	longManifestExportHelp  = i18n.G(`
+The manifest export command writes to standard output, in YAML format, the
+snaps installed on the system along with their channel, revision, cohort and
+refresh hold, configuration and aliases, as well as the interface connections
+made or removed manually, quota groups and validation sets.
+
+Gadget and kernel snaps are not part of the manifest.
+`)
+
+	shortManifestApplyHelp = i18n.G("Make the system match a manifest")
+	longManifestApplyHelp  = i18n.G(`
+The manifest apply command installs, refreshes and sets up snaps, connects
+and disconnects interfaces, and creates or updates quota groups and
+validation sets so that the system matches the given manifest, as written by
+'snap manifest export'. What the manifest does not mention is left alone.
+
+Setting up snaps that are yet to be installed is skipped; applying the
+manifest again once they are installed takes care of it.
+`)
+)
+
+type cmdManifestExport struct {
+	clientMixin
+}
+
+type cmdManifestApply struct {
+	waitMixin
+	DryRun     bool `long:"dry-run"`
+	Positional struct {
+		Filename flags.Filename
+	} `positional-args:"true" required:"true"`
+}
+
+func init() {
+	addManifestCommand("export", shortManifestExportHelp, longManifestExportHelp, func() flags.Commander {
+		return &cmdManifestExport{}
+	}, nil, nil)
+	addManifestCommand("apply", shortManifestApplyHelp, longManifestApplyHelp, func() flags.Commander {
+		return &cmdManifestApply{}
+	}, waitDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"dry-run": i18n.G("Only print what would be done"),
+	}), []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<manifest file>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("Manifest file to apply, or - for standard input"),
+	}})
+}
+
+func (x *cmdManifest) Execute(args []string) error {
+	return flags.ErrHelp
+}
+
+func (x *cmdManifestExport) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	m, err := x.client.Manifest()
+	if err != nil {
+		return err
+	}
+	for _, sn := range m.Snaps {
+		withoutJSONNumbers(sn.Config)
+	}
+	for _, q := range m.Quotas {
+		withoutJSONNumbers(q.Limits)
+	}
+
+	enc := yaml.NewEncoder(Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("cannot write manifest: %v", err)
+	}
+	return enc.Close()
+}
+
+func readManifest(filename string) (*client.Manifest, error) {
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot read manifest: %v"), err)
+	}
+	var m client.Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot parse manifest: %v"), err)
+	}
+	return &m, nil
+}
+
+func printSkipped(skipped []string) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintln(Stdout, i18n.G("Skipped:"))
+	for _, s := range skipped {
+		fmt.Fprintf(Stdout, "  %s\n", s)
+	}
+}
+
+func (x *cmdManifestApply) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	m, err := readManifest(string(x.Positional.Filename))
+	if err != nil {
+		return err
+	}
+
+	changeID, plan, err := x.client.ApplyManifest(m, &client.ApplyManifestOptions{DryRun: x.DryRun})
+	if err != nil {
+		return err
+	}
+
+	if x.DryRun {
+		if len(plan.Actions) == 0 {
+			fmt.Fprintln(Stdout, i18n.G("Nothing to do."))
+		}
+		for _, action := range plan.Actions {
+			fmt.Fprintln(Stdout, action)
+		}
+		printSkipped(plan.Skipped)
+		return nil
+	}
+
+	if _, err := x.wait(changeID); err != nil {
+		if err == noWait {
+			return nil
+		}
+		return err
+	}
+	if len(plan.Actions) == 0 {
+		fmt.Fprintln(Stdout, i18n.G("Nothing to do."))
+	} else {
+		fmt.Fprintln(Stdout, i18n.G("Manifest applied."))
+	}
+	printSkipped(plan.Skipped)
+	return nil
+}
@@ -0,0 +1,193 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+const manifestYAML = `snaps:
+  - name: foo
+    channel: latest/stable
+    revision: "7"
+    hold: forever
+    config:
+      bar:
+        baz: 1.5
+      count: 2
+connections:
+  - plug: foo:home
+    slot: core:home
+    disconnected: true
+quotas:
+  - name: grp
+    snaps:
+      - foo
+    limits:
+      memory: 1048576
+validation-sets:
+  - account-id: acc
+    name: set
+    mode: enforce
+    sequence: 3
+`
+
+func (s *SnapSuite) TestManifestExport(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/manifest")
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+			"snaps": [{"name": "foo", "channel": "latest/stable", "revision": "7", "hold": "forever", "config": {"bar": {"baz": 1.5}, "count": 2}}],
+			"connections": [{"plug": "foo:home", "slot": "core:home", "disconnected": true}],
+			"quotas": [{"name": "grp", "snaps": ["foo"], "limits": {"memory": 1048576}}],
+			"validation-sets": [{"account-id": "acc", "name": "set", "mode": "enforce", "sequence": 3}]
+		}}`)
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"manifest", "export"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, manifestYAML)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestManifestApply(c *check.C) {
+	manifestPath := filepath.Join(c.MkDir(), "system.yaml")
+	c.Assert(os.WriteFile(manifestPath, []byte(manifestYAML), 0644), check.IsNil)
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/manifest")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+				"action": "apply",
+				"manifest": map[string]interface{}{
+					"snaps": []interface{}{map[string]interface{}{
+						"name":     "foo",
+						"channel":  "latest/stable",
+						"revision": "7",
+						"hold":     "forever",
+						"config": map[string]interface{}{
+							"bar":   map[string]interface{}{"baz": json.Number("1.5")},
+							"count": json.Number("2"),
+						},
+					}},
+					"connections": []interface{}{map[string]interface{}{
+						"plug":         "foo:home",
+						"slot":         "core:home",
+						"disconnected": true,
+					}},
+					"quotas": []interface{}{map[string]interface{}{
+						"name":   "grp",
+						"snaps":  []interface{}{"foo"},
+						"limits": map[string]interface{}{"memory": json.Number("1048576")},
+					}},
+					"validation-sets": []interface{}{map[string]interface{}{
+						"account-id": "acc",
+						"name":       "set",
+						"mode":       "enforce",
+						"sequence":   json.Number("3"),
+					}},
+				},
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type": "async", "change": "42", "status-code": 202, "result": {
+				"actions": ["Refresh \"foo\" at revision 7"],
+				"skipped": ["Connect foo:home to core:home: snap \"foo\" is not installed"]
+			}}`)
+		case 2:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done"}}`)
+		default:
+			c.Errorf("expected 2 queries, currently on %d", n)
+		}
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"manifest", "apply", manifestPath})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(n, check.Equals, 2)
+	c.Check(s.Stdout(), check.Equals, `Manifest applied.
+Skipped:
+  Connect foo:home to core:home: snap "foo" is not installed
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestManifestApplyDryRunStdin(c *check.C) {
+	s.stdin.WriteString(manifestYAML)
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/manifest")
+		body := DecodedRequestBody(c, r)
+		c.Check(body["dry-run"], check.Equals, true)
+		c.Check(body["manifest"], check.NotNil)
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+			"actions": ["Enforce validation set acc/set=3", "Refresh \"foo\" at revision 7"],
+			"skipped": ["Create quota group \"grp\": snap \"foo\" is not installed"]
+		}}`)
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"manifest", "apply", "--dry-run", "-"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, `Enforce validation set acc/set=3
+Refresh "foo" at revision 7
+Skipped:
+  Create quota group "grp": snap "foo" is not installed
+`)
+}
+
+func (s *SnapSuite) TestManifestApplyNothingToDo(c *check.C) {
+	s.stdin.WriteString("snaps: [{name: foo}]\n")
+
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": {}}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"manifest", "apply", "--dry-run", "-"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "Nothing to do.\n")
+}
+
+func (s *SnapSuite) TestManifestApplyBadFile(c *check.C) {
+	manifestPath := filepath.Join(c.MkDir(), "system.yaml")
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"manifest", "apply", manifestPath})
+	c.Check(err, check.ErrorMatches, "cannot read manifest: .*no such file or directory")
+
+	c.Assert(os.WriteFile(manifestPath, []byte("snaps: {"), 0644), check.IsNil)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"manifest", "apply", manifestPath})
+	c.Check(err, check.ErrorMatches, "cannot parse manifest: .*")
+}
@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdNotices struct {
+	clientMixin
+	timeMixin
+	Types    []string `long:"type"`
+	Keys     []string `long:"key"`
+	AllUsers bool     `long:"all-users"`
+	Follow   bool     `long:"follow"`
+}
+
+var shortNoticesHelp = i18n.G("List notices")
+var longNoticesHelp = i18n.G(`
+The notices command lists the notices recorded by snapd, such as those about
+changes being updated or snaps being inhibited from refreshing, from the
+least to the most recently repeated.
+
+With --follow, the notices that occur afterwards are listed as well, as they
+occur, until the command is interrupted.
+`)
+
+func init() {
+	addCommand("notices", shortNoticesHelp, longNoticesHelp, func() flags.Commander { return &cmdNotices{} }, timeDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"type": i18n.G("Only list notices of this type (can be repeated)"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"key": i18n.G("Only list notices with this key (can be repeated)"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"all-users": i18n.G("List the notices of all users (requires root)"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"follow": i18n.G("Wait for new notices and list them as they occur"),
+	}), nil)
+}
+
+func (cmd *cmdNotices) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	opts := &client.NoticesOptions{
+		Keys:     cmd.Keys,
+		AllUsers: cmd.AllUsers,
+	}
+	for _, t := range cmd.Types {
+		opts.Types = append(opts.Types, client.NoticeType(t))
+	}
+
+	if cmd.Follow {
+		notices, err := cmd.client.WatchNotices(context.Background(), opts)
+		if err != nil {
+			return err
+		}
+		w := tabWriter()
+		cmd.writeHeader(w)
+		for notice := range notices {
+			cmd.writeNotice(w, &notice)
+			w.Flush()
+		}
+		return nil
+	}
+
+	notices, err := cmd.client.Notices(opts)
+	if err != nil {
+		return err
+	}
+	if len(notices) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No notices."))
+		return nil
+	}
+	w := tabWriter()
+	cmd.writeHeader(w)
+	for _, notice := range notices {
+		cmd.writeNotice(w, notice)
+	}
+	w.Flush()
+	return nil
+}
+
+func (cmd *cmdNotices) writeHeader(w *tabwriter.Writer) {
+	fmt.Fprintln(w, i18n.G("ID\tType\tKey\tOccurrences\tLast repeated"))
+}
+
+func (cmd *cmdNotices) writeNotice(w *tabwriter.Writer, notice *client.Notice) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", notice.ID, notice.Type, notice.Key, notice.Occurrences, cmd.fmtTime(notice.LastRepeated))
+}
@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+const mockNoticesJSON = `{"type": "sync", "result": [
+  {"id": "1", "type": "change-update", "key": "42", "occurrences": 3, "last-repeated": "2026-01-02T03:04:05Z"},
+  {"id": "2", "type": "refresh-inhibit", "key": "-", "occurrences": 1, "last-repeated": "2026-01-02T03:04:06Z"}
+]}`
+
+func (s *SnapSuite) TestNotices(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/notices")
+			c.Check(r.URL.Query(), check.DeepEquals, url.Values{
+				"types": {"change-update,refresh-inhibit"},
+				"keys":  {"42,-"},
+				"users": {"all"},
+			})
+			fmt.Fprintln(w, mockNoticesJSON)
+		default:
+			c.Errorf("expected 1 query, currently on %d", n)
+		}
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"notices", "--abs-time", "--type=change-update", "--type=refresh-inhibit", "--key=42", "--key=-", "--all-users"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `
+ID   Type             Key  Occurrences  Last repeated
+1    change-update    42   3            2026-01-02T03:04:05Z
+2    refresh-inhibit  -    1            2026-01-02T03:04:06Z
+`[1:])
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(n, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestNoticesNone(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"notices"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "No notices.\n")
+}
+
+func (s *SnapSuite) TestNoticesFollow(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/notices")
+		c.Check(r.URL.Query(), check.DeepEquals, url.Values{"follow": {"true"}})
+		switch n {
+		case 1:
+			c.Check(r.Header.Get("Last-Event-ID"), check.Equals, "")
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, `id: 2026-01-02T03:04:05Z
+event: notice
+data: {"id": "1", "type": "change-update", "key": "42", "occurrences": 1, "last-repeated": "2026-01-02T03:04:05Z"}
+
+`)
+		case 2:
+			// resuming after the stream ended
+			c.Check(r.Header.Get("Last-Event-ID"), check.Equals, "2026-01-02T03:04:05Z")
+			w.WriteHeader(403)
+			fmt.Fprintln(w, `{"type": "error", "status-code": 403, "result": {"message": "access denied"}}`)
+		default:
+			c.Errorf("expected 2 queries, currently on %d", n)
+		}
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"notices", "--follow", "--abs-time"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `
+ID   Type           Key  Occurrences  Last repeated
+1    change-update  42   1            2026-01-02T03:04:05Z
+`[1:])
+	c.Check(n, check.Equals, 2)
+}
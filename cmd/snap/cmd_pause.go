@@ -0,0 +1,101 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdPause struct{ changeIDMixin }
+
+type cmdResume struct{ changeIDMixin }
+
+var shortPauseHelp = i18n.G("Pause a pending change")
+
+var longPauseHelp = i18n.G(`
+The pause command holds a change that still has pending tasks: the tasks
+already running go on until they are done, but no other task of the change
+is started until it is resumed with 'snap resume'.
+
+Tasks of a paused change that fail do not cause the change to be undone until
+it is resumed, so that it can be retried with 'snap retry' instead.
+`)
+
+var shortResumeHelp = i18n.G("Resume a paused change")
+
+var longResumeHelp = i18n.G(`
+The resume command continues a change that was paused with 'snap pause'. If
+tasks of the change failed in the meantime, the change is undone.
+`)
+
+func init() {
+	addCommand("pause",
+		shortPauseHelp,
+		longPauseHelp,
+		func() flags.Commander {
+			return &cmdPause{}
+		},
+		changeIDMixinOptDesc,
+		changeIDMixinArgDesc,
+	)
+	addCommand("resume",
+		shortResumeHelp,
+		longResumeHelp,
+		func() flags.Commander {
+			return &cmdResume{}
+		},
+		changeIDMixinOptDesc,
+		changeIDMixinArgDesc,
+	)
+}
+
+func (x *cmdPause) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	id, err := x.GetChangeID()
+	if err != nil {
+		if err == noChangeFoundOK {
+			return nil
+		}
+		return err
+	}
+	_, err = x.client.Pause(id)
+	return err
+}
+
+func (x *cmdResume) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	id, err := x.GetChangeID()
+	if err != nil {
+		if err == noChangeFoundOK {
+			return nil
+		}
+		return err
+	}
+	_, err = x.client.Resume(id)
+	return err
+}
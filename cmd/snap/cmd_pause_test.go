@@ -0,0 +1,92 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) testChangeAction(c *check.C, action string) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{"action": action})
+			fmt.Fprintln(w, mockChangeJSON)
+		default:
+			c.Errorf("expected 1 query, currently on %d", n)
+		}
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{action, "42"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "")
+
+	c.Assert(n, check.Equals, 1)
+}
+
+func (s *SnapSuite) TestPause(c *check.C) {
+	s.testChangeAction(c, "pause")
+}
+
+func (s *SnapSuite) TestResume(c *check.C) {
+	s.testChangeAction(c, "resume")
+}
+
+func (s *SnapSuite) TestPauseLast(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes")
+			fmt.Fprintln(w, mockChangesJSON)
+		case 2:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/two")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{"action": "pause"})
+			fmt.Fprintln(w, mockChangeJSON)
+		default:
+			c.Errorf("expected 2 queries, currently on %d", n)
+		}
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"pause", "--last=install"})
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 2)
+}
+
+func (s *SnapSuite) TestResumeError(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		fmt.Fprintln(w, `{"type": "error", "result": {"message": "cannot resume change 42 with nothing pending"}, "status-code": 400}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"resume", "42"})
+	c.Assert(err, check.ErrorMatches, "cannot resume change 42 with nothing pending")
+}
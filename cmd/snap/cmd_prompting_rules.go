@@ -0,0 +1,345 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdPromptingRules struct{}
+
+var shortPromptingRulesHelp = i18n.G("Manage the rules answering permission prompts")
+var longPromptingRulesHelp = i18n.G(`
+The prompting-rules command contains sub-commands to list, add, remove, export
+and import the rules which determine how requests made by snaps through interfaces which
+support prompting are handled without asking the user.
+
+Rules added with --all-users are defined by an administrator, apply to every
+user and take precedence over the rules of the users themselves.
+`)
+
+var (
+	shortPromptingRulesListHelp = i18n.G("List prompting rules")
+	longPromptingRulesListHelp  = i18n.G(`
+The prompting-rules list command lists the prompting rules which apply to the
+calling user, including those defined by an administrator for all users. With
+--all-users, only the latter are listed.
+`)
+
+	shortPromptingRulesAddHelp = i18n.G("Add a prompting rule")
+	longPromptingRulesAddHelp  = i18n.G(`
+The prompting-rules add command adds a rule determining the outcome of
+requests made by the given snap through the given interface, for the given
+permissions on paths matching the given path pattern.
+
+Unless a lifespan is given, the rule applies forever, or for the given
+duration if there is one.
+`)
+
+	shortPromptingRulesRemoveHelp = i18n.G("Remove a prompting rule")
+	longPromptingRulesRemoveHelp  = i18n.G(`
+The prompting-rules remove command removes the prompting rule with the given
+ID. Rules defined for all users can only be removed with --all-users.
+`)
+
+	shortPromptingRulesExportHelp = i18n.G("Export prompting rules")
+	longPromptingRulesExportHelp  = i18n.G(`
+The prompting-rules export command writes the prompting rules of the calling
+user, or with --all-users those defined for all users, to standard output as
+JSON which can be imported again with prompting-rules import.
+`)
+
+	shortPromptingRulesImportHelp = i18n.G("Import prompting rules")
+	longPromptingRulesImportHelp  = i18n.G(`
+The prompting-rules import command imports prompting rules from the given file,
+or from standard input if the file is "-", as written by prompting-rules export.
+
+Either all the rules are imported, or none of them are if any rule is invalid
+or conflicts with an existing rule.
+`)
+)
+
+type promptingAllUsersMixin struct {
+	AllUsers bool `long:"all-users"`
+}
+
+var promptingAllUsersDescs = mixinDescs{
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"all-users": i18n.G("Act on the rules defined by an administrator for all users"),
+}
+
+func (x promptingAllUsersMixin) options() *client.PromptingRulesOptions {
+	return &client.PromptingRulesOptions{AllUsers: x.AllUsers}
+}
+
+type cmdPromptingRulesList struct {
+	clientMixin
+	promptingAllUsersMixin
+	Snap      string `long:"snap"`
+	Interface string `long:"interface"`
+}
+
+type cmdPromptingRulesAdd struct {
+	clientMixin
+	promptingAllUsersMixin
+	Outcome    string `long:"outcome" default:"allow" choice:"allow" choice:"deny"`
+	Lifespan   string `long:"lifespan" choice:"forever" choice:"timespan"`
+	Duration   string `long:"duration"`
+	Positional struct {
+		Snap        string   `positional-arg-name:"<snap>"`
+		Interface   string   `positional-arg-name:"<interface>"`
+		PathPattern string   `positional-arg-name:"<path-pattern>"`
+		Permissions []string `positional-arg-name:"<permission>" required:"1"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+type cmdPromptingRulesRemove struct {
+	clientMixin
+	promptingAllUsersMixin
+	Positional struct {
+		ID string `positional-arg-name:"<rule-id>"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+type cmdPromptingRulesExport struct {
+	clientMixin
+	promptingAllUsersMixin
+	Snap      string `long:"snap"`
+	Interface string `long:"interface"`
+}
+
+type cmdPromptingRulesImport struct {
+	clientMixin
+	promptingAllUsersMixin
+	Positional struct {
+		Filename string `positional-arg-name:"<file>"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+func init() {
+	addPromptingRulesCommand("list", shortPromptingRulesListHelp, longPromptingRulesListHelp, func() flags.Commander {
+		return &cmdPromptingRulesList{}
+	}, promptingAllUsersDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"snap": i18n.G("Only list the rules of the given snap"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"interface": i18n.G("Only list the rules of the given interface"),
+	}), nil)
+	addPromptingRulesCommand("add", shortPromptingRulesAddHelp, longPromptingRulesAddHelp, func() flags.Commander {
+		return &cmdPromptingRulesAdd{}
+	}, promptingAllUsersDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"outcome": i18n.G("Whether to allow or deny the requests"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"lifespan": i18n.G("How long the rule applies for"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"duration": i18n.G("Duration of a rule with a timespan lifespan, e.g. 1h30m"),
+	}), []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<snap>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("Snap the rule applies to"),
+	}, {
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<interface>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("Interface the rule applies to"),
+	}, {
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<path-pattern>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("Pattern of the paths the rule applies to"),
+	}, {
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<permission>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("Permission the rule applies to, e.g. read"),
+	}})
+	addPromptingRulesCommand("remove", shortPromptingRulesRemoveHelp, longPromptingRulesRemoveHelp, func() flags.Commander {
+		return &cmdPromptingRulesRemove{}
+	}, promptingAllUsersDescs, []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<rule-id>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("ID of the rule to remove"),
+	}})
+	addPromptingRulesCommand("export", shortPromptingRulesExportHelp, longPromptingRulesExportHelp, func() flags.Commander {
+		return &cmdPromptingRulesExport{}
+	}, promptingAllUsersDescs.also(map[string]string{
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"snap": i18n.G("Only export the rules of the given snap"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		"interface": i18n.G("Only export the rules of the given interface"),
+	}), nil)
+	addPromptingRulesCommand("import", shortPromptingRulesImportHelp, longPromptingRulesImportHelp, func() flags.Commander {
+		return &cmdPromptingRulesImport{}
+	}, promptingAllUsersDescs, []argDesc{{
+		// TRANSLATORS: This needs to begin with < and end with >
+		name: i18n.G("<file>"),
+		// TRANSLATORS: This should not start with a lowercase letter.
+		desc: i18n.G("File to import the rules from, or - for standard input"),
+	}})
+}
+
+func (x *cmdPromptingRules) Execute(args []string) error {
+	return flags.ErrHelp
+}
+
+func (x *cmdPromptingRulesList) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	opts := x.options()
+	opts.Snap = x.Snap
+	opts.Interface = x.Interface
+	rules, err := x.client.PromptingRules(opts)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No prompting rules."))
+		return nil
+	}
+
+	w := tabWriter()
+	fmt.Fprintln(w, i18n.G("ID\tSnap\tInterface\tOrigin\tPath pattern\tPermissions\tHits"))
+	for _, rule := range rules {
+		var pathPattern string
+		var perms []string
+		if rule.Constraints != nil {
+			pathPattern = rule.Constraints.PathPattern
+			for perm, entry := range rule.Constraints.Permissions {
+				perms = append(perms, fmt.Sprintf("%s:%s", perm, entry.Outcome))
+			}
+		}
+		sort.Strings(perms)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n", rule.ID, rule.Snap, rule.Interface, rule.Origin, pathPattern, strings.Join(perms, ","), rule.HitCount)
+	}
+	w.Flush()
+	return nil
+}
+
+func (x *cmdPromptingRulesAdd) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	lifespan := x.Lifespan
+	if lifespan == "" {
+		lifespan = "forever"
+		if x.Duration != "" {
+			lifespan = "timespan"
+		}
+	}
+	constraints := &client.PromptingConstraints{
+		PathPattern: x.Positional.PathPattern,
+		Permissions: make(map[string]*client.PromptingPermissionEntry, len(x.Positional.Permissions)),
+	}
+	for _, perm := range x.Positional.Permissions {
+		constraints.Permissions[perm] = &client.PromptingPermissionEntry{
+			Outcome:  x.Outcome,
+			Lifespan: lifespan,
+			Duration: x.Duration,
+		}
+	}
+
+	rule, err := x.client.AddPromptingRule(x.Positional.Snap, x.Positional.Interface, constraints, x.options())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(Stdout, i18n.G("Added prompting rule %s.\n"), rule.ID)
+	return nil
+}
+
+func (x *cmdPromptingRulesRemove) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	rule, err := x.client.RemovePromptingRule(x.Positional.ID, x.options())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(Stdout, i18n.G("Removed prompting rule %s.\n"), rule.ID)
+	return nil
+}
+
+func (x *cmdPromptingRulesExport) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	opts := x.options()
+	opts.Snap = x.Snap
+	opts.Interface = x.Interface
+	rules, err := x.client.ExportPromptingRules(opts)
+	if err != nil {
+		return err
+	}
+	if rules == nil {
+		rules = []*client.PromptingExportedRule{}
+	}
+
+	enc := json.NewEncoder(Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rules); err != nil {
+		return fmt.Errorf(i18n.G("cannot write prompting rules: %v"), err)
+	}
+	return nil
+}
+
+func (x *cmdPromptingRulesImport) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	var data []byte
+	var err error
+	if x.Positional.Filename == "-" {
+		data, err = io.ReadAll(Stdin)
+	} else {
+		data, err = os.ReadFile(x.Positional.Filename)
+	}
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot read prompting rules: %v"), err)
+	}
+	var rules []*client.PromptingExportedRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf(i18n.G("cannot parse prompting rules: %v"), err)
+	}
+
+	imported, err := x.client.ImportPromptingRules(rules, x.options())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(Stdout, i18n.NG("Imported %d prompting rule.\n", "Imported %d prompting rules.\n", len(imported)), len(imported))
+	return nil
+}
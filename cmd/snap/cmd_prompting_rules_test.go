@@ -0,0 +1,264 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestPromptingRulesList(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+		c.Check(r.URL.Query().Get("snap"), check.Equals, "firefox")
+		c.Check(r.URL.Query().Get("all-users"), check.Equals, "")
+		fmt.Fprintln(w, `{"type": "sync", "result": [{
+			"id": "0000000000000001",
+			"user": 1000,
+			"snap": "firefox",
+			"interface": "home",
+			"origin": "user",
+			"constraints": {
+				"path-pattern": "/home/test/Downloads/**",
+				"permissions": {"write": {"outcome": "allow", "lifespan": "forever"}, "read": {"outcome": "allow", "lifespan": "forever"}}
+			},
+			"hit-count": 12,
+			"last-matched": "2026-10-17T10:00:00Z"
+		}, {
+			"id": "0000000000000002",
+			"user": 4294967295,
+			"snap": "firefox",
+			"interface": "home",
+			"origin": "admin",
+			"constraints": {
+				"path-pattern": "/home/*/.ssh/**",
+				"permissions": {"read": {"outcome": "deny", "lifespan": "forever"}}
+			}
+		}]}`)
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "list", "--snap", "firefox"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, ""+
+		"ID                Snap     Interface  Origin  Path pattern             Permissions             Hits\n"+
+		"0000000000000001  firefox  home       user    /home/test/Downloads/**  read:allow,write:allow  12\n"+
+		"0000000000000002  firefox  home       admin   /home/*/.ssh/**          read:deny               0\n")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestPromptingRulesListEmpty(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Query().Get("all-users"), check.Equals, "true")
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "list", "--all-users"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "No prompting rules.\n")
+}
+
+func (s *SnapSuite) TestPromptingRulesAddAllUsers(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+		c.Check(r.URL.Query().Get("all-users"), check.Equals, "true")
+		var data map[string]interface{}
+		c.Assert(json.NewDecoder(r.Body).Decode(&data), check.IsNil)
+		c.Check(data, check.DeepEquals, map[string]interface{}{
+			"action": "add",
+			"rule": map[string]interface{}{
+				"snap":      "firefox",
+				"interface": "home",
+				"constraints": map[string]interface{}{
+					"path-pattern": "/home/*/.ssh/**",
+					"permissions": map[string]interface{}{
+						"read":  map[string]interface{}{"outcome": "deny", "lifespan": "forever"},
+						"write": map[string]interface{}{"outcome": "deny", "lifespan": "forever"},
+					},
+				},
+			},
+		})
+		fmt.Fprintln(w, `{"type": "sync", "result": {"id": "0000000000000003", "origin": "admin"}}`)
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "add", "--all-users", "--outcome=deny", "firefox", "home", "/home/*/.ssh/**", "read", "write"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, "Added prompting rule 0000000000000003.\n")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestPromptingRulesAddDuration(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Query().Get("all-users"), check.Equals, "")
+		var data map[string]interface{}
+		c.Assert(json.NewDecoder(r.Body).Decode(&data), check.IsNil)
+		rule := data["rule"].(map[string]interface{})
+		c.Check(rule["constraints"].(map[string]interface{})["permissions"], check.DeepEquals, map[string]interface{}{
+			"read": map[string]interface{}{"outcome": "allow", "lifespan": "timespan", "duration": "1h"},
+		})
+		fmt.Fprintln(w, `{"type": "sync", "result": {"id": "0000000000000004", "origin": "user"}}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "add", "--duration=1h", "firefox", "home", "/home/test/Downloads/**", "read"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "Added prompting rule 0000000000000004.\n")
+}
+
+func (s *SnapSuite) TestPromptingRulesRemove(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/rules/0000000000000002")
+		c.Check(r.URL.Query().Get("all-users"), check.Equals, "true")
+		fmt.Fprintln(w, `{"type": "sync", "result": {"id": "0000000000000002", "origin": "admin"}}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "remove", "--all-users", "0000000000000002"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "Removed prompting rule 0000000000000002.\n")
+}
+
+func (s *SnapSuite) TestPromptingRulesRemoveError(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(403)
+		fmt.Fprintln(w, `{"type": "error", "status-code": 403, "result": {"message": "only admins may use the \"all-users\" parameter"}}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "remove", "--all-users", "0000000000000002"})
+	c.Check(err, check.ErrorMatches, `only admins may use the "all-users" parameter`)
+}
+
+func (s *SnapSuite) TestPromptingRulesExport(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+		c.Check(r.URL.Query().Get("export"), check.Equals, "true")
+		c.Check(r.URL.Query().Get("snap"), check.Equals, "firefox")
+		fmt.Fprintln(w, `{"type": "sync", "result": [{
+			"snap": "firefox",
+			"interface": "home",
+			"constraints": {
+				"path-pattern": "/home/test/Downloads/**",
+				"permissions": {"write": {"outcome": "allow", "lifespan": "forever"}}
+			}
+		}]}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "export", "--snap", "firefox"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `[
+  {
+    "snap": "firefox",
+    "interface": "home",
+    "constraints": {
+      "path-pattern": "/home/test/Downloads/**",
+      "permissions": {
+        "write": {
+          "outcome": "allow",
+          "lifespan": "forever"
+        }
+      }
+    }
+  }
+]
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestPromptingRulesExportEmpty(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Query().Get("all-users"), check.Equals, "true")
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "export", "--all-users"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "[]\n")
+}
+
+const promptingRulesToImport = `[{
+	"snap": "firefox",
+	"interface": "home",
+	"constraints": {
+		"path-pattern": "/home/test/Downloads/**",
+		"permissions": {"write": {"outcome": "allow", "lifespan": "forever"}}
+	}
+}, {
+	"snap": "thunderbird",
+	"interface": "home",
+	"constraints": {
+		"path-pattern": "/home/test/Mail/**",
+		"permissions": {"read": {"outcome": "allow", "lifespan": "forever"}}
+	}
+}]`
+
+func (s *SnapSuite) TestPromptingRulesImport(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/interfaces/requests/rules")
+		c.Check(r.URL.Query().Get("all-users"), check.Equals, "")
+		var data map[string]interface{}
+		c.Assert(json.NewDecoder(r.Body).Decode(&data), check.IsNil)
+		c.Check(data["action"], check.Equals, "import")
+		c.Check(data["rules"], check.HasLen, 2)
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"id": "0000000000000005"}, {"id": "0000000000000006"}]}`)
+	})
+
+	filename := filepath.Join(c.MkDir(), "rules.json")
+	c.Assert(os.WriteFile(filename, []byte(promptingRulesToImport), 0o644), check.IsNil)
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", filename})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "Imported 2 prompting rules.\n")
+
+	s.ResetStdStreams()
+	s.stdin.WriteString(promptingRulesToImport)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", "-"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "Imported 2 prompting rules.\n")
+	c.Check(n, check.Equals, 2)
+}
+
+func (s *SnapSuite) TestPromptingRulesImportErrors(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(409)
+		fmt.Fprintln(w, `{"type": "error", "status-code": 409, "result": {"message": "cannot import rules: a rule with conflicting path pattern and permission already exists in the rule database", "kind": "interfaces-requests-rule-conflict"}}`)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", filepath.Join(c.MkDir(), "missing.json")})
+	c.Check(err, check.ErrorMatches, `cannot read prompting rules: .*`)
+
+	s.stdin.WriteString("not json")
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", "-"})
+	c.Check(err, check.ErrorMatches, `cannot parse prompting rules: .*`)
+
+	s.stdin.WriteString(promptingRulesToImport)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"prompting-rules", "import", "-"})
+	c.Check(err, check.ErrorMatches, `cannot import rules: a rule with conflicting .*`)
+}
@@ -124,7 +124,7 @@ func init() {
 			"journal-rate-limit": i18n.G("Journal rate limit as <message count>/<message period>"),
 			"parent":             i18n.G("Parent quota group"),
 		}), nil)
-	addCommand("quota", shortQuotaHelp, longQuotaHelp, func() flags.Commander { return &cmdQuota{} }, nil, nil)
+	addCommand("quota", shortQuotaHelp, longQuotaHelp, func() flags.Commander { return &cmdQuota{} }, formatDescs, nil)
 	addCommand("quotas", shortQuotasHelp, longQuotasHelp, func() flags.Commander { return &cmdQuotas{} }, nil, nil)
 	addCommand("remove-quota", shortRemoveQuotaHelp, longRemoveQuotaHelp, func() flags.Commander { return &cmdRemoveQuota{} }, nil, nil)
 }
@@ -380,6 +380,7 @@ func (x *cmdSetQuota) Execute(args []string) (err error) {
 
 type cmdQuota struct {
 	clientMixin
+	formatMixin
 
 	Positional struct {
 		GroupName string `positional-arg-name:"<group-name>" required:"true"`
@@ -395,6 +396,9 @@ func (x *cmdQuota) Execute(args []string) (err error) {
 	if err != nil {
 		return err
 	}
+	if x.formatted() {
+		return x.writeFormatted(group)
+	}
 
 	w := tabWriter()
 	defer w.Flush()
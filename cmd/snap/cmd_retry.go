@@ -0,0 +1,71 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdRetry struct{ changeIDMixin }
+
+var shortRetryHelp = i18n.G("Retry a failed change")
+
+var longRetryHelp = i18n.G(`
+The retry command runs again the tasks of a change that failed, provided
+nothing of the change was undone yet and the failed tasks are safe to run
+again; currently only downloads are. The change is resumed if it was
+paused.
+
+A change that is not paused is undone as soon as one of its tasks fails,
+so it can only be retried if nothing was done before the failed task, as
+when a download fails first thing. Changes that are paused with
+'snap pause' before they fail are not undone until they are resumed, so
+that they can be retried.
+`)
+
+func init() {
+	addCommand("retry",
+		shortRetryHelp,
+		longRetryHelp,
+		func() flags.Commander {
+			return &cmdRetry{}
+		},
+		changeIDMixinOptDesc,
+		changeIDMixinArgDesc,
+	)
+}
+
+func (x *cmdRetry) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	id, err := x.GetChangeID()
+	if err != nil {
+		if err == noChangeFoundOK {
+			return nil
+		}
+		return err
+	}
+	_, err = x.client.Retry(id)
+	return err
+}
@@ -0,0 +1,47 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestRetry(c *check.C) {
+	s.testChangeAction(c, "retry")
+}
+
+func (s *SnapSuite) TestRetryError(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		fmt.Fprintln(w, `{"type": "error", "result": {"message": "cannot retry change 42: no task failed"}, "status-code": 400}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"retry", "42"})
+	c.Assert(err, check.ErrorMatches, "cannot retry change 42: no task failed")
+}
+
+func (s *SnapSuite) TestRetryExtraArgs(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"retry", "42", "43"})
+	c.Assert(err, check.ErrorMatches, "too many arguments for command")
+}
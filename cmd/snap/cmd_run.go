@@ -30,9 +30,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/godbus/dbus/v5"
@@ -45,6 +47,8 @@ import (
 	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/denials"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/strace"
@@ -53,6 +57,7 @@ import (
 	"github.com/snapcore/snapd/sandbox/selinux"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snapenv"
+	"github.com/snapcore/snapd/snapdtool"
 	"github.com/snapcore/snapd/strutil/shlex"
 	"github.com/snapcore/snapd/timeutil"
 	"github.com/snapcore/snapd/x11"
@@ -88,6 +93,8 @@ type cmdRun struct {
 	Gdbserver             string `long:"gdbserver" default:"no-gdbserver" optional-value:":0" optional:"true"`
 	ExperimentalGdbserver string `long:"experimental-gdbserver" default:"no-gdbserver" optional-value:":0" optional:"true" hidden:"yes"`
 	TraceExec             bool   `long:"trace-exec"`
+	RecordDenials         string `long:"record-denials" optional:"true" optional-value:"report" choice:"report" choice:"json"`
+	RecordDenialsOutput   string `long:"record-denials-output"`
 
 	// not a real option, used to check if cmdRun is initialized by
 	// the parser
@@ -130,6 +137,10 @@ and environment.
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"trace-exec": i18n.G("Display exec calls timing data"),
 			// TRANSLATORS: This should not start with a lowercase letter.
+			"record-denials": i18n.G("Record sandbox denials while the command runs and suggest plugs that would allow them (use =json for the raw denials)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"record-denials-output": i18n.G("Write the recorded denials to the given file instead of standard error (required with --record-denials=json)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
 			"debug-log":  i18n.G("Enable debug logging during early snap startup phases"),
 			"parser-ran": "",
 		}, nil)
@@ -301,6 +312,16 @@ func (x *cmdRun) Execute(args []string) error {
 	if x.Revision != "unset" && x.Revision != "" && x.HookName == "" {
 		return errors.New(i18n.G("-r can only be used with --hook"))
 	}
+	if x.RecordDenials != "" && (x.TraceExec || x.Gdb || x.useGdbserver() || x.useStrace()) {
+		return errors.New(i18n.G("cannot use --record-denials with --trace-exec, --strace or --gdbserver"))
+	}
+	if x.RecordDenialsOutput != "" && x.RecordDenials == "" {
+		return errors.New(i18n.G("cannot use --record-denials-output without --record-denials"))
+	}
+	if x.RecordDenials == "json" && x.RecordDenialsOutput == "" {
+		// the denials would be mixed with the output of the command
+		return errors.New(i18n.G("cannot use --record-denials=json without --record-denials-output"))
+	}
 	if x.HookName != "" && len(args) > 0 {
 		// TRANSLATORS: %q is the hook name; %s a space-separated list of extra arguments
 		return fmt.Errorf(i18n.G("too many arguments for hook %q: %s"), x.HookName, strings.Join(args, " "))
@@ -1119,6 +1140,109 @@ func (x *cmdRun) runCmdWithTraceExec(origCmd []string, envForExec envForExecFunc
 	return err
 }
 
+type denialsRecorder interface {
+	Stop() ([]*denials.Event, error)
+}
+
+var newDenialsRecorder = func(instanceName string) (denialsRecorder, error) {
+	return denials.NewRecorder(instanceName)
+}
+
+var denialsResolveSyscallNames = denials.ResolveSyscallNames
+
+func (x *cmdRun) runCmdRecordingDenials(instanceName string, origCmd []string, envForExec envForExecFunc) error {
+	var out io.Writer = Stderr
+	if x.RecordDenialsOutput != "" {
+		f, err := os.OpenFile(x.RecordDenialsOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return fmt.Errorf(i18n.G("cannot open denials output: %v"), err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	recorder, err := newDenialsRecorder(instanceName)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(origCmd[0], origCmd[1:]...)
+	cmd.Env = envForExec(nil)
+	cmd.Stdin = Stdin
+	cmd.Stdout = Stdout
+	cmd.Stderr = Stderr
+	err = cmd.Run()
+
+	events, stopErr := recorder.Stop()
+	if stopErr != nil {
+		fmt.Fprintf(Stderr, i18n.G("WARNING: %v\n"), stopErr)
+	}
+	if snapSeccomp, toolErr := snapdtool.InternalToolPath("snap-seccomp"); toolErr != nil {
+		logger.Noticef("cannot find snap-seccomp: %v", toolErr)
+	} else if resolveErr := denialsResolveSyscallNames(events, snapSeccomp); resolveErr != nil {
+		logger.Noticef("%v", resolveErr)
+	}
+
+	if x.RecordDenials == "json" {
+		if events == nil {
+			events = []*denials.Event{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(events); encErr != nil {
+			logger.Noticef("cannot encode denials: %v", encErr)
+		}
+	} else {
+		displayDenials(out, instanceName, events)
+	}
+	return err
+}
+
+// maxDenialInterfaces is the number of interfaces listed for each denial.
+const maxDenialInterfaces = 3
+
+func displayDenials(out io.Writer, instanceName string, events []*denials.Event) {
+	if len(events) == 0 {
+		fmt.Fprintf(out, i18n.G("No denials recorded for snap %q.\n"), instanceName)
+		return
+	}
+
+	matcher := denials.NewMatcher(builtin.Interfaces())
+	suggested := make(map[string]bool)
+	seen := make(map[string]bool)
+	w := tabwriter.NewWriter(out, 5, 3, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.G("Label\tDenied\tAllowed by"))
+	for _, ev := range events {
+		key := ev.Label + "\x00" + ev.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		ifaces := matcher.InterfacesFor(ev)
+		allowedBy := "-"
+		if len(ifaces) > 0 {
+			suggested[ifaces[0]] = true
+			allowedBy = strings.Join(ifaces, ", ")
+			if len(ifaces) > maxDenialInterfaces {
+				// TRANSLATORS: %s is a list of interfaces, %d how many more there are
+				allowedBy = fmt.Sprintf(i18n.G("%s (+%d more)"), strings.Join(ifaces[:maxDenialInterfaces], ", "), len(ifaces)-maxDenialInterfaces)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", ev.Label, ev.String(), allowedBy)
+	}
+	w.Flush()
+
+	if len(suggested) > 0 {
+		plugs := make([]string, 0, len(suggested))
+		for iface := range suggested {
+			plugs = append(plugs, iface)
+		}
+		sort.Strings(plugs)
+		fmt.Fprintf(out, i18n.G("\nSuggested plugs: %s\n"), strings.Join(plugs, ", "))
+	}
+}
+
 func (x *cmdRun) runCmdUnderStrace(origCmd []string, envForExec envForExecFunc) error {
 	extraStraceOpts, raw, err := x.straceOpts()
 	if err != nil {
@@ -1547,6 +1671,8 @@ func (x *cmdRun) runSnapConfine(info *snap.Info, runner runnable, beforeExec fun
 	logger.StartupStageTimestamp("snap to snap-confine")
 	if x.TraceExec {
 		return x.runCmdWithTraceExec(cmd, envForExec)
+	} else if x.RecordDenials != "" {
+		return x.runCmdRecordingDenials(info.InstanceName(), cmd, envForExec)
 	} else if x.Gdb {
 		return x.runCmdUnderGdb(cmd, envForExec)
 	} else if x.useGdbserver() {
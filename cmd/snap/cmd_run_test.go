@@ -37,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/denials"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/strace"
@@ -2049,6 +2050,161 @@ func (s *RunSuite) TestRunCmdWithTraceExecUnhappy(c *check.C) {
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+type fakeDenialsRecorder struct {
+	events  []*denials.Event
+	err     error
+	stopped bool
+}
+
+func (r *fakeDenialsRecorder) Stop() ([]*denials.Event, error) {
+	r.stopped = true
+	return r.events, r.err
+}
+
+func (s *RunSuite) mockRecordDenials(c *check.C, events []*denials.Event) *fakeDenialsRecorder {
+	// snap-confine is run as a child process
+	snapConfine := filepath.Join(dirs.DistroLibExecDir, "snap-confine")
+	c.Assert(os.WriteFile(snapConfine, []byte("#!/bin/sh\necho running \"$@\"\n"), 0755), check.IsNil)
+	c.Assert(os.Chmod(snapConfine, 0755), check.IsNil)
+
+	recorder := &fakeDenialsRecorder{events: events}
+	s.AddCleanup(snaprun.MockNewDenialsRecorder(func(instanceName string) (snaprun.DenialsRecorder, error) {
+		c.Check(instanceName, check.Equals, "snapname")
+		return recorder, nil
+	}))
+	s.AddCleanup(snaprun.MockDenialsResolveSyscallNames(func(events []*denials.Event, snapSeccomp string) error {
+		c.Check(snapSeccomp, check.Equals, filepath.Join(dirs.DistroLibExecDir, "snap-seccomp"))
+		for _, ev := range events {
+			if ev.Kind == denials.KindSeccomp && ev.Syscall == 165 {
+				ev.SyscallName = "mount"
+			}
+		}
+		return nil
+	}))
+	return recorder
+}
+
+func (s *RunSuite) TestSnapRunRecordDenials(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+	recorder := s.mockRecordDenials(c, []*denials.Event{
+		{Kind: denials.KindSeccomp, Label: "snap.snapname.app", Arch: "amd64", Syscall: 165},
+		{Kind: denials.KindSeccomp, Label: "snap.snapname.app", Arch: "amd64", Syscall: 165},
+		{Kind: denials.KindAppArmor, Label: "snap.snapname.app", Interface: "org.freedesktop.NetworkManager", Member: "GetDevices"},
+		{Kind: denials.KindAppArmor, Label: "snap.snapname.app", Operation: "signal"},
+	})
+
+	rest, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials", "--", "snapname.app", "--arg1"})
+	c.Assert(err, check.IsNil)
+	c.Check(rest, check.DeepEquals, []string{"snapname.app", "--arg1"})
+	c.Check(recorder.stopped, check.Equals, true)
+	c.Check(s.Stdout(), check.Matches, "running snap.snapname.app .*/snap-exec snapname.app --arg1\n")
+	c.Check(s.Stderr(), check.Matches, `(?s)Label +Denied +Allowed by
+snap.snapname.app +mount +\S+, \S+, \S+ \(\+\d+ more\)
+snap.snapname.app +dbus org.freedesktop.NetworkManager.GetDevices +network-manager-observe
+snap.snapname.app +signal +-
+
+Suggested plugs: \S+, network-manager-observe
+`)
+}
+
+func (s *RunSuite) TestSnapRunRecordDenialsJSON(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+	s.mockRecordDenials(c, []*denials.Event{
+		{Kind: denials.KindSeccomp, Label: "snap.snapname.app", Arch: "amd64", Syscall: 165},
+	})
+
+	output := filepath.Join(c.MkDir(), "denials.json")
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials=json", "--record-denials-output", output, "--", "snapname.app"})
+	c.Assert(err, check.IsNil)
+	// the denials are kept apart from the output of the command
+	c.Check(s.Stdout(), check.Matches, "running snap.snapname.app .*\n")
+	c.Check(s.Stderr(), check.Equals, "")
+	data, err := os.ReadFile(output)
+	c.Assert(err, check.IsNil)
+	var events []map[string]interface{}
+	c.Assert(json.Unmarshal(data, &events), check.IsNil)
+	c.Check(events, check.DeepEquals, []map[string]interface{}{{
+		"time":         "0001-01-01T00:00:00Z",
+		"kind":         "seccomp",
+		"label":        "snap.snapname.app",
+		"arch":         "amd64",
+		"syscall":      165.0,
+		"syscall-name": "mount",
+	}})
+}
+
+func (s *RunSuite) TestSnapRunRecordDenialsNone(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+	s.mockRecordDenials(c, nil)
+
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials", "--", "snapname.app"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stderr(), check.Equals, "No denials recorded for snap \"snapname\".\n")
+}
+
+func (s *RunSuite) TestSnapRunRecordDenialsOutput(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+	s.mockRecordDenials(c, nil)
+
+	output := filepath.Join(c.MkDir(), "denials")
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials", "--record-denials-output", output, "--", "snapname.app"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stderr(), check.Equals, "")
+	c.Check(output, testutil.FileEquals, "No denials recorded for snap \"snapname\".\n")
+}
+
+func (s *RunSuite) TestSnapRunRecordDenialsIncomplete(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+	recorder := s.mockRecordDenials(c, nil)
+	recorder.err = errors.New("kernel messages were lost while recording, some denials may be missing")
+
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials", "--", "snapname.app"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stderr(), check.Equals, `WARNING: kernel messages were lost while recording, some denials may be missing
+No denials recorded for snap "snapname".
+`)
+}
+
+func (s *RunSuite) TestSnapRunRecordDenialsErrors(c *check.C) {
+	_, err := snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials", "--trace-exec", "--", "snapname.app"})
+	c.Check(err, check.ErrorMatches, "cannot use --record-denials with --trace-exec, --strace or --gdbserver")
+
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials=yaml", "--", "snapname.app"})
+	c.Check(err, check.ErrorMatches, `Invalid value .yaml. for option .--record-denials.*`)
+
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials=json", "--", "snapname.app"})
+	c.Check(err, check.ErrorMatches, "cannot use --record-denials=json without --record-denials-output")
+
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials-output", "out", "--", "snapname.app"})
+	c.Check(err, check.ErrorMatches, "cannot use --record-denials-output without --record-denials")
+
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+	snaptest.MockSnapCurrent(c, string(mockYaml), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+	restore := snaprun.MockNewDenialsRecorder(func(instanceName string) (snaprun.DenialsRecorder, error) {
+		return nil, errors.New("cannot read denials from /dev/kmsg: permission denied (try with sudo)")
+	})
+	defer restore()
+	_, err = snaprun.Parser(snaprun.Client()).ParseArgs([]string{"run", "--record-denials", "--", "snapname.app"})
+	c.Check(err, check.ErrorMatches, `cannot read denials from /dev/kmsg: permission denied \(try with sudo\)`)
+}
+
 func (s *RunSuite) TestSnapRunRestoreSecurityContextHappy(c *check.C) {
 	logbuf, restorer := logger.MockLogger()
 	defer restorer()
@@ -34,6 +34,7 @@ import (
 
 type svcStatus struct {
 	clientMixin
+	formatMixin
 	Positional struct {
 		ServiceNames []serviceName
 	} `positional-args:"yes"`
@@ -84,6 +85,9 @@ The restart command restarts the given services.
 
 If the --reload option is given, for each service whose app has a reload
 command, a reload is performed instead of a restart.
+
+With --at, the change that restarts the services is created right away, but
+held until the given time. It can be cancelled with 'snap abort' until then.
 `)
 )
 
@@ -94,12 +98,12 @@ func init() {
 		// TRANSLATORS: This should not start with a lowercase letter.
 		desc: i18n.G("A service specification, which can be just a snap name (for all services in the snap), or <snap>.<app> for a single service."),
 	}}
-	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, map[string]string{
+	addCommand("services", shortServicesHelp, longServicesHelp, func() flags.Commander { return &svcStatus{} }, formatDescs.also(map[string]string{
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"global": i18n.G("Show the global enable status for user services instead of the status for the current user."),
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"user": i18n.G("Show the current status of the user services instead of the global enable status."),
-	}, argdescs)
+	}), argdescs)
 	addCommand("logs", shortLogsHelp, longLogsHelp, func() flags.Commander { return &svcLogs{} },
 		timeDescs.also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
@@ -119,7 +123,7 @@ func init() {
 			"disable": i18n.G("As well as stopping the service now, arrange for it to no longer be started on boot."),
 		}), argdescs)
 	addCommand("restart", shortRestartHelp, longRestartHelp, func() flags.Commander { return &svcRestart{} },
-		waitDescs.also(userAndScopeDescs).also(map[string]string{
+		waitDescs.also(userAndScopeDescs).also(atDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"reload": i18n.G("If the service has a reload command, use it instead of restarting."),
 		}), argdescs)
@@ -172,6 +176,9 @@ func (s *svcStatus) Execute(args []string) error {
 	if err != nil {
 		return err
 	}
+	if s.formatted() {
+		return s.writeFormatted(services)
+	}
 
 	if len(services) == 0 {
 		fmt.Fprintln(Stderr, i18n.G("There are no services provided by installed snaps."))
@@ -295,6 +302,7 @@ func (s *svcStop) Execute(args []string) error {
 
 type svcRestart struct {
 	waitMixin
+	atMixin
 	clientutil.ServiceScopeOptions
 	Positional struct {
 		ServiceNames []serviceName `required:"1"`
@@ -309,11 +317,19 @@ func (s *svcRestart) Execute(args []string) error {
 	if err := s.Validate(); err != nil {
 		return err
 	}
+	at, err := s.atTime()
+	if err != nil {
+		return err
+	}
 	names := svcNames(s.Positional.ServiceNames)
-	changeID, err := s.client.Restart(names, s.Scope(), s.Users(), client.RestartOptions{Reload: s.Reload})
+	changeID, err := s.client.Restart(names, s.Scope(), s.Users(), client.RestartOptions{Reload: s.Reload, At: at})
 	if err != nil {
 		return err
 	}
+	if at != "" {
+		s.showScheduled(changeID, at)
+		return nil
+	}
 	if _, err := s.wait(changeID); err != nil {
 		if err == noWait {
 			return nil
@@ -171,6 +171,35 @@ func (s *appOpSuite) TestAppOps(c *check.C) {
 	}
 }
 
+func (s *appOpSuite) TestRestartAt(c *check.C) {
+	restore := snap.MockTimeNow(func() time.Time {
+		return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	})
+	defer restore()
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/apps")
+			expectedBody := s.expectedBody("restart", []string{"foo"}, nil)
+			expectedBody["at"] = "2026-10-20T02:00:00Z"
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, expectedBody)
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+		default:
+			c.Fatalf("expected to get 1 request, now on %d", n+1)
+		}
+		n++
+	})
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"restart", "--at", "2026-10-20T02:00:00Z", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+	c.Check(s.Stdout(), check.Equals, "Change 42 scheduled for 2026-10-20T02:00:00Z.\n")
+	c.Check(n, check.Equals, 1)
+}
+
 func (s *appOpSuite) TestAppOpsScopeSwitches(c *check.C) {
 	var n int
 	var body map[string]interface{}
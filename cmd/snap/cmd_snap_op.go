@@ -83,6 +83,9 @@ revision is removed.
 Unless automatic snapshots are disabled, a snapshot of all data for the snap is 
 saved upon removal, which is then available for future restoration with snap
 restore. The --purge option disables automatically creating snapshots.
+
+With --at, the change that removes the snaps is created right away, but held
+until the given time. It can be cancelled with 'snap abort' until then.
 `)
 
 var longRefreshHelp = i18n.G(`
@@ -106,6 +109,9 @@ requests from 'snap refresh target-snap'.
 When snaps are specified --hold is effective on both their auto-refreshes
 and general refresh requests from 'snap refresh'. However, specific snap
 requests from 'snap refresh target-snap' remain unblocked and will proceed.
+
+With --at, the change that refreshes the snaps is created right away, but held
+until the given time. It can be cancelled with 'snap abort' until then.
 `)
 
 var longTryHelp = i18n.G(`
@@ -131,6 +137,7 @@ and the snap can easily be enabled again.
 
 type cmdRemove struct {
 	waitMixin
+	atMixin
 
 	Revision   string `long:"revision"`
 	Purge      bool   `long:"purge"`
@@ -215,6 +222,10 @@ func (x *cmdRemove) removeOne(opts *client.SnapOptions) error {
 		fmt.Fprintln(Stderr, msg)
 		return nil
 	}
+	if opts.At != "" {
+		x.showScheduled(changeID, opts.At)
+		return nil
+	}
 
 	chg, err := x.wait(changeID)
 	if err != nil {
@@ -291,6 +302,10 @@ func (x *cmdRemove) removeMany(opts *client.SnapOptions) error {
 		fmt.Fprintln(Stderr, msg)
 		return nil
 	}
+	if opts.At != "" {
+		x.showScheduled(changeID, opts.At)
+		return nil
+	}
 
 	chg, err := x.wait(changeID)
 	if err != nil {
@@ -310,7 +325,11 @@ func (x *cmdRemove) removeMany(opts *client.SnapOptions) error {
 }
 
 func (x *cmdRemove) Execute([]string) error {
-	opts := &client.SnapOptions{Revision: x.Revision, Purge: x.Purge, Terminate: x.Terminate}
+	at, err := x.atTime()
+	if err != nil {
+		return err
+	}
+	opts := &client.SnapOptions{Revision: x.Revision, Purge: x.Purge, Terminate: x.Terminate, At: at}
 	if len(x.Positional.Snaps) == 1 {
 		return x.removeOne(opts)
 	}
@@ -911,6 +930,7 @@ type cmdRefresh struct {
 	waitMixin
 	channelMixin
 	modeMixin
+	atMixin
 
 	Amend            bool                   `long:"amend"`
 	Revision         string                 `long:"revision"`
@@ -923,6 +943,7 @@ type cmdRefresh struct {
 	Transaction      client.TransactionType `long:"transaction" default:"per-snap" choice:"all-snaps" choice:"per-snap"`
 	Hold             string                 `long:"hold" optional:"yes" optional-value:"forever"`
 	Unhold           bool                   `long:"unhold"`
+	Simulate         bool                   `long:"simulate"`
 	Positional       struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
@@ -939,6 +960,10 @@ func (x *cmdRefresh) refreshMany(snaps []string, opts *client.SnapOptions) error
 	if err != nil {
 		return err
 	}
+	if opts.At != "" {
+		x.showScheduled(changeID, opts.At)
+		return nil
+	}
 
 	chg, err := x.wait(changeID)
 	if err != nil {
@@ -978,6 +1003,10 @@ func (x *cmdRefresh) refreshOne(name string, opts *client.SnapOptions) error {
 		fmt.Fprintln(Stderr, msg)
 		return nil
 	}
+	if opts.At != "" {
+		x.showScheduled(changeID, opts.At)
+		return nil
+	}
 
 	chg, err := x.wait(changeID)
 	if err != nil {
@@ -1073,6 +1102,76 @@ func (x *cmdRefresh) listRefresh() error {
 	return nil
 }
 
+func (x *cmdRefresh) simulateRefresh(names []string, opts *client.SnapOptions) error {
+	sim, err := x.client.SimulateRefresh(names, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(sim.Snaps) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("All snaps up to date."))
+	} else {
+		w := tabWriter()
+		fmt.Fprintln(w, i18n.G("Name\tCurrent\tRev\tSize\tDelta\tNotes"))
+		for _, refresh := range sim.Snaps {
+			current := "-"
+			if !refresh.CurrentRevision.Unset() {
+				current = refresh.CurrentRevision.String()
+			}
+			delta := "-"
+			if refresh.DeltaSize > 0 {
+				delta = strutil.SizeToStr(refresh.DeltaSize)
+			}
+			notes := "-"
+			if refresh.Reboot {
+				notes = i18n.G("reboot")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", refresh.Name, current, refresh.Revision, strutil.SizeToStr(refresh.DownloadSize), delta, notes)
+		}
+		w.Flush()
+
+		for _, refresh := range sim.Snaps {
+			var details []string
+			if len(refresh.RunningApps) > 0 {
+				details = append(details, fmt.Sprintf(i18n.G("running apps: %s"), strings.Join(refresh.RunningApps, ", ")))
+			}
+			if len(refresh.GatingSnaps) > 0 {
+				details = append(details, fmt.Sprintf(i18n.G("auto-refresh gated by: %s"), strings.Join(refresh.GatingSnaps, ", ")))
+			}
+			if refresh.Conflict != "" {
+				details = append(details, fmt.Sprintf(i18n.G("conflict: %s"), refresh.Conflict))
+			}
+			for _, id := range refresh.AutoConnect {
+				details = append(details, fmt.Sprintf(i18n.G("auto-connect: %s"), id))
+			}
+			if len(details) == 0 {
+				continue
+			}
+			fmt.Fprintf(Stdout, "\n%s:\n", refresh.Name)
+			for _, detail := range details {
+				fmt.Fprintf(Stdout, "  %s\n", detail)
+			}
+		}
+	}
+
+	if len(sim.Skipped) > 0 {
+		fmt.Fprintf(Stdout, "\n%s\n", i18n.G("Skipped:"))
+		for _, skipped := range sim.Skipped {
+			fmt.Fprintf(Stdout, "  %s: %s\n", skipped.Name, skipped.Reason)
+		}
+	}
+
+	if sim.DiskSpace != nil {
+		// TRANSLATORS: the first %s is a size, the second a path
+		fmt.Fprintf(Stdout, "\n"+i18n.G("Disk space: %s needed in %s")+"\n", strutil.SizeToStr(int64(sim.DiskSpace.Required)), sim.DiskSpace.Path)
+		if sim.DiskSpace.Error != "" {
+			fmt.Fprintf(Stdout, "  %s\n", sim.DiskSpace.Error)
+		}
+	}
+
+	return nil
+}
+
 func (x *cmdRefresh) Execute([]string) error {
 	if err := x.setChannelFromCommandline(); err != nil {
 		return err
@@ -1103,7 +1202,7 @@ func (x *cmdRefresh) Execute([]string) error {
 
 	otherFlags := x.Amend || x.Revision != "" || x.Cohort != "" ||
 		x.LeaveCohort || x.List || x.Time || x.IgnoreValidation || x.IgnoreRunning ||
-		x.Transaction != client.TransactionPerSnap
+		x.Transaction != client.TransactionPerSnap || x.At != "" || x.Simulate
 
 	if x.Hold != "" && (x.Unhold || otherFlags) {
 		return errors.New(i18n.G("cannot use --hold with other flags"))
@@ -1115,6 +1214,15 @@ func (x *cmdRefresh) Execute([]string) error {
 		return x.unholdRefreshes()
 	}
 
+	if x.Simulate && x.At != "" {
+		return errors.New(i18n.G("cannot use --simulate with --at"))
+	}
+
+	at, err := x.atTime()
+	if err != nil {
+		return err
+	}
+
 	names := installedSnapNames(x.Positional.Snaps)
 	if len(names) == 1 {
 		opts := &client.SnapOptions{
@@ -1126,15 +1234,20 @@ func (x *cmdRefresh) Execute([]string) error {
 			CohortKey:        x.Cohort,
 			LeaveCohort:      x.LeaveCohort,
 			Transaction:      x.Transaction,
+			At:               at,
 		}
 		x.setModes(opts)
+		if x.Simulate {
+			return x.simulateRefresh(names, opts)
+		}
 		return x.refreshOne(names[0], opts)
 	}
-	// transaction flag and ignore-running flags are the only ones with meaning when
-	// refreshing many snaps
+	// transaction, ignore-running and at flags are the only ones with
+	// meaning when refreshing many snaps
 	opts := &client.SnapOptions{
 		IgnoreRunning: x.IgnoreRunning,
 		Transaction:   x.Transaction,
+		At:            at,
 	}
 
 	if x.asksForMode() || x.asksForChannel() {
@@ -1145,6 +1258,9 @@ func (x *cmdRefresh) Execute([]string) error {
 		return errors.New(i18n.G("a single snap name must be specified when ignoring validation"))
 	}
 
+	if x.Simulate {
+		return x.simulateRefresh(names, opts)
+	}
 	return x.refreshMany(names, opts)
 }
 
@@ -1500,7 +1616,7 @@ func (x cmdSwitch) Execute(args []string) error {
 
 func init() {
 	addCommand("remove", shortRemoveHelp, longRemoveHelp, func() flags.Commander { return &cmdRemove{} },
-		waitDescs.also(map[string]string{
+		waitDescs.also(atDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"revision": i18n.G("Remove only the given revision"),
 			// TRANSLATORS: This should not start with a lowercase letter.
@@ -1534,7 +1650,7 @@ func init() {
 			"prefer": i18n.G("Enable all aliases of the given snap in preference to conflicting aliases of other snaps"),
 		}), nil)
 	addCommand("refresh", shortRefreshHelp, longRefreshHelp, func() flags.Commander { return &cmdRefresh{} },
-		colorDescs.also(waitDescs).also(channelDescs).also(modeDescs).also(timeDescs).also(map[string]string{
+		colorDescs.also(waitDescs).also(channelDescs).also(modeDescs).also(timeDescs).also(atDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"amend": i18n.G("Allow refresh attempt on snap unknown to the store"),
 			// TRANSLATORS: This should not start with a lowercase letter.
@@ -1557,6 +1673,8 @@ func init() {
 			"hold": i18n.G("Hold refreshes for a specified duration (or forever, if no value is specified)"),
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"unhold": i18n.G("Remove refresh hold"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"simulate": i18n.G("Show what the refresh would do without doing it"),
 		}), nil)
 	addCommand("try", shortTryHelp, longTryHelp, func() flags.Commander { return &cmdTry{} }, waitDescs.also(modeDescs), nil)
 	addCommand("enable", shortEnableHelp, longEnableHelp, func() flags.Commander { return &cmdEnable{} }, waitDescs, nil)
@@ -2211,6 +2211,71 @@ func (s *SnapSuite) TestRefreshUnholdManySnaps(c *check.C) {
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *SnapSuite) TestRefreshSimulate(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		c.Check(r.Method, check.Equals, "POST")
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":      "refresh",
+			"transaction": "per-snap",
+			"simulate":    true,
+		})
+		fmt.Fprintln(w, `{"type": "sync", "result": {
+			"snaps": [
+				{"name": "pc-kernel", "current-revision": "1", "revision": "2", "download-size": 2097152, "delta-size": 1048576, "reboot": true},
+				{"name": "foo", "current-revision": "3", "revision": "4", "download-size": 1024, "running-apps": ["app"], "auto-connect": ["foo:network core:network"]}
+			],
+			"skipped": [{"name": "bar", "reason": "held by system"}],
+			"disk-space": {"path": "/var/lib/snapd", "required": 8393728, "error": "insufficient space"}
+		}}`)
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--simulate"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(n, check.Equals, 1)
+	c.Check(s.Stdout(), check.Equals, `Name       Current  Rev  Size  Delta  Notes
+pc-kernel  1        2    2MB   1MB    reboot
+foo        3        4    1kB   -      -
+
+foo:
+  running apps: app
+  auto-connect: foo:network core:network
+
+Skipped:
+  bar: held by system
+
+Disk space: 8MB needed in /var/lib/snapd
+  insufficient space
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestRefreshSimulateOneNothing(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action":      "refresh",
+			"channel":     "beta",
+			"transaction": "per-snap",
+			"simulate":    true,
+		})
+		fmt.Fprintln(w, `{"type": "sync", "result": {"snaps": [], "skipped": [{"name": "foo", "reason": "no update available"}]}}`)
+	})
+
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--simulate", "--beta", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "\nSkipped:\n  foo: no update available\n")
+	c.Check(s.Stderr(), check.Equals, "All snaps up to date.\n")
+}
+
+func (s *SnapSuite) TestRefreshSimulateAt(c *check.C) {
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--simulate", "--at", "2026-10-17T10:00:00Z"})
+	c.Assert(err, check.ErrorMatches, "cannot use --simulate with --at")
+}
+
 func (s *SnapSuite) TestRefreshHoldAndUnholdFailWithOtherFlags(c *check.C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		c.Errorf("unexpected request")
@@ -2511,6 +2576,66 @@ func (s *SnapOpSuite) TestRefreshOneIgnoreValidation(c *check.C) {
 	c.Assert(err, check.IsNil)
 }
 
+func (s *SnapOpSuite) TestRefreshAt(c *check.C) {
+	restore := snap.MockTimeNow(func() time.Time {
+		return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	})
+	defer restore()
+
+	for _, t := range []struct {
+		args []string
+		path string
+		body map[string]interface{}
+	}{
+		{[]string{"one"}, "/v2/snaps/one", map[string]interface{}{
+			"action":      "refresh",
+			"at":          "2026-10-20T02:00:00Z",
+			"transaction": string(client.TransactionPerSnap),
+		}},
+		{[]string{"one", "two"}, "/v2/snaps", map[string]interface{}{
+			"action":      "refresh",
+			"snaps":       []interface{}{"one", "two"},
+			"at":          "2026-10-20T02:00:00Z",
+			"transaction": string(client.TransactionPerSnap),
+		}},
+	} {
+		s.stdout.Reset()
+		n := 0
+		s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+			switch n {
+			case 0:
+				c.Check(r.Method, check.Equals, "POST")
+				c.Check(r.URL.Path, check.Equals, t.path)
+				c.Check(DecodedRequestBody(c, r), check.DeepEquals, t.body)
+				w.WriteHeader(202)
+				fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+			default:
+				c.Fatalf("expected to get 1 request, now on %d", n+1)
+			}
+			n++
+		})
+		rest, err := snap.Parser(snap.Client()).ParseArgs(append([]string{"refresh", "--at", "2026-10-20T02:00:00Z"}, t.args...))
+		c.Assert(err, check.IsNil)
+		c.Assert(rest, check.DeepEquals, []string{})
+		c.Check(s.Stdout(), check.Equals, "Change 42 scheduled for 2026-10-20T02:00:00Z.\n")
+	}
+}
+
+func (s *SnapOpSuite) TestRefreshAtErrors(c *check.C) {
+	restore := snap.MockTimeNow(func() time.Time {
+		return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	})
+	defer restore()
+
+	s.RedirectClientToTestServer(nil)
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--at", "2026-10-01T02:00:00Z", "one"})
+	c.Assert(err, check.ErrorMatches, `cannot hold change until 2026-10-01T02:00:00Z: time is in the past`)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--at", "someday", "one"})
+	c.Assert(err, check.ErrorMatches, `cannot parse --at "someday": .*`)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"refresh", "--at", "02:00", "--hold"})
+	c.Assert(err, check.ErrorMatches, `cannot use --hold with other flags`)
+}
+
 func (s *SnapOpSuite) TestRefreshOneRebooting(c *check.C) {
 	s.RedirectClientToTestServer(s.srv.handle)
 	s.srv.checker = func(r *http.Request) {
@@ -3104,6 +3229,36 @@ func (s *SnapOpSuite) TestRemoveManyOptions(c *check.C) {
 	c.Assert(err, check.ErrorMatches, `cannot use --revision with multiple snap names`)
 }
 
+func (s *SnapOpSuite) TestRemoveAt(c *check.C) {
+	restore := snap.MockTimeNow(func() time.Time {
+		return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	})
+	defer restore()
+
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/snaps/one")
+			c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+				"action": "remove",
+				"at":     "2026-10-20T02:00:00Z",
+			})
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "change": "42", "status-code": 202}`)
+		default:
+			c.Fatalf("expected to get 1 request, now on %d", n+1)
+		}
+		n++
+	})
+
+	rest, err := snap.Parser(snap.Client()).ParseArgs([]string{"remove", "--at", "2026-10-20T02:00:00Z", "one"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, "Change 42 scheduled for 2026-10-20T02:00:00Z.\n")
+}
+
 func (s *SnapOpSuite) TestRemoveMany(c *check.C) {
 	total := 3
 	n := 0
@@ -20,14 +20,19 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/strutil/quantity"
@@ -50,6 +55,19 @@ var (
 var longSavedHelp = i18n.G(`
 The saved command displays a list of snapshots that have been created
 previously with the 'save' command.
+
+With --list-files and a snapshot set id and a snap, the files and
+directories in the data of that snap in the snapshot are listed instead,
+with their paths relative to the data directory of the snap. Those are
+the paths 'snap restore --path' takes.
+
+With --diff and two snapshot set ids, optionally followed by snaps, the
+configuration and the files of the snaps in the first set are compared
+with those in the second one, listing what was added, removed or
+modified. Files whose contents, mode and link target are the same are
+not considered modified.
+
+Encrypted snapshots are listed or compared with --decrypt or --key-file.
 `)
 var longSaveHelp = i18n.G(`
 The save command creates a snapshot of the current user, system and
@@ -62,6 +80,11 @@ for which users, or a combination of these.
 If a snap is included in a save operation, excluding its system and
 configuration data from the snapshot is not currently possible. This
 restriction may be lifted in the future.
+
+With --encrypt, the data is encrypted with a key derived from a
+passphrase that is asked for, or from the contents of the file given
+with --key-file. The same passphrase or key file is needed to restore
+the snapshot; it cannot be recovered if lost.
 `)
 var longForgetHelp = i18n.G(`
 The forget command deletes a snapshot. This operation can not be
@@ -100,6 +123,16 @@ for which users, or a combination of these.
 If a snap is included in a restore operation, excluding its system and
 configuration data from the restore is not currently possible. This
 restriction may be lifted in the future.
+
+Encrypted snapshots are restored with --decrypt, which asks for the
+passphrase the snapshot was saved with, or with --key-file.
+
+With --path, only the given files or directories in the data of the one
+snap given are restored, leaving the rest of its data and its
+configuration as they are. Paths are relative to the data directory of
+the snap, as listed by 'snap saved --list-files', like common/config.toml
+or 42/db; the revision directory in the snapshot is restored into the
+current revision's one.
 `)
 
 var longExportSnapshotHelp = i18n.G(`
@@ -112,15 +145,35 @@ with a new snapshot ID and can be restored using the restore command.
 `)
 
 type savedCmd struct {
-	clientMixin
+	mustWaitMixin
 	durationMixin
+	formatMixin
 	ID         snapshotID `long:"id"`
+	ListFiles  bool       `long:"list-files"`
+	Diff       bool       `long:"diff"`
+	Decrypt    bool       `long:"decrypt"`
+	KeyFile    string     `long:"key-file"`
 	Positional struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
 }
 
 func (x *savedCmd) Execute([]string) error {
+	if x.ListFiles && x.Diff {
+		return errors.New(i18n.G("cannot use --list-files and --diff together"))
+	}
+	if x.formatted() && (x.ListFiles || x.Diff) {
+		return errors.New(i18n.G("cannot use --format with --list-files or --diff"))
+	}
+	if (x.Decrypt || x.KeyFile != "") && !x.ListFiles && !x.Diff {
+		return errors.New(i18n.G("--decrypt and --key-file can only be used with --list-files or --diff"))
+	}
+	if x.ListFiles {
+		return x.listFiles()
+	}
+	if x.Diff {
+		return x.diff()
+	}
 	var setID uint64
 	var err error
 	if x.ID != "" {
@@ -134,6 +187,9 @@ func (x *savedCmd) Execute([]string) error {
 	if err != nil {
 		return err
 	}
+	if x.formatted() {
+		return x.writeFormatted(list)
+	}
 	if len(list) == 0 {
 		fmt.Fprintln(Stdout, i18n.G("No snapshots found."))
 		return nil
@@ -160,6 +216,9 @@ func (x *savedCmd) Execute([]string) error {
 			if sh.Auto {
 				notes = append(notes, "auto")
 			}
+			if sh.Encryption != nil {
+				notes = append(notes, "encrypted")
+			}
 			if sh.Broken != "" {
 				notes = append(notes, "broken: "+sh.Broken)
 			}
@@ -175,19 +234,241 @@ func (x *savedCmd) Execute([]string) error {
 	return nil
 }
 
+func (x *savedCmd) listFiles() error {
+	if x.ID != "" || len(x.Positional.Snaps) != 2 {
+		return errors.New(i18n.G("--list-files requires a snapshot set id and a snap"))
+	}
+	setID, err := snapshotID(x.Positional.Snaps[0]).ToUint()
+	if err != nil {
+		return err
+	}
+	snapName := string(x.Positional.Snaps[1])
+	var files []client.SnapshotFile
+	if x.Decrypt || x.KeyFile != "" {
+		var secret []byte
+		secret, err = snapshotSecret(x.KeyFile, false)
+		if err != nil {
+			return err
+		}
+		files, err = x.client.EncryptedSnapshotFiles(setID, snapName, nil, secret)
+	} else {
+		files, err = x.client.SnapshotFiles(setID, snapName, nil)
+	}
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(Stdout, i18n.G("No files found."))
+		return nil
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+		i18n.G("User"),
+		i18n.G("Mode"),
+		i18n.G("Size"),
+		// TRANSLATORS: 'Age' as in how long ago the file was modified
+		i18n.G("Age"),
+		i18n.G("Path"))
+	for _, f := range files {
+		user := f.User
+		if user == "" {
+			// the system data
+			user = "-"
+		}
+		size := "-"
+		if f.Mode.IsRegular() {
+			size = fmtSize(f.Size)
+		}
+		path := f.Path
+		if f.Link != "" {
+			path += " -> " + f.Link
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", user, f.Mode, size, x.fmtDuration(f.ModTime), path)
+	}
+	return nil
+}
+
+func (x *savedCmd) diff() error {
+	if x.ID != "" || len(x.Positional.Snaps) < 2 {
+		return errors.New(i18n.G("--diff requires two snapshot set ids"))
+	}
+	setID, err := snapshotID(x.Positional.Snaps[0]).ToUint()
+	if err != nil {
+		return err
+	}
+	otherSetID, err := snapshotID(x.Positional.Snaps[1]).ToUint()
+	if err != nil {
+		return err
+	}
+	snaps := installedSnapNames(x.Positional.Snaps[2:])
+	var secret []byte
+	if x.Decrypt || x.KeyFile != "" {
+		secret, err = snapshotSecret(x.KeyFile, false)
+		if err != nil {
+			return err
+		}
+	}
+	// comparing reads all of the data, so it's done in a change
+	changeID, err := x.client.SnapshotDiff(setID, otherSetID, snaps, secret)
+	if err != nil {
+		return err
+	}
+	chg, err := x.wait(changeID)
+	if err != nil {
+		return err
+	}
+	var diffs []client.SnapshotDiff
+	if err := chg.Get("diffs", &diffs); err != nil && err != client.ErrNoData {
+		return err
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+
+	header := false
+	row := func(snapName, user, change, item, details string) {
+		if !header {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				"Snap",
+				i18n.G("User"),
+				i18n.G("Change"),
+				i18n.G("Item"),
+				i18n.G("Details"))
+			header = true
+		}
+		if user == "" {
+			user = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", snapName, user, change, item, details)
+	}
+	for _, diff := range diffs {
+		for _, ch := range diff.Config {
+			var details string
+			switch ch.Change {
+			case client.SnapshotAdded:
+				details = fmtConfigValue(ch.New)
+			case client.SnapshotRemoved:
+				details = fmtConfigValue(ch.Old)
+			default:
+				details = fmtConfigValue(ch.Old) + " -> " + fmtConfigValue(ch.New)
+			}
+			// TRANSLATORS: %s is a configuration key
+			row(diff.Snap, "", ch.Change, fmt.Sprintf(i18n.G("config %s"), ch.Key), details)
+		}
+		for _, ch := range diff.Files {
+			details := "-"
+			if ch.Change == client.SnapshotModified {
+				details = fmtFileChange(ch.Old, ch.New)
+			}
+			row(diff.Snap, ch.User, ch.Change, ch.Path, details)
+		}
+	}
+	if !header {
+		fmt.Fprintln(Stdout, i18n.G("No differences found."))
+	}
+	return nil
+}
+
+func fmtConfigValue(v interface{}) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(bs)
+}
+
+func fmtFileChange(old, new *client.SnapshotFile) string {
+	var what []string
+	if old.Mode.Type() != new.Mode.Type() {
+		what = append(what, i18n.G("type"))
+	} else if old.Size != new.Size || old.SHA3_384 != new.SHA3_384 {
+		what = append(what, i18n.G("contents"))
+	}
+	if old.Mode.Perm() != new.Mode.Perm() {
+		// TRANSLATORS: the %s are file modes, like -rw-r--r--
+		what = append(what, fmt.Sprintf(i18n.G("mode %s -> %s"), old.Mode, new.Mode))
+	}
+	if old.Link != new.Link && old.Mode.Type() == new.Mode.Type() {
+		// TRANSLATORS: the %s are symlink targets
+		what = append(what, fmt.Sprintf(i18n.G("link %s -> %s"), old.Link, new.Link))
+	}
+	return strings.Join(what, ", ")
+}
+
 type saveCmd struct {
 	waitMixin
 	durationMixin
 	Users      string `long:"users"`
+	Encrypt    bool   `long:"encrypt"`
+	KeyFile    string `long:"key-file"`
 	Positional struct {
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
 }
 
+// snapshotSecret returns the secret to encrypt or decrypt a snapshot
+// with: the contents of the key file if one is given, or a passphrase
+// read from the terminal (twice, if confirm is set).
+func snapshotSecret(keyFile string, confirm bool) ([]byte, error) {
+	if keyFile != "" {
+		secret, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.G("cannot read key file: %v"), err)
+		}
+		if len(secret) == 0 {
+			return nil, fmt.Errorf(i18n.G("key file %q is empty"), keyFile)
+		}
+		return secret, nil
+	}
+
+	readPassphrase := func(prompt string) ([]byte, error) {
+		fmt.Fprint(Stdout, prompt)
+		passphrase, err := ReadPassword(0)
+		fmt.Fprint(Stdout, "\n")
+		if err != nil {
+			return nil, err
+		}
+		// we get \r from the pty in the tests
+		return bytes.TrimRight(passphrase, "\r\n"), nil
+	}
+	passphrase, err := readPassphrase(i18n.G("Passphrase: "))
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New(i18n.G("passphrase cannot be empty"))
+	}
+	if confirm {
+		again, err := readPassphrase(i18n.G("Repeat passphrase: "))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, again) {
+			return nil, errors.New(i18n.G("passphrases do not match"))
+		}
+	}
+	return passphrase, nil
+}
+
 func (x *saveCmd) Execute([]string) error {
 	snaps := installedSnapNames(x.Positional.Snaps)
 	users := strutil.CommaSeparatedList(x.Users)
-	setID, changeID, err := x.client.SnapshotMany(snaps, users)
+	var setID uint64
+	var changeID string
+	var err error
+	if x.Encrypt || x.KeyFile != "" {
+		var secret []byte
+		secret, err = snapshotSecret(x.KeyFile, true)
+		if err != nil {
+			return err
+		}
+		setID, changeID, err = x.client.EncryptedSnapshotMany(snaps, users, secret)
+	} else {
+		setID, changeID, err = x.client.SnapshotMany(snaps, users)
+	}
 	if err != nil {
 		return err
 	}
@@ -199,7 +480,7 @@ func (x *saveCmd) Execute([]string) error {
 	}
 
 	y := &savedCmd{
-		clientMixin:   x.clientMixin,
+		mustWaitMixin: mustWaitMixin{clientMixin: x.clientMixin},
 		durationMixin: x.durationMixin,
 		ID:            snapshotID(strconv.FormatUint(setID, 10)),
 	}
@@ -282,7 +563,10 @@ func (x *checkSnapshotCmd) Execute([]string) error {
 
 type restoreCmd struct {
 	waitMixin
-	Users      string `long:"users"`
+	Users      string   `long:"users"`
+	Decrypt    bool     `long:"decrypt"`
+	KeyFile    string   `long:"key-file"`
+	Paths      []string `long:"path"`
 	Positional struct {
 		ID    snapshotID          `positional-arg-name:"<id>"`
 		Snaps []installedSnapName `positional-arg-name:"<snap>"`
@@ -296,7 +580,20 @@ func (x *restoreCmd) Execute([]string) error {
 	}
 	snaps := installedSnapNames(x.Positional.Snaps)
 	users := strutil.CommaSeparatedList(x.Users)
-	changeID, err := x.client.RestoreSnapshots(setID, snaps, users)
+	if len(x.Paths) > 0 && len(snaps) != 1 {
+		return errors.New(i18n.G("--path requires exactly one snap"))
+	}
+	opts := &client.RestoreSnapshotOptions{}
+	for _, p := range x.Paths {
+		opts.Paths = append(opts.Paths, path.Clean(p))
+	}
+	if x.Decrypt || x.KeyFile != "" {
+		opts.Secret, err = snapshotSecret(x.KeyFile, false)
+		if err != nil {
+			return err
+		}
+	}
+	changeID, err := x.client.RestoreSnapshotsWithOptions(setID, snaps, users, opts)
 	if err != nil {
 		return err
 	}
@@ -326,9 +623,17 @@ func init() {
 		func() flags.Commander {
 			return &savedCmd{}
 		},
-		durationDescs.also(map[string]string{
+		durationDescs.also(formatDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"id": i18n.G("Show only a specific snapshot."),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"list-files": i18n.G("List the files in the data of a snap in a snapshot"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"diff": i18n.G("Compare the data of snaps in two snapshots"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"decrypt": i18n.G("Decrypt an encrypted snapshot with its passphrase"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"key-file": i18n.G("Decrypt an encrypted snapshot with the key in the given file"),
 		}),
 		nil)
 
@@ -340,6 +645,10 @@ func init() {
 		}, durationDescs.also(waitDescs).also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"users": i18n.G("Snapshot data of only specific users (comma-separated) (default: all users)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"encrypt": i18n.G("Encrypt the snapshot with a passphrase"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"key-file": i18n.G("Encrypt the snapshot with the key in the given file"),
 		}), nil)
 
 	addCommand("restore",
@@ -350,6 +659,12 @@ func init() {
 		}, waitDescs.also(map[string]string{
 			// TRANSLATORS: This should not start with a lowercase letter.
 			"users": i18n.G("Restore data of only specific users (comma-separated) (default: all users)"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"decrypt": i18n.G("Decrypt an encrypted snapshot with its passphrase"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"key-file": i18n.G("Decrypt an encrypted snapshot with the key in the given file"),
+			// TRANSLATORS: This should not start with a lowercase letter.
+			"path": i18n.G("Restore only the given file or directory (can be repeated)"),
 		}), []argDesc{
 			{
 				name: "<id>",
@@ -515,7 +830,7 @@ func (x *importSnapshotCmd) Execute([]string) error {
 	// "snap saved" command for this which displays details about
 	// the snapshot.
 	y := &savedCmd{
-		clientMixin:   x.clientMixin,
+		mustWaitMixin: mustWaitMixin{clientMixin: x.clientMixin},
 		durationMixin: x.durationMixin,
 		ID:            snapshotID(strconv.FormatUint(importSet.ID, 10)),
 	}
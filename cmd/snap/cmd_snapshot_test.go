@@ -20,6 +20,8 @@
 package main_test
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -47,6 +49,30 @@ var snapshotsTests = []getCmdArgs{{
 }, {
 	args:   "saved",
 	stdout: "Set  Snap  Age    Version  Rev   Size    Notes\n1    htop  .*  2        1168      1B  -\n",
+}, {
+	args:  "saved --list-files 1",
+	error: `--list-files requires a snapshot set id and a snap`,
+}, {
+	args:  "saved --decrypt 1 htop",
+	error: `--decrypt and --key-file can only be used with --list-files or --diff`,
+}, {
+	args:  "saved --list-files --id=1 htop",
+	error: `--list-files requires a snapshot set id and a snap`,
+}, {
+	args:  "saved --list-files x htop",
+	error: `invalid argument for snapshot set id: expected a non-negative integer argument \(see 'snap help saved'\)`,
+}, {
+	args:  "saved --diff 1",
+	error: `--diff requires two snapshot set ids`,
+}, {
+	args:  "saved --diff 1 x",
+	error: `invalid argument for snapshot set id: expected a non-negative integer argument \(see 'snap help saved'\)`,
+}, {
+	args:  "saved --diff --list-files 1 htop",
+	error: `cannot use --list-files and --diff together`,
+}, {
+	args:  "restore --path common/foo 1",
+	error: `--path requires exactly one snap`,
 }, {
 	args:  "forget x",
 	error: `invalid argument for snapshot set id: expected a non-negative integer argument \(see 'snap help saved'\)`,
@@ -167,3 +193,219 @@ Set  Snap  Age    Version  Rev   Size    Notes
 1    htop  %-6s 2        1168      1B  -
 `, ageStr))
 }
+
+func (s *SnapSuite) mockEncryptedSnapshotsServer(c *C, body *map[string]interface{}) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snaps", "/v2/snapshots":
+			if r.Method == "GET" {
+				snapshotTime := time.Now().AddDate(0, -1, 0).Format(time.RFC3339)
+				fmt.Fprintf(w, `{"type":"sync","status-code":200,"status":"OK","result":[{"id":1,"snapshots":[{"set":1,"time":%q,"snap":"htop","revision":"1168","snap-id":"Z","epoch":{"read":[0],"write":[0]},"summary":"","version":"2","sha3-384":{"archive.tgz":""},"size":1,"encryption":{"cipher":"aes-256-gcm-stream","kdf":"argon2id"}}]}]}`, snapshotTime)
+				return
+			}
+			c.Check(json.NewDecoder(r.Body).Decode(body), IsNil)
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "9", "result": {"set-id": 1}}`)
+		case "/v2/changes/9":
+			fmt.Fprintln(w, `{"type": "sync", "result": {"ready": true, "status": "Done", "data": {}}}`)
+		default:
+			c.Errorf("unexpected path %q", r.URL.Path)
+		}
+	})
+}
+
+func (s *SnapSuite) TestSaveEncryptedPassphrase(c *C) {
+	var body map[string]interface{}
+	s.mockEncryptedSnapshotsServer(c, &body)
+	s.password = "s3cr3t"
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"save", "--encrypt", "htop"})
+	c.Assert(err, IsNil)
+	c.Check(body["action"], Equals, "snapshot")
+	c.Check(body["secret"], Equals, "czNjcjN0")
+	c.Check(s.Stdout(), testutil.MatchesWrapped, `Passphrase: 
+Repeat passphrase: 
+Set  Snap  Age    Version  Rev   Size    Notes
+1    htop  .*  2        1168      1B  encrypted
+`)
+}
+
+func (s *SnapSuite) TestSaveEncryptedEmptyPassphrase(c *C) {
+	var body map[string]interface{}
+	s.mockEncryptedSnapshotsServer(c, &body)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"save", "--encrypt", "htop"})
+	c.Assert(err, ErrorMatches, "passphrase cannot be empty")
+	c.Check(body, IsNil)
+}
+
+func (s *SnapSuite) TestSaveEncryptedKeyFile(c *C) {
+	var body map[string]interface{}
+	s.mockEncryptedSnapshotsServer(c, &body)
+	keyFile := filepath.Join(c.MkDir(), "key")
+	c.Assert(os.WriteFile(keyFile, []byte("s3cr3t"), 0600), IsNil)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"save", "--key-file", keyFile, "htop"})
+	c.Assert(err, IsNil)
+	c.Check(body["secret"], Equals, "czNjcjN0")
+	c.Check(s.Stdout(), Not(testutil.Contains), "Passphrase")
+}
+
+func (s *SnapSuite) TestRestoreEncrypted(c *C) {
+	var body map[string]interface{}
+	s.mockEncryptedSnapshotsServer(c, &body)
+	s.password = "s3cr3t"
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"restore", "--decrypt", "1"})
+	c.Assert(err, IsNil)
+	c.Check(body["action"], Equals, "restore")
+	c.Check(body["secret"], Equals, "czNjcjN0")
+	c.Check(s.Stdout(), Equals, "Passphrase: \nRestored snapshot #1.\n")
+}
+
+func (s *SnapSuite) TestRestoreEncryptedEmptyKeyFile(c *C) {
+	var body map[string]interface{}
+	s.mockEncryptedSnapshotsServer(c, &body)
+	keyFile := filepath.Join(c.MkDir(), "key")
+	c.Assert(os.WriteFile(keyFile, nil, 0600), IsNil)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"restore", "--key-file", keyFile, "1"})
+	c.Assert(err, ErrorMatches, `key file ".*/key" is empty`)
+	c.Check(body, IsNil)
+}
+
+func (s *SnapSuite) TestSavedListFiles(c *C) {
+	mtime := time.Now().AddDate(0, 0, -2)
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/snapshots/1/files")
+		c.Check(r.URL.Query().Get("snap"), Equals, "htop")
+		fmt.Fprintf(w, `{"type":"sync","status-code":200,"status":"OK","result":[
+{"path":"common","mode":%d,"mtime":%[2]q},
+{"path":"common/htoprc","size":2048,"mode":420,"mtime":%[2]q},
+{"user":"someone","path":"1168/config","mode":%[3]d,"mtime":%[2]q,"link":"../common/config"}]}`,
+			uint32(os.ModeDir|0755), mtime.Format(time.RFC3339), uint32(os.ModeSymlink|0777))
+	})
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"saved", "--list-files", "--abs-time", "1", "htop"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stderr(), Equals, "")
+	c.Check(s.Stdout(), Equals, fmt.Sprintf(`User     Mode        Size    Age                   Path
+-        drwxr-xr-x  -       %[1]s  common
+-        -rw-r--r--   2048B  %[1]s  common/htoprc
+someone  Lrwxrwxrwx  -       %[1]s  1168/config -> ../common/config
+`, mtime.Format(time.RFC3339)))
+}
+
+func (s *SnapSuite) TestSavedListFilesEncrypted(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "POST")
+		c.Check(r.URL.Path, Equals, "/v2/snapshots/1/files")
+		var body map[string]interface{}
+		c.Check(json.NewDecoder(r.Body).Decode(&body), IsNil)
+		c.Check(body["snap"], Equals, "htop")
+		c.Check(body["secret"], Equals, base64.StdEncoding.EncodeToString([]byte("k3y\n")))
+		fmt.Fprintln(w, `{"type":"sync","status-code":200,"status":"OK","result":[]}`)
+	})
+	keyFile := filepath.Join(c.MkDir(), "key")
+	c.Assert(os.WriteFile(keyFile, []byte("k3y\n"), 0600), IsNil)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"saved", "--list-files", "--key-file", keyFile, "1", "htop"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "No files found.\n")
+}
+
+func (s *SnapSuite) TestSavedListFilesNone(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type":"sync","status-code":200,"status":"OK","result":[]}`)
+	})
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"saved", "--list-files", "1", "htop"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "No files found.\n")
+}
+
+func (s *SnapSuite) TestRestorePaths(c *C) {
+	var body map[string]interface{}
+	s.mockEncryptedSnapshotsServer(c, &body)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"restore", "--path", "common/db/", "--path", "1168/config.toml", "1", "htop"})
+	c.Assert(err, IsNil)
+	c.Check(body["action"], Equals, "restore")
+	c.Check(body["snaps"], DeepEquals, []interface{}{"htop"})
+	c.Check(body["paths"], DeepEquals, []interface{}{"common/db", "1168/config.toml"})
+	c.Check(body["secret"], IsNil)
+	c.Check(s.Stdout(), Equals, "Restored snapshot #1 of snaps \"htop\".\n")
+}
+
+// mockSnapshotDiffServer mocks a server doing a diff, with the given diffs
+// as the data of its change.
+func (s *SnapSuite) mockSnapshotDiffServer(c *C, body *map[string]interface{}, diffs string) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/snapshots":
+			c.Check(r.Method, Equals, "POST")
+			c.Check(json.NewDecoder(r.Body).Decode(body), IsNil)
+			w.WriteHeader(202)
+			fmt.Fprintln(w, `{"type":"async", "status-code": 202, "change": "9"}`)
+		case "/v2/changes/9":
+			fmt.Fprintf(w, `{"type": "sync", "result": {"ready": true, "status": "Done", "data": {"diffs": %s}}}`, diffs)
+		default:
+			c.Errorf("unexpected path %q", r.URL.Path)
+		}
+	})
+}
+
+func (s *SnapSuite) TestSavedDiff(c *C) {
+	var body map[string]interface{}
+	s.mockSnapshotDiffServer(c, &body, fmt.Sprintf(`[{
+"snap":"htop",
+"config":[
+ {"key":"color","change":"modified","old":"red","new":"blue"},
+ {"key":"tree.depth","change":"added","new":3},
+ {"key":"verbose","change":"removed","old":true}],
+"files":[
+ {"path":"common/htoprc","change":"modified","old":{"path":"common/htoprc","size":3,"mode":420,"sha3-384":"a"},"new":{"path":"common/htoprc","size":3,"mode":384,"sha3-384":"b"}},
+ {"path":"common/new","change":"added","new":{"path":"common/new","mode":420}},
+ {"user":"bob","path":"1168/link","change":"modified","old":{"path":"1168/link","mode":%[1]d,"link":"a"},"new":{"path":"1168/link","mode":%[1]d,"link":"b"}}]
+}]`, uint32(os.ModeSymlink|0777)))
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"saved", "--diff", "1", "2", "htop"})
+	c.Assert(err, IsNil)
+	c.Check(body, DeepEquals, map[string]interface{}{
+		"set":       1.,
+		"other-set": 2.,
+		"action":    "diff",
+		"snaps":     []interface{}{"htop"},
+	})
+	c.Check(s.Stderr(), Equals, "")
+	c.Check(s.Stdout(), Equals, `Snap  User  Change    Item               Details
+htop  -     modified  config color       "red" -> "blue"
+htop  -     added     config tree.depth  3
+htop  -     removed   config verbose     true
+htop  -     modified  common/htoprc      contents, mode -rw-r--r-- -> -rw-------
+htop  -     added     common/new         -
+htop  bob   modified  1168/link          link a -> b
+`)
+}
+
+func (s *SnapSuite) TestSavedDiffEncrypted(c *C) {
+	var body map[string]interface{}
+	s.mockSnapshotDiffServer(c, &body, `[{"snap":"htop"}]`)
+	s.password = "s3cr3t"
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"saved", "--diff", "--decrypt", "1", "2"})
+	c.Assert(err, IsNil)
+	c.Check(body["action"], Equals, "diff")
+	c.Check(body["secret"], Equals, "czNjcjN0")
+	c.Check(s.Stdout(), Equals, "Passphrase: \nNo differences found.\n")
+}
+
+func (s *SnapSuite) TestSavedDiffNone(c *C) {
+	var body map[string]interface{}
+	s.mockSnapshotDiffServer(c, &body, `[{"snap":"htop"}]`)
+
+	_, err := main.Parser(main.Client()).ParseArgs([]string{"saved", "--diff", "1", "2"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "No differences found.\n")
+}
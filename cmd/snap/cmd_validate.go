@@ -42,6 +42,7 @@ type cmdValidate struct {
 	} `positional-args:"yes"`
 	colorMixin
 	waitMixin
+	formatMixin
 }
 
 var shortValidateHelp = i18n.G("List or apply validation sets")
@@ -56,7 +57,7 @@ operations which would result in snaps breaking the validation set's constraints
 `)
 
 func init() {
-	addCommand("validate", shortValidateHelp, longValidateHelp, func() flags.Commander { return &cmdValidate{} }, waitDescs.also(colorDescs.also(map[string]string{
+	addCommand("validate", shortValidateHelp, longValidateHelp, func() flags.Commander { return &cmdValidate{} }, waitDescs.also(colorDescs).also(formatDescs).also(map[string]string{
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"monitor": i18n.G("Monitor the given validations set"),
 		// TRANSLATORS: This should not start with a lowercase letter.
@@ -65,7 +66,7 @@ func init() {
 		"forget": i18n.G("Forget the given validation set"),
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"refresh": i18n.G("Refresh or install snaps to satisfy enforced validation sets"),
-	})), []argDesc{{
+	}), []argDesc{{
 		// TRANSLATORS: This needs to begin with < and end with >
 		name: i18n.G("<validation-set>"),
 		// TRANSLATORS: This should not start with a lowercase letter.
@@ -114,6 +115,10 @@ func (cmd *cmdValidate) Execute(args []string) error {
 		return fmt.Errorf("missing validation set argument")
 	}
 
+	if cmd.formatted() && action != "" {
+		return fmt.Errorf("--format can only be used when listing or querying validation sets")
+	}
+
 	var accountID, name string
 	var seq int
 	var err error
@@ -181,6 +186,9 @@ func (cmd *cmdValidate) Execute(args []string) error {
 		if err != nil {
 			return err
 		}
+		if cmd.formatted() {
+			return cmd.writeFormatted(vsets)
+		}
 		if len(vsets) == 0 {
 			fmt.Fprintln(Stderr, i18n.G("No validations are available"))
 			return nil
@@ -210,6 +218,9 @@ func (cmd *cmdValidate) Execute(args []string) error {
 		if err != nil {
 			return err
 		}
+		if cmd.formatted() {
+			return cmd.writeFormatted(vset)
+		}
 		fmt.Fprintln(Stdout, fmtValid(vset))
 		// XXX: exit status 1 if invalid?
 	}
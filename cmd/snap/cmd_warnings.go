@@ -39,6 +39,7 @@ type cmdWarnings struct {
 	clientMixin
 	timeMixin
 	unicodeMixin
+	formatMixin
 	All     bool `long:"all"`
 	Verbose bool `long:"verbose"`
 }
@@ -65,7 +66,7 @@ sufficient time has passed.
 `)
 
 func init() {
-	addCommand("warnings", shortWarningsHelp, longWarningsHelp, func() flags.Commander { return &cmdWarnings{} }, timeDescs.also(unicodeDescs).also(map[string]string{
+	addCommand("warnings", shortWarningsHelp, longWarningsHelp, func() flags.Commander { return &cmdWarnings{} }, timeDescs.also(unicodeDescs).also(formatDescs).also(map[string]string{
 		// TRANSLATORS: This should not start with a lowercase letter.
 		"all": i18n.G("Show all warnings"),
 		// TRANSLATORS: This should not start with a lowercase letter.
@@ -84,6 +85,14 @@ func (cmd *cmdWarnings) Execute(args []string) error {
 	if err != nil {
 		return err
 	}
+	if cmd.formatted() {
+		if len(warnings) > 0 {
+			if err := writeWarningTimestamp(now); err != nil {
+				return err
+			}
+		}
+		return cmd.writeFormatted(warnings)
+	}
 	if len(warnings) == 0 {
 		if t, _ := lastWarningTimestamp(); t.IsZero() {
 			fmt.Fprintln(Stdout, i18n.G("No warnings."))
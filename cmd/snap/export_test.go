@@ -29,6 +29,7 @@ import (
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/cmd/snaplock/runinhibit"
 	"github.com/snapcore/snapd/image"
+	"github.com/snapcore/snapd/interfaces/denials"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/sandbox/cgroup"
@@ -101,6 +102,8 @@ var (
 	SnapInstancesAndComponentsFromNames = snapInstancesAndComponentsFromNames
 
 	GetSystemKeyRetryCount = getSystemKeyRetryCount
+
+	ParseAt = parseAt
 )
 
 func HiddenCmd(descr string, completeHidden bool) *cmdInfo {
@@ -174,6 +177,16 @@ func MockMaxGoneTime(d time.Duration) (restore func()) {
 	}
 }
 
+type DenialsRecorder = denialsRecorder
+
+func MockNewDenialsRecorder(f func(instanceName string) (DenialsRecorder, error)) (restore func()) {
+	return testutil.Mock(&newDenialsRecorder, f)
+}
+
+func MockDenialsResolveSyscallNames(f func(events []*denials.Event, snapSeccomp string) error) (restore func()) {
+	return testutil.Mock(&denialsResolveSyscallNames, f)
+}
+
 func MockSyscallExec(f func(string, []string, []string) error) (restore func()) {
 	syscallExecOrig := syscallExec
 	syscallExec = f
@@ -0,0 +1,163 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/jsonutil"
+)
+
+// outputFormat is a machine-readable format requested with the global
+// --format option.
+type outputFormat struct {
+	name string
+	tmpl *template.Template
+}
+
+const goTemplatePrefix = "go-template="
+
+func parseOutputFormat(format string) (*outputFormat, error) {
+	switch {
+	case format == "json" || format == "yaml":
+		return &outputFormat{name: format}, nil
+	case strings.HasPrefix(format, goTemplatePrefix):
+		tmpl, err := template.New("format").Parse(strings.TrimPrefix(format, goTemplatePrefix))
+		if err != nil {
+			return nil, fmt.Errorf(i18n.G("cannot parse --format template: %v"), err)
+		}
+		return &outputFormat{name: "go-template", tmpl: tmpl}, nil
+	}
+	return nil, fmt.Errorf(i18n.G("unknown output format %q, expected json, yaml or go-template=<template>"), format)
+}
+
+type formatSetter interface {
+	setFormat(globalFormat string) error
+}
+
+// formatMixin is embedded by the commands that support writing their output
+// in a machine-readable format. The format can also be given with the
+// global --format option, which other commands refuse.
+type formatMixin struct {
+	Format string `long:"format"`
+	format *outputFormat
+}
+
+var formatDescs = mixinDescs{
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"format": i18n.G("Write the output as json, yaml or with a Go template (go-template=<template>)"),
+}
+
+func (mx *formatMixin) setFormat(globalFormat string) error {
+	format := mx.Format
+	if format == "" {
+		format = globalFormat
+	}
+	if format == "" {
+		return nil
+	}
+	f, err := parseOutputFormat(format)
+	if err != nil {
+		return err
+	}
+	mx.format = f
+	return nil
+}
+
+// formatted returns whether the output is to be written in the requested
+// format, using writeFormatted, instead of as text.
+func (mx *formatMixin) formatted() bool {
+	return mx.format != nil
+}
+
+// writeFormatted writes v, one of the structures returned by the client,
+// to Stdout in the requested format. Go templates are
+// executed against v itself, while JSON and YAML use its JSON encoding.
+func (mx *formatMixin) writeFormatted(v interface{}) error {
+	// no results are an empty list rather than null
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+
+	switch mx.format.name {
+	case "go-template":
+		var buf bytes.Buffer
+		if err := mx.format.tmpl.Execute(&buf, v); err != nil {
+			return fmt.Errorf(i18n.G("cannot execute --format template: %v"), err)
+		}
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		_, err := Stdout.Write(buf.Bytes())
+		return err
+	case "json":
+		enc := json.NewEncoder(Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	// go through JSON so that YAML uses the same field names and values
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := jsonutil.DecodeWithNumber(bytes.NewReader(data), &generic); err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(withoutJSONNumbers(generic)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// withoutJSONNumbers returns v with the numbers decoded from JSON turned
+// into integers or floats, as the YAML encoder doesn't know about them.
+func withoutJSONNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = withoutJSONNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = withoutJSONNumbers(e)
+		}
+	}
+	return v
+}
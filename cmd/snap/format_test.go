@@ -0,0 +1,71 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestFormatNotSupported(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"--format=json", "okay"})
+	c.Check(err, check.ErrorMatches, "this command does not support --format")
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"okay", "--format=json"})
+	c.Check(err, check.ErrorMatches, "this command does not support --format")
+}
+
+func (s *SnapSuite) TestFormatInvalid(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--format=xml"})
+	c.Check(err, check.ErrorMatches, `unknown output format "xml", expected json, yaml or go-template=<template>`)
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"--format=go-template={{.Name", "list"})
+	c.Check(err, check.ErrorMatches, `cannot parse --format template: .*unclosed action`)
+}
+
+func (s *SnapSuite) TestFormatTemplateError(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "foo"}]}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"list", "--format=go-template={{.Name}}"})
+	c.Check(err, check.ErrorMatches, `cannot execute --format template: .*can't evaluate field Name.*`)
+}
+
+func (s *SnapSuite) TestFormatDoesNotLeak(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser(snap.Client()).ParseArgs([]string{"--format=json", "list"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "[]\n")
+	s.ResetStdStreams()
+
+	// a new parser starts afresh, and okay runs
+	_, err = snap.Parser(snap.Client()).ParseArgs([]string{"okay"})
+	c.Check(err, check.ErrorMatches, "you must have looked at the warnings before acknowledging them.*")
+}
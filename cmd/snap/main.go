@@ -70,6 +70,7 @@ var (
 
 type options struct {
 	Version func() `long:"version"`
+	Format  string `long:"format"`
 }
 
 type argDesc struct {
@@ -105,6 +106,13 @@ var debugCommands []*cmdInfo
 // routineCommands holds information about all internal commands.
 var routineCommands []*cmdInfo
 
+// manifestCommands holds information about all manifest commands.
+var manifestCommands []*cmdInfo
+
+// promptingRulesCommands holds information about all prompting-rules
+// commands.
+var promptingRulesCommands []*cmdInfo
+
 // addCommand replaces parser.addCommand() in a way that is compatible with
 // re-constructing a pristine parser.
 func addCommand(name, shortHelp, longHelp string, builder func() flags.Commander, optDescs map[string]string, argDescs []argDesc) *cmdInfo {
@@ -152,6 +160,38 @@ func addRoutineCommand(name, shortHelp, longHelp string, builder func() flags.Co
 	return info
 }
 
+// addManifestCommand replaces parser.addCommand() in a way that is
+// compatible with re-constructing a pristine parser. It is meant for
+// adding "snap manifest" commands.
+func addManifestCommand(name, shortHelp, longHelp string, builder func() flags.Commander, optDescs map[string]string, argDescs []argDesc) *cmdInfo {
+	info := &cmdInfo{
+		name:      name,
+		shortHelp: shortHelp,
+		longHelp:  longHelp,
+		builder:   builder,
+		optDescs:  optDescs,
+		argDescs:  argDescs,
+	}
+	manifestCommands = append(manifestCommands, info)
+	return info
+}
+
+// addPromptingRulesCommand replaces parser.addCommand() in a way that is
+// compatible with re-constructing a pristine parser. It is meant for
+// adding "snap prompting-rules" commands.
+func addPromptingRulesCommand(name, shortHelp, longHelp string, builder func() flags.Commander, optDescs map[string]string, argDescs []argDesc) *cmdInfo {
+	info := &cmdInfo{
+		name:      name,
+		shortHelp: shortHelp,
+		longHelp:  longHelp,
+		builder:   builder,
+		optDescs:  optDescs,
+		argDescs:  argDescs,
+	}
+	promptingRulesCommands = append(promptingRulesCommands, info)
+	return info
+}
+
 type parserSetter interface {
 	setParser(*flags.Parser)
 }
@@ -317,6 +357,7 @@ func Parser(cli *client.Client) *flags.Parser {
 		printVersions(cli)
 		panic(&exitStatus{0})
 	}
+	optionsData.Format = ""
 	flagopts := flags.Options(flags.PassDoubleDash)
 	if firstNonOptionIsRun() {
 		flagopts |= flags.PassAfterNonOption
@@ -331,10 +372,26 @@ func Parser(cli *client.Client) *flags.Parser {
 		version.Description = i18n.G("Print the version and exit")
 		version.Hidden = true
 	}
+	// the commands that support --format have their own, this one is for
+	// when it's given before the command
+	if format := parser.FindOptionByLongName("format"); format != nil {
+		format.Description = i18n.G("Write the output as json, yaml or with a Go template (go-template=<template>)")
+		format.Hidden = true
+	}
+	parser.CommandHandler = func(command flags.Commander, args []string) error {
+		if x, ok := command.(formatSetter); ok {
+			if err := x.setFormat(optionsData.Format); err != nil {
+				return err
+			}
+		} else if optionsData.Format != "" {
+			return errors.New(i18n.G("this command does not support --format"))
+		}
+		return command.Execute(args)
+	}
 	// add --help like what go-flags would do for us, but hidden
 	addHelp(parser)
 
-	seen := make(map[string]bool, len(commands)+len(debugCommands)+len(routineCommands))
+	seen := make(map[string]bool, len(commands)+len(debugCommands)+len(routineCommands)+len(manifestCommands)+len(promptingRulesCommands))
 	checkUnique := func(ci *cmdInfo, kind string) {
 		if seen[ci.shortHelp] && ci.shortHelp != "Internal" && ci.shortHelp != "Deprecated (hidden)" {
 			logger.Panicf(`%scommand %q has an already employed description != "Internal"|"Deprecated (hidden)": %s`, kind, ci.name, ci.shortHelp)
@@ -365,6 +422,24 @@ func Parser(cli *client.Client) *flags.Parser {
 	registerCommands(cli, parser, routineCommand, routineCommands, func(ci *cmdInfo) {
 		checkUnique(ci, "routine ")
 	})
+	// Add the manifest command
+	manifestCommand, err := parser.AddCommand("manifest", shortManifestHelp, longManifestHelp, &cmdManifest{})
+	if err != nil {
+		logger.Panicf("cannot add command %q: %v", "manifest", err)
+	}
+	// Add all the sub-commands of the manifest command
+	registerCommands(cli, parser, manifestCommand, manifestCommands, func(ci *cmdInfo) {
+		checkUnique(ci, "manifest ")
+	})
+	// Add the prompting-rules command
+	promptingRulesCommand, err := parser.AddCommand("prompting-rules", shortPromptingRulesHelp, longPromptingRulesHelp, &cmdPromptingRules{})
+	if err != nil {
+		logger.Panicf("cannot add command %q: %v", "prompting-rules", err)
+	}
+	// Add all the sub-commands of the prompting-rules command
+	registerCommands(cli, parser, promptingRulesCommand, promptingRulesCommands, func(ci *cmdInfo) {
+		checkUnique(ci, "prompting-rules ")
+	})
 	return parser
 }
 
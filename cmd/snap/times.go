@@ -20,6 +20,7 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -61,3 +62,93 @@ func (mx durationMixin) fmtDuration(t time.Time) string {
 	}
 	return strings.TrimSpace(quantity.FormatDuration(time.Since(t).Seconds()))
 }
+
+type atMixin struct {
+	At string `long:"at"`
+}
+
+var atDescs = mixinDescs{
+	// TRANSLATORS: This should not start with a lowercase letter.
+	"at": i18n.G("Create the change now, but hold it until the given time: a time of the day (HH:MM), optionally preceded by a day of the week (such as \"tue 02:00\"), a date and a time (YYYY-MM-DD HH:MM) or a time in RFC 3339 format"),
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(s)
+	if len(s) < 3 {
+		return 0, false
+	}
+	wd, ok := weekdays[s[:3]]
+	if !ok || !strings.HasPrefix(strings.ToLower(wd.String()), s) {
+		return 0, false
+	}
+	return wd, true
+}
+
+// parseAt returns the time described by s, as taken by --at; times of the
+// day, on a given day of the week or not, are the next ones to come.
+func parseAt(s string) (time.Time, error) {
+	now := timeNow()
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	fields := strings.Fields(s)
+	weekday := time.Weekday(-1)
+	if len(fields) == 2 {
+		wd, ok := parseWeekday(fields[0])
+		if !ok {
+			return time.Time{}, fmt.Errorf(i18n.G("cannot parse --at %q: unknown day of the week %q"), s, fields[0])
+		}
+		weekday = wd
+		fields = fields[1:]
+	}
+	if len(fields) != 1 {
+		return time.Time{}, fmt.Errorf(i18n.G("cannot parse --at %q: expected a time of the day, optionally preceded by a day of the week, a date and a time (YYYY-MM-DD HH:MM) or a time in RFC 3339 format"), s)
+	}
+	clock, err := time.Parse("15:04", fields[0])
+	if err != nil {
+		return time.Time{}, fmt.Errorf(i18n.G("cannot parse --at %q: invalid time of the day %q"), s, fields[0])
+	}
+
+	now = now.In(time.Local)
+	t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+	for !t.After(now) || (weekday >= 0 && t.Weekday() != weekday) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// atTime returns the time given with --at, in RFC 3339 format, or an empty
+// string if none was.
+func (mx atMixin) atTime() (string, error) {
+	if mx.At == "" {
+		return "", nil
+	}
+	t, err := parseAt(mx.At)
+	if err != nil {
+		return "", err
+	}
+	if !t.After(timeNow()) {
+		return "", fmt.Errorf(i18n.G("cannot hold change until %s: time is in the past"), t.Format(time.RFC3339))
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// showScheduled tells about the change held until the given time, instead
+// of waiting for it.
+func (mx atMixin) showScheduled(changeID, at string) {
+	fmt.Fprintf(Stdout, i18n.G("Change %s scheduled for %s.\n"), changeID, at)
+}
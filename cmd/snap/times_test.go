@@ -0,0 +1,68 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"time"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestParseAt(c *check.C) {
+	// a Saturday
+	now := time.Date(2026, 10, 17, 12, 30, 0, 0, time.Local)
+	restore := snap.MockTimeNow(func() time.Time { return now })
+	defer restore()
+
+	for _, t := range []struct {
+		in       string
+		expected time.Time
+	}{
+		{"2026-10-20T02:00:00Z", time.Date(2026, 10, 20, 2, 0, 0, 0, time.UTC)},
+		{"2026-10-20 02:00", time.Date(2026, 10, 20, 2, 0, 0, 0, time.Local)},
+		{"13:00", time.Date(2026, 10, 17, 13, 0, 0, 0, time.Local)},
+		{"02:00", time.Date(2026, 10, 18, 2, 0, 0, 0, time.Local)},
+		{"12:30", time.Date(2026, 10, 18, 12, 30, 0, 0, time.Local)},
+		{"tue 02:00", time.Date(2026, 10, 20, 2, 0, 0, 0, time.Local)},
+		{"Tuesday 02:00", time.Date(2026, 10, 20, 2, 0, 0, 0, time.Local)},
+		{"sat 13:00", time.Date(2026, 10, 17, 13, 0, 0, 0, time.Local)},
+		{"sat 02:00", time.Date(2026, 10, 24, 2, 0, 0, 0, time.Local)},
+	} {
+		at, err := snap.ParseAt(t.in)
+		c.Assert(err, check.IsNil, check.Commentf(t.in))
+		c.Check(at.Equal(t.expected), check.Equals, true, check.Commentf("%s: %s", t.in, at))
+	}
+
+	for _, t := range []struct {
+		in  string
+		err string
+	}{
+		{"tomorrow", `cannot parse --at "tomorrow": invalid time of the day "tomorrow"`},
+		{"tu 02:00", `cannot parse --at "tu 02:00": unknown day of the week "tu"`},
+		{"tuesdays 02:00", `cannot parse --at "tuesdays 02:00": unknown day of the week "tuesdays"`},
+		{"tue 25:00", `cannot parse --at "tue 25:00": invalid time of the day "25:00"`},
+		{"next tue 02:00", `cannot parse --at "next tue 02:00": expected .*`},
+	} {
+		_, err := snap.ParseAt(t.in)
+		c.Check(err, check.ErrorMatches, t.err)
+	}
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/snapcore/snapd/snapdtool"
 	"github.com/snapcore/snapd/syscheck"
 	"github.com/snapcore/snapd/systemd"
+	"github.com/snapcore/snapd/timings"
 )
 
 var (
@@ -122,6 +123,10 @@ func run(ch chan os.Signal) error {
 	ctx := context.Background()
 
 	t0 := time.Now().Truncate(time.Millisecond)
+
+	if path := os.Getenv("SNAPD_TIMINGS_OTLP_FILE"); path != "" {
+		timings.EnableOTLPExport(path)
+	}
 	snapdenv.SetUserAgentFromVersion(snapdtool.Version, sandbox.ForceDevMode)
 
 	d, err := daemon.New()
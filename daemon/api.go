@@ -69,6 +69,7 @@ var api = []*Command{
 	debugCmd,
 	snapshotCmd,
 	snapshotExportCmd,
+	snapshotFilesCmd,
 	connectionsCmd,
 	modelCmd,
 	cohortsCmd,
@@ -93,6 +94,8 @@ var api = []*Command{
 	requestsRuleCmd,
 	systemSecurebootCmd,
 	systemVolumesCmd,
+	metricsCmd,
+	manifestCmd,
 }
 
 const (
@@ -163,6 +166,7 @@ var (
 	snapstateStoreUpdateGoal                = snapstate.StoreUpdateGoal
 	snapstateUpdateWithGoal                 = snapstate.UpdateWithGoal
 	snapstateUpdateOne                      = snapstate.UpdateOne
+	snapstateSimulateUpdate                 = snapstate.SimulateUpdate
 	snapstateRemove                         = snapstate.Remove
 	snapstateRemoveMany                     = snapstate.RemoveMany
 	snapstateResolveValSetsEnforcementError = snapstate.ResolveValidationSetsEnforcementError
@@ -29,6 +29,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/snapcore/snapd/client/clientutil"
 	"github.com/snapcore/snapd/osutil/user"
@@ -311,6 +312,19 @@ func postApps(c *Command, r *http.Request, user *auth.UserState) Response {
 	if err := inst.Validate(u, appInfos); err != nil {
 		return BadRequest("cannot perform operation on services: %v", err)
 	}
+	var at time.Time
+	if inst.At != "" {
+		if inst.Action != "restart" {
+			return BadRequest(`cannot perform operation on services: at can only be specified for the "restart" action`)
+		}
+		at, err = time.Parse(time.RFC3339, inst.At)
+		if err != nil {
+			return BadRequest("cannot perform operation on services: at must be in RFC3339 format: %v", err)
+		}
+		if !at.After(time.Now()) {
+			return BadRequest("cannot perform operation on services: at must be in the future")
+		}
+	}
 	inst.EnsureDefaultScopeForUser(u)
 
 	// do not pass flags - only create service-control tasks, do not create
@@ -329,6 +343,10 @@ func postApps(c *Command, r *http.Request, user *auth.UserState) Response {
 	// names received in the request can be snap or snap.app, we need to
 	// extract the actual snap names before associating them with a change
 	chg := newChange(st, "service-control", "Running service command", tss, namesToSnapNames(inst))
+	if !at.IsZero() {
+		// held until then
+		chg.At(at)
+	}
 	st.EnsureBefore(0)
 	return AsyncResponse(nil, chg.ID())
 }
@@ -32,6 +32,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -771,6 +772,51 @@ func (s *appsSuite) TestPostAppsReload(c *check.C) {
 	s.testPostApps(c, inst, expected)
 }
 
+func (s *appsSuite) TestPostAppsRestartAt(c *check.C) {
+	when := time.Now().Add(time.Hour).Truncate(time.Second)
+	inst := servicestate.Instruction{Action: "restart", Names: []string{"snap-a.svc2"}}
+	inst.At = when.Format(time.RFC3339)
+	postBody, err := json.Marshal(inst)
+	c.Assert(err, check.IsNil)
+
+	req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBuffer(postBody))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, s.authUser)
+	c.Assert(rsp.Status, check.Equals, 202)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.ScheduledTime().Equal(when), check.Equals, true)
+	for _, t := range chg.Tasks() {
+		c.Check(t.AtTime().Equal(when), check.Equals, true)
+		c.Check(t.Status(), check.Equals, state.DoStatus)
+	}
+}
+
+func (s *appsSuite) TestPostAppsAtErrors(c *check.C) {
+	for _, t := range []struct {
+		inst servicestate.Instruction
+		at   string
+		err  string
+	}{
+		{servicestate.Instruction{Action: "start", Names: []string{"snap-a.svc2"}}, "tomorrow", `cannot perform operation on services: at can only be specified for the "restart" action`},
+		{servicestate.Instruction{Action: "restart", Names: []string{"snap-a.svc2"}}, "tomorrow", `cannot perform operation on services: at must be in RFC3339 format: .*`},
+		{servicestate.Instruction{Action: "restart", Names: []string{"snap-a.svc2"}}, "2020-10-20T02:00:00Z", `cannot perform operation on services: at must be in the future`},
+	} {
+		t.inst.At = t.at
+		postBody, err := json.Marshal(t.inst)
+		c.Assert(err, check.IsNil)
+		req, err := http.NewRequest("POST", "/v2/apps", bytes.NewBuffer(postBody))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, s.authUser)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Matches, t.err)
+	}
+}
+
 func (s *appsSuite) TestPostAppsEnableNow(c *check.C) {
 	inst := servicestate.Instruction{Action: "start", Names: []string{"snap-a.svc2"}}
 	inst.Enable = true
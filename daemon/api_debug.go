@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/snapcore/snapd/asserts"
@@ -32,6 +33,7 @@ import (
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/devicestate"
+	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/timings"
@@ -106,7 +108,8 @@ type changeTimings struct {
 
 type debugTimings struct {
 	ChangeID string `json:"change-id"`
-	// total duration of the activity - present for ensure and startup timings only
+	// start time and total duration of the activity - present for ensure and startup timings only
+	StartTime      time.Time             `json:"start-time,omitzero"`
 	TotalDuration  time.Duration         `json:"total-duration,omitempty"`
 	EnsureTimings  []*timings.TimingJSON `json:"ensure-timings,omitempty"`
 	StartupTimings []*timings.TimingJSON `json:"startup-timings,omitempty"`
@@ -200,6 +203,7 @@ func collectEnsureTimings(st *state.State, ensureTag string, allEnsures bool) ([
 			ChangeID:      ensureChangeID,
 			ChangeTimings: changeTimings,
 			EnsureTimings: ensureTm.NestedTimings,
+			StartTime:     ensureTm.StartTime,
 			TotalDuration: ensureTm.Duration,
 		}
 		responseData = append(responseData, debugTm)
@@ -228,6 +232,7 @@ func collectStartupTimings(st *state.State, startupTag string, allStarts bool) (
 	for _, startTm := range starts[first:] {
 		debugTm := &debugTimings{
 			StartupTimings: startTm.NestedTimings,
+			StartTime:      startTm.StartTime,
 			TotalDuration:  startTm.Duration,
 		}
 		responseData = append(responseData, debugTm)
@@ -261,13 +266,24 @@ func getChangeTimings(st *state.State, changeID, ensureTag, startupTag string, a
 		return BadRequest(err.Error())
 	}
 
-	responseData := []*debugTimings{
-		{
-			ChangeID:      changeID,
-			ChangeTimings: changeTimings,
-		},
+	debugTm := &debugTimings{
+		ChangeID:      changeID,
+		ChangeTimings: changeTimings,
+	}
+	// include the ensure activity which created the change, if any
+	ensures, err := timings.Get(st, -1, func(tags map[string]string) bool {
+		return tags["ensure"] != "" && tags["change-id"] == changeID
+	})
+	if err != nil {
+		return InternalError("cannot get timings of change %s: %v", changeID, err)
+	}
+	if len(ensures) > 0 {
+		ensureTm := ensures[len(ensures)-1]
+		debugTm.EnsureTimings = ensureTm.NestedTimings
+		debugTm.StartTime = ensureTm.StartTime
+		debugTm.TotalDuration = ensureTm.Duration
 	}
-	return SyncResponse(responseData)
+	return SyncResponse([]*debugTimings{debugTm})
 }
 
 func getGadgetDiskMapping(st *state.State) Response {
@@ -338,6 +354,22 @@ func createRecovery(st *state.State, label string) Response {
 	return AsyncResponse(nil, chg.ID())
 }
 
+func explainConnection(c *Command, plug, slot string) Response {
+	plugSnap, plugName, _ := strings.Cut(plug, ":")
+	if plugSnap == "" || plugName == "" {
+		return BadRequest("cannot explain connection: invalid plug %q", plug)
+	}
+	slotSnap, slotName, _ := strings.Cut(slot, ":")
+	plugSnap = ifacestate.RemapSnapFromRequest(plugSnap)
+	slotSnap = ifacestate.RemapSnapFromRequest(slotSnap)
+
+	expl, err := c.d.overlord.InterfaceManager().ExplainConnection(plugSnap, plugName, slotSnap, slotName)
+	if err != nil {
+		return BadRequest("cannot explain connection: %v", err)
+	}
+	return SyncResponse(expl)
+}
+
 func getDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 	query := r.URL.Query()
 	aspect := query.Get("aspect")
@@ -372,6 +404,8 @@ func getDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return getDisks(st)
 	case "raa":
 		return getRAAInfo(st)
+	case "explain-connection":
+		return explainConnection(c, query.Get("plug"), query.Get("slot"))
 	default:
 		return BadRequest("unknown debug aspect %q", aspect)
 	}
@@ -413,6 +447,8 @@ func postDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return createRecovery(st, a.Params.RecoverySystemLabel)
 	case "migrate-home":
 		return migrateHome(st, a.Snaps)
+	case "bundle":
+		return debugBundle(c, st)
 	default:
 		return BadRequest("unknown debug action: %v", a.Action)
 	}
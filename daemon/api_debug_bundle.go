@@ -0,0 +1,323 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/systemd"
+	"github.com/snapcore/snapd/timings"
+)
+
+var (
+	bootDebugDumpBootVars = boot.DebugDumpBootVars
+
+	debugBundleJournal = func(n int) (io.ReadCloser, error) {
+		sysd := systemd.New(systemd.SystemMode, nil)
+		return sysd.LogReader([]string{"snapd.service", "snapd.socket"}, n, false, false)
+	}
+)
+
+// debugBundleJournalLines is the number of lines of the snapd journal
+// included in a debug bundle.
+const debugBundleJournalLines = 5000
+
+const redactedValue = "<redacted>"
+
+// redactedKeys are the keys whose values are replaced when found anywhere in
+// the state, the changes or the tasks put in a debug bundle.
+var redactedKeys = map[string]bool{
+	// auth.AuthState, auth.UserState and auth.DeviceState
+	"macaroon-key":     true,
+	"macaroon":         true,
+	"discharges":       true,
+	"store-macaroon":   true,
+	"store-discharges": true,
+	"session-macaroon": true,
+	"email":            true,
+	// the salt of the refresh hints sent to the store
+	"refresh-privacy-key": true,
+	// the cookies identifying snaps to snapctl are the keys of the map
+	"snap-cookies": true,
+}
+
+// redactedSubtrees are the keys under which all values are replaced, while
+// keeping the structure of the data visible.
+var redactedSubtrees = map[string]bool{
+	// confdb data, pending transactions and the data read by them
+	"confdb-databags":    true,
+	"confdb-ongoing-txs": true,
+	"confdb-transaction": true,
+	"confdb-data":        true,
+	// proxy settings in the configuration may carry credentials
+	"proxy": true,
+	// digests of the FDE primary keys
+	"primary-keys": true,
+}
+
+// redactJSON replaces the secrets in the given JSON document.
+func redactJSON(data []byte) ([]byte, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(redactValue(v, false)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func redactValue(v any, all bool) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, sub := range x {
+			switch {
+			case redactedKeys[k] && sub != nil:
+				x[k] = redactedValue
+			default:
+				x[k] = redactValue(sub, all || redactedSubtrees[k])
+			}
+		}
+		return x
+	case []any:
+		for i, sub := range x {
+			x[i] = redactValue(sub, all)
+		}
+		return x
+	case nil:
+		return nil
+	default:
+		if all {
+			return redactedValue
+		}
+		return x
+	}
+}
+
+type debugBundleFile struct {
+	name string
+	data []byte
+}
+
+// debugBundleResponse streams a debug bundle as a gzipped tar archive. The
+// data derived from the state is collected upfront, while holding the state
+// lock, and the rest is collected from the system while streaming.
+type debugBundleResponse struct {
+	files  []debugBundleFile
+	errors []string
+}
+
+func (rsp *debugBundleResponse) add(name string, data []byte) {
+	rsp.files = append(rsp.files, debugBundleFile{name: name, data: data})
+}
+
+func (rsp *debugBundleResponse) addJSON(name string, v any) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		data, err = redactJSON(data)
+	}
+	if err != nil {
+		rsp.addError("cannot collect %s: %v", name, err)
+		return
+	}
+	rsp.add(name, data)
+}
+
+func (rsp *debugBundleResponse) addError(format string, args ...any) {
+	rsp.errors = append(rsp.errors, fmt.Sprintf(format, args...))
+}
+
+// addFile adds the given file, if it exists.
+func (rsp *debugBundleResponse) addFile(name, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			rsp.addError("cannot collect %s: %v", name, err)
+		}
+		return
+	}
+	rsp.add(name, data)
+}
+
+// addDir adds the regular files of the given directory matching the given
+// glob pattern, if the directory exists.
+func (rsp *debugBundleResponse) addDir(name, dir, pattern string) {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		rsp.addError("cannot collect %s: %v", name, err)
+		return
+	}
+	sort.Strings(matches)
+	for _, path := range matches {
+		if fi, err := os.Lstat(path); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		rsp.addFile(name+"/"+filepath.Base(path), path)
+	}
+}
+
+func (rsp *debugBundleResponse) addBootVars() {
+	var buf bytes.Buffer
+	if err := bootDebugDumpBootVars(&buf, "", false); err != nil {
+		rsp.addError("cannot collect boot variables: %v", err)
+		return
+	}
+	rsp.add("bootvars.txt", buf.Bytes())
+}
+
+func (rsp *debugBundleResponse) addJournal() {
+	r, err := debugBundleJournal(debugBundleJournalLines)
+	if err != nil {
+		rsp.addError("cannot collect snapd journal: %v", err)
+		return
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	dec := json.NewDecoder(r)
+	for {
+		var log systemd.Log
+		if err := dec.Decode(&log); err != nil {
+			if err != io.EOF {
+				rsp.addError("cannot collect snapd journal: %v", err)
+			}
+			break
+		}
+		t, _ := log.Time()
+		fmt.Fprintf(&buf, "%s %s[%s]: %s\n", t.UTC().Format(time.RFC3339Nano), log.SID(), log.PID(), log.Message())
+	}
+	rsp.add("journal-snapd.txt", buf.Bytes())
+}
+
+// collectSystem collects the parts of the bundle that are not kept in the
+// state.
+func (rsp *debugBundleResponse) collectSystem() {
+	rsp.addFile("system-key", dirs.SnapSystemKeyFile)
+	rsp.addFile("modeenv", dirs.SnapModeenvFile)
+	rsp.addBootVars()
+	rsp.addDir("profiles/apparmor", dirs.SnapAppArmorDir, "*")
+	rsp.addDir("profiles/seccomp", dirs.SnapSeccompDir, "*.src")
+	rsp.addDir("profiles/udev", dirs.SnapUdevRulesDir, "*-snap.*.rules")
+	rsp.addJournal()
+	if len(rsp.errors) > 0 {
+		rsp.add("errors.txt", []byte(strings.Join(rsp.errors, "\n")+"\n"))
+	}
+}
+
+func (rsp *debugBundleResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rsp.collectSystem()
+
+	now := time.Now()
+	name := fmt.Sprintf("snapd-debug-%s.tar.gz", now.UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", client.DebugBundleMediaType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+name)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, f := range rsp.files {
+		hdr := &tar.Header{
+			Name:    f.name,
+			Mode:    0644,
+			Size:    int64(len(f.data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			logger.Noticef("cannot stream debug bundle: %v", err)
+			return
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			logger.Noticef("cannot stream debug bundle: %v", err)
+			return
+		}
+	}
+	if err := tw.Close(); err != nil {
+		logger.Noticef("cannot stream debug bundle: %v", err)
+		return
+	}
+	if err := gz.Close(); err != nil {
+		logger.Noticef("cannot stream debug bundle: %v", err)
+	}
+}
+
+// debugBundle collects the state derived parts of a debug bundle, the rest
+// is collected when the response is served. The state must be locked.
+func debugBundle(c *Command, st *state.State) Response {
+	rsp := &debugBundleResponse{}
+
+	stateData, err := st.MarshalJSON()
+	if err == nil {
+		stateData, err = redactJSON(stateData)
+	}
+	if err != nil {
+		return InternalError("cannot collect state: %v", err)
+	}
+	rsp.add("state.json", stateData)
+
+	changes := st.Changes()
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].SpawnTime().Before(changes[j].SpawnTime())
+	})
+	chgInfos := make([]*changeInfo, 0, len(changes))
+	chgTimings := make(map[string]map[string]*changeTimings, len(changes))
+	for _, chg := range changes {
+		chgInfos = append(chgInfos, change2changeInfo(chg))
+		if tm, err := collectChangeTimings(st, chg.ID()); err == nil {
+			chgTimings[chg.ID()] = tm
+		}
+	}
+	rsp.addJSON("changes.json", chgInfos)
+
+	allTimings, err := timings.Get(st, -1, func(map[string]string) bool { return true })
+	if err != nil {
+		rsp.addError("cannot collect timings: %v", err)
+	}
+	rsp.addJSON("timings.json", map[string]any{
+		"timings":        allTimings,
+		"change-timings": chgTimings,
+	})
+
+	if ifaceMgr := c.d.overlord.InterfaceManager(); ifaceMgr != nil {
+		rsp.addJSON("sandbox-features.json", sandboxFeatures(ifaceMgr.Repository().Backends()))
+	}
+
+	return rsp
+}
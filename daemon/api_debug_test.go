@@ -31,6 +31,10 @@ import (
 
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/overlord/ifacestate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
@@ -112,6 +116,50 @@ func (s *postDebugSuite) TestGetDebugBaseDeclaration(c *check.C) {
 		testutil.Contains, "type: base-declaration")
 }
 
+func (s *postDebugSuite) TestGetDebugExplainConnection(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+
+	s.daemon(c)
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	req, err := http.NewRequest("GET", "/v2/debug?aspect=explain-connection&plug=consumer:plug&slot=producer", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+
+	expl, ok := rsp.Result.(*ifacestate.ConnectionExplanation)
+	c.Assert(ok, check.Equals, true)
+	c.Check(expl.Plug, check.Equals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
+	c.Check(expl.Slot, check.Equals, interfaces.SlotRef{Snap: "producer", Name: "slot"})
+	c.Check(expl.Unasserted, check.DeepEquals, []string{"consumer", "producer"})
+	c.Check(expl.Connection.Allowed, check.Equals, true)
+	c.Check(expl.AutoConnection.Kind, check.Equals, "auto-connection")
+}
+
+func (s *postDebugSuite) TestGetDebugExplainConnectionErrors(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+
+	s.daemon(c)
+	s.mockSnap(c, consumerYaml)
+
+	for _, t := range []struct {
+		query string
+		err   string
+	}{
+		{"plug=consumer", `cannot explain connection: invalid plug "consumer"`},
+		{"plug=consumer:plug&slot=producer:slot", `cannot explain connection: snap "producer" has no slot named "slot"`},
+		{"plug=consumer:missing", `cannot explain connection: snap "consumer" has no plug named "missing"`},
+	} {
+		req, err := http.NewRequest("GET", "/v2/debug?aspect=explain-connection&"+t.query, nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, t.err, check.Commentf(t.query))
+	}
+}
+
 func mockDurationThreshold() func() {
 	oldDurationThreshold := timings.DurationThreshold
 	restore := func() {
@@ -183,6 +231,10 @@ func (s *postDebugSuite) TestGetDebugTimingsSingleChange(c *check.C) {
 	tmData := dataJSON[0].(map[string]interface{})
 	c.Check(tmData["change-id"], check.DeepEquals, "1")
 	c.Check(tmData["change-timings"], check.NotNil)
+	// the ensure which created the change is included
+	c.Check(tmData["ensure-timings"], check.HasLen, 1)
+	c.Check(tmData["start-time"], check.NotNil)
+	c.Check(tmData["total-duration"], check.NotNil)
 }
 
 func (s *postDebugSuite) TestGetDebugTimingsEnsureLatest(c *check.C) {
@@ -192,6 +244,7 @@ func (s *postDebugSuite) TestGetDebugTimingsEnsureLatest(c *check.C) {
 	tmData := dataJSON[0].(map[string]interface{})
 	c.Check(tmData["change-id"], check.DeepEquals, "2")
 	c.Check(tmData["change-timings"], check.NotNil)
+	c.Check(tmData["start-time"], check.NotNil)
 	c.Check(tmData["total-duration"], check.NotNil)
 }
 
@@ -202,11 +255,13 @@ func (s *postDebugSuite) TestGetDebugTimingsEnsureAll(c *check.C) {
 	tmData := dataJSON[0].(map[string]interface{})
 	c.Check(tmData["change-id"], check.DeepEquals, "1")
 	c.Check(tmData["change-timings"], check.NotNil)
+	c.Check(tmData["start-time"], check.NotNil)
 	c.Check(tmData["total-duration"], check.NotNil)
 
 	tmData = dataJSON[1].(map[string]interface{})
 	c.Check(tmData["change-id"], check.DeepEquals, "2")
 	c.Check(tmData["change-timings"], check.NotNil)
+	c.Check(tmData["start-time"], check.NotNil)
 	c.Check(tmData["total-duration"], check.NotNil)
 }
 
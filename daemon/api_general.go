@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/url"
 	"sort"
 	"time"
 
@@ -35,6 +36,7 @@ import (
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/changehistory"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/ifacestate"
@@ -64,7 +66,7 @@ var (
 	stateChangeCmd = &Command{
 		Path:        "/v2/changes/{id}",
 		GET:         getChange,
-		POST:        abortChange,
+		POST:        postChange,
 		ReadAccess:  interfaceOpenAccess{Interfaces: []string{"snap-refresh-observe"}},
 		WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
 	}
@@ -276,6 +278,12 @@ func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
 	if qselect == "" {
 		qselect = "in-progress"
 	}
+	if qselect == "archived" {
+		return getArchivedChanges(c, query)
+	}
+	if query.Get("since") != "" {
+		return BadRequest("since can only be used with select=archived")
+	}
 	var filter func(*state.Change) bool
 	switch qselect {
 	case "all":
@@ -285,7 +293,7 @@ func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
 	case "ready":
 		filter = func(chg *state.Change) bool { return chg.IsReady() }
 	default:
-		return BadRequest("select should be one of: all,in-progress,ready")
+		return BadRequest("select should be one of: all,in-progress,ready,archived")
 	}
 
 	if wantedName := query.Get("for"); wantedName != "" {
@@ -329,7 +337,30 @@ func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(chgInfos)
 }
 
-func abortChange(c *Command, r *http.Request, user *auth.UserState) Response {
+func getArchivedChanges(c *Command, query url.Values) Response {
+	opts := &changehistory.Options{
+		SnapName: query.Get("for"),
+	}
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return BadRequest("invalid since time %q: %v", since, err)
+		}
+		opts.Since = t
+	}
+
+	entries, err := c.d.overlord.ChangeHistory().Entries(opts)
+	if err != nil {
+		return InternalError("%v", err)
+	}
+	chgInfos := make([]*changeInfo, 0, len(entries))
+	for _, entry := range entries {
+		chgInfos = append(chgInfos, entry2changeInfo(entry))
+	}
+	return SyncResponse(chgInfos)
+}
+
+func postChange(c *Command, r *http.Request, user *auth.UserState) Response {
 	chID := muxVars(r)["id"]
 	state := c.d.overlord.State()
 	state.Lock()
@@ -348,18 +379,31 @@ func abortChange(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("cannot decode data from request body: %v", err)
 	}
 
-	if reqData.Action != "abort" {
+	switch reqData.Action {
+	case "abort", "pause", "resume":
+		if chg.IsReady() {
+			return BadRequest("cannot %s change %s with nothing pending", reqData.Action, chID)
+		}
+	case "retry":
+	default:
 		return BadRequest("change action %q is unsupported", reqData.Action)
 	}
 
-	if chg.IsReady() {
-		return BadRequest("cannot abort change %s with nothing pending", chID)
+	switch reqData.Action {
+	case "abort":
+		// flag the change
+		chg.Abort()
+	case "pause":
+		chg.Pause()
+	case "resume":
+		chg.Resume()
+	case "retry":
+		if err := c.d.overlord.TaskRunner().RetryChange(chg); err != nil {
+			return BadRequest("%v", err)
+		}
 	}
 
-	// flag the change
-	chg.Abort()
-
-	// actually ask to proceed with the abort
+	// actually ask to proceed with the action
 	ensureStateSoon(state)
 
 	return SyncResponse(change2changeInfo(chg))
@@ -372,10 +416,12 @@ type changeInfo struct {
 	Status  string      `json:"status"`
 	Tasks   []*taskInfo `json:"tasks,omitempty"`
 	Ready   bool        `json:"ready"`
+	Paused  bool        `json:"paused,omitempty"`
 	Err     string      `json:"err,omitempty"`
 
-	SpawnTime time.Time  `json:"spawn-time,omitzero"`
-	ReadyTime *time.Time `json:"ready-time,omitempty"`
+	SpawnTime     time.Time  `json:"spawn-time,omitzero"`
+	ReadyTime     *time.Time `json:"ready-time,omitempty"`
+	ScheduledTime *time.Time `json:"scheduled-time,omitempty"`
 
 	Data map[string]*json.RawMessage `json:"data,omitempty"`
 }
@@ -388,8 +434,9 @@ type taskInfo struct {
 	Log      []string         `json:"log,omitempty"`
 	Progress taskInfoProgress `json:"progress"`
 
-	SpawnTime time.Time  `json:"spawn-time,omitzero"`
-	ReadyTime *time.Time `json:"ready-time,omitempty"`
+	SpawnTime     time.Time  `json:"spawn-time,omitzero"`
+	ReadyTime     *time.Time `json:"ready-time,omitempty"`
+	ScheduledTime *time.Time `json:"scheduled-time,omitempty"`
 
 	Data map[string]*json.RawMessage `json:"data,omitempty"`
 }
@@ -408,6 +455,7 @@ func change2changeInfo(chg *state.Change) *changeInfo {
 		Summary: chg.Summary(),
 		Status:  status.String(),
 		Ready:   status.Ready(),
+		Paused:  chg.IsPaused(),
 
 		SpawnTime: chg.SpawnTime(),
 	}
@@ -415,6 +463,9 @@ func change2changeInfo(chg *state.Change) *changeInfo {
 	if !readyTime.IsZero() {
 		chgInfo.ReadyTime = &readyTime
 	}
+	if scheduledTime := chg.ScheduledTime(); !scheduledTime.IsZero() {
+		chgInfo.ScheduledTime = &scheduledTime
+	}
 	if err := chg.Err(); err != nil {
 		chgInfo.Err = err.Error()
 	}
@@ -456,6 +507,31 @@ func change2changeInfo(chg *state.Change) *changeInfo {
 	return chgInfo
 }
 
+func entry2changeInfo(entry *changehistory.Entry) *changeInfo {
+	chgInfo := &changeInfo{
+		ID:        entry.ID,
+		Kind:      entry.Kind,
+		Summary:   entry.Summary,
+		Status:    entry.Status,
+		Ready:     entry.ReadyTime != nil,
+		Err:       entry.Err,
+		SpawnTime: entry.SpawnTime,
+		ReadyTime: entry.ReadyTime,
+	}
+	for _, t := range entry.Tasks {
+		chgInfo.Tasks = append(chgInfo.Tasks, &taskInfo{
+			ID:        t.ID,
+			Kind:      t.Kind,
+			Summary:   t.Summary,
+			Status:    t.Status,
+			Log:       t.Log,
+			SpawnTime: t.SpawnTime,
+			ReadyTime: t.ReadyTime,
+		})
+	}
+	return chgInfo
+}
+
 var snapstateSnapsAffectedByTask = snapstate.SnapsAffectedByTask
 
 // taskApiData returns a map similar to change data which is currently
@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/arch"
 	"github.com/snapcore/snapd/boot"
@@ -40,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/changehistory"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
@@ -870,6 +872,138 @@ func (s *generalSuite) TestStateChangesForSnapName(c *check.C) {
 	c.Assert(rec.Code, check.Equals, 200)
 }
 
+func (s *generalSuite) TestStateChangesArchived(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+
+	t0 := time.Date(2026, 4, 21, 1, 2, 3, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	h := d.Overlord().ChangeHistory()
+	c.Assert(h.Add(&changehistory.Entry{
+		ID:        "1",
+		Kind:      "install-snap",
+		Summary:   `Install "foo" snap`,
+		Status:    "Error",
+		Err:       "cannot install",
+		SnapNames: []string{"foo"},
+		SpawnTime: t0,
+		ReadyTime: &t0,
+		Tasks: []*changehistory.TaskEntry{{
+			ID:        "1",
+			Kind:      "download-snap",
+			Summary:   "Download foo",
+			Status:    "Error",
+			Log:       []string{"cannot download"},
+			SpawnTime: t0,
+			ReadyTime: &t0,
+		}},
+	}), check.IsNil)
+	c.Assert(h.Add(&changehistory.Entry{
+		ID:        "2",
+		Kind:      "refresh-snap",
+		Summary:   `Refresh "bar" snap`,
+		Status:    "Done",
+		SnapNames: []string{"bar"},
+		SpawnTime: t1,
+		ReadyTime: &t1,
+	}), check.IsNil)
+
+	// not archived
+	st := d.Overlord().State()
+	st.Lock()
+	setupChanges(st)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/changes?select=archived", nil)
+	c.Assert(err, check.IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+	c.Assert(rsp.Result, check.FitsTypeOf, []*daemon.ChangeInfo(nil))
+	res := rsp.Result.([]*daemon.ChangeInfo)
+	c.Assert(res, check.HasLen, 2)
+
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, nil)
+	c.Assert(rec.Code, check.Equals, 200)
+	var body map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), check.IsNil)
+	c.Check(body["result"], check.DeepEquals, []interface{}{
+		map[string]interface{}{
+			"id":         "1",
+			"kind":       "install-snap",
+			"summary":    `Install "foo" snap`,
+			"status":     "Error",
+			"ready":      true,
+			"err":        "cannot install",
+			"spawn-time": "2026-04-21T01:02:03Z",
+			"ready-time": "2026-04-21T01:02:03Z",
+			"tasks": []interface{}{
+				map[string]interface{}{
+					"id":         "1",
+					"kind":       "download-snap",
+					"summary":    "Download foo",
+					"status":     "Error",
+					"log":        []interface{}{"cannot download"},
+					"progress":   map[string]interface{}{"label": "", "done": 0., "total": 0.},
+					"spawn-time": "2026-04-21T01:02:03Z",
+					"ready-time": "2026-04-21T01:02:03Z",
+				},
+			},
+		},
+		map[string]interface{}{
+			"id":         "2",
+			"kind":       "refresh-snap",
+			"summary":    `Refresh "bar" snap`,
+			"status":     "Done",
+			"ready":      true,
+			"spawn-time": "2026-04-21T02:02:03Z",
+			"ready-time": "2026-04-21T02:02:03Z",
+		},
+	})
+
+	for _, t := range []struct {
+		query string
+		ids   []string
+	}{
+		{"for=foo", []string{"1"}},
+		{"for=bar", []string{"2"}},
+		{"for=baz", nil},
+		{"since=2026-04-21T01:30:00Z", []string{"2"}},
+		{"since=2026-04-21T02:30:00%2B02:00", []string{"1", "2"}},
+		{"for=foo&since=2026-04-21T01:30:00Z", nil},
+	} {
+		req, err := http.NewRequest("GET", "/v2/changes?select=archived&"+t.query, nil)
+		c.Assert(err, check.IsNil)
+		rsp := s.syncReq(c, req, nil)
+		var ids []string
+		for _, chg := range rsp.Result.([]*daemon.ChangeInfo) {
+			ids = append(ids, chg.ID)
+		}
+		c.Check(ids, check.DeepEquals, t.ids, check.Commentf("%s", t.query))
+	}
+}
+
+func (s *generalSuite) TestStateChangesArchivedErrors(c *check.C) {
+	s.expectChangesReadAccess()
+	s.daemon(c)
+
+	for _, t := range []struct {
+		query string
+		msg   string
+	}{
+		{"select=archived&since=yesterday", `invalid since time "yesterday": .*`},
+		{"select=all&since=2026-04-21T01:30:00Z", "since can only be used with select=archived"},
+		{"since=2026-04-21T01:30:00Z", "since can only be used with select=archived"},
+		{"select=foo", "select should be one of: all,in-progress,ready,archived"},
+	} {
+		req, err := http.NewRequest("GET", "/v2/changes?"+t.query, nil)
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%s", t.query))
+		c.Check(rspe.Message, check.Matches, t.msg, check.Commentf("%s", t.query))
+	}
+}
+
 func (s *generalSuite) TestStateChangesForSnapNameWithApp(c *check.C) {
 	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
 	defer restore()
@@ -1094,6 +1228,170 @@ func (s *generalSuite) TestStateChangeAbortIsReady(c *check.C) {
 	})
 }
 
+func (s *generalSuite) TestStateChangeScheduled(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	when := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Change(ids[0]).At(when)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/changes/"+ids[0], nil)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil).ServeHTTP(rec, req)
+	c.Assert(rec.Code, check.Equals, 200)
+
+	var body map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), check.IsNil)
+	result := body["result"].(map[string]interface{})
+	c.Check(result["scheduled-time"], check.Equals, when.Format(time.RFC3339))
+	c.Check(result["status"], check.Equals, "Do")
+}
+
+func (s *generalSuite) postChangeAction(c *check.C, id, action string) (*http.Request, *httptest.ResponseRecorder) {
+	req, err := http.NewRequest("POST", "/v2/changes/"+id, bytes.NewBufferString(fmt.Sprintf(`{"action": %q}`, action)))
+	c.Assert(err, check.IsNil)
+	return req, httptest.NewRecorder()
+}
+
+func (s *generalSuite) TestStateChangePauseResume(c *check.C) {
+	soon := 0
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {
+		soon++
+	})
+	defer restore()
+
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	s.expectManageAccess()
+
+	req, rec := s.postChangeAction(c, ids[0], "pause")
+	rsp := s.syncReq(c, req, nil)
+	rsp.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(soon, check.Equals, 1)
+
+	var body map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), check.IsNil)
+	c.Check(body["result"].(map[string]interface{})["paused"], check.Equals, true)
+
+	st.Lock()
+	c.Check(st.Change(ids[0]).IsPaused(), check.Equals, true)
+	st.Unlock()
+
+	req, rec = s.postChangeAction(c, ids[0], "resume")
+	rsp = s.syncReq(c, req, nil)
+	rsp.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(soon, check.Equals, 2)
+
+	body = nil
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), check.IsNil)
+	_, ok := body["result"].(map[string]interface{})["paused"]
+	c.Check(ok, check.Equals, false)
+
+	st.Lock()
+	c.Check(st.Change(ids[0]).IsPaused(), check.Equals, false)
+	st.Unlock()
+}
+
+func (s *generalSuite) TestStateChangePauseIsReady(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	s.expectManageAccess()
+
+	for _, action := range []string{"pause", "resume"} {
+		req, _ := s.postChangeAction(c, ids[1], action)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, fmt.Sprintf("cannot %s change %s with nothing pending", action, ids[1]))
+	}
+}
+
+func (s *generalSuite) TestStateChangeRetry(c *check.C) {
+	soon := 0
+	_, restore := daemon.MockEnsureStateSoon(func(st *state.State) {
+		soon++
+	})
+	defer restore()
+
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	runner := d.Overlord().TaskRunner()
+	runner.AddHandler("unlink", func(*state.Task, *tomb.Tomb) error { return nil }, nil)
+	runner.MarkIdempotent("unlink")
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	s.expectManageAccess()
+
+	req, rec := s.postChangeAction(c, ids[1], "retry")
+	rsp := s.syncReq(c, req, nil)
+	rsp.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(soon, check.Equals, 1)
+
+	var body map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), check.IsNil)
+	result := body["result"].(map[string]interface{})
+	c.Check(result["status"], check.Equals, "Do")
+	c.Check(result["ready"], check.Equals, false)
+}
+
+func (s *generalSuite) TestStateChangeRetryError(c *check.C) {
+	s.expectChangesReadAccess()
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	s.expectManageAccess()
+
+	req, _ := s.postChangeAction(c, ids[1], "retry")
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, fmt.Sprintf("cannot retry change %s: task %s (1...) cannot be run again", ids[1], ids[4]))
+
+	// nothing failed in this one
+	req, _ = s.postChangeAction(c, ids[0], "retry")
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, fmt.Sprintf("cannot retry change %s: no task failed", ids[0]))
+
+	// a change that was not paused when it failed was undone
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("download-snap", "1...")
+	t2 := st.NewTask("download-snap", "2...")
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	t1.SetStatus(state.UndoneStatus)
+	t2.SetStatus(state.ErrorStatus)
+	st.Unlock()
+	req, _ = s.postChangeAction(c, chg.ID(), "retry")
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, fmt.Sprintf("cannot retry change %s: it is already being undone (changes are undone as soon as a task fails unless paused before)", chg.ID()))
+}
+
 func (s *generalSuite) testWarnings(c *check.C, all bool, body io.Reader) (calls string, result interface{}) {
 	s.daemon(c)
 
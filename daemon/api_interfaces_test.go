@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -177,6 +178,77 @@ func (s *interfacesSuite) TestConnectPlugSuccess(c *check.C) {
 	}})
 }
 
+func (s *interfacesSuite) TestConnectPlugUntil(c *check.C) {
+	restore := builtin.MockInterface(&ifacetest.TestInterface{InterfaceName: "test"})
+	defer restore()
+
+	d := s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	expires := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	action := &client.InterfaceAction{
+		Action:  "connect",
+		Plugs:   []client.Plug{{Snap: "consumer", Name: "plug"}},
+		Slots:   []client.Slot{{Snap: "producer", Name: "slot"}},
+		Expires: expires,
+	}
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	buf := bytes.NewBuffer(text)
+	req, err := http.NewRequest("POST", "/v2/interfaces", buf)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil).ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 202)
+	var body map[string]interface{}
+	err = json.Unmarshal(rec.Body.Bytes(), &body)
+	c.Check(err, check.IsNil)
+	id := body["change"].(string)
+
+	st := d.Overlord().State()
+	st.Lock()
+	chg := st.Change(id)
+	st.Unlock()
+	c.Assert(chg, check.NotNil)
+
+	<-chg.Ready()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Assert(chg.Err(), check.IsNil)
+
+	conns, err := ifacestate.ConnectionStates(st)
+	c.Assert(err, check.IsNil)
+	c.Assert(conns, check.HasLen, 1)
+	c.Check(conns["consumer:plug producer:slot"].Expires.Equal(expires), check.Equals, true)
+}
+
+func (s *interfacesSuite) TestInterfaceActionExpiresOnlyForConnect(c *check.C) {
+	s.daemon(c)
+
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	action := &client.InterfaceAction{
+		Action:  "disconnect",
+		Plugs:   []client.Plug{{Snap: "consumer", Name: "plug"}},
+		Slots:   []client.Slot{{Snap: "producer", Name: "slot"}},
+		Expires: time.Now().Add(time.Hour),
+	}
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	req, err := http.NewRequest("POST", "/v2/interfaces", bytes.NewBuffer(text))
+	c.Assert(err, check.IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "expiration time can only be set when connecting")
+}
+
 func (s *interfacesSuite) TestConnectPlugFailureInterfaceMismatch(c *check.C) {
 	d := s.daemon(c)
 
@@ -307,6 +379,73 @@ func (s *interfacesSuite) TestConnectAlreadyConnected(c *check.C) {
 	st.Unlock()
 }
 
+func (s *interfacesSuite) TestConnectAlreadyConnectedUntil(c *check.C) {
+	d := s.daemon(c)
+
+	mockIface(c, d, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, producerYaml)
+	s.mockSnap(c, consumerYaml)
+
+	repo := d.Overlord().InterfaceManager().Repository()
+	connRef := &interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	}
+
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	_, err := repo.Connect(connRef, nil, nil, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+	conns := map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{
+			"interface": "test",
+		},
+	}
+	st := d.Overlord().State()
+	st.Lock()
+	st.Set("conns", conns)
+	st.Unlock()
+
+	expires := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	action := &client.InterfaceAction{
+		Action:  "connect",
+		Plugs:   []client.Plug{{Snap: "consumer", Name: "plug"}},
+		Slots:   []client.Slot{{Snap: "producer", Name: "slot"}},
+		Expires: expires,
+	}
+	text, err := json.Marshal(action)
+	c.Assert(err, check.IsNil)
+	buf := bytes.NewBuffer(text)
+	req, err := http.NewRequest("POST", "/v2/interfaces", buf)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	s.req(c, req, nil).ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 202)
+	var body map[string]interface{}
+	err = json.Unmarshal(rec.Body.Bytes(), &body)
+	c.Check(err, check.IsNil)
+	id := body["change"].(string)
+
+	st.Lock()
+	chg := st.Change(id)
+	c.Assert(chg, check.NotNil)
+	c.Assert(chg.Tasks(), check.HasLen, 1)
+	c.Check(chg.Tasks()[0].Kind(), check.Equals, "set-connection-expiry")
+	st.Unlock()
+
+	<-chg.Ready()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Assert(chg.Err(), check.IsNil)
+
+	cstates, err := ifacestate.ConnectionStates(st)
+	c.Assert(err, check.IsNil)
+	c.Assert(cstates, check.HasLen, 1)
+	c.Check(cstates["consumer:plug producer:slot"].Expires.Equal(expires), check.Equals, true)
+}
+
 func (s *interfacesSuite) TestConnectPlugFailureNoSuchSlot(c *check.C) {
 	d := s.daemon(c)
 
@@ -20,6 +20,8 @@
 package daemon
 
 import (
+	"time"
+
 	"github.com/snapcore/snapd/interfaces"
 )
 
@@ -62,6 +64,9 @@ type interfaceAction struct {
 	Forget bool       `json:"forget,omitempty"`
 	Plugs  []plugJSON `json:"plugs,omitempty"`
 	Slots  []slotJSON `json:"slots,omitempty"`
+	// Expires is the time at which a connection is automatically
+	// disconnected again.
+	Expires time.Time `json:"expires,omitzero"`
 }
 
 // connectionsJSON aids in marshalling information about a single connection
@@ -74,6 +79,7 @@ type connectionJSON struct {
 	Gadget    bool                   `json:"gadget,omitempty"`
 	SlotAttrs map[string]interface{} `json:"slot-attrs,omitempty"`
 	PlugAttrs map[string]interface{} `json:"plug-attrs,omitempty"`
+	Expires   time.Time              `json:"expires,omitzero"`
 }
 
 // legacyConnectionsJSON aids in marshaling legacy connections into JSON.
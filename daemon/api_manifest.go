@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/manifest"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var manifestCmd = &Command{
+	Path:        "/v2/manifest",
+	GET:         getManifest,
+	POST:        postManifest,
+	ReadAccess:  authenticatedAccess{Polkit: polkitActionManageConfiguration},
+	WriteAccess: rootAccess{},
+}
+
+var (
+	manifestExport = manifest.Export
+	manifestApply  = manifest.Apply
+)
+
+func getManifest(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	m, err := manifestExport(st)
+	if err != nil {
+		return InternalError("cannot export manifest: %v", err)
+	}
+	return SyncResponse(m)
+}
+
+type postManifestData struct {
+	Action   string             `json:"action"`
+	Manifest *manifest.Manifest `json:"manifest"`
+	DryRun   bool               `json:"dry-run,omitempty"`
+}
+
+func postManifest(c *Command, r *http.Request, user *auth.UserState) Response {
+	var data postManifestData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		return BadRequest("cannot decode request body: %v", err)
+	}
+	if data.Action != "apply" {
+		return BadRequest("unknown manifest action %q", data.Action)
+	}
+	if data.Manifest == nil {
+		return BadRequest("manifest action %q requires a manifest", data.Action)
+	}
+	if err := data.Manifest.Validate(); err != nil {
+		return BadRequest("%v", err)
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var userID int
+	if user != nil {
+		userID = user.ID
+	}
+
+	plan, tss, affected, err := manifestApply(r.Context(), st, data.Manifest, userID, data.DryRun)
+	if err != nil {
+		return errToResponse(err, nil, InternalError, "cannot apply manifest: %v")
+	}
+	if data.DryRun {
+		return SyncResponse(plan)
+	}
+
+	chg := newChange(st, "apply-manifest", i18n.G("Apply system manifest"), tss, affected)
+	if len(tss) == 0 {
+		chg.SetStatus(state.DoneStatus)
+	}
+	ensureStateSoon(st)
+
+	result := map[string]interface{}{}
+	if len(plan.Actions) > 0 {
+		result["actions"] = plan.Actions
+	}
+	if len(plan.Skipped) > 0 {
+		result["skipped"] = plan.Skipped
+	}
+	return AsyncResponse(result, chg.ID())
+}
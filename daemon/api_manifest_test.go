@@ -0,0 +1,179 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/daemon"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/manifest"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+var _ = Suite(&manifestSuite{})
+
+type manifestSuite struct {
+	apiBaseSuite
+}
+
+func (s *manifestSuite) SetUpTest(c *C) {
+	s.apiBaseSuite.SetUpTest(c)
+
+	s.expectReadAccess(daemon.AuthenticatedAccess{Polkit: "io.snapcraft.snapd.manage-configuration"})
+	s.expectWriteAccess(daemon.RootAccess{})
+
+	_, restore := daemon.MockEnsureStateSoon(func(*state.State) {})
+	s.AddCleanup(restore)
+}
+
+func (s *manifestSuite) TestGetManifest(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	snapstate.Set(st, "foo", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "foo", Revision: snap.R(3), SnapID: "foo-id"},
+		}),
+		Current:         snap.R(3),
+		SnapType:        "app",
+		TrackingChannel: "latest/stable",
+	})
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/manifest", nil)
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, DeepEquals, &manifest.Manifest{
+		Snaps: []*manifest.Snap{{Name: "foo", Channel: "latest/stable", Revision: "3"}},
+	})
+}
+
+func (s *manifestSuite) TestApplyManifest(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	s.AddCleanup(daemon.MockManifestApply(func(ctx context.Context, st *state.State, m *manifest.Manifest, userID int, dryRun bool) (*manifest.Plan, []*state.TaskSet, []string, error) {
+		c.Check(m.Snaps, DeepEquals, []*manifest.Snap{{Name: "foo", Channel: "edge"}})
+		c.Check(userID, Equals, 42)
+		c.Check(dryRun, Equals, false)
+		plan := &manifest.Plan{
+			Actions: []string{`Refresh "foo" from latest/edge`},
+			Skipped: []string{"something: because"},
+		}
+		return plan, []*state.TaskSet{state.NewTaskSet(st.NewTask("foo", "..."))}, []string{"foo"}, nil
+	}))
+
+	body := `{"action": "apply", "manifest": {"snaps": [{"name": "foo", "channel": "edge"}]}}`
+	req, err := http.NewRequest("POST", "/v2/manifest", bytes.NewBufferString(body))
+	c.Assert(err, IsNil)
+	rsp := s.asyncReq(c, req, &auth.UserState{ID: 42})
+	c.Check(rsp.Result, DeepEquals, map[string]interface{}{
+		"actions": []string{`Refresh "foo" from latest/edge`},
+		"skipped": []string{"something: because"},
+	})
+
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, NotNil)
+	c.Check(chg.Kind(), Equals, "apply-manifest")
+	c.Check(chg.Summary(), Equals, "Apply system manifest")
+	c.Check(chg.Tasks(), HasLen, 1)
+	var snapNames []string
+	c.Assert(chg.Get("snap-names", &snapNames), IsNil)
+	c.Check(snapNames, DeepEquals, []string{"foo"})
+}
+
+func (s *manifestSuite) TestApplyManifestNothingToDo(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	s.AddCleanup(daemon.MockManifestApply(func(ctx context.Context, st *state.State, m *manifest.Manifest, userID int, dryRun bool) (*manifest.Plan, []*state.TaskSet, []string, error) {
+		return &manifest.Plan{}, nil, nil, nil
+	}))
+
+	req, err := http.NewRequest("POST", "/v2/manifest", bytes.NewBufferString(`{"action": "apply", "manifest": {}}`))
+	c.Assert(err, IsNil)
+	rsp := s.asyncReq(c, req, nil)
+	c.Check(rsp.Result, DeepEquals, map[string]interface{}{})
+
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, NotNil)
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+}
+
+func (s *manifestSuite) TestApplyManifestDryRun(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+
+	plan := &manifest.Plan{Actions: []string{`Install "foo"`}}
+	s.AddCleanup(daemon.MockManifestApply(func(ctx context.Context, st *state.State, m *manifest.Manifest, userID int, dryRun bool) (*manifest.Plan, []*state.TaskSet, []string, error) {
+		c.Check(dryRun, Equals, true)
+		return plan, nil, []string{"foo"}, nil
+	}))
+
+	body := `{"action": "apply", "manifest": {"snaps": [{"name": "foo"}]}, "dry-run": true}`
+	req, err := http.NewRequest("POST", "/v2/manifest", bytes.NewBufferString(body))
+	c.Assert(err, IsNil)
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, DeepEquals, plan)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), HasLen, 0)
+}
+
+func (s *manifestSuite) TestApplyManifestErrors(c *C) {
+	s.daemon(c)
+
+	s.AddCleanup(daemon.MockManifestApply(func(ctx context.Context, st *state.State, m *manifest.Manifest, userID int, dryRun bool) (*manifest.Plan, []*state.TaskSet, []string, error) {
+		return nil, nil, nil, errors.New("boom")
+	}))
+
+	for _, t := range []struct {
+		body   string
+		status int
+		msg    string
+	}{
+		{`}`, 400, "cannot decode request body: .*"},
+		{`{"action": "export"}`, 400, `unknown manifest action "export"`},
+		{`{"action": "apply"}`, 400, `manifest action "apply" requires a manifest`},
+		{`{"action": "apply", "manifest": {"snaps": [{"name": "Foo"}]}}`, 400, `invalid manifest: invalid snap name: "Foo"`},
+		{`{"action": "apply", "manifest": {}}`, 500, "cannot apply manifest: boom"},
+	} {
+		req, err := http.NewRequest("POST", "/v2/manifest", bytes.NewBufferString(t.body))
+		c.Assert(err, IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, Equals, t.status, Commentf(t.body))
+		c.Check(rspe.Message, Matches, t.msg, Commentf(t.body))
+	}
+}
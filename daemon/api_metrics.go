@@ -0,0 +1,57 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon
+
+import (
+	"net/http"
+
+	"github.com/snapcore/snapd/features"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
+	"github.com/snapcore/snapd/overlord/auth"
+)
+
+var metricsCmd = &Command{
+	Path:       "/v2/metrics",
+	GET:        getMetrics,
+	ReadAccess: openAccess{},
+}
+
+func getMetrics(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	err := validateFeatureFlag(st, features.Metrics)
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+	return metricsResponse{}
+}
+
+// metricsResponse is a Response that writes out the metrics in the
+// OpenMetrics text format.
+type metricsResponse struct{}
+
+func (metricsResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if err := metrics.Write(w); err != nil {
+		logger.Noticef("cannot write metrics: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package daemon_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/features"
+	"github.com/snapcore/snapd/metrics"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/testutil"
+)
+
+var _ = Suite(&metricsSuite{})
+
+type metricsSuite struct {
+	apiBaseSuite
+}
+
+func (s *metricsSuite) SetUpTest(c *C) {
+	s.apiBaseSuite.SetUpTest(c)
+	s.AddCleanup(testutil.Backup(&metrics.DefaultRegistry))
+	metrics.DefaultRegistry = metrics.NewRegistry()
+}
+
+func (s *metricsSuite) TestGetMetrics(c *C) {
+	d := s.daemon(c)
+	st := d.Overlord().State()
+	st.Lock()
+	tr := config.NewTransaction(st)
+	_, confOption := features.Metrics.ConfigOption()
+	c.Assert(tr.Set("core", confOption, true), IsNil)
+	tr.Commit()
+	chg := st.NewChange("foo", "...")
+	chg.AddTask(st.NewTask("bar", "..."))
+	st.Unlock()
+
+	metrics.NewCounter("foo_total", "Foo.", "kind").Inc("bar")
+
+	req, err := http.NewRequest("GET", "/v2/metrics", nil)
+	c.Assert(err, IsNil)
+	s.asUserAuth(c, req)
+	rec := httptest.NewRecorder()
+	s.serveHTTP(c, rec, req)
+	c.Check(rec.Code, Equals, 200)
+	c.Check(rec.Header().Get("Content-Type"), Equals, "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	c.Check(rec.Body.String(), Equals, `# TYPE foo counter
+# HELP foo Foo.
+foo_total{kind="bar"} 1
+# TYPE snapd_tasks gauge
+# HELP snapd_tasks Number of tasks in the state, by kind and status.
+snapd_tasks{kind="bar",status="Do"} 1
+# EOF
+`)
+}
+
+func (s *metricsSuite) TestGetMetricsDisabled(c *C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/metrics", nil)
+	c.Assert(err, IsNil)
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, Equals, 400)
+	c.Check(rspe.Message, Equals, `feature flag "metrics" is disabled: set 'experimental.metrics' to true`)
+}
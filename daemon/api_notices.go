@@ -15,6 +15,7 @@
 package daemon
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -22,8 +23,10 @@ import (
 	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap/naming"
@@ -34,6 +37,7 @@ var noticeReadInterfaces = map[state.NoticeType][]string{
 	state.ChangeUpdateNotice:                 {"snap-refresh-observe"},
 	state.RefreshInhibitNotice:               {"snap-refresh-observe"},
 	state.SnapRunInhibitNotice:               {"snap-refresh-observe"},
+	state.SnapLifecycleNotice:                {"snap-refresh-observe"},
 	state.InterfacesRequestsPromptNotice:     {"snap-interfaces-requests-control"},
 	state.InterfacesRequestsRuleUpdateNotice: {"snap-interfaces-requests-control"},
 }
@@ -124,6 +128,33 @@ func getNotices(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("invalid timeout: %v", err)
 	}
 
+	follow := false
+	if s := query.Get("follow"); s != "" {
+		f, err := strconv.ParseBool(s)
+		if err != nil {
+			return BadRequest(`invalid value for follow: %q: %v`, s, err)
+		}
+		follow = f
+	}
+	if follow {
+		if timeout != 0 {
+			return BadRequest(`cannot use both "follow" and "timeout" parameters`)
+		}
+		// clients reconnecting to the stream resume after the last
+		// event they got
+		if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+			filter.After, err = time.Parse(time.RFC3339Nano, lastEventID)
+			if err != nil {
+				return BadRequest(`invalid "Last-Event-ID" header: %v`, err)
+			}
+		}
+		return &noticesStreamResponse{
+			st:     c.d.overlord.State(),
+			filter: filter,
+			ctx:    c.d.tomb.Context(r.Context()),
+		}
+	}
+
 	st := c.d.overlord.State()
 	st.Lock()
 	defer st.Unlock()
@@ -157,6 +188,78 @@ func getNotices(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(notices)
 }
 
+// noticesKeepAliveInterval is how often a comment is sent on an otherwise
+// idle notices stream, so that broken connections are noticed.
+var noticesKeepAliveInterval = 30 * time.Second
+
+// noticesStreamResponse is a Response that streams the notices matching its
+// filter as Server-Sent Events as they occur, until the request is done.
+//
+// Each event has the notice as data and its last-repeated time as ID, for
+// clients to resume the stream from with the Last-Event-ID header.
+type noticesStreamResponse struct {
+	st     *state.State
+	filter *state.NoticeFilter
+	ctx    context.Context
+}
+
+func (nr *noticesStreamResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(200)
+
+	flusher, hasFlusher := w.(http.Flusher)
+	flush := func() {
+		if hasFlusher {
+			flusher.Flush()
+		}
+	}
+	flush()
+
+	filter := *nr.filter
+	for {
+		events, err := nr.waitEvents(&filter)
+		if nr.ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			events = []byte(": keep-alive\n\n")
+		} else if err != nil {
+			logger.Noticef("cannot stream notices: %v", err)
+			return
+		}
+		if _, err := w.Write(events); err != nil {
+			return
+		}
+		flush()
+	}
+}
+
+// waitEvents waits for notices matching the filter, and returns them as
+// events, moving the filter past them.
+func (nr *noticesStreamResponse) waitEvents(filter *state.NoticeFilter) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(nr.ctx, noticesKeepAliveInterval)
+	defer cancel()
+
+	nr.st.Lock()
+	defer nr.st.Unlock()
+	notices, err := nr.st.WaitNotices(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, notice := range notices {
+		data, err := json.Marshal(notice)
+		if err != nil {
+			return nil, err
+		}
+		lastRepeated := notice.LastRepeated()
+		fmt.Fprintf(&buf, "id: %s\nevent: notice\ndata: %s\n\n", lastRepeated.Format(time.RFC3339Nano), data)
+		filter.After = lastRepeated
+	}
+	return buf.Bytes(), nil
+}
+
 // Get the UID of the request. If the UID is not known, return an error.
 func uidFromRequest(r *http.Request) (uint32, error) {
 	cred, err := ucrednetGet(r.RemoteAddr)
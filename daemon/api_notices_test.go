@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"path/filepath"
 	"strconv"
@@ -243,6 +244,8 @@ func (s *noticesSuite) TestNoticesShowsTypesAllowedForSnap(c *C) {
 	addNotice(c, st, nil, state.WarningNotice, "danger", nil)
 	addNotice(c, st, nil, state.SnapRunInhibitNotice, "snap-name", nil)
 	addNotice(c, st, nil, state.InterfacesRequestsPromptNotice, "def", nil)
+	addNotice(c, st, nil, state.SnapLifecycleNotice, "snap-name", nil)
+	addNotice(c, st, nil, state.InterfaceConnectionNotice, "snap-name", nil)
 	st.Unlock()
 
 	// Check that a snap request without specifying types filter only shows
@@ -266,7 +269,7 @@ func (s *noticesSuite) TestNoticesShowsTypesAllowedForSnap(c *C) {
 	c.Check(rsp.Status, Equals, 200)
 	notices, ok = rsp.Result.([]*state.Notice)
 	c.Assert(ok, Equals, true)
-	c.Assert(notices, HasLen, 3)
+	c.Assert(notices, HasLen, 4)
 
 	seenNoticeType := make(map[string]int)
 	for _, notice := range notices {
@@ -277,6 +280,7 @@ func (s *noticesSuite) TestNoticesShowsTypesAllowedForSnap(c *C) {
 	c.Check(seenNoticeType["change-update"], Equals, 1)
 	c.Check(seenNoticeType["refresh-inhibit"], Equals, 1)
 	c.Check(seenNoticeType["snap-run-inhibit"], Equals, 1)
+	c.Check(seenNoticeType["snap-lifecycle"], Equals, 1)
 
 	// Check that multiple interfaces allow accessing notice types granted by
 	// any of the connected interfaces
@@ -287,7 +291,7 @@ func (s *noticesSuite) TestNoticesShowsTypesAllowedForSnap(c *C) {
 	c.Check(rsp.Status, Equals, 200)
 	notices, ok = rsp.Result.([]*state.Notice)
 	c.Assert(ok, Equals, true)
-	c.Assert(notices, HasLen, 6)
+	c.Assert(notices, HasLen, 7)
 
 	seenNoticeType = make(map[string]int)
 	for _, notice := range notices {
@@ -300,6 +304,8 @@ func (s *noticesSuite) TestNoticesShowsTypesAllowedForSnap(c *C) {
 	c.Check(seenNoticeType["snap-run-inhibit"], Equals, 1)
 	c.Check(seenNoticeType["interfaces-requests-prompt"], Equals, 2)
 	c.Check(seenNoticeType["interfaces-requests-rule-update"], Equals, 1)
+	c.Check(seenNoticeType["snap-lifecycle"], Equals, 1)
+	c.Check(seenNoticeType["interface-connection"], Equals, 0)
 }
 
 func (s *noticesSuite) TestNoticesFilterTypesForSnap(c *C) {
@@ -667,6 +673,153 @@ func (s *noticesSuite) TestNoticesRequestCancelled(c *C) {
 	c.Check(elapsed < reqTimeout, Equals, true)
 }
 
+// streamRecorder passes on what is written to it through a channel, for
+// streamed responses to be checked as they go.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+	writes chan string
+}
+
+func (r *streamRecorder) Write(p []byte) (int, error) {
+	r.writes <- string(p)
+	return len(p), nil
+}
+
+// followNotices starts streaming notices for the given request, returning
+// what is written and a function to stop streaming.
+func (s *noticesSuite) followNotices(c *C, req *http.Request) (writes <-chan string, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req = req.WithContext(ctx)
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=0;socket=%s;", dirs.SnapdSocket)
+	rsp := s.req(c, req, nil)
+
+	rec := &streamRecorder{ResponseRecorder: httptest.NewRecorder(), writes: make(chan string)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rsp.ServeHTTP(rec, req)
+	}()
+	return rec.writes, func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(testutil.HostScaledTimeout(5 * time.Second)):
+			c.Fatal("notices stream did not stop")
+		}
+		c.Check(rec.Code, Equals, 200)
+		c.Check(rec.Header().Get("Content-Type"), Equals, "text/event-stream")
+	}
+}
+
+func nextWrite(c *C, writes <-chan string) string {
+	select {
+	case w := <-writes:
+		return w
+	case <-time.After(testutil.HostScaledTimeout(5 * time.Second)):
+		c.Fatal("timed out waiting for notices")
+	}
+	return ""
+}
+
+func checkNoticeEvent(c *C, event string, noticeID, key string) time.Time {
+	lines := strings.Split(event, "\n")
+	c.Assert(lines, HasLen, 5)
+	c.Assert(strings.HasPrefix(lines[0], "id: "), Equals, true)
+	id, err := time.Parse(time.RFC3339Nano, strings.TrimPrefix(lines[0], "id: "))
+	c.Assert(err, IsNil)
+	c.Check(lines[1], Equals, "event: notice")
+	c.Assert(strings.HasPrefix(lines[2], "data: "), Equals, true)
+	var n map[string]any
+	c.Assert(json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &n), IsNil)
+	c.Check(n["id"], Equals, noticeID)
+	c.Check(n["key"], Equals, key)
+	lastRepeated, err := time.Parse(time.RFC3339Nano, n["last-repeated"].(string))
+	c.Assert(err, IsNil)
+	c.Check(id.Equal(lastRepeated), Equals, true)
+	c.Check(lines[3:], DeepEquals, []string{"", ""})
+	return id
+}
+
+func (s *noticesSuite) TestNoticesFollow(c *C) {
+	s.daemon(c)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	fooID, err := st.AddNotice(nil, state.WarningNotice, "foo", nil)
+	c.Assert(err, IsNil)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/notices?follow=true&types=warning", nil)
+	c.Assert(err, IsNil)
+	writes, stop := s.followNotices(c, req)
+	defer stop()
+
+	checkNoticeEvent(c, nextWrite(c, writes), fooID, "foo")
+
+	st.Lock()
+	addNotice(c, st, nil, state.ChangeUpdateNotice, "123", nil)
+	barID, err := st.AddNotice(nil, state.WarningNotice, "bar", nil)
+	c.Assert(err, IsNil)
+	st.Unlock()
+
+	// only what matches the filter is sent
+	checkNoticeEvent(c, nextWrite(c, writes), barID, "bar")
+}
+
+func (s *noticesSuite) TestNoticesFollowResume(c *C) {
+	s.daemon(c)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	addNotice(c, st, nil, state.WarningNotice, "foo", nil)
+	lastEventID := st.Notices(nil)[0].LastRepeated().Format(time.RFC3339Nano)
+	time.Sleep(time.Microsecond)
+	barID, err := st.AddNotice(nil, state.WarningNotice, "bar", nil)
+	c.Assert(err, IsNil)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/notices?follow=true", nil)
+	c.Assert(err, IsNil)
+	req.Header.Set("Last-Event-ID", lastEventID)
+	writes, stop := s.followNotices(c, req)
+	defer stop()
+
+	checkNoticeEvent(c, nextWrite(c, writes), barID, "bar")
+}
+
+func (s *noticesSuite) TestNoticesFollowKeepAlive(c *C) {
+	s.daemon(c)
+	restore := daemon.MockNoticesKeepAliveInterval(time.Millisecond)
+	defer restore()
+
+	req, err := http.NewRequest("GET", "/v2/notices?follow=true", nil)
+	c.Assert(err, IsNil)
+	writes, stop := s.followNotices(c, req)
+	defer stop()
+
+	c.Check(nextWrite(c, writes), Equals, ": keep-alive\n\n")
+}
+
+func (s *noticesSuite) TestNoticesInvalidFollow(c *C) {
+	s.testNoticesBadRequest(c, "follow=foo", `invalid value for follow: "foo": .*`)
+}
+
+func (s *noticesSuite) TestNoticesFollowWithTimeout(c *C) {
+	s.testNoticesBadRequest(c, "follow=true&timeout=1s", `cannot use both "follow" and "timeout" parameters`)
+}
+
+func (s *noticesSuite) TestNoticesFollowInvalidLastEventID(c *C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/notices?follow=true", nil)
+	c.Assert(err, IsNil)
+	req.Header.Set("Last-Event-ID", "foo")
+	req.RemoteAddr = fmt.Sprintf("pid=100;uid=0;socket=%s;", dirs.SnapdSocket)
+	rsp := s.errorReq(c, req, nil)
+	c.Check(rsp.Status, Equals, 400)
+	c.Check(rsp.Message, Matches, `invalid "Last-Event-ID" header: .*`)
+}
+
 func (s *noticesSuite) TestNoticesInvalidUserID(c *C) {
 	s.testNoticesBadRequest(c, "user-id=foo", `invalid "user-id" filter:.*`)
 }
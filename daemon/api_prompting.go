@@ -100,6 +100,39 @@ func getUserID(r *http.Request) (uint32, Response) {
 	return uint32(userIDInt), nil
 }
 
+// getRulesUserID returns prompting.AllUsers if the all-users parameter of the
+// query is true, so that rules defined by an administrator for every user are
+// acted upon, otherwise the UID as returned by getUserID.
+//
+// Only admin users are allowed to use the all-users parameter.
+//
+// If an error occurs, returns an error response, otherwise returns the user ID
+// and a nil response.
+func getRulesUserID(r *http.Request) (uint32, Response) {
+	query := r.URL.Query()
+	if len(query["all-users"]) == 0 {
+		return getUserID(r)
+	}
+	allUsers, err := strconv.ParseBool(query.Get("all-users"))
+	if err != nil {
+		return 0, BadRequest(`invalid "all-users" parameter: %v`, err)
+	}
+	if !allUsers {
+		return getUserID(r)
+	}
+	ucred, err := ucrednetGet(r.RemoteAddr)
+	if err != nil {
+		return 0, Forbidden("cannot get remote user: %v", err)
+	}
+	if ucred.Uid != 0 {
+		return 0, Forbidden(`only admins may use the "all-users" parameter`)
+	}
+	if len(query["user-id"]) != 0 {
+		return 0, BadRequest(`cannot use "all-users" and "user-id" parameters together`)
+	}
+	return prompting.AllUsers, nil
+}
+
 // isClientActivity returns true if the request comes a prompting handler
 // service.
 func isClientActivity(c *Command, r *http.Request) bool {
@@ -315,9 +348,10 @@ type patchRuleContents struct {
 }
 
 type postRulesRequestBody struct {
-	Action         string               `json:"action"`
-	AddRule        *addRuleContents     `json:"rule,omitempty"`
-	RemoveSelector *removeRulesSelector `json:"selector,omitempty"`
+	Action         string                       `json:"action"`
+	AddRule        *addRuleContents             `json:"rule,omitempty"`
+	RemoveSelector *removeRulesSelector         `json:"selector,omitempty"`
+	ImportRules    []*requestrules.ExportedRule `json:"rules,omitempty"`
 }
 
 type postRuleRequestBody struct {
@@ -415,7 +449,7 @@ func postPrompt(c *Command, r *http.Request, user *auth.UserState) Response {
 }
 
 func getRules(c *Command, r *http.Request, user *auth.UserState) Response {
-	userID, errorResp := getUserID(r)
+	userID, errorResp := getRulesUserID(r)
 	if errorResp != nil {
 		return errorResp
 	}
@@ -428,6 +462,16 @@ func getRules(c *Command, r *http.Request, user *auth.UserState) Response {
 	snap := query.Get("snap")
 	iface := query.Get("interface")
 
+	if len(query["export"]) != 0 {
+		export, err := strconv.ParseBool(query.Get("export"))
+		if err != nil {
+			return BadRequest(`invalid "export" parameter: %v`, err)
+		}
+		if export {
+			return exportRules(c, userID, snap, iface)
+		}
+	}
+
 	rules, err := getInterfaceManager(c).InterfacesRequestsManager().Rules(userID, snap, iface)
 	if err != nil {
 		// Should be impossible, Rules() always returns nil error
@@ -441,8 +485,21 @@ func getRules(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(rules)
 }
 
+func exportRules(c *Command, userID uint32, snap, iface string) Response {
+	exported, err := getInterfaceManager(c).InterfacesRequestsManager().ExportRules(userID, snap, iface)
+	if err != nil {
+		return promptingError(err)
+	}
+
+	if len(exported) == 0 {
+		exported = []*requestrules.ExportedRule{}
+	}
+
+	return SyncResponse(exported)
+}
+
 func postRules(c *Command, r *http.Request, user *auth.UserState) Response {
-	userID, errorResp := getUserID(r)
+	userID, errorResp := getRulesUserID(r)
 	if errorResp != nil {
 		return errorResp
 	}
@@ -479,8 +536,20 @@ func postRules(c *Command, r *http.Request, user *auth.UserState) Response {
 			return promptingError(err)
 		}
 		return SyncResponse(removedRules)
+	case "import":
+		if postBody.ImportRules == nil {
+			return BadRequest(`must include "rules" field in request body when action is "import"`)
+		}
+		importedRules, err := getInterfaceManager(c).InterfacesRequestsManager().ImportRules(userID, postBody.ImportRules)
+		if err != nil {
+			return promptingError(err)
+		}
+		if len(importedRules) == 0 {
+			importedRules = []*requestrules.Rule{}
+		}
+		return SyncResponse(importedRules)
 	default:
-		return BadRequest(`"action" field must be "create" or "remove"`)
+		return BadRequest(`"action" field must be "add", "remove" or "import"`)
 	}
 }
 
@@ -488,7 +557,7 @@ func getRule(c *Command, r *http.Request, user *auth.UserState) Response {
 	vars := muxVars(r)
 	id := vars["id"]
 
-	userID, errorResp := getUserID(r)
+	userID, errorResp := getRulesUserID(r)
 	if errorResp != nil {
 		return errorResp
 	}
@@ -514,7 +583,7 @@ func postRule(c *Command, r *http.Request, user *auth.UserState) Response {
 	vars := muxVars(r)
 	id := vars["id"]
 
-	userID, errorResp := getUserID(r)
+	userID, errorResp := getRulesUserID(r)
 	if errorResp != nil {
 		return errorResp
 	}
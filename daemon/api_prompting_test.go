@@ -49,6 +49,7 @@ type fakeInterfacesRequestsManager struct {
 	prompt       *requestprompts.Prompt
 	rule         *requestrules.Rule
 	satisfiedIDs []prompting.IDType
+	exported     []*requestrules.ExportedRule
 	err          error
 
 	// Store most recent received values
@@ -58,6 +59,7 @@ type fakeInterfacesRequestsManager struct {
 	id               prompting.IDType // used for prompt ID or rule ID
 	ruleConstraints  *prompting.Constraints
 	constraintsPatch *prompting.RuleConstraintsPatch
+	importedRules    []*requestrules.ExportedRule
 	replyConstraints *prompting.ReplyConstraints
 	outcome          prompting.OutcomeType
 	lifespan         prompting.LifespanType
@@ -130,6 +132,19 @@ func (m *fakeInterfacesRequestsManager) RemoveRule(userID uint32, ruleID prompti
 	return m.rule, m.err
 }
 
+func (m *fakeInterfacesRequestsManager) ExportRules(userID uint32, snap string, iface string) ([]*requestrules.ExportedRule, error) {
+	m.userID = userID
+	m.snap = snap
+	m.iface = iface
+	return m.exported, m.err
+}
+
+func (m *fakeInterfacesRequestsManager) ImportRules(userID uint32, rules []*requestrules.ExportedRule) ([]*requestrules.Rule, error) {
+	m.userID = userID
+	m.importedRules = rules
+	return m.rules, m.err
+}
+
 type promptingSuite struct {
 	apiBaseSuite
 
@@ -255,6 +270,73 @@ func (s *promptingSuite) TestGetUserID(c *C) {
 	}
 }
 
+func (s *promptingSuite) TestGetRulesUserID(c *C) {
+	s.daemon(c)
+
+	for _, testCase := range []struct {
+		path         string
+		uid          string
+		expectedUser uint32
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			path:         "/v2/interfaces/requests/rules?all-users=true",
+			uid:          "0",
+			expectedUser: prompting.AllUsers,
+		},
+		{
+			path:         "/v2/interfaces/requests/rules?all-users=false",
+			uid:          "1000",
+			expectedUser: 1000,
+		},
+		{
+			path:         "/v2/interfaces/requests/rules?all-users=false&user-id=1234",
+			uid:          "0",
+			expectedUser: 1234,
+		},
+		{
+			path:         "/v2/interfaces/requests/rules?all-users=true",
+			uid:          "1000",
+			expectedCode: 403,
+			expectedErr:  `only admins may use the "all-users" parameter`,
+		},
+		{
+			path:         "/v2/interfaces/requests/rules?all-users=true",
+			uid:          "invalid",
+			expectedCode: 403,
+			expectedErr:  "cannot get remote user: ",
+		},
+		{
+			path:         "/v2/interfaces/requests/rules?all-users=foo",
+			uid:          "0",
+			expectedCode: 400,
+			expectedErr:  `invalid "all-users" parameter: `,
+		},
+		{
+			path:         "/v2/interfaces/requests/rules?all-users=true&user-id=1234",
+			uid:          "0",
+			expectedCode: 400,
+			expectedErr:  `cannot use "all-users" and "user-id" parameters together`,
+		},
+	} {
+		req, err := http.NewRequest("GET", testCase.path, nil)
+		c.Assert(err, IsNil)
+		req.RemoteAddr = fmt.Sprintf("pid=100;uid=%s;socket=;", testCase.uid)
+
+		userID, rsp := daemon.GetRulesUserID(req)
+		if testCase.expectedErr == "" {
+			c.Check(rsp, IsNil)
+		} else {
+			rspe, ok := rsp.(*daemon.APIError)
+			c.Assert(ok, Equals, true)
+			c.Check(rspe.Status, Equals, testCase.expectedCode)
+			c.Check(rspe.Message, testutil.Contains, testCase.expectedErr)
+		}
+		c.Check(userID, Equals, testCase.expectedUser)
+	}
+}
+
 func (s *promptingSuite) TestPromptingNotRunningError(c *C) {
 	apiResp := daemon.PromptingNotRunningError()
 	jsonResp := apiResp.JSON()
@@ -778,6 +860,11 @@ func (s *promptingSuite) TestGetRulesHappy(c *C) {
 			"firefox",
 			"home",
 		},
+		{
+			"?snap=firefox&all-users=false",
+			"firefox",
+			"",
+		},
 	} {
 		// Make sure manager is zeroed out again
 		s.manager = &fakeInterfacesRequestsManager{}
@@ -877,6 +964,67 @@ func (s *promptingSuite) TestPostRulesAddHappy(c *C) {
 	c.Check(rule, DeepEquals, s.manager.rule)
 }
 
+func (s *promptingSuite) TestPostRulesAddAllUsers(c *C) {
+	s.expectWriteAccess(daemon.InterfaceAuthenticatedAccess{Interfaces: []string{"snap-interfaces-requests-control"}, Polkit: "io.snapcraft.snapd.manage"})
+
+	s.daemon(c)
+
+	s.manager.rule = &requestrules.Rule{
+		ID:        prompting.IDType(1234),
+		Timestamp: time.Now(),
+		User:      prompting.AllUsers,
+		Snap:      "thunderbird",
+		Interface: "home",
+		Origin:    requestrules.OriginAdmin,
+		Constraints: &prompting.RuleConstraints{
+			PathPattern: mustParsePathPattern(c, "/home/*/.ssh/**"),
+			Permissions: prompting.RulePermissionMap{
+				"write": &prompting.RulePermissionEntry{
+					Outcome:  prompting.OutcomeDeny,
+					Lifespan: prompting.LifespanForever,
+				},
+			},
+		},
+	}
+
+	contents := &daemon.AddRuleContents{
+		Snap:      "thunderbird",
+		Interface: "home",
+		Constraints: &prompting.Constraints{
+			PathPattern: mustParsePathPattern(c, "/home/*/.ssh/**"),
+			Permissions: prompting.PermissionMap{
+				"write": &prompting.PermissionEntry{
+					Outcome:  prompting.OutcomeDeny,
+					Lifespan: prompting.LifespanForever,
+				},
+			},
+		},
+	}
+	postBody := &daemon.PostRulesRequestBody{
+		Action:  "add",
+		AddRule: contents,
+	}
+	marshalled, err := json.Marshal(postBody)
+	c.Assert(err, IsNil)
+
+	rsp := s.makeSyncReq(c, "POST", "/v2/interfaces/requests/rules?all-users=true", 0, marshalled)
+
+	c.Check(s.manager.userID, Equals, prompting.AllUsers)
+	c.Check(s.manager.snap, Equals, contents.Snap)
+	c.Check(s.manager.ruleConstraints, DeepEquals, contents.Constraints)
+
+	rule, ok := rsp.Result.(*requestrules.Rule)
+	c.Check(ok, Equals, true)
+	c.Check(rule, DeepEquals, s.manager.rule)
+
+	// Non-admin users cannot add rules for all users
+	req, err := http.NewRequest("POST", "/v2/interfaces/requests/rules?all-users=true", bytes.NewReader(marshalled))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, Equals, 403)
+}
+
 func (s *promptingSuite) TestPostRulesRemoveHappy(c *C) {
 	s.expectWriteAccess(daemon.InterfaceAuthenticatedAccess{Interfaces: []string{"snap-interfaces-requests-control"}, Polkit: "io.snapcraft.snapd.manage"})
 
@@ -968,6 +1116,139 @@ func (s *promptingSuite) TestPostRulesRemoveHappy(c *C) {
 	}
 }
 
+func (s *promptingSuite) TestGetRulesExport(c *C) {
+	s.daemon(c)
+
+	s.manager.exported = []*requestrules.ExportedRule{
+		{
+			Snap:      "firefox",
+			Interface: "home",
+			Constraints: &prompting.Constraints{
+				PathPattern: mustParsePathPattern(c, "/home/test/Downloads/**"),
+				Permissions: prompting.PermissionMap{
+					"write": &prompting.PermissionEntry{
+						Outcome:  prompting.OutcomeAllow,
+						Lifespan: prompting.LifespanForever,
+					},
+				},
+			},
+		},
+	}
+
+	rsp := s.makeSyncReq(c, "GET", "/v2/interfaces/requests/rules?export=true&snap=firefox", 1000, nil)
+
+	// Check parameters
+	c.Check(s.manager.userID, Equals, uint32(1000))
+	c.Check(s.manager.snap, Equals, "firefox")
+	c.Check(s.manager.iface, Equals, "")
+
+	// Check return value
+	exported, ok := rsp.Result.([]*requestrules.ExportedRule)
+	c.Check(ok, Equals, true)
+	c.Check(exported, DeepEquals, s.manager.exported)
+
+	// An empty export is a list rather than null
+	s.manager.exported = nil
+	rsp = s.makeSyncReq(c, "GET", "/v2/interfaces/requests/rules?export=true", 1000, nil)
+	exported, ok = rsp.Result.([]*requestrules.ExportedRule)
+	c.Check(ok, Equals, true)
+	c.Check(exported, HasLen, 0)
+	c.Check(exported, NotNil)
+
+	req, err := http.NewRequest("GET", "/v2/interfaces/requests/rules?export=foo", nil)
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, Equals, 400)
+	c.Check(rspe.Message, Matches, `invalid "export" parameter: .*`)
+}
+
+func (s *promptingSuite) TestPostRulesImportHappy(c *C) {
+	s.expectWriteAccess(daemon.InterfaceAuthenticatedAccess{Interfaces: []string{"snap-interfaces-requests-control"}, Polkit: "io.snapcraft.snapd.manage"})
+
+	s.daemon(c)
+
+	s.manager.rules = []*requestrules.Rule{
+		{
+			ID:        prompting.IDType(1234),
+			Timestamp: time.Now(),
+			User:      1000,
+			Snap:      "firefox",
+			Interface: "home",
+			Origin:    requestrules.OriginUser,
+			Constraints: &prompting.RuleConstraints{
+				PathPattern: mustParsePathPattern(c, "/home/test/Downloads/**"),
+				Permissions: prompting.RulePermissionMap{
+					"write": &prompting.RulePermissionEntry{
+						Outcome:  prompting.OutcomeAllow,
+						Lifespan: prompting.LifespanForever,
+					},
+				},
+			},
+		},
+	}
+
+	rules := []*requestrules.ExportedRule{
+		{
+			Snap:      "firefox",
+			Interface: "home",
+			Constraints: &prompting.Constraints{
+				PathPattern: mustParsePathPattern(c, "/home/test/Downloads/**"),
+				Permissions: prompting.PermissionMap{
+					"write": &prompting.PermissionEntry{
+						Outcome:  prompting.OutcomeAllow,
+						Lifespan: prompting.LifespanForever,
+					},
+				},
+			},
+		},
+	}
+	postBody := &daemon.PostRulesRequestBody{
+		Action:      "import",
+		ImportRules: rules,
+	}
+	marshalled, err := json.Marshal(postBody)
+	c.Assert(err, IsNil)
+
+	rsp := s.makeSyncReq(c, "POST", "/v2/interfaces/requests/rules", 1000, marshalled)
+
+	// Check parameters
+	c.Check(s.manager.userID, Equals, uint32(1000))
+	c.Check(s.manager.importedRules, DeepEquals, rules)
+
+	// Check return value
+	imported, ok := rsp.Result.([]*requestrules.Rule)
+	c.Check(ok, Equals, true)
+	c.Check(imported, DeepEquals, s.manager.rules)
+}
+
+func (s *promptingSuite) TestPostRulesImportUnhappy(c *C) {
+	s.expectWriteAccess(daemon.InterfaceAuthenticatedAccess{Interfaces: []string{"snap-interfaces-requests-control"}, Polkit: "io.snapcraft.snapd.manage"})
+
+	s.daemon(c)
+
+	req, err := http.NewRequest("POST", "/v2/interfaces/requests/rules", bytes.NewReader([]byte(`{"action":"import"}`)))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, Equals, 400)
+	c.Check(rspe.Message, Equals, `must include "rules" field in request body when action is "import"`)
+
+	s.manager.err = fmt.Errorf("cannot import rules: %w", &prompting_errors.RuleConflictError{
+		Conflicts: []prompting_errors.RuleConflict{{
+			Permission:    "write",
+			Variant:       "/home/test/Downloads/**",
+			ConflictingID: "0000000000001234",
+		}},
+	})
+	req, err = http.NewRequest("POST", "/v2/interfaces/requests/rules", bytes.NewReader([]byte(`{"action":"import","rules":[]}`)))
+	c.Assert(err, IsNil)
+	req.RemoteAddr = "pid=100;uid=1000;socket=;"
+	rspe = s.errorReq(c, req, nil)
+	c.Check(rspe.Status, Equals, 409)
+	c.Check(rspe.Kind, Equals, client.ErrorKindInterfacesRequestsRuleConflict)
+}
+
 func (s *promptingSuite) TestGetRuleHappy(c *C) {
 	s.daemon(c)
 
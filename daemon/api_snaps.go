@@ -146,6 +146,10 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("%s", err)
 	}
 
+	if inst.Simulate {
+		return simulateRefresh(r.Context(), &inst, st)
+	}
+
 	impl := inst.dispatch()
 	if impl == nil {
 		return BadRequest("unknown action %s", inst.Action)
@@ -159,6 +163,9 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 	chg := newChange(st, inst.Action+"-snap", res.Summary, res.Tasksets, res.Affected)
 	if len(res.Tasksets) == 0 {
 		chg.SetStatus(state.DoneStatus)
+	} else if !inst.at.IsZero() {
+		// held until then
+		chg.At(inst.at)
 	}
 
 	if inst.SystemRestartImmediate {
@@ -229,13 +236,17 @@ type snapInstruction struct {
 	Snaps                  []string                         `json:"snaps"`
 	Users                  []string                         `json:"users"`
 	SnapshotOptions        map[string]*snap.SnapshotOptions `json:"snapshot-options"`
+	Secret                 []byte                           `json:"secret"`
 	ValidationSets         []string                         `json:"validation-sets"`
 	QuotaGroupName         string                           `json:"quota-group"`
 	Time                   string                           `json:"time"`
 	HoldLevel              string                           `json:"hold-level"`
+	At                     string                           `json:"at"`
+	Simulate               bool                             `json:"simulate"`
 
 	// The fields below should not be unmarshalled into. Do not export them.
 	userID int
+	at     time.Time
 }
 
 func (inst *snapInstruction) setCompsFromRawList() error {
@@ -364,6 +375,9 @@ func (inst *snapInstruction) validate() error {
 	default:
 		return fmt.Errorf("invalid value for transaction type: %s", inst.Transaction)
 	}
+	if len(inst.Secret) != 0 && inst.Action != "snapshot" {
+		return fmt.Errorf("secret can only be specified for snapshot action")
+	}
 	if inst.QuotaGroupName != "" && inst.Action != "install" {
 		return fmt.Errorf("quota-group can only be specified on install")
 	}
@@ -392,6 +406,20 @@ func (inst *snapInstruction) validate() error {
 		}
 	}
 
+	if inst.At != "" {
+		if inst.Action != "refresh" && inst.Action != "remove" {
+			return errors.New(`at can only be specified for the "refresh" or "remove" actions`)
+		}
+		at, err := time.Parse(time.RFC3339, inst.At)
+		if err != nil {
+			return fmt.Errorf("at must be in RFC3339 format: %v", err)
+		}
+		if !at.After(time.Now()) {
+			return errors.New("at must be in the future")
+		}
+		inst.at = at
+	}
+
 	if inst.Unaliased && inst.Prefer {
 		return errUnaliasedPreferConflict
 	}
@@ -399,6 +427,18 @@ func (inst *snapInstruction) validate() error {
 		return fmt.Errorf("the prefer flag can only be specified on install")
 	}
 
+	if inst.Simulate {
+		if inst.Action != "refresh" {
+			return errors.New(`simulate can only be specified for the "refresh" action`)
+		}
+		if len(inst.ValidationSets) > 0 {
+			return errors.New("cannot simulate enforcing validation sets")
+		}
+		if inst.At != "" {
+			return errors.New("cannot simulate a scheduled refresh")
+		}
+	}
+
 	if inst.Terminate && inst.Action != "remove" {
 		return fmt.Errorf(`terminate can only be specified for the "remove" action`)
 	}
@@ -589,6 +629,36 @@ func snapUpdate(ctx context.Context, inst *snapInstruction, st *state.State) (*s
 	}, nil
 }
 
+// simulateRefresh reports what refreshing the snaps of the instruction
+// would do, without changing anything.
+func simulateRefresh(ctx context.Context, inst *snapInstruction, st *state.State) Response {
+	flags, err := inst.modeFlags()
+	if err != nil {
+		return inst.errToResponse(err)
+	}
+	flags.IgnoreValidation = inst.IgnoreValidation
+	flags.IgnoreRunning = inst.IgnoreRunning
+	flags.Amend = inst.Amend
+
+	updates := make([]snapstate.StoreUpdate, 0, len(inst.Snaps))
+	for _, name := range inst.Snaps {
+		updates = append(updates, snapstate.StoreUpdate{
+			InstanceName:         name,
+			RevOpts:              *inst.revnoOpts(),
+			AdditionalComponents: inst.CompsForSnaps[name],
+		})
+	}
+
+	sim, err := snapstateSimulateUpdate(ctx, st, snapstateStoreUpdateGoal(updates...), snapstate.Options{
+		Flags:  flags,
+		UserID: inst.userID,
+	})
+	if err != nil {
+		return inst.errToResponse(err)
+	}
+	return SyncResponse(sim)
+}
+
 func snapRemove(_ context.Context, inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
 	if len(inst.CompsForSnaps) > 0 {
 		msg, allTaskSets, err := removeSnapComponents(inst, st)
@@ -812,6 +882,10 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 		inst.userID = user.ID
 	}
 
+	if inst.Simulate {
+		return simulateRefresh(r.Context(), &inst, st)
+	}
+
 	op := inst.dispatchForMany()
 	if op == nil {
 		return BadRequest("unsupported multi-snap operation %q", inst.Action)
@@ -825,6 +899,9 @@ func snapOpMany(c *Command, r *http.Request, user *auth.UserState) Response {
 	chg := newChange(st, inst.Action+"-snap", res.Summary, res.Tasksets, res.Affected)
 	if len(res.Tasksets) == 0 {
 		chg.SetStatus(state.DoneStatus)
+	} else if !inst.at.IsZero() {
+		// held until then
+		chg.At(inst.at)
 	}
 
 	if inst.SystemRestartImmediate {
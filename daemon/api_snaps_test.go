@@ -498,6 +498,22 @@ func (s *snapsSuite) TestPostSnapsNoWeirdses(c *check.C) {
 	}
 }
 
+func (s *snapsSuite) TestPostSnapsSecretUnsupportedActionError(c *check.C) {
+	s.daemon(c)
+	const expectedErr = "secret can only be specified for snapshot action"
+
+	for _, action := range []string{"install", "refresh", "revert", "remove", "enable", "disable", "switch"} {
+		buf := strings.NewReader(fmt.Sprintf(`{"action": "%s", "snaps":["foo"], "secret": "czNjcjN0"}`, action))
+		req, err := http.NewRequest("POST", "/v2/snaps", buf)
+		c.Assert(err, check.IsNil)
+		req.Header.Set("Content-Type", "application/json")
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, check.Commentf("%q", action))
+		c.Check(rspe.Message, check.Equals, expectedErr, check.Commentf("%q", action))
+	}
+}
+
 func (s *snapsSuite) TestPostSnapsOptionsUnsupportedActionError(c *check.C) {
 	s.daemon(c)
 	const expectedErr = "snapshot-options can only be specified for snapshot action"
@@ -629,10 +645,58 @@ func (s *snapsSuite) TestPostSnapsRemoveManyWithPurge(c *check.C) {
 	c.Check(chg.Summary(), check.Equals, `Remove snaps "foo", "bar"`)
 }
 
+func (s *snapsSuite) TestPostSnapsRemoveManyAt(c *check.C) {
+	d := s.daemonWithOverlordMockAndStore()
+	d.Overlord().Loop()
+	defer d.Overlord().Stop()
+
+	defer daemon.MockSnapstateRemoveMany(func(s *state.State, names []string, opts *snapstate.RemoveFlags) ([]string, []*state.TaskSet, error) {
+		t := s.NewTask("fake-remove-2", "Remove two")
+		return names, []*state.TaskSet{state.NewTaskSet(t)}, nil
+	})()
+
+	when := time.Now().Add(time.Hour).Truncate(time.Second)
+	buf := strings.NewReader(fmt.Sprintf(`{"action": "remove", "snaps":["foo", "bar"], "at": %q}`, when.Format(time.RFC3339)))
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.jsonReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 202)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Check(chg.ScheduledTime().Equal(when), check.Equals, true)
+	c.Assert(chg.Tasks(), check.HasLen, 1)
+	c.Check(chg.Tasks()[0].AtTime().Equal(when), check.Equals, true)
+}
+
+func (s *snapsSuite) TestPostSnapsAtErrors(c *check.C) {
+	s.daemon(c)
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "install", "snaps": ["foo"], "at": "2026-10-20T02:00:00Z"}`, `at can only be specified for the "refresh" or "remove" actions.*`},
+		{`{"action": "refresh", "at": "tuesday"}`, `at must be in RFC3339 format: .*`},
+		{`{"action": "refresh", "at": "2020-10-20T02:00:00Z"}`, `at must be in the future.*`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps", strings.NewReader(t.body))
+		c.Assert(err, check.IsNil)
+		req.Header.Set("Content-Type", "application/json")
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Error(), check.Matches, t.err)
+	}
+}
+
 func (s *snapsSuite) TestPostSnapsOptionsClean(c *check.C) {
 	var snapshotSaveCalled int
 	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
-		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		options map[string]*snap.SnapshotOptions, secret []byte) (uint64, []string, *state.TaskSet, error) {
 		snapshotSaveCalled++
 
 		c.Check(snaps, check.HasLen, 3)
@@ -816,6 +880,90 @@ func (s *snapsSuite) TestRefreshAllRestoresValidationSets(c *check.C) {
 	c.Check(refreshAssertionsOpts.IsRefreshOfAllSnaps, check.Equals, true)
 }
 
+func (s *snapsSuite) TestPostSnapsOpSimulate(c *check.C) {
+	defer daemon.MockAssertstateRefreshSnapAssertions(func(*state.State, int, *assertstate.RefreshAssertionsOptions) error {
+		c.Fatalf("unexpected refresh of assertions")
+		return nil
+	})()
+	defer daemon.MockSnapstateUpdateWithGoal(func(_ context.Context, s *state.State, g snapstate.UpdateGoal, filter func(*snap.Info, *snapstate.SnapState) bool, opts snapstate.Options) ([]string, *snapstate.UpdateTaskSets, error) {
+		c.Fatalf("unexpected refresh")
+		return nil, nil, nil
+	})()
+	var calledFlags snapstate.Flags
+	defer daemon.MockSnapstateSimulateUpdate(func(_ context.Context, st *state.State, g snapstate.UpdateGoal, opts snapstate.Options) (*snapstate.RefreshSimulation, error) {
+		goal := g.(*storeUpdateGoalRecorder)
+		c.Check(goal.names(), check.DeepEquals, []string{"foo", "bar"})
+		calledFlags = opts.Flags
+		return &snapstate.RefreshSimulation{
+			Snaps:   []*snapstate.SimulatedRefresh{{InstanceName: "foo", Revision: snap.R(2), RunningApps: []string{"app"}}},
+			Skipped: []snapstate.SimulatedSkip{{InstanceName: "bar", Reason: "no update available"}},
+		}, nil
+	})()
+
+	d := s.daemonWithOverlordMockAndStore()
+
+	buf := bytes.NewBufferString(`{"action": "refresh", "snaps": ["foo", "bar"], "simulate": true, "ignore-running": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := s.syncReq(c, req, nil)
+	sim, ok := rsp.Result.(*snapstate.RefreshSimulation)
+	c.Assert(ok, check.Equals, true)
+	c.Check(sim.Snaps[0].InstanceName, check.Equals, "foo")
+	c.Check(sim.Skipped, check.HasLen, 1)
+	c.Check(calledFlags.IgnoreRunning, check.Equals, true)
+
+	st := d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+}
+
+func (s *snapsSuite) TestPostSnapSimulate(c *check.C) {
+	defer daemon.MockSnapstateSimulateUpdate(func(_ context.Context, st *state.State, g snapstate.UpdateGoal, opts snapstate.Options) (*snapstate.RefreshSimulation, error) {
+		goal := g.(*storeUpdateGoalRecorder)
+		c.Assert(goal.snaps, check.HasLen, 1)
+		c.Check(goal.snaps[0].InstanceName, check.Equals, "foo")
+		c.Check(goal.snaps[0].RevOpts.Channel, check.Equals, "beta")
+		return &snapstate.RefreshSimulation{Snaps: []*snapstate.SimulatedRefresh{}}, nil
+	})()
+
+	s.daemonWithOverlordMockAndStore()
+
+	buf := bytes.NewBufferString(`{"action": "refresh", "channel": "beta", "simulate": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Result, check.DeepEquals, &snapstate.RefreshSimulation{Snaps: []*snapstate.SimulatedRefresh{}})
+}
+
+func (s *snapsSuite) TestPostSnapSimulateErrors(c *check.C) {
+	s.daemonWithOverlordMockAndStore()
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "install", "simulate": true}`, `simulate can only be specified for the "refresh" action`},
+		{`{"action": "refresh", "simulate": true, "at": "2026-10-17T10:00:00Z"}`, `cannot simulate a scheduled refresh`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snaps/foo", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400)
+		c.Check(rspe.Message, check.Equals, t.err)
+	}
+
+	req, err := http.NewRequest("POST", "/v2/snaps", bytes.NewBufferString(`{"action": "refresh", "simulate": true, "validation-sets": ["foo/bar"]}`))
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+	rspe := s.errorReq(c, req, nil)
+	c.Check(rspe.Status, check.Equals, 400)
+	c.Check(rspe.Message, check.Equals, "cannot simulate enforcing validation sets")
+}
+
 func (s *snapsSuite) TestRefreshManyTransactionally(c *check.C) {
 	var calledFlags *snapstate.Flags
 
@@ -45,6 +45,16 @@ var snapshotCmd = &Command{
 	WriteAccess: authenticatedAccess{Polkit: polkitActionManage},
 }
 
+var snapshotFilesCmd = &Command{
+	Path: "/v2/snapshots/{id}/files",
+	GET:  listSnapshotFiles,
+	// POST is used to list the files of encrypted snapshots, as the
+	// secret must not be part of the URL
+	POST:        listEncryptedSnapshotFiles,
+	ReadAccess:  authenticatedAccess{},
+	WriteAccess: authenticatedAccess{},
+}
+
 var snapshotExportCmd = &Command{
 	Path:       "/v2/snapshots/{id}/export",
 	GET:        getSnapshotExport,
@@ -53,6 +63,8 @@ var snapshotExportCmd = &Command{
 
 var (
 	snapshotList    = snapshotstate.List
+	snapshotFiles   = snapshotstate.Files
+	snapshotDiff    = snapshotstate.Diff
 	snapshotCheck   = snapshotstate.Check
 	snapshotForget  = snapshotstate.Forget
 	snapshotRestore = snapshotstate.Restore
@@ -82,6 +94,67 @@ func listSnapshots(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(sets)
 }
 
+func listSnapshotFiles(c *Command, r *http.Request, user *auth.UserState) Response {
+	sid := muxVars(r)["id"]
+	setID, err := strconv.ParseUint(sid, 10, 64)
+	if err != nil {
+		return BadRequest("'id' must be a positive base 10 number; got %q", sid)
+	}
+	query := r.URL.Query()
+	return snapshotFilesResponse(r.Context(), setID, query.Get("snap"), strutil.CommaSeparatedList(query.Get("users")), nil)
+}
+
+// snapshotFilesRequest is the body of a request to list the files of an
+// encrypted snapshot.
+type snapshotFilesRequest struct {
+	Snap   string   `json:"snap"`
+	Users  []string `json:"users,omitempty"`
+	Secret []byte   `json:"secret"`
+}
+
+func listEncryptedSnapshotFiles(c *Command, r *http.Request, user *auth.UserState) Response {
+	sid := muxVars(r)["id"]
+	setID, err := strconv.ParseUint(sid, 10, 64)
+	if err != nil {
+		return BadRequest("'id' must be a positive base 10 number; got %q", sid)
+	}
+	var req snapshotFilesRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		return BadRequest("cannot decode request body into snapshot files request: %v", err)
+	}
+	if decoder.More() {
+		return BadRequest("extra content found after snapshot files request")
+	}
+	if len(req.Secret) == 0 {
+		return BadRequest("listing encrypted snapshot files requires a secret")
+	}
+	return snapshotFilesResponse(r.Context(), setID, req.Snap, req.Users, req.Secret)
+}
+
+func snapshotFilesResponse(ctx context.Context, setID uint64, snapName string, users []string, secret []byte) Response {
+	if snapName == "" {
+		return BadRequest("listing snapshot files requires a snap")
+	}
+
+	// this reads the archives, so it's done without the state lock
+	files, err := snapshotFiles(ctx, setID, snapName, users, secret)
+	switch err {
+	case nil:
+		// woo
+	case client.ErrSnapshotSetNotFound, client.ErrSnapshotSnapsNotFound:
+		return NotFound("%v", err)
+	case client.ErrSnapshotEncrypted, snapshotstate.ErrWrongKey:
+		return BadRequest("%v", err)
+	default:
+		return InternalError("%v", err)
+	}
+	if files == nil {
+		files = []client.SnapshotFile{}
+	}
+	return SyncResponse(files)
+}
+
 // A snapshotAction is used to request an operation on a snapshot
 // keep this in sync with client/snapshotAction...
 type snapshotAction struct {
@@ -89,6 +162,10 @@ type snapshotAction struct {
 	Action string   `json:"action"`
 	Snaps  []string `json:"snaps,omitempty"`
 	Users  []string `json:"users,omitempty"`
+	Paths  []string `json:"paths,omitempty"`
+	Secret []byte   `json:"secret,omitempty"`
+	// OtherSetID is the set to compare with in a diff
+	OtherSetID uint64 `json:"other-set,omitempty"`
 }
 
 func (action snapshotAction) String() string {
@@ -127,6 +204,18 @@ func changeSnapshots(c *Command, r *http.Request, user *auth.UserState) Response
 		return BadRequest("snapshot operation requires action")
 	}
 
+	if len(action.Secret) != 0 && action.Action != "restore" && action.Action != "diff" {
+		return BadRequest("snapshot %q operation cannot specify a secret", action.Action)
+	}
+
+	if len(action.Paths) != 0 && action.Action != "restore" {
+		return BadRequest("snapshot %q operation cannot specify paths", action.Action)
+	}
+
+	if action.OtherSetID != 0 && action.Action != "diff" {
+		return BadRequest("snapshot %q operation cannot specify another set", action.Action)
+	}
+
 	var affected []string
 	var ts *state.TaskSet
 	var err error
@@ -139,12 +228,20 @@ func changeSnapshots(c *Command, r *http.Request, user *auth.UserState) Response
 	case "check":
 		affected, ts, err = snapshotCheck(st, action.SetID, action.Snaps, action.Users)
 	case "restore":
-		affected, ts, err = snapshotRestore(st, action.SetID, action.Snaps, action.Users)
+		affected, ts, err = snapshotRestore(st, action.SetID, action.Snaps, action.Users, action.Paths, action.Secret)
 	case "forget":
 		if len(action.Users) != 0 {
 			return BadRequest(`snapshot "forget" operation cannot specify users`)
 		}
 		affected, ts, err = snapshotForget(st, action.SetID, action.Snaps)
+	case "diff":
+		if action.OtherSetID == 0 {
+			return BadRequest(`snapshot "diff" operation requires another snapshot set ID`)
+		}
+		if len(action.Users) != 0 {
+			return BadRequest(`snapshot "diff" operation cannot specify users`)
+		}
+		affected, ts, err = snapshotDiff(st, action.SetID, action.OtherSetID, action.Snaps, action.Secret)
 	default:
 		return BadRequest("unknown snapshot operation %q", action.Action)
 	}
@@ -154,6 +251,8 @@ func changeSnapshots(c *Command, r *http.Request, user *auth.UserState) Response
 		// woo
 	case client.ErrSnapshotSetNotFound, client.ErrSnapshotSnapsNotFound:
 		return NotFound("%v", err)
+	case client.ErrSnapshotEncrypted:
+		return BadRequest("%v", err)
 	default:
 		return InternalError("%v", err)
 	}
@@ -218,7 +317,7 @@ func doSnapshotImport(c *Command, r *http.Request, user *auth.UserState) Respons
 }
 
 func snapshotMany(_ context.Context, inst *snapInstruction, st *state.State) (*snapInstructionResult, error) {
-	setID, snapshotted, ts, err := snapshotSave(st, inst.Snaps, inst.Users, inst.SnapshotOptions)
+	setID, snapshotted, ts, err := snapshotSave(st, inst.Snaps, inst.Users, inst.SnapshotOptions, inst.Secret)
 	if err != nil {
 		return nil, err
 	}
@@ -26,8 +26,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/check.v1"
 
@@ -53,7 +55,7 @@ func (s *snapshotSuite) SetUpTest(c *check.C) {
 
 func (s *snapshotSuite) TestSnapshotManyOptionsNone(c *check.C) {
 	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
-		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		options map[string]*snap.SnapshotOptions, secret []byte) (uint64, []string, *state.TaskSet, error) {
 		c.Check(snaps, check.HasLen, 2)
 		c.Check(options, check.IsNil)
 		t := s.NewTask("fake-snapshot-2", "Snapshot two")
@@ -74,7 +76,7 @@ func (s *snapshotSuite) TestSnapshotManyOptionsNone(c *check.C) {
 func (s *snapshotSuite) TestSnapshotManyOptionsFull(c *check.C) {
 	var snapshotSaveCalled int
 	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
-		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		options map[string]*snap.SnapshotOptions, secret []byte) (uint64, []string, *state.TaskSet, error) {
 		snapshotSaveCalled++
 		c.Check(snaps, check.HasLen, 2)
 		c.Check(options, check.HasLen, 2)
@@ -99,9 +101,29 @@ func (s *snapshotSuite) TestSnapshotManyOptionsFull(c *check.C) {
 	c.Check(snapshotSaveCalled, check.Equals, 1)
 }
 
+func (s *snapshotSuite) TestSnapshotManyEncrypted(c *check.C) {
+	var saveSecret []byte
+	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
+		options map[string]*snap.SnapshotOptions, secret []byte) (uint64, []string, *state.TaskSet, error) {
+		saveSecret = secret
+		t := s.NewTask("fake-snapshot-2", "Snapshot two")
+		return 1, snaps, state.NewTaskSet(t), nil
+	})()
+
+	// the secret is base64-encoded
+	inst := daemon.MustUnmarshalSnapInstruction(c, `{"action": "snapshot", "snaps": ["foo"], "secret": "czNjcjN0"}`)
+
+	st := s.d.Overlord().State()
+	st.Lock()
+	_, err := inst.DispatchForMany()(context.Background(), inst, st)
+	st.Unlock()
+	c.Assert(err, check.IsNil)
+	c.Check(saveSecret, check.DeepEquals, []byte("s3cr3t"))
+}
+
 func (s *snapshotSuite) TestSnapshotManyError(c *check.C) {
 	defer daemon.MockSnapshotSave(func(s *state.State, snaps, users []string,
-		options map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error) {
+		options map[string]*snap.SnapshotOptions, secret []byte) (uint64, []string, *state.TaskSet, error) {
 		c.Check(snaps, check.HasLen, 2)
 		return 0, nil, nil, &snap.NotInstalledError{Snap: "foo"}
 	})()
@@ -257,7 +279,7 @@ func (s *snapshotSuite) TestChangeSnapshots404(c *check.C) {
 		done = "check"
 		return nil, nil, expectedError
 	})()
-	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string, []string, []byte) ([]string, *state.TaskSet, error) {
 		done = "restore"
 		return nil, nil, expectedError
 	})()
@@ -288,7 +310,7 @@ func (s *snapshotSuite) TestChangeSnapshots500(c *check.C) {
 		done = "check"
 		return nil, nil, expectedError
 	})()
-	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string, []string, []byte) ([]string, *state.TaskSet, error) {
 		done = "restore"
 		return nil, nil, expectedError
 	})()
@@ -315,7 +337,7 @@ func (s *snapshotSuite) TestChangeSnapshot(c *check.C) {
 		done = "check"
 		return []string{"foo"}, state.NewTaskSet(), nil
 	})()
-	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error) {
+	defer daemon.MockSnapshotRestore(func(*state.State, uint64, []string, []string, []string, []byte) ([]string, *state.TaskSet, error) {
 		done = "restore"
 		return []string{"foo"}, state.NewTaskSet(), nil
 	})()
@@ -355,6 +377,221 @@ func (s *snapshotSuite) TestChangeSnapshot(c *check.C) {
 	}
 }
 
+func (s *snapshotSuite) TestChangeSnapshotRestoreEncrypted(c *check.C) {
+	var restoreSecret []byte
+	defer daemon.MockSnapshotRestore(func(_ *state.State, _ uint64, _ []string, _ []string, _ []string, secret []byte) ([]string, *state.TaskSet, error) {
+		restoreSecret = secret
+		return []string{"foo"}, state.NewTaskSet(), nil
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(`{"set": 42, "action": "restore", "secret": "czNjcjN0"}`))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 202)
+	c.Check(restoreSecret, check.DeepEquals, []byte("s3cr3t"))
+}
+
+func (s *snapshotSuite) TestChangeSnapshotSecretOnlyForRestore(c *check.C) {
+	for _, action := range []string{"check", "forget"} {
+		comm := check.Commentf("%s", action)
+		body := fmt.Sprintf(`{"set": 42, "action": "%s", "secret": "czNjcjN0"}`, action)
+		req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(body))
+		c.Assert(err, check.IsNil, comm)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, comm)
+		c.Check(rspe.Message, check.Equals, fmt.Sprintf(`snapshot %q operation cannot specify a secret`, action), comm)
+	}
+}
+
+func (s *snapshotSuite) TestChangeSnapshotRestorePaths(c *check.C) {
+	var restorePaths []string
+	defer daemon.MockSnapshotRestore(func(_ *state.State, _ uint64, snaps []string, _ []string, paths []string, _ []byte) ([]string, *state.TaskSet, error) {
+		c.Check(snaps, check.DeepEquals, []string{"foo"})
+		restorePaths = paths
+		return []string{"foo"}, state.NewTaskSet(), nil
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(`{"set": 42, "action": "restore", "snaps": ["foo"], "paths": ["common/db", "x1/config.toml"]}`))
+	c.Assert(err, check.IsNil)
+	rsp := s.asyncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 202)
+	c.Check(restorePaths, check.DeepEquals, []string{"common/db", "x1/config.toml"})
+}
+
+func (s *snapshotSuite) TestChangeSnapshotPathsOnlyForRestore(c *check.C) {
+	for _, action := range []string{"check", "forget"} {
+		comm := check.Commentf("%s", action)
+		body := fmt.Sprintf(`{"set": 42, "action": "%s", "paths": ["common"]}`, action)
+		req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(body))
+		c.Assert(err, check.IsNil, comm)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, 400, comm)
+		c.Check(rspe.Message, check.Equals, fmt.Sprintf(`snapshot %q operation cannot specify paths`, action), comm)
+	}
+}
+
+func (s *snapshotSuite) TestListSnapshotFiles(c *check.C) {
+	s.expectReadAccess(daemon.AuthenticatedAccess{})
+
+	mtime := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	files := []client.SnapshotFile{
+		{Path: "common", Mode: os.ModeDir | 0755, ModTime: mtime},
+		{User: "bob", Path: "x1/config.toml", Size: 42, Mode: 0644, ModTime: mtime},
+	}
+	defer daemon.MockSnapshotFiles(func(_ context.Context, setID uint64, snapName string, users []string, secret []byte) ([]client.SnapshotFile, error) {
+		c.Check(setID, check.Equals, uint64(42))
+		c.Check(snapName, check.Equals, "foo")
+		c.Check(users, check.DeepEquals, []string{"bob", "alice"})
+		c.Check(secret, check.IsNil)
+		return files, nil
+	})()
+
+	req, err := http.NewRequest("GET", "/v2/snapshots/42/files?snap=foo&users=bob,alice", nil)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+	c.Check(rsp.Result, check.DeepEquals, files)
+}
+
+func (s *snapshotSuite) TestListSnapshotFilesErrors(c *check.C) {
+	defer daemon.MockSnapshotFiles(func(_ context.Context, setID uint64, snapName string, users []string, secret []byte) ([]client.SnapshotFile, error) {
+		switch snapName {
+		case "not-there":
+			return nil, client.ErrSnapshotSnapsNotFound
+		case "encrypted":
+			if secret == nil {
+				return nil, client.ErrSnapshotEncrypted
+			}
+			return nil, snapshotstate.ErrWrongKey
+		case "broken":
+			return nil, errors.New("boom")
+		}
+		c.Fatalf("unexpected snap %q", snapName)
+		return nil, nil
+	})()
+
+	for _, t := range []struct {
+		url     string
+		status  int
+		message string
+	}{
+		{"/v2/snapshots/xxx/files?snap=foo", 400, `'id' must be a positive base 10 number; got "xxx"`},
+		{"/v2/snapshots/42/files", 400, `listing snapshot files requires a snap`},
+		{"/v2/snapshots/42/files?snap=not-there", 404, client.ErrSnapshotSnapsNotFound.Error()},
+		{"/v2/snapshots/42/files?snap=broken", 500, `boom`},
+		{"/v2/snapshots/42/files?snap=encrypted", 400, client.ErrSnapshotEncrypted.Error()},
+	} {
+		req, err := http.NewRequest("GET", t.url, nil)
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, t.status, check.Commentf(t.url))
+		c.Check(rspe.Message, check.Equals, t.message, check.Commentf(t.url))
+	}
+
+	s.expectWriteAccess(daemon.AuthenticatedAccess{})
+	for _, t := range []struct {
+		body    string
+		status  int
+		message string
+	}{
+		{`{"snap": "encrypted"`, 400, `cannot decode request body into snapshot files request: unexpected EOF`},
+		{`{"snap": "encrypted"}{}`, 400, `extra content found after snapshot files request`},
+		{`{"snap": "encrypted"}`, 400, `listing encrypted snapshot files requires a secret`},
+		{`{"secret": "czNjcjN0"}`, 400, `listing snapshot files requires a snap`},
+		{`{"snap": "encrypted", "secret": "czNjcjN0"}`, 400, snapshotstate.ErrWrongKey.Error()},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snapshots/42/files", strings.NewReader(t.body))
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, t.status, check.Commentf(t.body))
+		c.Check(rspe.Message, check.Equals, t.message, check.Commentf(t.body))
+	}
+}
+
+func (s *snapshotSuite) TestListEncryptedSnapshotFiles(c *check.C) {
+	s.expectWriteAccess(daemon.AuthenticatedAccess{})
+
+	files := []client.SnapshotFile{{Path: "common", Mode: os.ModeDir | 0755}}
+	defer daemon.MockSnapshotFiles(func(_ context.Context, setID uint64, snapName string, users []string, secret []byte) ([]client.SnapshotFile, error) {
+		c.Check(setID, check.Equals, uint64(42))
+		c.Check(snapName, check.Equals, "foo")
+		c.Check(users, check.DeepEquals, []string{"bob"})
+		c.Check(secret, check.DeepEquals, []byte("s3cr3t"))
+		return files, nil
+	})()
+
+	req, err := http.NewRequest("POST", "/v2/snapshots/42/files", strings.NewReader(`{"snap": "foo", "users": ["bob"], "secret": "czNjcjN0"}`))
+	c.Assert(err, check.IsNil)
+
+	rsp := s.syncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 200)
+	c.Check(rsp.Result, check.DeepEquals, files)
+}
+
+func (s *snapshotSuite) TestChangeSnapshotDiff(c *check.C) {
+	defer daemon.MockSnapshotDiff(func(_ *state.State, setID, otherSetID uint64, snapNames []string, secret []byte) ([]string, *state.TaskSet, error) {
+		c.Check(setID, check.Equals, uint64(42))
+		c.Check(otherSetID, check.Equals, uint64(43))
+		c.Check(snapNames, check.DeepEquals, []string{"foo"})
+		c.Check(secret, check.DeepEquals, []byte("s3cr3t"))
+		return []string{"foo"}, state.NewTaskSet(), nil
+	})()
+
+	buf := bytes.NewBufferString(`{"set": 42, "other-set": 43, "action": "diff", "snaps": ["foo"], "secret": "czNjcjN0"}`)
+	req, err := http.NewRequest("POST", "/v2/snapshots", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := s.asyncReq(c, req, nil)
+	c.Check(rsp.Status, check.Equals, 202)
+	st := s.d.Overlord().State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "diff-snapshot")
+	c.Check(chg.Summary(), check.Equals, `Diff of snapshot set #42 for snaps "foo"`)
+}
+
+func (s *snapshotSuite) TestChangeSnapshotDiffErrors(c *check.C) {
+	defer daemon.MockSnapshotDiff(func(_ *state.State, setID, otherSetID uint64, snapNames []string, secret []byte) ([]string, *state.TaskSet, error) {
+		switch setID {
+		case 1:
+			return nil, nil, client.ErrSnapshotSetNotFound
+		case 2:
+			return nil, nil, errors.New("boom")
+		case 3:
+			return nil, nil, client.ErrSnapshotEncrypted
+		}
+		c.Fatalf("unexpected set %d", setID)
+		return nil, nil, nil
+	})()
+
+	for _, t := range []struct {
+		body    string
+		status  int
+		message string
+	}{
+		{`{"set": 42, "action": "diff"}`, 400, `snapshot "diff" operation requires another snapshot set ID`},
+		{`{"set": 42, "other-set": 43, "action": "diff", "users": ["bob"]}`, 400, `snapshot "diff" operation cannot specify users`},
+		{`{"set": 42, "other-set": 43, "action": "check"}`, 400, `snapshot "check" operation cannot specify another set`},
+		{`{"set": 1, "other-set": 43, "action": "diff"}`, 404, client.ErrSnapshotSetNotFound.Error()},
+		{`{"set": 2, "other-set": 43, "action": "diff"}`, 500, `boom`},
+		{`{"set": 3, "other-set": 43, "action": "diff"}`, 400, client.ErrSnapshotEncrypted.Error()},
+	} {
+		req, err := http.NewRequest("POST", "/v2/snapshots", strings.NewReader(t.body))
+		c.Assert(err, check.IsNil)
+
+		rspe := s.errorReq(c, req, nil)
+		c.Check(rspe.Status, check.Equals, t.status, check.Commentf(t.body))
+		c.Check(rspe.Message, check.Equals, t.message, check.Commentf(t.body))
+	}
+}
+
 func (s *snapshotSuite) TestExportSnapshots(c *check.C) {
 	var snapshotExportCalled int
 
@@ -20,11 +20,13 @@
 package daemon
 
 import (
+	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/testutil"
 )
 
 var (
-	GetUserID = getUserID
+	GetUserID      = getUserID
+	GetRulesUserID = getRulesUserID
 
 	PromptingNotRunningError = promptingNotRunningError
 	PromptingError           = promptingError
@@ -37,9 +39,10 @@ type PatchRuleContents patchRuleContents
 
 // When the types have nested contents, must redefine with exported types.
 type PostRulesRequestBody struct {
-	Action         string               `json:"action"`
-	AddRule        *AddRuleContents     `json:"rule,omitempty"`
-	RemoveSelector *RemoveRulesSelector `json:"selector,omitempty"`
+	Action         string                       `json:"action"`
+	AddRule        *AddRuleContents             `json:"rule,omitempty"`
+	RemoveSelector *RemoveRulesSelector         `json:"selector,omitempty"`
+	ImportRules    []*requestrules.ExportedRule `json:"rules,omitempty"`
 }
 
 type PostRuleRequestBody struct {
@@ -32,7 +32,7 @@ import (
 	"github.com/snapcore/snapd/snap"
 )
 
-func MockSnapshotSave(newSave func(*state.State, []string, []string, map[string]*snap.SnapshotOptions) (uint64, []string, *state.TaskSet, error)) (restore func()) {
+func MockSnapshotSave(newSave func(*state.State, []string, []string, map[string]*snap.SnapshotOptions, []byte) (uint64, []string, *state.TaskSet, error)) (restore func()) {
 	oldSave := snapshotSave
 	snapshotSave = newSave
 	return func() {
@@ -64,7 +64,7 @@ func MockSnapshotCheck(newCheck func(*state.State, uint64, []string, []string) (
 	}
 }
 
-func MockSnapshotRestore(newRestore func(*state.State, uint64, []string, []string) ([]string, *state.TaskSet, error)) (restore func()) {
+func MockSnapshotRestore(newRestore func(*state.State, uint64, []string, []string, []string, []byte) ([]string, *state.TaskSet, error)) (restore func()) {
 	oldRestore := snapshotRestore
 	snapshotRestore = newRestore
 	return func() {
@@ -72,6 +72,22 @@ func MockSnapshotRestore(newRestore func(*state.State, uint64, []string, []strin
 	}
 }
 
+func MockSnapshotFiles(newFiles func(context.Context, uint64, string, []string, []byte) ([]client.SnapshotFile, error)) (restore func()) {
+	oldFiles := snapshotFiles
+	snapshotFiles = newFiles
+	return func() {
+		snapshotFiles = oldFiles
+	}
+}
+
+func MockSnapshotDiff(newDiff func(*state.State, uint64, uint64, []string, []byte) ([]string, *state.TaskSet, error)) (restore func()) {
+	oldDiff := snapshotDiff
+	snapshotDiff = newDiff
+	return func() {
+		snapshotDiff = oldDiff
+	}
+}
+
 func MockSnapshotForget(newForget func(*state.State, uint64, []string) ([]string, *state.TaskSet, error)) (restore func()) {
 	oldForget := snapshotForget
 	snapshotForget = newForget
@@ -21,6 +21,7 @@ package daemon
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"time"
 
@@ -38,6 +39,7 @@ import (
 	"github.com/snapcore/snapd/overlord/confdbstate"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/manifest"
 	"github.com/snapcore/snapd/overlord/restart"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -157,6 +159,10 @@ func MockSnapstateUpdateWithGoal(mock func(ctx context.Context, st *state.State,
 	return testutil.Mock(&snapstateUpdateWithGoal, mock)
 }
 
+func MockSnapstateSimulateUpdate(mock func(ctx context.Context, st *state.State, goal snapstate.UpdateGoal, opts snapstate.Options) (*snapstate.RefreshSimulation, error)) (restore func()) {
+	return testutil.Mock(&snapstateSimulateUpdate, mock)
+}
+
 func MockSnapstatePathUpdateGoal(mock func(snaps ...snapstate.PathSnap) snapstate.UpdateGoal) (restore func()) {
 	return testutil.Mock(&snapstatePathUpdateGoal, mock)
 }
@@ -400,6 +406,10 @@ func MockRebootNoticeWait(d time.Duration) (restore func()) {
 	return restore
 }
 
+func MockNoticesKeepAliveInterval(d time.Duration) (restore func()) {
+	return testutil.Mock(&noticesKeepAliveInterval, d)
+}
+
 func MockSystemUserFromRequest(f func(r *http.Request) (*user.User, error)) (restore func()) {
 	restore = testutil.Backup(&systemUserFromRequest)
 	systemUserFromRequest = f
@@ -443,3 +453,15 @@ func ValidateFeatureFlag(st *state.State, feature features.SnapdFeature) *apiErr
 func MockDeviceStateSignConfdbControl(f func(m *devicestate.DeviceManager, groups []interface{}, revision int) (*asserts.ConfdbControl, error)) (restore func()) {
 	return testutil.Mock(&devicestateSignConfdbControl, f)
 }
+
+func MockManifestApply(f func(ctx context.Context, st *state.State, m *manifest.Manifest, userID int, dryRun bool) (*manifest.Plan, []*state.TaskSet, []string, error)) (restore func()) {
+	return testutil.Mock(&manifestApply, f)
+}
+
+func MockBootDebugDumpBootVars(f func(w io.Writer, dir string, uc20 bool) error) (restore func()) {
+	return testutil.Mock(&bootDebugDumpBootVars, f)
+}
+
+func MockDebugBundleJournal(f func(n int) (io.ReadCloser, error)) (restore func()) {
+	return testutil.Mock(&debugBundleJournal, f)
+}
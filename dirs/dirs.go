@@ -82,9 +82,10 @@ var (
 	SnapAssertsSpoolDir   string
 	SnapSeqDir            string
 
-	SnapStateFile     string
-	SnapStateLockFile string
-	SnapSystemKeyFile string
+	SnapStateFile        string
+	SnapStateLockFile    string
+	SnapChangeHistoryDir string
+	SnapSystemKeyFile    string
 
 	SnapRepairConfigFile string
 	SnapRepairDir        string
@@ -592,6 +593,7 @@ func SetRootDir(rootdir string) {
 
 	SnapStateFile = SnapStateFileUnder(rootdir)
 	SnapStateLockFile = SnapStateLockFileUnder(rootdir)
+	SnapChangeHistoryDir = filepath.Join(rootdir, snappyDir, "change-history")
 	SnapSystemKeyFile = filepath.Join(rootdir, snappyDir, "system-key")
 
 	SnapCacheDir = filepath.Join(rootdir, "/var/cache/snapd")
@@ -75,6 +75,10 @@ const (
 	ConfdbControl
 	// AppArmorPrompting enables AppArmor to prompt the user for permission when apps perform certain operations.
 	AppArmorPrompting
+	// StateJournal enables journaling changes to the state instead of rewriting it whole on every change.
+	StateJournal
+	// Metrics enables exporting metrics about the internals of snapd.
+	Metrics
 
 	// lastFeature is the final known feature, it is only used for testing.
 	lastFeature
@@ -124,6 +128,10 @@ var featureNames = map[SnapdFeature]string{
 	ConfdbControl: "confdb-control",
 
 	AppArmorPrompting: "apparmor-prompting",
+
+	StateJournal: "state-journal",
+
+	Metrics: "metrics",
 }
 
 // featuresEnabledWhenUnset contains a set of features that are enabled when not explicitly configured.
@@ -147,6 +155,8 @@ var featuresExported = map[SnapdFeature]bool{
 	RefreshAppAwarenessUX: true,
 	Confdb:                true,
 	AppArmorPrompting:     true,
+	StateJournal:          true,
+	Metrics:               true,
 }
 
 var (
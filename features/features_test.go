@@ -64,6 +64,8 @@ func (*featureSuite) TestName(c *C) {
 	check(features.Confdb, "confdb")
 	check(features.ConfdbControl, "confdb-control")
 	check(features.AppArmorPrompting, "apparmor-prompting")
+	check(features.StateJournal, "state-journal")
+	check(features.Metrics, "metrics")
 
 	c.Check(tested, Equals, features.NumberOfFeatures())
 	c.Check(func() { _ = features.SnapdFeature(1000).String() }, PanicMatches, "unknown feature flag code 1000")
@@ -104,6 +106,8 @@ func (*featureSuite) TestIsExported(c *C) {
 	check(features.Confdb, true)
 	check(features.ConfdbControl, false)
 	check(features.AppArmorPrompting, true)
+	check(features.StateJournal, true)
+	check(features.Metrics, true)
 
 	c.Check(tested, Equals, features.NumberOfFeatures())
 }
@@ -229,6 +233,8 @@ func (*featureSuite) TestIsEnabledWhenUnset(c *C) {
 	check(features.Confdb, false)
 	check(features.AppArmorPrompting, false)
 	check(features.ConfdbControl, false)
+	check(features.StateJournal, false)
+	check(features.Metrics, false)
 
 	c.Check(tested, Equals, features.NumberOfFeatures())
 }
@@ -242,6 +248,7 @@ func (*featureSuite) TestControlFile(c *C) {
 	c.Check(features.RefreshAppAwarenessUX.ControlFile(), Equals, "/var/lib/snapd/features/refresh-app-awareness-ux")
 	c.Check(features.Confdb.ControlFile(), Equals, "/var/lib/snapd/features/confdb")
 	c.Check(features.AppArmorPrompting.ControlFile(), Equals, "/var/lib/snapd/features/apparmor-prompting")
+	c.Check(features.StateJournal.ControlFile(), Equals, "/var/lib/snapd/features/state-journal")
 	// Features that are not exported don't have a control file.
 	c.Check(features.Layouts.ControlFile, PanicMatches, `cannot compute the control file of feature "layouts" because that feature is not exported`)
 }
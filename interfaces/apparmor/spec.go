@@ -301,6 +301,21 @@ func (spec *Specification) AddSnippet(snippet string) {
 	}
 }
 
+// AddPromptableSnippet adds a new apparmor snippet whose rules prefixed with
+// ###PROMPT### may be mediated by prompting, on behalf of an interface other
+// than home. Requests are attributed to the interface by way of the given
+// metadata tag, which the snippet is wrapped in. If prompting is not enabled or
+// metadata tags are not supported, the prefix is dropped, so that the rules are
+// enforced as usual rather than being prompted for on behalf of the wrong
+// interface.
+func (spec *Specification) AddPromptableSnippet(snippet string, tag MetadataTag) {
+	if !spec.usePromptPrefix || !metadataTagsSupported() {
+		spec.AddSnippet(promptReplacer.ReplaceAllLiteralString(snippet, ""))
+		return
+	}
+	spec.AddSnippet(MetadataTagSnippet(snippet, []MetadataTag{tag}))
+}
+
 // AddPrioritizedSnippet adds a new apparmor snippet to all applications and hooks using the interface,
 // but identified with a key and a priority. If no other snippet exists with that key, the snippet is
 // added like with AddSnippet, but if there is already another snippet with that key, the priority of
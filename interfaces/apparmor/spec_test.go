@@ -118,6 +118,51 @@ func (s *specSuite) TestSpecificationIface(c *C) {
 	})
 }
 
+func (s *specSuite) TestAddPromptableSnippet(c *C) {
+	tag := apparmor.RegisterMetadataTagWithInterface("promptable", "test")
+	iface := &ifacetest.TestInterface{
+		InterfaceName: "test",
+		AppArmorConnectedPlugCallback: func(spec *apparmor.Specification, plug *interfaces.ConnectedPlug, slot *interfaces.ConnectedSlot) error {
+			spec.AddPromptableSnippet("/dir/ r,\n###PROMPT### /dir/** rw,", tag)
+			return nil
+		},
+	}
+
+	for _, testCase := range []struct {
+		prompting     bool
+		tagsSupported bool
+		expected      string
+	}{
+		{
+			prompting:     true,
+			tagsSupported: true,
+			expected:      "\ntags=(promptable) {\n/dir/ r,\n###PROMPT### /dir/** rw,\n}\n",
+		},
+		{
+			// without tags, the requests could not be told apart from
+			// those for the home interface
+			prompting:     true,
+			tagsSupported: false,
+			expected:      "/dir/ r,\n/dir/** rw,",
+		},
+		{
+			prompting:     false,
+			tagsSupported: true,
+			expected:      "/dir/ r,\n/dir/** rw,",
+		},
+	} {
+		restore := apparmor.MockMetadataTagsSupported(func() bool { return testCase.tagsSupported })
+		defer restore()
+
+		backend := &apparmor.Backend{}
+		spec := backend.NewSpecification(s.plug.AppSet(), interfaces.ConfinementOptions{AppArmorPrompting: testCase.prompting}).(*apparmor.Specification)
+		c.Assert(spec.AddConnectedPlug(iface, s.plug, s.slot), IsNil)
+		c.Check(spec.Snippets(), DeepEquals, map[string][]string{
+			"snap.snap1.app1": {testCase.expected},
+		}, Commentf("prompting: %v, tags supported: %v", testCase.prompting, testCase.tagsSupported))
+	}
+}
+
 // MetadataTagSnippet wraps a snippet in the given metadata tags.
 func (s *specSuite) TestMetadataTagSnippet(c *C) {
 	tagFoo := apparmor.RegisterMetadataTagWithInterface("foo", "an-interface")
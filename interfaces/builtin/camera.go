@@ -19,6 +19,10 @@
 
 package builtin
 
+import (
+	"github.com/snapcore/snapd/interfaces/apparmor"
+)
+
 const cameraSummary = `allows access to all cameras`
 
 const cameraBaseDeclarationSlots = `
@@ -31,7 +35,7 @@ const cameraBaseDeclarationSlots = `
 
 const cameraConnectedPlugAppArmor = `
 # Until we have proper device assignment, allow access to all cameras
-/dev/video[0-9]* rw,
+###PROMPT### /dev/video[0-9]* rw,
 
 # VideoCore cameras (shared device with VideoCore/EGL)
 /dev/vchiq rw,
@@ -65,6 +69,7 @@ func init() {
 		implicitOnClassic:     true,
 		baseDeclarationSlots:  cameraBaseDeclarationSlots,
 		connectedPlugAppArmor: cameraConnectedPlugAppArmor,
+		appArmorPromptTag:     apparmor.RegisterMetadataTagWithInterface("snapd-camera", "camera"),
 		connectedPlugUDev:     cameraConnectedPlugUDev,
 	})
 }
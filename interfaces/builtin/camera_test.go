@@ -29,6 +29,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/udev"
+	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -85,6 +86,19 @@ func (s *CameraInterfaceSuite) TestAppArmorSpec(c *C) {
 	c.Assert(spec.SnippetForTag("snap.consumer.app"), testutil.Contains, "/dev/video[0-9]* rw")
 }
 
+func (s *CameraInterfaceSuite) TestAppArmorSpecPrompting(c *C) {
+	restore := apparmor_sandbox.MockFeatures([]string{"policy:notify:user:tags"}, nil, []string{"tags"}, nil)
+	defer restore()
+
+	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
+	c.Assert(err, IsNil)
+	backend := &apparmor.Backend{}
+	spec := backend.NewSpecification(appSet, interfaces.ConfinementOptions{AppArmorPrompting: true}).(*apparmor.Specification)
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, s.slot), IsNil)
+	c.Assert(spec.SnippetForTag("snap.consumer.app"), testutil.Contains, "\ntags=(snapd-camera) {\n")
+	c.Assert(spec.SnippetForTag("snap.consumer.app"), testutil.Contains, "###PROMPT### /dev/video[0-9]* rw,")
+}
+
 func (s *CameraInterfaceSuite) TestUDevSpec(c *C) {
 	appSet, err := interfaces.NewSnapAppSet(s.plug.Snap(), nil)
 	c.Assert(err, IsNil)
@@ -68,7 +68,11 @@ type commonInterface struct {
 	// patterns".
 	baseDeclarationSlots string
 
-	connectedPlugAppArmor  string
+	connectedPlugAppArmor string
+	// appArmorPromptTag is the metadata tag attributing requests matched
+	// by the ###PROMPT### rules of connectedPlugAppArmor to the interface,
+	// if the interface supports prompting.
+	appArmorPromptTag      apparmor.MetadataTag
 	connectedPlugSecComp   string
 	connectedPlugUDev      []string
 	rejectAutoConnectPairs bool
@@ -138,7 +142,11 @@ func (iface *commonInterface) AppArmorConnectedPlug(spec *apparmor.Specification
 		spec.SetSuppressSysModuleCapability()
 	}
 	if snippet := iface.connectedPlugAppArmor; snippet != "" {
-		spec.AddSnippet(snippet)
+		if iface.appArmorPromptTag != (apparmor.MetadataTag{}) {
+			spec.AddPromptableSnippet(snippet, iface.appArmorPromptTag)
+		} else {
+			spec.AddSnippet(snippet)
+		}
 	}
 	if snippet := iface.connectedPlugUpdateNSAppArmor; snippet != "" {
 		spec.AddUpdateNS(snippet)
@@ -76,13 +76,13 @@ func formatPath(ip interface{}) (string, error) {
 	return fmt.Sprintf("%s%q", prefix, p), nil
 }
 
-func allowPathAccess(buf *bytes.Buffer, perm filesAAPerm, paths []interface{}) error {
+func allowPathAccess(buf *bytes.Buffer, rulePrefix string, perm filesAAPerm, paths []interface{}) error {
 	for _, rawPath := range paths {
 		p, err := formatPath(rawPath)
 		if err != nil {
 			return err
 		}
-		fmt.Fprintf(buf, "%s %s,\n", p, perm)
+		fmt.Fprintf(buf, "%s%s %s,\n", rulePrefix, p, perm)
 	}
 	return nil
 }
@@ -161,14 +161,23 @@ func (iface *commonFilesInterface) AppArmorConnectedPlug(spec *apparmor.Specific
 	_ = plug.Attr("write", &writes)
 
 	errPrefix := fmt.Sprintf(`cannot connect plug %s: `, plug.Name())
+	promptable := iface.appArmorPromptTag != (apparmor.MetadataTag{})
+	rulePrefix := ""
+	if promptable {
+		rulePrefix = "###PROMPT### "
+	}
 	buf := bytes.NewBufferString(iface.apparmorHeader)
-	if err := allowPathAccess(buf, filesRead, reads); err != nil {
+	if err := allowPathAccess(buf, rulePrefix, filesRead, reads); err != nil {
 		return fmt.Errorf("%s%v", errPrefix, err)
 	}
-	if err := allowPathAccess(buf, filesWrite, writes); err != nil {
+	if err := allowPathAccess(buf, rulePrefix, filesWrite, writes); err != nil {
 		return fmt.Errorf("%s%v", errPrefix, err)
 	}
-	spec.AddSnippet(buf.String())
+	if promptable {
+		spec.AddPromptableSnippet(buf.String(), iface.appArmorPromptTag)
+	} else {
+		spec.AddSnippet(buf.String())
+	}
 
 	return nil
 }
@@ -75,6 +75,7 @@ func init() {
 				implicitOnClassic:    true,
 				baseDeclarationPlugs: personalFilesBaseDeclarationPlugs,
 				baseDeclarationSlots: personalFilesBaseDeclarationSlots,
+				appArmorPromptTag:    apparmor.RegisterMetadataTagWithInterface("snapd-personal-files", "personal-files"),
 			},
 			apparmorHeader:    personalFilesConnectedPlugAppArmor,
 			extraPathValidate: validateSinglePathHome,
@@ -29,6 +29,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/mount"
 	"github.com/snapcore/snapd/osutil"
+	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
@@ -100,6 +101,32 @@ owner "@{HOME}/.local/share/dir1/dir2/target{,/,/**}" rwkl,
   owner @{HOME}/.local/share/dir1/dir2/ rw,`)
 }
 
+func (s *personalFilesInterfaceSuite) TestConnectedPlugAppArmorPrompting(c *C) {
+	restore := apparmor_sandbox.MockFeatures([]string{"policy:notify:user:tags"}, nil, []string{"tags"}, nil)
+	defer restore()
+
+	backend := &apparmor.Backend{}
+	apparmorSpec := backend.NewSpecification(s.plug.AppSet(), interfaces.ConfinementOptions{AppArmorPrompting: true}).(*apparmor.Specification)
+	err := apparmorSpec.AddConnectedPlug(s.iface, s.plug, s.slot)
+	c.Assert(err, IsNil)
+	c.Check(apparmorSpec.SnippetForTag("snap.other.app"), Equals, `
+tags=(snapd-personal-files) {
+
+# Description: Can access specific personal files or directories in the 
+# users's home directory.
+# This is restricted because it gives file access to arbitrary locations.
+###PROMPT### owner "@{HOME}/.read-dir{,/,/**}" rk,
+###PROMPT### owner "@{HOME}/.read-file{,/,/**}" rk,
+###PROMPT### owner "@{HOME}/.local/share/target{,/,/**}" rk,
+###PROMPT### owner "@{HOME}/.write-dir{,/,/**}" rwkl,
+###PROMPT### owner "@{HOME}/.write-file{,/,/**}" rwkl,
+###PROMPT### owner "@{HOME}/.local/share/target{,/,/**}" rwkl,
+###PROMPT### owner "@{HOME}/.local/share/dir1/dir2/target{,/,/**}" rwkl,
+
+}
+`)
+}
+
 func (s *personalFilesInterfaceSuite) TestConnectedPlugApparmorErrorNotString(c *C) {
 	const mockPlugSnapInfo = `name: other
 version: 1.0
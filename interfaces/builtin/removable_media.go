@@ -19,6 +19,10 @@
 
 package builtin
 
+import (
+	"github.com/snapcore/snapd/interfaces/apparmor"
+)
+
 const removableMediaSummary = `allows access to mounted removable storage`
 
 const removableMediaBaseDeclarationSlots = `
@@ -41,12 +45,12 @@ const removableMediaConnectedPlugAppArmor = `
 
 # Mount points could be in /run/media/<user>/* or /media/<user>/*
 /{,run/}media/*/ r,
-/{,run/}media/*/** mrwklix,
+###PROMPT### /{,run/}media/*/** mrwklix,
 
 # Allow read-only access to /mnt to enumerate items.
 /mnt/ r,
 # Allow write access to anything under /mnt
-/mnt/** mrwklix,
+###PROMPT### /mnt/** mrwklix,
 `
 
 func init() {
@@ -57,5 +61,6 @@ func init() {
 		implicitOnClassic:     true,
 		baseDeclarationSlots:  removableMediaBaseDeclarationSlots,
 		connectedPlugAppArmor: removableMediaConnectedPlugAppArmor,
+		appArmorPromptTag:     apparmor.RegisterMetadataTagWithInterface("snapd-removable-media", "removable-media"),
 	})
 }
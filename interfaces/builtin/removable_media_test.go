@@ -25,6 +25,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	apparmor_sandbox "github.com/snapcore/snapd/sandbox/apparmor"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -82,6 +83,21 @@ func (s *RemovableMediaInterfaceSuite) TestUsedSecuritySystems(c *C) {
 	c.Check(apparmorSpec.SnippetForTag("snap.client-snap.other"), testutil.Contains, "/mnt/** mrwklix,")
 }
 
+func (s *RemovableMediaInterfaceSuite) TestAppArmorPrompting(c *C) {
+	restore := apparmor_sandbox.MockFeatures([]string{"policy:notify:user:tags"}, nil, []string{"tags"}, nil)
+	defer restore()
+
+	backend := &apparmor.Backend{}
+	apparmorSpec := backend.NewSpecification(s.plug.AppSet(), interfaces.ConfinementOptions{AppArmorPrompting: true}).(*apparmor.Specification)
+	err := apparmorSpec.AddConnectedPlug(s.iface, s.plug, s.slot)
+	c.Assert(err, IsNil)
+	snippet := apparmorSpec.SnippetForTag("snap.client-snap.other")
+	c.Check(snippet, testutil.Contains, "\ntags=(snapd-removable-media) {\n")
+	c.Check(snippet, testutil.Contains, "/{,run/}media/*/ r")
+	c.Check(snippet, testutil.Contains, "###PROMPT### /{,run/}media/*/** mrwklix,")
+	c.Check(snippet, testutil.Contains, "###PROMPT### /mnt/** mrwklix,")
+}
+
 func (s *RemovableMediaInterfaceSuite) TestInterfaces(c *C) {
 	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
 }
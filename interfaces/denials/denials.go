@@ -0,0 +1,239 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package denials provides support for recording AppArmor and seccomp
+// denials of snap applications from the kernel log and for finding the
+// interfaces which would have allowed them.
+package denials
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the kind of a sandbox denial.
+type Kind string
+
+const (
+	// KindAppArmor is a denial reported by the AppArmor LSM.
+	KindAppArmor Kind = "apparmor"
+	// KindSeccomp is a denial reported by the seccomp filter.
+	KindSeccomp Kind = "seccomp"
+)
+
+// Event holds the details of a single denial as found in the kernel log.
+type Event struct {
+	Time time.Time `json:"time"`
+	Kind Kind      `json:"kind"`
+	// Label is the security tag of the denied process.
+	Label string `json:"label"`
+	PID   int    `json:"pid,omitempty"`
+	Comm  string `json:"comm,omitempty"`
+
+	// Operation, Class, Name and the masks are set for AppArmor denials.
+	Operation string `json:"operation,omitempty"`
+	Class     string `json:"class,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Requested string `json:"requested-mask,omitempty"`
+	Denied    string `json:"denied-mask,omitempty"`
+	// Capability is set for denied capabilities.
+	Capability string `json:"capability,omitempty"`
+	// Family and SockType are set for denied network access.
+	Family   string `json:"family,omitempty"`
+	SockType string `json:"sock-type,omitempty"`
+	// Interface and Member are set for denied D-Bus messages.
+	Interface string `json:"interface,omitempty"`
+	Member    string `json:"member,omitempty"`
+
+	// Arch is the dpkg architecture of a denied system call.
+	Arch string `json:"arch,omitempty"`
+	// Syscall is the number of a denied system call.
+	Syscall int `json:"syscall,omitempty"`
+	// SyscallName is the name of a denied system call, when known.
+	SyscallName string `json:"syscall-name,omitempty"`
+}
+
+// String returns a short description of what was denied.
+func (ev *Event) String() string {
+	switch {
+	case ev.Kind == KindSeccomp && ev.SyscallName != "":
+		return ev.SyscallName
+	case ev.Kind == KindSeccomp:
+		return fmt.Sprintf("syscall %d (%s)", ev.Syscall, ev.Arch)
+	case ev.Capability != "":
+		return fmt.Sprintf("capability %s", ev.Capability)
+	case ev.Family != "":
+		return strings.TrimSpace(fmt.Sprintf("network %s %s", ev.Family, ev.SockType))
+	case ev.Interface != "":
+		return fmt.Sprintf("dbus %s.%s", ev.Interface, ev.Member)
+	case ev.Name != "":
+		return fmt.Sprintf("%s %s (%s)", ev.Operation, ev.Name, ev.Requested)
+	}
+	return ev.Operation
+}
+
+// auditArchToDpkgArch maps the AUDIT_ARCH_* values reported for seccomp
+// denials to dpkg architectures.
+var auditArchToDpkgArch = map[string]string{
+	"c000003e": "amd64",
+	"40000003": "i386",
+	"c00000b7": "arm64",
+	"40000028": "armhf",
+	"00000014": "powerpc",
+	"80000015": "ppc64",
+	"c0000015": "ppc64el",
+	"80000016": "s390x",
+	"c00000f3": "riscv64",
+}
+
+// hexEncodedFields are fields which the audit subsystem hex encodes when
+// their value contains spaces or other special characters.
+var hexEncodedFields = map[string]bool{
+	"name":    true,
+	"comm":    true,
+	"profile": true,
+	"exe":     true,
+}
+
+// parseFields parses the key=value pairs of an audit record.
+func parseFields(s string) map[string]string {
+	fields := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+		if sp := strings.LastIndexByte(key, ' '); sp >= 0 {
+			// a token without a value, like "(enforce)"
+			key = key[sp+1:]
+		}
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+			if hexEncodedFields[key] && len(value)%2 == 0 {
+				if decoded, err := hex.DecodeString(value); err == nil {
+					value = string(decoded)
+				}
+			}
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// parseAuditTime parses the "audit(<seconds>.<millis>:<serial>)" stamp of an
+// audit record.
+func parseAuditTime(stamp string) time.Time {
+	if colon := strings.IndexByte(stamp, ':'); colon >= 0 {
+		stamp = stamp[:colon]
+	}
+	secs, millis, _ := strings.Cut(stamp, ".")
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	msec, _ := strconv.ParseInt(millis, 10, 64)
+	return time.Unix(sec, msec*int64(time.Millisecond))
+}
+
+// ParseLine parses a line of either /dev/kmsg or the audit log and returns
+// the AppArmor or seccomp denial it describes. It returns nil for lines
+// which do not describe a denial.
+func ParseLine(line string) *Event {
+	// /dev/kmsg records are prefixed with "<prio>,<seq>,<ts>,<flags>;"
+	if semi := strings.IndexByte(line, ';'); semi >= 0 && !strings.Contains(line[:semi], " ") {
+		line = line[semi+1:]
+	}
+	start := strings.Index(line, "audit(")
+	if start < 0 {
+		return nil
+	}
+	end := strings.Index(line[start:], "):")
+	if end < 0 {
+		return nil
+	}
+	header := line[:start]
+	stamp := line[start+len("audit(") : start+end]
+	fields := parseFields(line[start+end+len("):"):])
+
+	ev := &Event{
+		Time: parseAuditTime(stamp),
+		Comm: fields["comm"],
+	}
+	ev.PID, _ = strconv.Atoi(fields["pid"])
+	switch {
+	case fields["apparmor"] == "DENIED":
+		ev.Kind = KindAppArmor
+		ev.Label = fields["profile"]
+		ev.Operation = fields["operation"]
+		ev.Class = fields["class"]
+		ev.Name = fields["name"]
+		ev.Requested = fields["requested_mask"]
+		ev.Denied = fields["denied_mask"]
+		ev.Capability = fields["capname"]
+		ev.Family = fields["family"]
+		ev.SockType = fields["sock_type"]
+		ev.Interface = fields["interface"]
+		ev.Member = fields["member"]
+	case strings.Contains(header, "type=1326") || strings.Contains(header, "type=SECCOMP"):
+		ev.Kind = KindSeccomp
+		ev.Label = strings.TrimPrefix(fields["subj"], "=")
+		ev.Arch = auditArchToDpkgArch[fields["arch"]]
+		if ev.Arch == "" {
+			ev.Arch = fields["arch"]
+		}
+		nr, err := strconv.Atoi(fields["syscall"])
+		if err != nil {
+			return nil
+		}
+		ev.Syscall = nr
+	default:
+		return nil
+	}
+	if ev.Label == "" {
+		return nil
+	}
+	return ev
+}
+
+// BelongsTo returns whether the denial was triggered by an application or
+// hook of the given snap instance.
+func (ev *Event) BelongsTo(instanceName string) bool {
+	label, _, _ := strings.Cut(ev.Label, "//")
+	return strings.HasPrefix(label, "snap."+instanceName+".") ||
+		strings.HasPrefix(label, "snap."+instanceName+"+") ||
+		label == "snap-update-ns."+instanceName
+}
@@ -0,0 +1,232 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denials_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces/denials"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type denialsSuite struct {
+	testutil.BaseTest
+}
+
+var _ = Suite(&denialsSuite{})
+
+func (s *denialsSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	dirs.SetRootDir(c.MkDir())
+	s.AddCleanup(func() { dirs.SetRootDir("") })
+	s.AddCleanup(denials.MockDelays(time.Millisecond, 10*time.Millisecond))
+}
+
+const (
+	kmsgAppArmorLine = `5,1234,5678901,-;audit: type=1400 audit(1700000000.123:42): apparmor="DENIED" operation="open" class="file" profile="snap.foo.app" name="/etc/shadow" pid=1234 comm="foo" requested_mask="r" denied_mask="r" fsuid=1000 ouid=0`
+	auditSeccompLine = `type=SECCOMP msg=audit(1700000001.500:43): auid=1000 uid=1000 gid=1000 ses=2 subj=snap.foo.hook.configure pid=1235 comm="mount" exe="/usr/bin/mount" sig=0 arch=c000003e syscall=165 compat=0 ip=0x7f0000000000 code=0x50000`
+)
+
+func (s *denialsSuite) TestParseLineAppArmor(c *C) {
+	ev := denials.ParseLine(kmsgAppArmorLine)
+	c.Assert(ev, NotNil)
+	c.Check(ev, DeepEquals, &denials.Event{
+		Time:      time.Unix(1700000000, 123*int64(time.Millisecond)),
+		Kind:      denials.KindAppArmor,
+		Label:     "snap.foo.app",
+		PID:       1234,
+		Comm:      "foo",
+		Operation: "open",
+		Class:     "file",
+		Name:      "/etc/shadow",
+		Requested: "r",
+		Denied:    "r",
+	})
+	c.Check(ev.String(), Equals, "open /etc/shadow (r)")
+	c.Check(ev.BelongsTo("foo"), Equals, true)
+	c.Check(ev.BelongsTo("fo"), Equals, false)
+}
+
+func (s *denialsSuite) TestParseLineAppArmorHexAndCapability(c *C) {
+	ev := denials.ParseLine(`audit: type=1400 audit(1700000000.000:1): apparmor="DENIED" operation="open" profile="snap.foo.app" name=2F746D702F6120622E747874 pid=1 comm="foo" requested_mask="wc" denied_mask="wc"`)
+	c.Assert(ev, NotNil)
+	c.Check(ev.Name, Equals, "/tmp/a b.txt")
+
+	ev = denials.ParseLine(`audit: type=1400 audit(1700000000.000:2): apparmor="DENIED" operation="capable" class="cap" profile="snap.foo.app" pid=1 comm="foo" capability=21  capname="sys_admin"`)
+	c.Assert(ev, NotNil)
+	c.Check(ev.String(), Equals, "capability sys_admin")
+
+	ev = denials.ParseLine(`audit: type=1400 audit(1700000000.000:3): apparmor="DENIED" operation="create" class="net" profile="snap.foo.app" pid=1 comm="foo" family="netlink" sock_type="raw" protocol=0 requested_mask="create" denied_mask="create"`)
+	c.Assert(ev, NotNil)
+	c.Check(ev.String(), Equals, "network netlink raw")
+}
+
+func (s *denialsSuite) TestParseLineSeccomp(c *C) {
+	ev := denials.ParseLine(auditSeccompLine)
+	c.Assert(ev, NotNil)
+	c.Check(ev.Kind, Equals, denials.KindSeccomp)
+	c.Check(ev.Label, Equals, "snap.foo.hook.configure")
+	c.Check(ev.Arch, Equals, "amd64")
+	c.Check(ev.Syscall, Equals, 165)
+	c.Check(ev.PID, Equals, 1235)
+	c.Check(ev.String(), Equals, "syscall 165 (amd64)")
+	c.Check(ev.BelongsTo("foo"), Equals, true)
+}
+
+func (s *denialsSuite) TestParseLineIgnored(c *C) {
+	for _, line := range []string{
+		"",
+		"6,1,2,-;usb 1-1: new high-speed USB device",
+		`audit: type=1400 audit(1700000000.000:1): apparmor="ALLOWED" operation="open" profile="snap.foo.app" name="/etc/shadow"`,
+		`audit: type=1400 audit(1700000000.000:1): apparmor="STATUS" operation="profile_load" name="snap.foo.app"`,
+		`audit: type=1326 audit(1700000000.000:1): subj=snap.foo.app pid=1 syscall=bad`,
+	} {
+		c.Check(denials.ParseLine(line), IsNil, Commentf("%q", line))
+	}
+}
+
+func (s *denialsSuite) TestRecorderAuditLog(c *C) {
+	auditLog := filepath.Join(c.MkDir(), "audit.log")
+	c.Assert(os.WriteFile(auditLog, []byte(kmsgAppArmorLine+"\n"), 0644), IsNil)
+	restore := denials.MockLogPaths(filepath.Join(c.MkDir(), "missing"), auditLog)
+	defer restore()
+
+	r, err := denials.NewRecorder("foo")
+	c.Assert(err, IsNil)
+
+	f, err := os.OpenFile(auditLog, os.O_APPEND|os.O_WRONLY, 0)
+	c.Assert(err, IsNil)
+	defer f.Close()
+	_, err = f.WriteString(auditSeccompLine + "\n" +
+		`type=AVC msg=audit(1700000002.000:44): apparmor="DENIED" operation="open" profile="snap.bar.app" name="/etc/shadow" requested_mask="r"` + "\n")
+	c.Assert(err, IsNil)
+
+	events, err := r.Stop()
+	c.Assert(err, IsNil)
+	// only denials of foo which happened while recording are returned
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0].Kind, Equals, denials.KindSeccomp)
+}
+
+func (s *denialsSuite) TestRecorderError(c *C) {
+	restore := denials.MockLogPaths(filepath.Join(c.MkDir(), "missing"), filepath.Join(c.MkDir(), "missing"))
+	defer restore()
+
+	_, err := denials.NewRecorder("foo")
+	c.Check(err, ErrorMatches, "cannot read denials: open .*/missing: no such file or directory")
+}
+
+type fakeRead struct {
+	data string
+	err  error
+}
+
+// fakeLog returns the given reads in order, and then EOF until closed.
+type fakeLog struct {
+	mu     sync.Mutex
+	reads  []fakeRead
+	closed bool
+}
+
+func (l *fakeLog) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return 0, os.ErrClosed
+	}
+	if len(l.reads) == 0 {
+		return 0, io.EOF
+	}
+	rd := l.reads[0]
+	l.reads = l.reads[1:]
+	return copy(p, rd.data), rd.err
+}
+
+func (l *fakeLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return nil
+}
+
+func (s *denialsSuite) TestRecorderLostMessages(c *C) {
+	log := &fakeLog{reads: []fakeRead{
+		{data: kmsgAppArmorLine + "\n"},
+		{err: syscall.EPIPE},
+		{data: kmsgAppArmorLine + "\n"},
+	}}
+	r := denials.StartRecorder("foo", log)
+
+	// recording continues past the lost messages
+	events, err := r.Stop()
+	c.Check(err, ErrorMatches, "kernel messages were lost while recording, some denials may be missing")
+	c.Check(events, HasLen, 2)
+}
+
+func (s *denialsSuite) TestRecorderReadError(c *C) {
+	log := &fakeLog{reads: []fakeRead{
+		{data: kmsgAppArmorLine + "\n"},
+		{err: errors.New("boom")},
+		{data: kmsgAppArmorLine + "\n"},
+	}}
+	r := denials.StartRecorder("foo", log)
+
+	events, err := r.Stop()
+	c.Check(err, ErrorMatches, "cannot read denials, recording stopped early: boom")
+	c.Check(events, HasLen, 1)
+}
+
+func (s *denialsSuite) TestResolveSyscallNames(c *C) {
+	cmd := testutil.MockCommand(c, "snap-seccomp", `echo mount; echo "?"`)
+	defer cmd.Restore()
+
+	events := []*denials.Event{
+		{Kind: denials.KindSeccomp, Arch: "amd64", Syscall: 165},
+		{Kind: denials.KindAppArmor, Name: "/etc/shadow"},
+		{Kind: denials.KindSeccomp, Arch: "amd64", Syscall: 9999},
+	}
+	c.Assert(denials.ResolveSyscallNames(events, cmd.Exe()), IsNil)
+	c.Check(events[0].SyscallName, Equals, "mount")
+	c.Check(events[0].String(), Equals, "mount")
+	c.Check(events[2].SyscallName, Equals, "")
+	c.Check(cmd.Calls(), DeepEquals, [][]string{
+		{"snap-seccomp", "syscall-names", "amd64", "165", "9999"},
+	})
+}
+
+func (s *denialsSuite) TestResolveSyscallNamesError(c *C) {
+	cmd := testutil.MockCommand(c, "snap-seccomp", `echo "boom" >&2; exit 1`)
+	defer cmd.Restore()
+
+	events := []*denials.Event{{Kind: denials.KindSeccomp, Arch: "amd64", Syscall: 165}}
+	c.Check(denials.ResolveSyscallNames(events, cmd.Exe()), ErrorMatches, "cannot resolve syscall names: boom")
+}
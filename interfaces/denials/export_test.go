@@ -0,0 +1,51 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denials
+
+import (
+	"io"
+	"time"
+
+	"github.com/snapcore/snapd/testutil"
+)
+
+var GlobToRegexp = globToRegexp
+
+func StartRecorder(instanceName string, f io.ReadCloser) *Recorder {
+	return startRecorder(instanceName, f)
+}
+
+func MockLogPaths(kmsg, auditLog string) (restore func()) {
+	r1 := testutil.Mock(&kmsgPath, kmsg)
+	r2 := testutil.Mock(&auditLogPath, auditLog)
+	return func() {
+		r2()
+		r1()
+	}
+}
+
+func MockDelays(poll, settle time.Duration) (restore func()) {
+	r1 := testutil.Mock(&pollInterval, poll)
+	r2 := testutil.Mock(&settleDelay, settle)
+	return func() {
+		r2()
+		r1()
+	}
+}
@@ -0,0 +1,332 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denials
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/seccomp"
+	"github.com/snapcore/snapd/snap"
+)
+
+const plugSnapYaml = `name: consumer
+version: 0
+apps:
+  app:
+    plugs: [plug]
+plugs:
+  plug:
+    interface: %s
+`
+
+const slotSnapYaml = `name: core
+version: 0
+type: os
+slots:
+  slot:
+    interface: %s
+`
+
+type fileRule struct {
+	path  *regexp.Regexp
+	perms string
+}
+
+type networkRule struct {
+	family   string
+	sockType string
+}
+
+// policy is the sandbox policy granted to the plug side of a connection of
+// a single interface.
+type policy struct {
+	iface        string
+	files        []fileRule
+	capabilities map[string]bool
+	network      []networkRule
+	dbus         map[string]bool
+	syscalls     map[string]bool
+}
+
+func (p *policy) size() int {
+	return len(p.files) + len(p.capabilities) + len(p.network) + len(p.dbus) + len(p.syscalls)
+}
+
+// Matcher finds the interfaces which allow denied operations.
+type Matcher struct {
+	policies []*policy
+}
+
+// NewMatcher returns a matcher for the policy granted to plugs of the given
+// interfaces when connected to a slot of the system snap. Interfaces which
+// cannot be connected without extra attributes are ignored.
+func NewMatcher(ifaces []interfaces.Interface) *Matcher {
+	m := &Matcher{}
+	for _, iface := range ifaces {
+		p, err := connectedPlugPolicy(iface)
+		if err != nil || p.size() == 0 {
+			continue
+		}
+		m.policies = append(m.policies, p)
+	}
+	// narrower interfaces are better suggestions
+	sort.SliceStable(m.policies, func(i, j int) bool {
+		if m.policies[i].size() != m.policies[j].size() {
+			return m.policies[i].size() < m.policies[j].size()
+		}
+		return m.policies[i].iface < m.policies[j].iface
+	})
+	return m
+}
+
+// InterfacesFor returns the names of the interfaces which would allow the
+// denied operation, ordered from the most to the least specific.
+func (m *Matcher) InterfacesFor(ev *Event) []string {
+	var names []string
+	for _, p := range m.policies {
+		if p.allows(ev) {
+			names = append(names, p.iface)
+		}
+	}
+	return names
+}
+
+func connectedPlugPolicy(iface interfaces.Interface) (*policy, error) {
+	plugSnap, err := snap.InfoFromSnapYaml([]byte(fmt.Sprintf(plugSnapYaml, iface.Name())))
+	if err != nil {
+		return nil, err
+	}
+	slotSnap, err := snap.InfoFromSnapYaml([]byte(fmt.Sprintf(slotSnapYaml, iface.Name())))
+	if err != nil {
+		return nil, err
+	}
+	plugInfo := plugSnap.Plugs["plug"]
+	slotInfo := slotSnap.Slots["slot"]
+	if plugInfo == nil || slotInfo == nil {
+		return nil, fmt.Errorf("cannot connect %q plugs to the system snap", iface.Name())
+	}
+	if err := interfaces.BeforePreparePlug(iface, plugInfo); err != nil {
+		return nil, err
+	}
+	if err := interfaces.BeforePrepareSlot(iface, slotInfo); err != nil {
+		return nil, err
+	}
+	plugAppSet, err := interfaces.NewSnapAppSet(plugSnap, nil)
+	if err != nil {
+		return nil, err
+	}
+	slotAppSet, err := interfaces.NewSnapAppSet(slotSnap, nil)
+	if err != nil {
+		return nil, err
+	}
+	plug := interfaces.NewConnectedPlug(plugInfo, plugAppSet, nil, nil)
+	slot := interfaces.NewConnectedSlot(slotInfo, slotAppSet, nil, nil)
+	if err := interfaces.BeforeConnectPlug(iface, plug); err != nil {
+		return nil, err
+	}
+
+	tag := snap.AppSecurityTag("consumer", "app")
+	apparmorSpec := apparmor.NewSpecification(plugAppSet)
+	if err := apparmorSpec.AddConnectedPlug(iface, plug, slot); err != nil {
+		return nil, err
+	}
+	seccompSpec := seccomp.NewSpecification(plugAppSet)
+	if err := seccompSpec.AddConnectedPlug(iface, plug, slot); err != nil {
+		return nil, err
+	}
+
+	p := &policy{iface: iface.Name()}
+	p.addAppArmor(apparmorSpec.SnippetForTag(tag))
+	p.addSeccomp(seccompSpec.SnippetForTag(tag))
+	return p, nil
+}
+
+var (
+	fileRuleRe       = regexp.MustCompile(`^(?:audit\s+)?(deny\s+)?(?:owner\s+)?(?:file\s+)?("[^"]+"|/\S*|@\{\S*)\s+([rwaklmixpcuPCUD]+)\s*(?:->\s*\S+\s*)?,`)
+	capabilityRuleRe = regexp.MustCompile(`^(?:audit\s+)?(deny\s+)?capability\s+([a-z_ ]+),`)
+	networkRuleRe    = regexp.MustCompile(`^(?:audit\s+)?(deny\s+)?network\s+([a-z0-9_]+)(?:\s+([a-z0-9_]+))?\s*,`)
+	dbusInterfaceRe  = regexp.MustCompile(`interface="?([A-Za-z0-9_.]+)"?`)
+)
+
+func (p *policy) addAppArmor(snippet string) {
+	p.capabilities = make(map[string]bool)
+	p.dbus = make(map[string]bool)
+	for _, line := range strings.Split(snippet, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := dbusInterfaceRe.FindStringSubmatch(line); m != nil {
+			p.dbus[m[1]] = true
+			continue
+		}
+		if m := fileRuleRe.FindStringSubmatch(line); m != nil {
+			if m[1] != "" {
+				continue
+			}
+			re, err := regexp.Compile("^" + globToRegexp(strings.Trim(m[2], `"`)) + "$")
+			if err != nil {
+				continue
+			}
+			p.files = append(p.files, fileRule{path: re, perms: m[3]})
+			continue
+		}
+		if m := capabilityRuleRe.FindStringSubmatch(line); m != nil {
+			if m[1] == "" {
+				for _, capability := range strings.Fields(m[2]) {
+					p.capabilities[capability] = true
+				}
+			}
+			continue
+		}
+		if m := networkRuleRe.FindStringSubmatch(line); m != nil {
+			if m[1] == "" {
+				p.network = append(p.network, networkRule{family: m[2], sockType: m[3]})
+			}
+		}
+	}
+}
+
+func (p *policy) addSeccomp(snippet string) {
+	p.syscalls = make(map[string]bool)
+	for _, line := range strings.Split(snippet, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") ||
+			strings.HasPrefix(fields[0], "~") || strings.HasPrefix(fields[0], "@") {
+			continue
+		}
+		p.syscalls[fields[0]] = true
+	}
+}
+
+func (p *policy) allows(ev *Event) bool {
+	switch {
+	case ev.Kind == KindSeccomp:
+		return ev.SyscallName != "" && p.syscalls[ev.SyscallName]
+	case ev.Capability != "":
+		return p.capabilities[ev.Capability]
+	case ev.Family != "":
+		for _, rule := range p.network {
+			if rule.family == ev.Family && (rule.sockType == "" || rule.sockType == ev.SockType) {
+				return true
+			}
+		}
+		return false
+	case ev.Interface != "":
+		return p.dbus[ev.Interface]
+	case ev.Name != "":
+		for _, rule := range p.files {
+			if rule.path.MatchString(ev.Name) && permsAllow(rule.perms, ev.Requested) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// permsAllow returns whether the AppArmor file permissions allow the
+// requested access mask as reported in a denial.
+func permsAllow(perms, requested string) bool {
+	for _, r := range requested {
+		var ok bool
+		switch r {
+		case 'w', 'c', 'd':
+			ok = strings.ContainsRune(perms, 'w')
+		case 'a':
+			ok = strings.ContainsAny(perms, "aw")
+		case 'x':
+			ok = strings.ContainsAny(perms, "ixpcuPCU")
+		case 'r', 'k', 'l', 'm':
+			ok = strings.ContainsRune(perms, r)
+		default:
+			ok = true
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var apparmorVariables = map[string]string{
+	"PROC":        "/proc",
+	"HOME":        "(/home/[^/]+|/root)",
+	"HOMEDIRS":    "/home",
+	"pid":         "[0-9]+",
+	"pids":        "[0-9]+",
+	"tid":         "[0-9]+",
+	"INSTALL_DIR": "(/snap|/var/lib/snapd/snap)",
+}
+
+// globToRegexp converts an AppArmor path glob to a regular expression.
+// Variables which are not well known match a single path component.
+func globToRegexp(glob string) string {
+	var buf strings.Builder
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			buf.WriteString(".*")
+			i++
+		case c == '*':
+			buf.WriteString("[^/]*")
+		case c == '?':
+			buf.WriteString("[^/]")
+		case c == '{':
+			buf.WriteString("(")
+			braces++
+		case c == '}' && braces > 0:
+			buf.WriteString(")")
+			braces--
+		case c == ',' && braces > 0:
+			buf.WriteString("|")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				buf.WriteString(`\[`)
+				continue
+			}
+			buf.WriteString(glob[i : i+end+1])
+			i += end
+		case c == '@' && strings.HasPrefix(glob[i:], "@{"):
+			end := strings.IndexByte(glob[i:], '}')
+			if end < 0 {
+				buf.WriteString("@")
+				continue
+			}
+			name := glob[i+2 : i+end]
+			if value, ok := apparmorVariables[name]; ok {
+				buf.WriteString(value)
+			} else {
+				buf.WriteString("[^/]+")
+			}
+			i += end
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,91 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denials_test
+
+import (
+	"regexp"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/denials"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type matchSuite struct{}
+
+var _ = Suite(&matchSuite{})
+
+func (s *matchSuite) TestGlobToRegexp(c *C) {
+	for _, t := range []struct {
+		glob    string
+		matches []string
+		misses  []string
+	}{
+		{"/etc/foo", []string{"/etc/foo"}, []string{"/etc/foo2", "/etc"}},
+		{"/sys/class/*/", []string{"/sys/class/net/"}, []string{"/sys/class/net/eth0/"}},
+		{"/sys/**", []string{"/sys/class/net/eth0"}, []string{"/proc/1"}},
+		{"/dev/tty{S,USB}[0-9]*", []string{"/dev/ttyS0", "/dev/ttyUSB12"}, []string{"/dev/ttyACM0"}},
+		{"@{PROC}/@{pid}/mounts", []string{"/proc/42/mounts"}, []string{"/proc/self/mounts"}},
+		{"owner @{HOME}/.foo", []string{}, []string{"/home/user/.foo"}},
+		{"@{HOME}/.foo", []string{"/home/user/.foo", "/root/.foo"}, []string{"/home/a/b/.foo"}},
+		{"/var/snap/@{SNAP_INSTANCE_NAME}/**", []string{"/var/snap/foo/common/x"}, nil},
+	} {
+		re := regexp.MustCompile("^" + denials.GlobToRegexp(t.glob) + "$")
+		for _, path := range t.matches {
+			c.Check(re.MatchString(path), Equals, true, Commentf("%s %s", t.glob, path))
+		}
+		for _, path := range t.misses {
+			c.Check(re.MatchString(path), Equals, false, Commentf("%s %s", t.glob, path))
+		}
+	}
+}
+
+func (s *matchSuite) TestInterfacesFor(c *C) {
+	m := denials.NewMatcher(builtin.Interfaces())
+
+	for _, t := range []struct {
+		ev       *denials.Event
+		contains string
+		first    string
+	}{
+		{&denials.Event{Kind: denials.KindSeccomp, SyscallName: "mount"}, "fuse-support", ""},
+		{&denials.Event{Kind: denials.KindAppArmor, Operation: "capable", Capability: "sys_time"}, "time-control", ""},
+		{&denials.Event{Kind: denials.KindAppArmor, Operation: "create", Family: "netlink", SockType: "raw"}, "network-observe", ""},
+		{&denials.Event{Kind: denials.KindAppArmor, Interface: "org.freedesktop.NetworkManager"}, "network-manager-observe", "network-manager-observe"},
+		{&denials.Event{Kind: denials.KindAppArmor, Operation: "open", Name: "/sys/class/net/eth0/statistics/rx_bytes", Requested: "r"}, "hardware-observe", ""},
+	} {
+		ifaces := m.InterfacesFor(t.ev)
+		c.Check(ifaces, testutil.Contains, t.contains, Commentf("%s", t.ev))
+		if t.first != "" {
+			c.Check(ifaces[0], Equals, t.first)
+		}
+	}
+
+	// read access does not grant write access
+	readShadow := &denials.Event{Kind: denials.KindAppArmor, Operation: "open", Name: "/etc/shadow", Requested: "r"}
+	c.Check(m.InterfacesFor(readShadow), testutil.Contains, "system-backup")
+	writeShadow := &denials.Event{Kind: denials.KindAppArmor, Operation: "open", Name: "/etc/shadow", Requested: "w"}
+	c.Check(m.InterfacesFor(writeShadow), Not(testutil.Contains), "system-backup")
+
+	// unresolved syscalls and unknown operations match nothing
+	c.Check(m.InterfacesFor(&denials.Event{Kind: denials.KindSeccomp, Syscall: 165}), HasLen, 0)
+	c.Check(m.InterfacesFor(&denials.Event{Kind: denials.KindAppArmor, Operation: "signal"}), HasLen, 0)
+}
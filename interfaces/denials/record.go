@@ -0,0 +1,162 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denials
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/snapcore/snapd/osutil"
+)
+
+var (
+	kmsgPath     = "/dev/kmsg"
+	auditLogPath = "/var/log/audit/audit.log"
+
+	pollInterval = 100 * time.Millisecond
+	// settleDelay is how long to keep reading after being asked to stop,
+	// so that denials of the last moments are not lost.
+	settleDelay = 250 * time.Millisecond
+)
+
+// Recorder collects the denials of a single snap from the kernel log.
+type Recorder struct {
+	instanceName string
+	f            io.ReadCloser
+
+	mu     sync.Mutex
+	events []*Event
+	// lost is set when the kernel overwrote messages before they were read
+	lost bool
+	err  error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRecorder starts recording the denials of the given snap instance. The
+// audit log is used when the audit daemon is running, otherwise the kernel
+// ring buffer is read. Only denials that happen after the call are recorded.
+func NewRecorder(instanceName string) (*Recorder, error) {
+	path := kmsgPath
+	if osutil.FileExists(auditLogPath) {
+		path = auditLogPath
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return nil, fmt.Errorf("cannot read denials from %s: permission denied (try with sudo)", path)
+		}
+		return nil, fmt.Errorf("cannot read denials: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("cannot read denials from %s: %v", path, err)
+	}
+	return startRecorder(instanceName, f), nil
+}
+
+func startRecorder(instanceName string, f io.ReadCloser) *Recorder {
+	r := &Recorder{
+		instanceName: instanceName,
+		f:            f,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+func (r *Recorder) loop() {
+	defer close(r.done)
+	br := bufio.NewReaderSize(r.f, 64*1024)
+	var partial string
+	for {
+		line, err := br.ReadString('\n')
+		partial += line
+		if err == nil {
+			r.add(partial)
+			partial = ""
+			continue
+		}
+		if errors.Is(err, syscall.EPIPE) {
+			// reading /dev/kmsg fails with EPIPE when the messages
+			// were overwritten before they could be read, reading
+			// continues with the oldest message still available
+			r.mu.Lock()
+			r.lost = true
+			r.mu.Unlock()
+			partial = ""
+			continue
+		}
+		if err != io.EOF {
+			select {
+			case <-r.stop:
+				// the file was closed by Stop
+			default:
+				r.mu.Lock()
+				r.err = err
+				r.mu.Unlock()
+			}
+			return
+		}
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (r *Recorder) add(line string) {
+	ev := ParseLine(line)
+	if ev == nil || !ev.BelongsTo(r.instanceName) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+}
+
+// Stop stops recording and returns the recorded denials. An error is
+// returned along with the denials recorded so far when the log could not be
+// read completely, in which case some denials may be missing.
+func (r *Recorder) Stop() ([]*Event, error) {
+	time.Sleep(settleDelay)
+	close(r.stop)
+	r.f.Close()
+	<-r.done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.events, fmt.Errorf("cannot read denials, recording stopped early: %v", r.err)
+	}
+	if r.lost {
+		return r.events, errors.New("kernel messages were lost while recording, some denials may be missing")
+	}
+	return r.events, nil
+}
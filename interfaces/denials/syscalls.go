@@ -0,0 +1,69 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package denials
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/snapcore/snapd/osutil"
+)
+
+// ResolveSyscallNames sets the names of the system calls of the given
+// seccomp denials using the "syscall-names" command of the snap-seccomp
+// binary at the given path.
+func ResolveSyscallNames(events []*Event, snapSeccomp string) error {
+	byArch := make(map[string][]*Event)
+	for _, ev := range events {
+		if ev.Kind == KindSeccomp && ev.SyscallName == "" {
+			byArch[ev.Arch] = append(byArch[ev.Arch], ev)
+		}
+	}
+	arches := make([]string, 0, len(byArch))
+	for arch := range byArch {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+
+	for _, arch := range arches {
+		archEvents := byArch[arch]
+		args := []string{"syscall-names", arch}
+		for _, ev := range archEvents {
+			args = append(args, strconv.Itoa(ev.Syscall))
+		}
+		output, err := exec.Command(snapSeccomp, args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("cannot resolve syscall names: %v", osutil.OutputErr(output, err))
+		}
+		names := strings.Split(strings.TrimSpace(string(output)), "\n")
+		if len(names) != len(archEvents) {
+			return fmt.Errorf("cannot resolve syscall names: unexpected output %q", output)
+		}
+		for i, ev := range archEvents {
+			if names[i] != "?" {
+				ev.SyscallName = names[i]
+			}
+		}
+	}
+	return nil
+}
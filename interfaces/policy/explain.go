@@ -0,0 +1,171 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package policy
+
+// ConstraintResult is the result of checking a single constraint of a rule
+// alternative.
+type ConstraintResult struct {
+	// Constraint is the name of the constraint as used in declarations,
+	// e.g. "slot-snap-type", "plug-attributes" or "on-store".
+	Constraint string `json:"constraint"`
+	// Error is why the constraint does not match, empty if it does.
+	Error string `json:"error,omitempty"`
+}
+
+// AlternativeResult is the result of checking one of the alternatives of a
+// deny or allow constraint of a rule.
+type AlternativeResult struct {
+	Matched bool `json:"matched"`
+	// Constraints holds the results for the constraints specified in the
+	// alternative, it is empty for a plain true or false alternative.
+	Constraints []ConstraintResult `json:"constraints,omitempty"`
+}
+
+// Explanation describes how the policy decision about a connection or
+// auto-connection was reached.
+type Explanation struct {
+	// Kind is either "connection" or "auto-connection".
+	Kind    string `json:"kind"`
+	Allowed bool   `json:"allowed"`
+	// Error is the error the policy check returned, if any.
+	Error string `json:"error,omitempty"`
+
+	// Declaration is where the deciding rule comes from, either
+	// "snap-declaration" or "base-declaration", it is empty if no rule
+	// applies to the interface.
+	Declaration string `json:"declaration,omitempty"`
+	// Snap is the snap of the snap-declaration with the deciding rule.
+	Snap string `json:"snap,omitempty"`
+	// Side is whether the deciding rule is a "plug" or a "slot" rule.
+	Side string `json:"side,omitempty"`
+
+	// DecidedBy is the constraint of the rule which decided, e.g.
+	// "deny-auto-connection" or "allow-connection".
+	DecidedBy string `json:"decided-by,omitempty"`
+	// Alternative is the index of the alternative of DecidedBy which
+	// matched, or -1 if none of the allow alternatives matched.
+	Alternative int `json:"alternative"`
+
+	Deny  []*AlternativeResult `json:"deny,omitempty"`
+	Allow []*AlternativeResult `json:"allow,omitempty"`
+}
+
+func explainAlternative(checks []constraintCheck) *AlternativeResult {
+	res := &AlternativeResult{Matched: true}
+	for _, c := range checks {
+		err := c.check()
+		if err != nil {
+			res.Matched = false
+		}
+		if !c.set {
+			continue
+		}
+		cres := ConstraintResult{Constraint: c.name}
+		if err != nil {
+			cres.Error = err.Error()
+		}
+		res.Constraints = append(res.Constraints, cres)
+	}
+	return res
+}
+
+// ExplainConnect explains whether the connection is allowed.
+func (connc *ConnectCandidate) ExplainConnect() *Explanation {
+	return connc.explain("connection")
+}
+
+// ExplainAutoConnect explains whether the connection is allowed to
+// auto-connect.
+func (connc *ConnectCandidate) ExplainAutoConnect() *Explanation {
+	return connc.explain("auto-connection")
+}
+
+func (connc *ConnectCandidate) explain(kind string) *Explanation {
+	expl := &Explanation{
+		Kind:        kind,
+		Alternative: -1,
+	}
+	if _, err := connc.check(kind); err != nil {
+		expl.Error = err.Error()
+	} else {
+		expl.Allowed = true
+	}
+	if connc.BaseDeclaration == nil || connc.Slot.Interface() != connc.Plug.Interface() {
+		return expl
+	}
+
+	plugRule, slotRule, snapRule := connc.rule(connc.Plug.Interface())
+	switch {
+	case plugRule != nil:
+		expl.Side = "plug"
+		if snapRule {
+			expl.Snap = connc.PlugSnapDeclaration.SnapName()
+		}
+		denyConst, allowConst := plugRule.DenyConnection, plugRule.AllowConnection
+		if kind == "auto-connection" {
+			denyConst, allowConst = plugRule.DenyAutoConnection, plugRule.AllowAutoConnection
+		}
+		for _, constraints := range denyConst {
+			expl.Deny = append(expl.Deny, explainAlternative(plugConnectionConstraintChecks(connc, constraints)))
+		}
+		for _, constraints := range allowConst {
+			expl.Allow = append(expl.Allow, explainAlternative(plugConnectionConstraintChecks(connc, constraints)))
+		}
+	case slotRule != nil:
+		expl.Side = "slot"
+		if snapRule {
+			expl.Snap = connc.SlotSnapDeclaration.SnapName()
+		}
+		denyConst, allowConst := slotRule.DenyConnection, slotRule.AllowConnection
+		if kind == "auto-connection" {
+			denyConst, allowConst = slotRule.DenyAutoConnection, slotRule.AllowAutoConnection
+		}
+		for _, constraints := range denyConst {
+			expl.Deny = append(expl.Deny, explainAlternative(slotConnectionConstraintChecks(connc, constraints)))
+		}
+		for _, constraints := range allowConst {
+			expl.Allow = append(expl.Allow, explainAlternative(slotConnectionConstraintChecks(connc, constraints)))
+		}
+	default:
+		return expl
+	}
+
+	expl.Declaration = "base-declaration"
+	if snapRule {
+		expl.Declaration = "snap-declaration"
+	}
+	// the first matching deny alternative decides, otherwise the
+	// first matching allow one
+	expl.DecidedBy = "deny-" + kind
+	for i, alt := range expl.Deny {
+		if alt.Matched {
+			expl.Alternative = i
+			return expl
+		}
+	}
+	expl.DecidedBy = "allow-" + kind
+	for i, alt := range expl.Allow {
+		if alt.Matched {
+			expl.Alternative = i
+			break
+		}
+	}
+	return expl
+}
@@ -113,42 +113,99 @@ func checkNameConstraints(c *asserts.NameConstraints, iface, which, name string)
 	return c.Check(which, name, special)
 }
 
-func checkPlugConnectionConstraints1(connc *ConnectCandidate, constraints *asserts.PlugConnectionConstraints) error {
-	if err := checkNameConstraints(constraints.PlugNames, connc.Plug.Interface(), "plug name", connc.Plug.Name()); err != nil {
-		return err
-	}
-	if err := checkNameConstraints(constraints.SlotNames, connc.Slot.Interface(), "slot name", connc.Slot.Name()); err != nil {
-		return err
-	}
+// constraintCheck is the check of a single constraint of a connection
+// constraints alternative.
+type constraintCheck struct {
+	// name is the name of the constraint as used in declarations
+	name string
+	// set is whether the constraint was specified at all
+	set   bool
+	check func() error
+}
 
-	if err := constraints.PlugAttributes.Check(connc.Plug, connc); err != nil {
-		return err
-	}
-	if err := constraints.SlotAttributes.Check(connc.Slot, connc); err != nil {
-		return err
+func runConstraintChecks(checks []constraintCheck) error {
+	for _, c := range checks {
+		if err := c.check(); err != nil {
+			return err
+		}
 	}
-	if err := checkSnapType(connc.Slot.Snap(), constraints.SlotSnapTypes); err != nil {
-		return err
+	return nil
+}
+
+// attributeConstraintsSet returns whether actual attribute constraints were
+// specified, as opposed to none or a plain true or false.
+func attributeConstraintsSet(c *asserts.AttributeConstraints) bool {
+	return c != nil && c != asserts.AlwaysMatchAttributes && c != asserts.NeverMatchAttributes
+}
+
+func deviceScopeConstraintName(c *asserts.DeviceScopeConstraint) string {
+	if c == nil {
+		return "on-store/on-brand/on-model"
 	}
-	if err := checkID("snap id", connc.slotSnapID(), constraints.SlotSnapIDs, nil); err != nil {
-		return err
+	var names []string
+	if len(c.Store) != 0 {
+		names = append(names, "on-store")
 	}
-	err := checkID("publisher id", connc.SlotPublisherID(), constraints.SlotPublisherIDs, map[string]string{
-		"$PLUG_PUBLISHER_ID": connc.PlugPublisherID(),
-	})
-	if err != nil {
-		return err
+	if len(c.Brand) != 0 {
+		names = append(names, "on-brand")
 	}
-	if err := checkOnClassic(constraints.OnClassic); err != nil {
-		return err
+	if len(c.Model) != 0 {
+		names = append(names, "on-model")
 	}
-	if err := checkOnCoreDesktop(constraints.OnCoreDesktop); err != nil {
-		return err
+	return strings.Join(names, ",")
+}
+
+func commonConnectionConstraintChecks(connc *ConnectCandidate, plugNames, slotNames *asserts.NameConstraints, plugAttrs, slotAttrs *asserts.AttributeConstraints) []constraintCheck {
+	return []constraintCheck{
+		{"plug-names", plugNames != nil, func() error {
+			return checkNameConstraints(plugNames, connc.Plug.Interface(), "plug name", connc.Plug.Name())
+		}},
+		{"slot-names", slotNames != nil, func() error {
+			return checkNameConstraints(slotNames, connc.Slot.Interface(), "slot name", connc.Slot.Name())
+		}},
+		{"plug-attributes", attributeConstraintsSet(plugAttrs), func() error {
+			return plugAttrs.Check(connc.Plug, connc)
+		}},
+		{"slot-attributes", attributeConstraintsSet(slotAttrs), func() error {
+			return slotAttrs.Check(connc.Slot, connc)
+		}},
 	}
-	if err := checkDeviceScope(constraints.DeviceScope, connc.Model, connc.Store); err != nil {
-		return err
+}
+
+func platformConstraintChecks(connc *ConnectCandidate, onClassic *asserts.OnClassicConstraint, onCoreDesktop *asserts.OnCoreDesktopConstraint, deviceScope *asserts.DeviceScopeConstraint) []constraintCheck {
+	return []constraintCheck{
+		{"on-classic", onClassic != nil, func() error {
+			return checkOnClassic(onClassic)
+		}},
+		{"on-core-desktop", onCoreDesktop != nil, func() error {
+			return checkOnCoreDesktop(onCoreDesktop)
+		}},
+		{deviceScopeConstraintName(deviceScope), deviceScope != nil, func() error {
+			return checkDeviceScope(deviceScope, connc.Model, connc.Store)
+		}},
 	}
-	return nil
+}
+
+func plugConnectionConstraintChecks(connc *ConnectCandidate, constraints *asserts.PlugConnectionConstraints) []constraintCheck {
+	checks := commonConnectionConstraintChecks(connc, constraints.PlugNames, constraints.SlotNames, constraints.PlugAttributes, constraints.SlotAttributes)
+	checks = append(checks,
+		constraintCheck{"slot-snap-type", len(constraints.SlotSnapTypes) != 0, func() error {
+			return checkSnapType(connc.Slot.Snap(), constraints.SlotSnapTypes)
+		}},
+		constraintCheck{"slot-snap-id", len(constraints.SlotSnapIDs) != 0, func() error {
+			return checkID("snap id", connc.slotSnapID(), constraints.SlotSnapIDs, nil)
+		}},
+		constraintCheck{"slot-publisher-id", len(constraints.SlotPublisherIDs) != 0, func() error {
+			return checkID("publisher id", connc.SlotPublisherID(), constraints.SlotPublisherIDs, map[string]string{
+				"$PLUG_PUBLISHER_ID": connc.PlugPublisherID(),
+			})
+		}},
+	)
+	return append(checks, platformConstraintChecks(connc, constraints.OnClassic, constraints.OnCoreDesktop, constraints.DeviceScope)...)
+}
+
+func checkPlugConnectionConstraints1(connc *ConnectCandidate, constraints *asserts.PlugConnectionConstraints) error {
+	return runConstraintChecks(plugConnectionConstraintChecks(connc, constraints))
 }
 
 func checkPlugConnectionAltConstraints(connc *ConnectCandidate, altConstraints []*asserts.PlugConnectionConstraints) (*asserts.PlugConnectionConstraints, error) {
@@ -166,45 +223,29 @@ func checkPlugConnectionAltConstraints(connc *ConnectCandidate, altConstraints [
 	return nil, firstErr
 }
 
-func checkSlotConnectionConstraints1(connc *ConnectCandidate, constraints *asserts.SlotConnectionConstraints) error {
-	if err := checkNameConstraints(constraints.PlugNames, connc.Plug.Interface(), "plug name", connc.Plug.Name()); err != nil {
-		return err
-	}
-	if err := checkNameConstraints(constraints.SlotNames, connc.Slot.Interface(), "slot name", connc.Slot.Name()); err != nil {
-		return err
-	}
+func slotConnectionConstraintChecks(connc *ConnectCandidate, constraints *asserts.SlotConnectionConstraints) []constraintCheck {
+	checks := commonConnectionConstraintChecks(connc, constraints.PlugNames, constraints.SlotNames, constraints.PlugAttributes, constraints.SlotAttributes)
+	checks = append(checks,
+		constraintCheck{"slot-snap-type", len(constraints.SlotSnapTypes) != 0, func() error {
+			return checkSnapType(connc.Slot.Snap(), constraints.SlotSnapTypes)
+		}},
+		constraintCheck{"plug-snap-type", len(constraints.PlugSnapTypes) != 0, func() error {
+			return checkSnapType(connc.Plug.Snap(), constraints.PlugSnapTypes)
+		}},
+		constraintCheck{"plug-snap-id", len(constraints.PlugSnapIDs) != 0, func() error {
+			return checkID("snap id", connc.plugSnapID(), constraints.PlugSnapIDs, nil)
+		}},
+		constraintCheck{"plug-publisher-id", len(constraints.PlugPublisherIDs) != 0, func() error {
+			return checkID("publisher id", connc.PlugPublisherID(), constraints.PlugPublisherIDs, map[string]string{
+				"$SLOT_PUBLISHER_ID": connc.SlotPublisherID(),
+			})
+		}},
+	)
+	return append(checks, platformConstraintChecks(connc, constraints.OnClassic, constraints.OnCoreDesktop, constraints.DeviceScope)...)
+}
 
-	if err := constraints.PlugAttributes.Check(connc.Plug, connc); err != nil {
-		return err
-	}
-	if err := constraints.SlotAttributes.Check(connc.Slot, connc); err != nil {
-		return err
-	}
-	if err := checkSnapType(connc.Slot.Snap(), constraints.SlotSnapTypes); err != nil {
-		return err
-	}
-	if err := checkSnapType(connc.Plug.Snap(), constraints.PlugSnapTypes); err != nil {
-		return err
-	}
-	if err := checkID("snap id", connc.plugSnapID(), constraints.PlugSnapIDs, nil); err != nil {
-		return err
-	}
-	err := checkID("publisher id", connc.PlugPublisherID(), constraints.PlugPublisherIDs, map[string]string{
-		"$SLOT_PUBLISHER_ID": connc.SlotPublisherID(),
-	})
-	if err != nil {
-		return err
-	}
-	if err := checkOnClassic(constraints.OnClassic); err != nil {
-		return err
-	}
-	if err := checkOnCoreDesktop(constraints.OnCoreDesktop); err != nil {
-		return err
-	}
-	if err := checkDeviceScope(constraints.DeviceScope, connc.Model, connc.Store); err != nil {
-		return err
-	}
-	return nil
+func checkSlotConnectionConstraints1(connc *ConnectCandidate, constraints *asserts.SlotConnectionConstraints) error {
+	return runConstraintChecks(slotConnectionConstraintChecks(connc, constraints))
 }
 
 func checkSlotConnectionAltConstraints(connc *ConnectCandidate, altConstraints []*asserts.SlotConnectionConstraints) (*asserts.SlotConnectionConstraints, error) {
@@ -125,6 +125,24 @@ func (ic *InstallCandidate) Check() error {
 	return nil
 }
 
+// CheckSlot checks whether the installation is allowed as far as the given
+// slot of the snap is concerned.
+func (ic *InstallCandidate) CheckSlot(slot *snap.SlotInfo) error {
+	if ic.BaseDeclaration == nil {
+		return fmt.Errorf("internal error: improperly initialized InstallCandidate")
+	}
+	return ic.checkSlot(slot)
+}
+
+// CheckPlug checks whether the installation is allowed as far as the given
+// plug of the snap is concerned.
+func (ic *InstallCandidate) CheckPlug(plug *snap.PlugInfo) error {
+	if ic.BaseDeclaration == nil {
+		return fmt.Errorf("internal error: improperly initialized InstallCandidate")
+	}
+	return ic.checkPlug(plug)
+}
+
 // ConnectCandidate represents a candidate connection.
 type ConnectCandidate struct {
 	Plug                *interfaces.ConnectedPlug
@@ -239,23 +257,36 @@ func (connc *ConnectCandidate) check(kind string) (interfaces.SideArity, error)
 		return nil, fmt.Errorf("cannot connect mismatched plug interface %q to slot interface %q", iface, connc.Slot.Interface())
 	}
 
+	plugRule, slotRule, snapRule := connc.rule(iface)
+	switch {
+	case plugRule != nil:
+		return connc.checkPlugRule(kind, plugRule, snapRule)
+	case slotRule != nil:
+		return connc.checkSlotRule(kind, slotRule, snapRule)
+	}
+	return nil, nil
+}
+
+// rule returns the rule which decides about the connection, either a plug or
+// a slot rule, and whether it comes from a snap-declaration.
+func (connc *ConnectCandidate) rule(iface string) (plugRule *asserts.PlugRule, slotRule *asserts.SlotRule, snapRule bool) {
 	if plugDecl := connc.PlugSnapDeclaration; plugDecl != nil {
 		if rule := plugDecl.PlugRule(iface); rule != nil {
-			return connc.checkPlugRule(kind, rule, true)
+			return rule, nil, true
 		}
 	}
 	if slotDecl := connc.SlotSnapDeclaration; slotDecl != nil {
 		if rule := slotDecl.SlotRule(iface); rule != nil {
-			return connc.checkSlotRule(kind, rule, true)
+			return nil, rule, true
 		}
 	}
-	if rule := baseDecl.PlugRule(iface); rule != nil {
-		return connc.checkPlugRule(kind, rule, false)
+	if rule := connc.BaseDeclaration.PlugRule(iface); rule != nil {
+		return rule, nil, false
 	}
-	if rule := baseDecl.SlotRule(iface); rule != nil {
-		return connc.checkSlotRule(kind, rule, false)
+	if rule := connc.BaseDeclaration.SlotRule(iface); rule != nil {
+		return nil, rule, false
 	}
-	return nil, nil
+	return nil, nil, false
 }
 
 // Check checks whether the connection is allowed.
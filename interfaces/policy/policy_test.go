@@ -1678,6 +1678,31 @@ AXNpZw==`, "@plugsSlots@", strings.TrimSpace(t.plugsSlots), 1)))
 	}
 }
 
+func (s *policySuite) TestInstallationCheckPlugSlot(c *C) {
+	installSnap := snaptest.MockInfo(c, `name: install-snap
+version: 0
+plugs:
+  install-plug-base-deny-snap-allow:
+    attr: attrvalue
+  install-plug-base-allow-snap-deny:
+    attr: give-me
+slots:
+  install-slot-base-deny-snap-allow:
+    have: yes
+`, nil)
+
+	cand := policy.InstallCandidate{
+		Snap:            installSnap,
+		BaseDeclaration: s.baseDecl,
+	}
+	c.Check(cand.CheckPlug(installSnap.Plugs["install-plug-base-deny-snap-allow"]), ErrorMatches, `installation denied by "install-plug-base-deny-snap-allow" plug rule of interface "install-plug-base-deny-snap-allow"`)
+	c.Check(cand.CheckPlug(installSnap.Plugs["install-plug-base-allow-snap-deny"]), IsNil)
+	c.Check(cand.CheckSlot(installSnap.Slots["install-slot-base-deny-snap-allow"]), ErrorMatches, `installation denied by "install-slot-base-deny-snap-allow" slot rule of interface "install-slot-base-deny-snap-allow"`)
+
+	cand.BaseDeclaration = nil
+	c.Check(cand.CheckPlug(installSnap.Plugs["install-plug-base-allow-snap-deny"]), ErrorMatches, "internal error: improperly initialized InstallCandidate")
+}
+
 func (s *policySuite) TestBaseDeclAllowDenyInstallationMinimalCheck(c *C) {
 	tests := []struct {
 		installYaml string
@@ -2061,6 +2086,83 @@ func (s *policySuite) TestPlugDeviceScopeCheckAutoConnection(c *C) {
 	}
 }
 
+func (s *policySuite) TestExplainConnectBaseDecl(c *C) {
+	cand := policy.ConnectCandidate{
+		Plug:            interfaces.NewConnectedPlug(s.plugSnap.Plugs["base-plug-deny"], s.plugAppSet, nil, nil),
+		Slot:            interfaces.NewConnectedSlot(s.slotSnap.Slots["base-plug-deny"], s.slotAppSet, nil, nil),
+		BaseDeclaration: s.baseDecl,
+	}
+	c.Check(cand.ExplainConnect(), DeepEquals, &policy.Explanation{
+		Kind:        "connection",
+		Error:       `connection denied by plug rule of interface "base-plug-deny"`,
+		Declaration: "base-declaration",
+		Side:        "plug",
+		DecidedBy:   "deny-connection",
+		Alternative: 0,
+		Deny:        []*policy.AlternativeResult{{Matched: true}},
+		Allow:       []*policy.AlternativeResult{{Matched: true}},
+	})
+
+	cand = policy.ConnectCandidate{
+		Plug:            interfaces.NewConnectedPlug(s.plugSnap.Plugs["plug-or-p2-s2"], s.plugAppSet, nil, nil),
+		Slot:            interfaces.NewConnectedSlot(s.slotSnap.Slots["plug-or-p2-s2"], s.slotAppSet, nil, nil),
+		BaseDeclaration: s.baseDecl,
+	}
+	expl := cand.ExplainConnect()
+	c.Check(expl.Allowed, Equals, true)
+	c.Check(expl.Error, Equals, "")
+	c.Check(expl.DecidedBy, Equals, "allow-connection")
+	c.Check(expl.Alternative, Equals, 1)
+	c.Assert(expl.Allow, HasLen, 2)
+	c.Check(expl.Allow[0].Matched, Equals, false)
+	c.Assert(expl.Allow[0].Constraints, HasLen, 2)
+	c.Check(expl.Allow[0].Constraints[0].Constraint, Equals, "plug-attributes")
+	c.Check(expl.Allow[0].Constraints[0].Error, Matches, `attribute "p" value "P2" does not match .*`)
+	c.Check(expl.Allow[0].Constraints[1].Constraint, Equals, "slot-attributes")
+	c.Check(expl.Allow[0].Constraints[1].Error, Not(Equals), "")
+	c.Check(expl.Allow[1], DeepEquals, &policy.AlternativeResult{
+		Matched: true,
+		Constraints: []policy.ConstraintResult{
+			{Constraint: "plug-attributes"},
+			{Constraint: "slot-attributes"},
+		},
+	})
+}
+
+func (s *policySuite) TestExplainAutoConnectDeviceScope(c *C) {
+	cand := policy.ConnectCandidate{
+		Plug:                interfaces.NewConnectedPlug(s.plugSnap.Plugs["auto-plug-on-multi"], s.plugAppSet, nil, nil),
+		Slot:                interfaces.NewConnectedSlot(s.slotSnap.Slots["auto-plug-on-multi"], s.slotAppSet, nil, nil),
+		PlugSnapDeclaration: s.plugDecl,
+		SlotSnapDeclaration: s.slotDecl,
+		BaseDeclaration:     s.baseDecl,
+		Model:               myModel2,
+	}
+	c.Check(cand.ExplainAutoConnect(), DeepEquals, &policy.Explanation{
+		Kind:        "auto-connection",
+		Error:       `auto-connection not allowed by plug rule of interface "auto-plug-on-multi" for "plug-snap" snap`,
+		Declaration: "snap-declaration",
+		Snap:        "plug-snap",
+		Side:        "plug",
+		DecidedBy:   "allow-auto-connection",
+		Alternative: -1,
+		Deny:        []*policy.AlternativeResult{{Matched: false}},
+		Allow: []*policy.AlternativeResult{{
+			Constraints: []policy.ConstraintResult{
+				{Constraint: "on-store,on-brand,on-model", Error: "on-store mismatch"},
+			},
+		}},
+	})
+
+	cand.Model = myModel1
+	expl := cand.ExplainAutoConnect()
+	c.Check(expl.Allowed, Equals, true)
+	c.Check(expl.Alternative, Equals, 0)
+	c.Check(expl.Allow[0].Constraints, DeepEquals, []policy.ConstraintResult{
+		{Constraint: "on-store,on-brand,on-model"},
+	})
+}
+
 func (s *policySuite) TestPlugDeviceScopeFriendlyStoreCheckAutoConnection(c *C) {
 	tests := []struct {
 		model *asserts.Model
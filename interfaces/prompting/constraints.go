@@ -22,6 +22,7 @@ package prompting
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	prompting_errors "github.com/snapcore/snapd/interfaces/prompting/errors"
@@ -82,6 +83,9 @@ func (c *Constraints) ToRuleConstraints(iface string, currTime time.Time) (*Rule
 	if err != nil {
 		return nil, err
 	}
+	if err := validatePathPatternScope(iface, c.PathPattern); err != nil {
+		return nil, err
+	}
 	ruleConstraints := &RuleConstraints{
 		PathPattern: c.PathPattern,
 		Permissions: rulePermissions,
@@ -108,7 +112,14 @@ func (c *RuleConstraints) ValidateForInterface(iface string, currTime time.Time)
 	if c.PathPattern == nil {
 		return false, prompting_errors.NewInvalidPathPatternError("", "no path pattern")
 	}
-	return c.Permissions.validateForInterface(iface, currTime)
+	expired, err = c.Permissions.validateForInterface(iface, currTime)
+	if err != nil {
+		return false, err
+	}
+	if err = validatePathPatternScope(iface, c.PathPattern); err != nil {
+		return false, err
+	}
+	return expired, nil
 }
 
 // Match returns true if the constraints match the given path, otherwise false.
@@ -126,6 +137,39 @@ func (c *RuleConstraints) Match(path string) (bool, error) {
 	return match, nil
 }
 
+// ToConstraints converts the receiving RuleConstraints to Constraints which,
+// when converted back to RuleConstraints at the given current time, result in
+// the same outcomes, and in expirations which are no earlier than the
+// original ones. Permissions which have expired relative to the given current
+// time are omitted.
+func (c *RuleConstraints) ToConstraints(currTime time.Time) *Constraints {
+	permissions := make(PermissionMap, len(c.Permissions))
+	for perm, entry := range c.Permissions {
+		if entry.Expired(currTime) {
+			continue
+		}
+		permEntry := &PermissionEntry{
+			Outcome:  entry.Outcome,
+			Lifespan: entry.Lifespan,
+		}
+		if entry.Lifespan == LifespanTimespan {
+			// Round up to whole seconds, so the permission does not expire
+			// early, and the duration is not rendered with fractions.
+			remaining := entry.Expiration.Sub(currTime)
+			duration := remaining.Truncate(time.Second)
+			if duration < remaining {
+				duration += time.Second
+			}
+			permEntry.Duration = duration.String()
+		}
+		permissions[perm] = permEntry
+	}
+	return &Constraints{
+		PathPattern: c.PathPattern,
+		Permissions: permissions,
+	}
+}
+
 // ReplyConstraints hold information about the applicability of a reply to
 // particular paths and permissions. Upon receiving the reply, snapd converts
 // ReplyConstraints to Constraints.
@@ -171,6 +215,9 @@ func (c *ReplyConstraints) ToConstraints(iface string, outcome OutcomeType, life
 	if len(invalidPerms) > 0 {
 		return nil, prompting_errors.NewInvalidPermissionsError(iface, invalidPerms, availablePerms)
 	}
+	if err := validatePathPatternScope(iface, c.PathPattern); err != nil {
+		return nil, err
+	}
 	constraints := &Constraints{
 		PathPattern: c.PathPattern,
 		Permissions: permissionMap,
@@ -209,6 +256,8 @@ func (c *RuleConstraintsPatch) PatchRuleConstraints(existing *RuleConstraints, i
 	}
 	if c.PathPattern == nil {
 		ruleConstraints.PathPattern = existing.PathPattern
+	} else if err := validatePathPatternScope(iface, c.PathPattern); err != nil {
+		return nil, err
 	}
 	if c.Permissions == nil {
 		ruleConstraints.Permissions = existing.Permissions
@@ -458,7 +507,10 @@ var (
 	// List of permissions available for each interface. This also defines the
 	// order in which the permissions should be presented.
 	interfacePermissionsAvailable = map[string][]string{
-		"home": {"read", "write", "execute"},
+		"home":            {"read", "write", "execute"},
+		"removable-media": {"read", "write", "execute"},
+		"personal-files":  {"read", "write"},
+		"camera":          {"access"},
 	}
 
 	// A mapping from interfaces which support AppArmor file permissions to
@@ -473,9 +525,56 @@ var (
 			"write":   notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
 			"execute": notify.AA_MAY_EXEC | notify.AA_EXEC_MMAP,
 		},
+		"removable-media": {
+			"read":    notify.AA_MAY_READ | notify.AA_MAY_GETATTR,
+			"write":   notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
+			"execute": notify.AA_MAY_EXEC | notify.AA_EXEC_MMAP,
+		},
+		"personal-files": {
+			"read":  notify.AA_MAY_READ | notify.AA_MAY_GETATTR,
+			"write": notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_CREATE | notify.AA_MAY_DELETE | notify.AA_MAY_RENAME | notify.AA_MAY_SETATTR | notify.AA_MAY_CHMOD | notify.AA_MAY_LOCK | notify.AA_MAY_LINK,
+		},
+		"camera": {
+			"access": notify.AA_MAY_READ | notify.AA_MAY_WRITE | notify.AA_MAY_APPEND | notify.AA_MAY_GETATTR,
+		},
+	}
+
+	// A mapping from interfaces to the path prefixes within which every
+	// variant of a path pattern for that interface must lie. Interfaces which
+	// are not included may have path patterns matching any path.
+	interfacePathPatternScopes = map[string][]string{
+		"removable-media": {"/media/", "/run/media/", "/mnt/"},
+		"camera":          {"/dev/video"},
 	}
 )
 
+// validatePathPatternScope checks that every variant of the given path pattern
+// lies within the scope of paths which the given interface may mediate.
+func validatePathPatternScope(iface string, pathPattern *patterns.PathPattern) error {
+	scopes, ok := interfacePathPatternScopes[iface]
+	if !ok {
+		return nil
+	}
+	inScope := true
+	pathPattern.RenderAllVariants(func(index int, variant patterns.PatternVariant) {
+		if !inScope {
+			return
+		}
+		rendered := variant.String()
+		for _, prefix := range scopes {
+			if strings.HasPrefix(rendered, prefix) {
+				return
+			}
+		}
+		inScope = false
+	})
+	if !inScope {
+		reason := fmt.Sprintf("not within the scope of the %s interface (%s)", iface, strings.Join(scopes, ", "))
+		return prompting_errors.NewInvalidPathPatternError(pathPattern.String(), reason)
+	}
+	return nil
+}
+
 // availableInterfaces returns the list of supported interfaces.
 func availableInterfaces() []string {
 	interfaces := make([]string, 0, len(interfacePermissionsAvailable))
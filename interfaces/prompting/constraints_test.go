@@ -523,6 +523,53 @@ func (s *constraintsSuite) TestRuleConstraintsValidateForInterfaceExpiration(c *
 	}
 }
 
+func (s *constraintsSuite) TestRuleConstraintsToConstraints(c *C) {
+	pathPattern := mustParsePathPattern(c, "/home/test/{foo,bar}/**")
+	currTime := time.Now()
+	ruleConstraints := &prompting.RuleConstraints{
+		PathPattern: pathPattern,
+		Permissions: prompting.RulePermissionMap{
+			"read": &prompting.RulePermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+			},
+			"write": &prompting.RulePermissionEntry{
+				Outcome:    prompting.OutcomeDeny,
+				Lifespan:   prompting.LifespanTimespan,
+				Expiration: currTime.Add(90*time.Minute + 500*time.Millisecond),
+			},
+			"execute": &prompting.RulePermissionEntry{
+				Outcome:    prompting.OutcomeAllow,
+				Lifespan:   prompting.LifespanTimespan,
+				Expiration: currTime.Add(-time.Second),
+			},
+		},
+	}
+
+	constraints := ruleConstraints.ToConstraints(currTime)
+	c.Check(constraints, DeepEquals, &prompting.Constraints{
+		PathPattern: pathPattern,
+		Permissions: prompting.PermissionMap{
+			"read": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+			},
+			"write": &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeDeny,
+				Lifespan: prompting.LifespanTimespan,
+				Duration: "1h30m1s",
+			},
+		},
+	})
+
+	// Converting back yields the same outcomes, and no earlier expiration
+	converted, err := constraints.ToRuleConstraints("home", currTime)
+	c.Assert(err, IsNil)
+	c.Check(converted.Permissions["read"], DeepEquals, ruleConstraints.Permissions["read"])
+	c.Check(converted.Permissions["write"].Outcome, Equals, prompting.OutcomeDeny)
+	c.Check(converted.Permissions["write"].Expiration.Before(ruleConstraints.Permissions["write"].Expiration), Equals, false)
+}
+
 func (s *constraintsSuite) TestReplyConstraintsToConstraintsHappy(c *C) {
 	iface := "home"
 	pathPattern := mustParsePathPattern(c, "/path/to/dir/{foo*,ba?/**}")
@@ -662,6 +709,104 @@ func (s *constraintsSuite) TestReplyConstraintsToConstraintsUnhappy(c *C) {
 	}
 }
 
+func (s *constraintsSuite) TestPathPatternScope(c *C) {
+	currTime := time.Now()
+	for _, testCase := range []struct {
+		iface   string
+		pattern string
+		errStr  string
+	}{
+		{
+			iface:   "home",
+			pattern: "/**",
+		},
+		{
+			iface:   "personal-files",
+			pattern: "/home/test/.config/foo/**",
+		},
+		{
+			iface:   "removable-media",
+			pattern: "/{media/test,run/media/test,mnt}/usb/**",
+		},
+		{
+			iface:   "removable-media",
+			pattern: "/{media,home}/test/**",
+			errStr:  `invalid path pattern: not within the scope of the removable-media interface \(/media/, /run/media/, /mnt/\): "/{media,home}/test/\*\*"`,
+		},
+		{
+			iface:   "removable-media",
+			pattern: "/mnt",
+			errStr:  `invalid path pattern: not within the scope of the removable-media interface .*`,
+		},
+		{
+			iface:   "camera",
+			pattern: "/dev/video{0,1}",
+		},
+		{
+			iface:   "camera",
+			pattern: "/dev/**",
+			errStr:  `invalid path pattern: not within the scope of the camera interface \(/dev/video\): "/dev/\*\*"`,
+		},
+	} {
+		pathPattern := mustParsePathPattern(c, testCase.pattern)
+		perms, err := prompting.AvailablePermissions(testCase.iface)
+		c.Assert(err, IsNil)
+		checkErr := func(err error) {
+			if testCase.errStr == "" {
+				c.Check(err, IsNil, Commentf("testCase: %+v", testCase))
+			} else {
+				c.Check(err, ErrorMatches, testCase.errStr, Commentf("testCase: %+v", testCase))
+			}
+		}
+
+		replyConstraints := &prompting.ReplyConstraints{
+			PathPattern: pathPattern,
+			Permissions: perms,
+		}
+		_, err = replyConstraints.ToConstraints(testCase.iface, prompting.OutcomeAllow, prompting.LifespanForever, "")
+		checkErr(err)
+
+		permissionMap := make(prompting.PermissionMap, len(perms))
+		rulePermissionMap := make(prompting.RulePermissionMap, len(perms))
+		for _, perm := range perms {
+			permissionMap[perm] = &prompting.PermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+			}
+			rulePermissionMap[perm] = &prompting.RulePermissionEntry{
+				Outcome:  prompting.OutcomeAllow,
+				Lifespan: prompting.LifespanForever,
+			}
+		}
+		constraints := &prompting.Constraints{
+			PathPattern: pathPattern,
+			Permissions: permissionMap,
+		}
+		_, err = constraints.ToRuleConstraints(testCase.iface, currTime)
+		checkErr(err)
+
+		ruleConstraints := &prompting.RuleConstraints{
+			PathPattern: pathPattern,
+			Permissions: rulePermissionMap,
+		}
+		_, err = ruleConstraints.ValidateForInterface(testCase.iface, currTime)
+		checkErr(err)
+
+		existing := &prompting.RuleConstraints{
+			PathPattern: mustParsePathPattern(c, "/media/test/foo"),
+			Permissions: rulePermissionMap,
+		}
+		if testCase.iface == "camera" {
+			existing.PathPattern = mustParsePathPattern(c, "/dev/video0")
+		}
+		patch := &prompting.RuleConstraintsPatch{
+			PathPattern: pathPattern,
+		}
+		_, err = patch.PatchRuleConstraints(existing, testCase.iface, currTime)
+		checkErr(err)
+	}
+}
+
 func (s *constraintsSuite) TestPatchRuleConstraintsHappy(c *C) {
 	origTime := time.Now()
 	patchTime := origTime.Add(time.Second)
@@ -1112,6 +1257,26 @@ func (s *constraintsSuite) TestAbstractPermissionsFromAppArmorPermissionsHappy(c
 			notify.AA_MAY_EXEC | notify.AA_MAY_WRITE | notify.AA_MAY_READ,
 			[]string{"read", "write", "execute"},
 		},
+		{
+			"removable-media",
+			notify.AA_MAY_OPEN | notify.AA_MAY_EXEC | notify.AA_MAY_CREATE,
+			[]string{"write", "execute"},
+		},
+		{
+			"personal-files",
+			notify.AA_MAY_OPEN | notify.AA_MAY_READ | notify.AA_MAY_LOCK,
+			[]string{"read", "write"},
+		},
+		{
+			"camera",
+			notify.AA_MAY_OPEN | notify.AA_MAY_READ | notify.AA_MAY_WRITE,
+			[]string{"access"},
+		},
+		{
+			"camera",
+			notify.AA_MAY_OPEN,
+			[]string{"access"},
+		},
 	}
 	for _, testCase := range cases {
 		perms, err := prompting.AbstractPermissionsFromAppArmorPermissions(testCase.iface, testCase.perms)
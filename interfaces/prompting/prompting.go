@@ -24,6 +24,7 @@ package prompting
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"strconv"
 	"time"
 
@@ -44,6 +45,11 @@ type Metadata struct {
 	Interface string
 }
 
+// AllUsers is the user ID with which rules defined by an administrator for
+// every user are stored. It never refers to an actual user, as (uid_t)-1 is
+// reserved.
+const AllUsers uint32 = math.MaxUint32
+
 type IDType uint64
 
 func IDFromString(idStr string) (IDType, error) {
@@ -28,6 +28,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -936,6 +937,9 @@ func (pdb *PromptDB) Reply(user uint32, id prompting.IDType, outcome prompting.O
 // Since rule is new, we don't check the expiration timestamps for any
 // permissions, since any permissions with lifespan timespan were validated to
 // have a non-zero duration, and we handle this rule as it was at its creation.
+//
+// If the user in the given metadata is prompting.AllUsers, the rule is applied
+// to the prompts of every user.
 func (pdb *PromptDB) HandleNewRule(metadata *prompting.Metadata, constraints *prompting.RuleConstraints) ([]prompting.IDType, error) {
 	pdb.mutex.Lock()
 	defer pdb.mutex.Unlock()
@@ -944,9 +948,13 @@ func (pdb *PromptDB) HandleNewRule(metadata *prompting.Metadata, constraints *pr
 		return nil, prompting_errors.ErrPromptsClosed
 	}
 
-	userEntry, ok := pdb.perUser[metadata.User]
-	if !ok {
-		return nil, nil
+	users := []uint32{metadata.User}
+	if metadata.User == prompting.AllUsers {
+		users = make([]uint32, 0, len(pdb.perUser))
+		for user := range pdb.perUser {
+			users = append(users, user)
+		}
+		sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
 	}
 
 	needToSave := false
@@ -956,6 +964,31 @@ func (pdb *PromptDB) HandleNewRule(metadata *prompting.Metadata, constraints *pr
 		}
 	}()
 
+	var satisfiedPromptIDs []prompting.IDType
+	for _, user := range users {
+		userEntry, ok := pdb.perUser[user]
+		if !ok {
+			continue
+		}
+		satisfied, err := pdb.handleNewRuleForUser(user, userEntry, metadata, constraints)
+		if len(satisfied) > 0 {
+			needToSave = true
+			satisfiedPromptIDs = append(satisfiedPromptIDs, satisfied...)
+		}
+		if err != nil {
+			return satisfiedPromptIDs, err
+		}
+	}
+	return satisfiedPromptIDs, nil
+}
+
+// handleNewRuleForUser applies the given rule contents to the outstanding
+// prompts of the given user, and returns the IDs of any prompts which were
+// fully satisfied.
+//
+// The caller must ensure that the database lock is held for writing, and
+// that the request ID map is saved if any prompts were satisfied.
+func (pdb *PromptDB) handleNewRuleForUser(user uint32, userEntry *userPromptDB, metadata *prompting.Metadata, constraints *prompting.RuleConstraints) ([]prompting.IDType, error) {
 	var satisfiedPromptIDs []prompting.IDType
 	for _, prompt := range userEntry.prompts {
 		if !(prompt.Snap == metadata.Snap && prompt.Interface == metadata.Interface) {
@@ -966,7 +999,7 @@ func (pdb *PromptDB) HandleNewRule(metadata *prompting.Metadata, constraints *pr
 		if err != nil {
 			// Should not occur, only error is if path pattern is malformed,
 			// which would have thrown an error while parsing, not now.
-			return nil, err
+			return satisfiedPromptIDs, err
 		}
 		if !affectedByRule {
 			continue
@@ -974,7 +1007,7 @@ func (pdb *PromptDB) HandleNewRule(metadata *prompting.Metadata, constraints *pr
 		if !respond {
 			// No response necessary, though the prompt constraints were
 			// modified, so just record a notice for the prompt.
-			pdb.notifyPrompt(metadata.User, prompt.ID, nil)
+			pdb.notifyPrompt(user, prompt.ID, nil)
 			continue
 		}
 
@@ -1008,11 +1041,10 @@ func (pdb *PromptDB) HandleNewRule(metadata *prompting.Metadata, constraints *pr
 		for _, listenerReq := range prompt.listenerReqs {
 			delete(pdb.requestIDMap, listenerReq.ID)
 		}
-		needToSave = true
 
 		satisfiedPromptIDs = append(satisfiedPromptIDs, prompt.ID)
 		data := map[string]string{"resolved": "satisfied"}
-		pdb.notifyPrompt(metadata.User, prompt.ID, data)
+		pdb.notifyPrompt(user, prompt.ID, data)
 	}
 	return satisfiedPromptIDs, nil
 }
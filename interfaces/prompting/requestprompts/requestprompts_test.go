@@ -1427,6 +1427,86 @@ func (s *requestpromptsSuite) TestHandleNewRuleNonMatches(c *C) {
 	c.Check(stored, IsNil)
 }
 
+func (s *requestpromptsSuite) TestHandleNewRuleAllUsers(c *C) {
+	listenerReqChan := make(chan *listener.Request, 2)
+	replyChan := make(chan notify.AppArmorPermission, 2)
+	restore := requestprompts.MockSendReply(func(listenerReq *listener.Request, allowedPermission notify.AppArmorPermission) error {
+		listenerReqChan <- listenerReq
+		replyChan <- allowedPermission
+		return nil
+	})
+	defer restore()
+
+	otherUser := s.defaultUser + 1
+	noticeUsers := make(map[prompting.IDType]uint32)
+	notifyPrompt := func(userID uint32, promptID prompting.IDType, data map[string]string) error {
+		noticeUsers[promptID] = userID
+		return s.defaultNotifyPrompt(s.defaultUser, promptID, data)
+	}
+	pdb, err := requestprompts.New(notifyPrompt)
+	c.Assert(err, IsNil)
+	defer pdb.Close()
+
+	path := "/home/test/Documents/foo.txt"
+	permissions := []string{"read"}
+
+	var prompts []*requestprompts.Prompt
+	for i, user := range []uint32{s.defaultUser, otherUser} {
+		metadata := &prompting.Metadata{
+			User:      user,
+			Snap:      "nextcloud",
+			PID:       123,
+			Interface: "home",
+		}
+		listenerReq := &listener.Request{ID: uint64(i + 1)}
+		prompt, merged, err := pdb.AddOrMerge(metadata, path, permissions, permissions, listenerReq)
+		c.Assert(err, IsNil)
+		c.Check(merged, Equals, false)
+		prompts = append(prompts, prompt)
+	}
+	s.checkNewNoticesSimple(c, []prompting.IDType{prompts[0].ID, prompts[1].ID}, nil)
+
+	pathPattern, err := patterns.ParsePathPattern("/home/test/Documents/**")
+	c.Assert(err, IsNil)
+	constraints := &prompting.RuleConstraints{
+		PathPattern: pathPattern,
+		Permissions: prompting.RulePermissionMap{
+			"read": &prompting.RulePermissionEntry{Outcome: prompting.OutcomeAllow},
+		},
+	}
+	metadata := &prompting.Metadata{
+		User:      prompting.AllUsers,
+		Snap:      "nextcloud",
+		Interface: "home",
+	}
+
+	satisfied, err := pdb.HandleNewRule(metadata, constraints)
+	c.Assert(err, IsNil)
+	c.Check(satisfied, DeepEquals, []prompting.IDType{prompts[0].ID, prompts[1].ID})
+
+	// Notices are recorded for the user who owns each prompt
+	s.checkNewNoticesSimple(c, []prompting.IDType{prompts[0].ID, prompts[1].ID}, map[string]string{"resolved": "satisfied"})
+	c.Check(noticeUsers, DeepEquals, map[prompting.IDType]uint32{
+		prompts[0].ID: s.defaultUser,
+		prompts[1].ID: otherUser,
+	})
+	s.checkWrittenIDMap(c, map[uint64]requestprompts.IDMapEntry{})
+
+	for i := 0; i < 2; i++ {
+		_, allowedPermission, err := s.waitForListenerReqAndReply(c, listenerReqChan, replyChan)
+		c.Check(err, IsNil)
+		expectedPerm, err := prompting.AbstractPermissionsToAppArmorPermissions("home", permissions)
+		c.Check(err, IsNil)
+		c.Check(allowedPermission, DeepEquals, expectedPerm)
+	}
+
+	for _, user := range []uint32{s.defaultUser, otherUser} {
+		stored, err := pdb.Prompts(user, false)
+		c.Check(err, IsNil)
+		c.Check(stored, HasLen, 0)
+	}
+}
+
 func (s *requestpromptsSuite) TestClose(c *C) {
 	var timer *testtime.TestTimer
 	restore := requestprompts.MockTimeAfterFunc(func(d time.Duration, f func()) timeutil.Timer {
@@ -22,6 +22,7 @@ package requestrules
 import (
 	"time"
 
+	"github.com/snapcore/snapd/interfaces/prompting"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -37,6 +38,13 @@ func (rdb *RuleDB) IsPathPermAllowed(user uint32, snap string, iface string, pat
 	return rdb.isPathPermAllowed(user, snap, iface, path, permission)
 }
 
+// StoredRule returns the rule with the given ID as stored in the database,
+// rather than a copy of it, so that tests can tamper with it.
+func (rdb *RuleDB) StoredRule(id prompting.IDType) *Rule {
+	rule, _ := rdb.lookupRuleByID(id)
+	return rule
+}
+
 func MockIsPathPermAllowed(f func(rdb *RuleDB, user uint32, snap string, iface string, path string, permission string) (bool, error)) func() {
 	return testutil.Mock(&isPathPermAllowedByRuleDB, f)
 }
@@ -41,6 +41,27 @@ import (
 	"github.com/snapcore/snapd/strutil"
 )
 
+// RuleOrigin describes who defined a rule.
+type RuleOrigin string
+
+const (
+	// OriginUser indicates that the rule was defined by the user to whom it
+	// applies, usually by replying to a prompt.
+	OriginUser RuleOrigin = "user"
+	// OriginAdmin indicates that the rule was defined by an administrator
+	// and applies to every user. Such rules take precedence over rules
+	// defined by the users themselves.
+	OriginAdmin RuleOrigin = "admin"
+)
+
+// originForUser returns the origin of rules stored for the given user.
+func originForUser(user uint32) RuleOrigin {
+	if user == prompting.AllUsers {
+		return OriginAdmin
+	}
+	return OriginUser
+}
+
 // Rule stores the contents of a request rule.
 type Rule struct {
 	ID          prompting.IDType           `json:"id"`
@@ -48,7 +69,14 @@ type Rule struct {
 	User        uint32                     `json:"user"`
 	Snap        string                     `json:"snap"`
 	Interface   string                     `json:"interface"`
+	Origin      RuleOrigin                 `json:"origin"`
 	Constraints *prompting.RuleConstraints `json:"constraints"`
+	// HitCount is the number of times the rule has matched a requested
+	// permission.
+	HitCount uint64 `json:"hit-count"`
+	// LastMatched is the time at which the rule last matched a requested
+	// permission, and is zero if it never did.
+	LastMatched time.Time `json:"last-matched,omitzero"`
 }
 
 // Validate verifies internal correctness of the rule's constraints and
@@ -63,6 +91,29 @@ func (rule *Rule) expired(currTime time.Time) bool {
 	return rule.Constraints.Permissions.Expired(currTime)
 }
 
+// lastUsed returns the time at which the rule last matched a requested
+// permission or, if it never did, the time at which it was last modified.
+func (rule *Rule) lastUsed() time.Time {
+	if rule.LastMatched.After(rule.Timestamp) {
+		return rule.LastMatched
+	}
+	return rule.Timestamp
+}
+
+// ruleStats holds the hit count and last matched timestamp of a rule.
+type ruleStats struct {
+	hitCount    uint64
+	lastMatched time.Time
+}
+
+// ExportedRule holds the contents of a rule in a form which can be imported
+// for another user or on another system.
+type ExportedRule struct {
+	Snap        string                 `json:"snap"`
+	Interface   string                 `json:"interface"`
+	Constraints *prompting.Constraints `json:"constraints"`
+}
+
 // variantEntry stores the actual pattern variant struct which can be used to
 // match paths, and a map from rule IDs whose path patterns render to this
 // variant to the relevant permission entry from that rule. All non-expired
@@ -124,6 +175,16 @@ type RuleDB struct {
 	// is matched by existing rules, and which of those rules has precedence.
 	perUser map[uint32]*userDB
 
+	// statsMutex guards the hit counts and last matched timestamps of the
+	// rules, which are updated while the database lock is only held for
+	// reading. They are kept apart from the rules themselves, which may be
+	// read concurrently, and only copied into the stored rules while the
+	// database lock is held for writing. statsDirty records whether they
+	// have changed since the database was last saved.
+	statsMutex sync.Mutex
+	stats      map[prompting.IDType]ruleStats
+	statsDirty bool
+
 	dbPath string
 	// notifyRule is a closure which will be called to record a notice when a
 	// rule is added, patched, or removed.
@@ -186,6 +247,7 @@ func (rdb *RuleDB) load() (retErr error) {
 	rdb.indexByID = make(map[prompting.IDType]int)
 	rdb.rules = make([]*Rule, 0)
 	rdb.perUser = make(map[uint32]*userDB)
+	rdb.stats = make(map[prompting.IDType]ruleStats)
 
 	expiredRules := make(map[prompting.IDType]bool)
 	// Store map of merged rules, where the original merged (removed) rule ID
@@ -216,6 +278,9 @@ func (rdb *RuleDB) load() (retErr error) {
 
 	var errInvalid error
 	for _, rule := range wrapped.Rules {
+		// Rules saved before origins were recorded are all user rules, and
+		// the origin is always implied by the user anyway.
+		rule.Origin = originForUser(rule.User)
 		expired, err := rule.validate(currTime)
 		if err != nil {
 			// we're loading previously saved rules, so this should not happen
@@ -248,6 +313,7 @@ func (rdb *RuleDB) load() (retErr error) {
 		rdb.indexByID = make(map[prompting.IDType]int)
 		rdb.rules = make([]*Rule, 0)
 		rdb.perUser = make(map[uint32]*userDB)
+		rdb.stats = make(map[prompting.IDType]ruleStats)
 
 		// Save the empty rule DB to disk to overwrite the previous one which
 		// was invalid.
@@ -277,15 +343,67 @@ func (rdb *RuleDB) load() (retErr error) {
 
 // save writes the current state of the rule database to the database file.
 //
-// The caller must ensure that the database lock is held.
+// The caller must ensure that the database lock is held for writing.
 func (rdb *RuleDB) save() error {
+	rdb.syncStats()
 	b, err := json.Marshal(rulesDBJSON{Rules: rdb.rules})
 	if err != nil {
 		// Should not occur, marshalling should always succeed
 		logger.Noticef("cannot marshal rule DB: %v", err)
 		return fmt.Errorf("cannot marshal rule DB: %w", err)
 	}
-	return osutil.AtomicWriteFile(rdb.dbPath, b, 0o600, 0)
+	if err := osutil.AtomicWriteFile(rdb.dbPath, b, 0o600, 0); err != nil {
+		return err
+	}
+	rdb.statsMutex.Lock()
+	rdb.statsDirty = false
+	rdb.statsMutex.Unlock()
+	return nil
+}
+
+// syncStats copies the hit counts and last matched timestamps recorded since
+// the last call into the stored rules, and forgets those of the rules which
+// are no longer in the database.
+//
+// The caller must ensure that the database lock is held for writing.
+func (rdb *RuleDB) syncStats() {
+	rdb.statsMutex.Lock()
+	defer rdb.statsMutex.Unlock()
+	for id, stats := range rdb.stats {
+		rule, err := rdb.lookupRuleByID(id)
+		if err != nil {
+			delete(rdb.stats, id)
+			continue
+		}
+		rule.HitCount = stats.hitCount
+		rule.LastMatched = stats.lastMatched
+	}
+}
+
+// withStats returns a copy of the given rule holding its current hit count
+// and last matched timestamp, which can be used without holding any lock.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) withStats(rule *Rule) *Rule {
+	rdb.statsMutex.Lock()
+	defer rdb.statsMutex.Unlock()
+	ruleCopy := *rule
+	if stats, ok := rdb.stats[rule.ID]; ok {
+		ruleCopy.HitCount = stats.hitCount
+		ruleCopy.LastMatched = stats.lastMatched
+	}
+	return &ruleCopy
+}
+
+// allWithStats returns copies of the given rules as returned by withStats.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) allWithStats(rules []*Rule) []*Rule {
+	copies := make([]*Rule, len(rules))
+	for i, rule := range rules {
+		copies[i] = rdb.withStats(rule)
+	}
+	return copies
 }
 
 // lookupRuleByPathPattern checks whether there is an existing rule for the
@@ -475,6 +593,7 @@ func (rdb *RuleDB) addNewRule(rule *Rule, save bool) error {
 	if err := rdb.addRuleToRulesList(rule); err != nil {
 		return err
 	}
+	rdb.seedStats(rule)
 	conflictErr := rdb.addRuleToTree(rule)
 	if conflictErr != nil {
 		// remove just-added rule from rules list and IDs
@@ -498,6 +617,26 @@ func (rdb *RuleDB) addNewRule(rule *Rule, save bool) error {
 	return nil
 }
 
+// seedStats records the hit count and last matched timestamp held by the
+// given rule, unless some were already recorded for a rule with its ID, as
+// when a rule is re-added after being patched or merged.
+//
+// The caller must ensure that the database lock is held for writing.
+func (rdb *RuleDB) seedStats(rule *Rule) {
+	rdb.statsMutex.Lock()
+	defer rdb.statsMutex.Unlock()
+	if _, ok := rdb.stats[rule.ID]; ok {
+		return
+	}
+	if rule.HitCount == 0 && rule.LastMatched.IsZero() {
+		return
+	}
+	rdb.stats[rule.ID] = ruleStats{
+		hitCount:    rule.HitCount,
+		lastMatched: rule.LastMatched,
+	}
+}
+
 // removeRuleByIDFromRulesList removes the rule with the given ID from the rules
 // list in the rule DB, but not from the rules tree. Whenever possible, it is
 // preferred to use `removeRuleByID` directly instead, since it ensures
@@ -893,7 +1032,7 @@ func (rdb *RuleDB) AddRule(user uint32, snap string, iface string, constraints *
 	}
 
 	rdb.notifyRule(user, newRule.ID, nil)
-	return newRule, nil
+	return rdb.withStats(newRule), nil
 }
 
 // makeNewRule creates a new Rule with the given contents. It does not assign
@@ -915,12 +1054,131 @@ func (rdb *RuleDB) makeNewRule(user uint32, snap string, iface string, constrain
 		User:        user,
 		Snap:        snap,
 		Interface:   iface,
+		Origin:      originForUser(user),
 		Constraints: ruleConstraints,
 	}
 
 	return &newRule, nil
 }
 
+// ExportRules returns the rules stored for exactly the given user, in a form
+// which can be imported for another user or on another system. If snap or
+// iface is non-empty, only the rules for that snap or interface are exported.
+//
+// Permissions which have expired are omitted, and the remaining time of those
+// with a timespan lifespan is exported as their duration.
+func (rdb *RuleDB) ExportRules(user uint32, snap string, iface string) []*ExportedRule {
+	rdb.mutex.RLock()
+	defer rdb.mutex.RUnlock()
+	ruleFilter := func(rule *Rule) bool {
+		return rule.User == user && (snap == "" || rule.Snap == snap) && (iface == "" || rule.Interface == iface)
+	}
+	rules := rdb.rulesInternal(ruleFilter)
+	currTime := time.Now()
+	exported := make([]*ExportedRule, 0, len(rules))
+	for _, rule := range rules {
+		exported = append(exported, &ExportedRule{
+			Snap:        rule.Snap,
+			Interface:   rule.Interface,
+			Constraints: rule.Constraints.ToConstraints(currTime),
+		})
+	}
+	return exported
+}
+
+// ImportRules adds the given exported rules for the given user. A rule with
+// the same path pattern as an existing rule for the same snap and interface is
+// merged into it, as when adding a rule.
+//
+// Either all of the rules are imported or none are. If any rule is invalid,
+// returns an error. If any rules conflict with existing rules or with each
+// other, returns a prompting_errors.RuleConflictError holding the conflicts of
+// all of them. Otherwise, saves the database to disk and returns the rules
+// which were added or into which imported rules were merged.
+func (rdb *RuleDB) ImportRules(user uint32, exported []*ExportedRule) ([]*Rule, error) {
+	rdb.mutex.Lock()
+	defer rdb.mutex.Unlock()
+
+	if rdb.maxIDMmap.IsClosed() {
+		return nil, prompting_errors.ErrRulesClosed
+	}
+
+	origRules := make([]*Rule, len(rdb.rules))
+	copy(origRules, rdb.rules)
+
+	var imported []*Rule
+	var conflicts []prompting_errors.RuleConflict
+	for i, contents := range exported {
+		constraints := contents.Constraints
+		if constraints == nil {
+			constraints = &prompting.Constraints{}
+		}
+		newRule, err := rdb.makeNewRule(user, contents.Snap, contents.Interface, constraints)
+		if err != nil {
+			rdb.restoreRules(origRules)
+			return nil, fmt.Errorf("cannot import rule %d: %w", i, err)
+		}
+		const save = false
+		newRule, _, err = rdb.addOrMergeRule(newRule, save)
+		if err != nil {
+			var conflictErr *prompting_errors.RuleConflictError
+			if errors.As(err, &conflictErr) {
+				// Keep going, so that the conflicts of every rule are known
+				conflicts = append(conflicts, conflictErr.Conflicts...)
+				continue
+			}
+			rdb.restoreRules(origRules)
+			return nil, fmt.Errorf("cannot import rule %d: %w", i, err)
+		}
+		imported = append(imported, newRule)
+	}
+	if len(conflicts) > 0 {
+		rdb.restoreRules(origRules)
+		return nil, fmt.Errorf("cannot import rules: %w", &prompting_errors.RuleConflictError{
+			Conflicts: conflicts,
+		})
+	}
+
+	if err := rdb.save(); err != nil {
+		rdb.restoreRules(origRules)
+		return nil, err
+	}
+
+	// Imported rules may have been merged into other imported rules, so only
+	// return and notify the rules which ended up in the database.
+	var result []*Rule
+	for _, rule := range imported {
+		if current, err := rdb.lookupRuleByID(rule.ID); err != nil || current != rule {
+			continue
+		}
+		result = append(result, rdb.withStats(rule))
+		rdb.notifyRule(user, rule.ID, nil)
+	}
+	return result, nil
+}
+
+// restoreRules resets the rule DB to hold exactly the given rules, which must
+// have been held by the rule DB together before. Rules which have since
+// expired are not restored, since their removal has already been notified.
+//
+// The caller must ensure that the database lock is held for writing.
+func (rdb *RuleDB) restoreRules(rules []*Rule) {
+	rdb.indexByID = make(map[prompting.IDType]int)
+	rdb.rules = make([]*Rule, 0, len(rules))
+	rdb.perUser = make(map[uint32]*userDB)
+
+	currTime := time.Now()
+	for _, rule := range rules {
+		if rule.expired(currTime) {
+			continue
+		}
+		// Should not fail, since the rules did not conflict before
+		if err := rdb.addNewRule(rule, false); err != nil {
+			logger.Noticef("internal error: cannot restore rule %s: %v", rule.ID, err)
+		}
+	}
+}
+
 // IsRequestAllowed checks whether a request with the given parameters is
 // allowed or denied by existing rules.
 //
@@ -956,10 +1214,26 @@ var isPathPermAllowedByRuleDB = func(rdb *RuleDB, user uint32, snap string, ifac
 
 // isPathPermAllowed checks whether the given path with the given permission is
 // allowed or denied by existing rules for the given user, snap, and interface.
-// If no rule applies, returns prompting_errors.ErrNoMatchingRule.
+// Rules defined by an administrator for all users take precedence over those
+// of the given user. If no rule applies, returns
+// prompting_errors.ErrNoMatchingRule.
 func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, path string, permission string) (bool, error) {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
+	allowed, err := rdb.isPathPermAllowedForUser(prompting.AllUsers, snap, iface, path, permission)
+	if !errors.Is(err, prompting_errors.ErrNoMatchingRule) || user == prompting.AllUsers {
+		return allowed, err
+	}
+	return rdb.isPathPermAllowedForUser(user, snap, iface, path, permission)
+}
+
+// isPathPermAllowedForUser checks whether the given path with the given
+// permission is allowed or denied by the rules stored for exactly the given
+// user, snap, and interface. If no rule applies, returns
+// prompting_errors.ErrNoMatchingRule.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) isPathPermAllowedForUser(user uint32, snap string, iface string, path string, permission string) (bool, error) {
 	permissionMap := rdb.permissionDBForUserSnapInterfacePermission(user, snap, iface, permission)
 	if permissionMap == nil {
 		return false, prompting_errors.ErrNoMatchingRule
@@ -1001,27 +1275,108 @@ func (rdb *RuleDB) isPathPermAllowed(user uint32, snap string, iface string, pat
 		return false, err
 	}
 	matchingEntry := variantMap[highestPrecedenceVariant.String()]
+	rdb.recordMatch(matchingEntry, currTime)
 	return matchingEntry.Outcome.AsBool()
 }
 
+// recordMatch records that the non-expired rules in the given variant entry
+// matched a requested permission at the given time.
+//
+// The statistics are not saved to disk right away, but along with the next
+// change to the database, or when SaveStats or Close is called.
+//
+// The caller must ensure that the database lock is held.
+func (rdb *RuleDB) recordMatch(entry variantEntry, currTime time.Time) {
+	rdb.statsMutex.Lock()
+	defer rdb.statsMutex.Unlock()
+	for id, permEntry := range entry.RuleEntries {
+		if permEntry.Expired(currTime) {
+			continue
+		}
+		stats := rdb.stats[id]
+		stats.hitCount++
+		stats.lastMatched = currTime
+		rdb.stats[id] = stats
+		rdb.statsDirty = true
+	}
+}
+
+// SaveStats saves the database to disk if the hit counts or last matched
+// timestamps of any rules have changed since it was last saved.
+func (rdb *RuleDB) SaveStats() error {
+	rdb.mutex.Lock()
+	defer rdb.mutex.Unlock()
+
+	if rdb.maxIDMmap.IsClosed() {
+		return prompting_errors.ErrRulesClosed
+	}
+
+	rdb.statsMutex.Lock()
+	dirty := rdb.statsDirty
+	rdb.statsMutex.Unlock()
+	if !dirty {
+		return nil
+	}
+	return rdb.save()
+}
+
+// ExpireUnusedRules removes the rules which have neither matched a requested
+// permission nor been modified within the given duration, and records a
+// notice for each one. Rules defined by an administrator for all users are
+// never removed this way.
+//
+// If any rules were removed, saves the database to disk and returns them.
+func (rdb *RuleDB) ExpireUnusedRules(unusedFor time.Duration) ([]*Rule, error) {
+	rdb.mutex.Lock()
+	defer rdb.mutex.Unlock()
+
+	rdb.syncStats()
+	cutoff := time.Now().Add(-unusedFor)
+	ruleFilter := func(rule *Rule) bool {
+		return rule.User != prompting.AllUsers && rule.lastUsed().Before(cutoff)
+	}
+	rules := rdb.rulesInternal(ruleFilter)
+	data := map[string]string{"removed": "unused"}
+	if err := rdb.removeRulesInternal(rules, data); err != nil {
+		return nil, err
+	}
+	return rdb.allWithStats(rules), nil
+}
+
 // RuleWithID returns the rule with the given ID.
 // If the rule is not found, returns ErrRuleNotFound.
 // If the rule does not apply to the given user, returns
 // prompting_errors.ErrRuleNotAllowed.
+//
+// Rules defined by an administrator for all users apply to every user.
 func (rdb *RuleDB) RuleWithID(user uint32, id prompting.IDType) (*Rule, error) {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
-	return rdb.lookupRuleByIDForUser(user, id)
+	rule, err := rdb.lookupRuleByIDForUser(prompting.AllUsers, id)
+	if err != nil {
+		rule, err = rdb.lookupRuleByIDForUser(user, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rdb.withStats(rule), nil
+}
+
+// appliesToUser returns true if the given rule was defined by the given user,
+// or by an administrator for all users.
+func appliesToUser(rule *Rule, user uint32) bool {
+	return rule.User == user || rule.User == prompting.AllUsers
 }
 
-// Rules returns all rules which apply to the given user.
+// Rules returns all rules which apply to the given user, including those
+// defined by an administrator for all users.
 func (rdb *RuleDB) Rules(user uint32) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user
+		return appliesToUser(rule, user)
 	}
-	return rdb.rulesInternal(ruleFilter)
+	return rdb.allWithStats(rdb.rulesInternal(ruleFilter))
 }
 
 // rulesInternal returns all rules matching the given filter.
@@ -1051,36 +1406,37 @@ func (rdb *RuleDB) rulesInternal(ruleFilter func(rule *Rule) bool) []*Rule {
 	return rules
 }
 
-// RulesForSnap returns all rules which apply to the given user and snap.
+// RulesForSnap returns all rules which apply to the given user and snap,
+// including those defined by an administrator for all users.
 func (rdb *RuleDB) RulesForSnap(user uint32, snap string) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Snap == snap
+		return appliesToUser(rule, user) && rule.Snap == snap
 	}
-	return rdb.rulesInternal(ruleFilter)
+	return rdb.allWithStats(rdb.rulesInternal(ruleFilter))
 }
 
 // RulesForInterface returns all rules which apply to the given user and
-// interface.
+// interface, including those defined by an administrator for all users.
 func (rdb *RuleDB) RulesForInterface(user uint32, iface string) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Interface == iface
+		return appliesToUser(rule, user) && rule.Interface == iface
 	}
-	return rdb.rulesInternal(ruleFilter)
+	return rdb.allWithStats(rdb.rulesInternal(ruleFilter))
 }
 
 // RulesForSnapInterface returns all rules which apply to the given user, snap,
-// and interface.
+// and interface, including those defined by an administrator for all users.
 func (rdb *RuleDB) RulesForSnapInterface(user uint32, snap string, iface string) []*Rule {
 	rdb.mutex.RLock()
 	defer rdb.mutex.RUnlock()
 	ruleFilter := func(rule *Rule) bool {
-		return rule.User == user && rule.Snap == snap && rule.Interface == iface
+		return appliesToUser(rule, user) && rule.Snap == snap && rule.Interface == iface
 	}
-	return rdb.rulesInternal(ruleFilter)
+	return rdb.allWithStats(rdb.rulesInternal(ruleFilter))
 }
 
 // lookupRuleByIDForUser returns the rule with the given ID, if it exists, for the
@@ -1132,7 +1488,7 @@ func (rdb *RuleDB) RemoveRule(user uint32, id prompting.IDType) (*Rule, error) {
 
 	data := map[string]string{"removed": "removed"}
 	rdb.notifyRule(user, id, data)
-	return rule, nil
+	return rdb.withStats(rule), nil
 }
 
 // RemoveRulesForSnap removes all rules pertaining to the given snap for the
@@ -1144,17 +1500,18 @@ func (rdb *RuleDB) RemoveRulesForSnap(user uint32, snap string) ([]*Rule, error)
 		return rule.User == user && rule.Snap == snap
 	}
 	rules := rdb.rulesInternal(ruleFilter)
-	if err := rdb.removeRulesInternal(user, rules); err != nil {
+	data := map[string]string{"removed": "removed"}
+	if err := rdb.removeRulesInternal(rules, data); err != nil {
 		return nil, err
 	}
-	return rules, nil
+	return rdb.allWithStats(rules), nil
 }
 
 // removeRulesInternal removes all of the given rules from the rule DB and
-// records a notice for each one.
+// records a notice with the given data for each one.
 //
 // The caller must ensure that the database lock is held for writing.
-func (rdb *RuleDB) removeRulesInternal(user uint32, rules []*Rule) error {
+func (rdb *RuleDB) removeRulesInternal(rules []*Rule, data map[string]string) error {
 	if rdb.maxIDMmap.IsClosed() {
 		return prompting_errors.ErrRulesClosed
 	}
@@ -1181,12 +1538,11 @@ func (rdb *RuleDB) removeRulesInternal(user uint32, rules []*Rule) error {
 	}
 
 	// Save successful, now remove rules' variants from tree
-	data := map[string]string{"removed": "removed"}
 	for _, rule := range rules {
 		rdb.removeRuleFromTree(rule)
 		// If error occurs, rule was still fully removed from tree, and no other
 		// rule was affected. We want the rule fully removed, so this is fine.
-		rdb.notifyRule(user, rule.ID, data)
+		rdb.notifyRule(rule.User, rule.ID, data)
 	}
 	return nil
 }
@@ -1200,10 +1556,11 @@ func (rdb *RuleDB) RemoveRulesForInterface(user uint32, iface string) ([]*Rule,
 		return rule.User == user && rule.Interface == iface
 	}
 	rules := rdb.rulesInternal(ruleFilter)
-	if err := rdb.removeRulesInternal(user, rules); err != nil {
+	data := map[string]string{"removed": "removed"}
+	if err := rdb.removeRulesInternal(rules, data); err != nil {
 		return nil, err
 	}
-	return rules, nil
+	return rdb.allWithStats(rules), nil
 }
 
 // RemoveRulesForSnapInterface removes all rules pertaining to the given snap
@@ -1215,10 +1572,11 @@ func (rdb *RuleDB) RemoveRulesForSnapInterface(user uint32, snap string, iface s
 		return rule.User == user && rule.Snap == snap && rule.Interface == iface
 	}
 	rules := rdb.rulesInternal(ruleFilter)
-	if err := rdb.removeRulesInternal(user, rules); err != nil {
+	data := map[string]string{"removed": "removed"}
+	if err := rdb.removeRulesInternal(rules, data); err != nil {
 		return nil, err
 	}
-	return rules, nil
+	return rdb.allWithStats(rules), nil
 }
 
 // PatchRule modifies the rule with the given ID by updating the rule's
@@ -1278,7 +1636,10 @@ func (rdb *RuleDB) PatchRule(user uint32, id prompting.IDType, constraintsPatch
 		User:        origRule.User,
 		Snap:        origRule.Snap,
 		Interface:   origRule.Interface,
+		Origin:      origRule.Origin,
 		Constraints: ruleConstraints,
+		HitCount:    origRule.HitCount,
+		LastMatched: origRule.LastMatched,
 	}
 
 	// Remove the existing rule from the tree. An error should not occur, since
@@ -1301,5 +1662,5 @@ func (rdb *RuleDB) PatchRule(user uint32, id prompting.IDType, constraintsPatch
 	}
 
 	rdb.notifyRule(newRule.User, newRule.ID, nil)
-	return newRule, nil
+	return rdb.withStats(newRule), nil
 }
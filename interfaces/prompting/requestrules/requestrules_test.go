@@ -27,6 +27,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -261,6 +262,7 @@ func (s *requestrulesSuite) ruleTemplate(c *C, id prompting.IDType) *requestrule
 		User:        s.defaultUser,
 		Snap:        "firefox",
 		Interface:   "home",
+		Origin:      requestrules.OriginUser,
 		Constraints: &constraints,
 	}
 	return &rule
@@ -799,6 +801,17 @@ func (s *requestrulesSuite) TestAddRuleHappy(c *C) {
 // for every empty field in the partial contents, fills it with the details
 // from the template contents, and then calls rdb.AddRule with the fields from
 // the filled-in contents, and returns the results.
+// currentRules replaces the given rules with their current contents in the
+// rule DB, as the rules returned by the rule DB are copies, which do not
+// reflect later hits.
+func currentRules(c *C, rdb *requestrules.RuleDB, rules []*requestrules.Rule) {
+	for i, rule := range rules {
+		current, err := rdb.RuleWithID(rule.User, rule.ID)
+		c.Assert(err, IsNil)
+		rules[i] = current
+	}
+}
+
 func addRuleFromTemplate(c *C, rdb *requestrules.RuleDB, template *addRuleContents, partial *addRuleContents) (*requestrules.Rule, error) {
 	if partial == nil {
 		partial = &addRuleContents{}
@@ -1811,6 +1824,7 @@ func (s *requestrulesSuite) TestIsPathPermAllowedPrecedence(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(rule, NotNil)
 		addedRules = append(addedRules, rule)
+		currentRules(c, rdb, addedRules)
 		s.checkWrittenRuleDB(c, addedRules)
 		s.checkNewNoticesSimple(c, nil, rule)
 
@@ -1823,6 +1837,93 @@ func (s *requestrulesSuite) TestIsPathPermAllowedPrecedence(c *C) {
 	}
 }
 
+func (s *requestrulesSuite) TestAllUsersRules(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/.ssh/**",
+		Permissions: []string{"write"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+
+	// A user rule allowing writes
+	userRule, err := addRuleFromTemplate(c, rdb, template, nil)
+	c.Assert(err, IsNil)
+	c.Check(userRule.Origin, Equals, requestrules.OriginUser)
+	s.checkNewNoticesSimple(c, nil, userRule)
+
+	allowed, err := rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/.ssh/id_rsa", "write")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+
+	// An admin rule denying the same writes does not conflict with the user
+	// rule, and takes precedence over it, even though its pattern is broader
+	adminRule, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{
+		User:        prompting.AllUsers,
+		PathPattern: "/home/*/.ssh/**",
+		Outcome:     prompting.OutcomeDeny,
+	})
+	c.Assert(err, IsNil)
+	c.Check(adminRule.User, Equals, prompting.AllUsers)
+	c.Check(adminRule.Origin, Equals, requestrules.OriginAdmin)
+	s.checkNewNoticesSimple(c, nil, adminRule)
+	userRule, err = rdb.RuleWithID(s.defaultUser, userRule.ID)
+	c.Assert(err, IsNil)
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{userRule, adminRule})
+
+	for _, user := range []uint32{s.defaultUser, s.defaultUser + 1} {
+		allowed, err = rdb.IsPathPermAllowed(user, "firefox", "home", "/home/test/.ssh/id_rsa", "write")
+		c.Check(err, IsNil)
+		c.Check(allowed, Equals, false)
+	}
+	adminRule, err = rdb.RuleWithID(prompting.AllUsers, adminRule.ID)
+	c.Assert(err, IsNil)
+	// User rules still apply where no admin rule does
+	_, err = rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/.ssh/id_rsa", "read")
+	c.Check(err, Equals, prompting_errors.ErrNoMatchingRule)
+
+	// Admin rules are listed for every user, and only they are listed for
+	// all users
+	c.Check(rdb.Rules(s.defaultUser), DeepEquals, []*requestrules.Rule{userRule, adminRule})
+	c.Check(rdb.RulesForSnap(s.defaultUser+1, "firefox"), DeepEquals, []*requestrules.Rule{adminRule})
+	c.Check(rdb.RulesForInterface(prompting.AllUsers, "home"), DeepEquals, []*requestrules.Rule{adminRule})
+	rule, err := rdb.RuleWithID(s.defaultUser+1, adminRule.ID)
+	c.Check(err, IsNil)
+	c.Check(rule, DeepEquals, adminRule)
+	_, err = rdb.RuleWithID(prompting.AllUsers, userRule.ID)
+	c.Check(err, Equals, prompting_errors.ErrRuleNotAllowed)
+
+	// Users cannot modify or remove admin rules
+	_, err = rdb.PatchRule(s.defaultUser, adminRule.ID, nil)
+	c.Check(err, Equals, prompting_errors.ErrRuleNotAllowed)
+	_, err = rdb.RemoveRule(s.defaultUser, adminRule.ID)
+	c.Check(err, Equals, prompting_errors.ErrRuleNotAllowed)
+	removed, err := rdb.RemoveRulesForSnap(s.defaultUser, "firefox")
+	c.Check(err, IsNil)
+	c.Check(removed, DeepEquals, []*requestrules.Rule{userRule})
+	s.checkNewNoticesSimple(c, map[string]string{"removed": "removed"}, userRule)
+
+	// The origin is preserved when the rule is patched
+	patched, err := rdb.PatchRule(prompting.AllUsers, adminRule.ID, nil)
+	c.Assert(err, IsNil)
+	c.Check(patched.Origin, Equals, requestrules.OriginAdmin)
+	s.checkNewNoticesSimple(c, nil, patched)
+
+	// The origin is restored when the rules are loaded
+	c.Assert(rdb.Close(), IsNil)
+	rdb, err = requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	loaded := rdb.Rules(prompting.AllUsers)
+	c.Assert(loaded, HasLen, 1)
+	c.Check(loaded[0].ID, Equals, patched.ID)
+	c.Check(loaded[0].Origin, Equals, requestrules.OriginAdmin)
+}
+
 func (s *requestrulesSuite) TestIsPathPermAllowedExpiration(c *C) {
 	// Target
 	user := s.defaultUser
@@ -1984,6 +2085,7 @@ func (s *requestrulesSuite) prepRuleDBForRulesForSnapInterface(c *C, rdb *reques
 
 	// Change interface of rules[3]
 	addedRules[3].Interface = "audio-playback"
+	rdb.StoredRule(addedRules[3].ID).Interface = "audio-playback"
 
 	return addedRules
 }
@@ -2136,6 +2238,7 @@ func (s *requestrulesSuite) TestRemoveRuleErrors(c *C) {
 	// Internal errors while removing are ignored and the rule is still removed.
 	// Cause "path pattern variant maps to different rule ID"
 	addedRules[1].Snap = addedRules[0].Snap
+	rdb.StoredRule(addedRules[1].ID).Snap = addedRules[1].Snap
 	result, err = rdb.RemoveRule(addedRules[1].User, addedRules[1].ID)
 	c.Check(err, IsNil)
 	c.Check(result, DeepEquals, addedRules[1])
@@ -2146,6 +2249,7 @@ func (s *requestrulesSuite) TestRemoveRuleErrors(c *C) {
 	c.Check(result, DeepEquals, addedRules[2])
 	// Cause "no rules in rule tree for..."
 	addedRules[3].Snap = "invalid"
+	rdb.StoredRule(addedRules[3].ID).Snap = addedRules[3].Snap
 	result, err = rdb.RemoveRule(addedRules[3].User, addedRules[3].ID)
 	c.Check(err, IsNil)
 	c.Check(result, DeepEquals, addedRules[3])
@@ -2716,3 +2820,353 @@ func (s *requestrulesSuite) TestPatchRuleExpired(c *C) {
 	}
 	c.Check(patched, DeepEquals, rule)
 }
+
+func (s *requestrulesSuite) TestRuleStats(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/Downloads/**",
+		Permissions: []string{"read", "write"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	rule, err := addRuleFromTemplate(c, rdb, template, nil)
+	c.Assert(err, IsNil)
+	other, err := addRuleFromTemplate(c, rdb, template, &addRuleContents{PathPattern: "/home/test/Documents/**"})
+	c.Assert(err, IsNil)
+	c.Check(rule.HitCount, Equals, uint64(0))
+	c.Check(rule.LastMatched.IsZero(), Equals, true)
+
+	before := time.Now()
+	allowed, denied, outstanding, err := rdb.IsRequestAllowed(s.defaultUser, "firefox", "home", "/home/test/Downloads/foo.txt", []string{"read", "write", "execute"})
+	c.Assert(err, IsNil)
+	c.Check(allowed, DeepEquals, []string{"read", "write"})
+	c.Check(denied, Equals, false)
+	c.Check(outstanding, DeepEquals, []string{"execute"})
+
+	// Each matched permission counts as a hit
+	matched, err := rdb.RuleWithID(s.defaultUser, rule.ID)
+	c.Assert(err, IsNil)
+	c.Check(matched.HitCount, Equals, uint64(2))
+	c.Check(matched.LastMatched.Before(before), Equals, false)
+	unmatched, err := rdb.RuleWithID(s.defaultUser, other.ID)
+	c.Assert(err, IsNil)
+	c.Check(unmatched, DeepEquals, other)
+
+	// Rules returned before are copies, which are left unchanged
+	c.Check(rule.HitCount, Equals, uint64(0))
+	c.Check(rule.LastMatched.IsZero(), Equals, true)
+
+	// Stats are only written to disk when saved explicitly
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{rule, other})
+	c.Assert(rdb.SaveStats(), IsNil)
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{matched, other})
+
+	// Stats are preserved when the rule is patched
+	patched, err := rdb.PatchRule(s.defaultUser, rule.ID, &prompting.RuleConstraintsPatch{
+		PathPattern: mustParsePathPattern(c, "/home/test/{Downloads,Desktop}/**"),
+	})
+	c.Assert(err, IsNil)
+	c.Check(patched.HitCount, Equals, uint64(2))
+	c.Check(patched.LastMatched.Equal(matched.LastMatched), Equals, true)
+
+	// Stats are preserved when the rule DB is closed and reloaded
+	before = time.Now()
+	_, _, _, err = rdb.IsRequestAllowed(s.defaultUser, "firefox", "home", "/home/test/Desktop/foo.txt", []string{"read"})
+	c.Assert(err, IsNil)
+	c.Assert(rdb.Close(), IsNil)
+	c.Check(rdb.SaveStats(), Equals, prompting_errors.ErrRulesClosed)
+	rdb, err = requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+	loaded, err := rdb.RuleWithID(s.defaultUser, patched.ID)
+	c.Assert(err, IsNil)
+	c.Check(loaded.HitCount, Equals, uint64(3))
+	c.Check(loaded.LastMatched.Before(before), Equals, false)
+}
+
+func (s *requestrulesSuite) TestRuleStatsConcurrentAccess(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/Downloads/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	rule, err := addRuleFromTemplate(c, rdb, template, nil)
+	c.Assert(err, IsNil)
+
+	// Rules are matched while the rules returned by the rule DB are read,
+	// as when the daemon serves them, which must not race (run with -race).
+	const n = 100
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			_, err := rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/Downloads/foo.txt", "read")
+			c.Check(err, IsNil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; i++ {
+			for _, r := range rdb.Rules(s.defaultUser) {
+				_, err := json.Marshal(r)
+				c.Check(err, IsNil)
+			}
+			current, err := rdb.RuleWithID(s.defaultUser, rule.ID)
+			c.Check(err, IsNil)
+			_, err = json.Marshal(current)
+			c.Check(err, IsNil)
+		}
+	}()
+	wg.Wait()
+
+	current, err := rdb.RuleWithID(s.defaultUser, rule.ID)
+	c.Assert(err, IsNil)
+	c.Check(current.HitCount, Equals, uint64(n))
+}
+
+func (s *requestrulesSuite) TestExpireUnusedRules(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	var rules []*requestrules.Rule
+	for _, contents := range []*addRuleContents{
+		{PathPattern: "/home/test/used/**"},
+		{PathPattern: "/home/test/unused/**"},
+		{PathPattern: "/home/test/recent/**"},
+		{PathPattern: "/home/*/admin/**", User: prompting.AllUsers},
+	} {
+		rule, err := addRuleFromTemplate(c, rdb, template, contents)
+		c.Assert(err, IsNil)
+		rules = append(rules, rule)
+	}
+	s.checkNewNoticesSimple(c, nil, rules...)
+
+	// All but the third rule were last modified long ago
+	for _, rule := range []*requestrules.Rule{rules[0], rules[1], rules[3]} {
+		rule.Timestamp = rule.Timestamp.Add(-2 * time.Hour)
+		rdb.StoredRule(rule.ID).Timestamp = rule.Timestamp
+	}
+	allowed, err := rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/used/foo", "read")
+	c.Assert(err, IsNil)
+	c.Check(allowed, Equals, true)
+
+	removed, err := rdb.ExpireUnusedRules(time.Hour)
+	c.Assert(err, IsNil)
+	c.Check(removed, DeepEquals, []*requestrules.Rule{rules[1]})
+	s.checkNewNoticesSimple(c, map[string]string{"removed": "unused"}, rules[1])
+	_, err = rdb.RuleWithID(s.defaultUser, rules[1].ID)
+	c.Check(err, Equals, prompting_errors.ErrRuleNotFound)
+	c.Check(rdb.Rules(s.defaultUser), HasLen, 3)
+
+	removed, err = rdb.ExpireUnusedRules(time.Hour)
+	c.Assert(err, IsNil)
+	c.Check(removed, HasLen, 0)
+	s.checkNewNoticesSimple(c, nil)
+}
+
+func (s *requestrulesSuite) TestExportRules(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	template := &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/Downloads/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}
+	for _, contents := range []*addRuleContents{
+		{},
+		{Snap: "thunderbird", Outcome: prompting.OutcomeDeny, Lifespan: prompting.LifespanTimespan, Duration: "10m"},
+		{User: s.defaultUser + 1},
+		{User: prompting.AllUsers, PathPattern: "/home/*/.ssh/**"},
+	} {
+		_, err := addRuleFromTemplate(c, rdb, template, contents)
+		c.Assert(err, IsNil)
+	}
+
+	exported := rdb.ExportRules(s.defaultUser, "", "")
+	c.Assert(exported, HasLen, 2)
+	c.Check(exported[0], DeepEquals, &requestrules.ExportedRule{
+		Snap:      "firefox",
+		Interface: "home",
+		Constraints: &prompting.Constraints{
+			PathPattern: mustParsePathPattern(c, "/home/test/Downloads/**"),
+			Permissions: prompting.PermissionMap{
+				"read": &prompting.PermissionEntry{
+					Outcome:  prompting.OutcomeAllow,
+					Lifespan: prompting.LifespanForever,
+				},
+			},
+		},
+	})
+	c.Check(exported[1].Snap, Equals, "thunderbird")
+	entry := exported[1].Constraints.Permissions["read"]
+	c.Check(entry.Outcome, Equals, prompting.OutcomeDeny)
+	c.Check(entry.Lifespan, Equals, prompting.LifespanTimespan)
+	duration, err := time.ParseDuration(entry.Duration)
+	c.Assert(err, IsNil)
+	c.Check(duration > 9*time.Minute && duration <= 10*time.Minute, Equals, true, Commentf("%s", duration))
+
+	c.Check(rdb.ExportRules(s.defaultUser, "thunderbird", "home"), DeepEquals, exported[1:])
+	c.Check(rdb.ExportRules(s.defaultUser, "", "camera"), HasLen, 0)
+	c.Check(rdb.ExportRules(prompting.AllUsers, "", ""), HasLen, 1)
+
+	// Exported rules can be marshalled and unmarshalled
+	data, err := json.Marshal(exported)
+	c.Assert(err, IsNil)
+	var unmarshalled []*requestrules.ExportedRule
+	c.Assert(json.Unmarshal(data, &unmarshalled), IsNil)
+	c.Check(unmarshalled, DeepEquals, exported)
+}
+
+func (s *requestrulesSuite) TestImportRules(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	existing, err := addRuleFromTemplate(c, rdb, &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/Downloads/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}, nil)
+	c.Assert(err, IsNil)
+	s.checkNewNoticesSimple(c, nil, existing)
+
+	var toImport []*requestrules.ExportedRule
+	c.Assert(json.Unmarshal([]byte(`[
+		{
+			"snap": "firefox",
+			"interface": "home",
+			"constraints": {
+				"path-pattern": "/home/test/Downloads/**",
+				"permissions": {"write": {"outcome": "allow", "lifespan": "forever"}}
+			}
+		},
+		{
+			"snap": "firefox",
+			"interface": "home",
+			"constraints": {
+				"path-pattern": "/home/test/Documents/**",
+				"permissions": {"read": {"outcome": "deny", "lifespan": "timespan", "duration": "1h"}}
+			}
+		}
+	]`), &toImport), IsNil)
+
+	imported, err := rdb.ImportRules(s.defaultUser, toImport)
+	c.Assert(err, IsNil)
+	c.Assert(imported, HasLen, 2)
+	// The first rule was merged into the existing rule
+	c.Check(imported[0].ID, Equals, existing.ID)
+	c.Check(imported[0].Constraints.Permissions, HasLen, 2)
+	c.Check(imported[1].User, Equals, s.defaultUser)
+	c.Check(imported[1].Origin, Equals, requestrules.OriginUser)
+	s.checkNewNoticesSimple(c, nil, imported...)
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{imported[0], imported[1]})
+
+	allowed, err := rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/Downloads/foo", "write")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+	allowed, err = rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/Documents/foo", "read")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, false)
+}
+
+func (s *requestrulesSuite) TestImportRulesConflicts(c *C) {
+	rdb, err := requestrules.New(s.defaultNotifyRule)
+	c.Assert(err, IsNil)
+
+	existing, err := addRuleFromTemplate(c, rdb, &addRuleContents{
+		User:        s.defaultUser,
+		Snap:        "firefox",
+		Interface:   "home",
+		PathPattern: "/home/test/{Downloads,Documents}/**",
+		Permissions: []string{"read"},
+		Outcome:     prompting.OutcomeAllow,
+		Lifespan:    prompting.LifespanForever,
+	}, nil)
+	c.Assert(err, IsNil)
+	s.checkNewNoticesSimple(c, nil, existing)
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{existing})
+
+	makeExported := func(pathPattern string, outcome prompting.OutcomeType) *requestrules.ExportedRule {
+		return &requestrules.ExportedRule{
+			Snap:      "firefox",
+			Interface: "home",
+			Constraints: &prompting.Constraints{
+				PathPattern: mustParsePathPattern(c, pathPattern),
+				Permissions: prompting.PermissionMap{
+					"read": &prompting.PermissionEntry{
+						Outcome:  outcome,
+						Lifespan: prompting.LifespanForever,
+					},
+				},
+			},
+		}
+	}
+
+	toImport := []*requestrules.ExportedRule{
+		makeExported("/home/test/Pictures/**", prompting.OutcomeAllow),
+		makeExported("/home/test/Downloads/**", prompting.OutcomeDeny),
+		makeExported("/home/test/Music/**", prompting.OutcomeAllow),
+		makeExported("/home/test/{Documents,Music}/**", prompting.OutcomeDeny),
+	}
+	imported, err := rdb.ImportRules(s.defaultUser, toImport)
+	c.Check(imported, IsNil)
+	c.Assert(err, ErrorMatches, "cannot import rules: a rule with conflicting path pattern and permission already exists.*")
+	var conflictErr *prompting_errors.RuleConflictError
+	c.Assert(errors.As(err, &conflictErr), Equals, true)
+	c.Assert(conflictErr.Conflicts, HasLen, 3)
+	c.Check(conflictErr.Conflicts[0], DeepEquals, prompting_errors.RuleConflict{
+		Permission:    "read",
+		Variant:       "/home/test/Downloads/**",
+		ConflictingID: existing.ID.String(),
+	})
+
+	// Nothing was imported
+	s.checkNewNoticesSimple(c, nil)
+	s.checkWrittenRuleDB(c, []*requestrules.Rule{existing})
+	c.Check(rdb.Rules(s.defaultUser), DeepEquals, []*requestrules.Rule{existing})
+	_, err = rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/Pictures/foo", "read")
+	c.Check(err, Equals, prompting_errors.ErrNoMatchingRule)
+	allowed, err := rdb.IsPathPermAllowed(s.defaultUser, "firefox", "home", "/home/test/Documents/foo", "read")
+	c.Check(err, IsNil)
+	c.Check(allowed, Equals, true)
+	existing, err = rdb.RuleWithID(s.defaultUser, existing.ID)
+	c.Assert(err, IsNil)
+
+	// Invalid rules are rejected as well
+	invalid := makeExported("/home/test/Pictures/**", prompting.OutcomeAllow)
+	invalid.Interface = "camera"
+	_, err = rdb.ImportRules(s.defaultUser, []*requestrules.ExportedRule{toImport[0], invalid})
+	c.Check(err, ErrorMatches, `cannot import rule 1: .*`)
+	_, err = rdb.ImportRules(s.defaultUser, []*requestrules.ExportedRule{{Snap: "firefox", Interface: "home"}})
+	c.Check(err, ErrorMatches, `cannot import rule 0: invalid path pattern: no path pattern.*`)
+	s.checkNewNoticesSimple(c, nil)
+	c.Check(rdb.Rules(s.defaultUser), DeepEquals, []*requestrules.Rule{existing})
+}
@@ -0,0 +1,44 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build structuredlogging
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package logger
+
+import (
+	"io"
+	"net"
+
+	"github.com/snapcore/snapd/testutil"
+)
+
+func MockJournalSocket(path string) (restore func()) {
+	return testutil.Mock(&journalSocket, path)
+}
+
+func MockJournalDial(f func() (*net.UnixConn, error)) (restore func()) {
+	return testutil.Mock(&journalDial, f)
+}
+
+func MockStderrIsJournal(isJournal bool) (restore func()) {
+	return testutil.Mock(&stderrIsJournal, func() bool { return isJournal })
+}
+
+func NewJournalLogger(w io.Writer) Logger {
+	return newStructuredLog(newJournalHandler(w, DefaultFlags), DefaultFlags, &LoggerOptions{})
+}
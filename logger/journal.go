@@ -0,0 +1,191 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//go:build structuredlogging
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+var journalSocket = "/run/systemd/journal/socket"
+
+var journalDial = func() (*net.UnixConn, error) {
+	return net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+}
+
+// stderrIsJournal returns whether the standard error is connected to the
+// journal, as advertised by systemd with JOURNAL_STREAM.
+var stderrIsJournal = func() bool {
+	stream := os.Getenv("JOURNAL_STREAM")
+	if stream == "" {
+		return false
+	}
+	var dev, ino uint64
+	if _, err := fmt.Sscanf(stream, "%d:%d", &dev, &ino); err != nil {
+		return false
+	}
+	var st syscall.Stat_t
+	if err := syscall.Fstat(int(os.Stderr.Fd()), &st); err != nil {
+		return false
+	}
+	return uint64(st.Dev) == dev && uint64(st.Ino) == ino
+}
+
+// journalPriorities maps log levels to syslog priorities.
+var journalPriorities = map[slog.Level]int{
+	levelTrace:      7,
+	slog.LevelDebug: 7,
+	slog.LevelInfo:  6,
+	levelNotice:     5,
+	slog.LevelWarn:  4,
+	slog.LevelError: 3,
+}
+
+// journalHandler is a slog.Handler sending records to the journal using
+// its native protocol, so that attributes become journal fields. Records
+// that cannot be sent are written as JSON to the fallback handler instead.
+type journalHandler struct {
+	identifier string
+	attrs      []slog.Attr
+	fallback   slog.Handler
+	// conn is shared with the handlers derived with WithAttrs
+	conn *journalConn
+}
+
+// journalConn is the connection to the journal socket, dialed when first
+// needed.
+type journalConn struct {
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+func newJournalHandler(w io.Writer, flag int) *journalHandler {
+	return &journalHandler{
+		identifier: filepath.Base(os.Args[0]),
+		fallback:   newJSONHandler(w, flag),
+		conn:       &journalConn{},
+	}
+}
+
+func (h *journalHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *journalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	h2.fallback = h.fallback.WithAttrs(attrs)
+	return &h2
+}
+
+// WithGroup does not nest attributes as journal fields are flat.
+func (h *journalHandler) WithGroup(name string) slog.Handler {
+	return h
+}
+
+// journalFieldName turns an attribute key into a journal field name, which
+// can contain only uppercase letters, digits and underscores.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+	return "SNAPD_" + name
+}
+
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n", name, value)
+		return
+	}
+	// values with newlines use the binary format
+	buf.WriteString(name)
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+func (h *journalHandler) Handle(ctx context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", r.Message)
+	priority, ok := journalPriorities[r.Level]
+	if !ok {
+		priority = 6
+	}
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(priority))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", h.identifier)
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		writeJournalField(&buf, "CODE_FILE", filepath.Base(frame.File))
+		writeJournalField(&buf, "CODE_LINE", strconv.Itoa(frame.Line))
+		writeJournalField(&buf, "CODE_FUNC", frame.Function)
+	}
+	addAttr := func(a slog.Attr) bool {
+		writeJournalField(&buf, journalFieldName(a.Key), a.Value.Resolve().String())
+		return true
+	}
+	for _, a := range h.attrs {
+		addAttr(a)
+	}
+	r.Attrs(addAttr)
+
+	if err := h.conn.send(buf.Bytes()); err != nil {
+		return h.fallback.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (jc *journalConn) send(data []byte) error {
+	jc.mu.Lock()
+	defer jc.mu.Unlock()
+	if jc.conn == nil {
+		conn, err := journalDial()
+		if err != nil {
+			return err
+		}
+		jc.conn = conn
+	}
+	if _, err := jc.conn.Write(data); err != nil {
+		// dial again next time, the journal might have been restarted
+		jc.conn.Close()
+		jc.conn = nil
+		return err
+	}
+	return nil
+}
@@ -20,13 +20,15 @@
 // The logger package implements logging facilities for snapd.
 // When built with the structuredlogging build tag, it offers the ability
 // to use structured JSON for log entries and to turn on trace logging.
-// To activate JSON logging, the SNAPD_JSON_LOGGING environment variable
-// should be set at the time of logger creation. Trace logging can be
-// activated by setting the SNAPD_TRACE env variable.
+// To activate JSON logging, the SNAPD_LOG_FORMAT environment variable
+// should be set to "json" (or the legacy SNAPD_JSON_LOGGING one) at the
+// time of logger creation, or the Format logger option used. When writing
+// to the journal, structured log entries use native journal fields instead.
+// Trace logging can be activated by setting the SNAPD_TRACE env variable.
 //
 // When built without the structuredlogging build tag, the logger package
-// offers only the simple logger and will not activate trace logging even
-// if the SNAPD_TRACE env variable is set.
+// offers only the simple logger and will not activate JSON or trace logging
+// even if the corresponding env variables are set.
 package logger
 
 import (
@@ -138,6 +140,75 @@ func NoGuardDebugf(format string, v ...interface{}) {
 	logger.NoGuardDebug(msg)
 }
 
+// attrLogger is implemented by loggers that can add key/value attributes to
+// the records of the messages.
+type attrLogger interface {
+	NoticeAttrs(msg string, attrs []any)
+	DebugAttrs(msg string, attrs []any)
+}
+
+// AttrsEnabled returns whether the current logger adds key/value attributes
+// to the records of the messages, that is whether structured logging is used.
+func AttrsEnabled() bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	_, ok := logger.(attrLogger)
+	return ok
+}
+
+// AttrLogger logs messages with key/value attributes, such as the change
+// and the task being run. The attributes are added to the records when
+// structured logging is used and are otherwise ignored.
+type AttrLogger struct {
+	attrs []any
+}
+
+// WithAttrs returns an AttrLogger adding the given key/value pairs to the
+// records of the messages it logs.
+func WithAttrs(attrs ...any) *AttrLogger {
+	return &AttrLogger{attrs: attrs}
+}
+
+// WithAttrs returns an AttrLogger adding the given key/value pairs in
+// addition to the ones of l.
+func (l *AttrLogger) WithAttrs(attrs ...any) *AttrLogger {
+	combined := make([]any, 0, len(l.attrs)+len(attrs))
+	combined = append(combined, l.attrs...)
+	return &AttrLogger{attrs: append(combined, attrs...)}
+}
+
+// Attrs returns the key/value pairs added by l.
+func (l *AttrLogger) Attrs() []any {
+	return l.attrs
+}
+
+// Noticef notifies the user of something
+func (l *AttrLogger) Noticef(format string, v ...any) {
+	msg := fmt.Sprintf(format, v...)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if al, ok := logger.(attrLogger); ok {
+		al.NoticeAttrs(msg, l.attrs)
+	} else {
+		logger.Notice(msg)
+	}
+}
+
+// Debugf records something in the debug log
+func (l *AttrLogger) Debugf(format string, v ...any) {
+	msg := fmt.Sprintf(format, v...)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if al, ok := logger.(attrLogger); ok {
+		al.DebugAttrs(msg, l.attrs)
+	} else {
+		logger.Debug(msg)
+	}
+}
+
 // MockLogger replaces the existing logger with a buffer and returns
 // the log buffer and a restore function.
 func MockLogger() (buf *bytes.Buffer, restore func()) {
@@ -227,10 +298,42 @@ func newLog(w io.Writer, flag int, opts *LoggerOptions) Logger {
 	return logger
 }
 
+const (
+	// TextFormat is the format of the simple logger.
+	TextFormat = "text"
+	// JSONFormat is the format of the structured logger.
+	JSONFormat = "json"
+)
+
 type LoggerOptions struct {
 	// ForceDebug can be set if we want debug traces even if not directly
 	// enabled by environment or kernel command line.
 	ForceDebug bool
+	// Format is either TextFormat or JSONFormat. If unset, the format is
+	// taken from the SNAPD_LOG_FORMAT environment variable.
+	Format string
+}
+
+func (opts *LoggerOptions) structured() bool {
+	switch opts.Format {
+	case JSONFormat:
+		return true
+	case TextFormat:
+		return false
+	}
+	return os.Getenv("SNAPD_LOG_FORMAT") == JSONFormat || osutil.GetenvBool("SNAPD_JSON_LOGGING")
+}
+
+// New creates a log.Logger using the given io.Writer and flag, using the
+// options from opts.
+func New(w io.Writer, flag int, opts *LoggerOptions) Logger {
+	if opts == nil {
+		opts = &LoggerOptions{}
+	}
+	if !opts.structured() {
+		return newLog(w, flag, opts)
+	}
+	return newStructured(w, flag, opts)
 }
 
 func buildFlags() int {
@@ -244,8 +347,16 @@ func buildFlags() int {
 
 // SimpleSetup creates the default (console) logger
 func SimpleSetup(opts *LoggerOptions) {
+	if opts == nil {
+		opts = &LoggerOptions{}
+	}
 	flags := buildFlags()
-	l := New(os.Stderr, flags, opts)
+	var l Logger
+	if opts.structured() {
+		l = newStructuredConsole(flags, opts)
+	} else {
+		l = newLog(os.Stderr, flags, opts)
+	}
 	SetLogger(l)
 }
 
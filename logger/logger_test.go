@@ -246,3 +246,22 @@ func (s *LogSuite) TestMockDebugLogger(c *C) {
 	logger.Debugf("xyzzy")
 	c.Check(logbuf.String(), testutil.Contains, "DEBUG: xyzzy")
 }
+
+func (s *LogSuite) TestAttrLoggerText(c *C) {
+	c.Check(logger.AttrsEnabled(), Equals, false)
+
+	l := logger.WithAttrs("change-id", "1").WithAttrs("task-id", "2")
+	c.Check(l.Attrs(), DeepEquals, []any{"change-id", "1", "task-id", "2"})
+
+	l.Noticef("xyzzy %d", 42)
+	c.Check(s.logbuf.String(), Matches, `(?m).*logger_test\.go:\d+: xyzzy 42\n`)
+
+	s.logbuf.Reset()
+	l.Debugf("quux")
+	c.Check(s.logbuf.String(), Equals, "")
+
+	os.Setenv("SNAPD_DEBUG", "1")
+	defer os.Unsetenv("SNAPD_DEBUG")
+	l.Debugf("quux")
+	c.Check(s.logbuf.String(), Matches, `(?m).*logger_test\.go:\d+: DEBUG: quux\n`)
+}
@@ -22,13 +22,22 @@ package logger
 
 import (
 	"io"
+	"os"
 )
 
-// New creates a log.Logger using the given io.Writer and flag, using the
-// options from opts.
-func New(w io.Writer, flag int, opts *LoggerOptions) Logger {
-	if opts == nil {
-		opts = &LoggerOptions{}
-	}
+// Structured logging is only available when built with the
+// structuredlogging build tag, the simple logger is used instead.
+
+// StructuredLoggingSupported returns whether snapd was built with support
+// for structured logging.
+func StructuredLoggingSupported() bool {
+	return false
+}
+
+func newStructured(w io.Writer, flag int, opts *LoggerOptions) Logger {
 	return newLog(w, flag, opts)
 }
+
+func newStructuredConsole(flag int, opts *LoggerOptions) Logger {
+	return newLog(os.Stderr, flag, opts)
+}
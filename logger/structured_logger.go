@@ -25,6 +25,7 @@ import (
 	"io"
 	"log"
 	"log/slog"
+	"os"
 	"path/filepath"
 	"runtime"
 	"time"
@@ -32,6 +33,12 @@ import (
 	"github.com/snapcore/snapd/osutil"
 )
 
+// StructuredLoggingSupported returns whether snapd was built with support
+// for structured logging.
+func StructuredLoggingSupported() bool {
+	return true
+}
+
 type StructuredLog struct {
 	log   *slog.Logger
 	debug bool
@@ -54,33 +61,51 @@ func (l *StructuredLog) debugEnabled() bool {
 	return l.debug || osutil.GetenvBool("SNAPD_DEBUG") || l.traceEnabled()
 }
 
+// handle logs a record with the source set to the caller of the package
+// level API function.
+func (l *StructuredLog) handle(level slog.Level, msg string, attrs []any) {
+	var pcs [1]uintptr
+	// runtime.Callers + this frame + method + single package level API
+	// func() + actual caller
+	runtime.Callers(4, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(attrs...)
+	l.log.Handler().Handle(context.Background(), r)
+}
+
 // Debug only prints if SNAPD_DEBUG or SNAPD_TRACE is set
 func (l *StructuredLog) Debug(msg string) {
 	if l.debugEnabled() {
-		var pcs [1]uintptr
-		runtime.Callers(3, pcs[:])
-		r := slog.NewRecord(time.Now(), slog.LevelDebug, msg, pcs[0])
-		l.log.Handler().Handle(context.Background(), r)
+		l.handle(slog.LevelDebug, msg, nil)
+	}
+}
+
+// DebugAttrs is like Debug but adds the given key/value pairs to the record.
+func (l *StructuredLog) DebugAttrs(msg string, attrs []any) {
+	if l.debugEnabled() {
+		l.handle(slog.LevelDebug, msg, attrs)
 	}
 }
 
 // Notice alerts the user about something, as well as putting in syslog
 func (l *StructuredLog) Notice(msg string) {
 	if !l.quiet {
-		var pcs [1]uintptr
-		runtime.Callers(3, pcs[:])
-		r := slog.NewRecord(time.Now(), levelNotice, msg, pcs[0])
-		l.log.Handler().Handle(context.Background(), r)
+		l.handle(levelNotice, msg, nil)
+	}
+}
+
+// NoticeAttrs is like Notice but adds the given key/value pairs to the
+// record.
+func (l *StructuredLog) NoticeAttrs(msg string, attrs []any) {
+	if !l.quiet {
+		l.handle(levelNotice, msg, attrs)
 	}
 }
 
 // NoGuardDebug always prints the message, w/o gating it based on environment
 // variables or other configurations.
 func (l *StructuredLog) NoGuardDebug(msg string) {
-	var pcs [1]uintptr
-	runtime.Callers(3, pcs[:])
-	r := slog.NewRecord(time.Now(), slog.LevelDebug, msg, pcs[0])
-	l.log.Handler().Handle(context.Background(), r)
+	l.handle(slog.LevelDebug, msg, nil)
 }
 
 func (l *StructuredLog) traceEnabled() bool {
@@ -97,23 +122,11 @@ func (l *StructuredLog) traceEnabled() bool {
 // Trace only prints if SNAPD_TRACE is set and structured logging is active
 func (l *StructuredLog) Trace(msg string, attrs ...any) {
 	if l.traceEnabled() {
-		var pcs [1]uintptr
-		runtime.Callers(3, pcs[:])
-		r := slog.NewRecord(time.Now(), levelTrace, msg, pcs[0])
-		r.Add(attrs...)
-		l.log.Handler().Handle(context.Background(), r)
+		l.handle(levelTrace, msg, attrs)
 	}
 }
 
-// // New creates a log.Logger using the given io.Writer and flag, using the
-// // options from opts.
-func New(w io.Writer, flag int, opts *LoggerOptions) Logger {
-	if opts == nil {
-		opts = &LoggerOptions{}
-	}
-	if !osutil.GetenvBool("SNAPD_JSON_LOGGING") {
-		return newLog(w, flag, opts)
-	}
+func newJSONHandler(w io.Writer, flag int) slog.Handler {
 	options := &slog.HandlerOptions{
 		AddSource: true,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
@@ -152,11 +165,28 @@ func New(w io.Writer, flag int, opts *LoggerOptions) Logger {
 			return a
 		},
 	}
-	logger := &StructuredLog{
-		log:   slog.New(slog.NewJSONHandler(w, options)),
+	return slog.NewJSONHandler(w, options)
+}
+
+// newStructured returns a structured logger writing JSON to w.
+func newStructured(w io.Writer, flag int, opts *LoggerOptions) Logger {
+	return newStructuredLog(newJSONHandler(w, flag), flag, opts)
+}
+
+// newStructuredConsole returns a structured logger writing to the standard
+// error, which uses native journal fields when connected to the journal.
+func newStructuredConsole(flag int, opts *LoggerOptions) Logger {
+	if stderrIsJournal() {
+		return newStructuredLog(newJournalHandler(os.Stderr, flag), flag, opts)
+	}
+	return newStructured(os.Stderr, flag, opts)
+}
+
+func newStructuredLog(h slog.Handler, flag int, opts *LoggerOptions) Logger {
+	return &StructuredLog{
+		log:   slog.New(h),
 		debug: opts.ForceDebug || debugEnabledOnKernelCmdline(),
 		flags: flag,
 		trace: false,
 	}
-	return logger
 }
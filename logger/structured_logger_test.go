@@ -23,7 +23,9 @@ package logger_test
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -284,3 +286,145 @@ func (s *LogStructuredSuite) TestMockDebugLoggerStructured(c *C) {
 	c.Check(data.Level, Equals, "DEBUG")
 	c.Check(data.Msg, Equals, "xyzzy")
 }
+
+func (s *LogStructuredSuite) TestLogFormatEnv(c *C) {
+	os.Setenv("SNAPD_LOG_FORMAT", "json")
+	defer os.Unsetenv("SNAPD_LOG_FORMAT")
+
+	var buf bytes.Buffer
+	l := logger.New(&buf, logger.DefaultFlags, nil)
+	l.Notice("xyzzy")
+
+	var data map[string]any
+	c.Assert(json.Unmarshal(buf.Bytes(), &data), IsNil)
+	c.Check(data["msg"], Equals, "xyzzy")
+	c.Check(data["level"], Equals, "NOTICE")
+
+	// the option takes precedence over the environment
+	buf.Reset()
+	l = logger.New(&buf, logger.DefaultFlags, &logger.LoggerOptions{Format: logger.TextFormat})
+	l.Notice("xyzzy")
+	c.Check(buf.String(), Matches, `(?m).*: xyzzy\n`)
+}
+
+func (s *LogStructuredSuite) TestLogFormatOption(c *C) {
+	var buf bytes.Buffer
+	l := logger.New(&buf, logger.DefaultFlags, &logger.LoggerOptions{Format: logger.JSONFormat})
+	l.Notice("xyzzy")
+
+	var data map[string]any
+	c.Assert(json.Unmarshal(buf.Bytes(), &data), IsNil)
+	c.Check(data["msg"], Equals, "xyzzy")
+}
+
+func (s *LogStructuredSuite) TestAttrLoggerJSON(c *C) {
+	var buf bytes.Buffer
+	logger.SetLogger(logger.New(&buf, logger.DefaultFlags, &logger.LoggerOptions{Format: logger.JSONFormat, ForceDebug: true}))
+	c.Check(logger.AttrsEnabled(), Equals, true)
+
+	l := logger.WithAttrs("change-id", "1", "task-kind", "link-snap")
+	l.Noticef("xyzzy")
+	l.Debugf("quux")
+
+	dec := json.NewDecoder(&buf)
+	for _, expected := range []struct{ msg, level string }{
+		{"xyzzy", "NOTICE"},
+		{"quux", "DEBUG"},
+	} {
+		var data struct {
+			Msg      string `json:"msg"`
+			Level    string `json:"level"`
+			ChangeID string `json:"change-id"`
+			TaskKind string `json:"task-kind"`
+			Source   struct {
+				File string `json:"file"`
+			} `json:"source"`
+		}
+		c.Assert(dec.Decode(&data), IsNil)
+		c.Check(data.Msg, Equals, expected.msg)
+		c.Check(data.Level, Equals, expected.level)
+		c.Check(data.ChangeID, Equals, "1")
+		c.Check(data.TaskKind, Equals, "link-snap")
+		c.Check(data.Source.File, Equals, "structured_logger_test.go")
+	}
+}
+
+func (s *LogStructuredSuite) TestSimpleSetupJournal(c *C) {
+	restore := logger.MockStderrIsJournal(true)
+	defer restore()
+
+	logger.SimpleSetup(nil)
+	c.Check(logger.GetLoggerFlags(), Not(Equals), -1)
+
+	logger.SimpleSetup(&logger.LoggerOptions{Format: logger.JSONFormat})
+	c.Check(logger.GetLogger(), FitsTypeOf, &logger.StructuredLog{})
+}
+
+func (s *LogStructuredSuite) TestJournalLogger(c *C) {
+	sock := filepath.Join(c.MkDir(), "socket")
+	restore := logger.MockJournalSocket(sock)
+	defer restore()
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	c.Assert(err, IsNil)
+	defer conn.Close()
+
+	var buf bytes.Buffer
+	logger.SetLogger(logger.NewJournalLogger(&buf))
+	logger.WithAttrs("change-id", "1", "task-kind", "link-snap").Noticef("hello\nworld")
+
+	dgram := make([]byte, 4096)
+	n, err := conn.Read(dgram)
+	c.Assert(err, IsNil)
+	data := string(dgram[:n])
+	c.Check(data, Matches, `(?s)MESSAGE\n.{8}hello\nworld\nPRIORITY=5\nSYSLOG_IDENTIFIER=.*\nCODE_FILE=structured_logger_test.go\nCODE_LINE=\d+\nCODE_FUNC=.*TestJournalLogger\nSNAPD_CHANGE_ID=1\nSNAPD_TASK_KIND=link-snap\n`)
+	c.Check(buf.String(), Equals, "")
+}
+
+func (s *LogStructuredSuite) TestJournalLoggerSharesConnection(c *C) {
+	sock := filepath.Join(c.MkDir(), "socket")
+	restore := logger.MockJournalSocket(sock)
+	defer restore()
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	c.Assert(err, IsNil)
+	defer conn.Close()
+
+	var dialed []*net.UnixConn
+	restore = logger.MockJournalDial(func() (*net.UnixConn, error) {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: sock, Net: "unixgram"})
+		if err == nil {
+			dialed = append(dialed, conn)
+		}
+		return conn, err
+	})
+	defer restore()
+
+	var buf bytes.Buffer
+	logger.SetLogger(logger.NewJournalLogger(&buf))
+	dgram := make([]byte, 4096)
+	for i := 0; i < 3; i++ {
+		logger.WithAttrs("change-id", fmt.Sprint(i)).Noticef("hello")
+		_, err := conn.Read(dgram)
+		c.Assert(err, IsNil)
+	}
+	logger.Noticef("hello")
+	_, err = conn.Read(dgram)
+	c.Assert(err, IsNil)
+
+	// derived loggers use the same connection
+	c.Check(dialed, HasLen, 1)
+	c.Check(buf.String(), Equals, "")
+}
+
+func (s *LogStructuredSuite) TestJournalLoggerFallback(c *C) {
+	restore := logger.MockJournalSocket(filepath.Join(c.MkDir(), "missing"))
+	defer restore()
+
+	var buf bytes.Buffer
+	logger.SetLogger(logger.NewJournalLogger(&buf))
+	logger.WithAttrs("change-id", "1").Noticef("xyzzy")
+
+	var data map[string]any
+	c.Assert(json.Unmarshal(buf.Bytes(), &data), IsNil)
+	c.Check(data["msg"], Equals, "xyzzy")
+	c.Check(data["change-id"], Equals, "1")
+}
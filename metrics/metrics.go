@@ -0,0 +1,349 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package metrics implements counters, histograms and gauges about the
+// internals of snapd that can be exported in the OpenMetrics text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType is the content type of the OpenMetrics text format.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of the buckets of
+// histograms of durations.
+var DefaultBuckets = []float64{.0005, .001, .005, .01, .05, .1, .5, 1, 5, 10, 60}
+
+// Metric is a family of samples with the same name, one for each
+// combination of the values of its labels.
+type Metric interface {
+	// Name returns the name of the metric.
+	Name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics to be exported together.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]Metric
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]Metric)}
+}
+
+// Register adds the given metric to the registry, replacing any metric
+// previously registered with the same name.
+func (r *Registry) Register(m Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[m.Name()] = m
+}
+
+// Write writes all the registered metrics to w in the OpenMetrics text
+// format, sorted by name.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	metrics := make([]Metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mu.Unlock()
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name() < metrics[j].Name() })
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+// DefaultRegistry is the registry the metrics created by NewCounter,
+// NewHistogram and NewGaugeFunc are registered with.
+var DefaultRegistry = NewRegistry()
+
+// Write writes the metrics in DefaultRegistry to w.
+func Write(w io.Writer) error {
+	return DefaultRegistry.Write(w)
+}
+
+var enabled int32
+
+// SetEnabled sets whether metrics that are costly to observe on hot
+// paths, such as the timing of the state lock, are collected.
+func SetEnabled(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&enabled, v)
+}
+
+// Enabled returns whether metrics that are costly to observe on hot paths
+// should be collected.
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+type desc struct {
+	name       string
+	help       string
+	labelNames []string
+}
+
+func (d *desc) Name() string {
+	return d.name
+}
+
+func (d *desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labelNames) {
+		panic(fmt.Sprintf("internal error: metric %s has %d labels, got %d values", d.name, len(d.labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+func (d *desc) writeHeader(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, typ)
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+}
+
+// writeSample writes a sample line, with the given extra label if name is
+// not empty.
+func (d *desc) writeSample(w *bufio.Writer, suffix string, labelValues []string, extraName, extraValue string, v float64) {
+	w.WriteString(d.name)
+	w.WriteString(suffix)
+	if len(d.labelNames) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, name := range d.labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", name, escapeLabelValue(labelValues[i]))
+		}
+		if extraName != "" {
+			if len(d.labelNames) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of the given map sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counter is a metric whose values only go up.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	v           float64
+}
+
+// NewCounter returns a new counter with the given labels, registered with
+// DefaultRegistry. By convention the name of counters ends in "_total".
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{
+		desc:   desc{name: name, help: help, labelNames: labelNames},
+		values: make(map[string]*counterValue),
+	}
+	DefaultRegistry.Register(c)
+	return c
+}
+
+// Add adds v, which must not be negative, to the counter for the given
+// label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("internal error: cannot decrease counter %s", c.name))
+	}
+	key := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cv := c.values[key]
+	if cv == nil {
+		cv = &counterValue{labelValues: labelValues}
+		c.values[key] = cv
+	}
+	cv.v += v
+}
+
+// Inc adds one to the counter for the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	// the name of the family doesn't include the suffix of its samples
+	d := c.desc
+	d.name = strings.TrimSuffix(d.name, "_total")
+	d.writeHeader(w, "counter")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		cv := c.values[key]
+		d.writeSample(w, "_total", cv.labelValues, "", "", cv.v)
+	}
+}
+
+// Histogram is a metric that counts observed values, such as durations,
+// in buckets.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// NewHistogram returns a new histogram with the given bucket upper bounds,
+// in increasing order, and labels, registered with DefaultRegistry.
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	h := &Histogram{
+		desc:    desc{name: name, help: help, labelNames: labelNames},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	DefaultRegistry.Register(h)
+	return h
+}
+
+// Observe adds the value v to the histogram for the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv := h.values[key]
+	if hv == nil {
+		hv = &histogramValue{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+	// buckets are cumulative
+	for i := len(h.buckets) - 1; i >= 0 && v <= h.buckets[i]; i-- {
+		hv.counts[i]++
+	}
+	hv.count++
+	hv.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.writeHeader(w, "histogram")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.values) {
+		hv := h.values[key]
+		for i, le := range h.buckets {
+			h.writeSample(w, "_bucket", hv.labelValues, "le", formatFloat(le), float64(hv.counts[i]))
+		}
+		h.writeSample(w, "_bucket", hv.labelValues, "le", "+Inf", float64(hv.count))
+		h.writeSample(w, "_count", hv.labelValues, "", "", float64(hv.count))
+		h.writeSample(w, "_sum", hv.labelValues, "", "", hv.sum)
+	}
+}
+
+// GaugeFunc is a metric whose values are computed when it is exported.
+type GaugeFunc struct {
+	desc
+	collect func(set func(v float64, labelValues ...string))
+}
+
+// NewGaugeFunc returns a new gauge with the given labels, registered with
+// DefaultRegistry. When the gauge is exported, collect is called to set its
+// value for every combination of label values of interest.
+func NewGaugeFunc(name, help string, labelNames []string, collect func(set func(v float64, labelValues ...string))) *GaugeFunc {
+	g := &GaugeFunc{
+		desc:    desc{name: name, help: help, labelNames: labelNames},
+		collect: collect,
+	}
+	DefaultRegistry.Register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	values := make(map[string]*counterValue)
+	g.collect(func(v float64, labelValues ...string) {
+		values[g.key(labelValues)] = &counterValue{labelValues: labelValues, v: v}
+	})
+
+	g.writeHeader(w, "gauge")
+	for _, key := range sortedKeys(values) {
+		gv := values[key]
+		g.writeSample(w, "", gv.labelValues, "", "", gv.v)
+	}
+}
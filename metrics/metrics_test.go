@@ -0,0 +1,138 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/metrics"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type metricsSuite struct {
+	testutil.BaseTest
+}
+
+var _ = Suite(&metricsSuite{})
+
+func (s *metricsSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.AddCleanup(testutil.Backup(&metrics.DefaultRegistry))
+	metrics.DefaultRegistry = metrics.NewRegistry()
+}
+
+func (s *metricsSuite) written(c *C) string {
+	var buf bytes.Buffer
+	c.Assert(metrics.Write(&buf), IsNil)
+	return buf.String()
+}
+
+func (s *metricsSuite) TestEmpty(c *C) {
+	c.Check(s.written(c), Equals, "# EOF\n")
+}
+
+func (s *metricsSuite) TestCounter(c *C) {
+	ctr := metrics.NewCounter("foo_total", "Foo things,\nwith a \\.", "kind", "status")
+	ctr.Inc("b", "Done")
+	ctr.Add(2.5, "a", `"x"`)
+	ctr.Inc("b", "Done")
+	metrics.NewCounter("bar_total", "Bar.").Inc()
+
+	c.Check(s.written(c), Equals, `# TYPE bar counter
+# HELP bar Bar.
+bar_total 1
+# TYPE foo counter
+# HELP foo Foo things,\nwith a \\.
+foo_total{kind="a",status="\"x\""} 2.5
+foo_total{kind="b",status="Done"} 2
+# EOF
+`)
+}
+
+func (s *metricsSuite) TestCounterErrors(c *C) {
+	ctr := metrics.NewCounter("foo_total", "Foo.", "kind")
+	c.Check(func() { ctr.Inc() }, PanicMatches, `internal error: metric foo_total has 1 labels, got 0 values`)
+	c.Check(func() { ctr.Add(-1, "a") }, PanicMatches, `internal error: cannot decrease counter foo_total`)
+}
+
+func (s *metricsSuite) TestEnabled(c *C) {
+	defer metrics.SetEnabled(false)
+
+	c.Check(metrics.Enabled(), Equals, false)
+	metrics.SetEnabled(true)
+	c.Check(metrics.Enabled(), Equals, true)
+	metrics.SetEnabled(false)
+	c.Check(metrics.Enabled(), Equals, false)
+}
+
+func (s *metricsSuite) TestHistogram(c *C) {
+	h := metrics.NewHistogram("foo_seconds", "Foo durations.", []float64{0.1, 1}, "manager")
+	h.Observe(0.05, "a")
+	h.Observe(0.5, "a")
+	h.Observe(2, "a")
+	h.Observe(1, "b")
+
+	c.Check(s.written(c), Equals, `# TYPE foo_seconds histogram
+# HELP foo_seconds Foo durations.
+foo_seconds_bucket{manager="a",le="0.1"} 1
+foo_seconds_bucket{manager="a",le="1"} 2
+foo_seconds_bucket{manager="a",le="+Inf"} 3
+foo_seconds_count{manager="a"} 3
+foo_seconds_sum{manager="a"} 2.55
+foo_seconds_bucket{manager="b",le="0.1"} 0
+foo_seconds_bucket{manager="b",le="1"} 1
+foo_seconds_bucket{manager="b",le="+Inf"} 1
+foo_seconds_count{manager="b"} 1
+foo_seconds_sum{manager="b"} 1
+# EOF
+`)
+}
+
+func (s *metricsSuite) TestGaugeFunc(c *C) {
+	n := 0
+	metrics.NewGaugeFunc("foo", "Foo.", []string{"kind"}, func(set func(v float64, labelValues ...string)) {
+		n++
+		set(float64(n), "b")
+		set(3, "a")
+	})
+
+	c.Check(s.written(c), Equals, `# TYPE foo gauge
+# HELP foo Foo.
+foo{kind="a"} 3
+foo{kind="b"} 1
+# EOF
+`)
+	c.Check(s.written(c), testutil.Contains, `foo{kind="b"} 2`)
+}
+
+func (s *metricsSuite) TestRegisterReplaces(c *C) {
+	metrics.NewCounter("foo_total", "Foo.").Inc()
+	metrics.NewCounter("foo_total", "Foo again.")
+
+	c.Check(s.written(c), Equals, `# TYPE foo counter
+# HELP foo Foo again.
+# EOF
+`)
+}
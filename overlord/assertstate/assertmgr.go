@@ -42,8 +42,9 @@ type AssertManager struct{}
 func Manager(s *state.State, runner *state.TaskRunner) (*AssertManager, error) {
 	delayedCrossMgrInit()
 
-	runner.AddHandler("validate-snap", doValidateSnap, nil)
-	runner.AddHandler("validate-component", doValidateComponent, nil)
+	mgrRunner := runner.ForManager("assertstate")
+	mgrRunner.AddHandler("validate-snap", doValidateSnap, nil)
+	mgrRunner.AddHandler("validate-component", doValidateComponent, nil)
 
 	db, err := sysdb.Open()
 	if err != nil {
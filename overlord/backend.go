@@ -1,7 +1,7 @@
 // -*- Mode: Go; indent-tabs-mode: t -*-
 
 /*
- * Copyright (C) 2016 Canonical Ltd
+ * Copyright (C) 2016-2026 Canonical Ltd
  *
  * This program is free software: you can redistribute it and/or modify
  * it under the terms of the GNU General Public License version 3 as
@@ -20,20 +20,125 @@
 package overlord
 
 import (
+	"os"
 	"time"
 
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
 )
 
+// stateJournalMaxAge is how long changes are kept in the state journal
+// only, before the state is written whole again
+var stateJournalMaxAge = time.Minute
+
 type overlordStateBackend struct {
 	path         string
 	ensureBefore func(d time.Duration)
+
+	// journaled is set to append the changes to the state to a journal
+	// next to it on checkpoint, instead of rewriting it whole
+	journaled bool
+	journal   *state.Journal
+	// compactBefore asks for compactIfStale to be called within the
+	// given duration
+	compactBefore func(d time.Duration)
 }
 
 func (osb *overlordStateBackend) Checkpoint(data []byte) error {
-	return osutil.AtomicWriteFile(osb.path, data, 0600, 0)
+	if !osb.journaled {
+		return osutil.AtomicWriteFile(osb.path, data, 0600, 0)
+	}
+	if osb.journal == nil {
+		osb.journal = state.NewJournal(osb.path)
+	}
+	return osb.journalCheckpoint(func() error {
+		return osb.journal.Checkpoint(data)
+	})
+}
+
+func (osb *overlordStateBackend) CanCheckpointDelta() bool {
+	return osb.journal != nil && osb.journal.CanCheckpointDelta()
+}
+
+func (osb *overlordStateBackend) CheckpointDelta(delta *state.Delta) error {
+	return osb.journalCheckpoint(func() error {
+		return osb.journal.CheckpointDelta(delta)
+	})
+}
+
+// journalCheckpoint calls checkpoint, making sure that the changes it puts
+// in the journal are written whole in time.
+func (osb *overlordStateBackend) journalCheckpoint(checkpoint func() error) error {
+	wasDirty := !osb.journal.DirtySince().IsZero()
+	if err := checkpoint(); err != nil {
+		return err
+	}
+	if !wasDirty && !osb.journal.DirtySince().IsZero() {
+		osb.compactBefore(stateJournalMaxAge)
+	}
+	return nil
 }
 
 func (osb *overlordStateBackend) EnsureBefore(d time.Duration) {
 	osb.ensureBefore(d)
 }
+
+// load returns the state on disk, with the changes in its journal (if
+// any) applied. The result is then written back whole, so the journal is
+// only replayed once.
+func (osb *overlordStateBackend) load() ([]byte, error) {
+	data, err := os.ReadFile(osb.path)
+	if err != nil {
+		return nil, err
+	}
+
+	replayed, err := state.ReplayJournal(osb.path, data)
+	if err != nil {
+		return nil, err
+	}
+	if replayed != nil {
+		if err := osutil.AtomicWriteFile(osb.path, replayed, 0600, 0); err != nil {
+			return nil, err
+		}
+		data = replayed
+	}
+	if err := os.Remove(state.JournalPath(osb.path)); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if osb.journaled {
+		// start a journal for what was loaded
+		osb.journal = state.NewJournal(osb.path)
+		if err := osb.journal.Checkpoint(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// compactIfStale writes the state whole if changes were kept in the
+// journal only for longer than stateJournalMaxAge, otherwise it asks to be
+// called again in time to do it.
+func (osb *overlordStateBackend) compactIfStale() error {
+	if osb.journal == nil {
+		return nil
+	}
+	dirtySince := osb.journal.DirtySince()
+	if dirtySince.IsZero() {
+		return nil
+	}
+	if age := time.Since(dirtySince); age < stateJournalMaxAge {
+		osb.compactBefore(stateJournalMaxAge - age)
+		return nil
+	}
+	return osb.journal.Compact()
+}
+
+// Close writes the state whole if there are changes in the journal, so
+// that it's all in the usual format for whatever reads it next.
+func (osb *overlordStateBackend) Close() error {
+	if osb.journal == nil {
+		return nil
+	}
+	return osb.journal.Close()
+}
@@ -0,0 +1,179 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package overlord_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type stateBackendSuite struct {
+	testutil.BaseTest
+	path string
+}
+
+var _ = Suite(&stateBackendSuite{})
+
+func (s *stateBackendSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.path = filepath.Join(c.MkDir(), "state.json")
+}
+
+// loaded returns the state on disk, as loaded by a new backend, along with
+// its contents
+func (s *stateBackendSuite) loaded(c *C) (*state.State, []byte) {
+	data, err := overlord.NewStateBackend(s.path, false).Load()
+	c.Assert(err, IsNil)
+	st, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	return st, data
+}
+
+func (s *stateBackendSuite) getMark(c *C, st *state.State, key string) int {
+	st.Lock()
+	defer st.Unlock()
+	var v int
+	err := st.Get(key, &v)
+	if err != nil {
+		c.Assert(err, testutil.ErrorIs, state.ErrNoState)
+	}
+	return v
+}
+
+func (s *stateBackendSuite) TestNotJournaled(c *C) {
+	st := state.New(overlord.NewStateBackend(s.path, false))
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	c.Check(s.path, testutil.FileContains, `"a":1`)
+	c.Check(state.JournalPath(s.path), testutil.FileAbsent)
+}
+
+func (s *stateBackendSuite) TestJournaled(c *C) {
+	backend := overlord.NewStateBackend(s.path, true)
+	st := state.New(backend)
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	// the first checkpoint is a full one
+	c.Check(s.path, testutil.FileContains, `"a":1`)
+	c.Check(state.JournalPath(s.path), testutil.FilePresent)
+
+	st.Lock()
+	st.Set("a", 2)
+	st.Set("b", 3)
+	chg := st.NewChange("foo", "...")
+	chg.AddTask(st.NewTask("bar", "..."))
+	st.Warnf("hello")
+	_, err := st.AddNotice(nil, state.ChangeUpdateNotice, "1", nil)
+	c.Assert(err, IsNil)
+	st.Unlock()
+
+	// the rest only went to the journal
+	c.Check(s.path, testutil.FileContains, `"a":1`)
+	c.Check(s.path, Not(testutil.FileContains), `"b"`)
+	c.Check(state.JournalPath(s.path), testutil.FileContains, `"key":"b","value":3`)
+
+	st.Lock()
+	st.Set("b", nil)
+	st.Unlock()
+
+	loaded, data := s.loaded(c)
+	c.Check(s.getMark(c, loaded, "a"), Equals, 2)
+	c.Check(s.getMark(c, loaded, "b"), Equals, 0)
+	loaded.Lock()
+	c.Check(loaded.Changes(), HasLen, 1)
+	c.Check(loaded.Tasks(), HasLen, 1)
+	c.Check(loaded.AllWarnings(), HasLen, 1)
+	c.Check(loaded.Notices(nil), HasLen, 1)
+	loaded.Unlock()
+
+	// same as the state it came from, as read whole
+	st.Lock()
+	whole, err := json.Marshal(st)
+	st.Unlock()
+	c.Assert(err, IsNil)
+	fromWhole, err := state.ReadState(nil, bytes.NewReader(whole))
+	c.Assert(err, IsNil)
+	fromWhole.Lock()
+	expected, err := json.Marshal(fromWhole)
+	fromWhole.Unlock()
+	c.Assert(err, IsNil)
+	loaded.Lock()
+	obtained, err := json.Marshal(loaded)
+	loaded.Unlock()
+	c.Assert(err, IsNil)
+	c.Check(string(obtained), Equals, string(expected))
+
+	// and it's now on disk whole, without the journal
+	c.Check(s.path, testutil.FileEquals, data)
+	c.Check(state.JournalPath(s.path), testutil.FileAbsent)
+}
+
+func (s *stateBackendSuite) TestJournaledLoad(c *C) {
+	c.Assert(os.WriteFile(s.path, []byte(`{"data":{"a":1}}`), 0600), IsNil)
+
+	backend := overlord.NewStateBackend(s.path, true)
+	data, err := backend.Load()
+	c.Assert(err, IsNil)
+	st, err := state.ReadState(backend, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	c.Check(s.getMark(c, st, "a"), Equals, 1)
+
+	// building on what was loaded
+	st.Lock()
+	st.Set("b", 2)
+	st.Unlock()
+	c.Check(s.path, Not(testutil.FileContains), `"b"`)
+
+	loaded, _ := s.loaded(c)
+	c.Check(s.getMark(c, loaded, "a"), Equals, 1)
+	c.Check(s.getMark(c, loaded, "b"), Equals, 2)
+}
+
+func (s *stateBackendSuite) TestClose(c *C) {
+	backend := overlord.NewStateBackend(s.path, true)
+	st := state.New(backend)
+	for i := 1; i <= 2; i++ {
+		st.Lock()
+		st.Set("a", i)
+		st.Unlock()
+	}
+	c.Check(s.path, testutil.FileContains, `"a":1`)
+
+	st.Lock()
+	c.Assert(backend.Close(), IsNil)
+	st.Unlock()
+	c.Check(s.path, testutil.FileContains, `"a":2`)
+	c.Check(state.JournalPath(s.path), testutil.FileAbsent)
+
+	// nothing to do then
+	c.Assert(backend.Close(), IsNil)
+}
@@ -0,0 +1,374 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package changehistory keeps a record of the changes pruned from the
+// state, so that what happened to the system can be looked at long after
+// the changes themselves are gone.
+package changehistory
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timings"
+)
+
+const historyFilename = "changes.json"
+
+var (
+	// maxFileSize is the size past which the history file is rotated.
+	maxFileSize int64 = 4 * 1024 * 1024
+	// maxRotated is the number of rotated history files that are kept.
+	maxRotated = 9
+)
+
+// Entry is the record of a change pruned from the state.
+type Entry struct {
+	ID        string       `json:"id"`
+	Kind      string       `json:"kind"`
+	Summary   string       `json:"summary"`
+	Status    string       `json:"status"`
+	Err       string       `json:"err,omitempty"`
+	SnapNames []string     `json:"snap-names,omitempty"`
+	SpawnTime time.Time    `json:"spawn-time"`
+	ReadyTime *time.Time   `json:"ready-time,omitempty"`
+	Tasks     []*TaskEntry `json:"tasks,omitempty"`
+	Timings   []*Timing    `json:"timings,omitempty"`
+}
+
+// TaskEntry is the record of a task of a change pruned from the state.
+type TaskEntry struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	Summary   string     `json:"summary"`
+	Status    string     `json:"status"`
+	Log       []string   `json:"log,omitempty"`
+	SpawnTime time.Time  `json:"spawn-time"`
+	ReadyTime *time.Time `json:"ready-time,omitempty"`
+}
+
+// Timing holds the timings measured for a change or for one of its tasks,
+// as kept in the state.
+type Timing struct {
+	Tags          map[string]string     `json:"tags,omitempty"`
+	Duration      time.Duration         `json:"duration"`
+	NestedTimings []*timings.TimingJSON `json:"timings,omitempty"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// NewEntry returns the record of the given change.
+// The state must be locked by the caller.
+func NewEntry(chg *state.Change) (*Entry, error) {
+	entry := &Entry{
+		ID:        chg.ID(),
+		Kind:      chg.Kind(),
+		Summary:   chg.Summary(),
+		Status:    chg.Status().String(),
+		SpawnTime: chg.SpawnTime(),
+		ReadyTime: optionalTime(chg.ReadyTime()),
+	}
+	if err := chg.Err(); err != nil {
+		entry.Err = err.Error()
+	}
+	if err := chg.Get("snap-names", &entry.SnapNames); err != nil && !errors.Is(err, state.ErrNoState) {
+		return nil, err
+	}
+	for _, t := range chg.Tasks() {
+		entry.Tasks = append(entry.Tasks, &TaskEntry{
+			ID:        t.ID(),
+			Kind:      t.Kind(),
+			Summary:   t.Summary(),
+			Status:    t.Status().String(),
+			Log:       t.Log(),
+			SpawnTime: t.SpawnTime(),
+			ReadyTime: optionalTime(t.ReadyTime()),
+		})
+	}
+	tms, err := timings.Get(chg.State(), -1, func(tags map[string]string) bool {
+		return tags["change-id"] == chg.ID()
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, tm := range tms {
+		entry.Timings = append(entry.Timings, &Timing{
+			Tags:          tm.Tags,
+			Duration:      tm.Duration,
+			NestedTimings: tm.NestedTimings,
+		})
+	}
+	return entry, nil
+}
+
+// affects returns whether the change was about the given snap.
+func (e *Entry) affects(snapName string) bool {
+	for _, name := range e.SnapNames {
+		// service-control changes can have <snap>.<app>
+		// in their snap-names
+		if name, _ := snap.SplitSnapApp(name); name == snapName {
+			return true
+		}
+	}
+	return false
+}
+
+// History is a record of changes kept on disk in a directory, in a file
+// that is rotated once it grows too big; only a limited number of the
+// rotated files are kept.
+type History struct {
+	dir string
+	// mu serializes the access to the files
+	mu sync.Mutex
+
+	// pendingMu protects pending, it is never held while doing I/O so
+	// that the changes can be archived with the state locked
+	pendingMu sync.Mutex
+	pending   []*Entry
+}
+
+// New returns the history kept in the given directory.
+func New(dir string) *History {
+	return &History{dir: dir}
+}
+
+func (h *History) filename(rotation int) string {
+	fn := filepath.Join(h.dir, historyFilename)
+	if rotation > 0 {
+		fn = fmt.Sprintf("%s.%d", fn, rotation)
+	}
+	return fn
+}
+
+// Archive adds the record of the given change to the history. The record
+// is kept in memory until the next call to Flush, so that no I/O is done
+// while the state is locked.
+// The state must be locked by the caller.
+func (h *History) Archive(chg *state.Change) error {
+	entry, err := NewEntry(chg)
+	if err != nil {
+		return fmt.Errorf("cannot archive change %s: %v", chg.ID(), err)
+	}
+	h.queue(entry)
+	return nil
+}
+
+func (h *History) queue(entry *Entry) {
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	h.pending = append(h.pending, entry)
+}
+
+// Add adds the given record to the history, writing it to disk along with
+// any record still pending.
+func (h *History) Add(entry *Entry) error {
+	h.queue(entry)
+	return h.Flush()
+}
+
+// Flush writes the records archived since the last call to disk. The
+// records that cannot be written are dropped.
+// The state should not be locked by the caller.
+func (h *History) Flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.pendingMu.Lock()
+	pending := h.pending
+	h.pending = nil
+	h.pendingMu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(h.dir, 0700); err != nil {
+		return fmt.Errorf("cannot write change history: %v", err)
+	}
+	for _, entry := range pending {
+		if err := h.write(entry); err != nil {
+			return fmt.Errorf("cannot write change history: %v", err)
+		}
+	}
+	return nil
+}
+
+func (h *History) write(entry *Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if fi, err := os.Stat(h.filename(0)); err == nil && fi.Size() > 0 && fi.Size()+int64(len(line)) > maxFileSize {
+		if err := h.rotate(); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(h.filename(0), os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	unterminated, err := endsUnterminated(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if unterminated {
+		// the last record was cut short by a crash, terminate it so
+		// that it does not run into this one
+		line = append([]byte{'\n'}, line...)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// endsUnterminated returns whether the given file ends with a line that is
+// not terminated by a newline.
+func endsUnterminated(f *os.File) (bool, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if fi.Size() == 0 {
+		return false, nil
+	}
+	var last [1]byte
+	if _, err := f.ReadAt(last[:], fi.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+func (h *History) rotate() error {
+	if err := os.Remove(h.filename(maxRotated)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for i := maxRotated - 1; i >= 0; i-- {
+		if err := os.Rename(h.filename(i), h.filename(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Options hold the criteria the records returned by Entries must meet.
+type Options struct {
+	// SnapName is the name of a snap the changes must be about.
+	SnapName string
+	// Since is the time from which the changes must have become ready.
+	Since time.Time
+}
+
+func (opts *Options) match(entry *Entry) bool {
+	if opts == nil {
+		return true
+	}
+	if opts.SnapName != "" && !entry.affects(opts.SnapName) {
+		return false
+	}
+	if !opts.Since.IsZero() {
+		when := entry.SpawnTime
+		if entry.ReadyTime != nil {
+			when = *entry.ReadyTime
+		}
+		if when.Before(opts.Since) {
+			return false
+		}
+	}
+	return true
+}
+
+func readEntries(f *os.File, found func(entry *Entry)) error {
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var entry Entry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				// most likely cut short by a crash
+				logger.Noticef("cannot decode change history entry in %s: %v", f.Name(), err)
+			} else {
+				found(&entry)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Entries returns the records in the history that meet the given
+// criteria, from the oldest to the most recent, including the ones not
+// written to disk yet.
+func (h *History) Entries(opts *Options) ([]*Entry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var entries []*Entry
+	for i := maxRotated; i >= 0; i-- {
+		f, err := os.Open(h.filename(i))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read change history: %v", err)
+		}
+		err = readEntries(f, func(entry *Entry) {
+			if opts.match(entry) {
+				entries = append(entries, entry)
+			}
+		})
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read change history: %v", err)
+		}
+	}
+
+	h.pendingMu.Lock()
+	defer h.pendingMu.Unlock()
+	for _, entry := range h.pending {
+		if opts.match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,285 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package changehistory_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/changehistory"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timings"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type historySuite struct {
+	testutil.BaseTest
+	dir string
+	st  *state.State
+}
+
+var _ = Suite(&historySuite{})
+
+func (s *historySuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.dir = filepath.Join(c.MkDir(), "history")
+	s.st = state.New(nil)
+}
+
+func (s *historySuite) TestArchive(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	chg := s.st.NewChange("refresh-snap", `Refresh "foo" snap`)
+	chg.Set("snap-names", []string{"foo"})
+	t1 := s.st.NewTask("download-snap", "Download foo")
+	t1.Logf("downloading")
+	chg.AddTask(t1)
+	t1.SetStatus(state.DoneStatus)
+	t2 := s.st.NewTask("link-snap", "Make foo available")
+	t2.Errorf("cannot link")
+	chg.AddTask(t2)
+	t2.SetStatus(state.ErrorStatus)
+
+	tm := timings.New(map[string]string{"change-id": chg.ID(), "task-id": t1.ID()})
+	span := tm.StartSpan("download", "download foo")
+	time.Sleep(10 * time.Millisecond)
+	span.Stop()
+	tm.Save(s.st)
+	other := timings.New(map[string]string{"change-id": "other"})
+	span = other.StartSpan("other", "...")
+	time.Sleep(10 * time.Millisecond)
+	span.Stop()
+	other.Save(s.st)
+
+	h := changehistory.New(s.dir)
+	c.Assert(h.Archive(chg), IsNil)
+	// nothing is written until flushed
+	c.Check(filepath.Join(s.dir, "changes.json"), testutil.FileAbsent)
+	c.Assert(h.Flush(), IsNil)
+
+	fi, err := os.Stat(filepath.Join(s.dir, "changes.json"))
+	c.Assert(err, IsNil)
+	c.Check(fi.Mode().Perm(), Equals, os.FileMode(0600))
+
+	entries, err := h.Entries(nil)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	entry := entries[0]
+	c.Check(entry.ID, Equals, chg.ID())
+	c.Check(entry.Kind, Equals, "refresh-snap")
+	c.Check(entry.Summary, Equals, `Refresh "foo" snap`)
+	c.Check(entry.Status, Equals, "Error")
+	c.Check(entry.Err, Equals, chg.Err().Error())
+	c.Check(entry.SnapNames, DeepEquals, []string{"foo"})
+	c.Check(entry.SpawnTime.Equal(chg.SpawnTime()), Equals, true)
+	c.Assert(entry.ReadyTime, NotNil)
+	c.Check(entry.ReadyTime.Equal(chg.ReadyTime()), Equals, true)
+
+	c.Assert(entry.Tasks, HasLen, 2)
+	c.Check(entry.Tasks[0].ID, Equals, t1.ID())
+	c.Check(entry.Tasks[0].Kind, Equals, "download-snap")
+	c.Check(entry.Tasks[0].Status, Equals, "Done")
+	c.Check(entry.Tasks[0].Log, DeepEquals, t1.Log())
+	c.Check(entry.Tasks[1].Status, Equals, "Error")
+	c.Check(entry.Tasks[1].Log, DeepEquals, t2.Log())
+
+	c.Assert(entry.Timings, HasLen, 1)
+	c.Check(entry.Timings[0].Tags, DeepEquals, map[string]string{"change-id": chg.ID(), "task-id": t1.ID()})
+	c.Assert(entry.Timings[0].NestedTimings, HasLen, 1)
+	c.Check(entry.Timings[0].NestedTimings[0].Label, Equals, "download")
+}
+
+func (s *historySuite) TestArchiveNotReady(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	chg := s.st.NewChange("empty", "...")
+
+	h := changehistory.New(s.dir)
+	c.Assert(h.Archive(chg), IsNil)
+
+	entries, err := h.Entries(nil)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0].ReadyTime, IsNil)
+	c.Check(entries[0].Tasks, HasLen, 0)
+}
+
+func (s *historySuite) TestArchiveError(c *C) {
+	c.Assert(os.WriteFile(s.dir, nil, 0644), IsNil)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+	chg := s.st.NewChange("foo", "...")
+
+	h := changehistory.New(s.dir)
+	c.Assert(h.Archive(chg), IsNil)
+	err := h.Flush()
+	c.Check(err, ErrorMatches, "cannot write change history: mkdir .*: not a directory")
+
+	// the record is dropped
+	c.Check(h.Flush(), IsNil)
+}
+
+func (s *historySuite) TestEntriesPending(c *C) {
+	h := changehistory.New(s.dir)
+	s.add(c, h, "1", nil, time.Now())
+
+	s.st.Lock()
+	chg := s.st.NewChange("foo", "...")
+	c.Assert(h.Archive(chg), IsNil)
+	s.st.Unlock()
+
+	entries, err := h.Entries(nil)
+	c.Assert(err, IsNil)
+	c.Check(entryIDs(entries), DeepEquals, []string{"1", chg.ID()})
+
+	c.Assert(h.Flush(), IsNil)
+	entries, err = h.Entries(nil)
+	c.Assert(err, IsNil)
+	c.Check(entryIDs(entries), DeepEquals, []string{"1", chg.ID()})
+}
+
+func (s *historySuite) add(c *C, h *changehistory.History, id string, snapNames []string, readyTime time.Time) {
+	entry := &changehistory.Entry{
+		ID:        id,
+		Kind:      "foo",
+		Status:    "Done",
+		SnapNames: snapNames,
+		SpawnTime: readyTime.Add(-time.Minute),
+	}
+	if !readyTime.IsZero() {
+		entry.ReadyTime = &readyTime
+	}
+	c.Assert(h.Add(entry), IsNil)
+}
+
+func entryIDs(entries []*changehistory.Entry) []string {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+func (s *historySuite) TestEntriesFiltered(c *C) {
+	h := changehistory.New(s.dir)
+
+	now := time.Now()
+	s.add(c, h, "1", []string{"foo"}, now.Add(-3*time.Hour))
+	s.add(c, h, "2", []string{"bar", "foo.svc"}, now.Add(-2*time.Hour))
+	s.add(c, h, "3", nil, now.Add(-time.Hour))
+	s.add(c, h, "4", []string{"bar"}, time.Time{})
+
+	for _, t := range []struct {
+		opts *changehistory.Options
+		ids  []string
+	}{
+		{nil, []string{"1", "2", "3", "4"}},
+		{&changehistory.Options{}, []string{"1", "2", "3", "4"}},
+		{&changehistory.Options{SnapName: "foo"}, []string{"1", "2"}},
+		{&changehistory.Options{SnapName: "bar"}, []string{"2", "4"}},
+		{&changehistory.Options{SnapName: "baz"}, []string{}},
+		{&changehistory.Options{Since: now.Add(-150 * time.Minute)}, []string{"2", "3"}},
+		{&changehistory.Options{SnapName: "foo", Since: now.Add(-150 * time.Minute)}, []string{"2"}},
+	} {
+		entries, err := h.Entries(t.opts)
+		c.Assert(err, IsNil)
+		c.Check(entryIDs(entries), DeepEquals, t.ids, Commentf("%+v", t.opts))
+	}
+}
+
+func (s *historySuite) TestEntriesNone(c *C) {
+	h := changehistory.New(s.dir)
+	entries, err := h.Entries(nil)
+	c.Assert(err, IsNil)
+	c.Check(entries, HasLen, 0)
+}
+
+func (s *historySuite) TestEntriesSkipsIncomplete(c *C) {
+	h := changehistory.New(s.dir)
+	s.add(c, h, "1", nil, time.Now())
+
+	f, err := os.OpenFile(filepath.Join(s.dir, "changes.json"), os.O_WRONLY|os.O_APPEND, 0)
+	c.Assert(err, IsNil)
+	_, err = f.WriteString(`{"id":"2","kind":`)
+	c.Assert(err, IsNil)
+	f.Close()
+
+	entries, err := h.Entries(nil)
+	c.Assert(err, IsNil)
+	c.Check(entryIDs(entries), DeepEquals, []string{"1"})
+
+	// the record cut short does not run into the next one
+	s.add(c, h, "3", nil, time.Now())
+	entries, err = h.Entries(nil)
+	c.Assert(err, IsNil)
+	c.Check(entryIDs(entries), DeepEquals, []string{"1", "3"})
+}
+
+func (s *historySuite) TestRotation(c *C) {
+	s.AddCleanup(changehistory.MockMaxFileSize(300))
+	s.AddCleanup(changehistory.MockMaxRotated(2))
+
+	h := changehistory.New(s.dir)
+	now := time.Now()
+	for i := 1; i <= 10; i++ {
+		s.add(c, h, fmt.Sprint(i), nil, now)
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"))
+	c.Assert(err, IsNil)
+	c.Check(files, DeepEquals, []string{
+		filepath.Join(s.dir, "changes.json"),
+		filepath.Join(s.dir, "changes.json.1"),
+		filepath.Join(s.dir, "changes.json.2"),
+	})
+	for _, fn := range files {
+		fi, err := os.Stat(fn)
+		c.Assert(err, IsNil)
+		c.Check(fi.Size() <= 300, Equals, true)
+	}
+
+	// the oldest ones are gone
+	entries, err := h.Entries(nil)
+	c.Assert(err, IsNil)
+	ids := entryIDs(entries)
+	c.Assert(len(ids) < 10, Equals, true)
+	c.Check(ids[len(ids)-1], Equals, "10")
+	for i := range ids {
+		c.Check(ids[i], Equals, fmt.Sprint(10-len(ids)+i+1))
+	}
+}
+
+func (s *historySuite) TestEntriesError(c *C) {
+	c.Assert(os.MkdirAll(filepath.Join(s.dir, "changes.json"), 0755), IsNil)
+
+	h := changehistory.New(s.dir)
+	_, err := h.Entries(nil)
+	c.Check(err, ErrorMatches, "cannot read change history: .*is a directory")
+}
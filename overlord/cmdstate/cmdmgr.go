@@ -35,7 +35,8 @@ type CommandManager struct{}
 
 // Manager returns a new CommandManager.
 func Manager(st *state.State, runner *state.TaskRunner) *CommandManager {
-	runner.AddHandler("exec-command", doExec, nil)
+	mgrRunner := runner.ForManager("cmdstate")
+	mgrRunner.AddHandler("exec-command", doExec, nil)
 	return &CommandManager{}
 }
 
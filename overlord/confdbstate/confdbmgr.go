@@ -49,13 +49,14 @@ type ConfdbManager struct{}
 func Manager(st *state.State, hookMgr *hookstate.HookManager, runner *state.TaskRunner) *ConfdbManager {
 	m := &ConfdbManager{}
 
+	mgrRunner := runner.ForManager("confdbstate")
 	// no undo since if we commit there's no rolling back
-	runner.AddHandler("commit-confdb-tx", m.doCommitTransaction, nil)
+	mgrRunner.AddHandler("commit-confdb-tx", m.doCommitTransaction, nil)
 	// only activated on undo, to clear the ongoing transaction from state and
 	// unblock others who may be waiting for it
-	runner.AddHandler("clear-confdb-tx-on-error", m.noop, m.clearOngoingTransaction)
-	runner.AddHandler("clear-confdb-tx", m.clearOngoingTransaction, nil)
-	runner.AddHandler("load-confdb-change", m.doLoadDataIntoChange, nil)
+	mgrRunner.AddHandler("clear-confdb-tx-on-error", m.noop, m.clearOngoingTransaction)
+	mgrRunner.AddHandler("clear-confdb-tx", m.clearOngoingTransaction, nil)
+	mgrRunner.AddHandler("load-confdb-change", m.doLoadDataIntoChange, nil)
 
 	hookMgr.Register(regexp.MustCompile("^change-view-.+$"), func(context *hookstate.Context) hookstate.Handler {
 		return &changeViewHandler{ctx: context}
@@ -21,6 +21,7 @@
 package configcore
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -34,25 +35,50 @@ import (
 
 const (
 	optionDebugSnapdLog            = "debug.snapd.log"
+	optionDebugSnapdLogFormat      = "debug.snapd.log-format"
 	optionDebugSystemdLogLevel     = "debug.systemd.log-level"
 	coreOptionDebugSnapdLog        = "core." + optionDebugSnapdLog
+	coreOptionDebugSnapdLogFormat  = "core." + optionDebugSnapdLogFormat
 	coreOptionDebugSystemdLogLevel = "core." + optionDebugSystemdLogLevel
 )
 
-var loggerSimpleSetup = logger.SimpleSetup
+var (
+	loggerSimpleSetup                = logger.SimpleSetup
+	loggerStructuredLoggingSupported = logger.StructuredLoggingSupported
+)
 
 func init() {
 	supportedConfigurations[coreOptionDebugSnapdLog] = true
+	supportedConfigurations[coreOptionDebugSnapdLogFormat] = true
 	supportedConfigurations[coreOptionDebugSystemdLogLevel] = true
 }
 
 func validateDebugSnapdLogSetting(tr RunTransaction) error {
-	return validateBoolFlag(tr, optionDebugSnapdLog)
+	if err := validateBoolFlag(tr, optionDebugSnapdLog); err != nil {
+		return err
+	}
+
+	logFormat, err := coreCfg(tr, optionDebugSnapdLogFormat)
+	if err != nil {
+		return err
+	}
+	switch logFormat {
+	case "", logger.TextFormat:
+		// noop
+	case logger.JSONFormat:
+		if !loggerStructuredLoggingSupported() {
+			return fmt.Errorf("cannot set %s to '%s': snapd is built without support for structured logging", optionDebugSnapdLogFormat, logger.JSONFormat)
+		}
+	default:
+		return fmt.Errorf("%s can only be set to '%s' or '%s'", optionDebugSnapdLogFormat, logger.TextFormat, logger.JSONFormat)
+	}
+	return nil
 }
 
 func handleDebugSnapdLogConfiguration(tr RunTransaction, opts *fsOnlyContext) error {
-	// Run only if the option changed to avoid extra filesystem access
-	if !strutil.ListContains(tr.Changes(), coreOptionDebugSnapdLog) {
+	// Run only if the options changed to avoid extra filesystem access
+	if !strutil.ListContains(tr.Changes(), coreOptionDebugSnapdLog) &&
+		!strutil.ListContains(tr.Changes(), coreOptionDebugSnapdLogFormat) {
 		return nil
 	}
 
@@ -60,6 +86,10 @@ func handleDebugSnapdLogConfiguration(tr RunTransaction, opts *fsOnlyContext) er
 	if err != nil {
 		return err
 	}
+	logFormat, err := coreCfg(tr, optionDebugSnapdLogFormat)
+	if err != nil {
+		return err
+	}
 
 	rootDir := dirs.GlobalRootDir
 	if opts != nil {
@@ -69,33 +99,41 @@ func handleDebugSnapdLogConfiguration(tr RunTransaction, opts *fsOnlyContext) er
 
 	snapdEnvPath := filepath.Join(envDir, "snapd.conf")
 
+	// The environment file is used just for the snapd logging options
+	var env bytes.Buffer
 	var enableDebug bool
 	switch debugLog {
 	case "true":
+		env.WriteString("SNAPD_DEBUG=1\n")
+		enableDebug = true
+	case "false", "":
+		enableDebug = false
+	default:
+		return fmt.Errorf("%s must be true of false, not: %q", optionDebugSnapdLog, debugLog)
+	}
+	if logFormat == logger.JSONFormat {
+		env.WriteString("SNAPD_LOG_FORMAT=json\n")
+	}
+
+	if env.Len() > 0 {
 		if err := os.Mkdir(envDir, 0755); err != nil && !os.IsExist(err) {
 			return err
 		}
 		if err := osutil.EnsureFileState(snapdEnvPath, &osutil.MemoryFileState{
-			Content: []byte("SNAPD_DEBUG=1\n"),
+			Content: env.Bytes(),
 			Mode:    os.FileMode(0644),
 		}); err != nil {
 			return err
 		}
-		enableDebug = true
-	case "false", "":
-		// We simply remove the env file as for the moment we use it
-		// just for SNAPD_DEBUG. If we change that we will need to
-		// locate the variable in the file and remove just that.
+	} else {
 		if err := os.Remove(snapdEnvPath); err != nil && !os.IsNotExist(err) {
 			return err
 		}
-		enableDebug = false
-	default:
-		return fmt.Errorf("%s must be true of false, not: %q", optionDebugSnapdLog, debugLog)
 	}
 
-	// Enable/disable debug logging for current snapd instance
-	loggerSimpleSetup(&logger.LoggerOptions{ForceDebug: enableDebug})
+	// Enable/disable debug logging and set the format for current snapd
+	// instance
+	loggerSimpleSetup(&logger.LoggerOptions{ForceDebug: enableDebug, Format: logFormat})
 
 	return nil
 }
@@ -121,6 +121,74 @@ func (s *debugSuite) TestConfigureDebugSnapdLogBadVals(c *C) {
 	}
 }
 
+func (s *debugSuite) TestConfigureDebugSnapdLogFormat(c *C) {
+	var loggerOpts *logger.LoggerOptions
+	r := configcore.MockLoggerSimpleSetup(func(opts *logger.LoggerOptions) {
+		loggerOpts = opts
+	})
+	defer r()
+	defer configcore.MockLoggerStructuredLoggingSupported(true)()
+
+	for _, t := range []struct {
+		debug, format string
+		env           string
+	}{
+		{"", "json", "SNAPD_LOG_FORMAT=json\n"},
+		{"true", "json", "SNAPD_DEBUG=1\nSNAPD_LOG_FORMAT=json\n"},
+		{"true", "text", "SNAPD_DEBUG=1\n"},
+		{"false", "text", ""},
+		{"", "", ""},
+	} {
+		err := configcore.Run(coreDev, &mockConf{
+			state:   s.state,
+			conf:    map[string]interface{}{"debug.snapd.log": t.debug},
+			changes: map[string]interface{}{"debug.snapd.log-format": t.format},
+		})
+		c.Assert(err, IsNil)
+
+		if t.env == "" {
+			c.Check(s.snapdEnvPath, testutil.FileAbsent)
+		} else {
+			c.Check(s.snapdEnvPath, testutil.FileEquals, t.env)
+		}
+		c.Check(loggerOpts, DeepEquals, &logger.LoggerOptions{ForceDebug: t.debug == "true", Format: t.format})
+	}
+}
+
+func (s *debugSuite) TestConfigureDebugSnapdLogFormatBadVals(c *C) {
+	r := configcore.MockLoggerSimpleSetup(func(opts *logger.LoggerOptions) {
+		c.Error("loggerSimpleSetup should not have been called")
+	})
+	defer r()
+
+	for _, val := range []string{"JSON", "yaml"} {
+		err := configcore.Run(coreDev, &mockConf{
+			state:   s.state,
+			changes: map[string]interface{}{"debug.snapd.log-format": val},
+		})
+		c.Assert(err, ErrorMatches,
+			"debug.snapd.log-format can only be set to 'text' or 'json'")
+
+		c.Check(s.snapdEnvPath, testutil.FileAbsent)
+	}
+}
+
+func (s *debugSuite) TestConfigureDebugSnapdLogFormatJSONUnsupported(c *C) {
+	r := configcore.MockLoggerSimpleSetup(func(opts *logger.LoggerOptions) {
+		c.Error("loggerSimpleSetup should not have been called")
+	})
+	defer r()
+	defer configcore.MockLoggerStructuredLoggingSupported(false)()
+
+	err := configcore.Run(coreDev, &mockConf{
+		state:   s.state,
+		changes: map[string]interface{}{"debug.snapd.log-format": "json"},
+	})
+	c.Assert(err, ErrorMatches,
+		"cannot set debug.snapd.log-format to 'json': snapd is built without support for structured logging")
+	c.Check(s.snapdEnvPath, testutil.FileAbsent)
+}
+
 func (s *debugSuite) TestConfigureSystemdLogLevelGoodVals(c *C) {
 	var systemctlArgs []string
 	numCalls := 0
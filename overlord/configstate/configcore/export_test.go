@@ -90,6 +90,10 @@ func MockLoggerSimpleSetup(f func(opts *logger.LoggerOptions)) func() {
 	return testutil.Mock(&loggerSimpleSetup, f)
 }
 
+func MockLoggerStructuredLoggingSupported(supported bool) func() {
+	return testutil.Mock(&loggerStructuredLoggingSupported, func() bool { return supported })
+}
+
 func MockRestartRequest(f func(st *state.State, t restart.RestartType, rebootInfo *boot.RebootInfo)) func() {
 	return testutil.Mock(&restartRequest, f)
 }
@@ -35,6 +35,10 @@ import (
 	"github.com/snapcore/snapd/snap"
 )
 
+func init() {
+	supportedConfigurations["core.prompting.unused-rule-expiry"] = true
+}
+
 var restartRequest = restart.Request
 
 var servicestateControl = servicestate.Control
@@ -152,3 +156,21 @@ func doExperimentalApparmorPromptingDaemonRestart(c RunTransaction, opts *fsOnly
 
 	return nil
 }
+
+func validatePromptingUnusedRuleExpiry(tr RunTransaction) error {
+	expiryStr, err := coreCfg(tr, "prompting.unused-rule-expiry")
+	if err != nil {
+		return err
+	}
+	if expiryStr == "" {
+		return nil
+	}
+	expiry, err := time.ParseDuration(expiryStr)
+	if err != nil {
+		return fmt.Errorf("prompting.unused-rule-expiry cannot be parsed: %v", err)
+	}
+	if expiry < 24*time.Hour {
+		return fmt.Errorf("prompting.unused-rule-expiry must be a value greater than 24 hours")
+	}
+	return nil
+}
@@ -551,6 +551,31 @@ func (s *promptingSuite) TestDoExperimentalApparmorPromptingHandlerServicesTimeo
 	})
 }
 
+func (s *promptingSuite) TestConfigurePromptingUnusedRuleExpiry(c *C) {
+	for _, tc := range []struct {
+		value string
+		err   string
+	}{
+		{"", ""},
+		{"720h", ""},
+		{"24h", ""},
+		{"23h59m", `prompting.unused-rule-expiry must be a value greater than 24 hours`},
+		{"invalid", `prompting.unused-rule-expiry cannot be parsed:.*`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			conf: map[string]interface{}{
+				"prompting.unused-rule-expiry": tc.value,
+			},
+		})
+		if tc.err == "" {
+			c.Check(err, IsNil, Commentf("%q", tc.value))
+		} else {
+			c.Check(err, ErrorMatches, tc.err, Commentf("%q", tc.value))
+		}
+	}
+}
+
 func (s *promptingSuite) mockSnapd(c *C) {
 	const snapdSnapYaml = `
 name: snapd
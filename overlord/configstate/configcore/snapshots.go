@@ -22,12 +22,27 @@ package configcore
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timeutil"
 )
 
 func init() {
 	// add supported configuration of this module
 	supportedConfigurations["core.snapshots.automatic.retention"] = true
+	supportedConfigurations["core.snapshots.schedule"] = true
+	supportedConfigurations["core.snapshots.retention.hourly"] = true
+	supportedConfigurations["core.snapshots.retention.daily"] = true
+	supportedConfigurations["core.snapshots.retention.weekly"] = true
+}
+
+const snapScheduleConfPrefix = "core.snapshots.snap-schedule."
+
+func isSnapshotSnapScheduleChange(key string) bool {
+	return strings.HasPrefix(key, snapScheduleConfPrefix)
 }
 
 func validateAutomaticSnapshotsExpiration(tr RunTransaction) error {
@@ -46,3 +61,52 @@ func validateAutomaticSnapshotsExpiration(tr RunTransaction) error {
 	}
 	return nil
 }
+
+func validateSnapshotsSchedule(tr RunTransaction) error {
+	scheduleStr, err := coreCfg(tr, "snapshots.schedule")
+	if err != nil {
+		return err
+	}
+	if scheduleStr != "" {
+		if _, err := timeutil.ParseSchedule(scheduleStr); err != nil {
+			return fmt.Errorf("snapshots.schedule cannot be parsed: %v", err)
+		}
+	}
+
+	for _, name := range tr.Changes() {
+		if !isSnapshotSnapScheduleChange(name) {
+			continue
+		}
+		snapName := strings.TrimPrefix(name, snapScheduleConfPrefix)
+		if err := snap.ValidateInstanceName(snapName); err != nil {
+			return fmt.Errorf("cannot set snapshot schedule of snap %q: %v", snapName, err)
+		}
+		nameWithoutCore := strings.TrimPrefix(name, "core.")
+		scheduleStr, err := coreCfg(tr, nameWithoutCore)
+		if err != nil {
+			return err
+		}
+		// "none" excludes the snap from the global schedule
+		if scheduleStr == "" || scheduleStr == "none" {
+			continue
+		}
+		if _, err := timeutil.ParseSchedule(scheduleStr); err != nil {
+			return fmt.Errorf("%s cannot be parsed: %v", nameWithoutCore, err)
+		}
+	}
+
+	for _, period := range []string{"hourly", "daily", "weekly"} {
+		option := "snapshots.retention." + period
+		keepStr, err := coreCfg(tr, option)
+		if err != nil {
+			return err
+		}
+		if keepStr == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(keepStr, 10, 16); err != nil {
+			return fmt.Errorf("%s must be a non-negative number", option)
+		}
+	}
+	return nil
+}
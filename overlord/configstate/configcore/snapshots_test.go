@@ -71,3 +71,39 @@ func (s *refreshSuite) TestConfigureAutomaticSnapshotsExpirationInvalid(c *C) {
 	})
 	c.Assert(err, ErrorMatches, `snapshots.automatic.retention cannot be parsed:.*`)
 }
+
+func (s *snapshotsSuite) TestConfigureSnapshotsScheduleHappy(c *C) {
+	err := configcore.Run(classicDev, &mockConf{
+		state: s.state,
+		changes: map[string]interface{}{
+			"snapshots.schedule":               "00:00-04:00",
+			"snapshots.snap-schedule.foo":      "mon,10:00",
+			"snapshots.snap-schedule.bar_inst": "none",
+			"snapshots.retention.hourly":       "24",
+			"snapshots.retention.daily":        7,
+			"snapshots.retention.weekly":       "0",
+		},
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *snapshotsSuite) TestConfigureSnapshotsScheduleInvalid(c *C) {
+	for _, t := range []struct {
+		key, value string
+		err        string
+	}{
+		{"snapshots.schedule", "whenever", `snapshots.schedule cannot be parsed: .*`},
+		{"snapshots.snap-schedule.foo", "25:00", `snapshots.snap-schedule.foo cannot be parsed: .*`},
+		{"snapshots.snap-schedule.Foo", "10:00", `cannot set snapshot schedule of snap "Foo": invalid snap name: "Foo"`},
+		{"snapshots.retention.hourly", "-1", `snapshots.retention.hourly must be a non-negative number`},
+		{"snapshots.retention.weekly", "lots", `snapshots.retention.weekly must be a non-negative number`},
+	} {
+		err := configcore.Run(classicDev, &mockConf{
+			state: s.state,
+			changes: map[string]interface{}{
+				t.key: t.value,
+			},
+		})
+		c.Check(err, ErrorMatches, t.err, Commentf("%s=%s", t.key, t.value))
+	}
+}
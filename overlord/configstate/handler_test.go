@@ -21,6 +21,7 @@
 package configstate_test
 
 import (
+	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
@@ -117,6 +118,31 @@ func (s *configureHandlerSuite) TestBeforeInitializesTransaction(c *C) {
 	c.Check(value, Equals, "bar")
 }
 
+func (s *configureHandlerSuite) TestDoneRecordsSnapConfigNotice(c *C) {
+	s.context.Lock()
+	s.context.Set("patch", map[string]interface{}{
+		"foo": "bar",
+		"baz": 42,
+	})
+	s.context.Unlock()
+
+	c.Assert(s.handler.Before(), IsNil)
+
+	s.context.Lock()
+	defer s.context.Unlock()
+	c.Assert(s.context.Done(), IsNil)
+
+	notices := s.state.Notices(nil)
+	c.Assert(notices, HasLen, 1)
+	buf, err := json.Marshal(notices[0])
+	c.Assert(err, IsNil)
+	var n map[string]interface{}
+	c.Assert(json.Unmarshal(buf, &n), IsNil)
+	c.Check(n["type"], Equals, "snap-config")
+	c.Check(n["key"], Equals, "test-snap")
+	c.Check(n["last-data"], DeepEquals, map[string]interface{}{"keys": "baz,foo"})
+}
+
 func makeModel(override map[string]interface{}) *asserts.Model {
 	model := map[string]interface{}{
 		"type":         "model",
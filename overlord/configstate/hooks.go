@@ -22,6 +22,7 @@ package configstate
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/hookstate"
@@ -173,6 +174,28 @@ func (h *defaultConfigureHandler) Error(err error) (bool, error) {
 	return false, nil
 }
 
+// addSnapConfigNotices records a snap-config notice for each snap with
+// changes to its configuration among the given ones, as returned by
+// Transaction.Changes, with the changed keys as data.
+func addSnapConfigNotices(st *state.State, changes []string) error {
+	var instanceNames []string
+	keys := make(map[string][]string)
+	for _, change := range changes {
+		instanceName, key, _ := strings.Cut(change, ".")
+		if keys[instanceName] == nil {
+			instanceNames = append(instanceNames, instanceName)
+		}
+		keys[instanceName] = append(keys[instanceName], key)
+	}
+	for _, instanceName := range instanceNames {
+		data := map[string]string{"keys": strings.Join(keys[instanceName], ",")}
+		if _, err := st.AddNotice(nil, state.SnapConfigNotice, instanceName, &state.AddNoticeOptions{Data: data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // cachedTransaction is the index into the context cache where the initialized
 // transaction is stored.
 type cachedTransaction struct{}
@@ -190,7 +213,11 @@ func ContextTransaction(context *hookstate.Context) *config.Transaction {
 	tr = config.NewTransaction(context.State())
 
 	context.OnDone(func() error {
+		changes := tr.Changes()
 		tr.Commit()
+		if err := addSnapConfigNotices(context.State(), changes); err != nil {
+			return err
+		}
 		if context.InstanceName() == "core" {
 			// make sure the Ensure logic can process
 			// system configuration changes as soon as possible
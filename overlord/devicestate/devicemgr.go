@@ -199,18 +199,19 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 	hookManager.Register(regexp.MustCompile("^prepare-device$"), newBasicHookStateHandler)
 	hookManager.Register(regexp.MustCompile("^install-device$"), newBasicHookStateHandler)
 
-	runner.AddHandler("generate-device-key", m.doGenerateDeviceKey, nil)
-	runner.AddHandler("request-serial", m.doRequestSerial, nil)
-	runner.AddHandler("mark-preseeded", m.doMarkPreseeded, nil)
-	runner.AddHandler("mark-seeded", m.doMarkSeeded, nil)
-	runner.AddHandler("setup-ubuntu-save", m.doSetupUbuntuSave, nil)
-	runner.AddHandler("setup-run-system", m.doSetupRunSystem, nil)
-	runner.AddHandler("factory-reset-run-system", m.doFactoryResetRunSystem, nil)
-	runner.AddHandler("restart-system-to-run-mode", m.doRestartSystemToRunMode, nil)
-	runner.AddHandler("prepare-remodeling", m.doPrepareRemodeling, nil)
+	mgrRunner := runner.ForManager("devicestate")
+	mgrRunner.AddHandler("generate-device-key", m.doGenerateDeviceKey, nil)
+	mgrRunner.AddHandler("request-serial", m.doRequestSerial, nil)
+	mgrRunner.AddHandler("mark-preseeded", m.doMarkPreseeded, nil)
+	mgrRunner.AddHandler("mark-seeded", m.doMarkSeeded, nil)
+	mgrRunner.AddHandler("setup-ubuntu-save", m.doSetupUbuntuSave, nil)
+	mgrRunner.AddHandler("setup-run-system", m.doSetupRunSystem, nil)
+	mgrRunner.AddHandler("factory-reset-run-system", m.doFactoryResetRunSystem, nil)
+	mgrRunner.AddHandler("restart-system-to-run-mode", m.doRestartSystemToRunMode, nil)
+	mgrRunner.AddHandler("prepare-remodeling", m.doPrepareRemodeling, nil)
 	runner.AddCleanup("prepare-remodeling", m.cleanupRemodel)
 	// this *must* always run last and finalizes a remodel
-	runner.AddHandler("set-model", m.doSetModel, nil)
+	mgrRunner.AddHandler("set-model", m.doSetModel, nil)
 	runner.AddCleanup("set-model", m.cleanupRemodel)
 	// There is no undo for successful gadget updates. The system is
 	// rebooted during update, if it boots up to the point where snapd runs
@@ -218,23 +219,23 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 	// deployed boot assets must be backward compatible with reverted kernel
 	// or gadget snaps. There are no further changes to the boot assets,
 	// unless a new gadget update is deployed.
-	runner.AddHandler("update-gadget-assets", m.doUpdateGadgetAssets, nil)
+	mgrRunner.AddHandler("update-gadget-assets", m.doUpdateGadgetAssets, nil)
 	// There is no undo handler for successful boot config update. The
 	// config assets are assumed to be always backwards compatible.
-	runner.AddHandler("update-managed-boot-config", m.doUpdateManagedBootConfig, nil)
+	mgrRunner.AddHandler("update-managed-boot-config", m.doUpdateManagedBootConfig, nil)
 	// kernel command line updates from a gadget supplied file
-	runner.AddHandler("update-gadget-cmdline", m.doUpdateGadgetCommandLine, m.undoUpdateGadgetCommandLine)
+	mgrRunner.AddHandler("update-gadget-cmdline", m.doUpdateGadgetCommandLine, m.undoUpdateGadgetCommandLine)
 	// recovery systems
-	runner.AddHandler("remove-recovery-system", m.doRemoveRecoverySystem, nil)
-	runner.AddHandler("create-recovery-system", m.doCreateRecoverySystem, m.undoCreateRecoverySystem)
+	mgrRunner.AddHandler("remove-recovery-system", m.doRemoveRecoverySystem, nil)
+	mgrRunner.AddHandler("create-recovery-system", m.doCreateRecoverySystem, m.undoCreateRecoverySystem)
 	runner.AddCleanup("create-recovery-system", m.cleanupRecoverySystem)
-	runner.AddHandler("finalize-recovery-system", m.doFinalizeTriedRecoverySystem, m.undoFinalizeTriedRecoverySystem)
+	mgrRunner.AddHandler("finalize-recovery-system", m.doFinalizeTriedRecoverySystem, m.undoFinalizeTriedRecoverySystem)
 	runner.AddCleanup("finalize-recovery-system", m.cleanupRecoverySystem)
 
 	// used from the install API
 	// TODO: use better task names that are close to our usual pattern
-	runner.AddHandler("install-finish", m.doInstallFinish, nil)
-	runner.AddHandler("install-setup-storage-encryption", m.doInstallSetupStorageEncryption, nil)
+	mgrRunner.AddHandler("install-finish", m.doInstallFinish, nil)
+	mgrRunner.AddHandler("install-setup-storage-encryption", m.doInstallSetupStorageEncryption, nil)
 
 	runner.AddBlocked(gadgetUpdateBlocked)
 
@@ -223,7 +223,7 @@ func (m *DeviceManager) doUpdateGadgetAssets(t *state.Task, _ *tomb.Tomb) error
 	}
 
 	if err := os.RemoveAll(snapRollbackDir); err != nil && !os.IsNotExist(err) {
-		logger.Noticef("failed to remove gadget update rollback directory %q: %v", snapRollbackDir, err)
+		t.Logger().Noticef("failed to remove gadget update rollback directory %q: %v", snapRollbackDir, err)
 	}
 
 	// TODO: consider having the option to do this early via recovery in
@@ -305,13 +305,13 @@ func buildAppendedKernelCommandLine(t *state.Task, gd *gadget.GadgetData, device
 			[]string{cmdlineAppend, cmdlineAppendDanger}, " ")
 	}
 
-	logger.Debugf("appended kernel command line part is %q", cmdlineAppend)
+	t.Logger().Debugf("appended kernel command line part is %q", cmdlineAppend)
 
 	return cmdlineAppend, nil
 }
 
 func (m *DeviceManager) updateGadgetCommandLine(t *state.Task, st *state.State, useCurrentGadget bool) (updated bool, err error) {
-	logger.Debugf("updating kernel command line")
+	t.Logger().Debugf("updating kernel command line")
 	devCtx, err := DeviceCtx(st, t, nil)
 	if err != nil {
 		return false, err
@@ -392,7 +392,7 @@ func (m *DeviceManager) doUpdateGadgetCommandLine(t *state.Task, _ *tomb.Tomb) e
 		return err
 	}
 	if !updated {
-		logger.Debugf("no kernel command line update from gadget")
+		t.Logger().Debugf("no kernel command line update from gadget")
 		return nil
 	}
 	t.Logf("Updated kernel command line")
@@ -402,7 +402,7 @@ func (m *DeviceManager) doUpdateGadgetCommandLine(t *state.Task, _ *tomb.Tomb) e
 	// kernel command line
 
 	if isSysOption {
-		logger.Debugf("change comes from system option, we do not reboot")
+		t.Logger().Debugf("change comes from system option, we do not reboot")
 		t.SetStatus(state.DoneStatus)
 		return nil
 	}
@@ -440,7 +440,7 @@ func (m *DeviceManager) undoUpdateGadgetCommandLine(t *state.Task, _ *tomb.Tomb)
 		return err
 	}
 	if !updated {
-		logger.Debugf("no kernel command line update to undo")
+		t.Logger().Debugf("no kernel command line update to undo")
 		return nil
 	}
 	t.Logf("Reverted kernel command line change")
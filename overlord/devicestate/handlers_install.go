@@ -281,7 +281,7 @@ func (m *DeviceManager) doSetupRunSystem(t *state.Task, _ *tomb.Tomb) error {
 
 	var installedSystem *install.InstalledSystemSideData
 	// run the create partition code
-	logger.Noticef("create and deploy partitions")
+	t.Logger().Noticef("create and deploy partitions")
 
 	// Load seed to find out kernel-modules components in run mode
 	systemAndSnaps, mntPtForType, mntPtForComps, unmount,
@@ -324,7 +324,7 @@ func (m *DeviceManager) doSetupRunSystem(t *state.Task, _ *tomb.Tomb) error {
 
 	// make it bootable, which should be the final step in the process, as
 	// it effectively makes it possible to boot into run mode
-	logger.Noticef("make system runnable")
+	t.Logger().Noticef("make system runnable")
 	bootBaseInfo, err := snapstate.BootBaseInfo(st, deviceCtx)
 	if err != nil {
 		return fmt.Errorf("cannot get boot base info: %v", err)
@@ -387,13 +387,13 @@ func (m *DeviceManager) doRestartSystemToRunMode(t *state.Task, _ *tomb.Tomb) er
 
 	preseeded, err := maybeApplyPreseededData(model, boot.InitramfsUbuntuSeedDir, modeEnv.RecoverySystem, boot.InstallHostWritableDir(model))
 	if err != nil {
-		logger.Noticef("failed to apply preseed data: %v", err)
+		t.Logger().Noticef("failed to apply preseed data: %v", err)
 		return err
 	}
 	if preseeded {
-		logger.Noticef("successfully preseeded the system")
+		t.Logger().Noticef("successfully preseeded the system")
 	} else {
-		logger.Noticef("preseed data not present, will do normal seeding")
+		t.Logger().Noticef("preseed data not present, will do normal seeding")
 	}
 
 	// if the model has a gadget snap, and said gadget snap has an install-device hook
@@ -423,11 +423,11 @@ func (m *DeviceManager) doRestartSystemToRunMode(t *state.Task, _ *tomb.Tomb) er
 
 	// write timing information
 	if err := writeTimings(st, boot.InstallHostWritableDir(model), modeEnv.Mode); err != nil {
-		logger.Noticef("cannot write timings: %v", err)
+		t.Logger().Noticef("cannot write timings: %v", err)
 	}
 	// store install-mode log into ubuntu-data partition
 	if err := writeLogs(boot.InstallHostWritableDir(model), modeEnv.Mode); err != nil {
-		logger.Noticef("cannot write installation log: %v", err)
+		t.Logger().Noticef("cannot write installation log: %v", err)
 	}
 
 	// request by default a restart as the last action after a
@@ -443,7 +443,7 @@ func (m *DeviceManager) doRestartSystemToRunMode(t *state.Task, _ *tomb.Tomb) er
 		what = "poweroff"
 		rst = restart.RestartSystemPoweroffNow
 	}
-	logger.Noticef("request immediate system %s", what)
+	t.Logger().Noticef("request immediate system %s", what)
 	restart.Request(st, rst, nil)
 
 	return nil
@@ -578,7 +578,7 @@ func (m *DeviceManager) doFactoryResetRunSystem(t *state.Task, _ *tomb.Tomb) err
 	kBootInfo := kBootInfo(systemAndSnaps, kernMntPoint, mntPtForComps, isCore)
 
 	// run the create partition code
-	logger.Noticef("create and deploy partitions")
+	t.Logger().Noticef("create and deploy partitions")
 	var installedSystem *install.InstalledSystemSideData
 	timings.Run(perfTimings, "factory-reset", "Factory reset", func(tm timings.Measurer) {
 		st.Unlock()
@@ -589,7 +589,7 @@ func (m *DeviceManager) doFactoryResetRunSystem(t *state.Task, _ *tomb.Tomb) err
 	if err != nil {
 		return fmt.Errorf("cannot perform factory reset: %v", err)
 	}
-	logger.Noticef("devs: %+v", installedSystem.DeviceForRole)
+	t.Logger().Noticef("devs: %+v", installedSystem.DeviceForRole)
 
 	if trustedInstallObserver != nil {
 		// We are required to call ObserveExistingTrustedRecoveryAssets on trusted observers
@@ -654,7 +654,7 @@ func (m *DeviceManager) doFactoryResetRunSystem(t *state.Task, _ *tomb.Tomb) err
 	}
 
 	// make it bootable
-	logger.Noticef("make system runnable")
+	t.Logger().Noticef("make system runnable")
 	bootBaseInfo, err := snapstate.BootBaseInfo(st, deviceCtx)
 	if err != nil {
 		return fmt.Errorf("cannot get boot base info: %v", err)
@@ -1026,7 +1026,7 @@ func (m *DeviceManager) doInstallFinish(t *state.Task, _ *tomb.Tomb) error {
 	}
 	useEncryption := encryptSetupData != nil
 
-	logger.Debugf("starting install-finish for %q (using encryption: %t) on %v", systemLabel, useEncryption, onVolumes)
+	t.Logger().Debugf("starting install-finish for %q (using encryption: %t) on %v", systemLabel, useEncryption, onVolumes)
 
 	// TODO we probably want to pass a different location for the assets cache
 	installObserver, trustedInstallObserver, err := installLogic.BuildInstallObserver(systemAndSnaps.Model, mntPtForType[snap.TypeGadget], useEncryption)
@@ -1087,7 +1087,7 @@ func (m *DeviceManager) doInstallFinish(t *state.Task, _ *tomb.Tomb) error {
 	isCore := !systemAndSnaps.Model.Classic()
 	kBootInfo := kBootInfo(systemAndSnaps, kernMntPoint, mntPtForComps, isCore)
 
-	logger.Debugf("writing content to partitions")
+	t.Logger().Debugf("writing content to partitions")
 	timings.Run(perfTimings, "install-content", "Writing content to partitions", func(tm timings.Measurer) {
 		st.Unlock()
 		defer st.Lock()
@@ -1124,7 +1124,7 @@ func (m *DeviceManager) doInstallFinish(t *state.Task, _ *tomb.Tomb) error {
 			}
 		}
 
-		logger.Debugf("copying label %q to seed partition", systemAndSnaps.Label)
+		t.Logger().Debugf("copying label %q to seed partition", systemAndSnaps.Label)
 		if err := copier.Copy(seedMntDir, seed.CopyOptions{
 			Label:              systemAndSnaps.Label,
 			OptionalContainers: optional,
@@ -1212,7 +1212,7 @@ func (m *DeviceManager) doInstallFinish(t *state.Task, _ *tomb.Tomb) error {
 	}
 
 	// installs in system-seed{,-null} partition: grub.cfg, grubenv
-	logger.Debugf("making the system-seed{,-null} partition bootable, mount dir is %q", seedMntDir)
+	t.Logger().Debugf("making the system-seed{,-null} partition bootable, mount dir is %q", seedMntDir)
 	opts := &bootloader.Options{
 		PrepareImageTime: false,
 		// We need the same configuration that a recovery partition,
@@ -1249,7 +1249,7 @@ func (m *DeviceManager) doInstallFinish(t *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
-	logger.Debugf("making the installed system runnable for system label %s", systemLabel)
+	t.Logger().Debugf("making the installed system runnable for system label %s", systemLabel)
 	if err := bootMakeRunnableStandalone(systemAndSnaps.Model, bootWith, trustedInstallObserver, st.Unlocker()); err != nil {
 		return err
 	}
@@ -1294,7 +1294,7 @@ func (m *DeviceManager) doInstallSetupStorageEncryption(t *state.Task, _ *tomb.T
 	if err := t.Get("on-volumes", &onVolumes); err != nil {
 		return err
 	}
-	logger.Debugf("install-setup-storage-encryption for %q on %v", systemLabel, onVolumes)
+	t.Logger().Debugf("install-setup-storage-encryption for %q on %v", systemLabel, onVolumes)
 	var volumesAuthRequired bool
 	if err := t.Get("volumes-auth-required", &volumesAuthRequired); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
@@ -26,7 +26,6 @@ import (
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/gadget"
-	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
@@ -125,7 +124,7 @@ func (m *DeviceManager) doSetModel(t *state.Task, _ *tomb.Tomb) (err error) {
 
 		// restore the old validation sets if something went wrong
 		if err := rollBackValidationSets(st, currentSets, newSets, remodCtx); err != nil {
-			logger.Debugf("cannot rollback validation sets: %v", err)
+			t.Logger().Debugf("cannot rollback validation sets: %v", err)
 		}
 	}()
 
@@ -170,8 +169,8 @@ func (m *DeviceManager) doSetModel(t *state.Task, _ *tomb.Tomb) (err error) {
 	}
 
 	logEverywhere := func(format string, args ...interface{}) {
-		t.Logf(format, args)
-		logger.Noticef(format, args)
+		t.Logf(format, args...)
+		t.Logger().Noticef(format, args...)
 	}
 
 	// and finish (this will set the new model), note that changes done in
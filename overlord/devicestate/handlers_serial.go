@@ -36,7 +36,6 @@ import (
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/httputil"
-	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
@@ -677,11 +676,11 @@ func getSerialRequestConfig(t *state.Task, regCtx registrationContext, client *h
 			// if they want to use the proxy store for their
 			// device-service.url or not. This needs design.
 			// (see LP:#2023166)
-			logger.Noticef("cannot reach proxy store: %v; ignore the proxy", err)
+			t.Logger().Noticef("cannot reach proxy store: %v; ignore the proxy", err)
 			proxyURL = nil
 		}
 		if !newEnough {
-			logger.Noticef("Proxy store does not support custom serial vault; ignoring the proxy")
+			t.Logger().Noticef("Proxy store does not support custom serial vault; ignoring the proxy")
 			proxyURL = nil
 		}
 	}
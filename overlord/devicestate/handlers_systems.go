@@ -336,7 +336,7 @@ func (m *DeviceManager) doCreateRecoverySystem(t *state.Task, _ *tomb.Tomb) (err
 			return
 		}
 		if err := purgeNewSystemSnapFiles(filepath.Join(systemDirectory, "snapd-new-file-log")); err != nil {
-			logger.Noticef("when removing seed files: %v", err)
+			t.Logger().Noticef("when removing seed files: %v", err)
 		}
 		// this is ok, as before the change with this task was created,
 		// we checked that the system directory did not exist; it may
@@ -344,10 +344,10 @@ func (m *DeviceManager) doCreateRecoverySystem(t *state.Task, _ *tomb.Tomb) (err
 		// task is being re-run after a reboot and creating a system
 		// failed
 		if err := os.RemoveAll(systemDirectory); err != nil && !os.IsNotExist(err) {
-			logger.Noticef("when removing recovery system %q: %v", label, err)
+			t.Logger().Noticef("when removing recovery system %q: %v", label, err)
 		}
 		if err := boot.DropRecoverySystem(remodelCtx, label); err != nil {
-			logger.Noticef("when dropping the recovery system %q: %v", label, err)
+			t.Logger().Noticef("when dropping the recovery system %q: %v", label, err)
 		}
 		// we could have reentered the task after a reboot, but the
 		// state was set up sufficiently such that the system was
@@ -364,7 +364,7 @@ func (m *DeviceManager) doCreateRecoverySystem(t *state.Task, _ *tomb.Tomb) (err
 	if err != nil {
 		return fmt.Errorf("cannot create a recovery system with label %q for %v: %v", label, model.Model(), err)
 	}
-	logger.Debugf("recovery system dir: %v", systemDirectory)
+	t.Logger().Debugf("recovery system dir: %v", systemDirectory)
 
 	// 2. keep track of the system in task state
 	if err := setTaskRecoverySystemSetup(t, setup); err != nil {
@@ -404,7 +404,7 @@ func (m *DeviceManager) doCreateRecoverySystem(t *state.Task, _ *tomb.Tomb) (err
 	}
 
 	// this task is done, further processing happens in finalize
-	logger.Noticef("restarting into candidate system %q", label)
+	t.Logger().Noticef("restarting into candidate system %q", label)
 	return snapstate.FinishTaskWithRestart(t, state.DoneStatus, restart.RestartSystemNow, nil)
 }
 
@@ -489,7 +489,7 @@ func (m *DeviceManager) doFinalizeTriedRecoverySystem(t *state.Task, _ *tomb.Tom
 	}
 	label := setup.Label
 
-	logger.Debugf("finalize recovery system with label %q", label)
+	t.Logger().Debugf("finalize recovery system with label %q", label)
 
 	if isRemodel {
 		// so far so good, a recovery system created during remodel was
@@ -501,9 +501,9 @@ func (m *DeviceManager) doFinalizeTriedRecoverySystem(t *state.Task, _ *tomb.Tom
 
 		// XXX: candidate system is promoted to the list of good ones once we
 		// complete the whole remodel change
-		logger.Debugf("recovery system created during remodel will be promoted later")
+		t.Logger().Debugf("recovery system created during remodel will be promoted later")
 	} else {
-		logger.Debugf("promoting recovery system %q", label)
+		t.Logger().Debugf("promoting recovery system %q", label)
 
 		if err := boot.PromoteTriedRecoverySystem(remodelCtx, label, triedSystems); err != nil {
 			return fmt.Errorf("cannot promote recovery system %q: %v", label, err)
@@ -116,3 +116,22 @@ func MockSystemdSdNotify(f func(notifyState string) error) (restore func()) {
 		systemdSdNotify = old
 	}
 }
+
+// NewStateBackend returns the backend the overlord uses for its state at
+// path, journaled or not.
+func NewStateBackend(path string, journaled bool) *overlordStateBackend {
+	return &overlordStateBackend{
+		path:          path,
+		ensureBefore:  func(time.Duration) {},
+		journaled:     journaled,
+		compactBefore: func(time.Duration) {},
+	}
+}
+
+func (osb *overlordStateBackend) Load() ([]byte, error) {
+	return osb.load()
+}
+
+func MockStateJournalMaxAge(d time.Duration) (restore func()) {
+	return testutil.Mock(&stateJournalMaxAge, d)
+}
@@ -107,10 +107,11 @@ func Manager(st *state.State, runner *state.TaskRunner) (*FDEManager, error) {
 
 	snapstate.RegisterAffectedSnapsByKind("efi-secureboot-db-update", dbxUpdateAffectedSnaps)
 
-	runner.AddHandler("efi-secureboot-db-update-prepare",
+	mgrRunner := runner.ForManager("fdestate")
+	mgrRunner.AddHandler("efi-secureboot-db-update-prepare",
 		m.doEFISecurebootDBUpdatePrepare, m.undoEFISecurebootDBUpdatePrepare)
 	runner.AddCleanup("efi-secureboot-db-update-prepare", m.doEFISecurebootDBUpdatePrepareCleanup)
-	runner.AddHandler("efi-secureboot-db-update", m.doEFISecurebootDBUpdate, nil)
+	mgrRunner.AddHandler("efi-secureboot-db-update", m.doEFISecurebootDBUpdate, nil)
 	runner.AddBlocked(func(t *state.Task, running []*state.Task) bool {
 		switch t.Kind() {
 		case "efi-secureboot-db-update":
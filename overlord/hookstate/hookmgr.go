@@ -41,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/naming"
+	"github.com/snapcore/snapd/timings"
 )
 
 type hijackFunc func(ctx *Context) error
@@ -139,14 +140,17 @@ func Manager(s *state.State, runner *state.TaskRunner) (*HookManager, error) {
 		runner:     runner,
 	}
 
-	runner.AddHandler("run-hook", manager.doRunHook, manager.undoRunHook)
+	mgrRunner := runner.ForManager("hookstate")
+	mgrRunner.AddHandler("run-hook", manager.doRunHook, manager.undoRunHook)
 	// Compatibility with snapd between 2.29 and 2.30 in edge only.
 	// We generated a configure-snapd task on core refreshes and
 	// for compatibility we need to handle those.
-	runner.AddHandler("configure-snapd", func(*state.Task, *tomb.Tomb) error {
+	mgrRunner.AddHandler("configure-snapd", func(*state.Task, *tomb.Tomb) error {
 		return nil
 	}, nil)
 
+	runner.AddLogAttrs(hookLogAttrs)
+
 	setupHooks(manager)
 
 	snapstate.RegisterAffectedSnapsByAttr("hook-setup", manager.hookAffectedSnaps)
@@ -154,6 +158,19 @@ func Manager(s *state.State, runner *state.TaskRunner) (*HookManager, error) {
 	return manager, nil
 }
 
+// hookLogAttrs returns the snap and the hook a hook task is about, for
+// structured logs.
+func hookLogAttrs(t *state.Task) []any {
+	if t.Kind() != "run-hook" {
+		return nil
+	}
+	var hooksup HookSetup
+	if err := t.Get("hook-setup", &hooksup); err != nil {
+		return nil
+	}
+	return []any{"snap", hooksup.Snap, "hook", hooksup.Hook}
+}
+
 // Register registers a function to create Handler values whenever hooks
 // matching the provided pattern are run.
 func (m *HookManager) Register(pattern *regexp.Regexp, generator HandlerGenerator) {
@@ -326,7 +343,24 @@ func (m *HookManager) runHookForTask(task *state.Task, tomb *tomb.Tomb, snapst *
 	if err != nil {
 		return err
 	}
-	return m.runHook(context, snapst, hooksup, tomb)
+
+	st := task.State()
+	st.Lock()
+	perfTimings := state.TimingsForTask(task)
+	st.Unlock()
+
+	summary := fmt.Sprintf("run hook %q of snap %q", hooksup.Hook, hooksup.Snap)
+	if hooksup.Component != "" {
+		summary = fmt.Sprintf(`run hook %q of component "%s+%s"`, hooksup.Hook, hooksup.Snap, hooksup.Component)
+	}
+	timings.Run(perfTimings, "run-hook", summary, func(timings.Measurer) {
+		err = m.runHook(context, snapst, hooksup, tomb)
+	})
+
+	st.Lock()
+	perfTimings.Save(st)
+	st.Unlock()
+	return err
 }
 
 // runHookGuardForRestarting helps avoiding running a hook if we are
@@ -45,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/snap/naming"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timings"
 )
 
 func TestHookManager(t *testing.T) { TestingT(t) }
@@ -297,6 +298,41 @@ func (s *hookManagerSuite) TestHookTaskEnsureRestarting(c *C) {
 	c.Check(s.manager.NumRunningHooks(), Equals, 0)
 }
 
+func (s *hookManagerSuite) TestHookTaskTimings(c *C) {
+	s.state.Lock()
+	oldDurationThreshold := timings.DurationThreshold
+	timings.DurationThreshold = 0
+	s.state.Unlock()
+	defer func() {
+		s.state.Lock()
+		timings.DurationThreshold = oldDurationThreshold
+		s.state.Unlock()
+	}()
+
+	s.se.Ensure()
+	s.se.Wait()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(s.task.Status(), Equals, state.DoneStatus)
+
+	tms, err := timings.Get(s.state, -1, func(tags map[string]string) bool {
+		return tags["task-id"] == s.task.ID()
+	})
+	c.Assert(err, IsNil)
+	c.Assert(tms, HasLen, 1)
+	c.Check(tms[0].Tags, DeepEquals, map[string]string{
+		"task-id":     s.task.ID(),
+		"task-kind":   "run-hook",
+		"task-status": "Doing",
+		"change-id":   s.change.ID(),
+	})
+	c.Assert(tms[0].NestedTimings, HasLen, 1)
+	c.Check(tms[0].NestedTimings[0].Label, Equals, "run-hook")
+	c.Check(tms[0].NestedTimings[0].Summary, Equals, `run hook "configure" of snap "test-snap"`)
+}
+
 func (s *hookManagerSuite) TestHookSnapMissing(c *C) {
 	s.state.Lock()
 	snapstate.Set(s.state, "test-snap", nil)
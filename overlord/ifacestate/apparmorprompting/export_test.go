@@ -20,6 +20,8 @@
 package apparmorprompting
 
 import (
+	"time"
+
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify"
@@ -135,6 +137,10 @@ func MockPromptsHandleReadying(f func(pdb *requestprompts.PromptDB) error) (rest
 	return testutil.Mock(&promptsHandleReadying, f)
 }
 
+func MockRulesMaintenanceInterval(interval time.Duration) (restore func()) {
+	return testutil.Mock(&rulesMaintenanceInterval, interval)
+}
+
 func MockPromptingInterfaceFromTagsets(f func(tagsets notify.TagsetMap) (string, error)) (restore func()) {
 	return testutil.Mock(&promptingInterfaceFromTagsets, f)
 }
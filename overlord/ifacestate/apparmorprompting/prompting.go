@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"gopkg.in/tomb.v2"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify/listener"
@@ -53,6 +55,10 @@ var (
 	promptsHandleReadying = (*requestprompts.PromptDB).HandleReadying
 
 	promptingInterfaceFromTagsets = prompting.InterfaceFromTagsets
+
+	// rulesMaintenanceInterval is how often rule statistics are saved and
+	// unused rules are expired.
+	rulesMaintenanceInterval = time.Hour
 )
 
 // A Manager holds outstanding prompts and mediates their replies, further it
@@ -67,13 +73,16 @@ type Manager interface {
 	RuleWithID(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
 	PatchRule(userID uint32, ruleID prompting.IDType, constraintsPatch *prompting.RuleConstraintsPatch) (*requestrules.Rule, error)
 	RemoveRule(userID uint32, ruleID prompting.IDType) (*requestrules.Rule, error)
+	ExportRules(userID uint32, snap string, iface string) ([]*requestrules.ExportedRule, error)
+	ImportRules(userID uint32, rules []*requestrules.ExportedRule) ([]*requestrules.Rule, error)
 }
 
 // verify that InterfacesRequestsManager implements Manager
 var _ Manager = (*InterfacesRequestsManager)(nil)
 
 type InterfacesRequestsManager struct {
-	tomb tomb.Tomb
+	tomb  tomb.Tomb
+	state *state.State
 	// The lock should be held for writing when acting on the manager in a way
 	// which requires synchronization between the prompts and rules databases,
 	// or when removing those databases. The lock can be held for reading when
@@ -118,7 +127,13 @@ func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
 		options := state.AddNoticeOptions{
 			Data: data,
 		}
-		_, err := s.AddNotice(&userID, state.InterfacesRequestsRuleUpdateNotice, ruleID.String(), &options)
+		noticeUserID := &userID
+		if userID == prompting.AllUsers {
+			// Rules for all users concern every user, so the notice should
+			// be visible to all of them.
+			noticeUserID = nil
+		}
+		_, err := s.AddNotice(noticeUserID, state.InterfacesRequestsRuleUpdateNotice, ruleID.String(), &options)
 		return err
 	}
 
@@ -153,6 +168,7 @@ func New(s *state.State) (m *InterfacesRequestsManager, retErr error) {
 	}()
 
 	m = &InterfacesRequestsManager{
+		state:        s,
 		listener:     listenerBackend,
 		prompts:      promptsBackend,
 		rules:        rulesBackend,
@@ -191,6 +207,9 @@ func (m *InterfacesRequestsManager) run() error {
 		}
 	}()
 
+	maintenanceTicker := time.NewTicker(rulesMaintenanceInterval)
+	defer maintenanceTicker.Stop()
+
 run_loop:
 	for {
 		logger.Debugf("waiting prompt loop")
@@ -236,6 +255,8 @@ run_loop:
 			if err := m.handleListenerReq(req); err != nil {
 				logger.Noticef("error while handling request: %+v", err)
 			}
+		case <-maintenanceTicker.C:
+			m.maintainRules()
 		case <-m.tomb.Dying():
 			logger.Debugf("InterfacesRequestsManager tomb is dying with error %v, disconnecting", m.tomb.Err())
 			break run_loop
@@ -244,6 +265,59 @@ run_loop:
 	return m.disconnect()
 }
 
+// maintainRules saves the statistics of the rules, and removes the rules which
+// have not been used within the period set by the prompting.unused-rule-expiry
+// system option, if any.
+func (m *InterfacesRequestsManager) maintainRules() {
+	unusedFor := unusedRuleExpiry(m.state)
+
+	// The lock need only be held for reading, since no synchronization is
+	// required between the rules and prompts backends, and the rules backend
+	// has an internal mutex.
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if err := m.rules.SaveStats(); err != nil {
+		logger.Noticef("cannot save prompting rule statistics: %v", err)
+	}
+	if unusedFor == 0 {
+		return
+	}
+	removed, err := m.rules.ExpireUnusedRules(unusedFor)
+	if err != nil {
+		logger.Noticef("cannot expire unused prompting rules: %v", err)
+		return
+	}
+	if len(removed) > 0 {
+		logger.Noticef("removed %d prompting rules unused for %s", len(removed), unusedFor)
+	}
+}
+
+// unusedRuleExpiry returns the duration set by the prompting.unused-rule-expiry
+// system option, or 0 if it is unset or invalid.
+func unusedRuleExpiry(st *state.State) time.Duration {
+	st.Lock()
+	defer st.Unlock()
+
+	var expiryStr string
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "prompting.unused-rule-expiry", &expiryStr); err != nil {
+		if !config.IsNoOption(err) {
+			logger.Noticef("cannot get prompting.unused-rule-expiry: %v", err)
+		}
+		return 0
+	}
+	if expiryStr == "" {
+		return 0
+	}
+	expiry, err := time.ParseDuration(expiryStr)
+	if err != nil {
+		logger.Noticef("prompting.unused-rule-expiry cannot be parsed: %v", err)
+		return 0
+	}
+	return expiry
+}
+
 func (m *InterfacesRequestsManager) listenerReadyForTheFirstTime() <-chan struct{} {
 	select {
 	case <-m.ready:
@@ -513,7 +587,9 @@ func (m *InterfacesRequestsManager) applyRuleToOutstandingPrompts(rule *requestr
 }
 
 // Rules returns all rules for the user with the given user ID and,
-// optionally, only those for the given snap and/or interface.
+// optionally, only those for the given snap and/or interface. The rules
+// defined by an administrator for all users are included as well, or
+// exclusively if the given user ID is prompting.AllUsers.
 func (m *InterfacesRequestsManager) Rules(userID uint32, snap string, iface string) ([]*requestrules.Rule, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
@@ -622,3 +698,38 @@ func (m *InterfacesRequestsManager) RemoveRule(userID uint32, ruleID prompting.I
 	rule, err := m.rules.RemoveRule(userID, ruleID)
 	return rule, err
 }
+
+// ExportRules returns the rules of the user with the given user ID and,
+// optionally, only those for the given snap and/or interface, in a form which
+// can be imported for another user or on another system. The rules defined by
+// an administrator for all users are only exported if the given user ID is
+// prompting.AllUsers.
+func (m *InterfacesRequestsManager) ExportRules(userID uint32, snap string, iface string) ([]*requestrules.ExportedRule, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	rules := m.rules.ExportRules(userID, snap, iface)
+	return rules, nil
+}
+
+// ImportRules adds the given exported rules for the user with the given user
+// ID and then checks them against outstanding prompts, resolving any prompts
+// which they satisfy. Either all of the rules are imported or none are.
+func (m *InterfacesRequestsManager) ImportRules(userID uint32, rules []*requestrules.ExportedRule) ([]*requestrules.Rule, error) {
+	// Wait until the listener has re-sent pending requests and prompts have
+	// been re-created.
+	<-m.ready
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	imported, err := m.rules.ImportRules(userID, rules)
+	if err != nil {
+		return nil, err
+	}
+	// Apply imported rules to outstanding prompts.
+	for _, rule := range imported {
+		m.applyRuleToOutstandingPrompts(rule)
+	}
+	return imported, nil
+}
@@ -21,6 +21,7 @@ package apparmorprompting_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -38,6 +39,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/prompting/requestprompts"
 	"github.com/snapcore/snapd/interfaces/prompting/requestrules"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/ifacestate/apparmorprompting"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/sandbox/apparmor/notify"
@@ -241,18 +243,37 @@ func (s *apparmorpromptingSuite) TestHandleListenerRequestInterfaceSelection(c *
 	c.Check(prompts[1].Interface, Equals, "home")
 	restore()
 
-	// Explicitly set some other interface based on tags.
-	// Currently only "home" is supported, so we expect a later error in order
-	// to see that the given interface was used when mapping permissions.
-	// TODO: when other interfaces are supported, use one here instead.
+	// Explicitly set some other supported interface based on tags
 	restore = apparmorprompting.MockPromptingInterfaceFromTagsets(func(notify.TagsetMap) (string, error) {
-		return "foo", nil
+		return "camera", nil
 	})
 	req = &listener.Request{
 		// Most fields don't matter here
 		ID:         3,
 		Label:      "snap3",
 		SubjectUID: s.defaultUser,
+		Permission: notify.AA_MAY_OPEN | notify.AA_MAY_READ,
+	}
+	reqChan <- req
+	time.Sleep(10 * time.Millisecond)
+	prompts, err = mgr.Prompts(s.defaultUser, clientActivity)
+	c.Check(err, IsNil)
+	c.Assert(prompts, HasLen, 3)
+	c.Check(prompts[2].Interface, Equals, "camera")
+	c.Check(prompts[2].Constraints.OutstandingPermissions(), DeepEquals, []string{"access"})
+	restore()
+
+	// Explicitly set an unsupported interface based on tags, and expect a
+	// later error in order to see that the given interface was used when
+	// mapping permissions.
+	restore = apparmorprompting.MockPromptingInterfaceFromTagsets(func(notify.TagsetMap) (string, error) {
+		return "foo", nil
+	})
+	req = &listener.Request{
+		// Most fields don't matter here
+		ID:         4,
+		Label:      "snap4",
+		SubjectUID: s.defaultUser,
 		Permission: notify.AA_MAY_OPEN,
 	}
 	reqChan <- req
@@ -1335,20 +1356,16 @@ func (s *apparmorpromptingSuite) prepManagerWithRules(c *C) (mgr *apparmorprompt
 
 	// Add rule for firefox and camera
 	constraints = &prompting.Constraints{
-		PathPattern: mustParsePathPattern(c, "/home/test/3"),
+		PathPattern: mustParsePathPattern(c, "/dev/video3"),
 		Permissions: prompting.PermissionMap{
-			"read": &prompting.PermissionEntry{
+			"access": &prompting.PermissionEntry{
 				Outcome:  prompting.OutcomeAllow,
 				Lifespan: prompting.LifespanForever,
 			},
 		},
 	}
-	rule3, err := mgr.AddRule(s.defaultUser, "firefox", "home", constraints)
+	rule3, err := mgr.AddRule(s.defaultUser, "firefox", "camera", constraints)
 	c.Assert(err, IsNil)
-	// Since camera interface isn't supported yet, must adjust the interface
-	// after the rule has been created. This abuses implementation details of
-	// the requestrules backend.
-	rule3.Interface = "camera"
 	rules = append(rules, rule3)
 
 	// Add rule for firefox and home, but for a different user
@@ -1485,7 +1502,7 @@ func (s *apparmorpromptingSuite) TestAddRuleWithIDPatchRemove(c *C) {
 	whenAccessed := time.Now()
 	retrieved, err := mgr.RuleWithID(rule.User, rule.ID)
 	c.Assert(err, IsNil)
-	c.Assert(retrieved, Equals, rule)
+	c.Assert(retrieved, DeepEquals, rule)
 	s.checkRecordedRuleUpdateNotices(c, whenAccessed, 0)
 
 	// Check prompt still exists and no prompt notices recorded since before
@@ -1518,7 +1535,7 @@ func (s *apparmorpromptingSuite) TestAddRuleWithIDPatchRemove(c *C) {
 	// Check that RuleWithID with original ID returns patched rule
 	retrieved, err = mgr.RuleWithID(rule.User, rule.ID)
 	c.Assert(err, IsNil)
-	c.Assert(retrieved, Equals, patched)
+	c.Assert(retrieved, DeepEquals, patched)
 
 	// Check that prompt has been satisfied
 	_, err = mgr.PromptWithID(s.defaultUser, prompt.ID, clientActivity)
@@ -1534,7 +1551,7 @@ func (s *apparmorpromptingSuite) TestAddRuleWithIDPatchRemove(c *C) {
 	whenRemoved := time.Now()
 	removed, err := mgr.RemoveRule(rule.User, rule.ID)
 	c.Assert(err, IsNil)
-	c.Assert(removed, Equals, patched)
+	c.Assert(removed, DeepEquals, patched)
 	s.checkRecordedRuleUpdateNotices(c, whenRemoved, 1)
 
 	// Check that it can no longer be found
@@ -1547,6 +1564,164 @@ func (s *apparmorpromptingSuite) TestAddRuleWithIDPatchRemove(c *C) {
 	c.Assert(mgr.Stop(), IsNil)
 }
 
+func (s *apparmorpromptingSuite) TestImportExportRules(c *C) {
+	readyChan, reqChan, replyChan, restore := apparmorprompting.MockListener()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+
+	// simulateRequest checks mgr.Prompts, so make sure we close readyChan first
+	close(readyChan)
+
+	req := &listener.Request{
+		Permission: notify.AA_MAY_READ,
+	}
+	_, prompt := s.simulateRequest(c, reqChan, mgr, req, false)
+
+	exported := []*requestrules.ExportedRule{
+		{
+			Snap:      "firefox",
+			Interface: "home",
+			Constraints: &prompting.Constraints{
+				PathPattern: mustParsePathPattern(c, "/home/test/**"),
+				Permissions: prompting.PermissionMap{
+					"read": &prompting.PermissionEntry{
+						Outcome:  prompting.OutcomeAllow,
+						Lifespan: prompting.LifespanForever,
+					},
+				},
+			},
+		},
+		{
+			Snap:      "thunderbird",
+			Interface: "home",
+			Constraints: &prompting.Constraints{
+				PathPattern: mustParsePathPattern(c, "/home/test/Mail/**"),
+				Permissions: prompting.PermissionMap{
+					"write": &prompting.PermissionEntry{
+						Outcome:  prompting.OutcomeDeny,
+						Lifespan: prompting.LifespanForever,
+					},
+				},
+			},
+		},
+	}
+	whenImported := time.Now()
+	imported, err := mgr.ImportRules(s.defaultUser, exported)
+	c.Assert(err, IsNil)
+	c.Check(imported, HasLen, 2)
+	s.checkRecordedRuleUpdateNotices(c, whenImported, 2)
+
+	// The imported rule satisfied the outstanding prompt
+	clientActivity := false
+	_, err = mgr.PromptWithID(s.defaultUser, prompt.ID, clientActivity)
+	c.Check(err, Equals, prompting_errors.ErrPromptNotFound)
+	resp, err := waitForReply(replyChan)
+	c.Assert(err, IsNil)
+	c.Check(resp.Request, Equals, req)
+
+	// The imported rules can be exported again
+	reexported, err := mgr.ExportRules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Check(reexported, DeepEquals, exported)
+	reexported, err = mgr.ExportRules(s.defaultUser, "thunderbird", "")
+	c.Assert(err, IsNil)
+	c.Check(reexported, DeepEquals, exported[1:])
+	reexported, err = mgr.ExportRules(s.defaultUser+1, "", "")
+	c.Assert(err, IsNil)
+	c.Check(reexported, HasLen, 0)
+
+	// Importing conflicting rules fails and changes nothing
+	exported[1].Constraints.Permissions["write"].Outcome = prompting.OutcomeAllow
+	whenConflicting := time.Now()
+	_, err = mgr.ImportRules(s.defaultUser, exported)
+	c.Check(err, ErrorMatches, "cannot import rules: .*conflicting.*")
+	s.checkRecordedRuleUpdateNotices(c, whenConflicting, 0)
+	rules, err := mgr.Rules(s.defaultUser, "", "")
+	c.Assert(err, IsNil)
+	c.Check(rules, DeepEquals, imported)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
+func (s *apparmorpromptingSuite) TestRulesMaintenance(c *C) {
+	readyChan, _, _, restore := apparmorprompting.MockListener()
+	defer restore()
+	restore = apparmorprompting.MockRulesMaintenanceInterval(10 * time.Millisecond)
+	defer restore()
+
+	// Write rules which were last used 2 days ago and 2 hours ago
+	currTime := time.Now()
+	var storedRules []*requestrules.Rule
+	for i, lastMatched := range []time.Time{currTime.Add(-48 * time.Hour), currTime.Add(-2 * time.Hour)} {
+		storedRules = append(storedRules, &requestrules.Rule{
+			ID:        prompting.IDType(i + 1),
+			Timestamp: currTime.Add(-72 * time.Hour),
+			User:      s.defaultUser,
+			Snap:      "firefox",
+			Interface: "home",
+			Origin:    requestrules.OriginUser,
+			Constraints: &prompting.RuleConstraints{
+				PathPattern: mustParsePathPattern(c, fmt.Sprintf("/home/test/%d/**", i)),
+				Permissions: prompting.RulePermissionMap{
+					"read": &prompting.RulePermissionEntry{
+						Outcome:  prompting.OutcomeAllow,
+						Lifespan: prompting.LifespanForever,
+					},
+				},
+			},
+			HitCount:    1,
+			LastMatched: lastMatched,
+		})
+	}
+	data, err := json.Marshal(map[string]interface{}{"rules": storedRules})
+	c.Assert(err, IsNil)
+	c.Assert(os.MkdirAll(dirs.SnapInterfacesRequestsStateDir, 0o755), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapInterfacesRequestsStateDir, "request-rules.json"), data, 0o600), IsNil)
+
+	s.st.Lock()
+	tr := config.NewTransaction(s.st)
+	c.Assert(tr.Set("core", "prompting.unused-rule-expiry", "24h"), IsNil)
+	tr.Commit()
+	s.st.Unlock()
+
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	mgr, err := apparmorprompting.New(s.st)
+	c.Assert(err, IsNil)
+	close(readyChan)
+
+	var rules []*requestrules.Rule
+	for i := 0; i < 100; i++ {
+		rules, err = mgr.Rules(s.defaultUser, "", "")
+		c.Assert(err, IsNil)
+		if len(rules) < 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(rules, HasLen, 1)
+	c.Check(rules[0].ID, Equals, prompting.IDType(2))
+	logger.WithLoggerLock(func() {
+		c.Check(logbuf.String(), testutil.Contains, "removed 1 prompting rules unused for 24h0m0s")
+	})
+
+	s.st.Lock()
+	n := s.st.Notices(&state.NoticeFilter{
+		Types: []state.NoticeType{state.InterfacesRequestsRuleUpdateNotice},
+		Keys:  []string{prompting.IDType(1).String()},
+	})
+	s.st.Unlock()
+	c.Assert(n, HasLen, 1)
+	noticeJSON, err := json.Marshal(n[0])
+	c.Assert(err, IsNil)
+	c.Check(string(noticeJSON), testutil.Contains, `"last-data":{"removed":"unused"}`)
+
+	c.Assert(mgr.Stop(), IsNil)
+}
+
 func (s *apparmorpromptingSuite) TestListenerReadyCausesPromptsHandleReadying(c *C) {
 	readyChan, _, _, restore := apparmorprompting.MockListener()
 	defer restore()
@@ -0,0 +1,123 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/policy"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/snap"
+)
+
+// ConnectionExplanation explains the policy decisions about connecting a
+// plug to a slot.
+type ConnectionExplanation struct {
+	Plug      interfaces.PlugRef `json:"plug"`
+	Slot      interfaces.SlotRef `json:"slot"`
+	Interface string             `json:"interface"`
+	// Unasserted lists the snaps without a snap-declaration, manual
+	// connections involving them are not checked against the policy.
+	Unasserted []string `json:"unasserted,omitempty"`
+
+	Connection     *policy.Explanation `json:"connection"`
+	AutoConnection *policy.Explanation `json:"auto-connection"`
+}
+
+// ExplainConnection explains whether the given plug can be connected,
+// manually or automatically, to the given slot according to the declarations
+// in the assertion database. Missing plug or slot names are resolved like for
+// connecting. The state must be locked by the caller.
+func (m *InterfaceManager) ExplainConnection(plugSnap, plugName, slotSnap, slotName string) (*ConnectionExplanation, error) {
+	st := m.state
+	connRef, err := m.repo.ResolveConnect(plugSnap, plugName, slotSnap, slotName)
+	if err != nil {
+		return nil, err
+	}
+	plugInfo := m.repo.Plug(connRef.PlugRef.Snap, connRef.PlugRef.Name)
+	slotInfo := m.repo.Slot(connRef.SlotRef.Snap, connRef.SlotRef.Name)
+
+	deviceCtx, err := snapstate.DeviceCtx(st, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	baseDecl, err := assertstate.BaseDeclaration(st)
+	if err != nil {
+		return nil, fmt.Errorf("internal error: cannot find base declaration: %v", err)
+	}
+	modelAs := deviceCtx.Model()
+	var storeAs *asserts.Store
+	if modelAs.Store() != "" {
+		storeAs, err = assertstate.Store(st, modelAs.Store())
+		if err != nil && !errors.Is(err, &asserts.NotFoundError{}) {
+			return nil, err
+		}
+	}
+
+	expl := &ConnectionExplanation{
+		Plug:      connRef.PlugRef,
+		Slot:      connRef.SlotRef,
+		Interface: plugInfo.Interface,
+	}
+	snapDeclaration := func(info *snap.Info) (*asserts.SnapDeclaration, error) {
+		if info.SnapID == "" {
+			expl.Unasserted = append(expl.Unasserted, info.InstanceName())
+			return nil, nil
+		}
+		snapDecl, err := assertstate.SnapDeclaration(st, info.SnapID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find snap declaration for %q: %v", info.InstanceName(), err)
+		}
+		return snapDecl, nil
+	}
+	plugDecl, err := snapDeclaration(plugInfo.Snap)
+	if err != nil {
+		return nil, err
+	}
+	slotDecl, err := snapDeclaration(slotInfo.Snap)
+	if err != nil {
+		return nil, err
+	}
+
+	plugAppSet, err := interfaces.NewSnapAppSet(plugInfo.Snap, nil)
+	if err != nil {
+		return nil, err
+	}
+	slotAppSet, err := interfaces.NewSnapAppSet(slotInfo.Snap, nil)
+	if err != nil {
+		return nil, err
+	}
+	ic := policy.ConnectCandidate{
+		Plug:                interfaces.NewConnectedPlug(plugInfo, plugAppSet, nil, nil),
+		PlugSnapDeclaration: plugDecl,
+		Slot:                interfaces.NewConnectedSlot(slotInfo, slotAppSet, nil, nil),
+		SlotSnapDeclaration: slotDecl,
+		BaseDeclaration:     baseDecl,
+		Model:               modelAs,
+		Store:               storeAs,
+	}
+	expl.Connection = ic.ExplainConnect()
+	expl.AutoConnection = ic.ExplainAutoConnect()
+	return expl, nil
+}
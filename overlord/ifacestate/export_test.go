@@ -159,6 +159,22 @@ func MockUDevInitRetryTimeout(t time.Duration) (restore func()) {
 	}
 }
 
+func MockExpiredConnectionRetryInterval(d time.Duration) (restore func()) {
+	old := expiredConnectionRetryInterval
+	expiredConnectionRetryInterval = d
+	return func() {
+		expiredConnectionRetryInterval = old
+	}
+}
+
+func MockTimeNow(f func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = f
+	return func() {
+		timeNow = old
+	}
+}
+
 // UpperCaseConnState returns a canned connection state map.
 // This allows us to keep connState private and still write some tests for it.
 func UpperCaseConnState() map[string]*schema.ConnState {
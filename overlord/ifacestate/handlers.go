@@ -34,7 +34,6 @@ import (
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/hotplug"
-	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate/schema"
@@ -437,7 +436,7 @@ func (m *InterfaceManager) undoSetupProfiles(task *state.Task, tomb *tomb.Tomb)
 	// may be invoking tools (like apparmor) from the wrong snapd. We set
 	// the default to true as we cannot set it otherwise since the change will
 	// always have been created by the old snapd (that may not have "finish-restart")
-	logger.Debugf("finish restart from undoLinkSnap")
+	task.Logger().Debugf("finish restart from undoLinkSnap")
 	finishOpts := snapstate.FinishRestartOptions{
 		// Only default to true for snapd snap
 		FinishRestartDefault: snapsup.Type == snap.TypeSnapd,
@@ -599,6 +598,10 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 	if err := task.Get("delayed-setup-profiles", &delayedSetupProfiles); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
 	}
+	var expires time.Time
+	if err := task.Get("expires", &expires); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
 
 	deviceCtx, err := snapstate.DeviceCtx(st, task, nil)
 	if err != nil {
@@ -688,7 +691,7 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 	defer func() {
 		if err != nil {
 			if err := m.repo.Disconnect(plugRef.Snap, plugRef.Name, slotRef.Snap, slotRef.Name); err != nil {
-				logger.Noticef("cannot undo failed connection: %v", err)
+				task.Logger().Noticef("cannot undo failed connection: %v", err)
 			}
 		}
 	}()
@@ -718,7 +721,7 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 			return err
 		}
 	} else {
-		logger.Debugf("Connect handler: skipping setupSnapSecurity for snaps %q and %q", plug.Snap.InstanceName(), slot.Snap.InstanceName())
+		task.Logger().Debugf("Connect handler: skipping setupSnapSecurity for snaps %q and %q", plug.Snap.InstanceName(), slot.Snap.InstanceName())
 	}
 
 	// For undo handler. We need to remember old state of the connection only
@@ -739,8 +742,12 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) (err error)
 		Auto:             autoConnect,
 		ByGadget:         byGadget,
 		HotplugKey:       slot.HotplugKey,
+		Expires:          expires,
 	}
 	setConns(st, conns)
+	if err := addInterfaceConnectionNotices(st, connRef, "connect"); err != nil {
+		return err
+	}
 
 	// the dynamic attributes might have been updated by the interface's BeforeConnectPlug/Slot code,
 	// so we need to update the task for connect-plug- and connect-slot- hooks to see new values.
@@ -861,7 +868,7 @@ func (m *InterfaceManager) doDisconnect(task *state.Task, _ *tomb.Tomb) error {
 	}
 	setConns(st, conns)
 
-	return nil
+	return addInterfaceConnectionNotices(st, &cref, "disconnect")
 }
 
 func (m *InterfaceManager) undoDisconnect(task *state.Task, _ *tomb.Tomb) error {
@@ -967,6 +974,71 @@ func (m *InterfaceManager) undoDisconnect(task *state.Task, _ *tomb.Tomb) error
 	conns[connRef.ID()] = &oldconn
 	setConns(st, conns)
 
+	return addInterfaceConnectionNotices(st, connRef, "connect")
+}
+
+func (m *InterfaceManager) doSetConnectionExpiry(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	plugRef, slotRef, err := getPlugAndSlotRefs(task)
+	if err != nil {
+		return err
+	}
+	var expires time.Time
+	if err := task.Get("expires", &expires); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+
+	connRef := interfaces.ConnRef{PlugRef: plugRef, SlotRef: slotRef}
+	conns, err := getConns(st)
+	if err != nil {
+		return err
+	}
+	conn, ok := conns[connRef.ID()]
+	if !ok || conn.Undesired || conn.HotplugGone {
+		return fmt.Errorf("cannot change when connection %s expires: not connected", connRef.ID())
+	}
+
+	task.Set("old-expires", conn.Expires)
+	conn.Expires = expires
+	conn.ExpiryChange = ""
+	setConns(st, conns)
+
+	// have Ensure look at when connections expire again
+	st.EnsureBefore(0)
+	return nil
+}
+
+func (m *InterfaceManager) undoSetConnectionExpiry(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	plugRef, slotRef, err := getPlugAndSlotRefs(task)
+	if err != nil {
+		return err
+	}
+	var oldExpires time.Time
+	if err := task.Get("old-expires", &oldExpires); err != nil {
+		return err
+	}
+
+	connRef := interfaces.ConnRef{PlugRef: plugRef, SlotRef: slotRef}
+	conns, err := getConns(st)
+	if err != nil {
+		return err
+	}
+	conn, ok := conns[connRef.ID()]
+	if !ok {
+		// disconnected in the meantime
+		return nil
+	}
+	conn.Expires = oldExpires
+	setConns(st, conns)
+
+	st.EnsureBefore(0)
 	return nil
 }
 
@@ -1003,13 +1075,16 @@ func (m *InterfaceManager) undoConnect(task *state.Task, _ *tomb.Tomb) error {
 	if err := m.repo.Disconnect(connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name); err != nil {
 		return err
 	}
+	if err := addInterfaceConnectionNotices(st, &connRef, "disconnect"); err != nil {
+		return err
+	}
 
 	var delayedSetupProfiles bool
 	if err := task.Get("delayed-setup-profiles", &delayedSetupProfiles); err != nil && !errors.Is(err, state.ErrNoState) {
 		return err
 	}
 	if delayedSetupProfiles {
-		logger.Debugf("Connect undo handler: skipping setupSnapSecurity for snaps %q and %q", connRef.PlugRef.Snap, connRef.SlotRef.Snap)
+		task.Logger().Debugf("Connect undo handler: skipping setupSnapSecurity for snaps %q and %q", connRef.PlugRef.Snap, connRef.SlotRef.Snap)
 		return nil
 	}
 
@@ -1408,7 +1483,7 @@ func (m *InterfaceManager) doAutoConnect(task *state.Task, _ *tomb.Tomb) error {
 	// may not have all the interfaces of the new core/base snap. We set
 	// the default to true as we always called FinishRestart in older
 	// snapd.
-	logger.Debugf("finish restart from doAutoConnect")
+	task.Logger().Debugf("finish restart from doAutoConnect")
 	if err := snapstateFinishRestart(task, snapsup,
 		snapstate.FinishRestartOptions{FinishRestartDefault: true}); err != nil {
 		return err
@@ -1582,7 +1657,7 @@ func (m *InterfaceManager) doAutoDisconnect(task *state.Task, _ *tomb.Tomb) erro
 	for _, connRef := range connections {
 		if err := checkDisconnectConflicts(st, snapName, connRef.PlugRef.Snap, connRef.SlotRef.Snap); err != nil {
 			if _, retry := err.(*state.Retry); retry {
-				logger.Debugf("disconnecting interfaces of snap %q will be retried because of %q - %q conflict", snapName, connRef.PlugRef.Snap, connRef.SlotRef.Snap)
+				task.Logger().Debugf("disconnecting interfaces of snap %q will be retried because of %q - %q conflict", snapName, connRef.PlugRef.Snap, connRef.SlotRef.Snap)
 				task.Logf("Waiting for conflicting change in progress...")
 				return err // will retry
 			}
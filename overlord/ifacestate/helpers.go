@@ -922,6 +922,41 @@ func filterUbuntuCoreSlots(candidates []*snap.SlotInfo, arities []interfaces.Sid
 	return candidates, arities
 }
 
+// applicableSlots returns the candidate slots the given plug could be
+// auto-connected to and, out of those, the ones it should be auto-connected
+// to after checking the arity of the candidates and filtering them with the
+// optional filter.
+func (c *autoConnectChecker) applicableSlots(plug *snap.PlugInfo, filter func([]*snap.SlotInfo) []*snap.SlotInfo) (candSlots, applicable []*snap.SlotInfo) {
+	candSlots, arities := c.repo.AutoConnectCandidateSlots(plug.Snap.InstanceName(), plug.Name, c.check)
+	if len(candSlots) == 0 {
+		return nil, nil
+	}
+
+	// If we are in a core transition we may have both the
+	// old ubuntu-core snap and the new core snap
+	// providing the same interface. In that situation we
+	// want to ignore any candidates in ubuntu-core and
+	// simply go with those from the new core snap.
+	candSlots, arities = filterUbuntuCoreSlots(candSlots, arities)
+
+	applicable = candSlots
+	// candidate arity check
+	for _, arity := range arities {
+		if !arity.SlotsPerPlugAny() {
+			// ATM not any (*) => none or exactly one
+			if len(candSlots) != 1 {
+				applicable = nil
+			}
+			break
+		}
+	}
+
+	if filter != nil {
+		applicable = filter(applicable)
+	}
+	return candSlots, applicable
+}
+
 // addAutoConnections adds to newconns any applicable auto-connections
 // from the given plugs to corresponding candidates slots after
 // filtering them with optional filter and against preexisting
@@ -930,35 +965,11 @@ func filterUbuntuCoreSlots(candidates []*snap.SlotInfo, arities []interfaces.Sid
 // to handle checkAutoconnectConflicts errors.
 func (c *autoConnectChecker) addAutoConnections(task *state.Task, newconns map[string]*interfaces.ConnRef, plugs []*snap.PlugInfo, filter func([]*snap.SlotInfo) []*snap.SlotInfo, conns map[string]*schema.ConnState, cannotAutoConnectLog func(plug *snap.PlugInfo, candRefs []string) string, conflictError func(*state.Retry, error) error) error {
 	for _, plug := range plugs {
-		candSlots, arities := c.repo.AutoConnectCandidateSlots(plug.Snap.InstanceName(), plug.Name, c.check)
-
+		candSlots, applicable := c.applicableSlots(plug, filter)
 		if len(candSlots) == 0 {
 			continue
 		}
 
-		// If we are in a core transition we may have both the
-		// old ubuntu-core snap and the new core snap
-		// providing the same interface. In that situation we
-		// want to ignore any candidates in ubuntu-core and
-		// simply go with those from the new core snap.
-		candSlots, arities = filterUbuntuCoreSlots(candSlots, arities)
-
-		applicable := candSlots
-		// candidate arity check
-		for _, arity := range arities {
-			if !arity.SlotsPerPlugAny() {
-				// ATM not any (*) => none or exactly one
-				if len(candSlots) != 1 {
-					applicable = nil
-				}
-				break
-			}
-		}
-
-		if filter != nil {
-			applicable = filter(applicable)
-		}
-
 		if len(applicable) == 0 {
 			crefs := make([]string, len(candSlots))
 			for i, candidate := range candSlots {
@@ -978,6 +989,83 @@ func (c *autoConnectChecker) addAutoConnections(task *state.Task, newconns map[s
 	return nil
 }
 
+// autoConnectChanges returns the IDs of the connections that would be
+// auto-connected if the snap was refreshed to the revision described by the
+// given info. It works on a scratch repository so that m.repo is left alone.
+func (m *InterfaceManager) autoConnectChanges(st *state.State, info *snap.Info, deviceCtx snapstate.DeviceContext) ([]string, error) {
+	repo := interfaces.NewRepository()
+	for _, iface := range m.repo.AllInterfaces() {
+		if err := repo.AddInterface(iface); err != nil {
+			return nil, err
+		}
+	}
+
+	appSets, err := snapsWithSecurityProfiles(st)
+	if err != nil {
+		return nil, err
+	}
+	for _, set := range appSets {
+		if set.InstanceName() == info.InstanceName() {
+			continue
+		}
+		if err := addImplicitInterfaces(st, set.Info()); err != nil {
+			return nil, err
+		}
+		if err := repo.AddAppSet(set); err != nil {
+			logger.Noticef("cannot add snap %q to interface repository: %s", set.InstanceName(), err)
+		}
+	}
+
+	if err := addImplicitInterfaces(st, info); err != nil {
+		return nil, err
+	}
+	set, err := interfaces.NewSnapAppSet(info, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.AddAppSet(set); err != nil {
+		return nil, err
+	}
+
+	autochecker, err := newAutoConnectChecker(st, repo, deviceCtx)
+	if err != nil {
+		return nil, err
+	}
+	conns, err := getConns(st)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]bool)
+	addChanges := func(plug *snap.PlugInfo, slots []*snap.SlotInfo) {
+		for _, slot := range slots {
+			id := interfaces.NewConnRef(plug, slot).ID()
+			if _, ok := conns[id]; !ok {
+				changes[id] = true
+			}
+		}
+	}
+
+	snapName := info.InstanceName()
+	for _, plug := range repo.Plugs(snapName) {
+		_, applicable := autochecker.applicableSlots(plug, nil)
+		addChanges(plug, applicable)
+	}
+	for _, slot := range repo.Slots(snapName) {
+		for _, plug := range repo.AutoConnectCandidatePlugs(snapName, slot.Name, autochecker.check) {
+			_, applicable := autochecker.applicableSlots(plug, filterForSlot(slot))
+			addChanges(plug, applicable)
+		}
+	}
+
+	ids := make([]string, 0, len(changes))
+	for id := range changes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
 type connectChecker struct {
 	st        *state.State
 	deviceCtx snapstate.DeviceContext
@@ -1095,6 +1183,31 @@ func getConns(st *state.State) (conns map[string]*schema.ConnState, err error) {
 	return remapped, nil
 }
 
+// addInterfaceConnectionNotices records an interface-connection notice for
+// each of the plug and slot snaps of the given connection, with the action
+// performed and the connection as data.
+//
+// Like connections, snaps are re-mapped according to RemapSnapToState.
+func addInterfaceConnectionNotices(st *state.State, cref *interfaces.ConnRef, action string) error {
+	plugRef := interfaces.PlugRef{Snap: RemapSnapToState(cref.PlugRef.Snap), Name: cref.PlugRef.Name}
+	slotRef := interfaces.SlotRef{Snap: RemapSnapToState(cref.SlotRef.Snap), Name: cref.SlotRef.Name}
+	data := map[string]string{
+		"action": action,
+		"plug":   plugRef.String(),
+		"slot":   slotRef.String(),
+	}
+	instanceNames := []string{plugRef.Snap}
+	if slotRef.Snap != plugRef.Snap {
+		instanceNames = append(instanceNames, slotRef.Snap)
+	}
+	for _, instanceName := range instanceNames {
+		if _, err := st.AddNotice(nil, state.InterfaceConnectionNotice, instanceName, &state.AddNoticeOptions{Data: data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // setConns sets information about connections in the state.
 //
 // Connections are transparently re-mapped according to remapOutgoingConnRef
@@ -20,10 +20,12 @@
 package ifacestate
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/backends"
 	"github.com/snapcore/snapd/logger"
@@ -95,14 +97,16 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 		preseed:         snapdenv.Preseeding(),
 	}
 
+	mgrRunner := runner.ForManager("ifacestate")
 	taskKinds := map[string]bool{}
 	addHandler := func(kind string, do, undo state.HandlerFunc) {
 		taskKinds[kind] = true
-		runner.AddHandler(kind, do, undo)
+		mgrRunner.AddHandler(kind, do, undo)
 	}
 
 	addHandler("connect", m.doConnect, m.undoConnect)
 	addHandler("disconnect", m.doDisconnect, m.undoDisconnect)
+	addHandler("set-connection-expiry", m.doSetConnectionExpiry, m.undoSetConnectionExpiry)
 	addHandler("setup-profiles", m.doSetupProfiles, m.undoSetupProfiles)
 	addHandler("remove-profiles", m.doRemoveProfiles, m.doSetupProfiles)
 	addHandler("discard-conns", m.doDiscardConns, m.undoDiscardConns)
@@ -116,7 +120,7 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, runner *state.T
 	addHandler("regenerate-security-profiles", m.doRegenerateAllSecurityProfiles, nil)
 
 	// don't block on hotplug-seq-wait task
-	runner.AddHandler("hotplug-seq-wait", m.doHotplugSeqWait, nil)
+	mgrRunner.AddHandler("hotplug-seq-wait", m.doHotplugSeqWait, nil)
 
 	// helper for ubuntu-core -> core
 	addHandler("transition-ubuntu-core", m.doTransitionUbuntuCore, m.undoTransitionUbuntuCore)
@@ -252,6 +256,8 @@ Run "systemctl enable --now snapd.apparmor" to correct this.`)
 
 	// wire late profile removal support into snapstate
 	snapstate.SecurityProfilesRemoveLate = m.discardSecurityProfilesLate
+	// and the auto-connections of simulated refreshes
+	snapstate.AutoConnectChanges = m.autoConnectChanges
 
 	perfTimings.Save(s)
 
@@ -260,11 +266,109 @@ Run "systemctl enable --now snapd.apparmor" to correct this.`)
 
 // Ensure implements StateManager.Ensure.
 func (m *InterfaceManager) Ensure() error {
-	// do not worry about udev monitor in preseeding mode
+	// do not worry about udev monitor or expired connections in preseeding
+	// mode
 	if m.preseed {
 		return nil
 	}
 
+	expiryErr := m.ensureExpiredConnectionsDisconnected()
+	if err := m.ensureUDevMonitor(); err != nil {
+		return err
+	}
+	return expiryErr
+}
+
+// ensureExpiredConnectionsDisconnected creates changes disconnecting the
+// connections which were established until a time which has now passed, and
+// makes sure that Ensure runs again when the next connection expires.
+func (m *InterfaceManager) ensureExpiredConnectionsDisconnected() error {
+	logger.Trace("ensure", "manager", "InterfaceManager", "func", "ensureExpiredConnectionsDisconnected")
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	conns, err := getConns(m.state)
+	if err != nil {
+		return err
+	}
+
+	now := timeNow()
+	var next time.Time
+	var changed bool
+	scheduleNext := func(t time.Time) {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+
+	for id, cstate := range conns {
+		if cstate.Expires.IsZero() || cstate.Undesired || cstate.HotplugGone {
+			continue
+		}
+		if cstate.Expires.After(now) {
+			scheduleNext(cstate.Expires)
+			continue
+		}
+		if chg := m.state.Change(cstate.ExpiryChange); chg != nil {
+			if !chg.IsReady() {
+				// being disconnected already
+				continue
+			}
+			// disconnecting failed, don't try again right away
+			if retry := chg.ReadyTime().Add(expiredConnectionRetryInterval); retry.After(now) {
+				scheduleNext(retry)
+				continue
+			}
+		}
+
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return err
+		}
+		conn, err := m.repo.Connection(connRef)
+		if err != nil {
+			// the plug or slot is not in the repository, e.g. because the
+			// current revision of the snap does not have it anymore; the
+			// connection is disconnected once it becomes active again
+			continue
+		}
+
+		// an existing change for either snap may be disconnecting the
+		// connection already, otherwise try again after it is done
+		if err := snapstate.CheckChangeConflictMany(m.state, []string{connRef.PlugRef.Snap, connRef.SlotRef.Snap}, ""); err != nil {
+			var conflictErr *snapstate.ChangeConflictError
+			if errors.As(err, &conflictErr) {
+				scheduleNext(now.Add(expiredConnectionRetryInterval))
+				continue
+			}
+			return err
+		}
+
+		ts, err := disconnectTasks(m.state, conn, disconnectOpts{})
+		if err != nil {
+			return err
+		}
+		summary := fmt.Sprintf(i18n.G("Disconnect %s:%s from %s:%s as the connection expired"),
+			connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
+		chg := m.state.NewChange("disconnect-snap", summary)
+		chg.AddAll(ts)
+		chg.Set("snap-names", []string{connRef.PlugRef.Snap, connRef.SlotRef.Snap})
+		logger.Noticef("Disconnecting %s as the connection expired at %s", connRef, cstate.Expires.Format(time.RFC3339))
+
+		cstate.ExpiryChange = chg.ID()
+		changed = true
+	}
+
+	if changed {
+		setConns(m.state, conns)
+	}
+	if !next.IsZero() {
+		m.state.EnsureBefore(next.Sub(now))
+	}
+	return nil
+}
+
+func (m *InterfaceManager) ensureUDevMonitor() error {
 	if m.udevMonitorDisabled {
 		return nil
 	}
@@ -284,6 +388,7 @@ func (m *InterfaceManager) Ensure() error {
 	// retry udev monitor initialization every 5 minutes
 	now := time.Now()
 	if now.After(m.udevRetryTimeout) {
+		logger.Trace("ensure", "manager", "InterfaceManager", "func", "ensureUDevMonitor")
 		err := m.initUDevMonitor()
 		if err != nil {
 			m.udevRetryTimeout = now.Add(udevInitRetryTimeout)
@@ -368,6 +473,9 @@ type ConnectionState struct {
 	StaticSlotAttrs  map[string]interface{}
 	DynamicSlotAttrs map[string]interface{}
 	HotplugGone      bool
+	// Expires is the time at which the connection is automatically
+	// disconnected, or zero if it does not expire
+	Expires time.Time
 }
 
 // Active returns true if connection is not undesired and not removed by
@@ -399,6 +507,7 @@ func ConnectionStates(st *state.State) (connStateByRef map[string]ConnectionStat
 			StaticSlotAttrs:  cstate.StaticSlotAttrs,
 			DynamicSlotAttrs: cstate.DynamicSlotAttrs,
 			HotplugGone:      cstate.HotplugGone,
+			Expires:          cstate.Expires,
 		}
 	}
 	return connStateByRef, nil
@@ -549,6 +658,8 @@ func (m *InterfaceManager) DisableUDevMonitor() {
 
 var (
 	udevInitRetryTimeout            = time.Minute * 5
+	expiredConnectionRetryInterval  = time.Minute
+	timeNow                         = time.Now
 	createUDevMonitor               = udevmonitor.New
 	createInterfacesRequestsManager = apparmorprompting.New
 )
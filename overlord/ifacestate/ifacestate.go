@@ -86,15 +86,68 @@ type connectOpts struct {
 	AutoConnect bool
 
 	DelayedSetupProfiles bool
+
+	// Expires is the time at which the connection is automatically
+	// disconnected, if not zero.
+	Expires time.Time
 }
 
-// Connect returns a set of tasks for connecting an interface.
+// Connect returns a set of tasks for connecting an interface. If the
+// interface is connected already until some time, the tasks make the
+// connection permanent instead.
 func Connect(st *state.State, plugSnap, plugName, slotSnap, slotName string) (*state.TaskSet, error) {
+	return connectUntil(st, plugSnap, plugName, slotSnap, slotName, time.Time{})
+}
+
+// ConnectUntil returns a set of tasks for connecting an interface until the
+// given time, after which the interface manager disconnects it again. If the
+// interface is connected already, the tasks only change when the connection
+// expires.
+func ConnectUntil(st *state.State, plugSnap, plugName, slotSnap, slotName string, expires time.Time) (*state.TaskSet, error) {
+	if !expires.After(timeNow()) {
+		return nil, fmt.Errorf("cannot connect %s:%s to %s:%s until %s: time is in the past",
+			plugSnap, plugName, slotSnap, slotName, expires.Format(time.RFC3339))
+	}
+	return connectUntil(st, plugSnap, plugName, slotSnap, slotName, expires)
+}
+
+func connectUntil(st *state.State, plugSnap, plugName, slotSnap, slotName string, expires time.Time) (*state.TaskSet, error) {
 	if err := snapstate.CheckChangeConflictMany(st, []string{plugSnap, slotSnap}, ""); err != nil {
 		return nil, err
 	}
 
-	return connect(st, plugSnap, plugName, slotSnap, slotName, connectOpts{})
+	conns, err := getConns(st)
+	if err != nil {
+		return nil, err
+	}
+	connRef := &interfaces.ConnRef{PlugRef: interfaces.PlugRef{Snap: plugSnap, Name: plugName}, SlotRef: interfaces.SlotRef{Snap: slotSnap, Name: slotName}}
+	if conn, ok := conns[connRef.ID()]; ok && !conn.Undesired && !conn.HotplugGone && !conn.Expires.Equal(expires) {
+		// the interface is connected already, only when the connection
+		// expires changes
+		return setConnectionExpiryTasks(st, connRef, expires), nil
+	}
+
+	return connect(st, plugSnap, plugName, slotSnap, slotName, connectOpts{Expires: expires})
+}
+
+// setConnectionExpiryTasks returns a set of tasks changing when the given
+// existing connection expires, a zero time makes it permanent.
+func setConnectionExpiryTasks(st *state.State, connRef *interfaces.ConnRef, expires time.Time) *state.TaskSet {
+	var summary string
+	if expires.IsZero() {
+		summary = fmt.Sprintf(i18n.G("Make connection of %s:%s to %s:%s permanent"),
+			connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name)
+	} else {
+		summary = fmt.Sprintf(i18n.G("Keep %s:%s connected to %s:%s until %s"),
+			connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name, expires.Format(time.RFC3339))
+	}
+	t := st.NewTask("set-connection-expiry", summary)
+	t.Set("plug", connRef.PlugRef)
+	t.Set("slot", connRef.SlotRef)
+	if !expires.IsZero() {
+		t.Set("expires", expires)
+	}
+	return state.NewTaskSet(t)
 }
 
 func connect(st *state.State, plugSnap, plugName, slotSnap, slotName string, flags connectOpts) (*state.TaskSet, error) {
@@ -209,6 +262,9 @@ func connect(st *state.State, plugSnap, plugName, slotSnap, slotName string, fla
 	if flags.DelayedSetupProfiles {
 		connectInterface.Set("delayed-setup-profiles", true)
 	}
+	if !flags.Expires.IsZero() {
+		connectInterface.Set("expires", flags.Expires)
+	}
 
 	// Expose a copy of all plug and slot attributes coming from yaml to interface hooks. The hooks will be able
 	// to modify them but all attributes will be checked against assertions after the hooks are run.
@@ -540,6 +596,7 @@ func delayedCrossMgrInit() {
 		// hook into conflict checks mechanisms
 		snapstate.RegisterAffectedSnapsByKind("connect", connectDisconnectAffectedSnaps)
 		snapstate.RegisterAffectedSnapsByKind("disconnect", connectDisconnectAffectedSnaps)
+		snapstate.RegisterAffectedSnapsByKind("set-connection-expiry", connectDisconnectAffectedSnaps)
 
 		// hook into snap linking/unlinking and activation state changes
 		snapstate.AddLinkSnapParticipant(snapstate.LinkSnapParticipantFunc(OnSnapLinkageChanged))
@@ -21,6 +21,7 @@ package ifacestate_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -40,6 +41,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/builtin"
 	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/policy"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
@@ -5295,6 +5297,65 @@ func (s *interfaceManagerSuite) TestConnectTracksConnectionsInState(c *C) {
 			"slot-static": map[string]interface{}{"attr2": "value2"},
 		},
 	})
+
+	// both snaps got a notice about the connection
+	checkInterfaceConnectionNotices(c, s.state, map[string]string{
+		"action": "connect",
+		"plug":   "consumer:plug",
+		"slot":   "producer:slot",
+	})
+}
+
+func checkInterfaceConnectionNotices(c *C, st *state.State, data map[string]string) {
+	notices := st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.InterfaceConnectionNotice}})
+	c.Assert(notices, HasLen, 2)
+	var keys []string
+	for _, n := range notices {
+		buf, err := json.Marshal(n)
+		c.Assert(err, IsNil)
+		var jn struct {
+			Key      string            `json:"key"`
+			LastData map[string]string `json:"last-data"`
+		}
+		c.Assert(json.Unmarshal(buf, &jn), IsNil)
+		keys = append(keys, jn.Key)
+		c.Check(jn.LastData, DeepEquals, data)
+	}
+	c.Check(keys, testutil.DeepUnsortedMatches, []string{"consumer", "producer"})
+}
+
+func (s *interfaceManagerSuite) TestDisconnectRecordsNotices(c *C) {
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{"interface": "test"},
+	})
+	s.state.Unlock()
+
+	_ = s.manager(c)
+	conn := s.getConnection(c, "consumer", "plug", "producer", "slot")
+
+	s.state.Lock()
+	ts, err := ifacestate.Disconnect(s.state, conn)
+	c.Assert(err, IsNil)
+	change := s.state.NewChange("disconnect", "...")
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(change.Err(), IsNil)
+	checkInterfaceConnectionNotices(c, s.state, map[string]string{
+		"action": "disconnect",
+		"plug":   "consumer:plug",
+		"slot":   "producer:slot",
+	})
 }
 
 func (s *interfaceManagerSuite) TestConnectSetsUpSecurity(c *C) {
@@ -5514,6 +5575,290 @@ func (s *interfaceManagerSuite) TestDisconnectTracksConnectionsInState(c *C) {
 	c.Check(conns, DeepEquals, map[string]interface{}{})
 }
 
+func (s *interfaceManagerSuite) TestConnectUntilTracksExpiryInState(c *C) {
+	s.MockModel(c, nil)
+
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	_ = s.manager(c)
+
+	s.state.Lock()
+
+	expires := time.Now().Add(2 * time.Hour).Truncate(time.Second).UTC()
+	ts, err := ifacestate.ConnectUntil(s.state, "consumer", "plug", "producer", "slot", expires)
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 5)
+
+	change := s.state.NewChange("connect", "")
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(change.Err(), IsNil)
+	c.Check(change.Status(), Equals, state.DoneStatus)
+	var conns map[string]interface{}
+	err = s.state.Get("conns", &conns)
+	c.Assert(err, IsNil)
+	c.Check(conns, DeepEquals, map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{
+			"interface":   "test",
+			"plug-static": map[string]interface{}{"attr1": "value1"},
+			"slot-static": map[string]interface{}{"attr2": "value2"},
+			"expires":     expires.Format(time.RFC3339),
+		},
+	})
+
+	connStates, err := ifacestate.ConnectionStates(s.state)
+	c.Assert(err, IsNil)
+	c.Check(connStates["consumer:plug producer:slot"].Expires.Equal(expires), Equals, true)
+}
+
+func (s *interfaceManagerSuite) TestConnectUntilInThePast(c *C) {
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	_ = s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	_, err := ifacestate.ConnectUntil(s.state, "consumer", "plug", "producer", "slot", time.Date(2020, 10, 17, 10, 0, 0, 0, time.UTC))
+	c.Check(err, ErrorMatches, `cannot connect consumer:plug to producer:slot until 2020-10-17T10:00:00Z: time is in the past`)
+}
+
+// testSetConnectionExpiry changes when the existing consumer:plug
+// producer:slot connection, expiring at the given time if not zero, expires
+// with connect and checks that the connection then expires at newExpires.
+func (s *interfaceManagerSuite) testSetConnectionExpiry(c *C, expires, newExpires time.Time, connect func() (*state.TaskSet, error), summary string) {
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	cstate := map[string]interface{}{"interface": "test"}
+	if !expires.IsZero() {
+		cstate["expires"] = expires.Format(time.RFC3339Nano)
+	}
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{"consumer:plug producer:slot": cstate})
+	s.state.Unlock()
+
+	_ = s.manager(c)
+
+	s.state.Lock()
+	ts, err := connect()
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 1)
+	t := ts.Tasks()[0]
+	c.Check(t.Kind(), Equals, "set-connection-expiry")
+	c.Check(t.Summary(), Equals, summary)
+	change := s.state.NewChange("connect", "")
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(change.Err(), IsNil)
+	c.Check(change.Status(), Equals, state.DoneStatus)
+	conns, err := ifacestate.ConnectionStates(s.state)
+	c.Assert(err, IsNil)
+	c.Assert(conns, HasLen, 1)
+	conn := conns["consumer:plug producer:slot"]
+	c.Check(conn.Interface, Equals, "test")
+	c.Check(conn.Expires.Equal(newExpires), Equals, true, Commentf("%v != %v", conn.Expires, newExpires))
+
+	// the connection is left alone when asked for the same again
+	_, err = connect()
+	c.Check(err, FitsTypeOf, &ifacestate.ErrAlreadyConnected{})
+}
+
+func (s *interfaceManagerSuite) TestConnectUntilPermanentConnection(c *C) {
+	expires := time.Now().Add(2 * time.Hour).Truncate(time.Second).UTC()
+	s.testSetConnectionExpiry(c, time.Time{}, expires, func() (*state.TaskSet, error) {
+		return ifacestate.ConnectUntil(s.state, "consumer", "plug", "producer", "slot", expires)
+	}, fmt.Sprintf("Keep consumer:plug connected to producer:slot until %s", expires.Format(time.RFC3339)))
+}
+
+func (s *interfaceManagerSuite) TestConnectUntilExtendsExpiry(c *C) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	newExpires := expires.Add(time.Hour)
+	s.testSetConnectionExpiry(c, expires, newExpires, func() (*state.TaskSet, error) {
+		return ifacestate.ConnectUntil(s.state, "consumer", "plug", "producer", "slot", newExpires)
+	}, fmt.Sprintf("Keep consumer:plug connected to producer:slot until %s", newExpires.Format(time.RFC3339)))
+}
+
+func (s *interfaceManagerSuite) TestConnectMakesConnectionPermanent(c *C) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	s.testSetConnectionExpiry(c, expires, time.Time{}, func() (*state.TaskSet, error) {
+		return ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	}, "Make connection of consumer:plug to producer:slot permanent")
+}
+
+func (s *interfaceManagerSuite) TestSetConnectionExpiryUndo(c *C) {
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{
+			"interface": "test",
+			"expires":   expires.Format(time.RFC3339),
+		},
+	})
+	s.state.Unlock()
+
+	_ = s.manager(c)
+
+	s.state.Lock()
+	ts, err := ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	c.Assert(err, IsNil)
+	change := s.state.NewChange("connect", "")
+	change.AddAll(ts)
+	terr := s.state.NewTask("error-trigger", "provoking undo")
+	terr.WaitAll(ts)
+	change.AddTask(terr)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(change.Status(), Equals, state.ErrorStatus)
+	c.Check(ts.Tasks()[0].Status(), Equals, state.UndoneStatus)
+	conns, err := ifacestate.ConnectionStates(s.state)
+	c.Assert(err, IsNil)
+	c.Check(conns["consumer:plug producer:slot"].Expires.Equal(expires), Equals, true)
+}
+
+func (s *interfaceManagerSuite) TestEnsureDisconnectsExpiredConnections(c *C) {
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	now := time.Now()
+	expires := now.Add(time.Hour)
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{
+			"interface": "test",
+			"expires":   expires.Format(time.RFC3339Nano),
+		},
+		"consumer:otherplug producer:otherslot": map[string]interface{}{
+			"interface": "test2",
+		},
+	})
+	s.state.Unlock()
+
+	s.manager(c)
+	s.settle(c)
+
+	// nothing has expired yet
+	s.state.Lock()
+	c.Check(s.state.Changes(), HasLen, 0)
+	conns, err := ifacestate.ConnectionStates(s.state)
+	c.Assert(err, IsNil)
+	c.Check(conns, HasLen, 2)
+	s.state.Unlock()
+
+	restore := ifacestate.MockTimeNow(func() time.Time { return expires.Add(time.Second) })
+	defer restore()
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	changes := s.state.Changes()
+	c.Assert(changes, HasLen, 1)
+	chg := changes[0]
+	c.Check(chg.Kind(), Equals, "disconnect-snap")
+	c.Check(chg.Summary(), Equals, "Disconnect consumer:plug from producer:slot as the connection expired")
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	var snapNames []string
+	c.Assert(chg.Get("snap-names", &snapNames), IsNil)
+	c.Check(snapNames, DeepEquals, []string{"consumer", "producer"})
+
+	// only the expired connection is gone
+	conns, err = ifacestate.ConnectionStates(s.state)
+	c.Assert(err, IsNil)
+	c.Check(conns, HasLen, 1)
+	c.Check(conns["consumer:otherplug producer:otherslot"].Expires.IsZero(), Equals, true)
+	_, err = s.manager(c).Repository().Connection(&interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+	})
+	c.Check(err, ErrorMatches, "no connection from consumer:plug to producer:slot")
+}
+
+func (s *interfaceManagerSuite) TestEnsureExpiredConnectionsDisconnectFails(c *C) {
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	now := time.Now()
+	expires := now.Add(-time.Hour)
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{
+			"interface": "test",
+			"expires":   expires.Format(time.RFC3339Nano),
+		},
+	})
+	s.state.Unlock()
+
+	restore := ifacestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	// longer than the interval of Ensure, for settle to converge
+	restore = ifacestate.MockExpiredConnectionRetryInterval(time.Hour)
+	defer restore()
+
+	s.manager(c)
+	s.o.TaskRunner().AddHandler("disconnect", func(task *state.Task, _ *tomb.Tomb) error {
+		return errors.New("boom")
+	}, nil)
+
+	s.settle(c)
+	// Ensure runs a few more times
+	s.settle(c)
+
+	s.state.Lock()
+	changes := s.state.Changes()
+	c.Assert(changes, HasLen, 1)
+	chg := changes[0]
+	c.Check(chg.Kind(), Equals, "disconnect-snap")
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	conns, err := ifacestate.ConnectionStates(s.state)
+	c.Assert(err, IsNil)
+	c.Check(conns, HasLen, 1)
+	s.state.Unlock()
+
+	// it is tried again after a while
+	restore = ifacestate.MockTimeNow(func() time.Time { return now.Add(2 * time.Hour) })
+	c.Assert(s.manager(c).Ensure(), IsNil)
+	restore()
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	changes = s.state.Changes()
+	c.Assert(changes, HasLen, 2)
+	for _, chg := range changes {
+		c.Check(chg.Kind(), Equals, "disconnect-snap")
+		c.Check(chg.Status(), Equals, state.ErrorStatus)
+	}
+}
+
 func (s *interfaceManagerSuite) TestDisconnectDisablesAutoConnect(c *C) {
 	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
 	plugAppSet := s.mockAppSet(c, consumerYaml)
@@ -5911,6 +6256,113 @@ func (s *interfaceManagerSuite) TestSetupProfilesDevModeMultiple(c *C) {
 	c.Check(s.secBackend.SetupCalls[3].Options, DeepEquals, interfaces.ConfinementOptions{KernelSnap: "krnl"})
 }
 
+func (s *interfaceManagerSuite) TestAutoConnectChanges(c *C) {
+	deviceCtx := s.TrivialDeviceContext(c, nil)
+
+	restore := assertstest.MockBuiltinBaseDeclaration([]byte(`
+type: base-declaration
+authority-id: canonical
+series: 16
+slots:
+  test:
+    allow-auto-connection: true
+`))
+	defer restore()
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"}, &ifacetest.TestInterface{InterfaceName: "test2"})
+	s.MockSnapDecl(c, "producer", "one-publisher", nil)
+	s.mockSnap(c, producerYaml)
+	s.MockSnapDecl(c, "consumer", "one-publisher", nil)
+	s.mockSnap(c, "name: consumer\nversion: 1\n")
+
+	mgr := s.manager(c)
+
+	// the new revision of consumer has a plug for the producer slot
+	newInfo := snaptest.MockInfo(c, consumerYaml, &snap.SideInfo{Revision: snap.R(2)})
+	newInfo.SnapID = ("consumer" + strings.Repeat("id", 16))[:32]
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	changes, err := snapstate.AutoConnectChanges(s.state, newInfo, deviceCtx)
+	c.Assert(err, IsNil)
+	c.Check(changes, DeepEquals, []string{"consumer:plug producer:slot"})
+
+	// the repository of the manager was left alone
+	c.Check(mgr.Repository().Plugs("consumer"), HasLen, 0)
+
+	// connections that exist already are not changes
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{"interface": "test", "undesired": true},
+	})
+	changes, err = snapstate.AutoConnectChanges(s.state, newInfo, deviceCtx)
+	c.Assert(err, IsNil)
+	c.Check(changes, HasLen, 0)
+}
+
+func (s *interfaceManagerSuite) TestExplainConnection(c *C) {
+	s.MockModel(c, map[string]interface{}{
+		"store": "other-store",
+	})
+	restore := assertstest.MockBuiltinBaseDeclaration([]byte(`
+type: base-declaration
+authority-id: canonical
+series: 16
+slots:
+  test:
+    allow-auto-connection: false
+`))
+	defer restore()
+	s.mockIfaces(&ifacetest.TestInterface{InterfaceName: "test"})
+
+	s.MockSnapDecl(c, "producer", "one-publisher", nil)
+	s.mockSnap(c, producerYaml)
+	s.MockSnapDecl(c, "consumer", "one-publisher", map[string]interface{}{
+		"format": "3",
+		"plugs": map[string]interface{}{
+			"test": map[string]interface{}{
+				"allow-auto-connection": map[string]interface{}{
+					"on-store": []interface{}{"my-store"},
+				},
+			},
+		},
+	})
+	s.mockSnap(c, consumerYaml)
+
+	mgr := s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	expl, err := mgr.ExplainConnection("consumer", "plug", "producer", "")
+	c.Assert(err, IsNil)
+	c.Check(expl.Plug, Equals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
+	c.Check(expl.Slot, Equals, interfaces.SlotRef{Snap: "producer", Name: "slot"})
+	c.Check(expl.Interface, Equals, "test")
+	c.Check(expl.Unasserted, HasLen, 0)
+
+	c.Check(expl.Connection.Allowed, Equals, true)
+	c.Check(expl.Connection.Declaration, Equals, "snap-declaration")
+	c.Check(expl.Connection.DecidedBy, Equals, "allow-connection")
+	c.Check(expl.Connection.Alternative, Equals, 0)
+
+	c.Check(expl.AutoConnection, DeepEquals, &policy.Explanation{
+		Kind:        "auto-connection",
+		Error:       `auto-connection not allowed by plug rule of interface "test" for "consumer" snap`,
+		Declaration: "snap-declaration",
+		Snap:        "consumer",
+		Side:        "plug",
+		DecidedBy:   "allow-auto-connection",
+		Alternative: -1,
+		Deny:        []*policy.AlternativeResult{{Matched: false}},
+		Allow: []*policy.AlternativeResult{{
+			Constraints: []policy.ConstraintResult{{Constraint: "on-store", Error: "on-store mismatch"}},
+		}},
+	})
+
+	_, err = mgr.ExplainConnection("consumer", "missing", "producer", "slot")
+	c.Check(err, ErrorMatches, `snap "consumer" has no plug named "missing"`)
+}
+
 func (s *interfaceManagerSuite) TestCheckInterfacesDeny(c *C) {
 	deviceCtx := s.TrivialDeviceContext(c, nil)
 
@@ -11602,5 +12054,5 @@ func (s *interfaceManagerSuite) TestSystemKeyMismatchCompat(c *C) {
 }
 
 func (s *interfaceManagerSuite) TestEnsureLoopLogging(c *C) {
-	testutil.CheckEnsureLoopLogging("ifacemgr.go", c, false)
+	testutil.CheckEnsureLoopLogging("ifacemgr.go", c, true)
 }
@@ -20,7 +20,11 @@
 // Package schema holds structs for reading and writing interface-related state data.
 package schema
 
-import "github.com/snapcore/snapd/snap"
+import (
+	"time"
+
+	"github.com/snapcore/snapd/snap"
+)
 
 // ConnState holds properties of an interface connection.
 type ConnState struct {
@@ -41,4 +45,11 @@ type ConnState struct {
 	// slots.
 	HotplugGone bool            `json:"hotplug-gone,omitempty" yaml:"hotplug-gone,omitempty"`
 	HotplugKey  snap.HotplugKey `json:"hotplug-key,omitempty" yaml:"hotplug-key,omitempty"`
+	// Expires is the time at which a connection established for a limited
+	// time is automatically disconnected. It is zero for connections which
+	// do not expire.
+	Expires time.Time `json:"expires,omitzero" yaml:"expires,omitempty"`
+	// ExpiryChange is the ID of the change disconnecting the connection
+	// once it expired.
+	ExpiryChange string `json:"expiry-change,omitempty" yaml:"expiry-change,omitempty"`
 }
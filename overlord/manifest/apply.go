@@ -0,0 +1,657 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/configstate"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/ifacestate/ifacerepo"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/channel"
+	"github.com/snapcore/snapd/strutil"
+)
+
+var (
+	snapstateInstallMany    = snapstate.InstallMany
+	snapstateUpdateMany     = snapstate.UpdateMany
+	snapstateAlias          = snapstate.Alias
+	ifacestateConnect       = ifacestate.Connect
+	servicestateCreateQuota = servicestate.CreateQuota
+	servicestateUpdateQuota = servicestate.UpdateQuota
+
+	assertstateFetchValidationSets = assertstate.FetchValidationSets
+)
+
+var ifacestateDisconnect = func(st *state.State, cref *interfaces.ConnRef) (*state.TaskSet, error) {
+	conn, err := ifacerepo.Get(st).Connection(cref)
+	if err != nil {
+		return nil, err
+	}
+	return ifacestate.Disconnect(st, conn)
+}
+
+// Plan describes what applying a manifest does to the system.
+type Plan struct {
+	// Actions are the summaries of what is done to converge the system.
+	Actions []string `json:"actions,omitempty"`
+	// Skipped are the summaries of what cannot be done, along with why.
+	// Applying the manifest again after the snaps it installs are in
+	// place takes care of most of them.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// action is something done to converge the system that comes after the
+// snaps are installed or refreshed. Actions return the task set doing the
+// work.
+type action struct {
+	summary string
+	do      func() (*state.TaskSet, error)
+}
+
+type applier struct {
+	st     *state.State
+	userID int
+
+	snapStates map[string]*snapstate.SnapState
+	installing map[string]bool
+
+	plan *Plan
+	// install and installClassic are the snaps to install without and
+	// with classic confinement, refresh the ones to refresh
+	install, installClassic, refresh []*Snap
+	revOpts                          map[string]*snapstate.RevisionOptions
+	// enforce are the validation sets to enforce once the snaps are in
+	// place
+	enforce  []*ValidationSet
+	actions  []*action
+	affected []string
+}
+
+func (a *applier) addAction(summary string, do func() (*state.TaskSet, error), snapNames ...string) {
+	a.plan.Actions = append(a.plan.Actions, summary)
+	a.actions = append(a.actions, &action{summary: summary, do: do})
+	a.affect(snapNames...)
+}
+
+func (a *applier) affect(snapNames ...string) {
+	for _, name := range snapNames {
+		if !strutil.ListContains(a.affected, name) {
+			a.affected = append(a.affected, name)
+		}
+	}
+}
+
+func (a *applier) skip(summary, reason string) {
+	a.plan.Skipped = append(a.plan.Skipped, fmt.Sprintf("%s: %s", summary, reason))
+}
+
+// missing returns whether any of the given snaps is not installed, in which
+// case what is summarized is skipped.
+func (a *applier) missing(summary string, snapNames ...string) bool {
+	for _, name := range snapNames {
+		if a.snapStates[name] != nil {
+			continue
+		}
+		if a.installing[name] {
+			a.skip(summary, fmt.Sprintf("snap %q is not installed yet", name))
+		} else {
+			a.skip(summary, fmt.Sprintf("snap %q is not installed", name))
+		}
+		return true
+	}
+	return false
+}
+
+// Apply computes what it takes for the system to match the given manifest.
+// Unless dryRun is set, it then returns the task sets that make it so,
+// along with the names of the snaps affected. Nothing is changed until
+// the task sets are run, except for fetching the assertions of the
+// validation sets to enforce.
+// The state must be locked by the caller.
+func Apply(ctx context.Context, st *state.State, m *Manifest, userID int, dryRun bool) (plan *Plan, tss []*state.TaskSet, affected []string, err error) {
+	if err := m.Validate(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	a := &applier{
+		st:         st,
+		userID:     userID,
+		snapStates: snapStates,
+		installing: make(map[string]bool),
+		plan:       &Plan{},
+		revOpts:    make(map[string]*snapstate.RevisionOptions),
+	}
+
+	if err := a.planValidationSets(m.ValidationSets); err != nil {
+		return nil, nil, nil, err
+	}
+	for _, s := range m.Snaps {
+		if err := a.planSnap(s); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for _, s := range m.Snaps {
+		if err := a.planSnapSetup(s); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if err := a.planConnections(m.Connections); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := a.planQuotas(m.Quotas); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if dryRun {
+		return a.plan, nil, a.affected, nil
+	}
+	tss, err = a.apply(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return a.plan, tss, a.affected, nil
+}
+
+func (a *applier) apply(ctx context.Context) ([]*state.TaskSet, error) {
+	vsets, enforceTask, err := a.enforceValidationSets()
+	if err != nil {
+		return nil, err
+	}
+	if vsets != nil {
+		// install and refresh the snaps as the validation sets to
+		// enforce require
+		for _, revOpts := range a.revOpts {
+			revOpts.ValidationSets = vsets
+		}
+	}
+
+	var snapTss []*state.TaskSet
+	for _, batch := range []struct {
+		snaps []*Snap
+		flags *snapstate.Flags
+	}{
+		{a.install, &snapstate.Flags{}},
+		{a.installClassic, &snapstate.Flags{Classic: true}},
+	} {
+		if len(batch.snaps) == 0 {
+			continue
+		}
+		names, revOpts := a.namesAndRevOpts(batch.snaps)
+		_, tss, err := snapstateInstallMany(a.st, names, revOpts, a.userID, batch.flags)
+		if err != nil {
+			return nil, err
+		}
+		snapTss = append(snapTss, tss...)
+	}
+	if len(a.refresh) > 0 {
+		names, revOpts := a.namesAndRevOpts(a.refresh)
+		_, tss, err := snapstateUpdateMany(ctx, a.st, names, revOpts, a.userID, nil)
+		if err != nil {
+			return nil, err
+		}
+		snapTss = append(snapTss, tss...)
+	}
+
+	tss := snapTss
+	for _, act := range a.actions {
+		ts, err := act.do()
+		if err != nil {
+			return nil, err
+		}
+		if ts == nil {
+			continue
+		}
+		// the snaps need to be in place first
+		for _, snapTs := range snapTss {
+			ts.WaitAll(snapTs)
+		}
+		tss = append(tss, ts)
+	}
+	if enforceTask != nil {
+		for _, snapTs := range snapTss {
+			enforceTask.WaitAll(snapTs)
+		}
+		tss = append(tss, state.NewTaskSet(enforceTask))
+	}
+	return tss, nil
+}
+
+// enforceValidationSets fetches the validation sets to enforce and returns
+// them along with the ones enforced already, as well as the task enforcing
+// them. The task needs to wait for the snaps to be installed or refreshed,
+// as the validation sets are checked against the installed snaps.
+func (a *applier) enforceValidationSets() (*snapasserts.ValidationSets, *state.Task, error) {
+	if len(a.enforce) == 0 {
+		return nil, nil, nil
+	}
+
+	toFetch := make([]*asserts.AtSequence, 0, len(a.enforce))
+	pinnedSeqs := make(map[string]int, len(a.enforce))
+	for _, vs := range a.enforce {
+		toFetch = append(toFetch, &asserts.AtSequence{
+			Type:        asserts.ValidationSetType,
+			SequenceKey: []string{release.Series, vs.AccountID, vs.Name},
+			Sequence:    vs.Sequence,
+			Revision:    asserts.RevisionNotKnown,
+			Pinned:      vs.Sequence > 0,
+		})
+		if vs.Sequence > 0 {
+			pinnedSeqs[assertstate.ValidationSetKey(vs.AccountID, vs.Name)] = vs.Sequence
+		}
+	}
+	fetched, err := assertstateFetchValidationSets(a.st, toFetch, assertstate.FetchValidationSetsOptions{}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sets := fetched.Sets()
+	vsets, err := assertstate.TrackedEnforcedValidationSets(a.st, sets...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := vsets.Conflict(); err != nil {
+		return nil, nil, err
+	}
+
+	encodedAsserts := make(map[string][]byte, len(sets))
+	for _, vs := range sets {
+		encodedAsserts[assertstate.ValidationSetKey(vs.AccountID(), vs.Name())] = asserts.Encode(vs)
+	}
+	t := a.st.NewTask("enforce-validation-sets", "Enforce validation sets")
+	t.Set("validation-sets", encodedAsserts)
+	t.Set("pinned-sequence-numbers", pinnedSeqs)
+	t.Set("userID", a.userID)
+	return vsets, t, nil
+}
+
+func (a *applier) namesAndRevOpts(snaps []*Snap) ([]string, []*snapstate.RevisionOptions) {
+	names := make([]string, len(snaps))
+	revOpts := make([]*snapstate.RevisionOptions, len(snaps))
+	for i, s := range snaps {
+		names[i] = s.Name
+		revOpts[i] = a.revOpts[s.Name]
+	}
+	return names, revOpts
+}
+
+func (a *applier) planValidationSets(vss []*ValidationSet) error {
+	trackings, err := assertstate.ValidationSets(a.st)
+	if err != nil {
+		return err
+	}
+	for _, vs := range vss {
+		mode, err := vs.mode()
+		if err != nil {
+			return err
+		}
+		tr := trackings[assertstate.ValidationSetKey(vs.AccountID, vs.Name)]
+		if tr != nil && tr.Mode == mode && tr.PinnedAt == vs.Sequence {
+			continue
+		}
+
+		if mode == assertstate.Enforce {
+			a.plan.Actions = append(a.plan.Actions, fmt.Sprintf("Enforce validation set %s", vs.key()))
+			a.enforce = append(a.enforce, vs)
+			continue
+		}
+		summary := fmt.Sprintf("Monitor validation set %s", vs.key())
+		vs := vs
+		a.addAction(summary, func() (*state.TaskSet, error) {
+			t := a.st.NewTask("monitor-validation-set", summary)
+			t.Set("validation-set", vs)
+			t.Set("user-id", a.userID)
+			return state.NewTaskSet(t), nil
+		})
+	}
+	return nil
+}
+
+func describeRevOpts(summary string, revOpts *snapstate.RevisionOptions) string {
+	if revOpts.Channel != "" {
+		summary += fmt.Sprintf(" from %s", revOpts.Channel)
+	}
+	if !revOpts.Revision.Unset() {
+		summary += fmt.Sprintf(" at revision %s", revOpts.Revision)
+	}
+	switch {
+	case revOpts.CohortKey != "":
+		summary += " in a cohort"
+	case revOpts.LeaveCohort:
+		summary += " leaving its cohort"
+	}
+	return summary
+}
+
+// planSnap plans the installation or refresh of the given snap.
+func (a *applier) planSnap(s *Snap) error {
+	revOpts := &snapstate.RevisionOptions{CohortKey: s.Cohort}
+	if s.Channel != "" {
+		ch, err := channel.Full(s.Channel)
+		if err != nil {
+			return err
+		}
+		revOpts.Channel = ch
+	}
+	if s.Revision != "" {
+		rev, err := snap.ParseRevision(s.Revision)
+		if err != nil {
+			return err
+		}
+		revOpts.Revision = rev
+	}
+
+	snapst := a.snapStates[s.Name]
+	if snapst == nil {
+		summary := describeRevOpts(fmt.Sprintf("Install %q", s.Name), revOpts)
+		if revOpts.Revision.Local() {
+			a.skip(summary, "local revisions cannot be installed from the store")
+			return nil
+		}
+		a.installing[s.Name] = true
+		a.revOpts[s.Name] = revOpts
+		if s.Classic {
+			a.installClassic = append(a.installClassic, s)
+		} else {
+			a.install = append(a.install, s)
+		}
+		a.plan.Actions = append(a.plan.Actions, summary)
+		a.affect(s.Name)
+		return nil
+	}
+
+	if revOpts.Channel == snapst.TrackingChannel {
+		revOpts.Channel = ""
+	}
+	if revOpts.Revision == snapst.Current {
+		revOpts.Revision = snap.Revision{}
+	}
+	if revOpts.CohortKey == snapst.CohortKey {
+		revOpts.CohortKey = ""
+	} else if revOpts.CohortKey == "" {
+		revOpts.LeaveCohort = true
+	}
+	if revOpts.Channel == "" && revOpts.Revision.Unset() && revOpts.CohortKey == "" && !revOpts.LeaveCohort {
+		return nil
+	}
+
+	summary := describeRevOpts(fmt.Sprintf("Refresh %q", s.Name), revOpts)
+	if revOpts.Revision.Local() {
+		a.skip(summary, "local revisions cannot be installed from the store")
+		return nil
+	}
+	a.revOpts[s.Name] = revOpts
+	a.refresh = append(a.refresh, s)
+	a.plan.Actions = append(a.plan.Actions, summary)
+	a.affect(s.Name)
+	return nil
+}
+
+// planSnapSetup plans the refresh holds, configuration and aliases of the
+// given snap.
+func (a *applier) planSnapSetup(s *Snap) error {
+	if err := a.planHold(s); err != nil {
+		return err
+	}
+	if err := a.planConfig(s); err != nil {
+		return err
+	}
+	a.planAliases(s)
+	return nil
+}
+
+func (a *applier) planHold(s *Snap) error {
+	if s.Hold == "" {
+		if a.snapStates[s.Name] == nil {
+			return nil
+		}
+		hold, err := snapstate.SystemHold(a.st, s.Name)
+		if err != nil {
+			return err
+		}
+		if formatHold(hold) == "" {
+			return nil
+		}
+		summary := fmt.Sprintf("Remove the refresh hold of %q", s.Name)
+		a.addAction(summary, func() (*state.TaskSet, error) {
+			return holdRefreshesTaskSet(a.st, summary, s.Name, ""), nil
+		}, s.Name)
+		return nil
+	}
+
+	summary := fmt.Sprintf("Hold refreshes of %q forever", s.Name)
+	if s.Hold != "forever" {
+		summary = fmt.Sprintf("Hold refreshes of %q until %s", s.Name, s.Hold)
+	}
+	if a.missing(summary, s.Name) {
+		return nil
+	}
+	hold, err := snapstate.SystemHold(a.st, s.Name)
+	if err != nil {
+		return err
+	}
+	if current := formatHold(hold); current == s.Hold || (s.Hold != "forever" && sameTime(current, s.Hold)) {
+		return nil
+	}
+	a.addAction(summary, func() (*state.TaskSet, error) {
+		return holdRefreshesTaskSet(a.st, summary, s.Name, s.Hold), nil
+	}, s.Name)
+	return nil
+}
+
+// holdRefreshesTaskSet returns the task set holding the refreshes of the
+// given snap until the given time or forever, or removing the hold if
+// hold is empty.
+func holdRefreshesTaskSet(st *state.State, summary, snapName, hold string) *state.TaskSet {
+	t := st.NewTask("hold-refreshes", summary)
+	t.Set("snap-name", snapName)
+	t.Set("hold", hold)
+	return state.NewTaskSet(t)
+}
+
+// sameTime returns whether the given RFC3339 times are the same.
+func sameTime(t1, t2 string) bool {
+	tm1, err1 := time.Parse(time.RFC3339, t1)
+	tm2, err2 := time.Parse(time.RFC3339, t2)
+	return err1 == nil && err2 == nil && tm1.Equal(tm2)
+}
+
+func (a *applier) planConfig(s *Snap) error {
+	if len(s.Config) == 0 {
+		return nil
+	}
+	current, err := snapConfig(a.st, s.Name)
+	if err != nil {
+		return err
+	}
+	patch := make(map[string]interface{})
+	var keys []string
+	for key, value := range s.Config {
+		if !reflect.DeepEqual(current[key], value) {
+			patch[key] = value
+			keys = append(keys, key)
+		}
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	summary := fmt.Sprintf("Configure %q: %s", s.Name, strings.Join(keys, ", "))
+	if a.installing[s.Name] {
+		a.addAction(summary, func() (*state.TaskSet, error) {
+			return configstate.Configure(a.st, s.Name, patch, 0), nil
+		}, s.Name)
+		return nil
+	}
+	if a.missing(summary, s.Name) {
+		return nil
+	}
+	a.addAction(summary, func() (*state.TaskSet, error) {
+		return configstate.ConfigureInstalled(a.st, s.Name, patch, 0)
+	}, s.Name)
+	return nil
+}
+
+func (a *applier) planAliases(s *Snap) {
+	aliases := make([]string, 0, len(s.Aliases))
+	for alias := range s.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		app := s.Aliases[alias]
+		summary := fmt.Sprintf("Alias %q as %q", snap.JoinSnapApp(s.Name, app), alias)
+		if a.missing(summary, s.Name) {
+			continue
+		}
+		if target := a.snapStates[s.Name].Aliases[alias]; target != nil && target.Manual == app {
+			continue
+		}
+		alias := alias
+		a.addAction(summary, func() (*state.TaskSet, error) {
+			return snapstateAlias(a.st, s.Name, app, alias)
+		}, s.Name)
+	}
+}
+
+func (a *applier) planConnections(conns []*Connection) error {
+	connStates, err := ifacestate.ConnectionStates(a.st)
+	if err != nil {
+		return err
+	}
+	for _, c := range conns {
+		cref, err := c.connRef()
+		if err != nil {
+			return err
+		}
+		cstate, ok := connStates[cref.ID()]
+		connected := ok && cstate.Active()
+		snapNames := []string{cref.PlugRef.Snap, cref.SlotRef.Snap}
+
+		if c.Disconnected {
+			summary := fmt.Sprintf("Disconnect %s from %s", cref.PlugRef, cref.SlotRef)
+			if !connected {
+				// automatic connections are made when installing
+				if a.installing[cref.PlugRef.Snap] || a.installing[cref.SlotRef.Snap] {
+					a.skip(summary, "the snaps are not installed yet")
+				}
+				continue
+			}
+			a.addAction(summary, func() (*state.TaskSet, error) {
+				return ifacestateDisconnect(a.st, cref)
+			}, snapNames...)
+			continue
+		}
+
+		summary := fmt.Sprintf("Connect %s to %s", cref.PlugRef, cref.SlotRef)
+		if connected || a.missing(summary, snapNames...) {
+			continue
+		}
+		a.addAction(summary, func() (*state.TaskSet, error) {
+			return ifacestateConnect(a.st, cref.PlugRef.Snap, cref.PlugRef.Name, cref.SlotRef.Snap, cref.SlotRef.Name)
+		}, snapNames...)
+	}
+	return nil
+}
+
+func (a *applier) planQuotas(quotas []*Quota) error {
+	groups, err := servicestate.AllQuotas(a.st)
+	if err != nil {
+		return err
+	}
+	for _, q := range quotas {
+		snapNames := append([]string(nil), q.Snaps...)
+		for _, svc := range q.Services {
+			snapName, _ := snap.SplitSnapApp(svc)
+			snapNames = append(snapNames, snapName)
+		}
+
+		grp := groups[q.Name]
+		if grp == nil {
+			summary := fmt.Sprintf("Create quota group %q", q.Name)
+			if a.missing(summary, snapNames...) {
+				continue
+			}
+			if q.Parent != "" && groups[q.Parent] == nil {
+				a.skip(summary, fmt.Sprintf("quota group %q does not exist yet", q.Parent))
+				continue
+			}
+			q := q
+			a.addAction(summary, func() (*state.TaskSet, error) {
+				return servicestateCreateQuota(a.st, q.Name, servicestate.CreateQuotaOptions{
+					ParentName:     q.Parent,
+					Snaps:          q.Snaps,
+					Services:       q.Services,
+					ResourceLimits: q.Limits,
+				})
+			}, snapNames...)
+			continue
+		}
+
+		opts := servicestate.UpdateQuotaOptions{
+			AddSnaps:    missingFrom(grp.Snaps, q.Snaps),
+			AddServices: missingFrom(grp.Services, q.Services),
+		}
+		if !reflect.DeepEqual(grp.GetQuotaResources(), q.Limits) {
+			opts.NewResourceLimits = q.Limits
+		} else if len(opts.AddSnaps) == 0 && len(opts.AddServices) == 0 {
+			continue
+		}
+		summary := fmt.Sprintf("Update quota group %q", q.Name)
+		if a.missing(summary, snapNames...) {
+			continue
+		}
+		name := q.Name
+		a.addAction(summary, func() (*state.TaskSet, error) {
+			return servicestateUpdateQuota(a.st, name, opts)
+		}, snapNames...)
+	}
+	return nil
+}
+
+// missingFrom returns the elements of wanted that are not in have.
+func missingFrom(have, wanted []string) []string {
+	var missing []string
+	for _, s := range wanted {
+		if !strutil.ListContains(have, s) {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,74 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package manifest
+
+import (
+	"context"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func MockSnapstateInstallMany(f func(st *state.State, names []string, revOpts []*snapstate.RevisionOptions, userID int, flags *snapstate.Flags) ([]string, []*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&snapstateInstallMany, f)
+}
+
+func MockSnapstateUpdateMany(f func(ctx context.Context, st *state.State, names []string, revOpts []*snapstate.RevisionOptions, userID int, flags *snapstate.Flags) ([]string, []*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&snapstateUpdateMany, f)
+}
+
+func MockSnapstateAlias(f func(st *state.State, instanceName, app, alias string) (*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&snapstateAlias, f)
+}
+
+func MockIfacestateConnect(f func(st *state.State, plugSnap, plugName, slotSnap, slotName string) (*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&ifacestateConnect, f)
+}
+
+func MockIfacestateDisconnect(f func(st *state.State, cref *interfaces.ConnRef) (*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&ifacestateDisconnect, f)
+}
+
+func MockServicestateCreateQuota(f func(st *state.State, name string, createOpts servicestate.CreateQuotaOptions) (*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&servicestateCreateQuota, f)
+}
+
+func MockServicestateUpdateQuota(f func(st *state.State, name string, updateOpts servicestate.UpdateQuotaOptions) (*state.TaskSet, error)) (restore func()) {
+	return testutil.Mock(&servicestateUpdateQuota, f)
+}
+
+func MockAssertstateMonitorValidationSet(f func(st *state.State, accountID, name string, sequence int, userID int) (*assertstate.ValidationSetTracking, error)) (restore func()) {
+	return testutil.Mock(&assertstateMonitorValidationSet, f)
+}
+
+func MockAssertstateFetchValidationSets(f func(st *state.State, toFetch []*asserts.AtSequence, opts assertstate.FetchValidationSetsOptions, deviceCtx snapstate.DeviceContext) (*snapasserts.ValidationSets, error)) (restore func()) {
+	return testutil.Mock(&assertstateFetchValidationSets, f)
+}
+
+var (
+	DoMonitorValidationSet = doMonitorValidationSet
+	DoHoldRefreshes        = doHoldRefreshes
+)
@@ -0,0 +1,73 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package manifest
+
+import (
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+var assertstateMonitorValidationSet = assertstate.MonitorValidationSet
+
+// Init registers the handlers of the tasks applying a manifest that are
+// not covered by other managers.
+func Init(runner *state.TaskRunner) {
+	mgrRunner := runner.ForManager("manifest")
+	mgrRunner.AddHandler("monitor-validation-set", doMonitorValidationSet, nil)
+	mgrRunner.AddHandler("hold-refreshes", doHoldRefreshes, nil)
+}
+
+func doMonitorValidationSet(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var vs ValidationSet
+	if err := t.Get("validation-set", &vs); err != nil {
+		return err
+	}
+	var userID int
+	if err := t.Get("user-id", &userID); err != nil {
+		return err
+	}
+	_, err := assertstateMonitorValidationSet(st, vs.AccountID, vs.Name, vs.Sequence, userID)
+	return err
+}
+
+func doHoldRefreshes(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var snapName, hold string
+	if err := t.Get("snap-name", &snapName); err != nil {
+		return err
+	}
+	if err := t.Get("hold", &hold); err != nil {
+		return err
+	}
+	if hold == "" {
+		return snapstate.ProceedWithRefresh(st, "system", []string{snapName})
+	}
+	return snapstate.HoldRefreshesBySystem(st, snapstate.HoldGeneral, hold, []string{snapName})
+}
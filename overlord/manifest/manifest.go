@@ -0,0 +1,327 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package manifest exports and applies declarative descriptions of the
+// snaps installed on a system and of how they are set up.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/channel"
+	"github.com/snapcore/snapd/snap/quota"
+)
+
+var timeNow = time.Now
+
+// Manifest describes the snaps installed on a system and how they are set
+// up. Anything a manifest does not mention is left alone when it is applied.
+type Manifest struct {
+	Snaps          []*Snap          `json:"snaps,omitempty"`
+	Connections    []*Connection    `json:"connections,omitempty"`
+	Quotas         []*Quota         `json:"quotas,omitempty"`
+	ValidationSets []*ValidationSet `json:"validation-sets,omitempty"`
+}
+
+// Snap describes an installed snap.
+type Snap struct {
+	Name     string `json:"name"`
+	Channel  string `json:"channel,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Cohort   string `json:"cohort,omitempty"`
+	Classic  bool   `json:"classic,omitempty"`
+	// Hold is either "forever" or the time, in RFC3339 format, until which
+	// refreshes of the snap are held by the administrator.
+	Hold   string                 `json:"hold,omitempty"`
+	Config map[string]interface{} `json:"config,omitempty"`
+	// Aliases maps the manual aliases of the snap to their app.
+	Aliases map[string]string `json:"aliases,omitempty"`
+}
+
+// Connection describes an interface connection made manually or, if
+// Disconnected is set, an automatic one that was disconnected.
+type Connection struct {
+	// Plug and Slot are of the form <snap>:<name>.
+	Plug         string `json:"plug"`
+	Slot         string `json:"slot"`
+	Disconnected bool   `json:"disconnected,omitempty"`
+}
+
+func (c *Connection) connRef() (*interfaces.ConnRef, error) {
+	return interfaces.ParseConnRef(c.Plug + " " + c.Slot)
+}
+
+// Quota describes a quota group.
+type Quota struct {
+	Name     string          `json:"name"`
+	Parent   string          `json:"parent,omitempty"`
+	Snaps    []string        `json:"snaps,omitempty"`
+	Services []string        `json:"services,omitempty"`
+	Limits   quota.Resources `json:"limits"`
+}
+
+// ValidationSet describes a tracked validation set.
+type ValidationSet struct {
+	AccountID string `json:"account-id"`
+	Name      string `json:"name"`
+	// Mode is either "monitor" or "enforce".
+	Mode string `json:"mode"`
+	// Sequence is the sequence the validation set is pinned at, if any.
+	Sequence int `json:"sequence,omitempty"`
+}
+
+func (vs *ValidationSet) key() string {
+	key := assertstate.ValidationSetKey(vs.AccountID, vs.Name)
+	if vs.Sequence > 0 {
+		key = fmt.Sprintf("%s=%d", key, vs.Sequence)
+	}
+	return key
+}
+
+func (vs *ValidationSet) mode() (assertstate.ValidationSetMode, error) {
+	switch vs.Mode {
+	case "monitor":
+		return assertstate.Monitor, nil
+	case "enforce":
+		return assertstate.Enforce, nil
+	}
+	return 0, fmt.Errorf("invalid mode %q of validation set %s", vs.Mode, vs.key())
+}
+
+// Validate checks that the manifest is well formed.
+func (m *Manifest) Validate() error {
+	seen := make(map[string]bool, len(m.Snaps))
+	for _, s := range m.Snaps {
+		if err := snap.ValidateInstanceName(s.Name); err != nil {
+			return fmt.Errorf("invalid manifest: %v", err)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("invalid manifest: snap %q is listed more than once", s.Name)
+		}
+		seen[s.Name] = true
+		if s.Channel != "" {
+			if _, err := channel.Full(s.Channel); err != nil {
+				return fmt.Errorf("invalid manifest: invalid channel of snap %q: %v", s.Name, err)
+			}
+		}
+		if s.Revision != "" {
+			if _, err := snap.ParseRevision(s.Revision); err != nil {
+				return fmt.Errorf("invalid manifest: invalid revision of snap %q: %v", s.Name, err)
+			}
+		}
+		if s.Hold != "" && s.Hold != "forever" {
+			if _, err := time.Parse(time.RFC3339, s.Hold); err != nil {
+				return fmt.Errorf("invalid manifest: invalid hold of snap %q: %v", s.Name, err)
+			}
+		}
+	}
+	for _, c := range m.Connections {
+		if _, err := c.connRef(); err != nil {
+			return fmt.Errorf("invalid manifest: %v", err)
+		}
+	}
+	for _, q := range m.Quotas {
+		if q.Name == "" {
+			return fmt.Errorf("invalid manifest: quota group without a name")
+		}
+	}
+	for _, vs := range m.ValidationSets {
+		if _, err := vs.mode(); err != nil {
+			return fmt.Errorf("invalid manifest: %v", err)
+		}
+	}
+	return nil
+}
+
+// Export returns the manifest of the snaps installed on the system, except
+// for the gadget and kernel snaps, which come with the model.
+// The state must be locked by the caller.
+func Export(st *state.State) (*Manifest, error) {
+	m := &Manifest{}
+
+	snapStates, err := snapstate.All(st)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(snapStates))
+	for name := range snapStates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s, err := exportSnap(st, name, snapStates[name])
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			m.Snaps = append(m.Snaps, s)
+		}
+	}
+
+	conns, err := ifacestate.ConnectionStates(st)
+	if err != nil {
+		return nil, err
+	}
+	connIDs := make([]string, 0, len(conns))
+	for id := range conns {
+		connIDs = append(connIDs, id)
+	}
+	sort.Strings(connIDs)
+	for _, id := range connIDs {
+		cstate := conns[id]
+		// automatic connections are made again anyway
+		if cstate.HotplugGone || (cstate.Auto && !cstate.Undesired) {
+			continue
+		}
+		cref, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return nil, err
+		}
+		m.Connections = append(m.Connections, &Connection{
+			Plug:         cref.PlugRef.String(),
+			Slot:         cref.SlotRef.String(),
+			Disconnected: cstate.Undesired,
+		})
+	}
+
+	groups, err := servicestate.AllQuotas(st)
+	if err != nil {
+		return nil, err
+	}
+	groupNames := make([]string, 0, len(groups))
+	for name := range groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		grp := groups[name]
+		m.Quotas = append(m.Quotas, &Quota{
+			Name:     grp.Name,
+			Parent:   grp.ParentGroup,
+			Snaps:    grp.Snaps,
+			Services: grp.Services,
+			Limits:   grp.GetQuotaResources(),
+		})
+	}
+
+	trackings, err := assertstate.ValidationSets(st)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(trackings))
+	for key := range trackings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		tr := trackings[key]
+		mode := "monitor"
+		if tr.Mode == assertstate.Enforce {
+			mode = "enforce"
+		}
+		m.ValidationSets = append(m.ValidationSets, &ValidationSet{
+			AccountID: tr.AccountID,
+			Name:      tr.Name,
+			Mode:      mode,
+			Sequence:  tr.PinnedAt,
+		})
+	}
+
+	return m, nil
+}
+
+func exportSnap(st *state.State, name string, snapst *snapstate.SnapState) (*Snap, error) {
+	typ, err := snapst.Type()
+	if err != nil {
+		return nil, err
+	}
+	if typ == snap.TypeGadget || typ == snap.TypeKernel {
+		return nil, nil
+	}
+
+	s := &Snap{
+		Name:     name,
+		Channel:  snapst.TrackingChannel,
+		Revision: snapst.Current.String(),
+		Cohort:   snapst.CohortKey,
+		Classic:  snapst.Classic,
+	}
+
+	hold, err := snapstate.SystemHold(st, name)
+	if err != nil {
+		return nil, err
+	}
+	s.Hold = formatHold(hold)
+
+	// only apps and the core snap, for the system, can be configured
+	if typ == snap.TypeApp || typ == snap.TypeOS {
+		if s.Config, err = snapConfig(st, name); err != nil {
+			return nil, err
+		}
+	}
+
+	for alias, target := range snapst.Aliases {
+		if target.Manual == "" {
+			continue
+		}
+		if s.Aliases == nil {
+			s.Aliases = make(map[string]string)
+		}
+		s.Aliases[alias] = target.Manual
+	}
+
+	return s, nil
+}
+
+// holdForever is past what holding refreshes "forever" amounts to.
+const holdForever = 100 * 365 * 24 * time.Hour
+
+func formatHold(hold time.Time) string {
+	now := timeNow()
+	switch {
+	case hold.Before(now):
+		return ""
+	case hold.After(now.Add(holdForever)):
+		return "forever"
+	}
+	return hold.Format(time.RFC3339)
+}
+
+func snapConfig(st *state.State, name string) (map[string]interface{}, error) {
+	raw, err := config.GetSnapConfig(st, name)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(*raw, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot decode configuration of snap %q: %v", name, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,665 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package manifest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/asserts/assertstest"
+	"github.com/snapcore/snapd/asserts/snapasserts"
+	"github.com/snapcore/snapd/gadget/quantity"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/manifest"
+	"github.com/snapcore/snapd/overlord/servicestate"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/quota"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/store/storetest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type fakeStore struct {
+	storetest.Store
+
+	db asserts.RODatabase
+}
+
+func (sto *fakeStore) Assertion(assertType *asserts.AssertionType, key []string, _ *auth.UserState) (asserts.Assertion, error) {
+	ref := &asserts.Ref{Type: assertType, PrimaryKey: key}
+	return ref.Resolve(sto.db.Find)
+}
+
+func (sto *fakeStore) SeqFormingAssertion(assertType *asserts.AssertionType, key []string, sequence int, _ *auth.UserState) (asserts.Assertion, error) {
+	hdrs, err := asserts.HeadersFromSequenceKey(assertType, key)
+	if err != nil {
+		return nil, err
+	}
+	if sequence <= 0 {
+		return sto.db.FindSequence(assertType, hdrs, -1, assertType.MaxSupportedFormat())
+	}
+	hdrs["sequence"] = fmt.Sprint(sequence)
+	return sto.db.Find(assertType, hdrs)
+}
+
+type manifestSuite struct {
+	testutil.BaseTest
+	st *state.State
+
+	storeSigning *assertstest.StoreStack
+	accSigning   *assertstest.SigningDB
+}
+
+var _ = Suite(&manifestSuite{})
+
+func (s *manifestSuite) SetUpTest(c *C) {
+	s.BaseTest.SetUpTest(c)
+	s.st = state.New(nil)
+
+	s.storeSigning = assertstest.NewStoreStack("can0nical", nil)
+	acct := assertstest.NewAccount(s.storeSigning, "acc", map[string]interface{}{"account-id": "acc"}, "")
+	c.Assert(s.storeSigning.Add(acct), IsNil)
+	accPrivKey, _ := assertstest.GenerateKey(752)
+	acctKey := assertstest.NewAccountKey(s.storeSigning, acct, nil, accPrivKey.PublicKey(), "")
+	c.Assert(s.storeSigning.Add(acctKey), IsNil)
+	s.accSigning = assertstest.NewSigningDB("acc", accPrivKey)
+
+	db, err := asserts.OpenDatabase(&asserts.DatabaseConfig{
+		Backstore: asserts.NewMemoryBackstore(),
+		Trusted:   s.storeSigning.Trusted,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(db.Add(s.storeSigning.StoreAccountKey("")), IsNil)
+
+	s.st.Lock()
+	assertstate.ReplaceDB(s.st, db)
+	snapstate.ReplaceStore(s.st, &fakeStore{db: s.storeSigning})
+	s.st.Unlock()
+}
+
+// addValidationSet adds to the store a validation set of account acc
+// requiring the given snaps at the given revisions.
+func (s *manifestSuite) addValidationSet(c *C, name string, sequence int, snapRevs map[string]int) {
+	var snaps []interface{}
+	for name, rev := range snapRevs {
+		snaps = append(snaps, map[string]interface{}{
+			"name":     name,
+			"id":       snaptest.AssertedSnapID(name),
+			"presence": "required",
+			"revision": fmt.Sprint(rev),
+		})
+	}
+	vs, err := s.accSigning.Sign(asserts.ValidationSetType, map[string]interface{}{
+		"authority-id": "acc",
+		"account-id":   "acc",
+		"series":       "16",
+		"name":         name,
+		"sequence":     fmt.Sprint(sequence),
+		"snaps":        snaps,
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	c.Assert(s.storeSigning.Add(vs), IsNil)
+}
+
+func (s *manifestSuite) setSnap(name string, typ snap.Type, channel string, rev snap.Revision, mod func(snapst *snapstate.SnapState)) {
+	snapst := &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: name, Revision: rev, SnapID: snaptest.AssertedSnapID(name)},
+		}),
+		Current:         rev,
+		SnapType:        string(typ),
+		TrackingChannel: channel,
+	}
+	if mod != nil {
+		mod(snapst)
+	}
+	snapstate.Set(s.st, name, snapst)
+}
+
+func (s *manifestSuite) setConfig(c *C, snapName string, cfg string) {
+	raw := json.RawMessage(cfg)
+	c.Assert(config.SetSnapConfig(s.st, snapName, &raw), IsNil)
+}
+
+func memoryLimit(size quantity.Size) quota.Resources {
+	return quota.NewResourcesBuilder().WithMemoryLimit(size).Build()
+}
+
+// mockSystem puts snaps, configuration, connections, quota groups and
+// validation sets in the state, as described by systemManifest.
+func (s *manifestSuite) mockSystem(c *C) {
+	s.setSnap("foo", snap.TypeApp, "latest/stable", snap.R(3), func(snapst *snapstate.SnapState) {
+		snapst.CohortKey = "cohort"
+		snapst.Classic = true
+		snapst.Aliases = map[string]*snapstate.AliasTarget{
+			"foo-manual": {Manual: "app"},
+			"foo-auto":   {Auto: "app"},
+		}
+	})
+	s.setSnap("bar", snap.TypeApp, "latest/edge", snap.R(7), nil)
+	s.setSnap("core", snap.TypeOS, "latest/stable", snap.R(1), nil)
+	s.setSnap("core22", snap.TypeBase, "latest/stable", snap.R(2), nil)
+	s.setSnap("pc-kernel", snap.TypeKernel, "22/stable", snap.R(4), nil)
+	s.setSnap("pc", snap.TypeGadget, "22/stable", snap.R(5), nil)
+
+	s.setConfig(c, "foo", `{"a":1,"b":{"c":"d"}}`)
+	s.setConfig(c, "core", `{"system":{"timezone":"UTC"}}`)
+	c.Assert(snapstate.HoldRefreshesBySystem(s.st, snapstate.HoldGeneral, "forever", []string{"bar"}), IsNil)
+
+	s.st.Set("conns", map[string]interface{}{
+		"foo:plug bar:slot":  map[string]interface{}{"interface": "test"},
+		"bar:auto foo:slot":  map[string]interface{}{"interface": "test", "auto": true},
+		"bar:plug core:slot": map[string]interface{}{"interface": "test", "auto": true, "undesired": true},
+	})
+
+	grp, err := quota.NewGroup("grp", memoryLimit(quantity.SizeGiB))
+	c.Assert(err, IsNil)
+	grp.Snaps = []string{"foo"}
+	s.st.Set("quotas", map[string]*quota.Group{"grp": grp})
+
+	assertstate.UpdateValidationSet(s.st, &assertstate.ValidationSetTracking{
+		AccountID: "acc",
+		Name:      "vs",
+		Mode:      assertstate.Enforce,
+		PinnedAt:  2,
+		Current:   2,
+	})
+}
+
+var systemManifest = &manifest.Manifest{
+	Snaps: []*manifest.Snap{{
+		Name:     "bar",
+		Channel:  "latest/edge",
+		Revision: "7",
+		Hold:     "forever",
+	}, {
+		Name:     "core",
+		Channel:  "latest/stable",
+		Revision: "1",
+		Config: map[string]interface{}{
+			"system": map[string]interface{}{"timezone": "UTC"},
+		},
+	}, {
+		Name:     "core22",
+		Channel:  "latest/stable",
+		Revision: "2",
+	}, {
+		Name:     "foo",
+		Channel:  "latest/stable",
+		Revision: "3",
+		Cohort:   "cohort",
+		Classic:  true,
+		Config: map[string]interface{}{
+			"a": 1.0,
+			"b": map[string]interface{}{"c": "d"},
+		},
+		Aliases: map[string]string{"foo-manual": "app"},
+	}},
+	Connections: []*manifest.Connection{
+		{Plug: "bar:plug", Slot: "core:slot", Disconnected: true},
+		{Plug: "foo:plug", Slot: "bar:slot"},
+	},
+	Quotas: []*manifest.Quota{{
+		Name:   "grp",
+		Snaps:  []string{"foo"},
+		Limits: memoryLimit(quantity.SizeGiB),
+	}},
+	ValidationSets: []*manifest.ValidationSet{
+		{AccountID: "acc", Name: "vs", Mode: "enforce", Sequence: 2},
+	},
+}
+
+func (s *manifestSuite) TestExport(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	s.mockSystem(c)
+
+	m, err := manifest.Export(s.st)
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, systemManifest)
+}
+
+func (s *manifestSuite) TestExportEmpty(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	m, err := manifest.Export(s.st)
+	c.Assert(err, IsNil)
+	c.Check(m, DeepEquals, &manifest.Manifest{})
+}
+
+func (s *manifestSuite) TestApplyExportedNothingToDo(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	s.mockSystem(c)
+
+	m, err := manifest.Export(s.st)
+	c.Assert(err, IsNil)
+	plan, tss, affected, err := manifest.Apply(context.Background(), s.st, m, 0, false)
+	c.Assert(err, IsNil)
+	c.Check(plan, DeepEquals, &manifest.Plan{})
+	c.Check(tss, HasLen, 0)
+	c.Check(affected, HasLen, 0)
+}
+
+// mockApplySystem puts what applyManifest is applied to in the state.
+func (s *manifestSuite) mockApplySystem(c *C) {
+	s.setSnap("foo", snap.TypeApp, "latest/stable", snap.R(3), nil)
+	s.setSnap("bar", snap.TypeApp, "latest/stable", snap.R(5), func(snapst *snapstate.SnapState) {
+		snapst.CohortKey = "cohort"
+	})
+	s.setSnap("core", snap.TypeOS, "latest/stable", snap.R(1), nil)
+	s.setConfig(c, "foo", `{"a":1}`)
+
+	s.st.Set("conns", map[string]interface{}{
+		"foo:plug bar:slot": map[string]interface{}{"interface": "test", "auto": true},
+	})
+
+	grp, err := quota.NewGroup("grp", memoryLimit(quantity.SizeGiB))
+	c.Assert(err, IsNil)
+	grp.Snaps = []string{"bar"}
+	s.st.Set("quotas", map[string]*quota.Group{"grp": grp})
+
+	assertstate.UpdateValidationSet(s.st, &assertstate.ValidationSetTracking{
+		AccountID: "acc",
+		Name:      "vs",
+		Mode:      assertstate.Monitor,
+		Current:   1,
+	})
+}
+
+var applyManifest = &manifest.Manifest{
+	Snaps: []*manifest.Snap{{
+		Name:    "foo",
+		Channel: "candidate",
+		Hold:    "forever",
+		Config:  map[string]interface{}{"a": 1.0, "b": "x"},
+		Aliases: map[string]string{"fa": "app"},
+	}, {
+		Name:     "bar",
+		Channel:  "latest/stable",
+		Revision: "5",
+	}, {
+		Name:    "baz",
+		Channel: "latest/edge",
+		Config:  map[string]interface{}{"k": true},
+		Aliases: map[string]string{"bz": "app"},
+	}, {
+		Name:    "cls",
+		Classic: true,
+	}, {
+		Name:     "loc",
+		Revision: "x1",
+	}, {
+		Name:     "core",
+		Channel:  "latest/stable",
+		Revision: "1",
+	}},
+	Connections: []*manifest.Connection{
+		{Plug: "foo:plug", Slot: "bar:slot", Disconnected: true},
+		{Plug: "baz:plug", Slot: "foo:slot"},
+		{Plug: "foo:other", Slot: "bar:slot"},
+	},
+	Quotas: []*manifest.Quota{{
+		Name:   "grp",
+		Snaps:  []string{"bar", "foo"},
+		Limits: memoryLimit(quantity.SizeGiB),
+	}, {
+		Name:   "new",
+		Snaps:  []string{"foo"},
+		Limits: memoryLimit(quantity.SizeGiB / 2),
+	}, {
+		Name:   "sub",
+		Parent: "new",
+		Limits: memoryLimit(quantity.SizeGiB / 4),
+	}},
+	ValidationSets: []*manifest.ValidationSet{
+		{AccountID: "acc", Name: "vs", Mode: "enforce"},
+		{AccountID: "acc", Name: "other", Mode: "monitor", Sequence: 3},
+	},
+}
+
+var applyPlan = &manifest.Plan{
+	Actions: []string{
+		"Enforce validation set acc/vs",
+		"Monitor validation set acc/other=3",
+		`Refresh "foo" from latest/candidate`,
+		`Refresh "bar" leaving its cohort`,
+		`Install "baz" from latest/edge`,
+		`Install "cls"`,
+		`Hold refreshes of "foo" forever`,
+		`Configure "foo": b`,
+		`Alias "foo.app" as "fa"`,
+		`Configure "baz": k`,
+		"Disconnect foo:plug from bar:slot",
+		"Connect foo:other to bar:slot",
+		`Update quota group "grp"`,
+		`Create quota group "new"`,
+	},
+	Skipped: []string{
+		`Install "loc" at revision x1: local revisions cannot be installed from the store`,
+		`Alias "baz.app" as "bz": snap "baz" is not installed yet`,
+		`Connect baz:plug to foo:slot: snap "baz" is not installed yet`,
+		`Create quota group "sub": quota group "new" does not exist yet`,
+	},
+}
+
+func (s *manifestSuite) TestApplyDryRun(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	s.mockApplySystem(c)
+
+	plan, tss, affected, err := manifest.Apply(context.Background(), s.st, applyManifest, 0, true)
+	c.Assert(err, IsNil)
+	c.Check(plan, DeepEquals, applyPlan)
+	c.Check(tss, HasLen, 0)
+	c.Check(affected, DeepEquals, []string{"foo", "bar", "baz", "cls"})
+
+	// nothing was done
+	hold, err := snapstate.SystemHold(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(hold.IsZero(), Equals, true)
+	c.Check(s.st.Changes(), HasLen, 0)
+	c.Check(s.st.Tasks(), HasLen, 0)
+}
+
+func (s *manifestSuite) TestApply(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	s.mockApplySystem(c)
+
+	newTaskSet := func(kind string) *state.TaskSet {
+		return state.NewTaskSet(s.st.NewTask(kind, "..."))
+	}
+	s.addValidationSet(c, "vs", 1, map[string]int{"baz": 2})
+	// the snaps are installed and refreshed with the validation sets to
+	// enforce
+	checkValidationSets := func(revOpts []*snapstate.RevisionOptions) {
+		for _, opts := range revOpts {
+			c.Assert(opts.ValidationSets, NotNil)
+			c.Check(opts.ValidationSets.Keys(), DeepEquals, []snapasserts.ValidationSetKey{"16/acc/vs/1"})
+			opts.ValidationSets = nil
+		}
+	}
+
+	var calls []string
+	s.AddCleanup(manifest.MockSnapstateInstallMany(func(st *state.State, names []string, revOpts []*snapstate.RevisionOptions, userID int, flags *snapstate.Flags) ([]string, []*state.TaskSet, error) {
+		c.Check(userID, Equals, 42)
+		checkValidationSets(revOpts)
+		if flags.Classic {
+			c.Check(names, DeepEquals, []string{"cls"})
+			c.Check(revOpts, DeepEquals, []*snapstate.RevisionOptions{{}})
+			calls = append(calls, "install-classic")
+			return names, []*state.TaskSet{newTaskSet("install-cls")}, nil
+		}
+		c.Check(names, DeepEquals, []string{"baz"})
+		c.Check(revOpts, DeepEquals, []*snapstate.RevisionOptions{{Channel: "latest/edge"}})
+		calls = append(calls, "install")
+		return names, []*state.TaskSet{newTaskSet("install-baz")}, nil
+	}))
+	s.AddCleanup(manifest.MockSnapstateUpdateMany(func(ctx context.Context, st *state.State, names []string, revOpts []*snapstate.RevisionOptions, userID int, flags *snapstate.Flags) ([]string, []*state.TaskSet, error) {
+		c.Check(names, DeepEquals, []string{"foo", "bar"})
+		checkValidationSets(revOpts)
+		c.Check(revOpts, DeepEquals, []*snapstate.RevisionOptions{
+			{Channel: "latest/candidate"},
+			{LeaveCohort: true},
+		})
+		calls = append(calls, "update")
+		return names, []*state.TaskSet{newTaskSet("refresh-foo"), newTaskSet("refresh-bar")}, nil
+	}))
+	s.AddCleanup(manifest.MockSnapstateAlias(func(st *state.State, instanceName, app, alias string) (*state.TaskSet, error) {
+		c.Check([]string{instanceName, app, alias}, DeepEquals, []string{"foo", "app", "fa"})
+		return newTaskSet("alias"), nil
+	}))
+	s.AddCleanup(manifest.MockIfacestateDisconnect(func(st *state.State, cref *interfaces.ConnRef) (*state.TaskSet, error) {
+		c.Check(cref.ID(), Equals, "foo:plug bar:slot")
+		return newTaskSet("disconnect"), nil
+	}))
+	s.AddCleanup(manifest.MockIfacestateConnect(func(st *state.State, plugSnap, plugName, slotSnap, slotName string) (*state.TaskSet, error) {
+		c.Check([]string{plugSnap, plugName, slotSnap, slotName}, DeepEquals, []string{"foo", "other", "bar", "slot"})
+		return newTaskSet("connect"), nil
+	}))
+	s.AddCleanup(manifest.MockServicestateUpdateQuota(func(st *state.State, name string, updateOpts servicestate.UpdateQuotaOptions) (*state.TaskSet, error) {
+		c.Check(name, Equals, "grp")
+		c.Check(updateOpts, DeepEquals, servicestate.UpdateQuotaOptions{AddSnaps: []string{"foo"}})
+		return newTaskSet("update-quota"), nil
+	}))
+	s.AddCleanup(manifest.MockServicestateCreateQuota(func(st *state.State, name string, createOpts servicestate.CreateQuotaOptions) (*state.TaskSet, error) {
+		c.Check(name, Equals, "new")
+		c.Check(createOpts, DeepEquals, servicestate.CreateQuotaOptions{
+			Snaps:          []string{"foo"},
+			ResourceLimits: memoryLimit(quantity.SizeGiB / 2),
+		})
+		return newTaskSet("create-quota"), nil
+	}))
+
+	plan, tss, affected, err := manifest.Apply(context.Background(), s.st, applyManifest, 42, false)
+	c.Assert(err, IsNil)
+	c.Check(plan, DeepEquals, applyPlan)
+	c.Check(affected, DeepEquals, []string{"foo", "bar", "baz", "cls"})
+	c.Check(calls, DeepEquals, []string{"install", "install-classic", "update"})
+
+	var kinds []string
+	for _, ts := range tss {
+		kinds = append(kinds, ts.Tasks()[0].Kind())
+	}
+	c.Check(kinds, DeepEquals, []string{
+		"install-baz", "install-cls", "refresh-foo", "refresh-bar",
+		"monitor-validation-set", "hold-refreshes", "run-hook", "alias", "run-hook",
+		"disconnect", "connect", "update-quota", "create-quota", "enforce-validation-sets",
+	})
+	// the rest waits for the snaps
+	snapTasks := []*state.Task{tss[0].Tasks()[0], tss[1].Tasks()[0], tss[2].Tasks()[0], tss[3].Tasks()[0]}
+	for _, ts := range tss[4:] {
+		c.Check(ts.Tasks()[0].WaitTasks(), testutil.DeepUnsortedMatches, snapTasks)
+	}
+
+	// nothing is done until the tasks run
+	hold, err := snapstate.SystemHold(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(hold.IsZero(), Equals, true)
+	trackings, err := assertstate.ValidationSets(s.st)
+	c.Assert(err, IsNil)
+	c.Check(trackings, HasLen, 1)
+	c.Check(trackings["acc/vs"].Mode, Equals, assertstate.Monitor)
+
+	enforceTask := tss[len(tss)-1].Tasks()[0]
+	var pinnedSeqs map[string]int
+	c.Assert(enforceTask.Get("pinned-sequence-numbers", &pinnedSeqs), IsNil)
+	c.Check(pinnedSeqs, HasLen, 0)
+	var userID int
+	c.Assert(enforceTask.Get("userID", &userID), IsNil)
+	c.Check(userID, Equals, 42)
+
+	s.AddCleanup(manifest.MockAssertstateMonitorValidationSet(func(st *state.State, accountID, name string, sequence int, userID int) (*assertstate.ValidationSetTracking, error) {
+		c.Check(accountID+"/"+name, Equals, "acc/other")
+		c.Check(sequence, Equals, 3)
+		c.Check(userID, Equals, 42)
+		calls = append(calls, "monitor")
+		return nil, nil
+	}))
+	s.st.Unlock()
+	err = manifest.DoMonitorValidationSet(tss[4].Tasks()[0], nil)
+	s.st.Lock()
+	c.Assert(err, IsNil)
+	c.Check(calls, DeepEquals, []string{"install", "install-classic", "update", "monitor"})
+
+	s.st.Unlock()
+	err = manifest.DoHoldRefreshes(tss[5].Tasks()[0], nil)
+	s.st.Lock()
+	c.Assert(err, IsNil)
+	hold, err = snapstate.SystemHold(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(hold.After(time.Now().Add(100*365*24*time.Hour)), Equals, true)
+}
+
+func (s *manifestSuite) TestApplyEnforceNotInstalled(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	s.AddCleanup(snapstatetest.UseFallbackDeviceModel())
+	s.st.Set("seeded", true)
+
+	// the validation set requires a snap that is not installed yet, as
+	// when applying an exported manifest to a fresh device
+	s.addValidationSet(c, "vs", 1, map[string]int{"foo": 3})
+	s.addValidationSet(c, "vs", 2, map[string]int{"foo": 5})
+
+	s.AddCleanup(manifest.MockSnapstateInstallMany(func(st *state.State, names []string, revOpts []*snapstate.RevisionOptions, userID int, flags *snapstate.Flags) ([]string, []*state.TaskSet, error) {
+		c.Check(names, DeepEquals, []string{"foo"})
+		c.Assert(revOpts[0].ValidationSets, NotNil)
+		revs, err := revOpts[0].ValidationSets.Revisions()
+		c.Assert(err, IsNil)
+		c.Check(revs, DeepEquals, map[string]snap.Revision{"foo": snap.R(3)})
+		return names, []*state.TaskSet{state.NewTaskSet(s.st.NewTask("install-foo", "..."))}, nil
+	}))
+
+	m := &manifest.Manifest{
+		Snaps:          []*manifest.Snap{{Name: "foo"}},
+		ValidationSets: []*manifest.ValidationSet{{AccountID: "acc", Name: "vs", Mode: "enforce", Sequence: 1}},
+	}
+	plan, tss, _, err := manifest.Apply(context.Background(), s.st, m, 0, false)
+	c.Assert(err, IsNil)
+	c.Check(plan.Actions, DeepEquals, []string{"Enforce validation set acc/vs=1", `Install "foo"`})
+	c.Assert(tss, HasLen, 2)
+	enforceTask := tss[1].Tasks()[0]
+	c.Check(enforceTask.Kind(), Equals, "enforce-validation-sets")
+	c.Check(enforceTask.WaitTasks(), DeepEquals, tss[0].Tasks())
+
+	// the validation set is not tracked until the task runs
+	trackings, err := assertstate.ValidationSets(s.st)
+	c.Assert(err, IsNil)
+	c.Check(trackings, HasLen, 0)
+
+	// once the snap is installed, what the task carries is enough to
+	// enforce the validation set
+	s.setSnap("foo", snap.TypeApp, "latest/stable", snap.R(3), nil)
+	encodedAsserts := make(map[string][]byte)
+	c.Assert(enforceTask.Get("validation-sets", &encodedAsserts), IsNil)
+	valsets := make(map[string]*asserts.ValidationSet)
+	for key, encoded := range encodedAsserts {
+		a, err := asserts.Decode(encoded)
+		c.Assert(err, IsNil)
+		valsets[key] = a.(*asserts.ValidationSet)
+	}
+	var pinnedSeqs map[string]int
+	c.Assert(enforceTask.Get("pinned-sequence-numbers", &pinnedSeqs), IsNil)
+	snaps, ignoreValidation, err := snapstate.InstalledSnaps(s.st)
+	c.Assert(err, IsNil)
+	c.Assert(assertstate.ApplyEnforcedValidationSets(s.st, valsets, pinnedSeqs, snaps, ignoreValidation, 0), IsNil)
+
+	trackings, err = assertstate.ValidationSets(s.st)
+	c.Assert(err, IsNil)
+	c.Check(trackings["acc/vs"], DeepEquals, &assertstate.ValidationSetTracking{
+		AccountID: "acc",
+		Name:      "vs",
+		Mode:      assertstate.Enforce,
+		PinnedAt:  1,
+		Current:   1,
+	})
+}
+
+func (s *manifestSuite) TestApplyEnforceInstallError(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	s.addValidationSet(c, "vs", 1, map[string]int{"foo": 3})
+
+	s.AddCleanup(manifest.MockSnapstateInstallMany(func(st *state.State, names []string, revOpts []*snapstate.RevisionOptions, userID int, flags *snapstate.Flags) ([]string, []*state.TaskSet, error) {
+		return nil, nil, fmt.Errorf("boom")
+	}))
+
+	m := &manifest.Manifest{
+		Snaps:          []*manifest.Snap{{Name: "foo"}},
+		ValidationSets: []*manifest.ValidationSet{{AccountID: "acc", Name: "vs", Mode: "enforce"}},
+	}
+	_, _, _, err := manifest.Apply(context.Background(), s.st, m, 0, false)
+	c.Assert(err, ErrorMatches, "boom")
+
+	// the validation set is left alone
+	trackings, err := assertstate.ValidationSets(s.st)
+	c.Assert(err, IsNil)
+	c.Check(trackings, HasLen, 0)
+}
+
+func (s *manifestSuite) TestApplyRemovesHold(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+	s.setSnap("foo", snap.TypeApp, "latest/stable", snap.R(3), nil)
+	c.Assert(snapstate.HoldRefreshesBySystem(s.st, snapstate.HoldGeneral, "forever", []string{"foo"}), IsNil)
+
+	m := &manifest.Manifest{Snaps: []*manifest.Snap{{Name: "foo"}}}
+	plan, tss, _, err := manifest.Apply(context.Background(), s.st, m, 0, false)
+	c.Assert(err, IsNil)
+	c.Check(plan.Actions, DeepEquals, []string{`Remove the refresh hold of "foo"`})
+	c.Assert(tss, HasLen, 1)
+	t := tss[0].Tasks()[0]
+	c.Check(t.Kind(), Equals, "hold-refreshes")
+	c.Check(t.Summary(), Equals, `Remove the refresh hold of "foo"`)
+
+	// the hold is removed when the task runs
+	hold, err := snapstate.SystemHold(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(hold.IsZero(), Equals, false)
+
+	s.st.Unlock()
+	err = manifest.DoHoldRefreshes(t, nil)
+	s.st.Lock()
+	c.Assert(err, IsNil)
+	hold, err = snapstate.SystemHold(s.st, "foo")
+	c.Assert(err, IsNil)
+	c.Check(hold.IsZero(), Equals, true)
+}
+
+func (s *manifestSuite) TestApplyInvalid(c *C) {
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	for _, t := range []struct {
+		m   *manifest.Manifest
+		err string
+	}{
+		{&manifest.Manifest{Snaps: []*manifest.Snap{{Name: "Foo"}}}, `invalid manifest: invalid snap name: "Foo"`},
+		{&manifest.Manifest{Snaps: []*manifest.Snap{{Name: "foo"}, {Name: "foo"}}}, `invalid manifest: snap "foo" is listed more than once`},
+		{&manifest.Manifest{Snaps: []*manifest.Snap{{Name: "foo", Channel: "a/b/c/d"}}}, `invalid manifest: invalid channel of snap "foo": .*`},
+		{&manifest.Manifest{Snaps: []*manifest.Snap{{Name: "foo", Revision: "one"}}}, `invalid manifest: invalid revision of snap "foo": .*`},
+		{&manifest.Manifest{Snaps: []*manifest.Snap{{Name: "foo", Hold: "tomorrow"}}}, `invalid manifest: invalid hold of snap "foo": .*`},
+		{&manifest.Manifest{Connections: []*manifest.Connection{{Plug: "foo", Slot: "bar:slot"}}}, `invalid manifest: .*`},
+		{&manifest.Manifest{Quotas: []*manifest.Quota{{}}}, `invalid manifest: quota group without a name`},
+		{&manifest.Manifest{ValidationSets: []*manifest.ValidationSet{{AccountID: "acc", Name: "vs", Mode: "maybe"}}}, `invalid manifest: invalid mode "maybe" of validation set acc/vs`},
+	} {
+		_, _, _, err := manifest.Apply(context.Background(), s.st, t.m, 0, true)
+		c.Check(err, ErrorMatches, t.err)
+	}
+}
@@ -22,6 +22,7 @@
 package overlord
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"net/http"
@@ -35,9 +36,12 @@ import (
 	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/assertstate"
+	"github.com/snapcore/snapd/overlord/changehistory"
 	"github.com/snapcore/snapd/overlord/cmdstate"
 	"github.com/snapcore/snapd/overlord/confdbstate"
 	"github.com/snapcore/snapd/overlord/configstate"
@@ -47,6 +51,7 @@ import (
 	"github.com/snapcore/snapd/overlord/healthstate"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/manifest"
 	"github.com/snapcore/snapd/overlord/patch"
 	"github.com/snapcore/snapd/overlord/restart"
 	"github.com/snapcore/snapd/overlord/servicestate"
@@ -90,7 +95,8 @@ var pruneTickerC = func(t *time.Ticker) <-chan time.Time {
 type Overlord struct {
 	stateFLock *osutil.FileLock
 
-	stateEng *StateEngine
+	stateBackend *overlordStateBackend
+	stateEng     *StateEngine
 	// ensure loop
 	loopTomb    *tomb.Tomb
 	ensureLock  sync.Mutex
@@ -115,6 +121,8 @@ type Overlord struct {
 	cmdMgr     *cmdstate.CommandManager
 	shotMgr    *snapshotstate.SnapshotManager
 	fdeMgr     *fdestate.FDEManager
+	// changeHistory keeps the changes pruned from the state
+	changeHistory *changehistory.History
 	// proxyConf mediates the http proxy config
 	proxyConf func(req *http.Request) (*url.URL, error)
 }
@@ -129,9 +137,12 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 	}
 
 	backend := &overlordStateBackend{
-		path:         dirs.SnapStateFile,
-		ensureBefore: o.ensureBefore,
+		path:          dirs.SnapStateFile,
+		ensureBefore:  o.ensureBefore,
+		journaled:     features.StateJournal.IsEnabled(),
+		compactBefore: o.ensureBeforeIfRunning,
 	}
+	o.stateBackend = backend
 	s, restartMgr, err := o.loadState(backend, restartHandler)
 	if err != nil {
 		return nil, err
@@ -140,6 +151,17 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 	o.stateEng = NewStateEngine(s)
 	o.runner = state.NewTaskRunner(s)
 
+	o.changeHistory = changehistory.New(dirs.SnapChangeHistoryDir)
+	s.Lock()
+	s.AddChangePrunedHandler(o.archiveChange)
+	s.Unlock()
+
+	// like the state journal, enabling or disabling the collection of
+	// metrics on hot paths takes effect on the next start of snapd
+	metrics.SetEnabled(features.Metrics.IsEnabled())
+	metrics.NewGaugeFunc("snapd_tasks", "Number of tasks in the state, by kind and status.",
+		[]string{"kind", "status"}, o.countTasks)
+
 	// any unknown task should be ignored and succeed
 	matchAnyUnknownTask := func(_ *state.Task) bool {
 		return true
@@ -195,6 +217,7 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 		return nil, err
 	}
 	healthstate.Init(hookMgr)
+	manifest.Init(o.runner)
 
 	// the shared task runner should be added last!
 	o.stateEng.AddManager(o.runner)
@@ -211,6 +234,18 @@ func New(restartHandler restart.Handler) (*Overlord, error) {
 	return o, nil
 }
 
+func (o *Overlord) archiveChange(chg *state.Change) {
+	if err := o.changeHistory.Archive(chg); err != nil {
+		logger.Noticef("%v", err)
+	}
+}
+
+func (o *Overlord) flushChangeHistory() {
+	if err := o.changeHistory.Flush(); err != nil {
+		logger.Noticef("%v", err)
+	}
+}
+
 func (o *Overlord) addManager(mgr StateManager) {
 	switch x := mgr.(type) {
 	case *hookstate.HookManager:
@@ -274,7 +309,26 @@ func lockWithTimeout(l *osutil.FileLock, timeout time.Duration) error {
 	}
 }
 
-func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handler) (*state.State, *restart.RestartManager, error) {
+// countTasks sets the number of tasks in the state for each kind and
+// status of task.
+func (o *Overlord) countTasks(set func(v float64, labelValues ...string)) {
+	type kindStatus struct {
+		kind   string
+		status state.Status
+	}
+	counts := make(map[kindStatus]int)
+	st := o.State()
+	st.Lock()
+	for _, t := range st.Tasks() {
+		counts[kindStatus{t.Kind(), t.Status()}]++
+	}
+	st.Unlock()
+	for ks, n := range counts {
+		set(float64(n), ks.kind, ks.status.String())
+	}
+}
+
+func (o *Overlord) loadState(backend *overlordStateBackend, restartHandler restart.Handler) (*state.State, *restart.RestartManager, error) {
 	flock, err := initStateFileLock()
 	if err != nil {
 		return nil, nil, fmt.Errorf("fatal: error opening lock file: %v", err)
@@ -311,15 +365,15 @@ func (o *Overlord) loadState(backend state.Backend, restartHandler restart.Handl
 		return s, restartMgr, nil
 	}
 
-	r, err := os.Open(dirs.SnapStateFile)
-	if err != nil {
-		return nil, nil, fmt.Errorf("cannot read the state file: %s", err)
-	}
-	defer r.Close()
-
 	var s *state.State
+	var data []byte
 	timings.Run(perfTimings, "read-state", "read snapd state from disk", func(tm timings.Measurer) {
-		s, err = state.ReadState(backend, r)
+		data, err = backend.load()
+		if err != nil {
+			err = fmt.Errorf("cannot read the state file: %s", err)
+			return
+		}
+		s, err = state.ReadState(backend, bytes.NewReader(data))
 	})
 	if err != nil {
 		return nil, nil, err
@@ -435,6 +489,21 @@ func (o *Overlord) ensureBefore(d time.Duration) {
 	if o.ensureTimer == nil {
 		panic("cannot use EnsureBefore before Overlord.Loop")
 	}
+	o.ensureBeforeLocked(d)
+}
+
+// ensureBeforeIfRunning is like ensureBefore, but does nothing if the
+// ensure loop is not running yet.
+func (o *Overlord) ensureBeforeIfRunning(d time.Duration) {
+	o.ensureLock.Lock()
+	defer o.ensureLock.Unlock()
+	if o.ensureTimer == nil {
+		return
+	}
+	o.ensureBeforeLocked(d)
+}
+
+func (o *Overlord) ensureBeforeLocked(d time.Duration) {
 	now := time.Now()
 	next := now.Add(d)
 	if next.Before(o.ensureNext) {
@@ -485,6 +554,7 @@ func (o *Overlord) Loop() {
 				preseedExitWithError(err)
 			}
 			o.ensureDidRun()
+			o.compactStateJournal()
 			pruneC := pruneTickerC(o.pruneTicker)
 			select {
 			case <-o.loopTomb.Dying():
@@ -500,11 +570,29 @@ func (o *Overlord) Loop() {
 				st.Lock()
 				st.Prune(o.startOfOperationTime, pruneWait, abortWait, pruneMaxChanges)
 				st.Unlock()
+				// the pruned changes are written out without
+				// holding the state lock
+				o.flushChangeHistory()
 			}
 		}
 	})
 }
 
+// compactStateJournal writes the state whole once it has been kept in the
+// journal for too long, so that the state file on disk does not get too
+// far behind.
+func (o *Overlord) compactStateJournal() {
+	if o.stateBackend == nil {
+		return
+	}
+	st := o.State()
+	st.Lock()
+	defer st.Unlock()
+	if err := o.stateBackend.compactIfStale(); err != nil {
+		logger.Noticef("cannot write the state whole: %v", err)
+	}
+}
+
 func (o *Overlord) ensureDidRun() {
 	atomic.StoreInt32(&o.ensureRun, 1)
 }
@@ -522,6 +610,16 @@ func (o *Overlord) Stop() error {
 		err = o.loopTomb.Wait()
 	}
 	o.stateEng.Stop()
+	o.flushChangeHistory()
+	if o.stateBackend != nil {
+		// leave the state whole on disk for whatever reads it next
+		st := o.State()
+		st.Lock()
+		if cerr := o.stateBackend.Close(); cerr != nil {
+			logger.Noticef("cannot write the state whole: %v", cerr)
+		}
+		st.Unlock()
+	}
 	if o.stateFLock != nil {
 		// This will also unlock the file
 		o.stateFLock.Close()
@@ -637,6 +735,11 @@ func (o *Overlord) TaskRunner() *state.TaskRunner {
 	return o.runner
 }
 
+// ChangeHistory returns the history of the changes pruned from the state.
+func (o *Overlord) ChangeHistory() *changehistory.History {
+	return o.changeHistory
+}
+
 // RestartManager returns the manager responsible for restart state.
 func (o *Overlord) RestartManager() *restart.RestartManager {
 	return o.restartMgr
@@ -712,6 +815,7 @@ func MockWithState(s *state.State) *Overlord {
 	}
 	o.stateEng = NewStateEngine(s)
 	o.runner = state.NewTaskRunner(s)
+	o.changeHistory = changehistory.New(dirs.SnapChangeHistoryDir)
 
 	return o
 }
@@ -39,6 +39,7 @@ import (
 	"github.com/snapcore/snapd/boot"
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/dirs/dirstest"
+	"github.com/snapcore/snapd/features"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/devicestate/devicestatetest"
@@ -633,6 +634,37 @@ func (ovs *overlordSuite) TestEnsureLoopPrune(c *C) {
 	c.Assert(t1.Status(), Equals, state.HoldStatus)
 }
 
+func (ovs *overlordSuite) TestPruneArchivesChanges(c *C) {
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	st := o.State()
+	st.Lock()
+	chg := st.NewChange("prune", "...")
+	t := st.NewTask("foo", "...")
+	chg.AddTask(t)
+	t.SetStatus(state.DoneStatus)
+	st.Prune(time.Now(), 0, 0, 0)
+	c.Check(st.Change(chg.ID()), IsNil)
+	st.Unlock()
+
+	entries, err := o.ChangeHistory().Entries(nil)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0].ID, Equals, chg.ID())
+	c.Check(entries[0].Kind, Equals, "prune")
+	c.Check(entries[0].Status, Equals, "Done")
+	// nothing is written while the state is locked by Prune
+	c.Check(filepath.Join(dirs.SnapChangeHistoryDir, "changes.json"), testutil.FileAbsent)
+
+	c.Assert(o.Stop(), IsNil)
+	c.Check(filepath.Join(dirs.SnapChangeHistoryDir, "changes.json"), testutil.FilePresent)
+	entries, err = o.ChangeHistory().Entries(nil)
+	c.Assert(err, IsNil)
+	c.Assert(entries, HasLen, 1)
+	c.Check(entries[0].ID, Equals, chg.ID())
+}
+
 func (ovs *overlordSuite) TestEnsureLoopPruneRunsMultipleTimes(c *C) {
 	restoreIntv := overlord.MockPruneInterval(100*time.Millisecond, 5*time.Millisecond, 1*time.Hour)
 	defer restoreIntv()
@@ -886,6 +918,61 @@ func (ovs *overlordSuite) TestCheckpoint(c *C) {
 	c.Check(dirs.SnapStateFile, testutil.FileContains, `"mark":1`)
 }
 
+func (ovs *overlordSuite) TestCheckpointJournaled(c *C) {
+	c.Assert(os.MkdirAll(dirs.FeaturesDir, 0755), IsNil)
+	c.Assert(os.WriteFile(features.StateJournal.ControlFile(), nil, 0644), IsNil)
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+
+	s := o.State()
+	s.Lock()
+	s.Set("mark", 1)
+	s.Unlock()
+
+	// only in the journal
+	c.Check(dirs.SnapStateFile, Not(testutil.FileContains), `"mark"`)
+	c.Check(state.JournalPath(dirs.SnapStateFile), testutil.FileContains, `"key":"mark","value":1`)
+
+	// the state is whole once stopped
+	c.Assert(o.Stop(), IsNil)
+	c.Check(dirs.SnapStateFile, testutil.FileContains, `"mark":1`)
+	c.Check(state.JournalPath(dirs.SnapStateFile), testutil.FileAbsent)
+}
+
+func (ovs *overlordSuite) TestEnsureLoopCompactsStateJournal(c *C) {
+	c.Assert(os.MkdirAll(dirs.FeaturesDir, 0755), IsNil)
+	c.Assert(os.WriteFile(features.StateJournal.ControlFile(), nil, 0644), IsNil)
+	restore := overlord.MockStateJournalMaxAge(50 * time.Millisecond)
+	defer restore()
+
+	o, err := overlord.New(nil)
+	c.Assert(err, IsNil)
+	c.Assert(o.StartUp(), IsNil)
+
+	s := o.State()
+	s.Lock()
+	s.Set("mark", 1)
+	s.Unlock()
+	c.Check(dirs.SnapStateFile, Not(testutil.FileContains), `"mark"`)
+
+	o.Loop()
+	defer o.Stop()
+
+	// the ensure loop writes the state whole once the change is old
+	// enough, without waiting for the regular ensure interval
+	for i := 0; ; i++ {
+		data, err := os.ReadFile(dirs.SnapStateFile)
+		c.Assert(err, IsNil)
+		if strings.Contains(string(data), `"mark":1`) {
+			break
+		}
+		c.Assert(i < 200, Equals, true, Commentf("state not written whole in time"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Check(state.JournalPath(dirs.SnapStateFile), testutil.FilePresent)
+}
+
 type sampleManager struct {
 	ensureCallback func()
 }
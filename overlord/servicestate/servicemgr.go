@@ -56,11 +56,12 @@ func Manager(st *state.State, runner *state.TaskRunner) *ServiceManager {
 	m := &ServiceManager{
 		state: st,
 	}
+	mgrRunner := runner.ForManager("servicestate")
 	// TODO: undo handler
-	runner.AddHandler("service-control", m.doServiceControl, nil)
+	mgrRunner.AddHandler("service-control", m.doServiceControl, nil)
 
 	// TODO: undo handler
-	runner.AddHandler("quota-control", m.doQuotaControl, nil)
+	mgrRunner.AddHandler("quota-control", m.doQuotaControl, nil)
 	RegisterAffectedQuotasByKind("quota-control", affectedQuotasForQuotaControl)
 	snapstate.RegisterAffectedSnapsByKind("quota-control", affectedSnapsForQuotaControl)
 
@@ -68,7 +69,7 @@ func Manager(st *state.State, runner *state.TaskRunner) *ServiceManager {
 	// so this task encapsulate taking care of calling quotaUpdate
 	// with the correct setup. This task also supports proper handling of
 	// failure during install and correctly removes the snap again.
-	runner.AddHandler("quota-add-snap", m.doQuotaAddSnap, m.undoQuotaAddSnap)
+	mgrRunner.AddHandler("quota-add-snap", m.doQuotaAddSnap, m.undoQuotaAddSnap)
 	RegisterAffectedQuotasByKind("quota-add-snap", affectedQuotasForQuotaAddSnap)
 	// quota-add-snap uses snap-setup and because of this retrieving the snap
 	// that is being added is implicitly already supported by snapstate/conflict.go
@@ -28,6 +28,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path"
@@ -44,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snapdenv"
 	"github.com/snapcore/snapd/strutil"
@@ -53,9 +55,14 @@ const (
 	archiveName  = "archive.tgz"
 	metadataName = "meta.json"
 	metaHashName = "meta.sha3_384"
+	metaMACName  = "meta.mac"
 
 	userArchivePrefix = "user/"
 	userArchiveSuffix = ".tgz"
+
+	// chunked archives are plain tar streams
+	chunkedArchiveName   = "archive.tar"
+	chunkedArchiveSuffix = ".tar"
 )
 
 var (
@@ -301,8 +308,13 @@ func EstimateSnapshotSize(si *snap.Info, usernames []string, dirOpts *dirs.SnapD
 	return total, nil
 }
 
-// Save a snapshot
-func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, dynSnapshotOpts *snap.SnapshotOptions, dirOpts *dirs.SnapDirOptions) (*client.Snapshot, error) {
+// Save a snapshot.
+//
+// If secret is not nil the archives and the configuration are encrypted
+// with a key derived from it, the metadata is authenticated with it, and
+// the archives are stored in the snapshot file itself instead of the
+// chunk store.
+func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, dynSnapshotOpts *snap.SnapshotOptions, dirOpts *dirs.SnapDirOptions, secret []byte) (*client.Snapshot, error) {
 	if err := os.MkdirAll(dirs.SnapshotsDir, 0700); err != nil {
 		return nil, err
 	}
@@ -335,6 +347,20 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 		}
 	}
 
+	var key *snapshotKey
+	if secret != nil {
+		snapshot.Encryption, key, err = newEncryption(secret)
+		if err != nil {
+			return nil, err
+		}
+		// the configuration is only kept encrypted
+		snapshot.Encryption.Conf, err = key.sealConf(snapshot.Conf)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Conf = nil
+	}
+
 	aw, err := osutil.NewAtomicFile(Filename(snapshot), 0600, 0, osutil.NoChown, osutil.NoChown)
 	if err != nil {
 		return nil, err
@@ -342,11 +368,22 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 	// if things worked, we'll commit (and Cancel becomes a NOP)
 	defer aw.Cancel()
 
+	// chunks added to the store must not be garbage collected before the
+	// snapshot referencing them is committed
+	chunkStoreLock.RLock()
+	defer chunkStoreLock.RUnlock()
+
+	idx := &chunkIndex{
+		Format:  chunkIndexFormat,
+		Entries: make(map[string][]chunkRef),
+	}
+
 	w := zip.NewWriter(aw)
 	defer w.Close() // note this does not close the file descriptor (that's done by hand on the atomic writer, above)
+	store := &archiveStore{idx: idx, zip: w, key: key}
 	savingUserData := false
 	baseDataDir := snap.BaseDataDir(si.InstanceName())
-	if err := addSnapDirToZip(ctx, snapshot, w, "root", archiveName, baseDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
+	if err := addSnapDirToArchive(ctx, snapshot, store, "root", store.systemEntry(), baseDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
 		return nil, err
 	}
 
@@ -358,7 +395,17 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 	savingUserData = true
 	for _, usr := range users {
 		snapDataDir := filepath.Dir(si.UserDataDir(usr.HomeDir, dirOpts))
-		if err := addSnapDirToZip(ctx, snapshot, w, usr.Username, userArchiveName(usr), snapDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
+		if err := addSnapDirToArchive(ctx, snapshot, store, usr.Username, store.userEntry(usr), snapDataDir, savingUserData, snapshotOptions.Exclude); err != nil {
+			return nil, err
+		}
+	}
+
+	if key == nil {
+		idxWriter, err := w.Create(chunkIndexName)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.NewEncoder(idxWriter).Encode(idx); err != nil {
 			return nil, err
 		}
 	}
@@ -368,8 +415,9 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 		return nil, err
 	}
 
+	var metaBuf bytes.Buffer
 	hasher := crypto.SHA3_384.New()
-	enc := json.NewEncoder(io.MultiWriter(metaWriter, hasher))
+	enc := json.NewEncoder(io.MultiWriter(metaWriter, hasher, &metaBuf))
 	if err := enc.Encode(snapshot); err != nil {
 		return nil, err
 	}
@@ -379,6 +427,14 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 		return nil, err
 	}
 	fmt.Fprintf(hashWriter, "%x\n", hasher.Sum(nil))
+
+	if key != nil {
+		macWriter, err := w.Create(metaMACName)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(macWriter, "%s\n", key.metaMAC(metaBuf.Bytes()))
+	}
 	if err := w.Close(); err != nil {
 		return nil, err
 	}
@@ -396,10 +452,10 @@ func Save(ctx context.Context, id uint64, si *snap.Info, cfg map[string]interfac
 
 var isTesting = snapdenv.Testing()
 
-// addSnapDirToZip adds the 'common' and the 'rev' revisioned dir under 'snapDir'
-// to the snapshot. If one doesn't exist, it's ignored. If none exists, the
-// operation is skipped.
-func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry, snapDir string, savingUserData bool, excludePaths []string) error {
+// addSnapDirToArchive adds the 'common' and the 'rev' revisioned dir under
+// 'snapDir' to the snapshot. If one doesn't exist, it's ignored. If none
+// exists, the operation is skipped.
+func addSnapDirToArchive(ctx context.Context, snapshot *client.Snapshot, store *archiveStore, username, entry, snapDir string, savingUserData bool, excludePaths []string) error {
 	paths, err := pathsForSnapshot(snapDir, snapshot)
 	if err != nil {
 		return err
@@ -442,24 +498,130 @@ func addSnapDirToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writ
 		expExcludePaths = append(expExcludePaths, expandedPath)
 	}
 
-	return addToZip(ctx, snapshot, w, username, entry, paths, expExcludePaths)
+	return addToArchive(ctx, snapshot, store, username, entry, paths, expExcludePaths)
+}
+
+// addToChunkStore adds 'paths' to the snapshot. tar will change into the paths'
+// parent directory before creating the archive so that parent dirs are not
+// added. The archive is not compressed so that unchanged data results in the
+// same chunks from one snapshot to the next; chunks are compressed
+// individually instead.
+// archiveStore is where the archives of a snapshot are written to: the
+// chunk store or, for encrypted snapshots, the snapshot file itself.
+type archiveStore struct {
+	idx *chunkIndex
+	zip *zip.Writer
+	key *snapshotKey
 }
 
-// addToZip adds 'paths' to the snapshot. tar will change into the paths' parent
-// directory before creating the archive so that parent dirs are not added.
-func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, username, entry string, paths []string, excludePaths []string) error {
-	archiveWriter, err := w.CreateHeader(&zip.FileHeader{Name: entry})
+// systemEntry returns the name of the entry for the system archive.
+func (s *archiveStore) systemEntry() string {
+	if s.key != nil {
+		return archiveName
+	}
+	return chunkedArchiveName
+}
+
+// userEntry returns the name of the entry for the archive of the given
+// user.
+func (s *archiveStore) userEntry(usr *user.User) string {
+	if s.key != nil {
+		return userArchiveName(usr)
+	}
+	return chunkedUserArchiveName(usr)
+}
+
+// archiveWriter writes the archive of a single entry.
+type archiveWriter interface {
+	io.Writer
+	// finish completes writing the archive, returning the size and the
+	// hash of its data as stored.
+	finish() (size int64, sha3_384 string, err error)
+}
+
+func (s *archiveStore) create(entry string) (archiveWriter, error) {
+	if s.key == nil {
+		cw := &chunkWriter{}
+		hasher := crypto.SHA3_384.New()
+		return &chunkedArchiveWriter{
+			Writer: io.MultiWriter(cw, hasher),
+			cw:     cw,
+			hasher: hasher,
+			idx:    s.idx,
+			entry:  entry,
+		}, nil
+	}
+
+	// the data is already compressed (and encrypted) so there is no
+	// point in deflating it
+	zw, err := s.zip.CreateHeader(&zip.FileHeader{Name: entry, Method: zip.Store})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	hasher := crypto.SHA3_384.New()
+	sz := &osutil.Sizer{}
+	ew, err := newEncryptWriter(io.MultiWriter(zw, hasher, sz), s.key, entry)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedArchiveWriter{encryptWriter: ew, hasher: hasher, sz: sz}, nil
+}
+
+type chunkedArchiveWriter struct {
+	io.Writer
+	cw     *chunkWriter
+	hasher hash.Hash
+	idx    *chunkIndex
+	entry  string
+}
+
+func (w *chunkedArchiveWriter) finish() (int64, string, error) {
+	if err := w.cw.Close(); err != nil {
+		return 0, "", err
+	}
+	w.idx.Entries[w.entry] = w.cw.refs
+	return w.cw.size, fmt.Sprintf("%x", w.hasher.Sum(nil)), nil
+}
+
+type encryptedArchiveWriter struct {
+	*encryptWriter
+	hasher hash.Hash
+	sz     *osutil.Sizer
+}
+
+func (w *encryptedArchiveWriter) finish() (int64, string, error) {
+	if err := w.Close(); err != nil {
+		return 0, "", err
+	}
+	return w.sz.Size(), fmt.Sprintf("%x", w.hasher.Sum(nil)), nil
+}
+
+// errWriter remembers the first error writing to its writer, as that is
+// more meaningful than tar dying because of it.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) Write(p []byte) (int, error) {
+	n, err := ew.w.Write(p)
+	if err != nil && ew.err == nil {
+		ew.err = err
 	}
+	return n, err
+}
 
+func addToArchive(ctx context.Context, snapshot *client.Snapshot, store *archiveStore, username, entry string, paths []string, excludePaths []string) error {
 	tarArgs := []string{
 		"--create",
-		"--sparse", "--gzip",
+		"--sparse",
 		"--format", "gnu",
 		"--anchored",
 		"--no-wildcards-match-slash",
 	}
+	if isCompressedArchive(entry) {
+		tarArgs = append(tarArgs, "--gzip")
+	}
 
 	for _, path := range excludePaths {
 		tarArgs = append(tarArgs, fmt.Sprintf("--exclude=%s", path))
@@ -471,11 +633,14 @@ func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, use
 		tarArgs = append(tarArgs, "--directory", parent, dir)
 	}
 
-	var sz osutil.Sizer
-	hasher := crypto.SHA3_384.New()
+	aw, err := store.create(entry)
+	if err != nil {
+		return err
+	}
+	out := &errWriter{w: aw}
 
 	cmd := tarAsUser(username, tarArgs...)
-	cmd.Stdout = io.MultiWriter(archiveWriter, hasher, &sz)
+	cmd.Stdout = out
 
 	// keep (at most) the last 5 non-empty lines of what 'tar' writes to stderr
 	// (those are the most likely contain the reason for fatal errors)
@@ -491,6 +656,9 @@ func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, use
 	}
 
 	if err := osutil.RunWithContext(ctx, cmd); err != nil {
+		if out.err != nil {
+			return out.err
+		}
 		matches, count := matchCounter.Matches()
 		if count > 0 {
 			note := ""
@@ -503,9 +671,13 @@ func addToZip(ctx context.Context, snapshot *client.Snapshot, w *zip.Writer, use
 		}
 		return fmt.Errorf("tar failed: %v", err)
 	}
+	size, sum, err := aw.finish()
+	if err != nil {
+		return err
+	}
 
-	snapshot.SHA3_384[entry] = fmt.Sprintf("%x", hasher.Sum(nil))
-	snapshot.Size += sz.Size()
+	snapshot.SHA3_384[entry] = sum
+	snapshot.Size += size
 
 	return nil
 }
@@ -751,6 +923,11 @@ func Import(ctx context.Context, id uint64, r io.Reader, flags *ImportFlags) (sn
 	// Cancel once Committed is a NOP
 	defer tr.Cancel()
 
+	// imported chunks must not be garbage collected before the snapshots
+	// referencing them are in place
+	chunkStoreLock.RLock()
+	defer chunkStoreLock.RUnlock()
+
 	// Unpack and validate the streamed data
 	//
 	// XXX: this will leak snapshot IDs, i.e. we allocate a new
@@ -855,6 +1032,14 @@ func unpackVerifySnapshotImport(ctx context.Context, r io.Reader, realSetID uint
 			return nil, fmt.Errorf("invalid filename in import file")
 		}
 
+		// chunks are exported ahead of the snapshots referencing them
+		if strings.HasPrefix(header.Name, chunkExportPrefix) {
+			if err := importChunk(tr, header.Name[len(chunkExportPrefix):]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		if header.Name == "content.json" {
 			var ej contentJSON
 			dec := json.NewDecoder(tr)
@@ -921,9 +1106,14 @@ type exportMetadata struct {
 	Files  []string  `json:"files"`
 }
 
+// chunkExportPrefix is the prefix of the name of chunks in the export tar.
+const chunkExportPrefix = "chunks/"
+
 type SnapshotExport struct {
 	// open snapshot files
 	snapshotFiles []*os.File
+	// open files of the chunks referenced by the snapshots
+	chunkFiles []*os.File
 
 	// contentHash of the full snapshot
 	contentHash []byte
@@ -938,7 +1128,7 @@ type SnapshotExport struct {
 // NewSnapshotExport will return a SnapshotExport structure. It must be
 // Close()ed after use to avoid leaking file descriptors.
 func NewSnapshotExport(ctx context.Context, setID uint64) (se *SnapshotExport, err error) {
-	var snapshotFiles []*os.File
+	var snapshotFiles, chunkFiles []*os.File
 	var snapshotSet client.SnapshotSet
 
 	defer func() {
@@ -947,9 +1137,19 @@ func NewSnapshotExport(ctx context.Context, setID uint64) (se *SnapshotExport, e
 			for _, f := range snapshotFiles {
 				f.Close()
 			}
+			for _, f := range chunkFiles {
+				f.Close()
+			}
 		}
 	}()
 
+	// keep the chunks around until they are opened; once open, the
+	// descriptors keep them readable even if they get removed
+	chunkStoreLock.RLock()
+	defer chunkStoreLock.RUnlock()
+
+	seenChunks := make(map[string]bool)
+
 	// Open all files first and keep the file descriptors
 	// open. The caller should have locked the state so that no
 	// delete/change snapshot operations can happen while the
@@ -972,6 +1172,23 @@ func NewSnapshotExport(ctx context.Context, setID uint64) (se *SnapshotExport, e
 				return fmt.Errorf("cannot open file from descriptor %d", fd)
 			}
 			snapshotFiles = append(snapshotFiles, f)
+
+			if reader.chunks == nil {
+				return nil
+			}
+			for _, refs := range reader.chunks.Entries {
+				for _, ref := range refs {
+					if seenChunks[ref.SHA3_384] {
+						continue
+					}
+					seenChunks[ref.SHA3_384] = true
+					cf, err := os.Open(chunkPath(reader.chunksDir, ref.SHA3_384))
+					if err != nil {
+						return fmt.Errorf("cannot open snapshot chunk: %v", err)
+					}
+					chunkFiles = append(chunkFiles, cf)
+				}
+			}
 		}
 		return nil
 	})
@@ -986,7 +1203,7 @@ func NewSnapshotExport(ctx context.Context, setID uint64) (se *SnapshotExport, e
 	if err != nil {
 		return nil, fmt.Errorf("cannot calculate content hash for snapshot export %v: %v", setID, err)
 	}
-	se = &SnapshotExport{snapshotFiles: snapshotFiles, setID: setID, contentHash: h}
+	se = &SnapshotExport{snapshotFiles: snapshotFiles, chunkFiles: chunkFiles, setID: setID, contentHash: h}
 
 	// ensure we never leak FDs even if the user does not call close
 	runtime.SetFinalizer(se, (*SnapshotExport).Close)
@@ -1023,6 +1240,39 @@ func (se *SnapshotExport) Close() {
 		f.Close()
 	}
 	se.snapshotFiles = nil
+	for _, f := range se.chunkFiles {
+		f.Close()
+	}
+	se.chunkFiles = nil
+}
+
+// streamFile writes the given file to the tar, naming it after its base
+// name with the given prefix. The resulting name is returned.
+func streamFile(tw *tar.Writer, f *os.File, prefix string) (name string, err error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !stat.Mode().IsRegular() {
+		// should never happen
+		return "", fmt.Errorf("unexported special file %q in snapshot: %s", stat.Name(), stat.Mode())
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return "", fmt.Errorf("cannot seek on %v: %v", stat.Name(), err)
+	}
+	hdr, err := tar.FileInfoHeader(stat, "")
+	if err != nil {
+		return "", fmt.Errorf("symlink: %v", stat.Name())
+	}
+	hdr.Name = prefix + hdr.Name
+	if err = tw.WriteHeader(hdr); err != nil {
+		return "", fmt.Errorf("cannot write header for %v: %v", stat.Name(), err)
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return "", fmt.Errorf("cannot write data for %v: %v", stat.Name(), err)
+	}
+
+	return path.Base(f.Name()), nil
 }
 
 type contentJSON struct {
@@ -1054,31 +1304,22 @@ func (se *SnapshotExport) StreamTo(w io.Writer) error {
 		return err
 	}
 
+	// write out the chunks first, so that on import they are in place by
+	// the time the snapshots referencing them are checked
+	for _, chunkFile := range se.chunkFiles {
+		if _, err := streamFile(tw, chunkFile, chunkExportPrefix); err != nil {
+			return err
+		}
+	}
+
 	// write out the individual snapshots
 	for _, snapshotFile := range se.snapshotFiles {
-		stat, err := snapshotFile.Stat()
+		name, err := streamFile(tw, snapshotFile, "")
 		if err != nil {
 			return err
 		}
-		if !stat.Mode().IsRegular() {
-			// should never happen
-			return fmt.Errorf("unexported special file %q in snapshot: %s", stat.Name(), stat.Mode())
-		}
-		if _, err := snapshotFile.Seek(0, 0); err != nil {
-			return fmt.Errorf("cannot seek on %v: %v", stat.Name(), err)
-		}
-		hdr, err := tar.FileInfoHeader(stat, "")
-		if err != nil {
-			return fmt.Errorf("symlink: %v", stat.Name())
-		}
-		if err = tw.WriteHeader(hdr); err != nil {
-			return fmt.Errorf("cannot write header for %v: %v", stat.Name(), err)
-		}
-		if _, err := io.Copy(tw, snapshotFile); err != nil {
-			return fmt.Errorf("cannot write data for %v: %v", stat.Name(), err)
-		}
 
-		files = append(files, path.Base(snapshotFile.Name()))
+		files = append(files, name)
 	}
 
 	// SnapshotExporter has se.Close() set as a finalizer, thus when the object
@@ -23,6 +23,7 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto"
 	"crypto/sha256"
@@ -30,6 +31,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
 	"os/exec"
 	"path"
@@ -49,6 +51,7 @@ import (
 	"github.com/snapcore/snapd/osutil/user"
 	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
 	"github.com/snapcore/snapd/testutil"
 )
 
@@ -450,13 +453,13 @@ func (s *snapshotSuite) TestIterSetIDoverride(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	cfg := map[string]interface{}{"some-setting": false}
 
-	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, uint64(12))
 
 	snapshotPath := filepath.Join(dirs.SnapshotsDir, "12_hello-snap_v1.33_42.zip")
 	c.Check(backend.Filename(shw), check.Equals, snapshotPath)
-	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz", "user/snapuser.tgz"})
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tar", "user/snapuser.tar"})
 
 	// rename the snapshot, verify that set id from the filename is used by the reader.
 	c.Assert(os.Rename(snapshotPath, filepath.Join(dirs.SnapshotsDir, "33_hello.zip")), check.IsNil)
@@ -555,7 +558,7 @@ func (s *snapshotSuite) TestList(c *check.C) {
 	}
 }
 
-func (s *snapshotSuite) TestAddDirToZipBails(c *check.C) {
+func (s *snapshotSuite) TestAddDirToChunkStoreBails(c *check.C) {
 	snapshot := &client.Snapshot{SetID: 42, Snap: "a-snap", Revision: snap.R(5)}
 
 	oldVal := os.Getenv("SNAPD_DEBUG")
@@ -567,13 +570,13 @@ func (s *snapshotSuite) TestAddDirToZipBails(c *check.C) {
 	buf, restore := logger.MockLogger()
 	defer restore()
 	savingUserData := false
-	// note as the zip is nil this would panic if it didn't bail
-	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, "", "an/entry", filepath.Join(s.root, "nonexistent"), savingUserData, nil), check.IsNil)
-	c.Check(backend.AddSnapDirToZip(nil, snapshot, nil, "", "an/entry", "/etc/passwd", savingUserData, nil), check.IsNil)
+	// note as the index is nil this would panic if it didn't bail
+	c.Check(backend.AddSnapDirToChunkStore(nil, snapshot, nil, "", "an/entry", filepath.Join(s.root, "nonexistent"), savingUserData, nil), check.IsNil)
+	c.Check(backend.AddSnapDirToChunkStore(nil, snapshot, nil, "", "an/entry", "/etc/passwd", savingUserData, nil), check.IsNil)
 	c.Check(buf.String(), check.Matches, "(?m).* is does not exist.*")
 }
 
-func (s *snapshotSuite) TestAddDirToZipTarFails(c *check.C) {
+func (s *snapshotSuite) TestAddDirToChunkStoreTarFails(c *check.C) {
 	rev := snap.R(5)
 	d := filepath.Join(s.root, rev.String())
 	c.Assert(os.MkdirAll(filepath.Join(d, "bar"), 0755), check.IsNil)
@@ -582,50 +585,87 @@ func (s *snapshotSuite) TestAddDirToZipTarFails(c *check.C) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	var buf bytes.Buffer
-	z := zip.NewWriter(&buf)
 	savingUserData := false
-	c.Assert(backend.AddSnapDirToZip(ctx, &client.Snapshot{Revision: rev}, z, "", "an/entry", s.root, savingUserData, nil), check.ErrorMatches, ".* context canceled")
+	c.Assert(backend.AddSnapDirToChunkStore(ctx, &client.Snapshot{Revision: rev}, backend.NewChunkIndex(), "", "an/entry", s.root, savingUserData, nil), check.ErrorMatches, ".* context canceled")
 }
 
-func (s *snapshotSuite) TestAddDirToZip(c *check.C) {
+func (s *snapshotSuite) TestAddDirToChunkStore(c *check.C) {
 	rev := snap.R(5)
 	d := filepath.Join(s.root, rev.String())
 	c.Assert(os.MkdirAll(filepath.Join(d, "bar"), 0755), check.IsNil)
 	c.Assert(os.MkdirAll(filepath.Join(s.root, "common"), 0755), check.IsNil)
 	c.Assert(os.WriteFile(filepath.Join(d, "bar", "baz"), []byte("hello\n"), 0644), check.IsNil)
 
-	var buf bytes.Buffer
-	z := zip.NewWriter(&buf)
+	idx := backend.NewChunkIndex()
 	snapshot := &client.Snapshot{
 		SHA3_384: map[string]string{},
 		Revision: rev,
 	}
 	savingUserData := false
-	c.Assert(backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, savingUserData, nil), check.IsNil)
-	z.Close() // write out the central directory
+	c.Assert(backend.AddSnapDirToChunkStore(context.Background(), snapshot, idx, "", "an/entry", s.root, savingUserData, nil), check.IsNil)
 
 	c.Check(snapshot.SHA3_384, check.HasLen, 1)
 	c.Check(snapshot.SHA3_384["an/entry"], check.HasLen, 96)
 	c.Check(snapshot.Size > 0, check.Equals, true) // actual size most likely system-dependent
-	br := bytes.NewReader(buf.Bytes())
-	r, err := zip.NewReader(br, int64(br.Len()))
-	c.Assert(err, check.IsNil)
-	c.Check(r.File, check.HasLen, 1)
-	c.Check(r.File[0].Name, check.Equals, "an/entry")
+	c.Check(idx.Entries, check.HasLen, 1)
+	chunks := idx.Chunks("an/entry")
+	// the archive is tiny, so it fits in a single chunk
+	c.Assert(chunks, check.HasLen, 1)
+	c.Check(backend.ChunkFilename(chunks[0]), testutil.FilePresent)
+}
+
+func (s *snapshotSuite) TestAddDirToChunkStoreSplitsAndDedups(c *check.C) {
+	defer backend.MockChunkSizes(1024, 8*1024, 1<<9-1)()
+
+	rev := snap.R(5)
+	d := filepath.Join(s.root, rev.String())
+	c.Assert(os.MkdirAll(d, 0755), check.IsNil)
+	data := make([]byte, 256*1024)
+	rand.New(rand.NewSource(42)).Read(data)
+	c.Assert(os.WriteFile(filepath.Join(d, "big"), data, 0644), check.IsNil)
+	// keep the timestamp fixed so only the data changes
+	stamp := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.Assert(os.Chtimes(filepath.Join(d, "big"), stamp, stamp), check.IsNil)
+
+	save := func() *backend.ChunkIndex {
+		idx := backend.NewChunkIndex()
+		snapshot := &client.Snapshot{
+			SHA3_384: map[string]string{},
+			Revision: rev,
+		}
+		c.Assert(backend.AddSnapDirToChunkStore(context.Background(), snapshot, idx, "", "an/entry", s.root, false, nil), check.IsNil)
+		return idx
+	}
+
+	first := save().Chunks("an/entry")
+	c.Check(len(first) > 10, check.Equals, true, check.Commentf("%d chunks", len(first)))
+
+	// change some data in the middle of the file
+	copy(data[128*1024:], "this is different")
+	c.Assert(os.WriteFile(filepath.Join(d, "big"), data, 0644), check.IsNil)
+	c.Assert(os.Chtimes(filepath.Join(d, "big"), stamp, stamp), check.IsNil)
+
+	second := save().Chunks("an/entry")
+	common := 0
+	for _, h := range second {
+		if strutil.ListContains(first, h) {
+			common++
+		}
+	}
+	// only the chunks around the change are new
+	c.Check(len(second)-common > 0, check.Equals, true)
+	c.Check(len(second)-common <= 2, check.Equals, true, check.Commentf("%d of %d chunks changed", len(second)-common, len(second)))
 }
 
-func (s *snapshotSuite) TestAddDirToZipExclusions(c *check.C) {
+func (s *snapshotSuite) TestAddDirToChunkStoreExclusions(c *check.C) {
 	d := filepath.Join(s.root, "x1")
 	c.Assert(os.MkdirAll(d, 0755), check.IsNil)
 
-	var buf bytes.Buffer
-	z := zip.NewWriter(&buf)
+	idx := backend.NewChunkIndex()
 	snapshot := &client.Snapshot{
 		SHA3_384: map[string]string{},
 		Revision: snap.R("x1"),
 	}
-	defer z.Close()
 
 	var tarArgs []string
 	restore := backend.MockTarAsUser(func(username string, args ...string) *exec.Cmd {
@@ -680,7 +720,7 @@ func (s *snapshotSuite) TestAddDirToZipExclusions(c *check.C) {
 	} {
 		testLabel := check.Commentf("%s/%v", testData.excludes, testData.savingUserData)
 
-		err := backend.AddSnapDirToZip(context.Background(), snapshot, z, "", "an/entry", s.root, testData.savingUserData, testData.excludes)
+		err := backend.AddSnapDirToChunkStore(context.Background(), snapshot, idx, "", "an/entry", s.root, testData.savingUserData, testData.excludes)
 		c.Check(err, check.ErrorMatches, "tar failed.*")
 		c.Check(tarArgs, check.DeepEquals, testData.expectedArgs, testLabel)
 	}
@@ -732,7 +772,7 @@ func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
 		return statSnapshotOpts, nil
 	})()
 
-	shw, err := backend.Save(context.TODO(), shID, info, cfg, []string{"snapuser"}, dynSnapshotOpts, nil)
+	shw, err := backend.Save(context.TODO(), shID, info, cfg, []string{"snapuser"}, dynSnapshotOpts, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, shID)
 	c.Check(shw.Snap, check.Equals, info.InstanceName())
@@ -744,7 +784,7 @@ func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
 	c.Check(shw.Auto, check.Equals, false)
 	c.Check(shw.Options, check.DeepEquals, dynSnapshotOpts)
 	c.Check(backend.Filename(shw), check.Equals, filepath.Join(dirs.SnapshotsDir, "12_hello-snap_v1.33_42.zip"))
-	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz", "user/snapuser.tgz"})
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tar", "user/snapuser.tar"})
 	c.Check(statSnapshotOpts.Exclude, check.DeepEquals, mergedExcludes)
 	c.Check(readSnapshotYamlCalled, check.Equals, 1)
 
@@ -778,7 +818,7 @@ func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
 	dirs.SetRootDir(newroot)
 
 	var diff = func() *exec.Cmd {
-		cmd := exec.Command("diff", "-urN", "-x*.zip", s.root, newroot)
+		cmd := exec.Command("diff", "-urN", "-x*.zip", "-xchunks", s.root, newroot)
 		// cmd.Stdout = os.Stdout
 		// cmd.Stderr = os.Stderr
 		return cmd
@@ -790,7 +830,7 @@ func (s *snapshotSuite) testHappyRoundtrip(c *check.C, marker string) {
 		c.Check(diff().Run(), check.NotNil, comm)
 
 		// restore leaves things like they were (again and again)
-		rs, err := shr.Restore(context.TODO(), snap.R(0), nil, logger.Debugf, nil)
+		rs, err := shr.Restore(context.TODO(), snap.R(0), nil, nil, logger.Debugf, nil)
 		c.Assert(err, check.IsNil, comm)
 		rs.Cleanup()
 		c.Check(diff().Run(), check.IsNil, comm)
@@ -810,12 +850,12 @@ func (s *snapshotSuite) TestOpenSetIDoverride(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	cfg := map[string]interface{}{"some-setting": false}
 
-	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, uint64(12))
 
 	c.Check(backend.Filename(shw), check.Equals, filepath.Join(dirs.SnapshotsDir, "12_hello-snap_v1.33_42.zip"))
-	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz", "user/snapuser.tgz"})
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tar", "user/snapuser.tar"})
 
 	shr, err := backend.Open(backend.Filename(shw), 99)
 	c.Assert(err, check.IsNil)
@@ -834,7 +874,7 @@ func (s *snapshotSuite) TestRestoreRoundtripDifferentRevision(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	shID := uint64(12)
 
-	shw, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.Revision, check.Equals, info.Revision)
 
@@ -858,7 +898,7 @@ func (s *snapshotSuite) TestRestoreRoundtripDifferentRevision(c *check.C) {
 	dirs.SetRootDir(newroot)
 
 	var diff = func() *exec.Cmd {
-		cmd := exec.Command("diff", "-urN", "-x*.zip", s.root, newroot)
+		cmd := exec.Command("diff", "-urN", "-x*.zip", "-xchunks", s.root, newroot)
 		// cmd.Stdout = os.Stdout
 		// cmd.Stderr = os.Stderr
 		return cmd
@@ -868,7 +908,7 @@ func (s *snapshotSuite) TestRestoreRoundtripDifferentRevision(c *check.C) {
 	c.Check(diff().Run(), check.NotNil)
 
 	// restore leaves things like they were, but in the new dir
-	rs, err := shr.Restore(context.TODO(), snap.R("17"), nil, logger.Debugf, nil)
+	rs, err := shr.Restore(context.TODO(), snap.R("17"), nil, nil, logger.Debugf, nil)
 	c.Assert(err, check.IsNil)
 	rs.Cleanup()
 	c.Check(diff().Run(), check.IsNil)
@@ -1109,7 +1149,7 @@ func (s *snapshotSuite) TestImportDuplicated(c *check.C) {
 	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33", Epoch: epoch}
 	shID := uint64(12)
 
-	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
 	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
@@ -1139,12 +1179,12 @@ func (s *snapshotSuite) TestImportExportRoundtrip(c *check.C) {
 	cfg := map[string]interface{}{"some-setting": false}
 	shID := uint64(12)
 
-	shw, err := backend.Save(ctx, shID, info, cfg, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(ctx, shID, info, cfg, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(shw.SetID, check.Equals, shID)
 
 	c.Check(backend.Filename(shw), check.Equals, filepath.Join(dirs.SnapshotsDir, "12_hello-snap_v1.33_42.zip"))
-	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz", "user/snapuser.tgz"})
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tar", "user/snapuser.tar"})
 
 	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
 	c.Assert(err, check.IsNil)
@@ -1155,8 +1195,9 @@ func (s *snapshotSuite) TestImportExportRoundtrip(c *check.C) {
 	c.Assert(export.StreamTo(buf), check.IsNil)
 	c.Check(buf.Len(), check.Equals, int(export.Size()))
 
-	// now import it
+	// now import it; the chunks come along with the export
 	c.Assert(os.Remove(filepath.Join(dirs.SnapshotsDir, "12_hello-snap_v1.33_42.zip")), check.IsNil)
+	c.Assert(os.RemoveAll(filepath.Join(dirs.SnapshotsDir, "chunks")), check.IsNil)
 
 	names, err := backend.Import(ctx, 123, buf, nil)
 	c.Assert(err, check.IsNil)
@@ -1173,6 +1214,582 @@ func (s *snapshotSuite) TestImportExportRoundtrip(c *check.C) {
 	c.Check(rdr.SetID, check.Equals, uint64(123))
 	c.Check(rdr.Snap, check.Equals, "hello-snap")
 	c.Check(rdr.IsValid(), check.Equals, true)
+	c.Check(rdr.Check(ctx, nil), check.IsNil)
+}
+
+func (s *snapshotSuite) TestImportTamperedChunk(c *check.C) {
+	ctx := context.TODO()
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
+	c.Assert(err, check.IsNil)
+	c.Assert(export.Init(), check.IsNil)
+	buf := bytes.NewBuffer(nil)
+	c.Assert(export.StreamTo(buf), check.IsNil)
+
+	// replace the content of the first chunk with something else
+	var tampered bytes.Buffer
+	tr := tar.NewReader(buf)
+	tw := tar.NewWriter(&tampered)
+	replaced := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.IsNil)
+		data, err := io.ReadAll(tr)
+		c.Assert(err, check.IsNil)
+		if strings.HasPrefix(hdr.Name, "chunks/") && !replaced {
+			var gzBuf bytes.Buffer
+			gz := gzip.NewWriter(&gzBuf)
+			gz.Write([]byte("evil data"))
+			gz.Close()
+			data = gzBuf.Bytes()
+			hdr.Size = int64(len(data))
+			replaced = true
+		}
+		c.Assert(tw.WriteHeader(hdr), check.IsNil)
+		_, err = tw.Write(data)
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(tw.Close(), check.IsNil)
+	c.Assert(replaced, check.Equals, true)
+
+	_, err = backend.Import(ctx, 123, &tampered, &backend.ImportFlags{NoDuplicatedImportCheck: true})
+	c.Assert(err, check.ErrorMatches, `cannot import snapshot 123: chunk [0-9a-f]{7}… does not match its content \([0-9a-f]{7}…\)`)
+	c.Check(filepath.Join(dirs.SnapshotsDir, "123_hello-snap_v1.33_42.zip"), testutil.FileAbsent)
+}
+
+func (s *snapshotSuite) TestEncryptedRoundtrip(c *check.C) {
+	if os.Geteuid() == 0 {
+		c.Skip("this test cannot run as root (runuser will fail)")
+	}
+	logger.SimpleSetup(nil)
+	defer backend.MockArgon2Params(1, 64, 1)()
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	c.Check(hashkeys(shw), check.DeepEquals, []string{"archive.tgz", "user/snapuser.tgz"})
+	c.Assert(shw.Encryption, check.NotNil)
+	c.Check(shw.Encryption.Cipher, check.Equals, "aes-256-gcm-stream")
+	c.Check(shw.Encryption.KDF, check.Equals, "argon2id")
+	c.Check(shw.Encryption.Salt, check.HasLen, 16)
+	// nothing went into the chunk store
+	c.Check(filepath.Join(dirs.SnapshotsDir, "chunks"), testutil.FileAbsent)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Encryption, check.DeepEquals, shw.Encryption)
+
+	// checking needs no key
+	c.Check(shr.Check(context.TODO(), nil), check.IsNil)
+
+	newroot := c.MkDir()
+	c.Assert(os.MkdirAll(filepath.Join(newroot, "home/snapuser"), 0755), check.IsNil)
+	dirs.SetRootDir(newroot)
+
+	// restoring does
+	_, err = shr.Restore(context.TODO(), snap.R(0), nil, nil, logger.Debugf, nil)
+	c.Check(err, check.ErrorMatches, `cannot restore encrypted snapshot ".*/12_hello-snap_v1.33_42.zip" without its key`)
+	c.Check(shr.Unlock([]byte("secret")), check.Equals, backend.ErrWrongKey)
+	c.Assert(shr.Unlock([]byte("s3cr3t")), check.IsNil)
+
+	rs, err := shr.Restore(context.TODO(), snap.R(0), nil, nil, logger.Debugf, nil)
+	c.Assert(err, check.IsNil)
+	rs.Cleanup()
+	c.Check(exec.Command("diff", "-urN", "-x*.zip", s.root, newroot).Run(), check.IsNil)
+}
+
+func filePaths(files []client.SnapshotFile) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.User + ":" + f.Path
+	}
+	return paths
+}
+
+func (s *snapshotSuite) TestFiles(c *check.C) {
+	if os.Geteuid() == 0 {
+		c.Skip("this test cannot run as root (runuser will fail)")
+	}
+	logger.SimpleSetup(nil)
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+
+	files, err := shr.Files(context.TODO(), nil)
+	c.Assert(err, check.IsNil)
+	c.Check(filePaths(files), check.DeepEquals, []string{
+		":42", ":42/foo", ":common", ":common/bar",
+		"snapuser:42", "snapuser:42/ufoo", "snapuser:common", "snapuser:common/ubar",
+	})
+	for _, f := range files {
+		switch f.Path {
+		case "common/bar":
+			c.Check(f.Size, check.Equals, int64(len("common system canary\n")))
+			c.Check(f.Mode, check.Equals, os.FileMode(0644))
+		case "42":
+			c.Check(f.Mode, check.Equals, os.ModeDir|0755)
+		}
+	}
+
+	files, err = shr.Files(context.TODO(), []string{"someone-else"})
+	c.Assert(err, check.IsNil)
+	c.Check(filePaths(files), check.DeepEquals, []string{":42", ":42/foo", ":common", ":common/bar"})
+
+	// only hashing gives hashes, and only of regular files
+	for _, f := range files {
+		c.Check(f.SHA3_384, check.Equals, "")
+	}
+	hasher := crypto.SHA3_384.New()
+	hasher.Write([]byte("versioned system canary\n"))
+	files, err = shr.HashedFiles(context.TODO(), []string{"someone-else"})
+	c.Assert(err, check.IsNil)
+	c.Assert(files, check.HasLen, 4)
+	c.Check(files[0].SHA3_384, check.Equals, "")
+	c.Check(files[1].Path, check.Equals, "42/foo")
+	c.Check(files[1].SHA3_384, check.Equals, fmt.Sprintf("%x", hasher.Sum(nil)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = shr.Files(ctx, nil)
+	c.Check(err, check.Equals, context.Canceled)
+}
+
+func (s *snapshotSuite) TestFilesEncrypted(c *check.C) {
+	if os.Geteuid() == 0 {
+		c.Skip("this test cannot run as root (runuser will fail)")
+	}
+	logger.SimpleSetup(nil)
+	defer backend.MockArgon2Params(1, 64, 1)()
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+
+	_, err = shr.Files(context.TODO(), nil)
+	c.Check(err, check.ErrorMatches, `cannot list files of encrypted snapshot ".*/12_hello-snap_v1.33_42.zip" without its key`)
+
+	c.Assert(shr.Unlock([]byte("s3cr3t")), check.IsNil)
+	files, err := shr.Files(context.TODO(), []string{"snapuser"})
+	c.Assert(err, check.IsNil)
+	c.Check(filePaths(files), check.DeepEquals, []string{
+		":42", ":42/foo", ":common", ":common/bar",
+		"snapuser:42", "snapuser:42/ufoo", "snapuser:common", "snapuser:common/ubar",
+	})
+}
+
+func (s *snapshotSuite) TestValidateRestorePath(c *check.C) {
+	for _, path := range []string{"common", "common/foo/bar", "42", "42/foo", "x1/foo"} {
+		c.Check(backend.ValidateRestorePath(path), check.IsNil, check.Commentf("%q", path))
+	}
+	for _, path := range []string{"", "/common/foo", "common/", "./common", "common/../42", "..", "../foo"} {
+		c.Check(backend.ValidateRestorePath(path), check.ErrorMatches, `invalid path ".*": must be a clean path relative to the snap data directory`, check.Commentf("%q", path))
+	}
+	for _, path := range []string{"foo", "current", "x/foo", "-1"} {
+		c.Check(backend.ValidateRestorePath(path), check.ErrorMatches, `invalid path ".*": must be in "common" or a revision directory`, check.Commentf("%q", path))
+	}
+}
+
+func (s *snapshotSuite) TestRestorePaths(c *check.C) {
+	if os.Geteuid() == 0 {
+		c.Skip("this test cannot run as root (runuser will fail)")
+	}
+	logger.SimpleSetup(nil)
+
+	si := snap.MinimalPlaceInfo("hello-snap", snap.R(42))
+	homeDir := filepath.Join(dirs.GlobalRootDir, "home/snapuser")
+	c.Assert(os.MkdirAll(filepath.Join(si.CommonDataDir(), "sub/dir"), 0700), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(si.CommonDataDir(), "sub/dir/baz"), []byte("nested canary\n"), 0644), check.IsNil)
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	// change everything after the snapshot was taken
+	for _, t := range table(si, homeDir) {
+		c.Assert(os.WriteFile(filepath.Join(t.dir, t.name), []byte("changed\n"), 0644), check.IsNil)
+	}
+	c.Assert(os.RemoveAll(filepath.Join(si.CommonDataDir(), "sub")), check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+
+	rs, err := shr.Restore(context.TODO(), snap.R(0), nil, []string{"42/foo", "common/sub/dir", "common/sub/dir/baz"}, logger.Debugf, nil)
+	c.Assert(err, check.IsNil)
+
+	// only the given paths were restored, for the system and the user
+	c.Check(filepath.Join(si.DataDir(), "foo"), testutil.FileEquals, "versioned system canary\n")
+	c.Check(filepath.Join(si.UserDataDir(homeDir, nil), "ufoo"), testutil.FileEquals, "changed\n")
+	c.Check(filepath.Join(si.CommonDataDir(), "bar"), testutil.FileEquals, "changed\n")
+	c.Check(filepath.Join(si.CommonDataDir(), "sub/dir/baz"), testutil.FileEquals, "nested canary\n")
+	c.Check(filepath.Join(si.UserCommonDataDir(homeDir, nil), "ubar"), testutil.FileEquals, "changed\n")
+	fi, err := os.Stat(filepath.Join(si.CommonDataDir(), "sub"))
+	c.Assert(err, check.IsNil)
+	c.Check(fi.Mode().Perm(), check.Equals, os.FileMode(0700))
+
+	// and it can all be undone
+	rs.Revert()
+	c.Check(filepath.Join(si.DataDir(), "foo"), testutil.FileEquals, "changed\n")
+	c.Check(filepath.Join(si.CommonDataDir(), "sub"), testutil.FileAbsent)
+}
+
+func (s *snapshotSuite) TestRestorePathsNotFound(c *check.C) {
+	if os.Geteuid() == 0 {
+		c.Skip("this test cannot run as root (runuser will fail)")
+	}
+	logger.SimpleSetup(nil)
+
+	si := snap.MinimalPlaceInfo("hello-snap", snap.R(42))
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(os.WriteFile(filepath.Join(si.CommonDataDir(), "bar"), []byte("changed\n"), 0644), check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+
+	_, err = shr.Restore(context.TODO(), snap.R(0), nil, []string{"common/bar", "43/foo"}, logger.Debugf, nil)
+	c.Check(err, check.ErrorMatches, `cannot restore "43/foo": not found in snapshot ".*/12_hello-snap_v1.33_42.zip"`)
+	// what was restored was undone
+	c.Check(filepath.Join(si.CommonDataDir(), "bar"), testutil.FileEquals, "changed\n")
+
+	_, err = shr.Restore(context.TODO(), snap.R(0), nil, []string{"../bar"}, logger.Debugf, nil)
+	c.Check(err, check.ErrorMatches, `invalid path "../bar": .*`)
+}
+
+func (s *snapshotSuite) TestRestorePathRefusesSymlinks(c *check.C) {
+	sourceDir := c.MkDir()
+	targetDir := c.MkDir()
+	outside := c.MkDir()
+	c.Assert(os.MkdirAll(filepath.Join(sourceDir, "common/sub"), 0755), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(sourceDir, "common/sub/foo"), []byte("canary\n"), 0644), check.IsNil)
+	c.Assert(os.Mkdir(filepath.Join(targetDir, "common"), 0755), check.IsNil)
+	// the user pointed a directory of their data somewhere else
+	c.Assert(os.Symlink(outside, filepath.Join(targetDir, "common/sub")), check.IsNil)
+
+	uid, gid := sys.UserID(os.Getuid()), sys.GroupID(os.Getgid())
+	rs := &backend.RestoreState{}
+	found, err := backend.RestorePath(rs, "common/sub/foo", sourceDir, targetDir, uid, gid)
+	c.Check(err, check.ErrorMatches, `cannot restore "common/sub/foo": ".*/common/sub" is a symbolic link`)
+	c.Check(found, check.Equals, false)
+	c.Check(filepath.Join(outside, "foo"), testutil.FileAbsent)
+	c.Check(filepath.Join(sourceDir, "common/sub/foo"), testutil.FilePresent)
+
+	// without the symlink things are restored
+	c.Assert(os.Remove(filepath.Join(targetDir, "common/sub")), check.IsNil)
+	found, err = backend.RestorePath(rs, "common/sub/foo", sourceDir, targetDir, uid, gid)
+	c.Assert(err, check.IsNil)
+	c.Check(found, check.Equals, true)
+	c.Check(filepath.Join(targetDir, "common/sub/foo"), testutil.FileEquals, "canary\n")
+}
+
+func (s *snapshotSuite) TestUnlockNotEncrypted(c *check.C) {
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(context.TODO(), 12, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(shw.Encryption, check.IsNil)
+
+	shr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer shr.Close()
+	c.Check(shr.Unlock([]byte("s3cr3t")), check.ErrorMatches, `snapshot ".*" is not encrypted`)
+}
+
+// rewriteSnapshotZip rewrites the members of the given snapshot file with
+// the given function, dropping those for which it returns nil.
+func rewriteSnapshotZip(c *check.C, fn string, rewrite func(name string, data []byte) []byte) {
+	zr, err := zip.OpenReader(fn)
+	c.Assert(err, check.IsNil)
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		c.Assert(err, check.IsNil)
+		member, err := io.ReadAll(rc)
+		c.Assert(err, check.IsNil)
+		rc.Close()
+		member = rewrite(f.Name, member)
+		if member == nil {
+			continue
+		}
+		w, err := zw.Create(f.Name)
+		c.Assert(err, check.IsNil)
+		_, err = w.Write(member)
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(zr.Close(), check.IsNil)
+	c.Assert(zw.Close(), check.IsNil)
+	c.Assert(os.WriteFile(fn, zipBuf.Bytes(), 0600), check.IsNil)
+}
+
+func (s *snapshotSuite) TestEncryptedMetadataAuthenticated(c *check.C) {
+	defer backend.MockArgon2Params(1, 64, 1)()
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	cfg := map[string]interface{}{"password": "hunter2"}
+	shw, err := backend.Save(context.TODO(), 12, info, cfg, nil, nil, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	c.Check(shw.Conf, check.IsNil)
+	c.Check(shw.Encryption.Conf, check.Not(check.HasLen), 0)
+	fn := backend.Filename(shw)
+
+	// the configuration is not stored in plaintext
+	zr, err := zip.OpenReader(fn)
+	c.Assert(err, check.IsNil)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		c.Assert(err, check.IsNil)
+		member, err := io.ReadAll(rc)
+		c.Assert(err, check.IsNil)
+		rc.Close()
+		c.Check(bytes.Contains(member, []byte("hunter2")), check.Equals, false, check.Commentf("%s", f.Name))
+	}
+	zr.Close()
+
+	shr, err := backend.Open(fn, backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	c.Check(shr.Conf, check.IsNil)
+	c.Assert(shr.Unlock([]byte("s3cr3t")), check.IsNil)
+	c.Check(shr.Conf, check.DeepEquals, cfg)
+	shr.Close()
+
+	// tamper with the metadata, keeping its hash right (the hash
+	// comes after the metadata in the snapshot file)
+	var metaHash string
+	rewriteSnapshotZip(c, fn, func(name string, data []byte) []byte {
+		switch name {
+		case "meta.json":
+			data = bytes.Replace(data, []byte(`"v1.33"`), []byte(`"v1.34"`), 1)
+			hasher := crypto.SHA3_384.New()
+			hasher.Write(data)
+			metaHash = fmt.Sprintf("%x\n", hasher.Sum(nil))
+		case "meta.sha3_384":
+			data = []byte(metaHash)
+		}
+		return data
+	})
+	shr, err = backend.Open(fn, backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	c.Check(shr.Version, check.Equals, "v1.34")
+	c.Check(shr.Unlock([]byte("s3cr3t")), check.ErrorMatches, `cannot authenticate metadata of snapshot ".*": MAC does not match`)
+	shr.Close()
+
+	// or drop the MAC altogether
+	rewriteSnapshotZip(c, fn, func(name string, data []byte) []byte {
+		if name == "meta.mac" {
+			return nil
+		}
+		return data
+	})
+	shr, err = backend.Open(fn, backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	c.Check(shr.Unlock([]byte("s3cr3t")), check.ErrorMatches, `cannot authenticate metadata of snapshot ".*": missing archive member "meta.mac"`)
+	shr.Close()
+}
+
+func (s *snapshotSuite) TestImportTamperedEncrypted(c *check.C) {
+	ctx := context.TODO()
+	defer backend.MockArgon2Params(1, 64, 1)()
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(ctx, 12, info, nil, []string{"snapuser"}, nil, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+
+	export, err := backend.NewSnapshotExport(ctx, shw.SetID)
+	c.Assert(err, check.IsNil)
+	c.Assert(export.Init(), check.IsNil)
+	buf := bytes.NewBuffer(nil)
+	c.Assert(export.StreamTo(buf), check.IsNil)
+
+	// flip a bit of the encrypted system archive, keeping the zip valid
+	var tampered bytes.Buffer
+	tr := tar.NewReader(buf)
+	tw := tar.NewWriter(&tampered)
+	replaced := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, check.IsNil)
+		data, err := io.ReadAll(tr)
+		c.Assert(err, check.IsNil)
+		if strings.HasSuffix(hdr.Name, ".zip") {
+			zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			c.Assert(err, check.IsNil)
+			var zipBuf bytes.Buffer
+			zw := zip.NewWriter(&zipBuf)
+			for _, f := range zr.File {
+				rc, err := f.Open()
+				c.Assert(err, check.IsNil)
+				member, err := io.ReadAll(rc)
+				c.Assert(err, check.IsNil)
+				rc.Close()
+				if f.Name == "archive.tgz" {
+					member[len(member)/2] ^= 1
+					replaced = true
+				}
+				w, err := zw.Create(f.Name)
+				c.Assert(err, check.IsNil)
+				_, err = w.Write(member)
+				c.Assert(err, check.IsNil)
+			}
+			c.Assert(zw.Close(), check.IsNil)
+			data = zipBuf.Bytes()
+			hdr.Size = int64(len(data))
+		}
+		c.Assert(tw.WriteHeader(hdr), check.IsNil)
+		_, err = tw.Write(data)
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(tw.Close(), check.IsNil)
+	c.Assert(replaced, check.Equals, true)
+
+	_, err = backend.Import(ctx, 123, &tampered, &backend.ImportFlags{NoDuplicatedImportCheck: true})
+	c.Assert(err, check.ErrorMatches, `cannot import snapshot 123: validation failed for .*: snapshot entry "archive.tgz" expected hash \([0-9a-f]{7}…\) does not match actual \([0-9a-f]{7}…\)`)
+	c.Check(filepath.Join(dirs.SnapshotsDir, "123_hello-snap_v1.33_42.zip"), testutil.FileAbsent)
+}
+
+func (s *snapshotSuite) TestCollectGarbage(c *check.C) {
+	ctx := context.TODO()
+
+	// nothing to do without snapshots
+	removed, err := backend.CollectGarbage(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(removed, check.Equals, 0)
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	sh1, err := backend.Save(ctx, 1, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+	// the data changes between the snapshots
+	c.Assert(os.WriteFile(filepath.Join(info.DataDir(), "new"), []byte("new data\n"), 0644), check.IsNil)
+	sh2, err := backend.Save(ctx, 2, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	chunks := func() (names []string) {
+		matches, err := filepath.Glob(filepath.Join(dirs.SnapshotsDir, "chunks", "*", "*"))
+		c.Assert(err, check.IsNil)
+		for _, m := range matches {
+			names = append(names, filepath.Base(m))
+		}
+		sort.Strings(names)
+		return names
+	}
+	// the user data did not change, so it is stored only once
+	c.Check(sh1.SHA3_384["user/snapuser.tar"], check.Equals, sh2.SHA3_384["user/snapuser.tar"])
+	c.Check(sh1.SHA3_384["archive.tar"], check.Not(check.Equals), sh2.SHA3_384["archive.tar"])
+	c.Check(chunks(), check.HasLen, 3)
+
+	// leftovers of interrupted writes are garbage too
+	leftover := filepath.Join(dirs.SnapshotsDir, "chunks", "ab", "abcd.XXXX~")
+	c.Assert(os.MkdirAll(filepath.Dir(leftover), 0700), check.IsNil)
+	c.Assert(os.WriteFile(leftover, nil, 0600), check.IsNil)
+
+	// all chunks are still referenced
+	removed, err = backend.CollectGarbage(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(removed, check.Equals, 1)
+	c.Check(chunks(), check.HasLen, 3)
+	c.Check(filepath.Dir(leftover), testutil.FileAbsent)
+
+	// forget the first snapshot
+	c.Assert(os.Remove(backend.Filename(sh1)), check.IsNil)
+	removed, err = backend.CollectGarbage(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(removed, check.Equals, 1)
+	c.Check(chunks(), check.HasLen, 2)
+
+	// the second one is still good
+	rdr, err := backend.Open(backend.Filename(sh2), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer rdr.Close()
+	c.Check(rdr.Check(ctx, nil), check.IsNil)
+
+	// and forgetting it too leaves nothing behind
+	c.Assert(os.Remove(backend.Filename(sh2)), check.IsNil)
+	removed, err = backend.CollectGarbage(ctx)
+	c.Assert(err, check.IsNil)
+	c.Check(removed, check.Equals, 2)
+	c.Check(chunks(), check.HasLen, 0)
+}
+
+func (s *snapshotSuite) TestCollectGarbageBailsOnUnreadableSnapshot(c *check.C) {
+	ctx := context.TODO()
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	_, err := backend.Save(ctx, 1, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapshotsDir, "2_broken_1.0_1.zip"), []byte("not a zip"), 0600), check.IsNil)
+	unreferenced := filepath.Join(dirs.SnapshotsDir, "chunks", "ab", "abcd")
+	c.Assert(os.MkdirAll(filepath.Dir(unreferenced), 0700), check.IsNil)
+	c.Assert(os.WriteFile(unreferenced, nil, 0600), check.IsNil)
+
+	removed, err := backend.CollectGarbage(ctx)
+	c.Assert(err, check.ErrorMatches, `cannot read chunk index of "2_broken_1.0_1.zip": zip: not a valid zip file`)
+	c.Check(removed, check.Equals, 0)
+	c.Check(unreferenced, testutil.FilePresent)
+}
+
+func (s *snapshotSuite) TestCollectGarbageDoesNotWaitForSaves(c *check.C) {
+	unreferenced := filepath.Join(dirs.SnapshotsDir, "chunks", "ab", "abcd")
+	c.Assert(os.MkdirAll(filepath.Dir(unreferenced), 0700), check.IsNil)
+	c.Assert(os.WriteFile(unreferenced, nil, 0600), check.IsNil)
+
+	// a snapshot is being saved
+	unlock := backend.LockChunkStoreForSave()
+	removed, err := backend.CollectGarbage(context.TODO())
+	c.Check(err, check.Equals, backend.ErrChunkStoreBusy)
+	c.Check(removed, check.Equals, 0)
+	c.Check(unreferenced, testutil.FilePresent)
+
+	unlock()
+	removed, err = backend.CollectGarbage(context.TODO())
+	c.Check(err, check.IsNil)
+	c.Check(removed, check.Equals, 1)
+	c.Check(unreferenced, testutil.FileAbsent)
+}
+
+func (s *snapshotSuite) TestCollectGarbageCancelled(c *check.C) {
+	c.Assert(os.MkdirAll(dirs.SnapshotsDir, 0700), check.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dirs.SnapshotsDir, "1_hello-snap_v1.33_42.zip"), nil, 0600), check.IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := backend.CollectGarbage(ctx)
+	c.Check(err, check.Equals, context.Canceled)
+}
+
+func (s *snapshotSuite) TestCheckChunkMissing(c *check.C) {
+	ctx := context.TODO()
+
+	info := &snap.Info{SideInfo: snap.SideInfo{RealName: "hello-snap", Revision: snap.R(42), SnapID: "hello-id"}, Version: "v1.33"}
+	shw, err := backend.Save(ctx, 1, info, nil, []string{"snapuser"}, nil, nil, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(os.RemoveAll(filepath.Join(dirs.SnapshotsDir, "chunks")), check.IsNil)
+
+	rdr, err := backend.Open(backend.Filename(shw), backend.ExtractFnameSetID)
+	c.Assert(err, check.IsNil)
+	defer rdr.Close()
+	c.Check(rdr.Check(ctx, nil), check.ErrorMatches, "cannot open snapshot chunk: open .*: no such file or directory")
 }
 
 func (s *snapshotSuite) TestEstimateSnapshotSize(c *check.C) {
@@ -1300,11 +1917,11 @@ func (s *snapshotSuite) TestExportTwice(c *check.C) {
 	}
 	// create a snapshot
 	shID := uint64(12)
-	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil)
+	_, err := backend.Save(context.TODO(), shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 
-	// content.json + num_files + export.json + footer
-	expectedSize := int64(1024 + 4*512 + 1024 + 2*512)
+	// content.json + 2 chunks + num_files + export.json + footer
+	expectedSize := int64(1024 + 2*(512+512) + 3*512 + 1024 + 2*512)
 	// do on export at the start of the epoch
 	restore := backend.MockTimeNow(func() time.Time { return time.Time{} })
 	defer restore()
@@ -1647,7 +2264,7 @@ func (s *snapshotSuite) TestSnapshotExportContentHash(c *check.C) {
 		Version: "v1.33",
 	}
 	shID := uint64(12)
-	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err := backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Check(err, check.IsNil)
 
 	// now export it
@@ -1669,7 +2286,7 @@ func (s *snapshotSuite) TestSnapshotExportContentHash(c *check.C) {
 		},
 		Version: "v1.33",
 	}
-	shw, err = backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil)
+	shw, err = backend.Save(ctx, shID, info, nil, []string{"snapuser"}, nil, nil, nil)
 	c.Check(err, check.IsNil)
 
 	export3, err := backend.NewSnapshotExport(ctx, shw.SetID)
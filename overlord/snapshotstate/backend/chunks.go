@@ -0,0 +1,455 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// Snapshots saved by this backend don't carry their archives inside the zip
+// file. Instead, the (uncompressed) tar stream of each archive is split into
+// content-defined chunks which are stored, gzipped, in a content-addressed
+// chunk store under the snapshots directory. The zip file then carries a
+// chunk index listing, for every archive entry, the chunks that make it up.
+// Chunks shared between snapshots are stored only once, and are removed by
+// CollectGarbage once no snapshot references them anymore.
+
+const (
+	chunkIndexName   = "chunks.json"
+	chunkIndexFormat = 1
+	chunksDirName    = "chunks"
+)
+
+var (
+	// chunk boundaries are found with a gear rolling hash; a boundary is
+	// placed once the hash has all the chunkMask bits unset, so chunks
+	// are on average chunkMinSize+chunkMask+1 bytes long.
+	chunkMinSize = 512 * 1024
+	chunkMaxSize = 8 * 1024 * 1024
+	chunkMask    = uint64(1<<19 - 1)
+
+	// chunkStoreLock must be held for reading while chunks are being
+	// added to the store and not yet referenced by a snapshot file, and
+	// for writing while garbage is being collected.
+	chunkStoreLock sync.RWMutex
+
+	chunkHashRegexp = regexp.MustCompile(`^[0-9a-f]{96}$`)
+)
+
+// gearTable is the table of random values used by the rolling hash. It must
+// never change, otherwise chunks of new snapshots would no longer match the
+// chunks of existing ones.
+var gearTable = func() (table [256]uint64) {
+	// splitmix64 with a fixed seed
+	seed := uint64(0x736e617073686f74) // "snapshot"
+	for i := range table {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// chunkRef identifies one chunk of an archive entry.
+type chunkRef struct {
+	SHA3_384 string `json:"sha3-384"`
+	Size     int64  `json:"size"`
+}
+
+// chunkIndex lists the chunks that make up each archive entry of a
+// snapshot, in order.
+type chunkIndex struct {
+	Format  int                   `json:"format"`
+	Entries map[string][]chunkRef `json:"entries"`
+}
+
+func chunksDir() string {
+	return filepath.Join(dirs.SnapshotsDir, chunksDirName)
+}
+
+// chunkPath returns the path of the given chunk in the given chunk store.
+func chunkPath(dir, hash string) string {
+	return filepath.Join(dir, hash[:2], hash)
+}
+
+func chunkFilename(hash string) string {
+	return chunkPath(chunksDir(), hash)
+}
+
+// readChunkIndex reads the chunk index of the given snapshot file. It returns
+// a nil index if the snapshot is not a chunked one.
+func readChunkIndex(f *os.File) (*chunkIndex, error) {
+	fh, err := findZipMember(f, chunkIndexName)
+	if err != nil || fh == nil {
+		return nil, err
+	}
+	body, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var idx chunkIndex
+	if err := json.NewDecoder(body).Decode(&idx); err != nil {
+		return nil, fmt.Errorf("cannot decode chunk index: %v", err)
+	}
+	if idx.Format != chunkIndexFormat {
+		return nil, fmt.Errorf("unsupported chunk index format %d", idx.Format)
+	}
+	for entry, refs := range idx.Entries {
+		for _, ref := range refs {
+			if !chunkHashRegexp.MatchString(ref.SHA3_384) || ref.Size < 0 {
+				return nil, fmt.Errorf("invalid chunk in chunk index entry %q", entry)
+			}
+		}
+	}
+	return &idx, nil
+}
+
+// entrySize returns the size of the data of the given entry.
+func (idx *chunkIndex) entrySize(entry string) int64 {
+	var sz int64
+	for _, ref := range idx.Entries[entry] {
+		sz += ref.Size
+	}
+	return sz
+}
+
+// storeChunk adds the given data to the chunk store, unless a chunk with the
+// same content is already there.
+func storeChunk(data []byte) (chunkRef, error) {
+	hasher := crypto.SHA3_384.New()
+	hasher.Write(data)
+	ref := chunkRef{
+		SHA3_384: fmt.Sprintf("%x", hasher.Sum(nil)),
+		Size:     int64(len(data)),
+	}
+
+	fn := chunkFilename(ref.SHA3_384)
+	if osutil.FileExists(fn) {
+		return ref, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), 0700); err != nil {
+		return ref, err
+	}
+	aw, err := osutil.NewAtomicFile(fn, 0600, 0, osutil.NoChown, osutil.NoChown)
+	if err != nil {
+		return ref, err
+	}
+	// Cancel is a NOP once committed
+	defer aw.Cancel()
+
+	gz := gzip.NewWriter(aw)
+	if _, err := gz.Write(data); err != nil {
+		return ref, err
+	}
+	if err := gz.Close(); err != nil {
+		return ref, err
+	}
+
+	return ref, aw.Commit()
+}
+
+// importChunk adds a chunk read from r, in its stored (gzipped) form, to the
+// chunk store after checking that its content matches the given hash.
+func importChunk(r io.Reader, hash string) error {
+	if !chunkHashRegexp.MatchString(hash) {
+		return fmt.Errorf("invalid chunk name %q", hash)
+	}
+
+	fn := chunkFilename(hash)
+	if err := os.MkdirAll(filepath.Dir(fn), 0700); err != nil {
+		return err
+	}
+	aw, err := osutil.NewAtomicFile(fn, 0600, 0, osutil.NoChown, osutil.NoChown)
+	if err != nil {
+		return err
+	}
+	// Cancel is a NOP once committed
+	defer aw.Cancel()
+
+	tr := io.TeeReader(r, aw)
+	gz, err := gzip.NewReader(tr)
+	if err != nil {
+		return fmt.Errorf("cannot read chunk %.7s…: %v", hash, err)
+	}
+	hasher := crypto.SHA3_384.New()
+	if _, err := io.Copy(hasher, gz); err != nil {
+		return fmt.Errorf("cannot read chunk %.7s…: %v", hash, err)
+	}
+	// write out anything the decompressor did not consume
+	if _, err := io.Copy(io.Discard, tr); err != nil {
+		return err
+	}
+	if actualHash := fmt.Sprintf("%x", hasher.Sum(nil)); actualHash != hash {
+		return fmt.Errorf("chunk %.7s… does not match its content (%.7s…)", hash, actualHash)
+	}
+
+	return aw.Commit()
+}
+
+// chunkWriter splits the data written to it into content-defined chunks,
+// and stores them in the chunk store.
+type chunkWriter struct {
+	buf  []byte
+	hash uint64
+	refs []chunkRef
+	size int64
+	err  error
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	start := 0
+	for i, b := range p {
+		cw.hash = (cw.hash << 1) + gearTable[b]
+		n := len(cw.buf) + i - start + 1
+		if n < chunkMinSize {
+			continue
+		}
+		if cw.hash&chunkMask == 0 || n >= chunkMaxSize {
+			cw.buf = append(cw.buf, p[start:i+1]...)
+			start = i + 1
+			if err := cw.flush(); err != nil {
+				return start, err
+			}
+		}
+	}
+	cw.buf = append(cw.buf, p[start:]...)
+	return len(p), nil
+}
+
+func (cw *chunkWriter) flush() error {
+	cw.hash = 0
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	ref, err := storeChunk(cw.buf)
+	if err != nil {
+		cw.err = fmt.Errorf("cannot store snapshot chunk: %v", err)
+		return cw.err
+	}
+	cw.refs = append(cw.refs, ref)
+	cw.size += ref.Size
+	cw.buf = cw.buf[:0]
+	return nil
+}
+
+// Close stores whatever data is left as the last chunk.
+func (cw *chunkWriter) Close() error {
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.flush()
+}
+
+// chunkReader reads the data of an archive entry back from a chunk store.
+type chunkReader struct {
+	dir  string
+	refs []chunkRef
+	cur  *os.File
+	gz   *gzip.Reader
+	read int64
+}
+
+func (cr *chunkReader) next() error {
+	cr.closeCurrent()
+	ref := cr.refs[0]
+	f, err := os.Open(chunkPath(cr.dir, ref.SHA3_384))
+	if err != nil {
+		return fmt.Errorf("cannot open snapshot chunk: %v", err)
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("cannot read snapshot chunk %.7s…: %v", ref.SHA3_384, err)
+	}
+	cr.cur = f
+	cr.gz = gz
+	cr.read = 0
+	return nil
+}
+
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if cr.gz == nil {
+			if len(cr.refs) == 0 {
+				return 0, io.EOF
+			}
+			if err := cr.next(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := cr.gz.Read(p)
+		cr.read += int64(n)
+		if err == io.EOF {
+			if cr.read != cr.refs[0].Size {
+				return n, fmt.Errorf("snapshot chunk %.7s… size (%d) different from expected (%d)", cr.refs[0].SHA3_384, cr.read, cr.refs[0].Size)
+			}
+			cr.refs = cr.refs[1:]
+			cr.closeCurrent()
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+func (cr *chunkReader) closeCurrent() {
+	if cr.cur != nil {
+		cr.cur.Close()
+	}
+	cr.cur = nil
+	cr.gz = nil
+}
+
+func (cr *chunkReader) Close() error {
+	cr.closeCurrent()
+	cr.refs = nil
+	return nil
+}
+
+// chunkRefCounts returns how many times each chunk is referenced by the
+// snapshot files in the snapshots directory.
+func chunkRefCounts(ctx context.Context) (map[string]int, error) {
+	entries, err := os.ReadDir(dirs.SnapshotsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read snapshots directory: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if ok, _ := isSnapshotFilename(entry.Name()); !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dirs.SnapshotsDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		idx, err := readChunkIndex(f)
+		f.Close()
+		if err != nil {
+			// be conservative: without knowing all the references
+			// no chunk can be removed
+			return nil, fmt.Errorf("cannot read chunk index of %q: %v", entry.Name(), err)
+		}
+		if idx == nil {
+			continue
+		}
+		for _, refs := range idx.Entries {
+			for _, ref := range refs {
+				counts[ref.SHA3_384]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// ErrChunkStoreBusy is returned by CollectGarbage when snapshots are being
+// saved, imported or exported.
+var ErrChunkStoreBusy = errors.New("snapshot chunk store is busy")
+
+// CollectGarbage removes the chunks that are no longer referenced by any
+// snapshot from the chunk store. It returns the number of chunks removed.
+//
+// As saving a snapshot can take a long time, CollectGarbage does not wait
+// for the running saves, imports and exports to finish but returns
+// ErrChunkStoreBusy instead.
+func CollectGarbage(ctx context.Context) (removed int, err error) {
+	if !chunkStoreLock.TryLock() {
+		return 0, ErrChunkStoreBusy
+	}
+	defer chunkStoreLock.Unlock()
+
+	counts, err := chunkRefCounts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	prefixes, err := os.ReadDir(chunksDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("cannot read snapshot chunks directory: %v", err)
+	}
+
+	var errs []error
+	for _, prefix := range prefixes {
+		if !prefix.IsDir() {
+			continue
+		}
+		dir := filepath.Join(chunksDir(), prefix.Name())
+		chunks, err := os.ReadDir(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		left := 0
+		for _, chunk := range chunks {
+			// anything that isn't a referenced chunk (including
+			// leftovers of interrupted writes) is garbage
+			if counts[chunk.Name()] > 0 {
+				left++
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, chunk.Name())); err != nil {
+				errs = append(errs, err)
+				left++
+				continue
+			}
+			removed++
+		}
+		if left == 0 {
+			if err := os.Remove(dir); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if removed > 0 {
+		logger.Debugf("Removed %d unused snapshot chunks.", removed)
+	}
+	if len(errs) > 0 {
+		return removed, newMultiError("cannot remove all unused snapshot chunks", errs)
+	}
+	return removed, nil
+}
@@ -0,0 +1,337 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/jsonutil"
+)
+
+// Encrypted archives are gzipped tar streams split into segments, each
+// sealed on its own with AES-256-GCM. The stream starts with a random
+// nonce prefix; the nonce of each segment is that prefix followed by
+// the segment's index and a flag marking the last segment, so that
+// segments can't be reordered, dropped, or the stream truncated without
+// it being noticed. The name of the entry is used as additional data,
+// so archives can't be swapped around either.
+//
+// The hashes of encrypted snapshots are of the encrypted data, so they
+// can be checked (and imports verified) without the key. As anyone can
+// recompute those, the metadata of encrypted snapshots is authenticated
+// with a MAC keyed from the secret, and the configuration of the snap,
+// which is kept in the metadata, is encrypted as well.
+const (
+	encryptionCipher = "aes-256-gcm-stream"
+	encryptionKDF    = "argon2id"
+
+	encSegmentSize = 64 * 1024
+	encPrefixSize  = 7
+	encSaltSize    = 16
+)
+
+var (
+	// argon2id parameters for new snapshots, as recommended by
+	// RFC 9106 for memory-constrained environments
+	argon2Time    uint32 = 3
+	argon2Memory  uint32 = 64 * 1024
+	argon2Threads uint8  = 4
+
+	randReader = rand.Reader
+)
+
+// ErrWrongKey is returned when trying to unlock an encrypted snapshot
+// with the wrong passphrase or key file.
+var ErrWrongKey = errors.New("wrong passphrase or key for encrypted snapshot")
+
+// snapshotKey is the key the archives of an encrypted snapshot are
+// encrypted with.
+type snapshotKey struct {
+	aead cipher.AEAD
+	// macKey is the key the metadata is authenticated with
+	macKey []byte
+}
+
+// deriveKey derives the key for the given encryption parameters from
+// the secret, returning it together with its key check value.
+func deriveKey(enc *client.SnapshotEncryption, secret []byte) (key *snapshotKey, check string, err error) {
+	if enc.Cipher != encryptionCipher {
+		return nil, "", fmt.Errorf("unsupported snapshot cipher %q", enc.Cipher)
+	}
+	if enc.KDF != encryptionKDF {
+		return nil, "", fmt.Errorf("unsupported snapshot key derivation function %q", enc.KDF)
+	}
+	if len(secret) == 0 {
+		return nil, "", fmt.Errorf("cannot derive snapshot key from an empty secret")
+	}
+	// the first third is the key proper, the second the key check,
+	// and the last one the metadata MAC key
+	material := argon2.IDKey(secret, enc.Salt, enc.Time, enc.Memory, enc.Threads, 96)
+	block, err := aes.NewCipher(material[:32])
+	if err != nil {
+		return nil, "", err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, "", err
+	}
+	h := crypto.SHA3_384.New()
+	h.Write(material[32:64])
+	return &snapshotKey{aead: aead, macKey: material[64:]}, fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// newEncryption returns the parameters and key to encrypt a new
+// snapshot with the given secret.
+func newEncryption(secret []byte) (*client.SnapshotEncryption, *snapshotKey, error) {
+	enc := &client.SnapshotEncryption{
+		Cipher:  encryptionCipher,
+		KDF:     encryptionKDF,
+		Salt:    make([]byte, encSaltSize),
+		Time:    argon2Time,
+		Memory:  argon2Memory,
+		Threads: argon2Threads,
+	}
+	if _, err := io.ReadFull(randReader, enc.Salt); err != nil {
+		return nil, nil, fmt.Errorf("cannot generate snapshot salt: %v", err)
+	}
+	key, check, err := deriveKey(enc, secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc.KeyCheck = check
+
+	return enc, key, nil
+}
+
+// unlockKey derives the key for the given encryption parameters from
+// the secret, checking it is the right one.
+func unlockKey(enc *client.SnapshotEncryption, secret []byte) (*snapshotKey, error) {
+	key, check, err := deriveKey(enc, secret)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(check), []byte(enc.KeyCheck)) != 1 {
+		return nil, ErrWrongKey
+	}
+	return key, nil
+}
+
+// metaMAC returns the MAC of the given snapshot metadata.
+func (k *snapshotKey) metaMAC(meta []byte) string {
+	mac := hmac.New(crypto.SHA3_384.New, k.macKey)
+	mac.Write(meta)
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+// checkMetaMAC checks the given MAC is the one of the given metadata.
+func (k *snapshotKey) checkMetaMAC(meta []byte, expected string) bool {
+	return hmac.Equal([]byte(k.metaMAC(meta)), []byte(expected))
+}
+
+// sealConf encrypts the configuration of a snap.
+func (k *snapshotKey) sealConf(conf map[string]interface{}) ([]byte, error) {
+	buf, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := io.ReadFull(randReader, nonce); err != nil {
+		return nil, fmt.Errorf("cannot generate snapshot nonce: %v", err)
+	}
+	return k.aead.Seal(nonce, nonce, buf, []byte(metadataName)), nil
+}
+
+// openConf decrypts the configuration of a snap sealed with sealConf.
+func (k *snapshotKey) openConf(sealed []byte) (map[string]interface{}, error) {
+	if len(sealed) < k.aead.NonceSize() {
+		return nil, errors.New("encrypted configuration is too short")
+	}
+	nonceSize := k.aead.NonceSize()
+	buf, err := k.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(metadataName))
+	if err != nil {
+		return nil, err
+	}
+	var conf map[string]interface{}
+	if err := jsonutil.DecodeWithNumber(bytes.NewReader(buf), &conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func (k *snapshotKey) nonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, k.aead.NonceSize())
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[encPrefixSize:], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// encryptWriter encrypts what's written to it into w. It must be
+// closed for the last segment to be written.
+type encryptWriter struct {
+	w       io.Writer
+	key     *snapshotKey
+	entry   []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	// started is set once the prefix has been written out
+	started bool
+}
+
+func newEncryptWriter(w io.Writer, key *snapshotKey, entry string) (*encryptWriter, error) {
+	prefix := make([]byte, encPrefixSize)
+	if _, err := io.ReadFull(randReader, prefix); err != nil {
+		return nil, fmt.Errorf("cannot generate snapshot nonce: %v", err)
+	}
+	return &encryptWriter{
+		w:      w,
+		key:    key,
+		entry:  []byte(entry),
+		prefix: prefix,
+		buf:    make([]byte, 0, encSegmentSize),
+	}, nil
+}
+
+func (ew *encryptWriter) seal(last bool) error {
+	if !ew.started {
+		if _, err := ew.w.Write(ew.prefix); err != nil {
+			return err
+		}
+		ew.started = true
+	}
+	if ew.counter == ^uint32(0) {
+		return fmt.Errorf("snapshot archive too big to encrypt")
+	}
+	out := ew.key.aead.Seal(nil, ew.key.nonce(ew.prefix, ew.counter, last), ew.buf, ew.entry)
+	ew.counter++
+	ew.buf = ew.buf[:0]
+	_, err := ew.w.Write(out)
+	return err
+}
+
+func (ew *encryptWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		// a full segment is only sealed once there's more data, as
+		// the last one needs to be marked as such
+		if len(ew.buf) == encSegmentSize {
+			if err := ew.seal(false); err != nil {
+				return n, err
+			}
+		}
+		m := copy(ew.buf[len(ew.buf):encSegmentSize], p)
+		ew.buf = ew.buf[:len(ew.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close writes out the last segment.
+func (ew *encryptWriter) Close() error {
+	return ew.seal(true)
+}
+
+// decryptReader decrypts and authenticates what's read from r.
+type decryptReader struct {
+	r       *bufio.Reader
+	key     *snapshotKey
+	entry   []byte
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	segment []byte
+	done    bool
+	err     error
+}
+
+func newDecryptReader(r io.Reader, key *snapshotKey, entry string) *decryptReader {
+	return &decryptReader{
+		r:       bufio.NewReader(r),
+		key:     key,
+		entry:   []byte(entry),
+		segment: make([]byte, encSegmentSize+key.aead.Overhead()),
+	}
+}
+
+func (dr *decryptReader) fill() error {
+	if dr.prefix == nil {
+		dr.prefix = make([]byte, encPrefixSize)
+		if _, err := io.ReadFull(dr.r, dr.prefix); err != nil {
+			return fmt.Errorf("cannot decrypt snapshot archive: %v", io.ErrUnexpectedEOF)
+		}
+	}
+	n, err := io.ReadFull(dr.r, dr.segment)
+	last := false
+	switch err {
+	case nil:
+		// a full segment is the last one if nothing follows it
+		if _, err := dr.r.Peek(1); err == io.EOF {
+			last = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+	buf, err := dr.key.aead.Open(dr.buf[:0], dr.key.nonce(dr.prefix, dr.counter, last), dr.segment[:n], dr.entry)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt snapshot archive: segment %d of %q is corrupted", dr.counter, dr.entry)
+	}
+	dr.buf = buf
+	dr.counter++
+	dr.done = last
+	return nil
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.buf) == 0 {
+		if dr.err != nil {
+			return 0, dr.err
+		}
+		if dr.done {
+			return 0, io.EOF
+		}
+		dr.err = dr.fill()
+	}
+	n := copy(p, dr.buf)
+	dr.buf = dr.buf[n:]
+	return n, nil
+}
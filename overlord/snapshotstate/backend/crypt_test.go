@@ -0,0 +1,144 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package backend_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+)
+
+type cryptSuite struct {
+	restore func()
+}
+
+var _ = check.Suite(&cryptSuite{})
+
+func (s *cryptSuite) SetUpTest(c *check.C) {
+	// keep key derivation cheap
+	s.restore = backend.MockArgon2Params(1, 64, 1)
+}
+
+func (s *cryptSuite) TearDownTest(c *check.C) {
+	s.restore()
+}
+
+var secret = []byte("correct horse battery staple")
+
+func encrypt(c *check.C, data []byte, entry string) ([]byte, *client.SnapshotEncryption) {
+	var buf bytes.Buffer
+	w, enc, err := backend.EncryptStream(&buf, secret, entry)
+	c.Assert(err, check.IsNil)
+	_, err = w.Write(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(w.Close(), check.IsNil)
+	return buf.Bytes(), enc
+}
+
+func (s *cryptSuite) TestRoundtrip(c *check.C) {
+	// the salt and nonce, and so the ciphertext, as well as the data are
+	// deterministic so that short plaintexts cannot show up in the
+	// ciphertext by chance
+	restore := backend.MockRandReader(rand.New(rand.NewSource(1)))
+	defer restore()
+	dataRand := rand.New(rand.NewSource(3))
+
+	for _, size := range []int{0, 1, backend.EncSegmentSize - 1, backend.EncSegmentSize, backend.EncSegmentSize + 1, 3*backend.EncSegmentSize + 42} {
+		comm := check.Commentf("size %d", size)
+		data := make([]byte, size)
+		dataRand.Read(data)
+
+		ciphertext, enc := encrypt(c, data, "archive.tgz")
+		c.Check(bytes.Contains(ciphertext, data), check.Equals, size == 0, comm)
+
+		r, err := backend.DecryptStream(bytes.NewReader(ciphertext), enc, secret, "archive.tgz")
+		c.Assert(err, check.IsNil, comm)
+		plaintext, err := io.ReadAll(r)
+		c.Assert(err, check.IsNil, comm)
+		c.Check(plaintext, check.HasLen, size, comm)
+		c.Check(bytes.Equal(plaintext, data), check.Equals, true, comm)
+	}
+}
+
+func (s *cryptSuite) TestWrongSecret(c *check.C) {
+	ciphertext, enc := encrypt(c, []byte("hello"), "archive.tgz")
+
+	_, err := backend.DecryptStream(bytes.NewReader(ciphertext), enc, []byte("hunter2"), "archive.tgz")
+	c.Check(err, check.Equals, backend.ErrWrongKey)
+}
+
+func (s *cryptSuite) TestUnsupportedParameters(c *check.C) {
+	ciphertext, enc := encrypt(c, []byte("hello"), "archive.tgz")
+
+	enc.Cipher = "rot13"
+	_, err := backend.DecryptStream(bytes.NewReader(ciphertext), enc, secret, "archive.tgz")
+	c.Check(err, check.ErrorMatches, `unsupported snapshot cipher "rot13"`)
+
+	enc.Cipher = "aes-256-gcm-stream"
+	enc.KDF = "md5"
+	_, err = backend.DecryptStream(bytes.NewReader(ciphertext), enc, secret, "archive.tgz")
+	c.Check(err, check.ErrorMatches, `unsupported snapshot key derivation function "md5"`)
+}
+
+func (s *cryptSuite) TestTampered(c *check.C) {
+	data := make([]byte, 2*backend.EncSegmentSize+100)
+	rand.Read(data)
+	ciphertext, enc := encrypt(c, data, "archive.tgz")
+	// prefix, then segments with a 16 byte tag each
+	segSize := backend.EncSegmentSize + 16
+	lastSeg := 7 + 2*segSize
+
+	flipped := append([]byte(nil), ciphertext...)
+	flipped[lastSeg+10] ^= 1
+
+	swapped := append([]byte(nil), ciphertext[:7]...)
+	swapped = append(swapped, ciphertext[7+segSize:lastSeg]...)
+	swapped = append(swapped, ciphertext[7:7+segSize]...)
+	swapped = append(swapped, ciphertext[lastSeg:]...)
+
+	for _, t := range []struct {
+		ciphertext []byte
+		entry      string
+		err        string
+	}{
+		// renamed
+		{ciphertext, "user/someone.tgz", `cannot decrypt snapshot archive: segment 0 of "user/someone.tgz" is corrupted`},
+		// bit flip
+		{flipped, "archive.tgz", `cannot decrypt snapshot archive: segment 2 of "archive.tgz" is corrupted`},
+		// segments reordered
+		{swapped, "archive.tgz", `cannot decrypt snapshot archive: segment 0 of "archive.tgz" is corrupted`},
+		// truncated at a segment boundary
+		{ciphertext[:lastSeg], "archive.tgz", `cannot decrypt snapshot archive: segment 1 of "archive.tgz" is corrupted`},
+		// trailing garbage
+		{append(append([]byte(nil), ciphertext...), 0), "archive.tgz", `cannot decrypt snapshot archive: segment 2 of "archive.tgz" is corrupted`},
+		// not even a prefix
+		{ciphertext[:3], "archive.tgz", `cannot decrypt snapshot archive: unexpected EOF`},
+	} {
+		r, err := backend.DecryptStream(bytes.NewReader(t.ciphertext), enc, secret, t.entry)
+		c.Assert(err, check.IsNil)
+		_, err = io.ReadAll(r)
+		c.Check(err, check.ErrorMatches, t.err)
+	}
+}
@@ -20,6 +20,8 @@
 package backend
 
 import (
+	"context"
+	"io"
 	"os"
 	"os/exec"
 	"time"
@@ -40,9 +42,79 @@ var (
 
 	NewMultiError = newMultiError
 
-	AddSnapDirToZip = addSnapDirToZip
+	ChunkFilename = chunkFilename
+
+	RestorePath = restorePath
 )
 
+func AddSnapDirToChunkStore(ctx context.Context, snapshot *client.Snapshot, idx *ChunkIndex, username, entry, snapDir string, savingUserData bool, excludePaths []string) error {
+	return addSnapDirToArchive(ctx, snapshot, &archiveStore{idx: idx}, username, entry, snapDir, savingUserData, excludePaths)
+}
+
+type ChunkIndex = chunkIndex
+
+func NewChunkIndex() *ChunkIndex {
+	return &chunkIndex{Format: chunkIndexFormat, Entries: make(map[string][]chunkRef)}
+}
+
+func (idx *ChunkIndex) Chunks(entry string) (hashes []string) {
+	for _, ref := range idx.Entries[entry] {
+		hashes = append(hashes, ref.SHA3_384)
+	}
+	return hashes
+}
+
+// LockChunkStoreForSave locks the chunk store like a running save does.
+func LockChunkStoreForSave() (unlock func()) {
+	chunkStoreLock.RLock()
+	return chunkStoreLock.RUnlock
+}
+
+func MockChunkSizes(min, max int, mask uint64) (restore func()) {
+	oldMin, oldMax, oldMask := chunkMinSize, chunkMaxSize, chunkMask
+	chunkMinSize, chunkMaxSize, chunkMask = min, max, mask
+	return func() {
+		chunkMinSize, chunkMaxSize, chunkMask = oldMin, oldMax, oldMask
+	}
+}
+
+func MockRandReader(r io.Reader) (restore func()) {
+	old := randReader
+	randReader = r
+	return func() {
+		randReader = old
+	}
+}
+
+func MockArgon2Params(time, memory uint32, threads uint8) (restore func()) {
+	oldTime, oldMemory, oldThreads := argon2Time, argon2Memory, argon2Threads
+	argon2Time, argon2Memory, argon2Threads = time, memory, threads
+	return func() {
+		argon2Time, argon2Memory, argon2Threads = oldTime, oldMemory, oldThreads
+	}
+}
+
+const EncSegmentSize = encSegmentSize
+
+// EncryptStream returns a writer encrypting into w with a new key derived
+// from secret, together with the parameters to derive it again.
+func EncryptStream(w io.Writer, secret []byte, entry string) (io.WriteCloser, *client.SnapshotEncryption, error) {
+	enc, key, err := newEncryption(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	ew, err := newEncryptWriter(w, key, entry)
+	return ew, enc, err
+}
+
+func DecryptStream(r io.Reader, enc *client.SnapshotEncryption, secret []byte, entry string) (io.Reader, error) {
+	key, err := unlockKey(enc, secret)
+	if err != nil {
+		return nil, err
+	}
+	return newDecryptReader(r, key, entry), nil
+}
+
 func MockIsTesting(newIsTesting bool) func() {
 	oldIsTesting := isTesting
 	isTesting = newIsTesting
@@ -36,44 +36,74 @@ import (
 	"github.com/snapcore/snapd/snap"
 )
 
-// zipMember returns an io.ReadCloser for the 'member' file in the 'f' zip file.
-func zipMember(f *os.File, member string) (r io.ReadCloser, sz int64, err error) {
+// findZipMember returns the 'member' file in the 'f' zip file, or nil if
+// there is no such member.
+func findZipMember(f *os.File, member string) (*zip.File, error) {
 	// rewind the file
 	// (shouldn't be needed, but doesn't hurt too much)
 	if _, err := f.Seek(0, 0); err != nil {
-		return nil, -1, err
+		return nil, err
 	}
 
 	fi, err := f.Stat()
 	if err != nil {
-		return nil, -1, err
+		return nil, err
 	}
 
 	arch, err := zip.NewReader(f, fi.Size())
 	if err != nil {
-		return nil, -1, err
+		return nil, err
 	}
 
 	for _, fh := range arch.File {
 		if fh.Name == member {
-			r, err = fh.Open()
-			return r, int64(fh.UncompressedSize64), err
+			return fh, nil
 		}
 	}
 
-	return nil, -1, fmt.Errorf("missing archive member %q", member)
+	return nil, nil
+}
+
+// zipMember returns an io.ReadCloser for the 'member' file in the 'f' zip file.
+func zipMember(f *os.File, member string) (r io.ReadCloser, sz int64, err error) {
+	fh, err := findZipMember(f, member)
+	if err != nil {
+		return nil, -1, err
+	}
+	if fh == nil {
+		return nil, -1, fmt.Errorf("missing archive member %q", member)
+	}
+
+	r, err = fh.Open()
+	return r, int64(fh.UncompressedSize64), err
 }
 
 func userArchiveName(usr *user.User) string {
 	return filepath.Join(userArchivePrefix, usr.Username+userArchiveSuffix)
 }
 
+func chunkedUserArchiveName(usr *user.User) string {
+	return filepath.Join(userArchivePrefix, usr.Username+chunkedArchiveSuffix)
+}
+
+func isSystemArchive(entry string) bool {
+	return entry == archiveName || entry == chunkedArchiveName
+}
+
 func isUserArchive(entry string) bool {
-	return strings.HasPrefix(entry, userArchivePrefix) && strings.HasSuffix(entry, userArchiveSuffix)
+	return strings.HasPrefix(entry, userArchivePrefix) &&
+		(strings.HasSuffix(entry, userArchiveSuffix) || strings.HasSuffix(entry, chunkedArchiveSuffix))
+}
+
+// isCompressedArchive returns whether the entry holds a gzipped tar, as
+// opposed to a plain one.
+func isCompressedArchive(entry string) bool {
+	return strings.HasSuffix(entry, userArchiveSuffix)
 }
 
 func entryUsername(entry string) string {
 	// this _will_ panic if !isUserArchive(entry)
+	// (both suffixes have the same length)
 	return entry[len(userArchivePrefix) : len(entry)-len(userArchiveSuffix)]
 }
 
@@ -20,7 +20,9 @@
 package backend
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto"
 	"errors"
@@ -30,6 +32,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"syscall"
 
 	"github.com/snapcore/snapd/client"
@@ -50,6 +53,17 @@ const ExtractFnameSetID = 0
 type Reader struct {
 	*os.File
 	client.Snapshot
+
+	// chunks is the chunk index of chunked snapshots
+	chunks *chunkIndex
+	// chunksDir is the chunk store holding the chunks of chunked
+	// snapshots, next to the snapshot file itself
+	chunksDir string
+	// key is the key to decrypt the archives of encrypted snapshots
+	key *snapshotKey
+	// meta is the metadata as read from the snapshot file, which is
+	// authenticated when unlocking encrypted snapshots
+	meta []byte
 }
 
 // Open a Snapshot given its full filename.
@@ -81,6 +95,7 @@ func Open(fn string, setID uint64) (reader *Reader, e error) {
 
 	// first try to load the metadata itself
 	var sz osutil.Sizer
+	var metaBuf bytes.Buffer
 	hasher := crypto.SHA3_384.New()
 	metaReader, metaSize, err := zipMember(f, metadataName)
 	if err != nil {
@@ -88,9 +103,10 @@ func Open(fn string, setID uint64) (reader *Reader, e error) {
 		return nil, err
 	}
 
-	if err := jsonutil.DecodeWithNumber(io.TeeReader(metaReader, io.MultiWriter(hasher, &sz)), &reader.Snapshot); err != nil {
+	if err := jsonutil.DecodeWithNumber(io.TeeReader(metaReader, io.MultiWriter(hasher, &sz, &metaBuf)), &reader.Snapshot); err != nil {
 		return nil, err
 	}
+	reader.meta = metaBuf.Bytes()
 
 	if setID == ExtractFnameSetID {
 		// set id from the filename has the authority and overrides the one from
@@ -139,11 +155,65 @@ func Open(fn string, setID uint64) (reader *Reader, e error) {
 		return reader, errors.New(reader.Broken)
 	}
 
+	reader.chunks, err = readChunkIndex(f)
+	if err != nil {
+		reader.Broken = err.Error()
+		return reader, err
+	}
+	reader.chunksDir = filepath.Join(filepath.Dir(fn), chunksDirName)
+
 	return reader, nil
 }
 
+// entryReader returns an io.ReadCloser for the data of the given entry,
+// either from the chunk store or from the snapshot file itself.
+func (r *Reader) entryReader(entry string) (rc io.ReadCloser, sz int64, err error) {
+	if r.chunks != nil {
+		if refs, ok := r.chunks.Entries[entry]; ok {
+			return &chunkReader{dir: r.chunksDir, refs: refs}, r.chunks.entrySize(entry), nil
+		}
+	}
+	return zipMember(r.File, entry)
+}
+
+// Unlock derives the key to decrypt the archives of an encrypted snapshot
+// from the given secret (the passphrase or contents of the key file it
+// was saved with). It returns ErrWrongKey if it's not the right secret.
+// The metadata of the snapshot is authenticated with the key, and the
+// configuration of the snap decrypted into Conf.
+func (r *Reader) Unlock(secret []byte) error {
+	if r.Encryption == nil {
+		return fmt.Errorf("snapshot %q is not encrypted", r.Name())
+	}
+	key, err := unlockKey(r.Encryption, secret)
+	if err != nil {
+		return err
+	}
+	macReader, _, err := zipMember(r.File, metaMACName)
+	if err != nil {
+		return fmt.Errorf("cannot authenticate metadata of snapshot %q: %v", r.Name(), err)
+	}
+	defer macReader.Close()
+	macBuf, err := io.ReadAll(io.LimitReader(macReader, 1024))
+	if err != nil {
+		return fmt.Errorf("cannot authenticate metadata of snapshot %q: %v", r.Name(), err)
+	}
+	if !key.checkMetaMAC(r.meta, string(bytes.TrimSpace(macBuf))) {
+		return fmt.Errorf("cannot authenticate metadata of snapshot %q: MAC does not match", r.Name())
+	}
+	if r.Encryption.Conf != nil {
+		conf, err := key.openConf(r.Encryption.Conf)
+		if err != nil {
+			return fmt.Errorf("cannot decrypt configuration of snapshot %q: %v", r.Name(), err)
+		}
+		r.Conf = conf
+	}
+	r.key = key
+	return nil
+}
+
 func (r *Reader) checkOne(ctx context.Context, entry string, hasher hash.Hash) error {
-	body, reportedSize, err := zipMember(r.File, entry)
+	body, reportedSize, err := r.entryReader(entry)
 	if err != nil {
 		return err
 	}
@@ -191,12 +261,132 @@ func (r *Reader) Check(ctx context.Context, usernames []string) error {
 // Logf is the type implemented by logging functions.
 type Logf func(format string, args ...interface{})
 
+// ValidateRestorePath checks that the given path, relative to the data
+// directory of a snap as listed by Files, is one that can be restored on
+// its own from a snapshot.
+func ValidateRestorePath(path string) error {
+	if path == "" || filepath.IsAbs(path) || filepath.Clean(path) != path || path == ".." || strings.HasPrefix(path, "../") {
+		return fmt.Errorf("invalid path %q: must be a clean path relative to the snap data directory", path)
+	}
+	if top := strings.SplitN(path, "/", 2)[0]; top != "common" {
+		if _, err := snap.ParseRevision(top); err != nil {
+			return fmt.Errorf("invalid path %q: must be in \"common\" or a revision directory", path)
+		}
+	}
+	return nil
+}
+
+// Files returns the files and directories in the archives of the snapshot,
+// for the system data and that of the given users (or of all of them).
+// Encrypted snapshots need to be unlocked first.
+func (r *Reader) Files(ctx context.Context, usernames []string) ([]client.SnapshotFile, error) {
+	return r.files(ctx, usernames, false)
+}
+
+// HashedFiles is like Files, but also hashes the contents of the regular
+// files, which means reading the archives in full.
+func (r *Reader) HashedFiles(ctx context.Context, usernames []string) ([]client.SnapshotFile, error) {
+	return r.files(ctx, usernames, true)
+}
+
+func (r *Reader) files(ctx context.Context, usernames []string, hashed bool) ([]client.SnapshotFile, error) {
+	if r.Encryption != nil && r.key == nil {
+		return nil, fmt.Errorf("cannot list files of encrypted snapshot %q without its key", r.Name())
+	}
+
+	sort.Strings(usernames)
+	entries := make([]string, 0, len(r.SHA3_384))
+	for entry := range r.SHA3_384 {
+		if isUserArchive(entry) {
+			if len(usernames) > 0 && !strutil.SortedListContains(usernames, entryUsername(entry)) {
+				continue
+			}
+		} else if !isSystemArchive(entry) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	// the system archive sorts before the user ones
+	sort.Strings(entries)
+
+	var files []client.SnapshotFile
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		entryFiles, err := r.entryFiles(entry, hashed)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, entryFiles...)
+	}
+	return files, nil
+}
+
+func (r *Reader) entryFiles(entry string, hashed bool) ([]client.SnapshotFile, error) {
+	body, _, err := r.entryReader(entry)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var rd io.Reader = body
+	if r.key != nil {
+		rd = newDecryptReader(rd, r.key, entry)
+	}
+	if isCompressedArchive(entry) {
+		gz, err := gzip.NewReader(rd)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read snapshot entry %q: %v", entry, err)
+		}
+		defer gz.Close()
+		rd = gz
+	}
+
+	var username string
+	if isUserArchive(entry) {
+		username = entryUsername(entry)
+	}
+	var files []client.SnapshotFile
+	// only reading the headers and contents, so archive/tar is fine here
+	tr := tar.NewReader(rd)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read snapshot entry %q: %v", entry, err)
+		}
+		file := client.SnapshotFile{
+			User:    username,
+			Path:    strings.TrimSuffix(strings.TrimPrefix(hdr.Name, "./"), "/"),
+			Size:    hdr.Size,
+			Mode:    hdr.FileInfo().Mode(),
+			ModTime: hdr.ModTime,
+			Link:    hdr.Linkname,
+		}
+		if hashed && file.Mode.IsRegular() {
+			hasher := crypto.SHA3_384.New()
+			if _, err := io.Copy(hasher, tr); err != nil {
+				return nil, fmt.Errorf("cannot read snapshot entry %q: %v", entry, err)
+			}
+			file.SHA3_384 = fmt.Sprintf("%x", hasher.Sum(nil))
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
 // Restore the data from the snapshot.
 //
 // If successful this will replace the existing data (for the given revision,
 // or the one in the snapshot) with that contained in the snapshot. It keeps
 // track of the old data in the task so it can be undone (or cleaned up).
-func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames []string, logf Logf, opts *dirs.SnapDirOptions) (rs *RestoreState, e error) {
+//
+// If paths are given, only those files or directories are replaced (see
+// ValidateRestorePath); it's an error if any of them isn't in the snapshot.
+func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames []string, paths []string, logf Logf, opts *dirs.SnapDirOptions) (rs *RestoreState, e error) {
 	rs = &RestoreState{}
 	defer func() {
 		if e != nil {
@@ -206,6 +396,18 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 		}
 	}()
 
+	if r.Encryption != nil && r.key == nil {
+		return rs, fmt.Errorf("cannot restore encrypted snapshot %q without its key", r.Name())
+	}
+
+	for _, path := range paths {
+		if err := ValidateRestorePath(path); err != nil {
+			return rs, err
+		}
+	}
+	paths = withoutNestedPaths(paths)
+	restored := make(map[string]bool, len(paths))
+
 	sort.Strings(usernames)
 	isRoot := sys.Geteuid() == 0
 	si := snap.MinimalPlaceInfo(r.Snap, r.Revision)
@@ -229,7 +431,7 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 		gid := sys.GroupID(osutil.NoChown)
 
 		if !isUser {
-			if entry != archiveName {
+			if !isSystemArchive(entry) {
 				// hmmm
 				logf("Skipping restore of unknown entry %q.", entry)
 				continue
@@ -310,22 +512,33 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 
 		logger.Debugf("Restoring %q from %q into %q.", entry, r.Name(), tempdir)
 
-		body, expectedSize, err := zipMember(r.File, entry)
+		body, expectedSize, err := r.entryReader(entry)
 		if err != nil {
 			return rs, err
 		}
 
 		expectedHash := r.SHA3_384[entry]
 
-		tr := io.TeeReader(body, io.MultiWriter(hasher, &sz))
+		// the hash of encrypted archives is that of the encrypted data
+		var tr io.Reader = io.TeeReader(body, io.MultiWriter(hasher, &sz))
+		var dr *decryptReader
+		if r.key != nil {
+			dr = newDecryptReader(tr, r.key, entry)
+			tr = dr
+		}
 
 		// resist the temptation of using archive/tar unless it's proven
 		// that calling out to tar has issues -- there are a lot of
 		// special cases we'd need to consider otherwise
-		cmd := tarAsUser(username,
+		tarArgs := []string{
 			"--extract",
-			"--preserve-permissions", "--preserve-order", "--gunzip",
-			"--directory", tempdir)
+			"--preserve-permissions", "--preserve-order",
+			"--directory", tempdir,
+		}
+		if isCompressedArchive(entry) {
+			tarArgs = append(tarArgs, "--gunzip")
+		}
+		cmd := tarAsUser(username, tarArgs...)
 		cmd.Env = []string{}
 		cmd.Stdin = tr
 		matchCounter := &strutil.MatchCounter{N: 1}
@@ -336,7 +549,14 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 			cmd.Stderr = io.MultiWriter(os.Stderr, matchCounter)
 		}
 
-		if err = osutil.RunWithContext(ctx, cmd); err != nil {
+		err = osutil.RunWithContext(ctx, cmd)
+		// done with the entry, don't keep its chunks open until all
+		// of them are restored
+		body.Close()
+		if err != nil {
+			if dr != nil && dr.err != nil {
+				return rs, dr.err
+			}
 			matches, count := matchCounter.Matches()
 			if count > 0 {
 				return rs, fmt.Errorf("cannot unpack archive: %s (and %d more)", matches[0], count-1)
@@ -363,19 +583,119 @@ func (r *Reader) Restore(ctx context.Context, current snap.Revision, usernames [
 			revdir = curdir
 		}
 
-		for _, dir := range []string{"common", revdir} {
-			if err := moveFile(rs, dir, tempdir, parent); err != nil {
+		if len(paths) == 0 {
+			for _, dir := range []string{"common", revdir} {
+				if err := moveFile(rs, dir, tempdir, parent); err != nil {
+					return rs, err
+				}
+			}
+		}
+		for _, path := range paths {
+			top, rest := path, ""
+			if idx := strings.IndexByte(path, '/'); idx >= 0 {
+				top, rest = path[:idx], path[idx+1:]
+			}
+			if top != "common" {
+				if top != r.Revision.String() {
+					// not in this snapshot
+					continue
+				}
+				// the revision directory might have been renamed above
+				top = revdir
+			}
+			found, err := restorePath(rs, filepath.Join(top, rest), tempdir, parent, uid, gid)
+			if err != nil {
 				return rs, err
 			}
+			if found {
+				restored[path] = true
+			}
 		}
 
 		sz.Reset()
 		hasher.Reset()
 	}
 
+	for _, path := range paths {
+		if !restored[path] {
+			return rs, fmt.Errorf("cannot restore %q: not found in snapshot %q", path, r.Name())
+		}
+	}
+
 	return rs, nil
 }
 
+// withoutNestedPaths returns the given paths without duplicates nor those
+// inside another one, as restoring the outer one takes care of them.
+func withoutNestedPaths(paths []string) []string {
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	var out []string
+	for _, path := range sorted {
+		if n := len(out); n > 0 && (path == out[n-1] || strings.HasPrefix(path, out[n-1]+"/")) {
+			continue
+		}
+		out = append(out, path)
+	}
+	return out
+}
+
+// restorePath moves the file or directory at path from sourceDir to
+// targetDir, creating the missing parent directories as in sourceDir. It
+// returns false if there is no such path in sourceDir. Everything moved and
+// created is registered in the RestoreState.
+func restorePath(rs *RestoreState, path, sourceDir, targetDir string, uid sys.UserID, gid sys.GroupID) (found bool, err error) {
+	if _, err := os.Lstat(filepath.Join(sourceDir, path)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	components := strings.Split(filepath.Dir(path), "/")
+	for i := range components {
+		dir := filepath.Join(components[:i+1]...)
+		if dir == "." {
+			break
+		}
+		dst := filepath.Join(targetDir, dir)
+		// this runs as root on directories owned by the user, so symlinks
+		// must not be followed, lest files be restored outside of them
+		fi, err := os.Lstat(dst)
+		if err == nil {
+			if fi.Mode()&os.ModeSymlink != 0 {
+				return false, fmt.Errorf("cannot restore %q: %q is a symbolic link", path, dst)
+			}
+			if !fi.IsDir() {
+				return false, fmt.Errorf("cannot restore %q: %q is not a directory", path, dst)
+			}
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return false, err
+		}
+		fi, err = os.Lstat(filepath.Join(sourceDir, dir))
+		if err != nil {
+			return false, err
+		}
+		if !fi.IsDir() {
+			return false, fmt.Errorf("cannot restore %q: %q in the snapshot is not a directory", path, dir)
+		}
+		if err := os.Mkdir(dst, fi.Mode().Perm()); err != nil {
+			return false, err
+		}
+		// only the topmost directory needs to be removed on revert
+		if len(rs.Created) == 0 || !strings.HasPrefix(dst, rs.Created[len(rs.Created)-1]+"/") {
+			rs.Created = append(rs.Created, dst)
+		}
+		if err := sys.ChownPath(dst, uid, gid); err != nil {
+			return false, err
+		}
+	}
+
+	return true, moveFile(rs, path, sourceDir, targetDir)
+}
+
 // moveFile moves file from the sourceDir to the targetDir. Directories moved
 // and created are registered in the RestoreState.
 func moveFile(rs *RestoreState, file, sourceDir, targetDir string) error {
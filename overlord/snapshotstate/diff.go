@@ -0,0 +1,281 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/strutil"
+)
+
+// diffSetup is the setup of a diff-snapshot task.
+type diffSetup struct {
+	SetID      uint64   `json:"set-id"`
+	OtherSetID uint64   `json:"other-set-id"`
+	Snaps      []string `json:"snaps,omitempty"`
+	Encrypted  bool     `json:"encrypted,omitempty"`
+}
+
+// Diff creates a taskset for comparing the data of the given snaps (or of
+// all of them) in the snapshot set with that in the other one. Comparing
+// needs to read all of the archives, so it is done by a task; the
+// differences are then found in the "diffs" entry of the "api-data" of
+// its change.
+// The secret is needed to compare encrypted snapshots, and is used for
+// those of both sets.
+// Note that the state must be locked by the caller.
+func Diff(st *state.State, setID, otherSetID uint64, snapNames []string, secret []byte) (snapsFound []string, ts *state.TaskSet, err error) {
+	// diff needs to conflict with forget of either set
+	for _, id := range []uint64{setID, otherSetID} {
+		if err := checkSnapshotConflict(st, id, "forget-snapshot"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var found, encrypted bool
+	for _, id := range []uint64{setID, otherSetID} {
+		summaries, err := snapSummariesInSnapshotSet(id, snapNames)
+		if err == client.ErrSnapshotSnapsNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		found = true
+		for _, summary := range summaries {
+			if summary.encrypted {
+				encrypted = true
+			}
+			if !strutil.ListContains(snapsFound, summary.snap) {
+				snapsFound = append(snapsFound, summary.snap)
+			}
+		}
+	}
+	if !found {
+		return nil, nil, client.ErrSnapshotSnapsNotFound
+	}
+	if encrypted && secret == nil {
+		return nil, nil, client.ErrSnapshotEncrypted
+	}
+	sort.Strings(snapsFound)
+
+	desc := fmt.Sprintf("Compare data of snapshot set #%d with snapshot set #%d", setID, otherSetID)
+	task := st.NewTask("diff-snapshot", desc)
+	task.Set("diff-setup", &diffSetup{
+		SetID:      setID,
+		OtherSetID: otherSetID,
+		Snaps:      snapNames,
+		Encrypted:  encrypted,
+	})
+	if encrypted {
+		cacheSnapshotSecret(task, secret)
+	}
+
+	return snapsFound, state.NewTaskSet(task), nil
+}
+
+// diffSnapshots compares the configuration and the files in the archives
+// of the given snaps (or of all of them) in the snapshot set with those
+// in the other one. Snaps in only one of the sets are compared as if they
+// had no configuration nor files in the other. If the snapshots are of
+// different revisions, the files in the revision directories are compared
+// as if they were in the directory of the revision of the other set.
+func diffSnapshots(ctx context.Context, setID, otherSetID uint64, snapNames []string, secret []byte) ([]client.SnapshotDiff, error) {
+	var found bool
+	filenames := make(map[string][2]string)
+	for i, id := range []uint64{setID, otherSetID} {
+		summaries, err := snapSummariesInSnapshotSet(id, snapNames)
+		if err == client.ErrSnapshotSnapsNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, summary := range summaries {
+			fns := filenames[summary.snap]
+			fns[i] = summary.filename
+			filenames[summary.snap] = fns
+		}
+	}
+	if !found {
+		return nil, client.ErrSnapshotSnapsNotFound
+	}
+
+	names := make([]string, 0, len(filenames))
+	for name := range filenames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	diffs := make([]client.SnapshotDiff, 0, len(names))
+	for _, name := range names {
+		var confs [2]map[string]interface{}
+		var files [2][]client.SnapshotFile
+		var revs [2]snap.Revision
+		for i, id := range []uint64{setID, otherSetID} {
+			fn := filenames[name][i]
+			if fn == "" {
+				continue
+			}
+			var err error
+			confs[i], files[i], revs[i], err = snapshotContents(ctx, fn, id, secret)
+			if err != nil {
+				return nil, fmt.Errorf("cannot compare snapshots of snap %q: %v", name, err)
+			}
+		}
+		if !revs[0].Unset() && !revs[1].Unset() && revs[0] != revs[1] {
+			// compare the data of the revisions, as a restore would
+			// also move it from one to the other
+			renameRevisionDir(files[0], revs[0].String(), revs[1].String())
+		}
+		diffs = append(diffs, client.SnapshotDiff{
+			Snap:   name,
+			Config: diffConfig(confs[0], confs[1]),
+			Files:  diffFiles(files[0], files[1]),
+		})
+	}
+	return diffs, nil
+}
+
+func snapshotContents(ctx context.Context, filename string, setID uint64, secret []byte) (map[string]interface{}, []client.SnapshotFile, snap.Revision, error) {
+	reader, err := backendOpen(filename, setID)
+	if err != nil {
+		return nil, nil, snap.Revision{}, err
+	}
+	defer reader.Close()
+
+	if reader.Encryption != nil {
+		if secret == nil {
+			return nil, nil, snap.Revision{}, client.ErrSnapshotEncrypted
+		}
+		if err := backendUnlock(reader, secret); err != nil {
+			return nil, nil, snap.Revision{}, err
+		}
+	}
+
+	files, err := backendHashedFiles(reader, ctx, nil)
+	if err != nil {
+		return nil, nil, snap.Revision{}, err
+	}
+	return reader.Conf, files, reader.Revision, nil
+}
+
+// renameRevisionDir changes the paths of the files in the from revision
+// directory to be in the to one instead.
+func renameRevisionDir(files []client.SnapshotFile, from, to string) {
+	for i := range files {
+		if files[i].Path == from || strings.HasPrefix(files[i].Path, from+"/") {
+			files[i].Path = to + files[i].Path[len(from):]
+		}
+	}
+}
+
+// flattenConfig adds the leaves of the given configuration to flat, with
+// their dotted keys.
+func flattenConfig(prefix string, conf map[string]interface{}, flat map[string]interface{}) {
+	for k, v := range conf {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]interface{}); ok && len(sub) > 0 {
+			flattenConfig(k, sub, flat)
+			continue
+		}
+		flat[k] = v
+	}
+}
+
+func diffConfig(old, new map[string]interface{}) []client.SnapshotConfigChange {
+	oldFlat := make(map[string]interface{})
+	flattenConfig("", old, oldFlat)
+	newFlat := make(map[string]interface{})
+	flattenConfig("", new, newFlat)
+
+	var changes []client.SnapshotConfigChange
+	for k, o := range oldFlat {
+		n, ok := newFlat[k]
+		switch {
+		case !ok:
+			changes = append(changes, client.SnapshotConfigChange{Key: k, Change: client.SnapshotRemoved, Old: o})
+		case !reflect.DeepEqual(o, n):
+			changes = append(changes, client.SnapshotConfigChange{Key: k, Change: client.SnapshotModified, Old: o, New: n})
+		}
+	}
+	for k, n := range newFlat {
+		if _, ok := oldFlat[k]; !ok {
+			changes = append(changes, client.SnapshotConfigChange{Key: k, Change: client.SnapshotAdded, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+type snapshotFileKey struct {
+	user string
+	path string
+}
+
+// fileModified returns whether the file changed in a way that matters for
+// its data; times alone don't.
+func fileModified(old, new *client.SnapshotFile) bool {
+	return old.Mode != new.Mode || old.Link != new.Link || old.Size != new.Size || old.SHA3_384 != new.SHA3_384
+}
+
+func diffFiles(old, new []client.SnapshotFile) []client.SnapshotFileChange {
+	oldFiles := make(map[snapshotFileKey]*client.SnapshotFile, len(old))
+	for i := range old {
+		oldFiles[snapshotFileKey{old[i].User, old[i].Path}] = &old[i]
+	}
+	newFiles := make(map[snapshotFileKey]*client.SnapshotFile, len(new))
+	for i := range new {
+		newFiles[snapshotFileKey{new[i].User, new[i].Path}] = &new[i]
+	}
+
+	var changes []client.SnapshotFileChange
+	for k, o := range oldFiles {
+		n, ok := newFiles[k]
+		switch {
+		case !ok:
+			changes = append(changes, client.SnapshotFileChange{User: k.user, Path: k.path, Change: client.SnapshotRemoved, Old: o})
+		case fileModified(o, n):
+			changes = append(changes, client.SnapshotFileChange{User: k.user, Path: k.path, Change: client.SnapshotModified, Old: o, New: n})
+		}
+	}
+	for k, n := range newFiles {
+		if _, ok := oldFiles[k]; !ok {
+			changes = append(changes, client.SnapshotFileChange{User: k.user, Path: k.path, Change: client.SnapshotAdded, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].User != changes[j].User {
+			return changes[i].User < changes[j].User
+		}
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
@@ -0,0 +1,302 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+type diffSnapshot struct {
+	setID uint64
+	snap  string
+	rev   snap.Revision
+	conf  map[string]interface{}
+	files []client.SnapshotFile
+
+	encrypted bool
+}
+
+func (shot *diffSnapshot) snapshot() client.Snapshot {
+	snapshot := client.Snapshot{SetID: shot.setID, Snap: shot.snap, Revision: shot.rev, Conf: shot.conf}
+	if shot.encrypted {
+		snapshot.Encryption = &client.SnapshotEncryption{Cipher: "aes-256-gcm-stream"}
+	}
+	return snapshot
+}
+
+// mockDiffBackend mocks a backend with the given snapshots
+func (s *snapshotSuite) mockDiffBackend(c *check.C, shots ...*diffSnapshot) {
+	dir := c.MkDir()
+	byName := make(map[string]*diffSnapshot)
+	var readers []*backend.Reader
+	for _, shot := range shots {
+		fn := filepath.Join(dir, fmt.Sprintf("%d_%s.zip", shot.setID, shot.snap))
+		f, err := os.Create(fn)
+		c.Assert(err, check.IsNil)
+		s.AddCleanup(func() { f.Close() })
+		byName[fn] = shot
+		readers = append(readers, &backend.Reader{
+			Snapshot: shot.snapshot(),
+			File:     f,
+		})
+	}
+
+	s.AddCleanup(snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		for _, r := range readers {
+			if err := f(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	s.AddCleanup(snapshotstate.MockBackendOpen(func(fn string, setID uint64) (*backend.Reader, error) {
+		shot := byName[fn]
+		c.Assert(shot, check.NotNil)
+		c.Check(setID, check.Equals, shot.setID)
+		return &backend.Reader{Snapshot: shot.snapshot()}, nil
+	}))
+	s.AddCleanup(snapshotstate.MockBackendHashedFiles(func(r *backend.Reader, _ context.Context, users []string) ([]client.SnapshotFile, error) {
+		c.Check(users, check.IsNil)
+		for _, shot := range shots {
+			if shot.setID == r.SetID && shot.snap == r.Snap {
+				// the diff may change the paths
+				return append([]client.SnapshotFile(nil), shot.files...), nil
+			}
+		}
+		return nil, errors.New("no such snapshot")
+	}))
+}
+
+func (s *snapshotSuite) TestDiffSnapshots(c *check.C) {
+	s.mockDiffBackend(c, &diffSnapshot{
+		setID: 1,
+		snap:  "a-snap",
+		rev:   snap.R(1),
+		conf: map[string]interface{}{
+			"same":    "value",
+			"changed": 1.,
+			"gone":    true,
+			"nested":  map[string]interface{}{"same": 1., "changed": "x", "gone": "y"},
+		},
+		files: []client.SnapshotFile{
+			{Path: "1", Mode: os.ModeDir | 0755},
+			{Path: "1/same", Mode: 0644, Size: 3, SHA3_384: "aaa"},
+			{Path: "1/touched", Mode: 0644, Size: 3, SHA3_384: "bbb"},
+			{Path: "common/changed", Mode: 0644, Size: 3, SHA3_384: "ccc"},
+			{Path: "common/chmod", Mode: 0644, Size: 3, SHA3_384: "ddd"},
+			{Path: "common/gone", Mode: 0644, Size: 3, SHA3_384: "eee"},
+			{User: "a-user", Path: "common/link", Mode: os.ModeSymlink | 0777, Link: "old"},
+		},
+	}, &diffSnapshot{
+		setID: 2,
+		snap:  "a-snap",
+		rev:   snap.R(2),
+		conf: map[string]interface{}{
+			"same":    "value",
+			"changed": 2.,
+			"new":     []interface{}{"a"},
+			"nested":  map[string]interface{}{"same": 1., "changed": "z", "new": "w"},
+		},
+		files: []client.SnapshotFile{
+			{Path: "2", Mode: os.ModeDir | 0755},
+			{Path: "2/same", Mode: 0644, Size: 3, SHA3_384: "aaa"},
+			// only the time changed
+			{Path: "2/touched", Mode: 0644, Size: 3, SHA3_384: "bbb", ModTime: time.Now()},
+			{Path: "common/changed", Mode: 0644, Size: 3, SHA3_384: "fff"},
+			{Path: "common/chmod", Mode: 0600, Size: 3, SHA3_384: "ddd"},
+			{Path: "common/new", Mode: 0644, Size: 3, SHA3_384: "ggg"},
+			{User: "a-user", Path: "common/link", Mode: os.ModeSymlink | 0777, Link: "new"},
+		},
+	}, &diffSnapshot{
+		setID: 2,
+		snap:  "b-snap",
+		rev:   snap.R(7),
+		files: []client.SnapshotFile{{Path: "common", Mode: os.ModeDir | 0755}},
+	})
+
+	diffs, err := snapshotstate.DiffSnapshots(context.TODO(), 1, 2, nil, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(diffs, check.HasLen, 2)
+
+	c.Check(diffs[0].Snap, check.Equals, "a-snap")
+	c.Check(diffs[0].Config, check.DeepEquals, []client.SnapshotConfigChange{
+		{Key: "changed", Change: client.SnapshotModified, Old: 1., New: 2.},
+		{Key: "gone", Change: client.SnapshotRemoved, Old: true},
+		{Key: "nested.changed", Change: client.SnapshotModified, Old: "x", New: "z"},
+		{Key: "nested.gone", Change: client.SnapshotRemoved, Old: "y"},
+		{Key: "nested.new", Change: client.SnapshotAdded, New: "w"},
+		{Key: "new", Change: client.SnapshotAdded, New: []interface{}{"a"}},
+	})
+	type change struct{ user, path, change string }
+	var changes []change
+	for _, ch := range diffs[0].Files {
+		changes = append(changes, change{ch.User, ch.Path, ch.Change})
+	}
+	c.Check(changes, check.DeepEquals, []change{
+		{"", "common/changed", client.SnapshotModified},
+		{"", "common/chmod", client.SnapshotModified},
+		{"", "common/gone", client.SnapshotRemoved},
+		{"", "common/new", client.SnapshotAdded},
+		{"a-user", "common/link", client.SnapshotModified},
+	})
+	c.Check(diffs[0].Files[0].Old.SHA3_384, check.Equals, "ccc")
+	c.Check(diffs[0].Files[0].New.SHA3_384, check.Equals, "fff")
+
+	// only in the second set
+	c.Check(diffs[1], check.DeepEquals, client.SnapshotDiff{
+		Snap: "b-snap",
+		Files: []client.SnapshotFileChange{{
+			Path:   "common",
+			Change: client.SnapshotAdded,
+			New:    &client.SnapshotFile{Path: "common", Mode: os.ModeDir | 0755},
+		}},
+	})
+
+	// and the other way around
+	diffs, err = snapshotstate.DiffSnapshots(context.TODO(), 2, 1, []string{"b-snap"}, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(diffs, check.HasLen, 1)
+	c.Check(diffs[0].Files, check.HasLen, 1)
+	c.Check(diffs[0].Files[0].Change, check.Equals, client.SnapshotRemoved)
+}
+
+func (s *snapshotSuite) TestDiffSnapshotsErrors(c *check.C) {
+	s.mockDiffBackend(c, &diffSnapshot{setID: 1, snap: "a-snap"}, &diffSnapshot{setID: 2, snap: "a-snap"})
+
+	_, err := snapshotstate.DiffSnapshots(context.TODO(), 1, 3, nil, nil)
+	c.Check(err, check.Equals, client.ErrSnapshotSetNotFound)
+	_, err = snapshotstate.DiffSnapshots(context.TODO(), 1, 2, []string{"b-snap"}, nil)
+	c.Check(err, check.Equals, client.ErrSnapshotSnapsNotFound)
+
+	s.AddCleanup(snapshotstate.MockBackendHashedFiles(func(*backend.Reader, context.Context, []string) ([]client.SnapshotFile, error) {
+		return nil, errors.New("boom")
+	}))
+	_, err = snapshotstate.DiffSnapshots(context.TODO(), 1, 2, nil, nil)
+	c.Check(err, check.ErrorMatches, `cannot compare snapshots of snap "a-snap": boom`)
+}
+
+func (s *snapshotSuite) TestDiffSnapshotsEncrypted(c *check.C) {
+	s.mockDiffBackend(c, &diffSnapshot{setID: 1, snap: "a-snap", encrypted: true}, &diffSnapshot{setID: 2, snap: "a-snap"})
+	var unlocked []uint64
+	unlockErr := backend.ErrWrongKey
+	s.AddCleanup(snapshotstate.MockBackendUnlock(func(r *backend.Reader, secret []byte) error {
+		c.Check(secret, check.DeepEquals, []byte("s3cr3t"))
+		unlocked = append(unlocked, r.SetID)
+		return unlockErr
+	}))
+
+	_, err := snapshotstate.DiffSnapshots(context.TODO(), 1, 2, nil, nil)
+	c.Check(err, check.ErrorMatches, `cannot compare snapshots of snap "a-snap": snapshot is encrypted, a passphrase or key file is needed`)
+	_, err = snapshotstate.DiffSnapshots(context.TODO(), 1, 2, nil, []byte("s3cr3t"))
+	c.Check(err, check.ErrorMatches, `cannot compare snapshots of snap "a-snap": wrong passphrase or key for encrypted snapshot`)
+
+	unlockErr = nil
+	unlocked = nil
+	diffs, err := snapshotstate.DiffSnapshots(context.TODO(), 1, 2, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	c.Check(diffs, check.HasLen, 1)
+	// only the encrypted snapshot was unlocked
+	c.Check(unlocked, check.DeepEquals, []uint64{1})
+}
+
+func (s *snapshotSuite) TestDiff(c *check.C) {
+	s.mockDiffBackend(c, &diffSnapshot{setID: 1, snap: "a-snap", encrypted: true}, &diffSnapshot{setID: 2, snap: "b-snap"})
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, err := snapshotstate.Diff(st, 1, 2, nil, nil)
+	c.Check(err, check.Equals, client.ErrSnapshotEncrypted)
+	_, _, err = snapshotstate.Diff(st, 1, 3, nil, []byte("s3cr3t"))
+	c.Check(err, check.Equals, client.ErrSnapshotSetNotFound)
+	_, _, err = snapshotstate.Diff(st, 1, 2, []string{"c-snap"}, []byte("s3cr3t"))
+	c.Check(err, check.Equals, client.ErrSnapshotSnapsNotFound)
+
+	snapsFound, ts, err := snapshotstate.Diff(st, 1, 2, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	c.Check(snapsFound, check.DeepEquals, []string{"a-snap", "b-snap"})
+	tasks := ts.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "diff-snapshot")
+	var setup map[string]interface{}
+	c.Assert(tasks[0].Get("diff-setup", &setup), check.IsNil)
+	c.Check(setup, check.DeepEquals, map[string]interface{}{
+		"set-id":       1.,
+		"other-set-id": 2.,
+		"encrypted":    true,
+	})
+	secret, err := snapshotstate.SnapshotSecret(tasks[0], 1)
+	c.Assert(err, check.IsNil)
+	c.Check(secret, check.DeepEquals, []byte("s3cr3t"))
+
+	// forgetting either set conflicts with the diff
+	chg := st.NewChange("diff-snapshot", "...")
+	chg.AddAll(ts)
+	for _, id := range []uint64{1, 2} {
+		_, _, err = snapshotstate.Forget(st, id, nil)
+		c.Check(err, check.ErrorMatches, fmt.Sprintf(`cannot operate on snapshot set #%d while change "%s" is in progress`, id, chg.ID()))
+	}
+}
+
+func (s *snapshotSuite) TestDoDiff(c *check.C) {
+	s.mockDiffBackend(c, &diffSnapshot{
+		setID: 1,
+		snap:  "a-snap",
+		conf:  map[string]interface{}{"a": "b"},
+	}, &diffSnapshot{setID: 2, snap: "a-snap"})
+	st := state.New(nil)
+	st.Lock()
+	_, ts, err := snapshotstate.Diff(st, 1, 2, nil, nil)
+	c.Assert(err, check.IsNil)
+	chg := st.NewChange("diff-snapshot", "...")
+	chg.AddAll(ts)
+	chg.Set("api-data", map[string]interface{}{"snap-names": []string{"a-snap"}})
+	task := ts.Tasks()[0]
+	st.Unlock()
+
+	c.Assert(snapshotstate.DoDiff(task, &tomb.Tomb{}), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	var data struct {
+		SnapNames []string              `json:"snap-names"`
+		Diffs     []client.SnapshotDiff `json:"diffs"`
+	}
+	c.Assert(chg.Get("api-data", &data), check.IsNil)
+	c.Check(data.SnapNames, check.DeepEquals, []string{"a-snap"})
+	c.Check(data.Diffs, check.DeepEquals, []client.SnapshotDiff{{
+		Snap:   "a-snap",
+		Config: []client.SnapshotConfigChange{{Key: "a", Change: client.SnapshotRemoved, Old: "b"}},
+	}})
+}
@@ -43,11 +43,21 @@ var (
 	DoRestore                  = doRestore
 	UndoRestore                = undoRestore
 	CleanupRestore             = cleanupRestore
+	RequestGarbageCollection   = requestGarbageCollection
 	DoCheck                    = doCheck
+	DoDiff                     = doDiff
+	DiffSnapshots              = diffSnapshots
 	DoForget                   = doForget
 	SaveExpiration             = saveExpiration
 	ExpiredSnapshotSets        = expiredSnapshotSets
 	RemoveSnapshotState        = removeSnapshotState
+	CacheSnapshotSecret        = cacheSnapshotSecret
+	SnapshotSecret             = snapshotSecret
+	SnapshotSchedules          = snapshotSchedules
+	AddScheduledSnapshot       = addScheduledSnapshot
+	ProcessScheduledSnapshot   = processScheduledSnapshot
+
+	ScheduledSnapshotRetention = scheduledSnapshotRetention
 
 	SetSnapshotOpInProgress = setSnapshotOpInProgress
 
@@ -123,7 +133,7 @@ func MockBackendList(f func(ctx context.Context, setID uint64, snapNames []strin
 	}
 }
 
-func MockBackendRestore(f func(*backend.Reader, context.Context, snap.Revision, []string, backend.Logf, *dirs.SnapDirOptions) (*backend.RestoreState, error)) (restore func()) {
+func MockBackendRestore(f func(*backend.Reader, context.Context, snap.Revision, []string, []string, backend.Logf, *dirs.SnapDirOptions) (*backend.RestoreState, error)) (restore func()) {
 	old := backendRestore
 	backendRestore = f
 	return func() {
@@ -131,6 +141,14 @@ func MockBackendRestore(f func(*backend.Reader, context.Context, snap.Revision,
 	}
 }
 
+func MockBackendUnlock(f func(*backend.Reader, []byte) error) (restore func()) {
+	old := backendUnlock
+	backendUnlock = f
+	return func() {
+		backendUnlock = old
+	}
+}
+
 func MockBackendCheck(f func(*backend.Reader, context.Context, []string) error) (restore func()) {
 	old := backendCheck
 	backendCheck = f
@@ -139,6 +157,22 @@ func MockBackendCheck(f func(*backend.Reader, context.Context, []string) error)
 	}
 }
 
+func MockBackendFiles(f func(*backend.Reader, context.Context, []string) ([]client.SnapshotFile, error)) (restore func()) {
+	old := backendFiles
+	backendFiles = f
+	return func() {
+		backendFiles = old
+	}
+}
+
+func MockBackendHashedFiles(f func(*backend.Reader, context.Context, []string) ([]client.SnapshotFile, error)) (restore func()) {
+	old := backendHashedFiles
+	backendHashedFiles = f
+	return func() {
+		backendHashedFiles = old
+	}
+}
+
 func MockBackendRevert(f func(*backend.RestoreState)) (restore func()) {
 	old := backendRevert
 	backendRevert = f
@@ -171,6 +205,14 @@ func MockBackendCleanupAbandonedImports(f func() (int, error)) (restore func())
 	}
 }
 
+func MockBackendCollectGarbage(f func(context.Context) (int, error)) (restore func()) {
+	old := backendCollectGarbage
+	backendCollectGarbage = f
+	return func() {
+		backendCollectGarbage = old
+	}
+}
+
 func MockBackendEstimateSnapshotSize(f func(*snap.Info, []string, *dirs.SnapDirOptions) (uint64, error)) (restore func()) {
 	old := backendEstimateSnapshotSize
 	backendEstimateSnapshotSize = f
@@ -208,6 +250,25 @@ func SetLastForgetExpiredSnapshotTime(mgr *SnapshotManager, t time.Time) {
 	mgr.lastForgetExpiredSnapshotTime = t
 }
 
+func MockTimeNow(f func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = f
+	return func() {
+		timeNow = old
+	}
+}
+
+type SnapshotRetention = snapshotRetention
+
+// RetentionKeep returns the sets to keep out of the given ones, by set ID.
+func RetentionKeep(r SnapshotRetention, sets map[uint64]time.Time) map[uint64]bool {
+	scheduled := make([]scheduledSet, 0, len(sets))
+	for setID, t := range sets {
+		scheduled = append(scheduled, scheduledSet{setID: setID, time: t})
+	}
+	return r.keep(scheduled)
+}
+
 func MockGetSnapDirOptions(f func(*state.State, string) (*dirs.SnapDirOptions, error)) (restore func()) {
 	old := getSnapDirOpts
 	getSnapDirOpts = f
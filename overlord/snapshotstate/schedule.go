@@ -0,0 +1,385 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/strutil"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+var (
+	timeNow = time.Now
+
+	// upper bound for the time between two scheduled snapshots, in case
+	// the schedule has no window in that time
+	maxScheduledSnapshotPostponement = 60 * 24 * time.Hour
+
+	// retention policy for scheduled snapshots, for whatever is not set
+	// by the user
+	defaultSnapshotRetention = snapshotRetention{Hourly: 24, Daily: 7, Weekly: 4}
+)
+
+// snapshotScheduler takes snapshots of snaps according to the
+// snapshots.schedule and snapshots.snap-schedule.<snap> configuration.
+type snapshotScheduler struct {
+	state *state.State
+
+	// next snapshot time, by schedule
+	nextSnapshot map[string]time.Time
+}
+
+func newSnapshotScheduler(st *state.State) *snapshotScheduler {
+	return &snapshotScheduler{
+		state:        st,
+		nextSnapshot: make(map[string]time.Time),
+	}
+}
+
+// snapshotSchedules returns the active snaps that should have snapshots
+// taken, grouped by their schedule.
+func snapshotSchedules(st *state.State) (map[string][]string, error) {
+	tr := config.NewTransaction(st)
+	var globalSchedule string
+	if err := tr.Get("core", "snapshots.schedule", &globalSchedule); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	var snapSchedules map[string]string
+	if err := tr.Get("core", "snapshots.snap-schedule", &snapSchedules); err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+	if globalSchedule == "" && len(snapSchedules) == 0 {
+		return nil, nil
+	}
+
+	names, err := allActiveSnapNames(st)
+	if err != nil {
+		return nil, err
+	}
+	schedules := make(map[string][]string)
+	for _, name := range names {
+		schedule := globalSchedule
+		if snapSchedule, ok := snapSchedules[name]; ok && snapSchedule != "" {
+			schedule = snapSchedule
+		}
+		if schedule == "" || schedule == "none" {
+			continue
+		}
+		schedules[schedule] = append(schedules[schedule], name)
+	}
+	return schedules, nil
+}
+
+// Ensure takes the snapshots that are due according to their schedule.
+func (m *snapshotScheduler) Ensure() error {
+	m.state.Lock()
+	defer m.state.Unlock()
+
+	schedules, err := snapshotSchedules(m.state)
+	if err != nil {
+		return err
+	}
+
+	// the last scheduled snapshot, by schedule, which is the anchor for
+	// the next one
+	var lastSnapshot map[string]time.Time
+	if err := m.state.Get("last-scheduled-snapshots", &lastSnapshot); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+
+	now := timeNow()
+	// only write the anchors back if something changed
+	changed := len(lastSnapshot) != len(schedules)
+	newLastSnapshot := make(map[string]time.Time, len(schedules))
+	nextSnapshot := make(map[string]time.Time, len(schedules))
+	for scheduleStr, snaps := range schedules {
+		schedule, err := timeutil.ParseSchedule(scheduleStr)
+		if err != nil {
+			// validated when set, so unlikely
+			logger.Noticef("cannot use snapshot schedule %q: %v", scheduleStr, err)
+			continue
+		}
+
+		last, ok := lastSnapshot[scheduleStr]
+		if !ok {
+			// new schedule, the first snapshot is taken in its
+			// next window
+			last = now
+			changed = true
+		}
+		newLastSnapshot[scheduleStr] = last
+
+		next := m.nextSnapshot[scheduleStr]
+		if next.IsZero() {
+			next = now.Add(timeutil.Next(schedule, last, maxScheduledSnapshotPostponement))
+			logger.Debugf("Next snapshot of %s scheduled for %s.", strutil.Quoted(snaps), next.Format(time.RFC3339))
+		}
+		if next.After(now) {
+			nextSnapshot[scheduleStr] = next
+			continue
+		}
+
+		if err := launchScheduledSnapshot(m.state, snaps); err != nil {
+			var conflictErr *snapstate.ChangeConflictError
+			if errors.As(err, &conflictErr) {
+				// try again on the next Ensure
+				logger.Debugf("Postponing scheduled snapshot of %s: %v", strutil.Quoted(snaps), err)
+				nextSnapshot[scheduleStr] = next
+				continue
+			}
+			addScheduledSnapshotWarning(m.state, snaps, err)
+		}
+		newLastSnapshot[scheduleStr] = now
+		changed = true
+	}
+
+	if changed {
+		m.state.Set("last-scheduled-snapshots", newLastSnapshot)
+	}
+	m.nextSnapshot = nextSnapshot
+
+	return nil
+}
+
+// launchScheduledSnapshot creates a change taking a snapshot of the given
+// snaps.
+func launchScheduledSnapshot(st *state.State, snaps []string) error {
+	setID, saved, ts, err := save(st, snaps, nil, nil, nil, true)
+	if err != nil {
+		return err
+	}
+
+	// TRANSLATORS: the %s is a comma-separated list of quoted snap names
+	msg := fmt.Sprintf(i18n.G("Scheduled snapshot of snaps %s"), strutil.Quoted(saved))
+	chg := st.NewChange("scheduled-snapshot", msg)
+	chg.AddAll(ts)
+	chg.Set("snap-names", saved)
+	chg.Set("api-data", map[string]interface{}{"snap-names": saved, "set-id": setID})
+
+	st.EnsureBefore(0)
+	return nil
+}
+
+func addScheduledSnapshotWarning(st *state.State, snaps []string, err error) {
+	msg := fmt.Sprintf("cannot take scheduled snapshot of snaps %s: %v", strutil.Quoted(snaps), err)
+	logger.Noticef("%s", msg)
+	st.AddWarning(msg, &state.AddWarningOptions{RepeatAfter: 24 * time.Hour})
+}
+
+// processScheduledSnapshot warns about failed scheduled snapshots, and asks
+// for the retention policy to be applied once they are done.
+func processScheduledSnapshot(chg *state.Change, old, new state.Status) {
+	if chg.Kind() != "scheduled-snapshot" || !new.Ready() {
+		return
+	}
+	st := chg.State()
+	switch new {
+	case state.DoneStatus:
+		st.Cache("snapshot-retention", true)
+		st.EnsureBefore(0)
+	case state.ErrorStatus:
+		var snaps []string
+		if err := chg.Get("snap-names", &snaps); err != nil {
+			logger.Noticef("internal error: cannot get snap names of change %s: %v", chg.ID(), err)
+		}
+		addScheduledSnapshotWarning(st, snaps, fmt.Errorf("see 'snap change %s' for details", chg.ID()))
+	}
+}
+
+// snapshotRetention is the grandfather-father-son retention policy for
+// scheduled snapshots: for each snap, the latest snapshot of each of the
+// last Hourly hours, Daily days and Weekly weeks with snapshots is kept.
+type snapshotRetention struct {
+	Hourly int
+	Daily  int
+	Weekly int
+}
+
+func scheduledSnapshotRetention(st *state.State) (snapshotRetention, error) {
+	tr := config.NewTransaction(st)
+	retention := defaultSnapshotRetention
+	for _, period := range []struct {
+		name string
+		keep *int
+	}{
+		{"hourly", &retention.Hourly},
+		{"daily", &retention.Daily},
+		{"weekly", &retention.Weekly},
+	} {
+		option := "snapshots.retention." + period.name
+		var value interface{}
+		if err := tr.Get("core", option, &value); err != nil && !config.IsNoOption(err) {
+			return snapshotRetention{}, err
+		}
+		if value == nil {
+			continue
+		}
+		keep, err := strconv.ParseUint(fmt.Sprintf("%v", value), 10, 16)
+		if err != nil {
+			// validated when set, so unlikely
+			logger.Noticef("%s cannot be parsed: %v", option, err)
+			continue
+		}
+		*period.keep = int(keep)
+	}
+	return retention, nil
+}
+
+type scheduledSet struct {
+	setID uint64
+	time  time.Time
+}
+
+// keep returns the sets to keep, from the given sets of a single snap.
+func (r snapshotRetention) keep(sets []scheduledSet) map[uint64]bool {
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].time.After(sets[j].time)
+	})
+
+	keep := make(map[uint64]bool)
+	for _, period := range []struct {
+		keep   int
+		bucket func(time.Time) string
+	}{
+		{r.Hourly, func(t time.Time) string { return t.Format("2006-01-02 15") }},
+		{r.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{r.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-%d", year, week)
+		}},
+	} {
+		kept := 0
+		lastBucket := ""
+		for _, set := range sets {
+			if kept >= period.keep {
+				break
+			}
+			// sets are newest first, so the first one in a bucket
+			// is the one to keep
+			bucket := period.bucket(set.time.Local())
+			if bucket == lastBucket {
+				continue
+			}
+			lastBucket = bucket
+			keep[set.setID] = true
+			kept++
+		}
+	}
+	return keep
+}
+
+// addScheduledSnapshot records a snapshot of the given snap as part of a
+// scheduled snapshot set, for the retention policy to consider.
+// The state needs to be locked by the caller.
+func addScheduledSnapshot(st *state.State, setID uint64, snapName string, t time.Time) error {
+	var snapshots map[uint64]*snapshotState
+	err := st.Get("snapshots", &snapshots)
+	if err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if snapshots == nil {
+		snapshots = make(map[uint64]*snapshotState)
+	}
+	snapshot := snapshots[setID]
+	if snapshot == nil {
+		snapshot = &snapshotState{Scheduled: &scheduledSnapshot{Time: t}}
+		snapshots[setID] = snapshot
+	}
+	if !strutil.ListContains(snapshot.Scheduled.Snaps, snapName) {
+		snapshot.Scheduled.Snaps = append(snapshot.Scheduled.Snaps, snapName)
+	}
+	st.Set("snapshots", snapshots)
+	return nil
+}
+
+// expireScheduledSnapshots applies the retention policy to the scheduled
+// snapshot sets, if this has been requested. The sets that are not to be
+// kept are expired, for forgetExpiredSnapshots to remove.
+func (mgr *SnapshotManager) expireScheduledSnapshots() error {
+	mgr.state.Lock()
+	defer mgr.state.Unlock()
+
+	if mgr.state.Cached("snapshot-retention") == nil {
+		return nil
+	}
+	mgr.state.Cache("snapshot-retention", nil)
+
+	retention, err := scheduledSnapshotRetention(mgr.state)
+	if err != nil {
+		return err
+	}
+	if retention == (snapshotRetention{}) {
+		// keep everything
+		return nil
+	}
+
+	var snapshots map[uint64]*snapshotState
+	if err := mgr.state.Get("snapshots", &snapshots); err != nil {
+		if errors.Is(err, state.ErrNoState) {
+			return nil
+		}
+		return err
+	}
+
+	bySnap := make(map[string][]scheduledSet)
+	for setID, snapshot := range snapshots {
+		if snapshot.Scheduled == nil || !snapshot.ExpiryTime.IsZero() {
+			continue
+		}
+		for _, snapName := range snapshot.Scheduled.Snaps {
+			bySnap[snapName] = append(bySnap[snapName], scheduledSet{setID: setID, time: snapshot.Scheduled.Time})
+		}
+	}
+	keep := make(map[uint64]bool)
+	for _, sets := range bySnap {
+		for setID := range retention.keep(sets) {
+			keep[setID] = true
+		}
+	}
+
+	// forgetExpiredSnapshots uses the real time
+	now := time.Now()
+	expired := 0
+	for setID, snapshot := range snapshots {
+		if snapshot.Scheduled == nil || !snapshot.ExpiryTime.IsZero() || keep[setID] {
+			continue
+		}
+		snapshot.ExpiryTime = now
+		expired++
+	}
+	if expired == 0 {
+		return nil
+	}
+	mgr.state.Set("snapshots", snapshots)
+	// forget them right away
+	mgr.lastForgetExpiredSnapshotTime = time.Time{}
+
+	return nil
+}
@@ -0,0 +1,395 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapshotstate_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/snapshotstate"
+	"github.com/snapcore/snapd/overlord/snapshotstate/backend"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/timeutil"
+)
+
+func setActiveSnaps(st *state.State, names ...string) {
+	for _, name := range names {
+		snapstate.Set(st, name, &snapstate.SnapState{
+			Active: true,
+			Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+				{RealName: name, Revision: snap.R(1)},
+			}),
+			Current: snap.R(1),
+		})
+	}
+}
+
+func (s *snapshotSuite) mockTime(t time.Time) *time.Time {
+	now := t
+	s.AddCleanup(snapshotstate.MockTimeNow(func() time.Time { return now }))
+	s.AddCleanup(timeutil.MockTimeNow(func() time.Time { return now }))
+	return &now
+}
+
+func (snapshotSuite) TestSnapshotSchedules(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	setActiveSnaps(st, "a-snap", "b-snap", "c-snap", "d-snap")
+
+	// nothing scheduled by default
+	schedules, err := snapshotstate.SnapshotSchedules(st)
+	c.Assert(err, check.IsNil)
+	c.Check(schedules, check.HasLen, 0)
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.schedule", "03:00-04:00")
+	tr.Set("core", "snapshots.snap-schedule.b-snap", "mon,10:00")
+	tr.Set("core", "snapshots.snap-schedule.c-snap", "none")
+	tr.Set("core", "snapshots.snap-schedule.not-installed", "mon,10:00")
+	tr.Commit()
+
+	schedules, err = snapshotstate.SnapshotSchedules(st)
+	c.Assert(err, check.IsNil)
+	c.Check(schedules, check.DeepEquals, map[string][]string{
+		"03:00-04:00": {"a-snap", "d-snap"},
+		"mon,10:00":   {"b-snap"},
+	})
+
+	// per-snap schedules work without a global one
+	tr = config.NewTransaction(st)
+	tr.Set("core", "snapshots.schedule", "")
+	tr.Commit()
+
+	schedules, err = snapshotstate.SnapshotSchedules(st)
+	c.Assert(err, check.IsNil)
+	c.Check(schedules, check.DeepEquals, map[string][]string{
+		"mon,10:00": {"b-snap"},
+	})
+}
+
+func (s *snapshotSuite) TestEnsureTakesScheduledSnapshots(c *check.C) {
+	now := s.mockTime(time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local))
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	st.Lock()
+	setActiveSnaps(st, "a-snap", "b-snap")
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.schedule", "03:00-04:00")
+	tr.Commit()
+	st.Unlock()
+
+	// the first run only anchors the schedule
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	var last map[string]time.Time
+	c.Assert(st.Get("last-scheduled-snapshots", &last), check.IsNil)
+	c.Check(last["03:00-04:00"].Equal(*now), check.Equals, true)
+	st.Unlock()
+
+	// not due yet
+	*now = time.Date(2026, 3, 3, 2, 0, 0, 0, time.Local)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	st.Unlock()
+
+	*now = time.Date(2026, 3, 3, 4, 0, 0, 0, time.Local)
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	chgs := st.Changes()
+	c.Assert(chgs, check.HasLen, 1)
+	chg := chgs[0]
+	c.Check(chg.Kind(), check.Equals, "scheduled-snapshot")
+	c.Check(chg.Summary(), check.Equals, `Scheduled snapshot of snaps "a-snap", "b-snap"`)
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	for i, name := range []string{"a-snap", "b-snap"} {
+		var snapshot map[string]interface{}
+		c.Check(tasks[i].Get("snapshot-setup", &snapshot), check.IsNil)
+		c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+			"set-id":    1.,
+			"snap":      name,
+			"current":   "unset",
+			"scheduled": true,
+		})
+	}
+	c.Assert(st.Get("last-scheduled-snapshots", &last), check.IsNil)
+	c.Check(last["03:00-04:00"].Equal(*now), check.Equals, true)
+}
+
+func (s *snapshotSuite) TestEnsureScheduledSnapshotConflict(c *check.C) {
+	now := s.mockTime(time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local))
+
+	conflict := true
+	s.AddCleanup(snapshotstate.MockSnapstateCheckChangeConflictMany(func(*state.State, []string, string) error {
+		if conflict {
+			return &snapstate.ChangeConflictError{Snap: "a-snap", ChangeKind: "refresh"}
+		}
+		return nil
+	}))
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	st.Lock()
+	setActiveSnaps(st, "a-snap")
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.schedule", "03:00-04:00")
+	tr.Commit()
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+	*now = time.Date(2026, 3, 3, 4, 0, 0, 0, time.Local)
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	c.Check(st.AllWarnings(), check.HasLen, 0)
+	st.Unlock()
+
+	// tried again on the next run
+	conflict = false
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 1)
+}
+
+func (s *snapshotSuite) TestEnsureScheduledSnapshotErrorWarns(c *check.C) {
+	now := s.mockTime(time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local))
+
+	s.AddCleanup(snapshotstate.MockSnapstateCheckChangeConflictMany(func(*state.State, []string, string) error {
+		return errors.New("boom")
+	}))
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	st.Lock()
+	setActiveSnaps(st, "a-snap")
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.schedule", "03:00-04:00")
+	tr.Commit()
+	st.Unlock()
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+	*now = time.Date(2026, 3, 3, 4, 0, 0, 0, time.Local)
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Changes(), check.HasLen, 0)
+	warnings := st.AllWarnings()
+	c.Assert(warnings, check.HasLen, 1)
+	c.Check(warnings[0].String(), check.Equals, `cannot take scheduled snapshot of snaps "a-snap": boom`)
+
+	// and not retried until the next window
+	var last map[string]time.Time
+	c.Assert(st.Get("last-scheduled-snapshots", &last), check.IsNil)
+	c.Check(last["03:00-04:00"].Equal(*now), check.Equals, true)
+}
+
+func (snapshotSuite) TestProcessScheduledSnapshot(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("scheduled-snapshot", "...")
+	chg.Set("snap-names", []string{"a-snap"})
+
+	snapshotstate.ProcessScheduledSnapshot(chg, state.DoStatus, state.DoingStatus)
+	c.Check(st.Cached("snapshot-retention"), check.IsNil)
+	c.Check(st.AllWarnings(), check.HasLen, 0)
+
+	snapshotstate.ProcessScheduledSnapshot(chg, state.DoingStatus, state.ErrorStatus)
+	c.Check(st.Cached("snapshot-retention"), check.IsNil)
+	warnings := st.AllWarnings()
+	c.Assert(warnings, check.HasLen, 1)
+	c.Check(warnings[0].String(), check.Equals, `cannot take scheduled snapshot of snaps "a-snap": see 'snap change `+chg.ID()+`' for details`)
+
+	snapshotstate.ProcessScheduledSnapshot(chg, state.DoingStatus, state.DoneStatus)
+	c.Check(st.Cached("snapshot-retention"), check.Equals, true)
+
+	// other changes are ignored
+	st.Cache("snapshot-retention", nil)
+	other := st.NewChange("snapshot", "...")
+	snapshotstate.ProcessScheduledSnapshot(other, state.DoingStatus, state.DoneStatus)
+	c.Check(st.Cached("snapshot-retention"), check.IsNil)
+}
+
+func (snapshotSuite) TestScheduledSnapshotRetention(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	retention, err := snapshotstate.ScheduledSnapshotRetention(st)
+	c.Assert(err, check.IsNil)
+	c.Check(retention, check.Equals, snapshotstate.SnapshotRetention{Hourly: 24, Daily: 7, Weekly: 4})
+
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.retention.hourly", 0)
+	tr.Set("core", "snapshots.retention.weekly", "10")
+	tr.Commit()
+
+	retention, err = snapshotstate.ScheduledSnapshotRetention(st)
+	c.Assert(err, check.IsNil)
+	c.Check(retention, check.Equals, snapshotstate.SnapshotRetention{Hourly: 0, Daily: 7, Weekly: 10})
+}
+
+func (snapshotSuite) TestRetentionKeep(c *check.C) {
+	at := func(day, hour, min int) time.Time {
+		// 2026-03-02 is a Monday
+		return time.Date(2026, 3, day, hour, min, 0, 0, time.Local)
+	}
+	sets := map[uint64]time.Time{
+		1:  at(2, 10, 0),
+		2:  at(3, 10, 0),
+		3:  at(9, 10, 0),
+		4:  at(10, 10, 0),
+		5:  at(11, 10, 0),
+		6:  at(11, 11, 0),
+		7:  at(11, 11, 30),
+		8:  at(11, 12, 0),
+		9:  at(16, 8, 0),
+		10: at(16, 9, 0),
+	}
+
+	for _, t := range []struct {
+		retention snapshotstate.SnapshotRetention
+		keep      []uint64
+	}{
+		// latest in each of the last 3 hours with snapshots
+		{snapshotstate.SnapshotRetention{Hourly: 3}, []uint64{10, 9, 8}},
+		// latest in each of the last 3 days with snapshots
+		{snapshotstate.SnapshotRetention{Daily: 3}, []uint64{10, 8, 4}},
+		// latest in each week
+		{snapshotstate.SnapshotRetention{Weekly: 5}, []uint64{10, 8, 2}},
+		{snapshotstate.SnapshotRetention{Hourly: 2, Daily: 3, Weekly: 3}, []uint64{10, 9, 8, 4, 2}},
+		{snapshotstate.SnapshotRetention{}, nil},
+	} {
+		keep := snapshotstate.RetentionKeep(t.retention, sets)
+		expected := make(map[uint64]bool, len(t.keep))
+		for _, setID := range t.keep {
+			expected[setID] = true
+		}
+		c.Check(keep, check.DeepEquals, expected, check.Commentf("%+v", t.retention))
+	}
+}
+
+func (snapshotSuite) TestAddScheduledSnapshot(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	c.Assert(snapshotstate.SaveExpiration(st, 1, t), check.IsNil)
+	c.Assert(snapshotstate.AddScheduledSnapshot(st, 2, "a-snap", t), check.IsNil)
+	c.Assert(snapshotstate.AddScheduledSnapshot(st, 2, "b-snap", t.Add(time.Minute)), check.IsNil)
+
+	var snapshots map[uint64]interface{}
+	c.Assert(st.Get("snapshots", &snapshots), check.IsNil)
+	c.Check(snapshots, check.DeepEquals, map[uint64]interface{}{
+		1: map[string]interface{}{"expiry-time": "2026-03-02T10:00:00Z"},
+		2: map[string]interface{}{
+			"expiry-time": "0001-01-01T00:00:00Z",
+			"scheduled": map[string]interface{}{
+				"time":  "2026-03-02T10:00:00Z",
+				"snaps": []interface{}{"a-snap", "b-snap"},
+			},
+		},
+	})
+
+	// scheduled snapshots are kept until the retention policy says otherwise
+	expired, err := snapshotstate.ExpiredSnapshotSets(st, t.Add(time.Hour))
+	c.Assert(err, check.IsNil)
+	c.Check(expired, check.DeepEquals, map[uint64]bool{1: true})
+}
+
+func (s *snapshotSuite) TestEnsureExpiresScheduledSnapshots(c *check.C) {
+	s.AddCleanup(snapshotstate.MockBackendIter(func(context.Context, func(*backend.Reader) error) error {
+		return nil
+	}))
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	st.Lock()
+	tr := config.NewTransaction(st)
+	tr.Set("core", "snapshots.retention.hourly", 1)
+	tr.Set("core", "snapshots.retention.daily", 0)
+	tr.Set("core", "snapshots.retention.weekly", 0)
+	tr.Commit()
+
+	t := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	snapshotstate.AddScheduledSnapshot(st, 1, "a-snap", t)
+	snapshotstate.AddScheduledSnapshot(st, 1, "b-snap", t)
+	snapshotstate.AddScheduledSnapshot(st, 2, "a-snap", t.Add(time.Hour))
+	snapshotstate.AddScheduledSnapshot(st, 3, "c-snap", t)
+	snapshotstate.SaveExpiration(st, 4, t.AddDate(1, 0, 0))
+	st.Unlock()
+
+	// nothing happens unless asked for
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+	expired, err := snapshotstate.ExpiredSnapshotSets(st, time.Now())
+	c.Assert(err, check.IsNil)
+	c.Check(expired, check.HasLen, 0)
+
+	st.Cache("snapshot-retention", true)
+	st.Unlock()
+	c.Assert(mgr.Ensure(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Cached("snapshot-retention"), check.IsNil)
+	// set 1 is still the latest b-snap snapshot
+	expired, err = snapshotstate.ExpiredSnapshotSets(st, time.Now().Add(time.Second))
+	c.Assert(err, check.IsNil)
+	c.Check(expired, check.HasLen, 0)
+
+	snapshotstate.AddScheduledSnapshot(st, 5, "b-snap", t.Add(2*time.Hour))
+	st.Cache("snapshot-retention", true)
+	st.Unlock()
+	c.Assert(mgr.Ensure(), check.IsNil)
+	st.Lock()
+
+	expired, err = snapshotstate.ExpiredSnapshotSets(st, time.Now().Add(time.Second))
+	c.Assert(err, check.IsNil)
+	c.Check(expired, check.DeepEquals, map[uint64]bool{1: true})
+}
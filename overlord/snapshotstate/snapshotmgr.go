@@ -22,6 +22,7 @@ package snapshotstate
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -48,10 +49,14 @@ var (
 	backendImport        = backend.Import
 	backendRestore       = (*backend.Reader).Restore // TODO: look into using an interface instead
 	backendCheck         = (*backend.Reader).Check
+	backendFiles         = (*backend.Reader).Files
+	backendHashedFiles   = (*backend.Reader).HashedFiles
+	backendUnlock        = (*backend.Reader).Unlock
 	backendRevert        = (*backend.RestoreState).Revert // ditto
 	backendCleanup       = (*backend.RestoreState).Cleanup
 
 	backendCleanupAbandonedImports = backend.CleanupAbandonedImports
+	backendCollectGarbage          = backend.CollectGarbage
 
 	autoExpirationInterval = time.Hour * 24 // interval between forgetExpiredSnapshots runs as part of Ensure()
 
@@ -62,22 +67,32 @@ var (
 type SnapshotManager struct {
 	state *state.State
 
+	scheduler *snapshotScheduler
+
 	lastForgetExpiredSnapshotTime time.Time
+
+	// ctx is cancelled when the manager is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // Manager returns a new SnapshotManager
 func Manager(st *state.State, runner *state.TaskRunner) *SnapshotManager {
 	delayedCrossMgrInit()
 
-	runner.AddHandler("save-snapshot", doSave, doForget)
-	runner.AddHandler("forget-snapshot", doForget, nil)
-	runner.AddHandler("check-snapshot", doCheck, nil)
-	runner.AddHandler("restore-snapshot", doRestore, undoRestore)
-	runner.AddHandler("cleanup-after-restore", doCleanupAfterRestore, nil)
+	mgrRunner := runner.ForManager("snapshotstate")
+	mgrRunner.AddHandler("save-snapshot", doSave, doForget)
+	mgrRunner.AddHandler("forget-snapshot", doForget, nil)
+	mgrRunner.AddHandler("check-snapshot", doCheck, nil)
+	mgrRunner.AddHandler("restore-snapshot", doRestore, undoRestore)
+	mgrRunner.AddHandler("cleanup-after-restore", doCleanupAfterRestore, nil)
+	mgrRunner.AddHandler("diff-snapshot", doDiff, nil)
 
 	manager := &SnapshotManager{
-		state: st,
+		state:     st,
+		scheduler: newSnapshotScheduler(st),
 	}
+	manager.ctx, manager.cancel = context.WithCancel(context.Background())
 	snapstate.RegisterAffectedSnapsByAttr("snapshot-setup", manager.affectedSnaps)
 
 	return manager
@@ -85,18 +100,85 @@ func Manager(st *state.State, runner *state.TaskRunner) *SnapshotManager {
 
 // Ensure is part of the overlord.StateManager interface.
 func (mgr *SnapshotManager) Ensure() error {
+	// a failure to take scheduled snapshots shouldn't get in the way of
+	// expiring the existing ones
+	schedErr := mgr.scheduler.Ensure()
+
+	if err := mgr.expireScheduledSnapshots(); err != nil {
+		return err
+	}
+
 	// process expired snapshots once a day.
 	if time.Now().After(mgr.lastForgetExpiredSnapshotTime.Add(autoExpirationInterval)) {
-		return mgr.forgetExpiredSnapshots()
+		if err := mgr.forgetExpiredSnapshots(); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	if err := mgr.collectGarbage(); err != nil {
+		return err
+	}
+	return schedErr
 }
 
 func (mgr *SnapshotManager) StartUp() error {
 	if _, err := backendCleanupAbandonedImports(); err != nil {
 		logger.Noticef("cannot cleanup incomplete imports: %v", err)
 	}
+
+	// chunks of snapshots that were being saved when snapd stopped are
+	// left behind unreferenced; the first Ensure will take care of them
+	// (no EnsureBefore here, as the overlord loop isn't running yet)
+	mgr.state.Lock()
+	mgr.state.Cache("snapshot-chunks-gc", true)
+	// and the retention policy might have changed
+	mgr.state.Cache("snapshot-retention", true)
+	mgr.state.AddChangeStatusChangedHandler(forgetSnapshotSecrets)
+	mgr.state.AddChangeStatusChangedHandler(processScheduledSnapshot)
+	mgr.state.Unlock()
+
+	return nil
+}
+
+// Stop implements StateStopper. It interrupts the removal of unused snapshot
+// data.
+func (mgr *SnapshotManager) Stop() {
+	mgr.cancel()
+}
+
+// requestGarbageCollection asks for unreferenced snapshot chunks to be
+// removed from the chunk store on the next Ensure.
+func requestGarbageCollection(st *state.State) {
+	st.Cache("snapshot-chunks-gc", true)
+	st.EnsureBefore(0)
+}
+
+// collectGarbage removes unreferenced snapshot chunks, if this has been
+// requested.
+func (mgr *SnapshotManager) collectGarbage() error {
+	mgr.state.Lock()
+	requested := mgr.state.Cached("snapshot-chunks-gc") != nil
+	mgr.state.Cache("snapshot-chunks-gc", nil)
+	mgr.state.Unlock()
+
+	if !requested {
+		return nil
+	}
+
+	// this walks all the snapshots, so do it without holding the lock;
+	// the backend makes sure it doesn't race with saves and imports
+	if _, err := backendCollectGarbage(mgr.ctx); err != nil {
+		// try again next time
+		mgr.state.Lock()
+		mgr.state.Cache("snapshot-chunks-gc", true)
+		mgr.state.Unlock()
+		if errors.Is(err, backend.ErrChunkStoreBusy) || mgr.ctx.Err() != nil {
+			// snapshots are being saved, imported or exported, or
+			// snapd is stopping
+			return nil
+		}
+		return fmt.Errorf("cannot remove unused snapshot data: %v", err)
+	}
 	return nil
 }
 
@@ -114,9 +196,9 @@ func (mgr *SnapshotManager) forgetExpiredSnapshots() error {
 	}
 
 	err = backendIter(context.TODO(), func(r *backend.Reader) error {
-		// forget needs to conflict with check and restore
+		// forget needs to conflict with check, restore and diff
 		if err := checkSnapshotConflict(mgr.state, r.SetID, "export-snapshot",
-			"check-snapshot", "restore-snapshot"); err != nil {
+			"check-snapshot", "restore-snapshot", "diff-snapshot"); err != nil {
 			// there is a conflict, do nothing and we will retry this set on next Ensure().
 			return nil
 		}
@@ -132,6 +214,7 @@ func (mgr *SnapshotManager) forgetExpiredSnapshots() error {
 			if err := osRemove(r.Name()); err != nil {
 				return fmt.Errorf("cannot remove snapshot file %q: %v", r.Name(), err)
 			}
+			requestGarbageCollection(mgr.state)
 		}
 		return nil
 	})
@@ -170,6 +253,52 @@ type snapshotSetup struct {
 	Filename string                `json:"filename,omitempty"`
 	Current  snap.Revision         `json:"current"`
 	Auto     bool                  `json:"auto,omitempty"`
+	// Encrypted is set for encrypted snapshots; the secret to
+	// encrypt or decrypt them is never stored in the state.
+	Encrypted bool `json:"encrypted,omitempty"`
+	// Scheduled is set for snapshots taken by the snapshot scheduler.
+	Scheduled bool `json:"scheduled,omitempty"`
+	// Paths limits a restore to those files or directories
+	Paths []string `json:"paths,omitempty"`
+}
+
+// snapshotSecretKey is the cache key of the secret of a task; secrets are
+// kept per task, as different changes may use the same snapshot set with
+// different secrets at the same time.
+type snapshotSecretKey struct {
+	taskID string
+}
+
+// cacheSnapshotSecret keeps the secret to encrypt or decrypt the snapshot
+// of the given task in memory, for the task to use.
+func cacheSnapshotSecret(task *state.Task, secret []byte) {
+	task.State().Cache(snapshotSecretKey{task.ID()}, secret)
+}
+
+// snapshotSecret returns the secret for encrypting or decrypting the
+// snapshot of the given task, from the given snapshot set.
+func snapshotSecret(task *state.Task, setID uint64) ([]byte, error) {
+	secret, _ := task.State().Cached(snapshotSecretKey{task.ID()}).([]byte)
+	if secret == nil {
+		// the secret is lost if snapd restarts
+		return nil, fmt.Errorf("cannot find the secret for encrypted snapshot set #%d (was snapd restarted?)", setID)
+	}
+	return secret, nil
+}
+
+// forgetSnapshotSecrets drops the secrets used by the tasks of the
+// change, once it's ready.
+func forgetSnapshotSecrets(chg *state.Change, old, new state.Status) {
+	if !new.Ready() {
+		return
+	}
+	st := chg.State()
+	for _, t := range chg.Tasks() {
+		switch t.Kind() {
+		case "save-snapshot", "restore-snapshot", "diff-snapshot":
+			st.Cache(snapshotSecretKey{t.ID()}, nil)
+		}
+	}
 }
 
 func filename(setID uint64, si *snap.Info) string {
@@ -215,6 +344,11 @@ func prepareSave(task *state.Task) (snapshot *snapshotSetup, cur *snap.Info, cfg
 			return nil, nil, nil, err
 		}
 	}
+	if snapshot.Scheduled {
+		if err := addScheduledSnapshot(st, snapshot.SetID, snapshot.Snap, timeNow()); err != nil {
+			return nil, nil, nil, err
+		}
+	}
 
 	return snapshot, cur, cfg, nil
 }
@@ -228,12 +362,16 @@ func doSave(task *state.Task, tomb *tomb.Tomb) error {
 
 	st.Lock()
 	opts, err := getSnapDirOpts(st, snapshot.Snap)
+	var secret []byte
+	if err == nil && snapshot.Encrypted {
+		secret, err = snapshotSecret(task, snapshot.SetID)
+	}
 	st.Unlock()
 	if err != nil {
 		return err
 	}
 
-	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users, snapshot.Options, opts)
+	_, err = backendSave(tomb.Context(nil), snapshot.SetID, cur, cfg, snapshot.Users, snapshot.Options, opts, secret)
 	if err != nil {
 		st.Lock()
 		defer st.Unlock()
@@ -313,28 +451,42 @@ func doRestore(task *state.Task, tomb *tomb.Tomb) error {
 
 	st.Lock()
 	opts, err := getSnapDirOpts(st, snapshot.Snap)
+	var secret []byte
+	if err == nil && snapshot.Encrypted {
+		secret, err = snapshotSecret(task, snapshot.SetID)
+	}
 	st.Unlock()
 	if err != nil {
 		return err
 	}
 
-	restoreState, err := backendRestore(reader, tomb.Context(nil), snapshot.Current, snapshot.Users, logf, opts)
-	if err != nil {
-		return err
+	if snapshot.Encrypted {
+		// deriving the key is expensive, so do it without the lock
+		if err := backendUnlock(reader, secret); err != nil {
+			return fmt.Errorf("cannot restore snapshot: %v", err)
+		}
 	}
 
-	raw, err := marshalSnapConfig(reader.Conf)
+	restoreState, err := backendRestore(reader, tomb.Context(nil), snapshot.Current, snapshot.Users, snapshot.Paths, logf, opts)
 	if err != nil {
-		backendRevert(restoreState)
-		return fmt.Errorf("cannot marshal saved config: %v", err)
+		return err
 	}
 
 	st.Lock()
 	defer st.Unlock()
 
-	if err := configSetSnapConfig(st, snapshot.Snap, raw); err != nil {
-		backendRevert(restoreState)
-		return fmt.Errorf("cannot set snap config: %v", err)
+	// restoring only some paths leaves the configuration alone
+	if len(snapshot.Paths) == 0 {
+		raw, err := marshalSnapConfig(reader.Conf)
+		if err != nil {
+			backendRevert(restoreState)
+			return fmt.Errorf("cannot marshal saved config: %v", err)
+		}
+
+		if err := configSetSnapConfig(st, snapshot.Snap, raw); err != nil {
+			backendRevert(restoreState)
+			return fmt.Errorf("cannot set snap config: %v", err)
+		}
 	}
 
 	restoreState.Config = oldCfg
@@ -436,6 +588,47 @@ func doCheck(task *state.Task, tomb *tomb.Tomb) error {
 	return backendCheck(reader, tomb.Context(nil), snapshot.Users)
 }
 
+func doDiff(task *state.Task, tomb *tomb.Tomb) error {
+	var setup diffSetup
+
+	st := task.State()
+	st.Lock()
+	err := task.Get("diff-setup", &setup)
+	if err != nil {
+		st.Unlock()
+		return taskGetErrMsg(task, err, "diff")
+	}
+	var secret []byte
+	if setup.Encrypted {
+		secret, err = snapshotSecret(task, setup.SetID)
+	}
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+
+	diffs, err := diffSnapshots(tomb.Context(nil), setup.SetID, setup.OtherSetID, setup.Snaps, secret)
+	if err != nil {
+		return err
+	}
+
+	st.Lock()
+	defer st.Unlock()
+
+	chg := task.Change()
+	var data map[string]interface{}
+	if err := chg.Get("api-data", &data); err != nil && !errors.Is(err, state.ErrNoState) {
+		return err
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["diffs"] = diffs
+	chg.Set("api-data", data)
+
+	return nil
+}
+
 func doForget(task *state.Task, _ *tomb.Tomb) error {
 	// note this is also undoSave
 	st := task.State()
@@ -458,7 +651,14 @@ func doForget(task *state.Task, _ *tomb.Tomb) error {
 		return fmt.Errorf("internal error: cannot remove state of snapshot set %d: %v", snapshot.SetID, err)
 	}
 
-	return osRemove(snapshot.Filename)
+	if err := osRemove(snapshot.Filename); err != nil {
+		return err
+	}
+
+	// the chunks only this snapshot referenced can go now
+	requestGarbageCollection(st)
+
+	return nil
 }
 
 func delayedCrossMgrInit() {
@@ -468,7 +668,7 @@ func delayedCrossMgrInit() {
 	snapstate.EstimateSnapshotSize = EstimateSnapshotSize
 }
 
-func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions) (*client.Snapshot, error)) (restore func()) {
+func MockBackendSave(f func(context.Context, uint64, *snap.Info, map[string]interface{}, []string, *snap.SnapshotOptions, *dirs.SnapDirOptions, []byte) (*client.Snapshot, error)) (restore func()) {
 	old := backendSave
 	backendSave = f
 	return func() {
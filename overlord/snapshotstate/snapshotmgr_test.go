@@ -54,6 +54,7 @@ func (snapshotSuite) TestManager(c *check.C) {
 	c.Check(kinds, check.DeepEquals, []string{
 		"check-snapshot",
 		"cleanup-after-restore",
+		"diff-snapshot",
 		"forget-snapshot",
 		"restore-snapshot",
 		"save-snapshot",
@@ -282,7 +283,7 @@ func (snapshotSuite) TestDoSave(c *check.C) {
 
 	expectedOptions := &snap.SnapshotOptions{}
 	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string,
-		options *snap.SnapshotOptions, _ *dirs.SnapDirOptions) (*client.Snapshot, error) {
+		options *snap.SnapshotOptions, _ *dirs.SnapDirOptions, _ []byte) (*client.Snapshot, error) {
 		c.Check(id, check.Equals, uint64(42))
 		c.Check(si, check.DeepEquals, &snapInfo)
 		c.Check(cfg, check.DeepEquals, map[string]interface{}{"hello": "there"})
@@ -338,7 +339,7 @@ func (snapshotSuite) TestDoSaveGetsSnapDirOpts(c *check.C) {
 	})()
 
 	var checkOpts bool
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, opts *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, opts *dirs.SnapDirOptions, _ []byte) (*client.Snapshot, error) {
 		c.Check(opts.HiddenSnapDataDir, check.Equals, true)
 		checkOpts = true
 		return nil, nil
@@ -357,12 +358,76 @@ func (snapshotSuite) TestDoSaveGetsSnapDirOpts(c *check.C) {
 	c.Check(checkOpts, check.Equals, true)
 }
 
+func (snapshotSuite) TestDoSaveEncrypted(c *check.C) {
+	snapInfo := snap.Info{SideInfo: snap.SideInfo{RealName: "a-snap", Revision: snap.R(-1)}, Version: "1.33"}
+	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
+		return &snapInfo, nil
+	})()
+	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
+	var saveSecret []byte
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, _ *dirs.SnapDirOptions, secret []byte) (*client.Snapshot, error) {
+		saveSecret = secret
+		return nil, nil
+	})()
+
+	st := state.New(nil)
+	st.Lock()
+	task := st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{
+		"set-id":    42,
+		"snap":      "a-snap",
+		"encrypted": true,
+	})
+	st.Unlock()
+
+	// e.g. snapd was restarted
+	err := snapshotstate.DoSave(task, &tomb.Tomb{})
+	c.Assert(err, check.ErrorMatches, `cannot find the secret for encrypted snapshot set #42 \(was snapd restarted\?\)`)
+	c.Check(saveSecret, check.IsNil)
+
+	st.Lock()
+	snapshotstate.CacheSnapshotSecret(task, []byte("s3cr3t"))
+	st.Unlock()
+
+	err = snapshotstate.DoSave(task, &tomb.Tomb{})
+	c.Assert(err, check.IsNil)
+	c.Check(saveSecret, check.DeepEquals, []byte("s3cr3t"))
+}
+
+func (snapshotSuite) TestSecretsForgottenWhenChangeReady(c *check.C) {
+	defer snapshotstate.MockBackendCleanupAbandonedImports(func() (int, error) { return 0, nil })()
+	o := overlord.Mock()
+	st := o.State()
+	mgr := snapshotstate.Manager(st, o.TaskRunner())
+	c.Assert(mgr.StartUp(), check.IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	chg := st.NewChange("save-snapshot", "...")
+	task := st.NewTask("save-snapshot", "...")
+	task.Set("snapshot-setup", map[string]interface{}{
+		"set-id":    42,
+		"snap":      "a-snap",
+		"encrypted": true,
+	})
+	chg.AddTask(task)
+	snapshotstate.CacheSnapshotSecret(task, []byte("s3cr3t"))
+
+	task.SetStatus(state.DoingStatus)
+	_, err := snapshotstate.SnapshotSecret(task, 42)
+	c.Check(err, check.IsNil)
+
+	task.SetStatus(state.DoneStatus)
+	_, err = snapshotstate.SnapshotSecret(task, 42)
+	c.Check(err, check.NotNil)
+}
+
 func (snapshotSuite) TestDoSaveFailsWithNoSnap(c *check.C) {
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) {
 		return nil, errors.New("bzzt")
 	})()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ []byte) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -389,7 +454,7 @@ func (snapshotSuite) TestDoSaveFailsWithNoSnapshot(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ []byte) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -413,7 +478,7 @@ func (snapshotSuite) TestDoSaveFailsBackendError(c *check.C) {
 	}
 	defer snapshotstate.MockSnapstateCurrentInfo(func(*state.State, string) (*snap.Info, error) { return &snapInfo, nil })()
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) { return nil, nil })()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ []byte) (*client.Snapshot, error) {
 		return nil, errors.New("bzzt")
 	})()
 
@@ -442,7 +507,7 @@ func (snapshotSuite) TestDoSaveFailsConfigError(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(*state.State, string) (*json.RawMessage, error) {
 		return nil, errors.New("bzzt")
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ []byte) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -473,7 +538,7 @@ func (snapshotSuite) TestDoSaveFailsBadConfig(c *check.C) {
 		buf := json.RawMessage(`"hello-there"`)
 		return &buf, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ []byte) (*client.Snapshot, error) {
 		return nil, nil
 	})()
 
@@ -506,7 +571,7 @@ func (snapshotSuite) TestDoSaveFailureRemovesStateEntry(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(_ *state.State, snapname string) (*json.RawMessage, error) {
 		return nil, nil
 	})()
-	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions) (*client.Snapshot, error) {
+	defer snapshotstate.MockBackendSave(func(_ context.Context, id uint64, si *snap.Info, cfg map[string]interface{}, usernames []string, _ *snap.SnapshotOptions, options *dirs.SnapDirOptions, _ []byte) (*client.Snapshot, error) {
 		var expirations map[uint64]interface{}
 		st.Lock()
 		defer st.Unlock()
@@ -576,7 +641,7 @@ func (rs *readerSuite) SetUpTest(c *check.C) {
 			rs.calls = append(rs.calls, "open")
 			return &backend.Reader{}, nil
 		}),
-		snapshotstate.MockBackendRestore(func(*backend.Reader, context.Context, snap.Revision, []string, backend.Logf, *dirs.SnapDirOptions) (*backend.RestoreState, error) {
+		snapshotstate.MockBackendRestore(func(*backend.Reader, context.Context, snap.Revision, []string, []string, backend.Logf, *dirs.SnapDirOptions) (*backend.RestoreState, error) {
 			rs.calls = append(rs.calls, "restore")
 			return &backend.RestoreState{}, nil
 		}),
@@ -615,7 +680,7 @@ func (rs *readerSuite) TestDoRestore(c *check.C) {
 			Snapshot: client.Snapshot{Conf: map[string]interface{}{"hello": "there"}},
 		}, nil
 	})()
-	defer snapshotstate.MockBackendRestore(func(_ *backend.Reader, _ context.Context, _ snap.Revision, users []string, _ backend.Logf, options *dirs.SnapDirOptions) (*backend.RestoreState, error) {
+	defer snapshotstate.MockBackendRestore(func(_ *backend.Reader, _ context.Context, _ snap.Revision, users []string, _ []string, _ backend.Logf, options *dirs.SnapDirOptions) (*backend.RestoreState, error) {
 		rs.calls = append(rs.calls, "restore")
 		c.Check(users, check.DeepEquals, []string{"a-user", "b-user"})
 		return &backend.RestoreState{}, nil
@@ -639,6 +704,73 @@ func (rs *readerSuite) TestDoRestore(c *check.C) {
 	c.Check(v, check.DeepEquals, map[string]interface{}{"config": map[string]interface{}{"old": "conf"}})
 }
 
+func (rs *readerSuite) TestDoRestoreEncrypted(c *check.C) {
+	st := rs.task.State()
+	st.Lock()
+	rs.task.Set("snapshot-setup", map[string]interface{}{
+		"set-id":    42,
+		"snap":      "a-snap",
+		"filename":  "/some/1_file.zip",
+		"encrypted": true,
+	})
+	snapshotstate.CacheSnapshotSecret(rs.task, []byte("s3cr3t"))
+	st.Unlock()
+
+	defer snapshotstate.MockBackendUnlock(func(_ *backend.Reader, secret []byte) error {
+		rs.calls = append(rs.calls, "unlock")
+		c.Check(secret, check.DeepEquals, []byte("s3cr3t"))
+		return nil
+	})()
+
+	err := snapshotstate.DoRestore(rs.task, &tomb.Tomb{})
+	c.Assert(err, check.IsNil)
+	c.Check(rs.calls, check.DeepEquals, []string{"get config", "open", "unlock", "restore", "set config"})
+}
+
+func (rs *readerSuite) TestDoRestorePaths(c *check.C) {
+	st := rs.task.State()
+	st.Lock()
+	rs.task.Set("snapshot-setup", map[string]interface{}{
+		"snap":     "a-snap",
+		"filename": "/some/1_file.zip",
+		"paths":    []string{"common/foo", "42/bar"},
+	})
+	st.Unlock()
+
+	defer snapshotstate.MockBackendRestore(func(_ *backend.Reader, _ context.Context, _ snap.Revision, _ []string, paths []string, _ backend.Logf, _ *dirs.SnapDirOptions) (*backend.RestoreState, error) {
+		rs.calls = append(rs.calls, "restore")
+		c.Check(paths, check.DeepEquals, []string{"common/foo", "42/bar"})
+		return &backend.RestoreState{}, nil
+	})()
+
+	err := snapshotstate.DoRestore(rs.task, &tomb.Tomb{})
+	c.Assert(err, check.IsNil)
+	// the configuration is left alone
+	c.Check(rs.calls, check.DeepEquals, []string{"get config", "open", "restore"})
+}
+
+func (rs *readerSuite) TestDoRestoreEncryptedWrongKey(c *check.C) {
+	st := rs.task.State()
+	st.Lock()
+	rs.task.Set("snapshot-setup", map[string]interface{}{
+		"set-id":    42,
+		"snap":      "a-snap",
+		"filename":  "/some/1_file.zip",
+		"encrypted": true,
+	})
+	snapshotstate.CacheSnapshotSecret(rs.task, []byte("s3cr3t"))
+	st.Unlock()
+
+	defer snapshotstate.MockBackendUnlock(func(*backend.Reader, []byte) error {
+		rs.calls = append(rs.calls, "unlock")
+		return backend.ErrWrongKey
+	})()
+
+	err := snapshotstate.DoRestore(rs.task, &tomb.Tomb{})
+	c.Assert(err, check.ErrorMatches, "cannot restore snapshot: wrong passphrase or key for encrypted snapshot")
+	c.Check(rs.calls, check.DeepEquals, []string{"get config", "open", "unlock"})
+}
+
 func (rs *readerSuite) TestDoRestoreNoConfig(c *check.C) {
 	defer snapshotstate.MockConfigGetSnapConfig(func(_ *state.State, snapname string) (*json.RawMessage, error) {
 		rs.calls = append(rs.calls, "get config")
@@ -657,7 +789,7 @@ func (rs *readerSuite) TestDoRestoreNoConfig(c *check.C) {
 			Snapshot: client.Snapshot{Snap: "a-snap", Conf: nil},
 		}, nil
 	})()
-	defer snapshotstate.MockBackendRestore(func(_ *backend.Reader, _ context.Context, _ snap.Revision, users []string, _ backend.Logf, options *dirs.SnapDirOptions) (*backend.RestoreState, error) {
+	defer snapshotstate.MockBackendRestore(func(_ *backend.Reader, _ context.Context, _ snap.Revision, users []string, _ []string, _ backend.Logf, options *dirs.SnapDirOptions) (*backend.RestoreState, error) {
 		rs.calls = append(rs.calls, "restore")
 		c.Check(users, check.DeepEquals, []string{"a-user", "b-user"})
 		return &backend.RestoreState{}, nil
@@ -740,7 +872,7 @@ func (rs *readerSuite) TestDoRestoreFailsUnserialisableSnapshotConfigError(c *ch
 }
 
 func (rs *readerSuite) TestDoRestoreFailsOnRestoreError(c *check.C) {
-	defer snapshotstate.MockBackendRestore(func(*backend.Reader, context.Context, snap.Revision, []string, backend.Logf, *dirs.SnapDirOptions) (*backend.RestoreState, error) {
+	defer snapshotstate.MockBackendRestore(func(*backend.Reader, context.Context, snap.Revision, []string, []string, backend.Logf, *dirs.SnapDirOptions) (*backend.RestoreState, error) {
 		rs.calls = append(rs.calls, "restore")
 		return nil, errors.New("bzzt")
 	})()
@@ -927,3 +1059,123 @@ func (snapshotSuite) TestManagerRunCleanupAbandonedImportsAtStartupErrorLogged(c
 	c.Check(n, check.Equals, 1)
 	c.Check(logbuf.String(), testutil.Contains, "cannot cleanup incomplete imports: some error\n")
 }
+
+func (snapshotSuite) TestManagerCollectsGarbageAtStartup(c *check.C) {
+	n := 0
+	restore := snapshotstate.MockBackendCollectGarbage(func(context.Context) (int, error) {
+		n++
+		return 0, nil
+	})
+	defer restore()
+
+	o := overlord.Mock()
+	st := o.State()
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	c.Assert(mgr, check.NotNil)
+	o.AddManager(mgr)
+	err := o.Settle(100 * time.Millisecond)
+	c.Assert(err, check.IsNil)
+
+	c.Check(n, check.Equals, 1)
+
+	// nothing else to collect
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(n, check.Equals, 1)
+}
+
+func (rs *readerSuite) TestDoForgetRequestsGarbageCollection(c *check.C) {
+	defer snapshotstate.MockOsRemove(func(filename string) error {
+		return nil
+	})()
+	n := 0
+	defer snapshotstate.MockBackendCollectGarbage(func(context.Context) (int, error) {
+		n++
+		return 3, nil
+	})()
+
+	st := rs.task.State()
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+
+	// nothing to collect yet
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(n, check.Equals, 0)
+
+	c.Assert(snapshotstate.DoForget(rs.task, &tomb.Tomb{}), check.IsNil)
+
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(n, check.Equals, 1)
+
+	// and only once
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(n, check.Equals, 1)
+}
+
+func (snapshotSuite) TestEnsureForgetSnapshotsCollectsGarbage(c *check.C) {
+	restoreOsRemove := snapshotstate.MockOsRemove(func(fileName string) error {
+		return nil
+	})
+	defer restoreOsRemove()
+	restore := mockFakeSnapshot(c)
+	defer restore()
+	var gcErr error
+	n := 0
+	defer snapshotstate.MockBackendCollectGarbage(func(context.Context) (int, error) {
+		n++
+		return 0, gcErr
+	})()
+
+	st := state.New(nil)
+	runner := state.NewTaskRunner(st)
+	mgr := snapshotstate.Manager(st, runner)
+
+	st.Lock()
+	st.Set("snapshots", map[uint64]interface{}{
+		1: map[string]interface{}{"expiry-time": "2001-03-11T11:24:00Z"},
+	})
+	st.Unlock()
+
+	gcErr = errors.New("boom")
+	c.Assert(mgr.Ensure(), check.ErrorMatches, "cannot remove unused snapshot data: boom")
+	c.Check(n, check.Equals, 1)
+
+	// failures are retried
+	gcErr = nil
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(n, check.Equals, 2)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(n, check.Equals, 2)
+}
+
+func (snapshotSuite) TestEnsureCollectGarbageBusy(c *check.C) {
+	var gcErr error
+	var gcCtx context.Context
+	n := 0
+	defer snapshotstate.MockBackendCollectGarbage(func(ctx context.Context) (int, error) {
+		n++
+		gcCtx = ctx
+		return 0, gcErr
+	})()
+
+	st := state.New(nil)
+	mgr := snapshotstate.Manager(st, state.NewTaskRunner(st))
+	st.Lock()
+	snapshotstate.RequestGarbageCollection(st)
+	st.Unlock()
+
+	// snapshots being saved don't hold up the ensure loop
+	gcErr = backend.ErrChunkStoreBusy
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(n, check.Equals, 1)
+	c.Check(gcCtx.Err(), check.IsNil)
+
+	// and the collection is retried
+	gcErr = nil
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(n, check.Equals, 2)
+	c.Assert(mgr.Ensure(), check.IsNil)
+	c.Check(n, check.Equals, 2)
+
+	// stopping the manager interrupts the collection
+	mgr.Stop()
+	c.Check(gcCtx.Err(), check.Equals, context.Canceled)
+}
@@ -53,6 +53,15 @@ var (
 
 type snapshotState struct {
 	ExpiryTime time.Time `json:"expiry-time"`
+	// Scheduled is set for snapshot sets taken by the snapshot
+	// scheduler; these have no expiry time until the retention policy
+	// says they should go.
+	Scheduled *scheduledSnapshot `json:"scheduled,omitempty"`
+}
+
+type scheduledSnapshot struct {
+	Time  time.Time `json:"time"`
+	Snaps []string  `json:"snaps"`
 }
 
 func newSnapshotSetID(st *state.State) (uint64, error) {
@@ -207,6 +216,10 @@ func expiredSnapshotSets(st *state.State, cutoffTime time.Time) (map[uint64]bool
 
 	expired := make(map[uint64]bool)
 	for setID, snapshotSet := range snapshots {
+		if snapshotSet.Scheduled != nil && snapshotSet.ExpiryTime.IsZero() {
+			// kept by the retention policy
+			continue
+		}
 		if snapshotSet.ExpiryTime.Before(cutoffTime) {
 			expired[setID] = true
 		}
@@ -228,10 +241,11 @@ func (summaries snapshotSnapSummaries) snapNames() []string {
 }
 
 type snapshotSnapSummary struct {
-	snap     string
-	snapID   string
-	filename string
-	epoch    snap.Epoch
+	snap      string
+	snapID    string
+	filename  string
+	epoch     snap.Epoch
+	encrypted bool
 }
 
 // snapSummariesInSnapshotSet goes looking for the requested snaps in the
@@ -244,10 +258,11 @@ func snapSummariesInSnapshotSet(setID uint64, requested []string) (summaries sna
 			found = true
 			if len(requested) == 0 || strutil.SortedListContains(requested, r.Snap) {
 				summaries = append(summaries, &snapshotSnapSummary{
-					filename: r.Name(),
-					snap:     r.Snap,
-					snapID:   r.SnapID,
-					epoch:    r.Epoch,
+					filename:  r.Name(),
+					snap:      r.Snap,
+					snapID:    r.SnapID,
+					epoch:     r.Epoch,
+					encrypted: r.Encryption != nil,
 				})
 			}
 		}
@@ -294,6 +309,18 @@ func checkSnapshotConflict(st *state.State, setID uint64, conflictingKinds ...st
 			continue
 		}
 
+		if task.Kind() == "diff-snapshot" {
+			// diffs are of two sets
+			var setup diffSetup
+			if err := task.Get("diff-setup", &setup); err != nil {
+				return taskGetErrMsg(task, err, "diff")
+			}
+			if setup.SetID == setID || setup.OtherSetID == setID {
+				return fmt.Errorf("cannot operate on snapshot set #%d while change %q is in progress", setID, task.Change().ID())
+			}
+			continue
+		}
+
 		var snapshot snapshotSetup
 		if err := task.Get("snapshot-setup", &snapshot); err != nil {
 			return taskGetErrMsg(task, err, "snapshot")
@@ -379,8 +406,14 @@ func Import(ctx context.Context, st *state.State, r io.Reader) (setID uint64, sn
 }
 
 // Save creates a taskset for taking snapshots of snaps' data.
+// If secret is not nil the snapshots are encrypted with a key derived from
+// it; the secret itself is only kept in memory for the tasks to use.
 // Note that the state must be locked by the caller.
-func Save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
+func Save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions, secret []byte) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
+	return save(st, instanceNames, users, options, secret, false)
+}
+
+func save(st *state.State, instanceNames []string, users []string, options map[string]*snap.SnapshotOptions, secret []byte, scheduled bool) (setID uint64, snapsSaved []string, ts *state.TaskSet, err error) {
 	if len(instanceNames) == 0 {
 		instanceNames, err = allActiveSnapNames(st)
 		if err != nil {
@@ -416,13 +449,18 @@ func Save(st *state.State, instanceNames []string, users []string, options map[s
 		task := st.NewTask("save-snapshot", desc)
 
 		snapshot := snapshotSetup{
-			SetID:   setID,
-			Snap:    name,
-			Users:   users,
-			Options: options[name],
+			SetID:     setID,
+			Snap:      name,
+			Users:     users,
+			Options:   options[name],
+			Encrypted: secret != nil,
+			Scheduled: scheduled,
 		}
 
 		task.Set("snapshot-setup", &snapshot)
+		if secret != nil {
+			cacheSnapshotSecret(task, secret)
+		}
 		// Here, note that a snapshot set behaves as a unit: it either
 		// succeeds, or fails, as a whole; we don't use lanes, to have
 		// some snaps' snapshot succeed and not others in a single set.
@@ -468,12 +506,36 @@ func AutomaticSnapshot(st *state.State, snapName string) (ts *state.TaskSet, err
 }
 
 // Restore creates a taskset for restoring a snapshot's data.
+// If paths are given, only those files or directories in the data of the
+// one given snap are restored, and its configuration is left alone.
+// The secret is needed to restore encrypted snapshots, and is only kept in
+// memory for the tasks to use.
 // Note that the state must be locked by the caller.
-func Restore(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error) {
+func Restore(st *state.State, setID uint64, snapNames []string, users []string, paths []string, secret []byte) (snapsFound []string, ts *state.TaskSet, err error) {
+	if len(paths) > 0 {
+		if len(snapNames) != 1 {
+			return nil, nil, fmt.Errorf("cannot restore specific paths of other than exactly one snap")
+		}
+		for _, path := range paths {
+			if err := backend.ValidateRestorePath(path); err != nil {
+				return nil, nil, fmt.Errorf("cannot restore snapshot for %q: %v", snapNames[0], err)
+			}
+		}
+	}
 	summaries, err := snapSummariesInSnapshotSet(setID, snapNames)
 	if err != nil {
 		return nil, nil, err
 	}
+	for _, summary := range summaries {
+		switch {
+		case secret == nil && summary.encrypted:
+			return nil, nil, fmt.Errorf("cannot restore snapshot for %q: snapshot is encrypted, a passphrase or key file is needed", summary.snap)
+		case secret != nil && !summary.encrypted:
+			// the archives of a set saved with a secret must not be
+			// silently replaced with unencrypted ones
+			return nil, nil, fmt.Errorf("cannot restore snapshot for %q: snapshot is not encrypted", summary.snap)
+		}
+	}
 	all, err := snapstateAll(st)
 	if err != nil {
 		return nil, nil, err
@@ -514,13 +576,18 @@ func Restore(st *state.State, setID uint64, snapNames []string, users []string)
 		desc := fmt.Sprintf("Restore data of snap %q from snapshot set #%d", summary.snap, setID)
 		task := st.NewTask("restore-snapshot", desc)
 		snapshot := snapshotSetup{
-			SetID:    setID,
-			Snap:     summary.snap,
-			Users:    users,
-			Filename: summary.filename,
-			Current:  current,
+			SetID:     setID,
+			Snap:      summary.snap,
+			Users:     users,
+			Paths:     paths,
+			Filename:  summary.filename,
+			Current:   current,
+			Encrypted: summary.encrypted,
 		}
 		task.Set("snapshot-setup", &snapshot)
+		if secret != nil {
+			cacheSnapshotSecret(task, secret)
+		}
 		// see the note about snapshots not using lanes, above.
 		ts.AddTask(task)
 	}
@@ -538,6 +605,37 @@ func Restore(st *state.State, setID uint64, snapNames []string, users []string)
 	return snapsFound, ts, nil
 }
 
+// ErrWrongKey is returned when the secret given for an encrypted snapshot
+// is not the one it was saved with.
+var ErrWrongKey = backend.ErrWrongKey
+
+// Files lists the files and directories in the data of the given snap in
+// the snapshot set, for the system and the given users (or all of them).
+// The secret is needed to list the files of encrypted snapshots.
+func Files(ctx context.Context, setID uint64, snapName string, users []string, secret []byte) ([]client.SnapshotFile, error) {
+	summaries, err := snapSummariesInSnapshotSet(setID, []string{snapName})
+	if err != nil {
+		return nil, err
+	}
+	// there's only one snapshot of a snap in a set
+	reader, err := backendOpen(summaries[0].filename, setID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open snapshot: %v", err)
+	}
+	defer reader.Close()
+
+	if summaries[0].encrypted {
+		if secret == nil {
+			return nil, client.ErrSnapshotEncrypted
+		}
+		if err := backendUnlock(reader, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	return backendFiles(reader, ctx, users)
+}
+
 // Check creates a taskset for checking a snapshot's data.
 // Note that the state must be locked by the caller.
 func Check(st *state.State, setID uint64, snapNames []string, users []string) (snapsFound []string, ts *state.TaskSet, err error) {
@@ -572,9 +670,9 @@ func Check(st *state.State, setID uint64, snapNames []string, users []string) (s
 // Forget creates a taskset for deletinig a snapshot.
 // Note that the state must be locked by the caller.
 func Forget(st *state.State, setID uint64, snapNames []string) (snapsFound []string, ts *state.TaskSet, err error) {
-	// forget needs to conflict with check, restore, import and export.
+	// forget needs to conflict with check, restore, diff, import and export.
 	if err := checkSnapshotConflict(st, setID, "export-snapshot",
-		"check-snapshot", "restore-snapshot"); err != nil {
+		"check-snapshot", "restore-snapshot", "diff-snapshot"); err != nil {
 		return nil, nil, err
 	}
 
@@ -358,7 +358,7 @@ func (snapshotSuite) TestSaveChecksSnapnamesError(c *check.C) {
 	st := state.New(nil)
 	st.Lock()
 	defer st.Unlock()
-	_, _, _, err := snapshotstate.Save(st, nil, nil, nil)
+	_, _, _, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Check(err, check.ErrorMatches, "bzzt")
 }
 
@@ -423,7 +423,7 @@ func (s snapshotSuite) TestSaveChecksSnapstateConflict(c *check.C) {
 	st, restore := s.createConflictingChange(c)
 	defer restore()
 
-	_, _, _, err := snapshotstate.Save(st, []string{"foo"}, nil, nil)
+	_, _, _, err := snapshotstate.Save(st, []string{"foo"}, nil, nil, nil)
 	c.Assert(err, check.NotNil)
 	c.Check(err, check.FitsTypeOf, &snapstate.ChangeConflictError{})
 }
@@ -459,7 +459,7 @@ func (snapshotSuite) TestSaveConflictsWithSnapstate(c *check.C) {
 	})
 
 	chg := st.NewChange("snapshot-save", "...")
-	_, _, saveTasks, err := snapshotstate.Save(st, nil, nil, nil)
+	_, _, saveTasks, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	chg.AddAll(saveTasks)
 
@@ -475,7 +475,7 @@ func (snapshotSuite) TestSaveChecksSnapstateConflictError(c *check.C) {
 	st := state.New(nil)
 	st.Lock()
 	defer st.Unlock()
-	_, _, _, err := snapshotstate.Save(st, nil, nil, nil)
+	_, _, _, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Check(err, check.ErrorMatches, "bzzt")
 }
 
@@ -486,7 +486,7 @@ func (snapshotSuite) TestSaveChecksSetIDError(c *check.C) {
 
 	st.Set("last-snapshot-set-id", "3/4")
 
-	_, _, _, err := snapshotstate.Save(st, nil, nil, nil)
+	_, _, _, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Check(err, check.ErrorMatches, ".* could not unmarshal .*")
 }
 
@@ -495,7 +495,7 @@ func (snapshotSuite) TestSaveNoSnapsInState(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, nil, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.HasLen, 0)
@@ -507,7 +507,7 @@ func (snapshotSuite) TestSaveSnapNotInstalled(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	setID, saved, taskset, err := snapshotstate.Save(st, []string{"foo"}, nil, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, []string{"foo"}, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, `snap "foo" is not installed`)
 	c.Check(setID, check.Equals, uint64(0))
 	c.Check(saved, check.HasLen, 0)
@@ -533,7 +533,7 @@ func (snapshotSuite) TestSaveSomeSnaps(c *check.C) {
 		"a-snap": {Exclude: []string{"$SNAP_COMMON/exclude", "$SNAP_DATA/exclude"}},
 	}
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, nil, snapshotOptions)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, nil, snapshotOptions, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"a-snap", "c-snap"})
@@ -579,7 +579,7 @@ func (s snapshotSuite) TestSaveOneSnap(c *check.C) {
 		Current: snap.R(1),
 	})
 
-	setID, saved, taskset, err := snapshotstate.Save(st, []string{"a-snap"}, []string{"a-user"}, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, []string{"a-snap"}, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"a-snap"})
@@ -597,6 +597,37 @@ func (s snapshotSuite) TestSaveOneSnap(c *check.C) {
 	})
 }
 
+func (snapshotSuite) TestSaveEncrypted(c *check.C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	snapstate.Set(st, "a-snap", &snapstate.SnapState{
+		Active: true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{
+			{RealName: "a-snap", Revision: snap.R(1)},
+		}),
+		Current: snap.R(1),
+	})
+
+	setID, _, taskset, err := snapshotstate.Save(st, []string{"a-snap"}, nil, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	tasks := taskset.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	var snapshot map[string]interface{}
+	c.Check(tasks[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	// the secret itself is not stored in the state
+	c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+		"set-id":    1.,
+		"snap":      "a-snap",
+		"current":   "unset",
+		"encrypted": true,
+	})
+	secret, err := snapshotstate.SnapshotSecret(tasks[0], setID)
+	c.Assert(err, check.IsNil)
+	c.Check(secret, check.DeepEquals, []byte("s3cr3t"))
+}
+
 func (snapshotSuite) TestSaveIntegration(c *check.C) {
 	if os.Geteuid() == 0 {
 		c.Skip("this test cannot run as root (runuser will fail)")
@@ -658,7 +689,7 @@ func (snapshotSuite) TestSaveIntegration(c *check.C) {
 		}
 	}
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, snapshotOptions)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, snapshotOptions, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"one-snap", "too-snap", "tri-snap"})
@@ -682,7 +713,7 @@ func (snapshotSuite) TestSaveIntegration(c *check.C) {
 		c.Check(r.Snapshot.Time.Before(tf), check.Equals, true)
 		c.Check(r.Snapshot.Size > 0, check.Equals, true)
 		c.Assert(r.Snapshot.SHA3_384, check.HasLen, 1)
-		c.Check(r.Snapshot.SHA3_384["user/a-user.tgz"], check.HasLen, 96)
+		c.Check(r.Snapshot.SHA3_384["user/a-user.tar"], check.HasLen, 96)
 
 		r.Snapshot.Time = time.Time{}
 		r.Snapshot.Size = 0
@@ -765,7 +796,7 @@ exec /bin/tar "$@"
 		c.Assert(os.Mkdir(filepath.Join(homedir, "snap", name, "common", "common-"+name), mode), check.IsNil)
 	}
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"one-snap", "too-snap", "tri-snap"})
@@ -865,7 +896,7 @@ exec /bin/tar "$@"
 	// these dir permissions (000) make tar unhappy
 	c.Assert(os.Mkdir(filepath.Join(homedir, "snap/tar-fail-snap/common/common-tar-fail-snap"), 00), check.IsNil)
 
-	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, nil)
+	setID, saved, taskset, err := snapshotstate.Save(st, nil, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(setID, check.Equals, uint64(1))
 	c.Check(saved, check.DeepEquals, []string{"tar-fail-snap"})
@@ -928,7 +959,7 @@ func (snapshotSuite) TestRestoreChecksIterError(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	_, _, err := snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err := snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, "bzzt")
 }
 
@@ -936,7 +967,7 @@ func (s snapshotSuite) TestRestoreChecksSnapstateConflicts(c *check.C) {
 	st, restore := s.createConflictingChange(c)
 	defer restore()
 
-	_, _, err := snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err := snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.NotNil)
 	c.Check(err, check.FitsTypeOf, &snapstate.ChangeConflictError{})
 
@@ -993,7 +1024,7 @@ func (snapshotSuite) TestRestoreConflictsWithSnapstate(c *check.C) {
 	})
 
 	chg := st.NewChange("snapshot-restore", "...")
-	_, restoreTasks, err := snapshotstate.Restore(st, 42, nil, nil)
+	_, restoreTasks, err := snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	chg.AddAll(restoreTasks)
 
@@ -1025,7 +1056,7 @@ func (snapshotSuite) TestRestoreChecksForgetConflicts(c *check.C) {
 	tsk.Set("snapshot-setup", map[string]int{"set-id": 42})
 	chg.AddTask(tsk)
 
-	_, _, err = snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, `cannot operate on snapshot set #42 while change \"1\" is in progress`)
 }
 
@@ -1060,7 +1091,7 @@ func (snapshotSuite) TestRestoreChecksChangesToSnapID(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	_, _, err = snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, `cannot restore snapshot for "a-snap": current snap \(ID 1234567…\) does not match snapshot \(ID 0987654…\)`)
 }
 
@@ -1101,7 +1132,7 @@ func (snapshotSuite) TestRestoreChecksChangesToEpoch(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	_, _, err = snapshotstate.Restore(st, 42, nil, nil)
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.ErrorMatches, `cannot restore snapshot for "a-snap": current snap \(epoch 17\) cannot read snapshot data \(epoch 42\)`)
 }
 
@@ -1142,7 +1173,7 @@ func (snapshotSuite) TestRestoreWorksWithCompatibleEpoch(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	found, taskset, err := snapshotstate.Restore(st, 42, nil, nil)
+	found, taskset, err := snapshotstate.Restore(st, 42, nil, nil, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(found, check.DeepEquals, []string{"a-snap"})
 	tasks := taskset.Tasks()
@@ -1179,7 +1210,7 @@ func (snapshotSuite) TestRestore(c *check.C) {
 	st.Lock()
 	defer st.Unlock()
 
-	found, taskset, err := snapshotstate.Restore(st, 42, []string{"a-snap", "b-snap"}, []string{"a-user"})
+	found, taskset, err := snapshotstate.Restore(st, 42, []string{"a-snap", "b-snap"}, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	c.Check(found, check.DeepEquals, []string{"a-snap"})
 	tasks := taskset.Tasks()
@@ -1198,6 +1229,198 @@ func (snapshotSuite) TestRestore(c *check.C) {
 	})
 }
 
+func (snapshotSuite) TestRestoreEncrypted(c *check.C) {
+	shotfile, err := os.Create(filepath.Join(c.MkDir(), "yadda.zip"))
+	c.Assert(err, check.IsNil)
+	defer shotfile.Close()
+	fakeIter := func(_ context.Context, f func(*backend.Reader) error) error {
+		c.Assert(f(&backend.Reader{
+			Snapshot: client.Snapshot{
+				SetID:      42,
+				Snap:       "a-snap",
+				Encryption: &client.SnapshotEncryption{Cipher: "aes-256-gcm-stream"},
+			},
+			File: shotfile,
+		}), check.IsNil)
+
+		return nil
+	}
+	defer snapshotstate.MockBackendIter(fakeIter)()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, nil, nil)
+	c.Assert(err, check.ErrorMatches, `cannot restore snapshot for "a-snap": snapshot is encrypted, a passphrase or key file is needed`)
+	_, taskset, err := snapshotstate.Restore(st, 42, nil, nil, nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	tasks := taskset.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	var snapshot map[string]interface{}
+	c.Check(tasks[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+		"set-id":    42.,
+		"snap":      "a-snap",
+		"filename":  shotfile.Name(),
+		"current":   "unset",
+		"encrypted": true,
+	})
+	secret, err := snapshotstate.SnapshotSecret(tasks[0], 42)
+	c.Assert(err, check.IsNil)
+	c.Check(secret, check.DeepEquals, []byte("s3cr3t"))
+
+	// another restore of the same set with another secret does not
+	// interfere with this one
+	_, taskset, err = snapshotstate.Restore(st, 42, nil, nil, nil, []byte("other"))
+	c.Assert(err, check.IsNil)
+	secret, err = snapshotstate.SnapshotSecret(taskset.Tasks()[0], 42)
+	c.Assert(err, check.IsNil)
+	c.Check(secret, check.DeepEquals, []byte("other"))
+	secret, err = snapshotstate.SnapshotSecret(tasks[0], 42)
+	c.Assert(err, check.IsNil)
+	c.Check(secret, check.DeepEquals, []byte("s3cr3t"))
+}
+
+func (snapshotSuite) TestRestoreNotEncryptedWithSecret(c *check.C) {
+	shotfile, err := os.Create(filepath.Join(c.MkDir(), "yadda.zip"))
+	c.Assert(err, check.IsNil)
+	defer shotfile.Close()
+	fakeIter := func(_ context.Context, f func(*backend.Reader) error) error {
+		c.Assert(f(&backend.Reader{
+			Snapshot: client.Snapshot{SetID: 42, Snap: "a-snap"},
+			File:     shotfile,
+		}), check.IsNil)
+
+		return nil
+	}
+	defer snapshotstate.MockBackendIter(fakeIter)()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, nil, []byte("s3cr3t"))
+	c.Assert(err, check.ErrorMatches, `cannot restore snapshot for "a-snap": snapshot is not encrypted`)
+}
+
+func (snapshotSuite) TestRestorePaths(c *check.C) {
+	shotfile, err := os.Create(filepath.Join(c.MkDir(), "yadda.zip"))
+	c.Assert(err, check.IsNil)
+	defer shotfile.Close()
+	fakeIter := func(_ context.Context, f func(*backend.Reader) error) error {
+		c.Assert(f(&backend.Reader{
+			Snapshot: client.Snapshot{SetID: 42, Snap: "a-snap"},
+			File:     shotfile,
+		}), check.IsNil)
+
+		return nil
+	}
+	defer snapshotstate.MockBackendIter(fakeIter)()
+
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	_, _, err = snapshotstate.Restore(st, 42, nil, nil, []string{"common/foo"}, nil)
+	c.Check(err, check.ErrorMatches, `cannot restore specific paths of other than exactly one snap`)
+	_, _, err = snapshotstate.Restore(st, 42, []string{"a-snap", "b-snap"}, nil, []string{"common/foo"}, nil)
+	c.Check(err, check.ErrorMatches, `cannot restore specific paths of other than exactly one snap`)
+	_, _, err = snapshotstate.Restore(st, 42, []string{"a-snap"}, nil, []string{"/etc/passwd"}, nil)
+	c.Check(err, check.ErrorMatches, `cannot restore snapshot for "a-snap": invalid path "/etc/passwd": must be a clean path relative to the snap data directory`)
+
+	found, taskset, err := snapshotstate.Restore(st, 42, []string{"a-snap"}, nil, []string{"common/foo", "x1"}, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(found, check.DeepEquals, []string{"a-snap"})
+	tasks := taskset.Tasks()
+	c.Assert(tasks, check.HasLen, 2)
+	var snapshot map[string]interface{}
+	c.Check(tasks[0].Get("snapshot-setup", &snapshot), check.IsNil)
+	c.Check(snapshot, check.DeepEquals, map[string]interface{}{
+		"set-id":   42.,
+		"snap":     "a-snap",
+		"filename": shotfile.Name(),
+		"paths":    []interface{}{"common/foo", "x1"},
+		"current":  "unset",
+	})
+}
+
+func (snapshotSuite) TestFiles(c *check.C) {
+	shotfile, err := os.Create(filepath.Join(c.MkDir(), "yadda.zip"))
+	c.Assert(err, check.IsNil)
+	defer shotfile.Close()
+	defer snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		for _, name := range []string{"a-snap", "b-snap"} {
+			c.Assert(f(&backend.Reader{
+				Snapshot: client.Snapshot{SetID: 42, Snap: name},
+				File:     shotfile,
+			}), check.IsNil)
+		}
+		return nil
+	})()
+	defer snapshotstate.MockBackendOpen(func(filename string, setID uint64) (*backend.Reader, error) {
+		c.Check(filename, check.Equals, shotfile.Name())
+		c.Check(setID, check.Equals, uint64(42))
+		return &backend.Reader{Snapshot: client.Snapshot{SetID: 42, Snap: "b-snap"}}, nil
+	})()
+	files := []client.SnapshotFile{{Path: "common"}, {User: "a-user", Path: "x1/foo", Size: 42}}
+	defer snapshotstate.MockBackendFiles(func(r *backend.Reader, _ context.Context, users []string) ([]client.SnapshotFile, error) {
+		c.Check(r.Snap, check.Equals, "b-snap")
+		c.Check(users, check.DeepEquals, []string{"a-user"})
+		return files, nil
+	})()
+
+	obtained, err := snapshotstate.Files(context.TODO(), 42, "b-snap", []string{"a-user"}, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(obtained, check.DeepEquals, files)
+
+	_, err = snapshotstate.Files(context.TODO(), 42, "c-snap", nil, nil)
+	c.Check(err, check.Equals, client.ErrSnapshotSnapsNotFound)
+	_, err = snapshotstate.Files(context.TODO(), 43, "b-snap", nil, nil)
+	c.Check(err, check.Equals, client.ErrSnapshotSetNotFound)
+}
+
+func (snapshotSuite) TestFilesEncrypted(c *check.C) {
+	shotfile, err := os.Create(filepath.Join(c.MkDir(), "yadda.zip"))
+	c.Assert(err, check.IsNil)
+	defer shotfile.Close()
+	encrypted := client.Snapshot{
+		SetID:      42,
+		Snap:       "a-snap",
+		Encryption: &client.SnapshotEncryption{Cipher: "aes-256-gcm-stream"},
+	}
+	defer snapshotstate.MockBackendIter(func(_ context.Context, f func(*backend.Reader) error) error {
+		c.Assert(f(&backend.Reader{Snapshot: encrypted, File: shotfile}), check.IsNil)
+		return nil
+	})()
+	defer snapshotstate.MockBackendOpen(func(string, uint64) (*backend.Reader, error) {
+		return &backend.Reader{Snapshot: encrypted}, nil
+	})()
+	var unlockSecret []byte
+	unlockErr := backend.ErrWrongKey
+	defer snapshotstate.MockBackendUnlock(func(_ *backend.Reader, secret []byte) error {
+		unlockSecret = secret
+		return unlockErr
+	})()
+	files := []client.SnapshotFile{{Path: "common"}}
+	defer snapshotstate.MockBackendFiles(func(*backend.Reader, context.Context, []string) ([]client.SnapshotFile, error) {
+		return files, nil
+	})()
+
+	_, err = snapshotstate.Files(context.TODO(), 42, "a-snap", nil, nil)
+	c.Check(err, check.Equals, client.ErrSnapshotEncrypted)
+	c.Check(unlockSecret, check.IsNil)
+
+	_, err = snapshotstate.Files(context.TODO(), 42, "a-snap", nil, []byte("secret"))
+	c.Check(err, check.Equals, snapshotstate.ErrWrongKey)
+
+	unlockErr = nil
+	obtained, err := snapshotstate.Files(context.TODO(), 42, "a-snap", nil, []byte("s3cr3t"))
+	c.Assert(err, check.IsNil)
+	c.Check(obtained, check.DeepEquals, files)
+	c.Check(unlockSecret, check.DeepEquals, []byte("s3cr3t"))
+}
+
 func (snapshotSuite) TestRestoreIntegration(c *check.C) {
 	testRestoreIntegration(c, dirs.UserHomeSnapDir, nil)
 }
@@ -1261,7 +1484,7 @@ func testRestoreIntegration(c *check.C, snapDataDir string, opts *dirs.SnapDirOp
 			c.Assert(os.MkdirAll(filepath.Join(home, snapDataDir, name, "common", "common-"+name), 0755), check.IsNil)
 		}
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"}, nil, opts)
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user", "b-user"}, nil, opts, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1270,7 +1493,7 @@ func testRestoreIntegration(c *check.C, snapDataDir string, opts *dirs.SnapDirOp
 	// remove b-user's home
 	c.Assert(os.RemoveAll(homedirB), check.IsNil)
 
-	found, taskset, err := snapshotstate.Restore(st, 42, nil, []string{"a-user", "b-user"})
+	found, taskset, err := snapshotstate.Restore(st, 42, nil, []string{"a-user", "b-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	sort.Strings(found)
 	c.Check(found, check.DeepEquals, []string{"one-snap", "too-snap", "tri-snap"})
@@ -1340,7 +1563,7 @@ func (snapshotSuite) TestRestoreIntegrationFails(c *check.C) {
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, fmt.Sprint(i+1), "canary-"+name), 0755), check.IsNil)
 		c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", name, "common", "common-"+name), 0755), check.IsNil)
 
-		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"}, nil, nil)
+		_, err := backend.Save(context.TODO(), 42, snapInfo, nil, []string{"a-user"}, nil, nil, nil)
 		c.Assert(err, check.IsNil)
 	}
 
@@ -1350,7 +1573,7 @@ func (snapshotSuite) TestRestoreIntegrationFails(c *check.C) {
 	c.Assert(os.MkdirAll(filepath.Join(homedir, "snap"), 0755), check.IsNil)
 	c.Assert(os.MkdirAll(filepath.Join(homedir, "snap", "too-snap"), 0), check.IsNil)
 
-	found, taskset, err := snapshotstate.Restore(st, 42, nil, []string{"a-user"})
+	found, taskset, err := snapshotstate.Restore(st, 42, nil, []string{"a-user"}, nil, nil)
 	c.Assert(err, check.IsNil)
 	sort.Strings(found)
 	c.Check(found, check.DeepEquals, []string{"one-snap", "too-snap", "tri-snap"})
@@ -33,6 +33,7 @@ import (
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/restart"
@@ -1045,6 +1046,16 @@ func computeSnapRefreshFailureSeverity(chg *state.Change, unlinkTask *state.Task
 	return snap.RefreshFailureSeverityNone
 }
 
+var autoRefreshMetric = metrics.NewCounter("snapd_auto_refresh_total",
+	"Number of auto-refresh changes that completed, by status.", "status")
+
+func countAutoRefreshOutcome(chg *state.Change, old, new state.Status) {
+	if chg.Kind() != "auto-refresh" || old.Ready() || !new.Ready() {
+		return
+	}
+	autoRefreshMetric.Inc(new.String())
+}
+
 func processFailedAutoRefresh(chg *state.Change, _ state.Status, new state.Status) {
 	if chg.Kind() != "auto-refresh" || new != state.ErrorStatus {
 		return
@@ -814,11 +814,11 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 		}
 		// Snap download succeeded, now try to download the snap icon
 		if iconURL == "" {
-			logger.Debugf("cannot download snap icon for %q: no icon URL", snapsup.SnapName())
+			t.Logger().Debugf("cannot download snap icon for %q: no icon URL", snapsup.SnapName())
 		} else {
 			timings.Run(perfTimings, "download-icon", fmt.Sprintf("download snap icon for %q", snapsup.SnapName()), func(timings.Measurer) {
 				if iconErr := theStore.DownloadIcon(ctx, snapsup.SnapName(), targetIconFn, iconURL); iconErr != nil {
-					logger.Debugf("cannot download snap icon for %q: %v", snapsup.SnapName(), iconErr)
+					t.Logger().Debugf("cannot download snap icon for %q: %v", snapsup.SnapName(), iconErr)
 				}
 			})
 		}
@@ -861,7 +861,7 @@ func waitForPreDownload(task *state.Task, snapsup *SnapSetup) error {
 			preTask.Set("waiting-tasks", taskIDs)
 		}
 
-		logger.Debugf("Download task %s will wait 1min for pre-download task %s", task.ID(), preTask.ID())
+		task.Logger().Debugf("Download task %s will wait 1min for pre-download task %s", task.ID(), preTask.ID())
 		return &state.Retry{After: 2 * time.Minute}
 
 	}
@@ -1277,7 +1277,7 @@ func (m *SnapManager) doMountSnap(t *state.Task, _ *tomb.Tomb) error {
 	for i := 0; i < 10; i++ {
 		_, readInfoErr = readInfo(snapsup.InstanceName(), snapsup.SideInfo, errorOnBroken)
 		if readInfoErr == nil {
-			logger.Debugf("snap %q (%v) available at %q", snapsup.InstanceName(), snapsup.Revision(), snapsup.placeInfo().MountDir())
+			t.Logger().Debugf("snap %q (%v) available at %q", snapsup.InstanceName(), snapsup.Revision(), snapsup.placeInfo().MountDir())
 			break
 		}
 		if _, ok := readInfoErr.(*snap.NotFoundError); !ok {
@@ -1315,7 +1315,7 @@ func (m *SnapManager) doMountSnap(t *state.Task, _ *tomb.Tomb) error {
 
 	if snapsup.Flags.RemoveSnapPath {
 		if err := os.Remove(snapsup.SnapPath); err != nil {
-			logger.Noticef("Failed to cleanup %s: %s", snapsup.SnapPath, err)
+			t.Logger().Noticef("Failed to cleanup %s: %s", snapsup.SnapPath, err)
 		}
 	}
 
@@ -2133,6 +2133,18 @@ func MockLinkSnapParticipants(ps []LinkSnapParticipant) (restore func()) {
 	}
 }
 
+// addSnapLifecycleNotice records a snap-lifecycle notice about the given
+// snap, with the action performed and the revision that is now current, if
+// any, as data.
+func addSnapLifecycleNotice(st *state.State, instanceName, action string, rev snap.Revision) error {
+	data := map[string]string{"action": action}
+	if !rev.Unset() {
+		data["revision"] = rev.String()
+	}
+	_, err := st.AddNotice(nil, state.SnapLifecycleNotice, instanceName, &state.AddNoticeOptions{Data: data})
+	return err
+}
+
 func notifyLinkParticipants(t *state.Task, snapsup *SnapSetup) {
 	st := t.State()
 	for _, p := range linkSnapParticipants {
@@ -2498,6 +2510,14 @@ func (m *SnapManager) doLinkSnap(t *state.Task, _ *tomb.Tomb) (err error) {
 	// Do at the end so we only preserve the new state if it worked.
 	Set(st, snapsup.InstanceName(), snapst)
 
+	// re-linking the current revision, as when enabling the snap, is not
+	// part of its lifecycle
+	if action := linkAction(snapsup, firstInstall); action != "refresh" || oldCurrent != snapst.Current {
+		if err := addSnapLifecycleNotice(st, snapsup.InstanceName(), action, snapst.Current); err != nil {
+			return err
+		}
+	}
+
 	// Notify link snap participants about link changes.
 	notifyLinkParticipants(t, snapsup)
 
@@ -2550,6 +2570,19 @@ func (m *SnapManager) doLinkSnap(t *state.Task, _ *tomb.Tomb) (err error) {
 	}
 }
 
+// linkAction returns the action that linking the snap is part of, as
+// recorded in snap-lifecycle notices.
+func linkAction(snapsup *SnapSetup, firstInstall bool) string {
+	switch {
+	case firstInstall:
+		return "install"
+	case snapsup.Revert:
+		return "revert"
+	default:
+		return "refresh"
+	}
+}
+
 func setMigrationFlagsInState(snapst *SnapState, snapsup *SnapSetup) {
 	if snapsup.MigratedHidden {
 		snapst.MigratedHidden = true
@@ -3002,6 +3035,14 @@ func (m *SnapManager) undoLinkSnap(t *state.Task, _ *tomb.Tomb) error {
 	// mark as inactive
 	Set(st, snapsup.InstanceName(), snapst)
 
+	// the revision that was current is relinked when undoing
+	// unlink-current-snap, if there is one
+	if action := linkAction(snapsup, firstInstall); action != "refresh" || oldCurrent != snapsup.Revision() {
+		if err := addSnapLifecycleNotice(st, snapsup.InstanceName(), "undo-"+action, oldCurrent); err != nil {
+			return err
+		}
+	}
+
 	// Notify link snap participants about link changes.
 	notifyLinkParticipants(t, snapsup)
 
@@ -3214,9 +3255,9 @@ func (m *SnapManager) startSnapServices(t *state.Task, _ *tomb.Tomb) error {
 	for _, svc := range snapst.LastActiveDisabledServices {
 		app, ok := currentInfo.Apps[svc]
 		if !ok {
-			logger.Noticef("previously disabled service %s no longer exists", svc)
+			t.Logger().Noticef("previously disabled service %s no longer exists", svc)
 		} else if !app.IsService() {
-			logger.Noticef("previously disabled service %s is now an app and not a service", svc)
+			t.Logger().Noticef("previously disabled service %s is now an app and not a service", svc)
 		}
 	}
 
@@ -3867,7 +3908,7 @@ func (m *SnapManager) doDiscardSnap(t *state.Task, _ *tomb.Tomb) error {
 
 		// try to remove the revision-agnostic store metadata
 		if err := backend.DiscardStoreMetadata(snapsup.SideInfo.SnapID, otherInstances); err != nil {
-			logger.Noticef("cannot remove store metadata for %q: %v", snapsup.InstanceName(), err)
+			t.Logger().Noticef("cannot remove store metadata for %q: %v", snapsup.InstanceName(), err)
 		}
 
 		// XXX: also remove sequence files?
@@ -3885,6 +3926,11 @@ func (m *SnapManager) doDiscardSnap(t *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 	Set(st, snapsup.InstanceName(), snapst)
+	if len(snapst.Sequence.Revisions) == 0 {
+		if err := addSnapLifecycleNotice(st, snapsup.InstanceName(), "remove", snap.Revision{}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -4064,7 +4110,7 @@ func (m *SnapManager) undoRemoveAliases(t *state.Task, _ *tomb.Tomb) error {
 
 	// The previous task's undo (unlink-current-snap) may have triggered a restart
 	// so if that is the case ensure we wait for it to happen here.
-	logger.Debugf("finish restart from undoRemoveAliases")
+	t.Logger().Debugf("finish restart from undoRemoveAliases")
 	if err := FinishRestart(t, snapsup, FinishRestartOptions{}); err != nil {
 		return err
 	}
@@ -4891,7 +4937,7 @@ func (m *SnapManager) doConditionalAutoRefresh(t *state.Task, tomb *tomb.Tomb) e
 	}
 
 	if len(snaps) == 0 {
-		logger.Debugf("refresh gating: no snaps to refresh")
+		t.Logger().Debugf("refresh gating: no snaps to refresh")
 		return nil
 	}
 
@@ -5376,7 +5422,7 @@ func (m *SnapManager) doDiscardOldKernelSnapSetup(t *state.Task, _ *tomb.Tomb) e
 
 	// Set the default to false for compatibility with older snapd (case of
 	// joint refresh of snapd and kernel).
-	logger.Debugf("finish restart from doDiscardOldKernelSnapSetup")
+	t.Logger().Debugf("finish restart from doDiscardOldKernelSnapSetup")
 	if err := FinishRestart(t, snapsup,
 		FinishRestartOptions{FinishRestartDefault: false}); err != nil {
 		return err
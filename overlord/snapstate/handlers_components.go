@@ -25,7 +25,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/snapstate/backend"
 	"github.com/snapcore/snapd/overlord/snapstate/sequence"
@@ -290,7 +289,7 @@ func (m *SnapManager) doMountComponent(t *state.Task, _ *tomb.Tomb) (err error)
 		compMntDir := cpi.MountDir()
 		_, readInfoErr = readComponentInfoAt(compMntDir, nil, csi)
 		if readInfoErr == nil {
-			logger.Debugf("component %q (%v) available at %q",
+			t.Logger().Debugf("component %q (%v) available at %q",
 				csi.Component, compSetup.Revision(), compMntDir)
 			break
 		}
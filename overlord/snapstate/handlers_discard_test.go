@@ -87,6 +87,9 @@ func (s *discardSnapSuite) TestDoDiscardSnapSuccess(c *C) {
 	c.Check(snapst.Sequence.Revisions, HasLen, 1)
 	c.Check(snapst.Current, Equals, snap.R(3))
 	c.Check(t.Status(), Equals, state.DoneStatus)
+
+	// the snap is still installed
+	c.Check(snapLifecycleNotices(c, s.state), HasLen, 0)
 }
 
 func (s *discardSnapSuite) TestDoDiscardSnapInQuotaGroup(c *C) {
@@ -163,6 +166,11 @@ func (s *discardSnapSuite) TestDoDiscardSnapToEmpty(c *C) {
 	var snapst snapstate.SnapState
 	err := snapstate.Get(s.state, "foo", &snapst)
 	c.Assert(err, testutil.ErrorIs, state.ErrNoState)
+
+	notices := snapLifecycleNotices(c, s.state)
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0]["key"], Equals, "foo")
+	c.Check(notices[0]["last-data"], DeepEquals, map[string]any{"action": "remove"})
 }
 
 func (s *discardSnapSuite) TestDoDiscardSnapErrorsForActive(c *C) {
@@ -155,6 +155,20 @@ func (s *linkSnapSuite) TestDoLinkSnapSuccess(c *C) {
 
 	// link snap participant was invoked
 	c.Check(lp.instanceNames, DeepEquals, []string{"foo"})
+
+	// and the installation was recorded
+	notices := snapLifecycleNotices(c, s.state)
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0]["key"], Equals, "foo")
+	c.Check(notices[0]["last-data"], DeepEquals, map[string]any{"action": "install", "revision": "33"})
+}
+
+func snapLifecycleNotices(c *C, st *state.State) []map[string]any {
+	var notices []map[string]any
+	for _, n := range st.Notices(&state.NoticeFilter{Types: []state.NoticeType{state.SnapLifecycleNotice}}) {
+		notices = append(notices, noticeToMap(c, n))
+	}
+	return notices
 }
 
 func (s *linkSnapSuite) TestDoLinkSnapSuccessWithCohort(c *C) {
@@ -426,6 +440,13 @@ func (s *linkSnapSuite) TestDoUndoLinkSnap(c *C) {
 
 	// link snap participant was invoked, once for do, once for undo.
 	c.Check(lp.instanceNames, DeepEquals, []string{"foo", "foo"})
+
+	// the installation was recorded, and then its undoing
+	notices := snapLifecycleNotices(c, s.state)
+	c.Assert(notices, HasLen, 1)
+	c.Check(notices[0]["key"], Equals, "foo")
+	c.Check(notices[0]["occurrences"], Equals, 2.0)
+	c.Check(notices[0]["last-data"], DeepEquals, map[string]any{"action": "undo-install"})
 }
 
 func (s *linkSnapSuite) TestDoUnlinkCurrentSnapWithIgnoreRunning(c *C) {
@@ -0,0 +1,279 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/boot"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/features"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// AutoConnectChanges is set by ifacestate to compute the IDs of the
+// connections that would be auto-connected if the snap was refreshed to the
+// given revision.
+var AutoConnectChanges func(st *state.State, info *snap.Info, deviceCtx DeviceContext) ([]string, error)
+
+// RefreshSimulation is the report of what a refresh would do.
+type RefreshSimulation struct {
+	// Snaps are the snaps that would be refreshed.
+	Snaps []*SimulatedRefresh `json:"snaps"`
+	// Skipped are the snaps that would not be refreshed, with the reason
+	// why.
+	Skipped []SimulatedSkip `json:"skipped,omitempty"`
+	// DiskSpace is the result of the check for the disk space needed by
+	// the refresh.
+	DiskSpace *SimulatedDiskSpace `json:"disk-space,omitempty"`
+}
+
+// SimulatedRefresh describes the refresh of one snap.
+type SimulatedRefresh struct {
+	InstanceName    string        `json:"name"`
+	CurrentRevision snap.Revision `json:"current-revision"`
+	Revision        snap.Revision `json:"revision"`
+	Version         string        `json:"version,omitempty"`
+	Channel         string        `json:"channel,omitempty"`
+	DownloadSize    int64         `json:"download-size"`
+	// DeltaSize is the size of the delta from the current revision, if
+	// the store offers one.
+	DeltaSize int64 `json:"delta-size,omitempty"`
+	// Reboot is whether the refresh would require rebooting the system.
+	Reboot bool `json:"reboot,omitempty"`
+	// RunningApps are the apps of the snap whose running processes would
+	// inhibit the refresh.
+	RunningApps []string `json:"running-apps,omitempty"`
+	// GatingSnaps are the snaps whose gate-auto-refresh hooks would be
+	// run before auto-refreshing the snap.
+	GatingSnaps []string `json:"gating-snaps,omitempty"`
+	// Conflict describes the change in progress the refresh would
+	// conflict with.
+	Conflict string `json:"conflict,omitempty"`
+	// AutoConnect are the IDs of the connections that would be
+	// auto-connected by the refresh.
+	AutoConnect []string `json:"auto-connect,omitempty"`
+}
+
+// SimulatedSkip describes a snap that would not be refreshed.
+type SimulatedSkip struct {
+	InstanceName string `json:"name"`
+	Reason       string `json:"reason"`
+}
+
+// SimulatedDiskSpace is the result of the check for the disk space needed
+// by a refresh.
+type SimulatedDiskSpace struct {
+	Path string `json:"path"`
+	// Required is the space needed by the refresh, including a safety
+	// margin.
+	Required uint64 `json:"required"`
+	// Error is set when there isn't enough space.
+	Error string `json:"error,omitempty"`
+}
+
+// SimulateUpdate goes through the same steps as UpdateWithGoal, looking up
+// the updates in the store and checking them against validation sets, holds
+// and refresh-control, but instead of creating the tasks of the refresh it
+// reports what the refresh would do. The state is not changed.
+func SimulateUpdate(ctx context.Context, st *state.State, goal UpdateGoal, opts Options) (*RefreshSimulation, error) {
+	if err := setDefaultSnapstateOptions(st, &opts); err != nil {
+		return nil, err
+	}
+
+	plan, err := goal.toUpdate(ctx, st, opts)
+	if err != nil {
+		return nil, err
+	}
+	sortComponentsOnTargets(plan.targets)
+
+	sim := &RefreshSimulation{Snaps: []*SimulatedRefresh{}}
+	skip := func(name, reason string) {
+		sim.Skipped = append(sim.Skipped, SimulatedSkip{InstanceName: name, Reason: reason})
+	}
+
+	// targets that stay at their current revision only switch channel or
+	// cohort
+	plan.filter(func(t target) (bool, error) {
+		return !t.snapst.IsInstalled() || t.snapst.Current != t.info.Revision || len(t.components) > 0, nil
+	})
+
+	for _, name := range plan.requested {
+		found := false
+		for _, t := range plan.targets {
+			if t.info.InstanceName() == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			skip(name, "no update available")
+		}
+	}
+
+	if plan.refreshAll() {
+		held, err := HeldSnaps(st, HoldGeneral)
+		if err != nil {
+			return nil, err
+		}
+		plan.filter(func(t target) (bool, error) {
+			holders, ok := held[t.info.InstanceName()]
+			if ok {
+				skip(t.info.InstanceName(), fmt.Sprintf("held by %s", strings.Join(holders, ", ")))
+			}
+			return !ok, nil
+		})
+	}
+
+	candidates := make(map[string]bool, len(plan.targets))
+	for _, t := range plan.targets {
+		candidates[t.info.InstanceName()] = true
+	}
+	if err := plan.validateAndFilterTargets(st, opts); err != nil {
+		return nil, err
+	}
+	for _, t := range plan.targets {
+		delete(candidates, t.info.InstanceName())
+	}
+	notValidated := make([]string, 0, len(candidates))
+	for name := range candidates {
+		notValidated = append(notValidated, name)
+	}
+	sort.Strings(notValidated)
+	for _, name := range notValidated {
+		skip(name, "refresh is not validated")
+	}
+
+	if len(plan.targets) == 0 {
+		return sim, nil
+	}
+
+	names := make([]string, 0, len(plan.targets))
+	installInfos := make([]minimalInstallInfo, 0, len(plan.targets))
+	for _, t := range plan.targets {
+		names = append(names, t.info.InstanceName())
+		installInfos = append(installInfos, installSnapInfo{t.info})
+	}
+
+	affected, err := affectedByRefresh(st, names)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := config.NewTransaction(st)
+	refreshAppAwareness, err := features.Flag(tr, features.RefreshAppAwareness)
+	if err != nil && !config.IsNoOption(err) {
+		return nil, err
+	}
+
+	for _, t := range plan.targets {
+		refresh, err := simulateTargetRefresh(st, t, opts, refreshAppAwareness)
+		if err != nil {
+			return nil, err
+		}
+		for gating, info := range affected {
+			if info.AffectingSnaps[refresh.InstanceName] {
+				refresh.GatingSnaps = append(refresh.GatingSnaps, gating)
+			}
+		}
+		sort.Strings(refresh.GatingSnaps)
+		sim.Snaps = append(sim.Snaps, refresh)
+	}
+
+	totalSize, err := installSize(st, installInfos, opts.UserID, opts.PrereqTracker)
+	if err != nil {
+		return nil, err
+	}
+	rootDir := dirs.SnapdStateDir(dirs.GlobalRootDir)
+	sim.DiskSpace = &SimulatedDiskSpace{
+		Path:     rootDir,
+		Required: safetyMarginDiskSpace(totalSize),
+	}
+	if err := checkForAvailableSpace(totalSize, installInfos, "refresh", rootDir); err != nil {
+		sim.DiskSpace.Error = err.Error()
+	}
+
+	return sim, nil
+}
+
+func simulateTargetRefresh(st *state.State, t target, opts Options, refreshAppAwareness bool) (*SimulatedRefresh, error) {
+	info := t.info
+	refresh := &SimulatedRefresh{
+		InstanceName: info.InstanceName(),
+		Revision:     info.Revision,
+		Version:      info.Version,
+		Channel:      t.setup.Channel,
+		DownloadSize: info.Size,
+	}
+
+	var currentInfo *snap.Info
+	if t.snapst.IsInstalled() {
+		var err error
+		currentInfo, err = t.snapst.CurrentInfo()
+		if err != nil {
+			return nil, err
+		}
+		refresh.CurrentRevision = currentInfo.Revision
+	}
+
+	for _, delta := range info.Deltas {
+		if delta.FromRevision == refresh.CurrentRevision.N && delta.ToRevision == info.Revision.N {
+			refresh.DeltaSize = delta.Size
+			break
+		}
+	}
+
+	// gadget refreshes need a reboot if they update the boot assets,
+	// which is only known once the gadget is downloaded
+	if !boot.Participant(info, info.Type(), opts.DeviceCtx).IsTrivial() ||
+		(info.Type() == snap.TypeGadget && !opts.DeviceCtx.Classic()) {
+		refresh.Reboot = true
+	}
+
+	if currentInfo != nil && refreshAppAwareness && !excludeFromRefreshAppAwareness(info.Type()) && !opts.Flags.IgnoreRunning {
+		err := refreshAppsCheck(currentInfo)
+		var busyErr *BusySnapError
+		if errors.As(err, &busyErr) {
+			refresh.RunningApps = busyErr.busyAppNames
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := CheckChangeConflictMany(st, []string{info.InstanceName()}, ""); err != nil {
+		refresh.Conflict = err.Error()
+	}
+
+	if AutoConnectChanges != nil {
+		autoConnect, err := AutoConnectChanges(st, info, opts.DeviceCtx)
+		if err != nil {
+			return nil, err
+		}
+		refresh.AutoConnect = autoConnect
+	}
+
+	return refresh, nil
+}
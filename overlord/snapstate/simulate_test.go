@@ -0,0 +1,159 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate_test
+
+import (
+	"context"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/snapstate/snapstatetest"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func (s *snapmgrTestSuite) TestSimulateUpdate(c *C) {
+	var freeSpaceChecked uint64
+	restore := snapstate.MockOsutilCheckFreeSpace(func(path string, sz uint64) error {
+		c.Check(path, Equals, filepath.Join(dirs.GlobalRootDir, "/var/lib/snapd"))
+		freeSpaceChecked = sz
+		return &osutil.NotEnoughDiskSpaceError{}
+	})
+	defer restore()
+	restore = snapstate.MockInstallSize(func(st *state.State, snaps []snapstate.MinimalInstallInfo, userID int, prqt snapstate.PrereqTracker) (uint64, error) {
+		c.Assert(snaps, HasLen, 1)
+		c.Check(snaps[0].InstanceName(), Equals, "some-snap")
+		return 123, nil
+	})
+	defer restore()
+	restore = snapstate.MockRefreshAppsCheck(func(info *snap.Info) error {
+		c.Check(info.Revision, Equals, snap.R(1))
+		return snapstate.NewBusySnapError(info, []int{42}, []string{"app"}, nil)
+	})
+	defer restore()
+	restore = testutil.Backup(&snapstate.AutoConnectChanges)
+	defer restore()
+	snapstate.AutoConnectChanges = func(st *state.State, info *snap.Info, deviceCtx snapstate.DeviceContext) ([]string, error) {
+		c.Check(info.Revision, Equals, snap.R(11))
+		return []string{"some-snap:network core:network"}, nil
+	}
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	si := &snap.SideInfo{RealName: "some-snap", SnapID: "some-snap-id", Revision: snap.R(1)}
+	snaptest.MockSnap(c, "name: some-snap\nversion: 1\napps:\n  app:\n    command: bin/app\n", si)
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Active:          true,
+		Sequence:        snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+		Current:         si.Revision,
+		SnapType:        "app",
+		TrackingChannel: "latest/stable",
+	})
+	otherSi := &snap.SideInfo{RealName: "some-other-snap", SnapID: "some-other-snap-id", Revision: snap.R(1)}
+	snaptest.MockSnap(c, "name: some-other-snap\nversion: 1\n", otherSi)
+	snapstate.Set(s.state, "some-other-snap", &snapstate.SnapState{
+		Active:   true,
+		Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{otherSi}),
+		Current:  otherSi.Revision,
+		SnapType: "app",
+	})
+	_, err := snapstate.HoldRefresh(s.state, snapstate.HoldGeneral, "system", 0, "some-other-snap")
+	c.Assert(err, IsNil)
+
+	sim, err := snapstate.SimulateUpdate(context.Background(), s.state, snapstate.StoreUpdateGoal(), snapstate.Options{})
+	c.Assert(err, IsNil)
+
+	c.Check(sim.Snaps, DeepEquals, []*snapstate.SimulatedRefresh{{
+		InstanceName:    "some-snap",
+		CurrentRevision: snap.R(1),
+		Revision:        snap.R(11),
+		Version:         "some-snapVer",
+		Channel:         "latest/stable",
+		RunningApps:     []string{"app"},
+		AutoConnect:     []string{"some-snap:network core:network"},
+	}})
+	c.Check(sim.Skipped, DeepEquals, []snapstate.SimulatedSkip{
+		{InstanceName: "some-other-snap", Reason: "held by system"},
+	})
+	c.Check(freeSpaceChecked, Equals, snapstate.SafetyMarginDiskSpace(123))
+	c.Assert(sim.DiskSpace, NotNil)
+	c.Check(sim.DiskSpace.Required, Equals, snapstate.SafetyMarginDiskSpace(123))
+	c.Check(sim.DiskSpace.Error, Matches, `insufficient space in .* to perform "refresh" change for the following snaps: some-snap`)
+
+	// nothing was changed
+	c.Check(s.state.Changes(), HasLen, 0)
+	c.Check(s.state.Tasks(), HasLen, 0)
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Current, Equals, snap.R(1))
+}
+
+func (s *snapmgrTestSuite) TestSimulateUpdateNoUpdateAndConflict(c *C) {
+	restore := snapstate.MockOsutilCheckFreeSpace(func(string, uint64) error { return nil })
+	defer restore()
+	restore = snapstate.MockRefreshAppsCheck(func(info *snap.Info) error { return nil })
+	defer restore()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, name := range []string{"some-snap", "other-snap"} {
+		si := &snap.SideInfo{RealName: name, SnapID: name + "-id", Revision: snap.R(1)}
+		snaptest.MockSnap(c, "name: "+name+"\nversion: 1\n", si)
+		snapstate.Set(s.state, name, &snapstate.SnapState{
+			Active:   true,
+			Sequence: snapstatetest.NewSequenceFromSnapSideInfos([]*snap.SideInfo{si}),
+			Current:  si.Revision,
+			SnapType: "app",
+		})
+	}
+
+	chg := s.state.NewChange("disable", "...")
+	t := s.state.NewTask("unlink-snap", "...")
+	t.Set("snap-setup", &snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: "some-snap"}})
+	chg.AddTask(t)
+
+	goal := snapstate.StoreUpdateGoal(
+		snapstate.StoreUpdate{InstanceName: "some-snap"},
+		snapstate.StoreUpdate{InstanceName: "other-snap"},
+	)
+	sim, err := snapstate.SimulateUpdate(context.Background(), s.state, goal, snapstate.Options{})
+	c.Assert(err, IsNil)
+
+	c.Assert(sim.Snaps, HasLen, 1)
+	c.Check(sim.Snaps[0].InstanceName, Equals, "some-snap")
+	c.Check(sim.Snaps[0].RunningApps, HasLen, 0)
+	c.Check(sim.Snaps[0].Conflict, Matches, `snap "some-snap" has "disable" change in progress`)
+	c.Check(sim.Skipped, DeepEquals, []snapstate.SimulatedSkip{
+		{InstanceName: "other-snap", Reason: "no update available"},
+	})
+	c.Assert(sim.DiskSpace, NotNil)
+	c.Check(sim.DiskSpace.Error, Equals, "")
+
+	c.Check(s.state.Changes(), HasLen, 1)
+	c.Check(s.state.Tasks(), HasLen, 1)
+}
@@ -815,8 +815,9 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 		return nil, fmt.Errorf("cannot generate request salt: %v", err)
 	}
 
+	mgrRunner := runner.ForManager("snapstate")
 	// this handler does nothing
-	runner.AddHandler("nop", func(t *state.Task, _ *tomb.Tomb) error {
+	mgrRunner.AddHandler("nop", func(t *state.Task, _ *tomb.Tomb) error {
 		return nil
 	}, nil)
 
@@ -824,70 +825,78 @@ func Manager(st *state.State, runner *state.TaskRunner) (*SnapManager, error) {
 
 	// TODO: no undo handler here, we may use the GC for this and just
 	// remove anything that is not referenced anymore
-	runner.AddHandler("prerequisites", m.doPrerequisites, nil)
-	runner.AddHandler("prepare-snap", m.doPrepareSnap, m.undoPrepareSnap)
-	runner.AddHandler("download-snap", m.doDownloadSnap, m.undoPrepareSnap)
-	runner.AddHandler("mount-snap", m.doMountSnap, m.undoMountSnap)
-	runner.AddHandler("unlink-current-snap", m.doUnlinkCurrentSnap, m.undoUnlinkCurrentSnap)
-	runner.AddHandler("copy-snap-data", m.doCopySnapData, m.undoCopySnapData)
+	mgrRunner.AddHandler("prerequisites", m.doPrerequisites, nil)
+	mgrRunner.AddHandler("prepare-snap", m.doPrepareSnap, m.undoPrepareSnap)
+	mgrRunner.AddHandler("download-snap", m.doDownloadSnap, m.undoPrepareSnap)
+	mgrRunner.AddHandler("mount-snap", m.doMountSnap, m.undoMountSnap)
+	mgrRunner.AddHandler("unlink-current-snap", m.doUnlinkCurrentSnap, m.undoUnlinkCurrentSnap)
+	mgrRunner.AddHandler("copy-snap-data", m.doCopySnapData, m.undoCopySnapData)
 	runner.AddCleanup("copy-snap-data", m.cleanupCopySnapData)
-	runner.AddHandler("link-snap", m.doLinkSnap, m.undoLinkSnap)
-	runner.AddHandler("start-snap-services", m.startSnapServices, m.undoStartSnapServices)
-	runner.AddHandler("switch-snap-channel", m.doSwitchSnapChannel, nil)
-	runner.AddHandler("toggle-snap-flags", m.doToggleSnapFlags, nil)
-	runner.AddHandler("check-rerefresh", m.doCheckReRefresh, nil)
-	runner.AddHandler("conditional-auto-refresh", m.doConditionalAutoRefresh, nil)
+	mgrRunner.AddHandler("link-snap", m.doLinkSnap, m.undoLinkSnap)
+	mgrRunner.AddHandler("start-snap-services", m.startSnapServices, m.undoStartSnapServices)
+	mgrRunner.AddHandler("switch-snap-channel", m.doSwitchSnapChannel, nil)
+	mgrRunner.AddHandler("toggle-snap-flags", m.doToggleSnapFlags, nil)
+	mgrRunner.AddHandler("check-rerefresh", m.doCheckReRefresh, nil)
+	mgrRunner.AddHandler("conditional-auto-refresh", m.doConditionalAutoRefresh, nil)
 
 	// specific set-up for the kernel snap
-	runner.AddHandler("prepare-kernel-snap", m.doPrepareKernelSnap, m.undoPrepareKernelSnap)
-	runner.AddHandler("discard-old-kernel-snap-setup", m.doDiscardOldKernelSnapSetup, m.undoDiscardOldKernelSnapSetup)
+	mgrRunner.AddHandler("prepare-kernel-snap", m.doPrepareKernelSnap, m.undoPrepareKernelSnap)
+	mgrRunner.AddHandler("discard-old-kernel-snap-setup", m.doDiscardOldKernelSnapSetup, m.undoDiscardOldKernelSnapSetup)
 
 	// FIXME: drop the task entirely after a while
 	// (having this wart here avoids yet-another-patch)
-	runner.AddHandler("cleanup", func(*state.Task, *tomb.Tomb) error { return nil }, nil)
+	mgrRunner.AddHandler("cleanup", func(*state.Task, *tomb.Tomb) error { return nil }, nil)
 
 	// remove related
-	runner.AddHandler("stop-snap-services", m.stopSnapServices, m.undoStopSnapServices)
-	runner.AddHandler("kill-snap-apps", m.doKillSnapApps, m.undoKillSnapApps)
-	runner.AddHandler("unlink-snap", m.doUnlinkSnap, m.undoUnlinkSnap)
-	runner.AddHandler("clear-snap", m.doClearSnapData, nil)
-	runner.AddHandler("discard-snap", m.doDiscardSnap, nil)
+	mgrRunner.AddHandler("stop-snap-services", m.stopSnapServices, m.undoStopSnapServices)
+	mgrRunner.AddHandler("kill-snap-apps", m.doKillSnapApps, m.undoKillSnapApps)
+	mgrRunner.AddHandler("unlink-snap", m.doUnlinkSnap, m.undoUnlinkSnap)
+	mgrRunner.AddHandler("clear-snap", m.doClearSnapData, nil)
+	mgrRunner.AddHandler("discard-snap", m.doDiscardSnap, nil)
 
 	// alias related
 	// FIXME: drop the task entirely after a while
-	runner.AddHandler("clear-aliases", func(*state.Task, *tomb.Tomb) error { return nil }, nil)
-	runner.AddHandler("set-auto-aliases", m.doSetAutoAliases, m.undoRefreshAliases)
-	runner.AddHandler("setup-aliases", m.doSetupAliases, m.undoSetupAliases)
-	runner.AddHandler("refresh-aliases", m.doRefreshAliases, m.undoRefreshAliases)
-	runner.AddHandler("prune-auto-aliases", m.doPruneAutoAliases, m.undoRefreshAliases)
-	runner.AddHandler("remove-aliases", m.doRemoveAliases, m.undoRemoveAliases)
-	runner.AddHandler("alias", m.doAlias, m.undoRefreshAliases)
-	runner.AddHandler("unalias", m.doUnalias, m.undoRefreshAliases)
-	runner.AddHandler("disable-aliases", m.doDisableAliases, m.undoRefreshAliases)
-	runner.AddHandler("prefer-aliases", m.doPreferAliases, m.undoRefreshAliases)
+	mgrRunner.AddHandler("clear-aliases", func(*state.Task, *tomb.Tomb) error { return nil }, nil)
+	mgrRunner.AddHandler("set-auto-aliases", m.doSetAutoAliases, m.undoRefreshAliases)
+	mgrRunner.AddHandler("setup-aliases", m.doSetupAliases, m.undoSetupAliases)
+	mgrRunner.AddHandler("refresh-aliases", m.doRefreshAliases, m.undoRefreshAliases)
+	mgrRunner.AddHandler("prune-auto-aliases", m.doPruneAutoAliases, m.undoRefreshAliases)
+	mgrRunner.AddHandler("remove-aliases", m.doRemoveAliases, m.undoRemoveAliases)
+	mgrRunner.AddHandler("alias", m.doAlias, m.undoRefreshAliases)
+	mgrRunner.AddHandler("unalias", m.doUnalias, m.undoRefreshAliases)
+	mgrRunner.AddHandler("disable-aliases", m.doDisableAliases, m.undoRefreshAliases)
+	mgrRunner.AddHandler("prefer-aliases", m.doPreferAliases, m.undoRefreshAliases)
 
 	// misc
-	runner.AddHandler("switch-snap", m.doSwitchSnap, nil)
-	runner.AddHandler("migrate-snap-home", m.doMigrateSnapHome, m.undoMigrateSnapHome)
+	mgrRunner.AddHandler("switch-snap", m.doSwitchSnap, nil)
+	mgrRunner.AddHandler("migrate-snap-home", m.doMigrateSnapHome, m.undoMigrateSnapHome)
 	// no undo for now since it's last task in valset auto-resolution change
-	runner.AddHandler("enforce-validation-sets", m.doEnforceValidationSets, nil)
-	runner.AddHandler("pre-download-snap", m.doPreDownloadSnap, nil)
+	mgrRunner.AddHandler("enforce-validation-sets", m.doEnforceValidationSets, nil)
+	mgrRunner.AddHandler("pre-download-snap", m.doPreDownloadSnap, nil)
 
 	// component tasks
-	runner.AddHandler("prepare-component", m.doPrepareComponent, nil)
-	runner.AddHandler("download-component", m.doDownloadComponent, nil)
-	runner.AddHandler("mount-component", m.doMountComponent, m.undoMountComponent)
-	runner.AddHandler("unlink-current-component", m.doUnlinkCurrentComponent, m.undoUnlinkCurrentComponent)
-	runner.AddHandler("link-component", m.doLinkComponent, m.undoLinkComponent)
-	runner.AddHandler("unlink-component", m.doUnlinkComponent, m.undoUnlinkComponent)
+	mgrRunner.AddHandler("prepare-component", m.doPrepareComponent, nil)
+	mgrRunner.AddHandler("download-component", m.doDownloadComponent, nil)
+	mgrRunner.AddHandler("mount-component", m.doMountComponent, m.undoMountComponent)
+	mgrRunner.AddHandler("unlink-current-component", m.doUnlinkCurrentComponent, m.undoUnlinkCurrentComponent)
+	mgrRunner.AddHandler("link-component", m.doLinkComponent, m.undoLinkComponent)
+	mgrRunner.AddHandler("unlink-component", m.doUnlinkComponent, m.undoUnlinkComponent)
 	// We cannot undo much after a component file is removed. And it is the
 	// last task anyway.
-	runner.AddHandler("discard-component", m.doDiscardComponent, nil)
-	runner.AddHandler("prepare-kernel-modules-components", m.doPrepareKernelModulesComponents, m.undoPrepareKernelModulesComponents)
+	mgrRunner.AddHandler("discard-component", m.doDiscardComponent, nil)
+	mgrRunner.AddHandler("prepare-kernel-modules-components", m.doPrepareKernelModulesComponents, m.undoPrepareKernelModulesComponents)
+
+	// downloads are resumed or started over, so changes can be retried
+	// when they fail
+	runner.MarkIdempotent("download-snap")
+	runner.MarkIdempotent("pre-download-snap")
+	runner.MarkIdempotent("download-component")
 
 	// control serialisation
 	runner.AddBlocked(m.blockedTask)
 
+	runner.AddLogAttrs(snapLogAttrs)
+
 	RegisterAffectedSnapsByKind("conditional-auto-refresh", conditionalAutoRefreshAffectedSnaps)
 
 	return m, nil
@@ -908,6 +917,8 @@ func (m *SnapManager) StartUp() error {
 		processInhibitedAutoRefresh(chg, old, new)
 		// This handler implements marks failed snaps auto-refresh attempts for backoff.
 		processFailedAutoRefresh(chg, old, new)
+		// This handler counts the outcomes of auto-refreshes.
+		countAutoRefreshOutcome(chg, old, new)
 	})
 
 	if CheckExpectedRestart(m.state) == ErrUnexpectedRuntimeRestart {
@@ -955,6 +966,16 @@ func genRefreshRequestSalt(st *state.State) error {
 	return nil
 }
 
+// snapLogAttrs returns the snap a task is about, if it has a snap-setup,
+// for structured logs.
+func snapLogAttrs(t *state.Task) []any {
+	snapsup, err := TaskSnapSetup(t)
+	if err != nil {
+		return nil
+	}
+	return []any{"snap", snapsup.InstanceName()}
+}
+
 func (m *SnapManager) blockedTask(cand *state.Task, running []*state.Task) bool {
 	// Serialize "prerequisites", the state lock is not enough as
 	// Install() inside doPrerequisites() will unlock to talk to
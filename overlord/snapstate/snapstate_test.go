@@ -489,6 +489,27 @@ func (s *snapmgrTestSuite) TestStore(c *C) {
 	c.Check(store2, Equals, sto)
 }
 
+func (s *snapmgrTestSuite) TestRetryFailedDownload(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	for _, kind := range []string{"download-snap", "pre-download-snap", "download-component"} {
+		chg := s.state.NewChange("foo", "...")
+		t := s.state.NewTask(kind, "...")
+		chg.AddTask(t)
+		t.SetStatus(state.ErrorStatus)
+		c.Check(s.o.TaskRunner().RetryChange(chg), IsNil, Commentf(kind))
+		c.Check(t.Status(), Equals, state.DoStatus)
+	}
+
+	// others are not safe to run again
+	chg := s.state.NewChange("foo", "...")
+	t := s.state.NewTask("link-snap", "Make foo available")
+	chg.AddTask(t)
+	t.SetStatus(state.ErrorStatus)
+	c.Check(s.o.TaskRunner().RetryChange(chg), ErrorMatches, `cannot retry change .*: task .* \(Make foo available\) cannot be run again`)
+}
+
 func (s *snapmgrTestSuite) TestStoreWithDeviceContext(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -145,6 +145,7 @@ type Change struct {
 	summary                  string
 	status                   Status
 	clean                    bool
+	paused                   bool
 	data                     customData
 	taskIDs                  []string
 	ready                    chan struct{}
@@ -181,6 +182,7 @@ type marshalledChange struct {
 	Summary string                      `json:"summary"`
 	Status  Status                      `json:"status"`
 	Clean   bool                        `json:"clean,omitempty"`
+	Paused  bool                        `json:"paused,omitempty"`
 	Data    map[string]*json.RawMessage `json:"data,omitempty"`
 	TaskIDs []string                    `json:"task-ids,omitempty"`
 
@@ -203,6 +205,7 @@ func (c *Change) MarshalJSON() ([]byte, error) {
 		Summary: c.summary,
 		Status:  c.status,
 		Clean:   c.clean,
+		Paused:  c.paused,
 		Data:    c.data,
 		TaskIDs: c.taskIDs,
 
@@ -213,6 +216,13 @@ func (c *Change) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// writing is called before modifying the change, marking it and its tasks
+// as modified.
+func (c *Change) writing() {
+	c.state.modifying()
+	c.state.dirtyChange(c.id, true)
+}
+
 // UnmarshalJSON makes Change a json.Unmarshaller
 func (c *Change) UnmarshalJSON(data []byte) error {
 	if c.state != nil {
@@ -228,6 +238,7 @@ func (c *Change) UnmarshalJSON(data []byte) error {
 	c.summary = unmarshalled.Summary
 	c.status = unmarshalled.Status
 	c.clean = unmarshalled.Clean
+	c.paused = unmarshalled.Paused
 	custData := unmarshalled.Data
 	if custData == nil {
 		custData = make(customData)
@@ -268,7 +279,8 @@ func (c *Change) Summary() string {
 // Set associates value with key for future consulting by managers.
 // The provided value must properly marshal and unmarshal with encoding/json.
 func (c *Change) Set(key string, value interface{}) {
-	c.state.writing()
+	c.state.modifying()
+	c.state.dirtyChange(c.id, false)
 	c.data.set(key, value)
 }
 
@@ -456,12 +468,13 @@ func (c *Change) notifyStatusChange(new Status) {
 			logger.Panicf(`internal error: failed to add "change-update" notice on status change: %v`, err)
 		}
 		c.lastRecordedNoticeStatus = new
+		c.state.dirtyChange(c.id, false)
 	}
 }
 
 // SetStatus sets the change status, overriding the default behavior (see Status method).
 func (c *Change) SetStatus(s Status) {
-	c.state.writing()
+	c.writing()
 	c.status = s
 	if s.Ready() {
 		c.markReady()
@@ -626,7 +639,7 @@ func (c *Change) State() *State {
 // AddTask registers a task as required for the state change to
 // be accomplished.
 func (c *Change) AddTask(t *Task) {
-	c.state.writing()
+	c.writing()
 	if t.change != "" {
 		panic(fmt.Sprintf("internal error: cannot add one %q task to multiple changes", t.Kind()))
 	}
@@ -637,7 +650,7 @@ func (c *Change) AddTask(t *Task) {
 // AddAll registers all tasks in the set as required for the state
 // change to be accomplished.
 func (c *Change) AddAll(ts *TaskSet) {
-	c.state.writing()
+	c.writing()
 	for _, t := range ts.tasks {
 		c.AddTask(t)
 	}
@@ -674,9 +687,11 @@ func (c *Change) LaneTasks(lanes ...int) []*Task {
 }
 
 // Abort flags the change for cancellation, whether in progress or not.
-// Cancellation will proceed at the next ensure pass.
+// Cancellation will proceed at the next ensure pass, resuming the change
+// if paused.
 func (c *Change) Abort() {
-	c.state.writing()
+	c.writing()
+	c.paused = false
 	tasks := make([]*Task, len(c.taskIDs))
 	for i, tid := range c.taskIDs {
 		tasks[i] = c.state.tasks[tid]
@@ -684,18 +699,153 @@ func (c *Change) Abort() {
 	c.abortTasks(tasks, make(map[int]bool), make(map[string]bool))
 }
 
+// At schedules all the tasks of the change that are not ready to happen no
+// earlier than when. See Task.At.
+func (c *Change) At(when time.Time) {
+	c.writing()
+	for _, tid := range c.taskIDs {
+		c.state.tasks[tid].At(when)
+	}
+}
+
+// ScheduledTime returns the time the change is held until, as set with At,
+// if none of its tasks was run yet, or the zero time otherwise.
+func (c *Change) ScheduledTime() time.Time {
+	c.state.reading()
+	var scheduled time.Time
+	for _, tid := range c.taskIDs {
+		t := c.state.tasks[tid]
+		if t.Status() != DoStatus || t.AtTime().IsZero() {
+			return time.Time{}
+		}
+		if scheduled.IsZero() || t.AtTime().Before(scheduled) {
+			scheduled = t.AtTime()
+		}
+	}
+	if !scheduled.After(timeNow()) {
+		return time.Time{}
+	}
+	return scheduled
+}
+
+// heldUntil returns the latest time any task of the change was held until
+// with At, or the zero time if none was.
+func (c *Change) heldUntil() time.Time {
+	var held time.Time
+	for _, tid := range c.taskIDs {
+		if at := c.state.tasks[tid].AtTime(); at.After(held) {
+			held = at
+		}
+	}
+	return held
+}
+
+// Pause holds the change at task boundaries: tasks already running go on
+// until they are done, but no other task of the change is started until it
+// is resumed. The tasks of a paused change that error out don't cause the
+// other tasks in their lanes to be aborted until then either, so that the
+// change can be retried instead.
+func (c *Change) Pause() {
+	c.writing()
+	c.paused = true
+}
+
+// IsPaused returns whether the change is paused. See Pause.
+func (c *Change) IsPaused() bool {
+	c.state.reading()
+	return c.paused
+}
+
+// Resume continues a paused change, aborting the lanes of the tasks that
+// errored out while it was paused.
+func (c *Change) Resume() {
+	c.writing()
+	c.resume()
+}
+
+func (c *Change) resume() {
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	var lanes []int
+	for _, tid := range c.taskIDs {
+		t := c.state.tasks[tid]
+		if t.Status() == ErrorStatus {
+			lanes = append(lanes, t.Lanes()...)
+		}
+	}
+	if len(lanes) > 0 {
+		c.abortLanes(lanes, make(map[int]bool), make(map[string]bool))
+	}
+}
+
+// retry runs again the tasks of a change that errored out, provided no
+// task was undone because of it yet and the tasks can be run again, and
+// the tasks held because of them. It also resumes the change if paused.
+// Unless the change was paused, the lanes of a failed task are aborted
+// right away, so only a change whose failed task had nothing done before
+// it in its lanes can be retried.
+func (c *Change) retry(canRunAgain func(t *Task) bool) error {
+	c.writing()
+	var failed []*Task
+	for _, tid := range c.taskIDs {
+		t := c.state.tasks[tid]
+		switch t.Status() {
+		case ErrorStatus:
+			if !canRunAgain(t) {
+				return fmt.Errorf("cannot retry change %s: task %s (%s) cannot be run again", c.id, t.ID(), t.Summary())
+			}
+			failed = append(failed, t)
+		case AbortStatus, UndoStatus, UndoingStatus, UndoneStatus:
+			return fmt.Errorf("cannot retry change %s: it is already being undone (changes are undone as soon as a task fails unless paused before)", c.id)
+		}
+	}
+	if len(failed) == 0 {
+		return fmt.Errorf("cannot retry change %s: no task failed", c.id)
+	}
+
+	// the change is no longer ready
+	c.status = DefaultStatus
+	if c.IsReady() {
+		c.ready = make(chan struct{})
+	}
+	c.readyTime = time.Time{}
+	c.paused = false
+
+	for _, tid := range c.taskIDs {
+		t := c.state.tasks[tid]
+		old := t.Status()
+		switch old {
+		case ErrorStatus, HoldStatus:
+			// not going through Task.SetStatus as the change
+			// can only become unready here
+			t.status = DoStatus
+			t.readyTime = time.Time{}
+			t.Logf("Retrying")
+			c.state.notifyTaskStatusChangedHandlers(t, old, DoStatus)
+		}
+	}
+	c.notifyStatusChange(c.Status())
+	return nil
+}
+
 // AbortLanes aborts all tasks in the provided lanes and any tasks waiting on them,
 // except for tasks that are also in a healthy lane (not aborted, and not waiting
-// on aborted).
+// on aborted). A paused change is resumed, as otherwise the aborted tasks
+// would never be undone.
 func (c *Change) AbortLanes(lanes []int) {
-	c.state.writing()
+	c.writing()
+	c.resume()
 	c.abortLanes(lanes, make(map[int]bool), make(map[string]bool))
 }
 
 // AbortUnreadyLanes aborts the tasks from lanes that aren't fully ready, where
-// a ready lane is one in which all tasks are ready.
+// a ready lane is one in which all tasks are ready. A paused change is
+// resumed, as otherwise the aborted tasks would never be undone.
 func (c *Change) AbortUnreadyLanes() {
-	c.state.writing()
+	c.writing()
+	c.resume()
 	c.abortUnreadyLanes()
 }
 
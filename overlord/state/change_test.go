@@ -20,6 +20,7 @@
 package state_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,6 +32,7 @@ import (
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
 )
 
 type changeSuite struct{}
@@ -56,6 +58,70 @@ func (cs *changeSuite) TestNewChange(c *C) {
 	c.Check(n["occurrences"], Equals, 1.0)
 }
 
+func (cs *changeSuite) TestPauseResume(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("install", "summary...")
+	c.Check(chg.IsPaused(), Equals, false)
+	chg.Pause()
+	c.Check(chg.IsPaused(), Equals, true)
+
+	// it's kept
+	data, err := json.Marshal(st)
+	c.Assert(err, IsNil)
+	c.Check(string(data), testutil.Contains, `"paused":true`)
+	st2, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st2.Lock()
+	c.Check(st2.Change(chg.ID()).IsPaused(), Equals, true)
+	st2.Unlock()
+
+	chg.Resume()
+	c.Check(chg.IsPaused(), Equals, false)
+	data, err = json.Marshal(st)
+	c.Assert(err, IsNil)
+	c.Check(string(data), Not(testutil.Contains), `"paused"`)
+}
+
+func (cs *changeSuite) TestAt(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("install", "summary...")
+	t1 := st.NewTask("download", "1...")
+	t2 := st.NewTask("link", "2...")
+	t2.WaitFor(t1)
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	c.Check(chg.ScheduledTime().IsZero(), Equals, true)
+
+	when := time.Now().Add(time.Hour)
+	chg.At(when)
+	c.Check(t1.AtTime().Equal(when), Equals, true)
+	c.Check(t2.AtTime().Equal(when), Equals, true)
+	c.Check(chg.ScheduledTime().Equal(when), Equals, true)
+
+	// it's kept
+	data, err := json.Marshal(st)
+	c.Assert(err, IsNil)
+	st2, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st2.Lock()
+	c.Check(st2.Change(chg.ID()).ScheduledTime().Equal(when), Equals, true)
+	st2.Unlock()
+
+	// not once it's past
+	restore := state.MockTime(when.Add(time.Second))
+	c.Check(chg.ScheduledTime().IsZero(), Equals, true)
+	restore()
+
+	// nor once a task has run
+	t1.SetStatus(state.DoingStatus)
+	c.Check(chg.ScheduledTime().IsZero(), Equals, true)
+}
+
 func (cs *changeSuite) TestReadyTime(c *C) {
 	st := state.New(nil)
 	st.Lock()
@@ -0,0 +1,196 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/snapcore/snapd/logger"
+)
+
+// A DeltaBackend is a Backend that can checkpoint the state from only the
+// parts of it that were modified since the last checkpoint, which saves
+// marshalling it whole on every unlock.
+type DeltaBackend interface {
+	Backend
+	// CanCheckpointDelta returns whether CheckpointDelta can be used
+	// for the next checkpoint, otherwise Checkpoint is.
+	CanCheckpointDelta() bool
+	// CheckpointDelta saves the modifications to the state since the
+	// last checkpoint.
+	CheckpointDelta(delta *Delta) error
+}
+
+// A Delta holds the parts of the state modified since the last
+// checkpoint, as they are marshalled in the whole state.
+type Delta struct {
+	// top holds the top-level entries of the state that are not
+	// sections, along with the warnings and notices if modified; the
+	// entries omitted from the whole state are nil
+	top map[string]json.RawMessage
+	// sections holds the modified elements of the data, changes and
+	// tasks, the removed ones are nil
+	sections map[string]map[string]json.RawMessage
+}
+
+// dirtyEntries keeps track of the parts of the state modified since the
+// last checkpoint, for backends that checkpoint only those.
+type dirtyEntries struct {
+	// all is set when what was modified is not known in detail
+	all  bool
+	data map[string]bool
+	// changes holds the modified changes, and whether their tasks
+	// were modified too
+	changes  map[string]bool
+	tasks    map[string]bool
+	warnings bool
+	notices  bool
+}
+
+func markDirty(m *map[string]bool, key string, v bool) {
+	if *m == nil {
+		*m = make(map[string]bool)
+	}
+	(*m)[key] = (*m)[key] || v
+}
+
+// modifying is like writing, but the caller marks itself what it modifies
+// with the dirty* methods.
+func (s *State) modifying() {
+	s.modified = true
+	if atomic.LoadInt32(&s.muC) != 1 {
+		panic("internal error: accessing state without lock")
+	}
+}
+
+func (s *State) dirtyData(key string) {
+	if s.trackDirty {
+		markDirty(&s.dirty.data, key, false)
+	}
+}
+
+// dirtyChange marks the given change as modified, and all its tasks too if
+// withTasks is set.
+func (s *State) dirtyChange(id string, withTasks bool) {
+	if s.trackDirty {
+		markDirty(&s.dirty.changes, id, withTasks)
+	}
+}
+
+func (s *State) dirtyTask(id string) {
+	if s.trackDirty {
+		markDirty(&s.dirty.tasks, id, false)
+	}
+}
+
+func (s *State) dirtyWarnings() {
+	s.dirty.warnings = true
+}
+
+func (s *State) dirtyNotices() {
+	s.dirty.notices = true
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// this shouldn't happen, because the actual delicate serializing happens at various Set()s
+		logger.Panicf("internal error: could not marshal state for checkpointing: %v", err)
+	}
+	return data
+}
+
+// delta returns what was modified since the last checkpoint.
+func (s *State) delta() *Delta {
+	d := &Delta{
+		top: map[string]json.RawMessage{
+			"last-change-id":        mustMarshal(s.lastChangeId),
+			"last-task-id":          mustMarshal(s.lastTaskId),
+			"last-lane-id":          mustMarshal(s.lastLaneId),
+			"last-notice-id":        mustMarshal(s.lastNoticeId),
+			"last-notice-timestamp": nil,
+		},
+		sections: map[string]map[string]json.RawMessage{
+			"data":    make(map[string]json.RawMessage, len(s.dirty.data)),
+			"changes": make(map[string]json.RawMessage, len(s.dirty.changes)),
+			"tasks":   make(map[string]json.RawMessage, len(s.dirty.tasks)),
+		},
+	}
+	if !s.lastNoticeTimestamp.IsZero() {
+		d.top["last-notice-timestamp"] = mustMarshal(s.lastNoticeTimestamp)
+	}
+	if s.dirty.warnings {
+		d.top["warnings"] = nil
+		if warnings := s.flattenWarnings(); len(warnings) > 0 {
+			d.top["warnings"] = mustMarshal(warnings)
+		}
+	}
+	if s.dirty.notices {
+		d.top["notices"] = nil
+		if notices := s.flattenNotices(nil); len(notices) > 0 {
+			d.top["notices"] = mustMarshal(notices)
+		}
+	}
+
+	for key := range s.dirty.data {
+		var v json.RawMessage
+		if entry, ok := s.data[key]; ok {
+			v = mustMarshal(entry)
+		}
+		d.sections["data"][key] = v
+	}
+	tasks := d.sections["tasks"]
+	for id, withTasks := range s.dirty.changes {
+		chg := s.changes[id]
+		if chg == nil {
+			d.sections["changes"][id] = nil
+			continue
+		}
+		d.sections["changes"][id] = mustMarshal(chg)
+		if withTasks {
+			for _, tid := range chg.taskIDs {
+				if t := s.tasks[tid]; t != nil {
+					tasks[tid] = mustMarshal(t)
+				}
+			}
+		}
+	}
+	for id := range s.dirty.tasks {
+		if _, ok := tasks[id]; ok {
+			continue
+		}
+		var v json.RawMessage
+		if t := s.tasks[id]; t != nil {
+			v = mustMarshal(t)
+		}
+		tasks[id] = v
+	}
+	return d
+}
+
+// checkpoint saves the state with the backend, only what was modified if
+// the backend supports it.
+func (s *State) checkpoint() error {
+	if db, ok := s.backend.(DeltaBackend); ok && !s.dirty.all && db.CanCheckpointDelta() {
+		return db.CheckpointDelta(s.delta())
+	}
+	return s.backend.Checkpoint(s.checkpointData())
+}
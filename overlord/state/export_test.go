@@ -35,6 +35,14 @@ func MockCheckpointRetryDelay(retryInterval, retryMaxTime time.Duration) (restor
 	}
 }
 
+func MockLoggerAttrsEnabled(enabled bool) (restore func()) {
+	old := loggerAttrsEnabled
+	loggerAttrsEnabled = func() bool { return enabled }
+	return func() {
+		loggerAttrsEnabled = old
+	}
+}
+
 func MockChangeTimes(chg *Change, spawnTime, readyTime time.Time) {
 	chg.spawnTime = spawnTime
 	chg.readyTime = readyTime
@@ -72,3 +80,11 @@ var (
 func (s *State) NumNotices() int {
 	return len(s.notices)
 }
+
+func MockJournalCompactMaxSize(size int64) (restore func()) {
+	old := journalCompactMaxSize
+	journalCompactMaxSize = size
+	return func() {
+		journalCompactMaxSize = old
+	}
+}
@@ -0,0 +1,523 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// JournalPath returns the path of the journal of the state file at the
+// given path.
+func JournalPath(statePath string) string {
+	return statePath + ".journal"
+}
+
+// A Journal keeps the state on disk as a full checkpoint in the usual
+// format, plus an append-only journal of the changes made to it since.
+//
+// The journal starts with a header line with the hash of the full
+// checkpoint it applies to, so if writing a new checkpoint is interrupted
+// the old journal is ignored. Every following line is a set of changes
+// prefixed by its checksum, so a line that was only partially written is
+// ignored too.
+//
+// A Journal is meant to be used as the Backend of a State, which must be
+// locked when it is used.
+type Journal struct {
+	path string
+	f    *os.File
+	// size of the journal
+	size int64
+	// dirtySince is when changes were first put in the journal since
+	// the last full checkpoint, if there are any
+	dirtySince time.Time
+	// last is what's on disk, split into sections
+	last *splitState
+}
+
+// NewJournal returns a journal for the state file at the given path. The
+// first checkpoint is a full one, which starts the journal.
+func NewJournal(statePath string) *Journal {
+	return &Journal{path: statePath}
+}
+
+// the journal is compacted into a full checkpoint once it's bigger than
+// this, so that the state file does not get too far behind
+var journalCompactMaxSize int64 = 256 * 1024
+
+type journalHeader struct {
+	Base string `json:"base"`
+}
+
+// A journalOp sets or deletes one top-level entry of the state, or one
+// element of one of its sections.
+type journalOp struct {
+	Section string          `json:"section,omitempty"`
+	Key     string          `json:"key"`
+	Value   json.RawMessage `json:"value,omitempty"`
+	Deleted bool            `json:"deleted,omitempty"`
+}
+
+// journalSections are the top-level entries of the state journaled element
+// by element; lists have their elements keyed by the given field.
+var journalSections = map[string]string{
+	"data":     "",
+	"changes":  "",
+	"tasks":    "",
+	"warnings": "message",
+	"notices":  "id",
+}
+
+// splitState is the state split in its top-level entries, with those in
+// journalSections further split in their elements.
+type splitState struct {
+	top      map[string]json.RawMessage
+	sections map[string]map[string]json.RawMessage
+}
+
+func splitStateData(data []byte) (*splitState, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, err
+	}
+	ss := &splitState{
+		top:      make(map[string]json.RawMessage, len(top)),
+		sections: make(map[string]map[string]json.RawMessage, len(journalSections)),
+	}
+	for section := range journalSections {
+		ss.sections[section] = make(map[string]json.RawMessage)
+	}
+	for k, v := range top {
+		field, ok := journalSections[k]
+		if !ok {
+			ss.top[k] = v
+			continue
+		}
+		if field == "" {
+			var elems map[string]json.RawMessage
+			if err := json.Unmarshal(v, &elems); err != nil {
+				return nil, err
+			}
+			if elems != nil {
+				ss.sections[k] = elems
+			}
+			continue
+		}
+		elems, err := splitList(v, field)
+		if err != nil {
+			return nil, err
+		}
+		ss.sections[k] = elems
+	}
+	return ss, nil
+}
+
+// splitList returns the elements of the given list keyed by their field.
+func splitList(data []byte, field string) (map[string]json.RawMessage, error) {
+	var list []json.RawMessage
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+	}
+	elems := make(map[string]json.RawMessage, len(list))
+	for _, elem := range list {
+		var keyed map[string]json.RawMessage
+		if err := json.Unmarshal(elem, &keyed); err != nil {
+			return nil, err
+		}
+		elems[string(keyed[field])] = elem
+	}
+	return elems, nil
+}
+
+// join returns the state in the usual format, with lists in the order of
+// their keys.
+func (ss *splitState) join() ([]byte, error) {
+	top := make(map[string]interface{}, len(ss.top)+len(ss.sections))
+	for k, v := range ss.top {
+		top[k] = v
+	}
+	for section, elems := range ss.sections {
+		if journalSections[section] == "" {
+			top[section] = elems
+			continue
+		}
+		if len(elems) == 0 {
+			// these are omitted when empty
+			continue
+		}
+		keys := make([]string, 0, len(elems))
+		for k := range elems {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		list := make([]json.RawMessage, len(keys))
+		for i, k := range keys {
+			list[i] = elems[k]
+		}
+		top[section] = list
+	}
+	return json.Marshal(top)
+}
+
+func (ss *splitState) apply(op *journalOp) {
+	entries := ss.top
+	if op.Section != "" {
+		entries = ss.sections[op.Section]
+		if entries == nil {
+			entries = make(map[string]json.RawMessage)
+			ss.sections[op.Section] = entries
+		}
+	}
+	if op.Deleted {
+		delete(entries, op.Key)
+	} else {
+		entries[op.Key] = op.Value
+	}
+}
+
+func diffEntries(section string, old, new map[string]json.RawMessage, ops []journalOp) []journalOp {
+	for k, v := range new {
+		if o, ok := old[k]; !ok || !bytes.Equal(o, v) {
+			ops = append(ops, journalOp{Section: section, Key: k, Value: v})
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			ops = append(ops, journalOp{Section: section, Key: k, Deleted: true})
+		}
+	}
+	return ops
+}
+
+// diffEntry appends the operation that sets the entry with the given key
+// to v, or deletes it if v is nil, unless that's already the case.
+func diffEntry(section string, old map[string]json.RawMessage, k string, v json.RawMessage, ops []journalOp) []journalOp {
+	o, ok := old[k]
+	if v == nil {
+		if ok {
+			ops = append(ops, journalOp{Section: section, Key: k, Deleted: true})
+		}
+		return ops
+	}
+	if !ok || !bytes.Equal(o, v) {
+		ops = append(ops, journalOp{Section: section, Key: k, Value: v})
+	}
+	return ops
+}
+
+// diff returns the operations that turn ss into new.
+func (ss *splitState) diff(new *splitState) []journalOp {
+	ops := diffEntries("", ss.top, new.top, nil)
+	for section, elems := range new.sections {
+		ops = diffEntries(section, ss.sections[section], elems, ops)
+	}
+	return ops
+}
+
+func journalLine(v interface{}) ([]byte, error) {
+	entry, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(entry), entry)), nil
+}
+
+// parseJournalLine returns the entry in a line written by journalLine
+// (without its newline), or nil if it's corrupted.
+func parseJournalLine(line []byte) []byte {
+	if len(line) < 10 || line[8] != ' ' {
+		return nil
+	}
+	var sum uint32
+	if _, err := fmt.Sscanf(string(line[:8]), "%08x", &sum); err != nil {
+		return nil
+	}
+	entry := line[9:]
+	if crc32.ChecksumIEEE(entry) != sum {
+		return nil
+	}
+	return entry
+}
+
+func stateHash(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// Checkpoint saves the given state, by appending to the journal what
+// changed since the last checkpoint.
+func (j *Journal) Checkpoint(data []byte) error {
+	new, err := splitStateData(data)
+	if err != nil {
+		return fmt.Errorf("cannot split state for journaling: %v", err)
+	}
+	if j.last == nil || j.f == nil {
+		// nothing (or nothing reliable) on disk to build on
+		return j.compactSplit(data, new)
+	}
+
+	return j.commit(j.last.diff(new))
+}
+
+// CanCheckpointDelta returns whether there's a journal to put a delta in,
+// otherwise the next checkpoint needs to be a full one.
+func (j *Journal) CanCheckpointDelta() bool {
+	return j.f != nil && j.last != nil
+}
+
+// CheckpointDelta saves the state by appending to the journal the given
+// parts of it that were modified since the last checkpoint.
+func (j *Journal) CheckpointDelta(delta *Delta) error {
+	if !j.CanCheckpointDelta() {
+		return fmt.Errorf("internal error: cannot checkpoint state delta without a journal")
+	}
+	var ops []journalOp
+	for k, v := range delta.top {
+		field, ok := journalSections[k]
+		if !ok {
+			ops = diffEntry("", j.last.top, k, v, ops)
+			continue
+		}
+		elems, err := splitList(v, field)
+		if err != nil {
+			return fmt.Errorf("cannot split state for journaling: %v", err)
+		}
+		ops = diffEntries(k, j.last.sections[k], elems, ops)
+	}
+	for section, elems := range delta.sections {
+		for k, v := range elems {
+			ops = diffEntry(section, j.last.sections[section], k, v, ops)
+		}
+	}
+	return j.commit(ops)
+}
+
+// commit appends the given operations to the journal and applies them to
+// what's on disk.
+func (j *Journal) commit(ops []journalOp) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	line, err := journalLine(ops)
+	if err != nil {
+		return err
+	}
+	if err := j.append(line); err != nil {
+		// what's in the journal can't be trusted anymore, so the
+		// next checkpoint will be a full one
+		j.f.Close()
+		j.f = nil
+		return err
+	}
+	for i := range ops {
+		j.last.apply(&ops[i])
+	}
+	if j.dirtySince.IsZero() {
+		j.dirtySince = timeNow()
+	}
+
+	if j.size > journalCompactMaxSize {
+		if err := j.compact(); err != nil {
+			// the changes are safe in the journal, try again next time
+			logger.Noticef("cannot compact state journal: %v", err)
+		}
+	}
+	return nil
+}
+
+func (j *Journal) append(line []byte) error {
+	n, err := j.f.Write(line)
+	if err == nil {
+		err = j.f.Sync()
+	}
+	if err != nil {
+		// drop what might have been written
+		if n > 0 {
+			j.f.Truncate(j.size)
+		}
+		return fmt.Errorf("cannot write state journal: %v", err)
+	}
+	j.size += int64(n)
+	return nil
+}
+
+func (j *Journal) compactSplit(data []byte, ss *splitState) error {
+	if err := osutil.AtomicWriteFile(j.path, data, 0600, 0); err != nil {
+		return err
+	}
+	j.last = ss
+
+	// from now on a journal needs to be for this checkpoint, so
+	// interrupting this leaves one that is ignored (or none)
+	header, err := journalLine(&journalHeader{Base: stateHash(data)})
+	if err != nil {
+		return err
+	}
+	if j.f != nil {
+		j.f.Close()
+		j.f = nil
+	}
+	f, err := os.OpenFile(JournalPath(j.path), os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot open state journal: %v", err)
+	}
+	j.f = f
+	j.size = 0
+	j.dirtySince = time.Time{}
+	if err := j.append(header); err != nil {
+		f.Close()
+		j.f = nil
+		return err
+	}
+	return nil
+}
+
+// DirtySince returns when changes were first put in the journal since the
+// state was last written whole, or the zero time if there are none.
+func (j *Journal) DirtySince() time.Time {
+	return j.dirtySince
+}
+
+// Compact writes the state whole if there are changes in the journal, and
+// starts a new journal for it.
+func (j *Journal) Compact() error {
+	if j.dirtySince.IsZero() || j.last == nil {
+		// nothing to compact, or nothing reliable to build on in
+		// which case the next checkpoint is a full one anyway
+		return nil
+	}
+	return j.compact()
+}
+
+func (j *Journal) compact() error {
+	data, err := j.last.join()
+	if err != nil {
+		return err
+	}
+	return j.compactSplit(data, j.last)
+}
+
+// Close writes the state whole if there are changes in the journal, and
+// removes the journal, so that it's all in the usual format for whatever
+// reads it next.
+func (j *Journal) Close() error {
+	if j.f != nil {
+		j.f.Close()
+		j.f = nil
+	}
+	last := j.last
+	j.last = nil
+	if last == nil {
+		// nothing was written
+		return nil
+	}
+	if !j.dirtySince.IsZero() {
+		data, err := last.join()
+		if err != nil {
+			return err
+		}
+		if err := osutil.AtomicWriteFile(j.path, data, 0600, 0); err != nil {
+			return err
+		}
+	}
+	return os.Remove(JournalPath(j.path))
+}
+
+// ReplayJournal returns the given content of the state file at statePath
+// with the changes in its journal applied, or nil if there are none for it.
+// Lines that are corrupted, like an incomplete last one, end the replay.
+func ReplayJournal(statePath string, data []byte) ([]byte, error) {
+	f, err := os.Open(JournalPath(statePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot open state journal: %v", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	readLine := func() []byte {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Noticef("cannot read state journal: %v", err)
+			}
+			// only complete lines were committed
+			return nil
+		}
+		return parseJournalLine(line[:len(line)-1])
+	}
+
+	var header journalHeader
+	entry := readLine()
+	if entry == nil || json.Unmarshal(entry, &header) != nil {
+		logger.Noticef("ignoring state journal without a valid header")
+		return nil, nil
+	}
+	if header.Base != stateHash(data) {
+		// the state was written whole after the journal
+		return nil, nil
+	}
+
+	var ss *splitState
+	for {
+		entry := readLine()
+		if entry == nil {
+			break
+		}
+		var ops []journalOp
+		if err := json.Unmarshal(entry, &ops); err != nil {
+			logger.Noticef("ignoring the rest of the state journal: %v", err)
+			break
+		}
+		if ss == nil {
+			if ss, err = splitStateData(data); err != nil {
+				return nil, fmt.Errorf("cannot split state to replay its journal: %v", err)
+			}
+		}
+		for i := range ops {
+			ss.apply(&ops[i])
+		}
+	}
+	if rest, _ := r.Peek(1); len(rest) > 0 {
+		logger.Noticef("ignoring corrupted or incomplete entries at the end of the state journal")
+	}
+	if ss == nil {
+		return nil, nil
+	}
+	return ss.join()
+}
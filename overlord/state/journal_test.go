@@ -0,0 +1,320 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type journalSuite struct {
+	path string
+}
+
+var _ = Suite(&journalSuite{})
+
+func (s *journalSuite) SetUpTest(c *C) {
+	s.path = filepath.Join(c.MkDir(), "state.json")
+}
+
+type journalBackend struct {
+	*state.Journal
+}
+
+func (journalBackend) EnsureBefore(d time.Duration) {}
+
+func (s *journalSuite) backend() journalBackend {
+	return journalBackend{state.NewJournal(s.path)}
+}
+
+// loaded returns the state on disk with its journal replayed
+func (s *journalSuite) loaded(c *C) (*state.State, []byte) {
+	data, err := os.ReadFile(s.path)
+	c.Assert(err, IsNil)
+	replayed, err := state.ReplayJournal(s.path, data)
+	c.Assert(err, IsNil)
+	if replayed != nil {
+		data = replayed
+	}
+	st, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	return st, data
+}
+
+func (s *journalSuite) getMark(c *C, st *state.State, key string) int {
+	st.Lock()
+	defer st.Unlock()
+	var v int
+	err := st.Get(key, &v)
+	if err != nil {
+		c.Assert(err, testutil.ErrorIs, state.ErrNoState)
+	}
+	return v
+}
+
+func (s *journalSuite) TestJournalIncompleteEntry(c *C) {
+	st := state.New(s.backend())
+	for i := 1; i <= 2; i++ {
+		st.Lock()
+		st.Set("a", i)
+		st.Unlock()
+	}
+
+	// a crash while writing the next entry
+	f, err := os.OpenFile(state.JournalPath(s.path), os.O_WRONLY|os.O_APPEND, 0)
+	c.Assert(err, IsNil)
+	_, err = f.WriteString(`12345678 [{"key":"a","val`)
+	c.Assert(err, IsNil)
+	f.Close()
+
+	loaded, _ := s.loaded(c)
+	c.Check(s.getMark(c, loaded, "a"), Equals, 2)
+}
+
+func (s *journalSuite) TestJournalCorruptedEntry(c *C) {
+	st := state.New(s.backend())
+	for i := 1; i <= 3; i++ {
+		st.Lock()
+		st.Set("a", i)
+		st.Unlock()
+	}
+
+	journal, err := os.ReadFile(state.JournalPath(s.path))
+	c.Assert(err, IsNil)
+	lines := strings.SplitAfter(string(journal), "\n")
+	c.Assert(lines, HasLen, 4)
+	// header, a=2, a=3 with a bit flipped, and the empty rest
+	lines[2] = strings.Replace(lines[2], "3", "4", 1)
+	c.Assert(os.WriteFile(state.JournalPath(s.path), []byte(strings.Join(lines, "")), 0600), IsNil)
+
+	loaded, _ := s.loaded(c)
+	c.Check(s.getMark(c, loaded, "a"), Equals, 2)
+}
+
+func (s *journalSuite) TestJournalForOtherState(c *C) {
+	st := state.New(s.backend())
+	for i := 1; i <= 2; i++ {
+		st.Lock()
+		st.Set("a", i)
+		st.Unlock()
+	}
+
+	// as if interrupted after writing the state whole, but before
+	// starting a new journal for it
+	c.Assert(os.WriteFile(s.path, []byte(`{"data":{"a":42}}`), 0600), IsNil)
+
+	// the journal is ignored
+	replayed, err := state.ReplayJournal(s.path, []byte(`{"data":{"a":42}}`))
+	c.Assert(err, IsNil)
+	c.Check(replayed, IsNil)
+	loaded, _ := s.loaded(c)
+	c.Check(s.getMark(c, loaded, "a"), Equals, 42)
+}
+
+func (s *journalSuite) TestJournalCompaction(c *C) {
+	defer state.MockJournalCompactMaxSize(1000)()
+
+	st := state.New(s.backend())
+	st.Lock()
+	st.Set("big", strings.Repeat("x", 10000))
+	st.Unlock()
+
+	// small changes are journaled until the journal is bigger than
+	// the limit, however big the state is
+	i := 0
+	for ; ; i++ {
+		st.Lock()
+		st.Set("a", i)
+		st.Unlock()
+		if strings.Contains(string(mustReadFile(c, s.path)), `"a":`) {
+			break
+		}
+		c.Assert(i < 1000, Equals, true)
+	}
+	c.Check(i > 10, Equals, true)
+	c.Check(s.path, testutil.FileContains, `"a":`)
+	journal := mustReadFile(c, state.JournalPath(s.path))
+	c.Check(bytes.Count(journal, []byte("\n")), Equals, 1)
+
+	loaded, _ := s.loaded(c)
+	c.Check(s.getMark(c, loaded, "a"), Equals, i)
+}
+
+func (s *journalSuite) TestCompact(c *C) {
+	now := time.Now()
+	defer state.MockTime(now)()
+
+	j := state.NewJournal(s.path)
+	st := state.New(journalBackend{j})
+	st.Lock()
+	defer st.Unlock()
+	// nothing to do before the first checkpoint
+	c.Assert(j.Compact(), IsNil)
+	c.Check(s.path, testutil.FileAbsent)
+
+	st.Set("a", 1)
+	st.Unlock()
+	st.Lock()
+	c.Check(j.DirtySince().IsZero(), Equals, true)
+	st.Set("a", 2)
+	st.Unlock()
+	st.Lock()
+	c.Check(j.DirtySince().Equal(now), Equals, true)
+	c.Check(s.path, testutil.FileContains, `"a":1`)
+
+	c.Assert(j.Compact(), IsNil)
+	c.Check(j.DirtySince().IsZero(), Equals, true)
+	c.Check(s.path, testutil.FileContains, `"a":2`)
+	journal := mustReadFile(c, state.JournalPath(s.path))
+	c.Check(bytes.Count(journal, []byte("\n")), Equals, 1)
+}
+
+// fullCountingBackend counts the checkpoints of the whole state
+type fullCountingBackend struct {
+	journalBackend
+	full int
+}
+
+func (b *fullCountingBackend) Checkpoint(data []byte) error {
+	b.full++
+	return b.journalBackend.Checkpoint(data)
+}
+
+// normalized returns the given state data unmarshalled, with its lists of
+// warnings and notices in a fixed order
+func normalized(c *C, data []byte) map[string]interface{} {
+	var top map[string]interface{}
+	c.Assert(json.Unmarshal(data, &top), IsNil)
+	for section, field := range map[string]string{"warnings": "message", "notices": "id"} {
+		list, _ := top[section].([]interface{})
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].(map[string]interface{})[field].(string) < list[j].(map[string]interface{})[field].(string)
+		})
+	}
+	return top
+}
+
+func (s *journalSuite) TestJournalDelta(c *C) {
+	b := &fullCountingBackend{journalBackend: s.backend()}
+	st := state.New(b)
+	st.Lock()
+	defer st.Unlock()
+	st.Set("big", strings.Repeat("x", 10000))
+	st.Unlock()
+	st.Lock()
+
+	var t1, t2 *state.Task
+	var chg1, chg2 *state.Change
+	for i, modify := range []func(){
+		func() { st.Set("mark", 1) },
+		func() {
+			chg1 = st.NewChange("install", "...")
+			t1 = st.NewTask("download", "...")
+			t2 = st.NewTask("link", "...")
+			t2.WaitFor(t1)
+			chg1.AddTask(t1)
+			chg1.AddTask(t2)
+		},
+		func() { t1.Set("x", "y") },
+		func() { t1.Logf("some %s", "log") },
+		func() { t2.JoinLane(st.NewLane()) },
+		func() { chg1.Set("z", true) },
+		func() { t1.SetStatus(state.DoingStatus) },
+		func() { st.AddWarning("hello", nil) },
+		func() { st.AddWarning("there", nil) },
+		func() { st.OkayWarnings(time.Now()) },
+		func() { c.Assert(st.RemoveWarning("hello"), IsNil) },
+		func() {
+			_, err := st.AddNotice(nil, state.RefreshInhibitNotice, "-", nil)
+			c.Assert(err, IsNil)
+		},
+		func() {
+			chg2 = st.NewChange("remove", "...")
+			chg2.AddTask(st.NewTask("unlink", "..."))
+		},
+		func() { chg2.Abort() },
+		func() { st.Set("mark", nil) },
+		func() {
+			t1.SetStatus(state.DoneStatus)
+			t2.SetStatus(state.DoneStatus)
+		},
+		func() { st.Prune(time.Now(), time.Hour, time.Hour, 1) },
+		func() { st.Prune(time.Now(), time.Hour, time.Hour, 0) },
+		func() { c.Assert(st.RemoveWarning("there"), IsNil) },
+	} {
+		modify()
+		st.Unlock()
+		st.Lock()
+
+		c.Logf("modification #%d", i)
+		_, data := s.loaded(c)
+		expected, err := json.Marshal(st)
+		c.Assert(err, IsNil)
+		c.Assert(normalized(c, data), DeepEquals, normalized(c, expected))
+	}
+	c.Check(st.Changes(), HasLen, 0)
+
+	// only the first checkpoint was of the whole state, the rest only
+	// of what was modified
+	c.Check(b.full, Equals, 1)
+	c.Check(s.path, Not(testutil.FileContains), `"mark"`)
+	journal := mustReadFile(c, state.JournalPath(s.path))
+	c.Check(bytes.Contains(journal, []byte("xxx")), Equals, false)
+}
+
+func (s *journalSuite) TestJournalDeltaAfterReadState(c *C) {
+	st := state.New(s.backend())
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+	data := mustReadFile(c, s.path)
+
+	b := &fullCountingBackend{journalBackend: s.backend()}
+	st, err := state.ReadState(b, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st.Lock()
+	st.Set("b", 2)
+	st.Unlock()
+	st.Lock()
+	st.Set("a", 3)
+	st.Unlock()
+
+	// what was read is checkpointed whole first
+	c.Check(b.full, Equals, 1)
+	loaded, _ := s.loaded(c)
+	c.Check(s.getMark(c, loaded, "a"), Equals, 3)
+	c.Check(s.getMark(c, loaded, "b"), Equals, 2)
+}
+
+func mustReadFile(c *C, path string) []byte {
+	data, err := os.ReadFile(path)
+	c.Assert(err, IsNil)
+	return data
+}
@@ -108,6 +108,12 @@ func (n *Notice) Type() NoticeType {
 	return n.noticeType
 }
 
+// LastRepeated returns the time the notice was last repeated, which is
+// what notices are ordered by.
+func (n *Notice) LastRepeated() time.Time {
+	return n.lastRepeated
+}
+
 func flattenUserID(userID *uint32) (uid uint32, isSet bool) {
 	if userID == nil {
 		return 0, false
@@ -214,11 +220,26 @@ const (
 	// expired. The key for interfaces-requests-rule-update notices is the
 	// rule ID.
 	InterfacesRequestsRuleUpdateNotice NoticeType = "interfaces-requests-rule-update"
+
+	// Recorded whenever a snap is installed, refreshed, reverted or
+	// removed, or such an operation is undone. The key for snap-lifecycle
+	// notices is the snap instance name.
+	SnapLifecycleNotice NoticeType = "snap-lifecycle"
+
+	// Recorded whenever an interface connection is made or removed. A
+	// notice is recorded for each of the plug and slot snaps, keyed by the
+	// snap instance name.
+	InterfaceConnectionNotice NoticeType = "interface-connection"
+
+	// Recorded whenever the configuration of a snap is changed. The key
+	// for snap-config notices is the snap instance name.
+	SnapConfigNotice NoticeType = "snap-config"
 )
 
 func (t NoticeType) Valid() bool {
 	switch t {
-	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice:
+	case ChangeUpdateNotice, WarningNotice, RefreshInhibitNotice, SnapRunInhibitNotice, InterfacesRequestsPromptNotice, InterfacesRequestsRuleUpdateNotice,
+		SnapLifecycleNotice, InterfaceConnectionNotice, SnapConfigNotice:
 		return true
 	}
 	return false
@@ -248,7 +269,8 @@ func (s *State) AddNotice(userID *uint32, noticeType NoticeType, key string, opt
 		return "", fmt.Errorf("internal error: %w", err)
 	}
 
-	s.writing()
+	s.modifying()
+	s.dirtyNotices()
 
 	now := options.Time
 	if now.IsZero() {
@@ -398,6 +398,7 @@ func (s *noticesSuite) TestNoticesFilterAfter(c *C) {
 	n := noticeToMap(c, notices[0])
 	lastRepeated, err := time.Parse(time.RFC3339, n["last-repeated"].(string))
 	c.Assert(err, IsNil)
+	c.Check(notices[0].LastRepeated().Equal(lastRepeated), Equals, true)
 
 	time.Sleep(time.Microsecond)
 	addNotice(c, st, nil, state.WarningNotice, "foo.com/y", nil)
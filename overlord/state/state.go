@@ -32,6 +32,7 @@ import (
 	"time"
 
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 )
 
 // A Backend is used by State to checkpoint on every unlock operation
@@ -107,6 +108,10 @@ type State struct {
 	noticeCond *sync.Cond
 
 	modified bool
+	// dirty keeps track of what was modified since the last checkpoint,
+	// if trackDirty is set as the backend can checkpoint only that
+	dirty      dirtyEntries
+	trackDirty bool
 
 	cache map[interface{}]interface{}
 
@@ -115,11 +120,22 @@ type State struct {
 	// task/changes observing
 	taskHandlers   map[int]func(t *Task, old, new Status) (remove bool)
 	changeHandlers map[int]func(chg *Change, old, new Status)
+	pruneHandlers  map[int]func(chg *Change)
 
 	lockWaitStart int64
 	lockHoldStart int64
+	// lockedAt is when the lock was acquired, only set when metrics
+	// are enabled
+	lockedAt time.Time
 }
 
+var (
+	lockWaitMetric = metrics.NewHistogram("snapd_state_lock_wait_seconds",
+		"Time spent waiting to acquire the state lock.", metrics.DefaultBuckets)
+	lockHoldMetric = metrics.NewHistogram("snapd_state_lock_hold_seconds",
+		"Time the state lock was held for.", metrics.DefaultBuckets)
+)
+
 // New returns a new empty state.
 func New(backend Backend) *State {
 	st := &State{
@@ -130,11 +146,14 @@ func New(backend Backend) *State {
 		warnings:            make(map[string]*Warning),
 		notices:             make(map[noticeKey]*Notice),
 		modified:            true,
+		dirty:               dirtyEntries{all: true},
 		cache:               make(map[interface{}]interface{}),
 		pendingChangeByAttr: make(map[string]func(*Change) bool),
 		taskHandlers:        make(map[int]func(t *Task, old Status, new Status) bool),
 		changeHandlers:      make(map[int]func(chg *Change, old Status, new Status)),
+		pruneHandlers:       make(map[int]func(chg *Change)),
 	}
+	_, st.trackDirty = backend.(DeltaBackend)
 	st.noticeCond = sync.NewCond(st) // use State.Lock and State.Unlock
 	return st
 }
@@ -147,10 +166,19 @@ func (s *State) Modified() bool {
 // Lock acquires the state lock.
 func (s *State) Lock() {
 	lockWait := lockTimestamp()
+	var waitStart time.Time
+	collectMetrics := metrics.Enabled()
+	if collectMetrics {
+		waitStart = time.Now()
+	}
 	s.mu.Lock()
 	atomic.AddInt32(&s.muC, 1)
 	s.lockWaitStart = lockWait
 	s.lockHoldStart = lockTimestamp()
+	if collectMetrics {
+		s.lockedAt = time.Now()
+		lockWaitMetric.Observe(s.lockedAt.Sub(waitStart).Seconds())
+	}
 }
 
 func (s *State) reading() {
@@ -159,11 +187,12 @@ func (s *State) reading() {
 	}
 }
 
+// writing is called before modifying the state. What is modified is not
+// known in detail then, so the next checkpoint is a whole one, see
+// modifying.
 func (s *State) writing() {
-	s.modified = true
-	if atomic.LoadInt32(&s.muC) != 1 {
-		panic("internal error: accessing state without lock")
-	}
+	s.modifying()
+	s.dirty.all = true
 }
 
 func (s *State) unlock() {
@@ -171,8 +200,17 @@ func (s *State) unlock() {
 	lockWaitStart, lockHoldStart := s.lockWaitStart, s.lockHoldStart
 	s.lockWaitStart, s.lockHoldStart = 0, 0
 	lockHoldEnd := lockTimestamp()
+	var held time.Duration
+	lockedAt := s.lockedAt
+	if !lockedAt.IsZero() {
+		held = time.Since(lockedAt)
+		s.lockedAt = time.Time{}
+	}
 	s.mu.Unlock()
 	maybeSaveLockTime(lockWaitStart, lockHoldStart, lockHoldEnd)
+	if !lockedAt.IsZero() {
+		lockHoldMetric.Observe(held.Seconds())
+	}
 }
 
 type marshalledState struct {
@@ -272,12 +310,12 @@ func (s *State) Unlock() {
 		return
 	}
 
-	data := s.checkpointData()
 	var err error
 	start := time.Now()
 	for time.Since(start) <= unlockCheckpointRetryMaxTime {
-		if err = s.backend.Checkpoint(data); err == nil {
+		if err = s.checkpoint(); err == nil {
 			s.modified = false
+			s.dirty = dirtyEntries{}
 			return
 		}
 		time.Sleep(unlockCheckpointRetryInterval)
@@ -334,7 +372,8 @@ func (s *State) Has(key string) bool {
 // Set associates value with key for future consulting by managers.
 // The provided value must properly marshal and unmarshal with encoding/json.
 func (s *State) Set(key string, value interface{}) {
-	s.writing()
+	s.modifying()
+	s.dirtyData(key)
 	s.data.set(key, value)
 }
 
@@ -358,11 +397,12 @@ func (s *State) Cache(key, value interface{}) {
 
 // NewChange adds a new change to the state.
 func (s *State) NewChange(kind, summary string) *Change {
-	s.writing()
+	s.modifying()
 	s.lastChangeId++
 	id := strconv.Itoa(s.lastChangeId)
 	chg := newChange(s, id, kind, summary)
 	s.changes[id] = chg
+	s.dirtyChange(id, false)
 	// Add change-update notice for newly spawned change
 	// NOTE: Implies State.writing()
 	if err := chg.addNotice(); err != nil {
@@ -373,7 +413,7 @@ func (s *State) NewChange(kind, summary string) *Change {
 
 // NewLane creates a new lane in the state.
 func (s *State) NewLane() int {
-	s.writing()
+	s.modifying()
 	s.lastLaneId++
 	return s.lastLaneId
 }
@@ -398,11 +438,12 @@ func (s *State) Change(id string) *Change {
 // It usually will be registered with a Change using AddTask or
 // through a TaskSet.
 func (s *State) NewTask(kind, summary string) *Task {
-	s.writing()
+	s.modifying()
 	s.lastTaskId++
 	id := strconv.Itoa(s.lastTaskId)
 	t := newTask(s, id, kind, summary)
 	s.tasks[id] = t
+	s.dirtyTask(id)
 	return t
 }
 
@@ -454,14 +495,18 @@ func (s *State) RegisterPendingChangeByAttr(attr string, f func(*Change) bool) {
 // Prune does several cleanup tasks to the in-memory state:
 //
 //   - it removes changes that became ready for more than pruneWait and aborts
-//     tasks spawned for more than abortWait unless prevented by predicates
-//     registered with RegisterPendingChangeByAttr.
+//     tasks spawned, or held with Change.At until, more than abortWait ago
+//     unless prevented by predicates registered with
+//     RegisterPendingChangeByAttr.
 //
 //   - it removes tasks unlinked to changes after pruneWait. When there are more
 //     changes than the limit set via "maxReadyChanges" those changes in ready
 //     state will also removed even if they are below the pruneWait duration.
 //
 //   - it removes expired warnings and notices.
+//
+// Changes are passed to the handlers registered with AddChangePrunedHandler
+// before they are removed.
 func (s *State) Prune(startOfOperation time.Time, pruneWait, abortWait time.Duration, maxReadyChanges int) {
 	now := time.Now()
 	pruneLimit := now.Add(-pruneWait)
@@ -487,6 +532,7 @@ func (s *State) Prune(startOfOperation time.Time, pruneWait, abortWait time.Dura
 
 	for k, n := range s.notices {
 		if n.expired(now) {
+			s.dirtyNotices()
 			delete(s.notices, k)
 		}
 	}
@@ -498,9 +544,15 @@ NextChange:
 		if spawnTime.Before(startOfOperation) {
 			spawnTime = startOfOperation
 		}
+		// a change held until some time with At only starts then
+		if heldUntil := chg.heldUntil(); spawnTime.Before(heldUntil) {
+			spawnTime = heldUntil
+		}
 		if readyTime.IsZero() {
 			if spawnTime.Before(pruneLimit) && len(chg.Tasks()) == 0 {
 				chg.Abort()
+				s.notifyChangePrunedHandlers(chg)
+				s.dirtyChange(chg.ID(), false)
 				delete(s.changes, chg.ID())
 			} else if spawnTime.Before(abortLimit) {
 				for attr, pending := range s.pendingChangeByAttr {
@@ -514,10 +566,13 @@ NextChange:
 		}
 		// change old or we have too many changes
 		if readyTime.Before(pruneLimit) || readyChangesCount > maxReadyChanges {
-			s.writing()
+			s.modifying()
+			s.notifyChangePrunedHandlers(chg)
 			for _, t := range chg.Tasks() {
+				s.dirtyTask(t.ID())
 				delete(s.tasks, t.ID())
 			}
+			s.dirtyChange(chg.ID(), false)
 			delete(s.changes, chg.ID())
 			readyChangesCount--
 		}
@@ -526,7 +581,8 @@ NextChange:
 	for tid, t := range s.tasks {
 		// TODO: this could be done more aggressively
 		if t.Change() == nil && t.SpawnTime().Before(pruneLimit) {
-			s.writing()
+			s.modifying()
+			s.dirtyTask(tid)
 			delete(s.tasks, tid)
 		}
 	}
@@ -537,6 +593,7 @@ func (s *State) pruneWarnings(now time.Time) {
 	defer s.warningsMu.Unlock()
 	for k, w := range s.warnings {
 		if w.ExpiredBefore(now) {
+			s.dirtyWarnings()
 			delete(s.warnings, k)
 		}
 	}
@@ -608,6 +665,30 @@ func (s *State) notifyChangeStatusChangedHandlers(chg *Change, old, new Status)
 	}
 }
 
+// AddChangePrunedHandler adds a callback function that will be invoked
+// by Prune with each change it is about to remove from the state, while
+// the change and its tasks can still be inspected.
+func (s *State) AddChangePrunedHandler(f func(chg *Change)) (id int) {
+	// We are reading here as we want to ensure access to the state is serialized,
+	// and not writing as we are not changing the part of state that goes on the disk.
+	s.reading()
+	id = s.lastHandlerId
+	s.lastHandlerId++
+	s.pruneHandlers[id] = f
+	return id
+}
+
+func (s *State) RemoveChangePrunedHandler(id int) {
+	s.reading()
+	delete(s.pruneHandlers, id)
+}
+
+func (s *State) notifyChangePrunedHandlers(chg *Change) {
+	for _, f := range s.pruneHandlers {
+		f(chg)
+	}
+}
+
 // SaveTimings implements timings.GetSaver
 func (s *State) SaveTimings(timings interface{}) {
 	s.Set("timings", timings)
@@ -624,11 +705,13 @@ func ReadState(backend Backend, r io.Reader) (*State, error) {
 		return nil, fmt.Errorf("cannot read state: %s", err)
 	}
 	s.backend = backend
+	_, s.trackDirty = backend.(DeltaBackend)
 	s.noticeCond = sync.NewCond(s)
 	s.modified = false
 	s.cache = make(map[interface{}]interface{})
 	s.pendingChangeByAttr = make(map[string]func(*Change) bool)
 	s.changeHandlers = make(map[int]func(chg *Change, old Status, new Status))
 	s.taskHandlers = make(map[int]func(t *Task, old Status, new Status) bool)
+	s.pruneHandlers = make(map[int]func(chg *Change))
 	return s, err
 }
@@ -24,11 +24,15 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"testing"
 	"time"
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -57,6 +61,42 @@ func (ss *stateSuite) TestLockUnlock(c *C) {
 	st.Unlock()
 }
 
+func lockMetricsCounts(c *C) (wait, hold int) {
+	var buf bytes.Buffer
+	c.Assert(metrics.Write(&buf), IsNil)
+	count := func(name string) int {
+		m := regexp.MustCompile("(?m)^" + name + "_count ([0-9]+)$").FindStringSubmatch(buf.String())
+		if m == nil {
+			return 0
+		}
+		n, err := strconv.Atoi(m[1])
+		c.Assert(err, IsNil)
+		return n
+	}
+	return count("snapd_state_lock_wait_seconds"), count("snapd_state_lock_hold_seconds")
+}
+
+func (ss *stateSuite) TestLockMetrics(c *C) {
+	defer metrics.SetEnabled(false)
+	st := state.New(nil)
+
+	// nothing is observed when metrics are disabled
+	metrics.SetEnabled(false)
+	wait0, hold0 := lockMetricsCounts(c)
+	st.Lock()
+	st.Unlock()
+	wait, hold := lockMetricsCounts(c)
+	c.Check(wait, Equals, wait0)
+	c.Check(hold, Equals, hold0)
+
+	metrics.SetEnabled(true)
+	st.Lock()
+	st.Unlock()
+	wait, hold = lockMetricsCounts(c)
+	c.Check(wait, Equals, wait0+1)
+	c.Check(hold, Equals, hold0+1)
+}
+
 func (ss *stateSuite) TestUnlocker(c *C) {
 	st := state.New(nil)
 	unlocker := st.Unlocker()
@@ -581,6 +621,7 @@ func (ss *stateSuite) TestEmptyStateDataAndCheckpointReadAndSet(c *C) {
 		"pendingChangeByAttr",
 		"taskHandlers",
 		"changeHandlers",
+		"pruneHandlers",
 	})
 }
 
@@ -862,6 +903,46 @@ func (ss *stateSuite) TestPrune(c *C) {
 	c.Check(st.AllWarnings(), HasLen, 1)
 }
 
+func (ss *stateSuite) TestPruneHeldChange(c *C) {
+	st := state.New(&fakeStateBackend{})
+	st.Lock()
+	defer st.Unlock()
+
+	now := time.Now()
+	pruneWait := 24 * time.Hour
+	abortWait := 3 * 24 * time.Hour
+
+	unset := time.Time{}
+
+	// held for longer than abortWait
+	t1 := st.NewTask("foo", "...")
+	chg1 := st.NewChange("held", "...")
+	chg1.AddTask(t1)
+	chg1.At(now.Add(4 * 24 * time.Hour))
+	state.MockChangeTimes(chg1, now.Add(-time.Hour), unset)
+
+	// was held until recently, so it only just started
+	t2 := st.NewTask("foo", "...")
+	chg2 := st.NewChange("started", "...")
+	chg2.AddTask(t2)
+	chg2.At(now.Add(-time.Hour))
+	state.MockChangeTimes(chg2, now.Add(-5*24*time.Hour), unset)
+
+	// was held until long ago
+	t3 := st.NewTask("foo", "...")
+	chg3 := st.NewChange("abort", "...")
+	chg3.AddTask(t3)
+	chg3.At(now.Add(-abortWait - time.Hour))
+	state.MockChangeTimes(chg3, now.Add(-5*24*time.Hour), unset)
+
+	past := time.Now().AddDate(-1, 0, 0)
+	st.Prune(past, pruneWait, abortWait, 100)
+
+	c.Check(t1.Status(), Equals, state.DoStatus)
+	c.Check(t2.Status(), Equals, state.DoStatus)
+	c.Check(t3.Status(), Equals, state.HoldStatus)
+}
+
 func (ss *stateSuite) TestRegisterPendingChangeByAttr(c *C) {
 	st := state.New(&fakeStateBackend{})
 	st.Lock()
@@ -932,6 +1013,59 @@ func (ss *stateSuite) TestPruneEmptyChange(c *C) {
 	c.Assert(st.Change(chg.ID()), IsNil)
 }
 
+func (ss *stateSuite) TestPruneChangePrunedHandlers(c *C) {
+	st := state.New(&fakeStateBackend{})
+	st.Lock()
+	defer st.Unlock()
+
+	now := time.Now()
+	pruneWait := 1 * time.Hour
+	abortWait := 3 * time.Hour
+
+	chg1 := st.NewChange("prune", "...")
+	t1 := st.NewTask("foo", "...")
+	chg1.AddTask(t1)
+	t1.SetStatus(state.DoneStatus)
+	state.MockChangeTimes(chg1, now.Add(-pruneWait), now.Add(-pruneWait))
+
+	chg2 := st.NewChange("empty", "...")
+	state.MockChangeTimes(chg2, now.Add(-pruneWait), time.Time{})
+
+	chg3 := st.NewChange("ready-but-recent", "...")
+	t3 := st.NewTask("foo", "...")
+	chg3.AddTask(t3)
+	t3.SetStatus(state.DoneStatus)
+	state.MockChangeTimes(chg3, now.Add(-pruneWait), now.Add(-pruneWait/2))
+
+	var pruned []string
+	st.AddChangePrunedHandler(func(chg *state.Change) {
+		// still all there
+		c.Check(st.Change(chg.ID()), Equals, chg)
+		for _, t := range chg.Tasks() {
+			c.Check(st.Task(t.ID()), Equals, t)
+		}
+		pruned = append(pruned, chg.Kind())
+	})
+	removed := st.AddChangePrunedHandler(func(chg *state.Change) {
+		c.Error("removed handler called")
+	})
+	st.RemoveChangePrunedHandler(removed)
+
+	past := time.Now().AddDate(-1, 0, 0)
+	st.Prune(past, pruneWait, abortWait, 100)
+
+	sort.Strings(pruned)
+	c.Check(pruned, DeepEquals, []string{"empty", "prune"})
+	c.Check(st.Change(chg1.ID()), IsNil)
+	c.Check(st.Change(chg2.ID()), IsNil)
+	c.Check(st.Change(chg3.ID()), Equals, chg3)
+
+	// too many changes
+	pruned = nil
+	st.Prune(past, pruneWait, abortWait, 0)
+	c.Check(pruned, DeepEquals, []string{"ready-but-recent"})
+}
+
 func (ss *stateSuite) TestPruneMaxChangesHappy(c *C) {
 	st := state.New(&fakeStateBackend{})
 	st.Lock()
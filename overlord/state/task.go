@@ -65,6 +65,9 @@ type Task struct {
 	undoingTime time.Duration
 
 	atTime time.Time
+
+	// logger is set by the TaskRunner when running a handler of the task
+	logger *logger.AttrLogger
 }
 
 func newTask(state *State, id, kind, summary string) *Task {
@@ -139,6 +142,16 @@ func (t *Task) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// writing is called before modifying the task, marking it and its change
+// as modified.
+func (t *Task) writing() {
+	t.state.modifying()
+	t.state.dirtyTask(t.id)
+	if t.change != "" {
+		t.state.dirtyChange(t.change, false)
+	}
+}
+
 // UnmarshalJSON makes Task a json.Unmarshaller
 func (t *Task) UnmarshalJSON(data []byte) error {
 	if t.state != nil {
@@ -189,6 +202,16 @@ func (t *Task) ID() string {
 	return t.id
 }
 
+// Logger returns a logger adding the change, the task and, when known, the
+// manager and the snap the task is about to the records of structured logs.
+// It can be used without holding the state lock.
+func (t *Task) Logger() *logger.AttrLogger {
+	if t.logger != nil {
+		return t.logger
+	}
+	return logger.WithAttrs("task-id", t.id, "task-kind", t.kind)
+}
+
 // Kind returns the nature of this task for managers to know how to handle it.
 func (t *Task) Kind() string {
 	return t.kind
@@ -264,7 +287,7 @@ func (t *Task) SetStatus(new Status) {
 		panic("Task.SetStatus() called with WaitStatus, which is not allowed. Use SetToWait() instead")
 	}
 
-	t.state.writing()
+	t.writing()
 	old := t.status
 	if new == DoneStatus && old == AbortStatus {
 		// if the task is in AbortStatus (because some other task ran
@@ -285,7 +308,7 @@ func (t *Task) SetToWait(resultStatus Status) {
 		panic("Task.SetToWait() cannot be invoked with either of DefaultStatus or WaitStatus")
 	}
 
-	t.state.writing()
+	t.writing()
 	old := t.status
 	if old == AbortStatus {
 		// if the task is in AbortStatus (because some other task ran
@@ -316,7 +339,7 @@ func (t *Task) IsClean() bool {
 //
 // Cleaning a task must only be done after the change is ready.
 func (t *Task) SetClean() {
-	t.state.writing()
+	t.writing()
 	if t.clean {
 		return
 	}
@@ -356,7 +379,7 @@ func (t *Task) Progress() (label string, done, total int) {
 func (t *Task) SetProgress(label string, done, total int) {
 	// Only mark state for checkpointing if progress is final.
 	if total > 0 && done == total {
-		t.state.writing()
+		t.writing()
 	} else {
 		t.state.reading()
 	}
@@ -387,12 +410,12 @@ func (t *Task) AtTime() time.Time {
 }
 
 func (t *Task) accumulateDoingTime(duration time.Duration) {
-	t.state.writing()
+	t.writing()
 	t.doingTime += duration
 }
 
 func (t *Task) accumulateUndoingTime(duration time.Duration) {
-	t.state.writing()
+	t.writing()
 	t.undoingTime += duration
 }
 
@@ -451,20 +474,20 @@ func (t *Task) Log() []string {
 
 // Logf logs information about the progress of the task.
 func (t *Task) Logf(format string, args ...interface{}) {
-	t.state.writing()
+	t.writing()
 	t.addLog(LogInfo, format, args)
 }
 
 // Errorf logs error information about the progress of the task.
 func (t *Task) Errorf(format string, args ...interface{}) {
-	t.state.writing()
+	t.writing()
 	t.addLog(LogError, format, args)
 }
 
 // Set associates value with key for future consulting by managers.
 // The provided value must properly marshal and unmarshal with encoding/json.
 func (t *Task) Set(key string, value interface{}) {
-	t.state.writing()
+	t.writing()
 	t.data.set(key, value)
 }
 
@@ -483,7 +506,7 @@ func (t *Task) Has(key string) bool {
 
 // Clear disassociates the value from key.
 func (t *Task) Clear(key string) {
-	t.state.writing()
+	t.writing()
 	delete(t.data, key)
 }
 
@@ -498,7 +521,7 @@ func addOnce(set []string, s string) []string {
 
 // WaitFor registers another task as a requirement for t to make progress.
 func (t *Task) WaitFor(another *Task) {
-	t.state.writing()
+	t.writing()
 	t.waitTasks = addOnce(t.waitTasks, another.id)
 	another.haltTasks = addOnce(another.haltTasks, t.id)
 }
@@ -540,13 +563,13 @@ func (t *Task) Lanes() []int {
 // JoinLane registers the task in the provided lane. Tasks in different lanes
 // abort independently on errors. See Change.AbortLane for details.
 func (t *Task) JoinLane(lane int) {
-	t.state.writing()
+	t.writing()
 	t.lanes = append(t.lanes, lane)
 }
 
 // At schedules the task, if it's not ready, to happen no earlier than when, if when is the zero time any previous special scheduling is suppressed.
 func (t *Task) At(when time.Time) {
-	t.state.writing()
+	t.writing()
 	iszero := when.IsZero()
 	if t.Status().Ready() && !iszero {
 		return
@@ -28,6 +28,8 @@ import (
 	"github.com/snapcore/snapd/logger"
 )
 
+var loggerAttrsEnabled = logger.AttrsEnabled
+
 // HandlerFunc is the type of function for the handlers
 type HandlerFunc func(task *Task, tomb *tomb.Tomb) error
 
@@ -76,9 +78,14 @@ type TaskRunner struct {
 	cleanups map[string]HandlerFunc
 	stopped  bool
 
+	// task kinds whose do handlers can be run again after failing
+	idempotent map[string]bool
+
 	blocked     []blockedFunc
 	someBlocked bool
 
+	logAttrs []func(t *Task) []any
+
 	// optional callback executed on task errors
 	taskErrorCallback func(err error)
 
@@ -88,6 +95,9 @@ type TaskRunner struct {
 
 type handlerPair struct {
 	do, undo HandlerFunc
+	// manager is the name of the manager which registered the handlers,
+	// if registered through a ManagerTaskRunner
+	manager string
 }
 
 type optionalHandler struct {
@@ -98,10 +108,11 @@ type optionalHandler struct {
 // NewTaskRunner creates a new TaskRunner
 func NewTaskRunner(s *State) *TaskRunner {
 	return &TaskRunner{
-		state:    s,
-		handlers: make(map[string]handlerPair),
-		cleanups: make(map[string]HandlerFunc),
-		tombs:    make(map[string]*tomb.Tomb),
+		state:      s,
+		handlers:   make(map[string]handlerPair),
+		cleanups:   make(map[string]HandlerFunc),
+		idempotent: make(map[string]bool),
+		tombs:      make(map[string]*tomb.Tomb),
 	}
 }
 
@@ -113,16 +124,84 @@ func (r *TaskRunner) OnTaskError(f func(err error)) {
 // AddHandler registers the functions to concurrently call for doing and
 // undoing tasks of the given kind. The undo handler may be nil.
 func (r *TaskRunner) AddHandler(kind string, do, undo HandlerFunc) {
+	r.addHandler(kind, handlerPair{do, undo, ""})
+}
+
+func (r *TaskRunner) addHandler(kind string, handlers handlerPair) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.handlers[kind] = handlerPair{do, undo}
+	r.handlers[kind] = handlers
 }
 
 // AddOptionalHandler register functions for doing and undoing tasks that match
 // the given predicate if no explicit handler was registered for the task kind.
 func (r *TaskRunner) AddOptionalHandler(match func(t *Task) bool, do, undo HandlerFunc) {
-	r.optional = append(r.optional, optionalHandler{match, handlerPair{do, undo}})
+	r.optional = append(r.optional, optionalHandler{match, handlerPair{do, undo, ""}})
+}
+
+// ManagerTaskRunner is a TaskRunner registering the handlers of a manager.
+// The name of the manager is added as "manager" to the records of
+// structured logs of the tasks it handles, see Task.Logger.
+type ManagerTaskRunner struct {
+	*TaskRunner
+	manager string
+}
+
+// ForManager returns a ManagerTaskRunner registering the handlers of the
+// manager with the given name on the task runner.
+func (r *TaskRunner) ForManager(manager string) *ManagerTaskRunner {
+	return &ManagerTaskRunner{TaskRunner: r, manager: manager}
+}
+
+// AddHandler registers the functions to concurrently call for doing and
+// undoing tasks of the given kind on behalf of the manager. The undo
+// handler may be nil.
+func (mr *ManagerTaskRunner) AddHandler(kind string, do, undo HandlerFunc) {
+	mr.addHandler(kind, handlerPair{do, undo, mr.manager})
+}
+
+// AddOptionalHandler register functions for doing and undoing tasks that
+// match the given predicate on behalf of the manager if no explicit handler
+// was registered for the task kind.
+func (mr *ManagerTaskRunner) AddOptionalHandler(match func(t *Task) bool, do, undo HandlerFunc) {
+	mr.optional = append(mr.optional, optionalHandler{match, handlerPair{do, undo, mr.manager}})
+}
+
+// AddLogAttrs registers a function returning key/value pairs to add to the
+// records of structured logs of a task, see Task.Logger. The function is
+// called with the state locked before running a handler of the task, and
+// only when structured logging is used. Pairs whose key was already added
+// are ignored.
+func (r *TaskRunner) AddLogAttrs(attrs func(t *Task) []any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.logAttrs = append(r.logAttrs, attrs)
+}
+
+func (r *TaskRunner) taskLogger(t *Task, manager string) *logger.AttrLogger {
+	attrs := []any{"change-id", t.Change().ID(), "task-id", t.ID(), "task-kind", t.Kind()}
+	if manager != "" {
+		attrs = append(attrs, "manager", manager)
+	}
+	if len(r.logAttrs) == 0 || !loggerAttrsEnabled() {
+		// avoid the cost of the registered functions when the
+		// attributes would be dropped anyway
+		return logger.WithAttrs(attrs...)
+	}
+	seen := map[any]bool{"change-id": true, "task-id": true, "task-kind": true, "manager": true}
+	for _, f := range r.logAttrs {
+		kvs := f(t)
+		for i := 0; i+1 < len(kvs); i += 2 {
+			if seen[kvs[i]] {
+				continue
+			}
+			seen[kvs[i]] = true
+			attrs = append(attrs, kvs[i], kvs[i+1])
+		}
+	}
+	return logger.WithAttrs(attrs...)
 }
 
 func (r *TaskRunner) handlerPair(t *Task) handlerPair {
@@ -167,6 +246,40 @@ func (r *TaskRunner) AddCleanup(kind string, cleanup HandlerFunc) {
 	r.cleanups[kind] = cleanup
 }
 
+// MarkIdempotent declares that the do handler for tasks of the given kind
+// can be run again after it failed, so that changes in which such tasks
+// failed can be retried with RetryChange.
+//
+// The handler for tasks of the provided kind must have been previously
+// registered before MarkIdempotent is called for it.
+func (r *TaskRunner) MarkIdempotent(kind string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.handlers[kind]; !ok {
+		panic("internal error: attempted to mark unknown task kind as idempotent")
+	}
+	r.idempotent[kind] = true
+}
+
+// RetryChange runs again the tasks of the given change that failed, and
+// the tasks that were held because of them, if the failed tasks are of
+// kinds marked with MarkIdempotent and no task of the change was undone
+// yet. The lanes of a task that fails are aborted right away, undoing the
+// tasks done before it, unless the change is paused: tasks of paused
+// changes that fail are not undone until the change is resumed. So a
+// change that was not paused can only be retried if nothing was done
+// before the failed task in its lanes. It also resumes the change if
+// paused.
+// The state must be locked by the caller.
+func (r *TaskRunner) RetryChange(chg *Change) error {
+	// not taking r.mu as it must be taken before the state lock; task
+	// kinds are marked as idempotent while setting up, as handlers are
+	// added
+	return chg.retry(func(t *Task) bool {
+		return r.idempotent[t.Kind()]
+	})
+}
+
 // SetBlocked sets a predicate function to decide whether to block a task from running based on the current running tasks. It can be used to control task serialisation.
 func (r *TaskRunner) SetBlocked(pred func(t *Task, running []*Task) bool) {
 	r.mu.Lock()
@@ -187,19 +300,20 @@ func (r *TaskRunner) AddBlocked(pred func(t *Task, running []*Task) bool) {
 func (r *TaskRunner) run(t *Task) {
 	var handler HandlerFunc
 	var accuRuntime func(dur time.Duration)
+	handlers := r.handlerPair(t)
 	switch t.Status() {
 	case DoStatus:
 		t.SetStatus(DoingStatus)
 		fallthrough
 	case DoingStatus:
-		handler = r.handlerPair(t).do
+		handler = handlers.do
 		accuRuntime = t.accumulateDoingTime
 
 	case UndoStatus:
 		t.SetStatus(UndoingStatus)
 		fallthrough
 	case UndoingStatus:
-		handler = r.handlerPair(t).undo
+		handler = handlers.undo
 		accuRuntime = t.accumulateUndoingTime
 
 	default:
@@ -210,6 +324,7 @@ func (r *TaskRunner) run(t *Task) {
 	}
 
 	t.At(time.Time{}) // clear schedule
+	t.logger = r.taskLogger(t, handlers.manager)
 	tomb := &tomb.Tomb{}
 	r.tombs[t.ID()] = tomb
 	tomb.Go(func() error {
@@ -292,11 +407,14 @@ func (r *TaskRunner) run(t *Task) {
 				r.state.EnsureBefore(0)
 			}
 		default:
-			r.abortLanes(t.Change(), t.Lanes())
+			if !t.Change().IsPaused() {
+				// lanes of paused changes are aborted once resumed
+				r.abortLanes(t.Change(), t.Lanes())
+			}
 			t.SetStatus(ErrorStatus)
 			t.Errorf("%s", err)
 			// ensure the error is available in the global log too
-			logger.Noticef("Change %s task (%s) failed: %v", t.Change().ID(), t.Summary(), err)
+			t.Logger().Noticef("Change %s task (%s) failed: %v", t.Change().ID(), t.Summary(), err)
 			if r.taskErrorCallback != nil {
 				r.taskErrorCallback(err)
 			}
@@ -332,7 +450,7 @@ func (r *TaskRunner) clean(t *Task) {
 		delete(r.tombs, t.ID())
 
 		if tomb.Err() != nil {
-			logger.Debugf("Cleaning task %s: %s", t.ID(), tomb.Err())
+			t.Logger().Debugf("Cleaning task %s: %s", t.ID(), tomb.Err())
 		} else {
 			t.SetClean()
 		}
@@ -439,6 +557,11 @@ ConsiderTasks:
 			continue
 		}
 
+		if (status == DoStatus || status == UndoStatus) && t.Change() != nil && t.Change().IsPaused() {
+			// Held at the task boundary until resumed.
+			continue
+		}
+
 		if status == UndoStatus && handlers.undo == nil {
 			// Although this has no dependencies itself, it must have waited
 			// above too since follow up tasks may have handlers again.
@@ -1261,6 +1261,130 @@ func (ts *taskRunnerSuite) TestCleanup(c *C) {
 	c.Assert(called, Equals, 2)
 }
 
+func (ts *taskRunnerSuite) TestTaskLogger(c *C) {
+	restore := state.MockLoggerAttrsEnabled(true)
+	defer restore()
+
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+
+	r.AddLogAttrs(func(t *state.Task) []any {
+		var snapName string
+		if err := t.Get("snap-name", &snapName); err != nil {
+			return nil
+		}
+		return []any{"snap", snapName}
+	})
+	r.AddLogAttrs(func(t *state.Task) []any {
+		// keys already added are ignored
+		return []any{"snap", "other", "task-id", "42"}
+	})
+
+	var attrs []any
+	r.ForManager("foomgr").AddHandler("foo", func(t *state.Task, tomb *tomb.Tomb) error {
+		attrs = t.Logger().Attrs()
+		return nil
+	}, nil)
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "...")
+	t1.Set("snap-name", "some-snap")
+	chg.AddTask(t1)
+	c.Check(t1.Logger().Attrs(), DeepEquals, []any{"task-id", t1.ID(), "task-kind", "foo"})
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+	r.Stop()
+
+	c.Check(attrs, DeepEquals, []any{
+		"change-id", chg.ID(),
+		"task-id", t1.ID(),
+		"task-kind", "foo",
+		"manager", "foomgr",
+		"snap", "some-snap",
+	})
+}
+
+func (ts *taskRunnerSuite) TestTaskLoggerAttrsDisabled(c *C) {
+	restore := state.MockLoggerAttrsEnabled(false)
+	defer restore()
+
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+
+	called := 0
+	r.AddLogAttrs(func(t *state.Task) []any {
+		called++
+		return []any{"snap", "some-snap"}
+	})
+
+	var attrs []any
+	r.ForManager("foomgr").AddHandler("foo", func(t *state.Task, tomb *tomb.Tomb) error {
+		attrs = t.Logger().Attrs()
+		return nil
+	}, nil)
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "...")
+	chg.AddTask(t1)
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+	r.Stop()
+
+	// the registered functions are not called when the attributes
+	// would not be logged
+	c.Check(called, Equals, 0)
+	c.Check(attrs, DeepEquals, []any{
+		"change-id", chg.ID(),
+		"task-id", t1.ID(),
+		"task-kind", "foo",
+		"manager", "foomgr",
+	})
+}
+
+func (ts *taskRunnerSuite) TestTaskLoggerManager(c *C) {
+	restore := state.MockLoggerAttrsEnabled(true)
+	defer restore()
+
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+
+	attrs := make(map[string][]any)
+	handler := func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		attrs[t.Kind()] = t.Logger().Attrs()
+		return nil
+	}
+	// no manager is known for handlers registered directly
+	r.AddHandler("foo", handler, nil)
+	r.ForManager("barmgr").AddOptionalHandler(func(t *state.Task) bool {
+		return t.Kind() == "bar"
+	}, handler, nil)
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "...")
+	t2 := st.NewTask("bar", "...")
+	chg.AddTask(t1)
+	chg.AddTask(t2)
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+	r.Stop()
+
+	c.Check(attrs, DeepEquals, map[string][]any{
+		"foo": {"change-id", chg.ID(), "task-id", t1.ID(), "task-kind", "foo"},
+		"bar": {"change-id", chg.ID(), "task-id", t2.ID(), "task-kind", "bar", "manager", "barmgr"},
+	})
+}
+
 func (ts *taskRunnerSuite) TestErrorCallbackCalledOnError(c *C) {
 	logbuf, restore := logger.MockLogger()
 	defer restore()
@@ -1328,3 +1452,378 @@ func (ts *taskRunnerSuite) TestErrorCallbackNotCalled(c *C) {
 	c.Check(t1.Status(), Equals, state.DoneStatus)
 	c.Check(called, Equals, false)
 }
+
+func (ts *taskRunnerSuite) TestPausedChange(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var calls []string
+	r.AddHandler("foo", func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary())
+		if t.Summary() == "t1" {
+			// paused while running, so this one completes
+			t.Change().Pause()
+		}
+		return nil
+	}, nil)
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	t2 := st.NewTask("foo", "t2")
+	t2.WaitFor(t1)
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	st.Unlock()
+
+	for i := 0; i < 3; i++ {
+		r.Ensure()
+		r.Wait()
+	}
+
+	st.Lock()
+	c.Check(calls, DeepEquals, []string{"t1"})
+	c.Check(chg.IsPaused(), Equals, true)
+	c.Check(t1.Status(), Equals, state.DoneStatus)
+	c.Check(t2.Status(), Equals, state.DoStatus)
+	c.Check(chg.IsReady(), Equals, false)
+	chg.Resume()
+	c.Check(chg.IsPaused(), Equals, false)
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(calls, DeepEquals, []string{"t1", "t2"})
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+}
+
+func (ts *taskRunnerSuite) TestPausedChangeErrorUndoneOnResume(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var calls []string
+	r.AddHandler("foo", func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary()+":do")
+		if t.Summary() == "t2" {
+			t.Change().Pause()
+			return errors.New("boom")
+		}
+		return nil
+	}, func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary()+":undo")
+		return nil
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	t2 := st.NewTask("foo", "t2")
+	t2.WaitFor(t1)
+	t3 := st.NewTask("foo", "t3")
+	t3.WaitFor(t2)
+	chg.AddAll(state.NewTaskSet(t1, t2, t3))
+	st.Unlock()
+
+	for i := 0; i < 4; i++ {
+		r.Ensure()
+		r.Wait()
+	}
+
+	// nothing undone while paused
+	st.Lock()
+	c.Check(calls, DeepEquals, []string{"t1:do", "t2:do"})
+	c.Check(t1.Status(), Equals, state.DoneStatus)
+	c.Check(t2.Status(), Equals, state.ErrorStatus)
+	c.Check(t3.Status(), Equals, state.DoStatus)
+	chg.Resume()
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(calls, DeepEquals, []string{"t1:do", "t2:do", "t1:undo"})
+	c.Check(t1.Status(), Equals, state.UndoneStatus)
+	c.Check(t3.Status(), Equals, state.HoldStatus)
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+}
+
+func (ts *taskRunnerSuite) testAbortPausedChange(c *C, abort func(st *state.State, chg *state.Change)) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var calls []string
+	r.AddHandler("foo", func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary()+":do")
+		if t.Summary() == "t1" {
+			t.Change().Pause()
+		}
+		return nil
+	}, func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary()+":undo")
+		return nil
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	t2 := st.NewTask("foo", "t2")
+	t2.WaitFor(t1)
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	st.Unlock()
+
+	for i := 0; i < 3; i++ {
+		r.Ensure()
+		r.Wait()
+	}
+
+	st.Lock()
+	c.Check(calls, DeepEquals, []string{"t1:do"})
+	c.Assert(chg.IsPaused(), Equals, true)
+	abort(st, chg)
+	c.Check(chg.IsPaused(), Equals, false)
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(calls, DeepEquals, []string{"t1:do", "t1:undo"})
+	c.Check(t1.Status(), Equals, state.UndoneStatus)
+	c.Check(t2.Status(), Equals, state.HoldStatus)
+	c.Check(chg.IsReady(), Equals, true)
+	c.Check(chg.Status(), Equals, state.UndoneStatus)
+}
+
+func (ts *taskRunnerSuite) TestAbortPausedChange(c *C) {
+	ts.testAbortPausedChange(c, func(st *state.State, chg *state.Change) {
+		chg.Abort()
+	})
+}
+
+func (ts *taskRunnerSuite) TestPruneAbortsPausedChange(c *C) {
+	ts.testAbortPausedChange(c, func(st *state.State, chg *state.Change) {
+		abortWait := 3 * time.Hour
+		state.MockChangeTimes(chg, time.Now().Add(-abortWait), time.Time{})
+		st.Prune(time.Now().AddDate(-1, 0, 0), time.Hour, abortWait, 100)
+		c.Assert(st.Change(chg.ID()), Equals, chg)
+	})
+}
+
+func (ts *taskRunnerSuite) TestRetryChange(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var calls []string
+	fail := true
+	r.AddHandler("foo", func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary())
+		if t.Summary() == "t1" && fail {
+			fail = false
+			return errors.New("transient")
+		}
+		return nil
+	}, nil)
+	r.MarkIdempotent("foo")
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	t2 := st.NewTask("foo", "t2")
+	t2.WaitFor(t1)
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(t2.Status(), Equals, state.HoldStatus)
+	c.Assert(chg.IsReady(), Equals, true)
+	c.Check(chg.ReadyTime().IsZero(), Equals, false)
+
+	c.Assert(r.RetryChange(chg), IsNil)
+	c.Check(chg.IsReady(), Equals, false)
+	c.Check(chg.ReadyTime().IsZero(), Equals, true)
+	c.Check(chg.Err(), IsNil)
+	c.Check(t1.Status(), Equals, state.DoStatus)
+	c.Check(t2.Status(), Equals, state.DoStatus)
+	c.Check(strings.Join(t1.Log(), "\n"), Matches, `(?s).*ERROR transient.*INFO Retrying`)
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(calls, DeepEquals, []string{"t1", "t1", "t2"})
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+	c.Check(chg.IsReady(), Equals, true)
+	select {
+	case <-chg.Ready():
+	default:
+		c.Fatal("change should be ready")
+	}
+}
+
+func (ts *taskRunnerSuite) TestRetryPausedChange(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var calls []string
+	fail := true
+	r.AddHandler("foo", func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary()+":do")
+		if t.Summary() == "t2" && fail {
+			fail = false
+			t.Change().Pause()
+			return errors.New("transient")
+		}
+		return nil
+	}, func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary()+":undo")
+		return nil
+	})
+	r.MarkIdempotent("foo")
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	t2 := st.NewTask("foo", "t2")
+	t2.WaitFor(t1)
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	st.Unlock()
+
+	for i := 0; i < 3; i++ {
+		r.Ensure()
+		r.Wait()
+	}
+
+	st.Lock()
+	c.Check(t1.Status(), Equals, state.DoneStatus)
+	c.Check(t2.Status(), Equals, state.ErrorStatus)
+	c.Assert(r.RetryChange(chg), IsNil)
+	c.Check(chg.IsPaused(), Equals, false)
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(calls, DeepEquals, []string{"t1:do", "t2:do", "t2:do"})
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+}
+
+func (ts *taskRunnerSuite) TestRetryUnpausedChangeUndone(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var calls []string
+	r.AddHandler("foo", func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary()+":do")
+		if t.Summary() == "t2" {
+			return errors.New("transient")
+		}
+		return nil
+	}, func(t *state.Task, tomb *tomb.Tomb) error {
+		st.Lock()
+		defer st.Unlock()
+		calls = append(calls, t.Summary()+":undo")
+		return nil
+	})
+	r.MarkIdempotent("foo")
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	t2 := st.NewTask("foo", "t2")
+	t2.WaitFor(t1)
+	chg.AddAll(state.NewTaskSet(t1, t2))
+	st.Unlock()
+
+	ensureChange(c, r, sb, chg)
+
+	st.Lock()
+	defer st.Unlock()
+	// the change was not paused, so what was done before the failed
+	// task was undone straight away and the change cannot be retried
+	// even if the failed task could be run again
+	c.Check(calls, DeepEquals, []string{"t1:do", "t2:do", "t1:undo"})
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(t1.Status(), Equals, state.UndoneStatus)
+	c.Check(t2.Status(), Equals, state.ErrorStatus)
+	c.Check(r.RetryChange(chg), ErrorMatches, `cannot retry change [0-9]+: it is already being undone \(changes are undone as soon as a task fails unless paused before\)`)
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+}
+
+func (ts *taskRunnerSuite) TestRetryChangeErrors(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	noop := func(t *state.Task, tomb *tomb.Tomb) error { return nil }
+	r.AddHandler("foo", noop, noop)
+	r.AddHandler("bar", noop, nil)
+	r.MarkIdempotent("foo")
+
+	st.Lock()
+	defer st.Unlock()
+
+	for _, t := range []struct {
+		statuses map[string]state.Status
+		err      string
+	}{{
+		statuses: map[string]state.Status{"foo": state.DoneStatus, "bar": state.DoneStatus},
+		err:      `cannot retry change [0-9]+: no task failed`,
+	}, {
+		statuses: map[string]state.Status{"foo": state.DoneStatus, "bar": state.ErrorStatus},
+		err:      `cannot retry change [0-9]+: task [0-9]+ \(bar task\) cannot be run again`,
+	}, {
+		statuses: map[string]state.Status{"foo": state.UndoneStatus, "bar": state.ErrorStatus},
+		err:      `cannot retry change [0-9]+: it is already being undone \(changes are undone as soon as a task fails unless paused before\)`,
+	}, {
+		statuses: map[string]state.Status{"foo": state.ErrorStatus, "bar": state.UndoStatus},
+		err:      `cannot retry change [0-9]+: it is already being undone \(changes are undone as soon as a task fails unless paused before\)`,
+	}} {
+		chg := st.NewChange("install", "...")
+		for _, kind := range []string{"foo", "bar"} {
+			task := st.NewTask(kind, kind+" task")
+			chg.AddTask(task)
+			task.SetStatus(t.statuses[kind])
+		}
+		c.Check(r.RetryChange(chg), ErrorMatches, t.err)
+	}
+
+	c.Check(func() { r.MarkIdempotent("baz") }, PanicMatches, "internal error: attempted to mark unknown task kind as idempotent")
+}
@@ -209,7 +209,8 @@ func (s *State) AddWarning(message string, options *AddWarningOptions) {
 		options = &AddWarningOptions{}
 	}
 
-	s.writing()
+	s.modifying()
+	s.dirtyWarnings()
 	s.warningsMu.Lock()
 	defer s.warningsMu.Unlock()
 
@@ -242,7 +243,8 @@ func (s *State) AddWarning(message string, options *AddWarningOptions) {
 //
 // Returns state.ErrNoState if no warning exists with given message.
 func (s *State) RemoveWarning(message string) error {
-	s.writing()
+	s.modifying()
+	s.dirtyWarnings()
 	s.warningsMu.Lock()
 	defer s.warningsMu.Unlock()
 	_, ok := s.warnings[message]
@@ -276,7 +278,8 @@ func (s *State) AllWarnings() []*Warning {
 func (s *State) OkayWarnings(t time.Time) int {
 	t = t.UTC()
 
-	s.writing()
+	s.modifying()
+	s.dirtyWarnings()
 	s.warningsMu.Lock()
 	defer s.warningsMu.Unlock()
 
@@ -345,7 +348,8 @@ func (s *State) WarningsSummary() (int, time.Time) {
 // UnshowAllWarnings clears the lastShown timestamp from all the
 // warnings. For use in debugging.
 func (s *State) UnshowAllWarnings() {
-	s.writing()
+	s.modifying()
+	s.dirtyWarnings()
 	s.warningsMu.Lock()
 	defer s.warningsMu.Unlock()
 	for _, w := range s.warnings {
@@ -22,9 +22,12 @@ package overlord
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/overlord/state"
 )
 
@@ -156,7 +159,9 @@ func (se *StateEngine) Ensure() error {
 	}
 	var errs []error
 	for _, m := range se.managers {
+		start := time.Now()
 		err := m.Ensure()
+		ensureDurationMetric.Observe(time.Since(start).Seconds(), managerName(m))
 		if err != nil {
 			logger.Noticef("state ensure error: %v", err)
 			errs = append(errs, err)
@@ -168,6 +173,15 @@ func (se *StateEngine) Ensure() error {
 	return nil
 }
 
+var ensureDurationMetric = metrics.NewHistogram("snapd_ensure_duration_seconds",
+	"Time spent by each manager in a run of the ensure loop.", metrics.DefaultBuckets, "manager")
+
+// managerName returns the name of the type of the manager, such as
+// "snapstate.SnapManager".
+func managerName(m StateManager) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", m), "*")
+}
+
 // AddManager adds the provided manager to take part in state operations.
 func (se *StateEngine) AddManager(m StateManager) {
 	se.mgrLock.Lock()
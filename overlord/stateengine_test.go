@@ -20,10 +20,12 @@
 package overlord_test
 
 import (
+	"bytes"
 	"errors"
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/state"
 )
@@ -136,6 +138,11 @@ func (ses *stateEngineSuite) TestEnsure(c *C) {
 	err = se.Ensure()
 	c.Assert(err, IsNil)
 	c.Check(calls, DeepEquals, []string{"ensure:mgr1", "ensure:mgr2", "ensure:mgr1", "ensure:mgr2"})
+
+	// the time spent in each manager is measured
+	var buf bytes.Buffer
+	c.Assert(metrics.Write(&buf), IsNil)
+	c.Check(buf.String(), Matches, `(?s).*\nsnapd_ensure_duration_seconds_count\{manager="overlord_test.fakeManager"\} [0-9]+\n.*`)
 }
 
 func (ses *stateEngineSuite) TestEnsureError(c *C) {
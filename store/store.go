@@ -45,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/jsonutil"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/release"
@@ -725,6 +726,15 @@ func (s *Store) retryRequestDecodeJSON(ctx context.Context, reqOptions *requestO
 	}, defaultRetryStrategy)
 }
 
+var (
+	requestDurationMetric = metrics.NewHistogram("snapd_store_request_duration_seconds",
+		"Time taken by requests to the store to get a response.", metrics.DefaultBuckets)
+	requestErrorsMetric = metrics.NewCounter("snapd_store_request_errors_total",
+		"Number of requests to the store that failed, by reason: network, for requests that got no response, or server, for responses with a 5xx status.", "reason")
+	downloadBytesMetric = metrics.NewCounter("snapd_store_download_bytes_total",
+		"Number of bytes downloaded from the store.")
+)
+
 // doRequest does an authenticated request to the store handling a potential macaroon refresh required if needed
 func (s *Store) doRequest(ctx context.Context, client *http.Client, reqOptions *requestOptions, user *auth.UserState) (*http.Response, error) {
 	authRefreshes := 0
@@ -737,10 +747,16 @@ func (s *Store) doRequest(ctx context.Context, client *http.Client, reqOptions *
 			req = req.WithContext(ctx)
 		}
 
+		start := time.Now()
 		resp, err := client.Do(req)
+		requestDurationMetric.Observe(time.Since(start).Seconds())
 		if err != nil {
+			requestErrorsMetric.Inc("network")
 			return nil, err
 		}
+		if resp.StatusCode >= 500 {
+			requestErrorsMetric.Inc("server")
+		}
 
 		if resp.StatusCode == 401 && authRefreshes < 4 {
 			// 4 tries: 2 tries for each in case both user
@@ -621,7 +621,9 @@ func downloadImpl(ctx context.Context, name, sha3_384, downloadURL string, user
 		}
 
 		stopMonitorCh := tc.Monitor()
-		_, finalErr = io.Copy(mw, limiter)
+		var n int64
+		n, finalErr = io.Copy(mw, limiter)
+		downloadBytesMetric.Add(float64(n))
 		close(stopMonitorCh)
 		pbar.Finished()
 
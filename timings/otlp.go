@@ -0,0 +1,225 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package timings
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// otlpExportFile is the file the timings are appended to in the
+// OTLP/JSON format when they are saved, if set.
+var otlpExportFile string
+
+var otlpExportMu sync.Mutex
+
+var randRead = rand.Read
+
+// EnableOTLPExport makes Save also append the complete span tree of
+// the timings, regardless of DurationThreshold, to the given file as
+// OpenTelemetry traces in the OTLP/JSON format, one request per line.
+// An empty path disables the export.
+func EnableOTLPExport(path string) {
+	otlpExportMu.Lock()
+	defer otlpExportMu.Unlock()
+	otlpExportFile = path
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTracesData struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+// otlpSpanKindInternal is SPAN_KIND_INTERNAL, the timings only
+// measure work done inside snapd.
+const otlpSpanKindInternal = 1
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpRandomID(n int) (string, error) {
+	id := make([]byte, n)
+	if _, err := randRead(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// otlpTraceID returns the trace id for the timings. All the timings of
+// a change share the same trace, so that its tasks and the ensure
+// activity that created it show up together.
+func (t *Timings) otlpTraceID() (string, error) {
+	if changeID := t.tags["change-id"]; changeID != "" {
+		h := sha256.Sum256([]byte("snapd-change-" + changeID))
+		return hex.EncodeToString(h[:16]), nil
+	}
+	return otlpRandomID(16)
+}
+
+// otlpRootName returns the name of the span covering the whole
+// measured activity.
+func (t *Timings) otlpRootName() string {
+	for _, tag := range []string{"task-kind", "ensure", "startup"} {
+		if name := t.tags[tag]; name != "" {
+			return name
+		}
+	}
+	return "timings"
+}
+
+func otlpSpans(traceID, parentID string, spans []*Span, out *[]*otlpSpan, maxStop *time.Time) error {
+	for _, span := range spans {
+		spanID, err := otlpRandomID(8)
+		if err != nil {
+			return err
+		}
+		stop := span.stop
+		if stop.IsZero() {
+			// never stopped, report it as still running
+			stop = timeNow()
+		}
+		if stop.After(*maxStop) {
+			*maxStop = stop
+		}
+		*out = append(*out, &otlpSpan{
+			TraceID:           traceID,
+			SpanID:            spanID,
+			ParentSpanID:      parentID,
+			Name:              span.label,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: otlpTime(span.start),
+			EndTimeUnixNano:   otlpTime(stop),
+			Attributes:        []otlpKeyValue{{Key: "snapd.summary", Value: otlpAnyValue{span.summary}}},
+		})
+		if err := otlpSpans(traceID, spanID, span.timings, out, maxStop); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Timings) otlp() (*otlpTracesData, error) {
+	traceID, err := t.otlpTraceID()
+	if err != nil {
+		return nil, err
+	}
+	rootID, err := otlpRandomID(8)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]string, 0, len(t.tags))
+	for tag := range t.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	attrs := make([]otlpKeyValue, 0, len(tags))
+	for _, tag := range tags {
+		attrs = append(attrs, otlpKeyValue{Key: "snapd." + tag, Value: otlpAnyValue{t.tags[tag]}})
+	}
+	root := &otlpSpan{
+		TraceID:    traceID,
+		SpanID:     rootID,
+		Name:       t.otlpRootName(),
+		Kind:       otlpSpanKindInternal,
+		Attributes: attrs,
+	}
+
+	var maxStop time.Time
+	spans := []*otlpSpan{root}
+	if err := otlpSpans(traceID, rootID, t.timings, &spans, &maxStop); err != nil {
+		return nil, err
+	}
+	root.StartTimeUnixNano = otlpTime(t.timings[0].start)
+	root.EndTimeUnixNano = otlpTime(maxStop)
+
+	scope := &otlpScopeSpans{Spans: spans}
+	scope.Scope.Name = "github.com/snapcore/snapd/timings"
+	resource := &otlpResourceSpans{ScopeSpans: []*otlpScopeSpans{scope}}
+	resource.Resource.Attributes = []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{"snapd"}}}
+	return &otlpTracesData{ResourceSpans: []*otlpResourceSpans{resource}}, nil
+}
+
+// exportOTLP appends the timings to the OTLP export file, if enabled.
+func exportOTLP(t *Timings) error {
+	otlpExportMu.Lock()
+	defer otlpExportMu.Unlock()
+	if otlpExportFile == "" || len(t.timings) == 0 {
+		return nil
+	}
+
+	data, err := t.otlp()
+	if err != nil {
+		return fmt.Errorf("cannot generate span ids: %v", err)
+	}
+	line, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(otlpExportFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,195 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package timings_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/testutil"
+	"github.com/snapcore/snapd/timings"
+)
+
+func (s *timingsSuite) enableOTLPExport(c *C) (exportFile string) {
+	exportFile = filepath.Join(c.MkDir(), "timings.otlp.json")
+	timings.EnableOTLPExport(exportFile)
+	s.AddCleanup(func() { timings.EnableOTLPExport("") })
+	return exportFile
+}
+
+func readExported(c *C, exportFile string) []map[string]interface{} {
+	f, err := os.Open(exportFile)
+	c.Assert(err, IsNil)
+	defer f.Close()
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		c.Assert(json.Unmarshal(scanner.Bytes(), &line), IsNil)
+		lines = append(lines, line)
+	}
+	c.Assert(scanner.Err(), IsNil)
+	return lines
+}
+
+func exportedSpans(c *C, line map[string]interface{}) []map[string]interface{} {
+	resourceSpans := line["resourceSpans"].([]interface{})
+	c.Assert(resourceSpans, HasLen, 1)
+	resource := resourceSpans[0].(map[string]interface{})
+	c.Check(resource["resource"], DeepEquals, map[string]interface{}{
+		"attributes": []interface{}{
+			map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "snapd"}},
+		},
+	})
+	scopeSpans := resource["scopeSpans"].([]interface{})
+	c.Assert(scopeSpans, HasLen, 1)
+	scope := scopeSpans[0].(map[string]interface{})
+	c.Check(scope["scope"], DeepEquals, map[string]interface{}{"name": "github.com/snapcore/snapd/timings"})
+
+	var spans []map[string]interface{}
+	for _, span := range scope["spans"].([]interface{}) {
+		spans = append(spans, span.(map[string]interface{}))
+	}
+	return spans
+}
+
+func (s *timingsSuite) TestOTLPExport(c *C) {
+	exportFile := s.enableOTLPExport(c)
+	s.mockDurationThreshold(time.Hour)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	timing := timings.New(map[string]string{"task-id": "3", "task-kind": "link-snap", "change-id": "12"})
+	meas := timing.StartSpan("doing something", "summary of something")      // time now = 1
+	timings.Run(meas, "nested", "nested summary", func(timings.Measurer) {}) // time now = 2, 3
+	meas.Stop()                                                              // time now = 4
+	timing.Save(s.st)
+
+	lines := readExported(c, exportFile)
+	c.Assert(lines, HasLen, 1)
+	spans := exportedSpans(c, lines[0])
+	c.Assert(spans, HasLen, 3)
+
+	root, span, nested := spans[0], spans[1], spans[2]
+	// all the spans of a change belong to the same trace
+	c.Check(root["traceId"], Equals, "946527e5f4d12dfbdf33f4537bf6dd2e")
+	for _, sp := range spans {
+		c.Check(sp["traceId"], Equals, root["traceId"])
+		c.Check(sp["spanId"], Matches, "[0-9a-f]{16}")
+		c.Check(sp["kind"], Equals, float64(1))
+	}
+
+	c.Check(root["parentSpanId"], IsNil)
+	c.Check(root["name"], Equals, "link-snap")
+	c.Check(root["startTimeUnixNano"], Equals, "1552294860001000000")
+	c.Check(root["endTimeUnixNano"], Equals, "1552294860004000000")
+	c.Check(root["attributes"], DeepEquals, []interface{}{
+		map[string]interface{}{"key": "snapd.change-id", "value": map[string]interface{}{"stringValue": "12"}},
+		map[string]interface{}{"key": "snapd.task-id", "value": map[string]interface{}{"stringValue": "3"}},
+		map[string]interface{}{"key": "snapd.task-kind", "value": map[string]interface{}{"stringValue": "link-snap"}},
+	})
+
+	c.Check(span["parentSpanId"], Equals, root["spanId"])
+	c.Check(span["name"], Equals, "doing something")
+	c.Check(span["startTimeUnixNano"], Equals, "1552294860001000000")
+	c.Check(span["endTimeUnixNano"], Equals, "1552294860004000000")
+	c.Check(span["attributes"], DeepEquals, []interface{}{
+		map[string]interface{}{"key": "snapd.summary", "value": map[string]interface{}{"stringValue": "summary of something"}},
+	})
+
+	c.Check(nested["parentSpanId"], Equals, span["spanId"])
+	c.Check(nested["name"], Equals, "nested")
+	c.Check(nested["startTimeUnixNano"], Equals, "1552294860002000000")
+	c.Check(nested["endTimeUnixNano"], Equals, "1552294860003000000")
+
+	// the export does not depend on the timings being kept in the state
+	var stateTimings []interface{}
+	c.Check(s.st.Get("timings", &stateTimings), IsNil)
+	c.Check(stateTimings, HasLen, 1)
+}
+
+func (s *timingsSuite) TestOTLPExportAppends(c *C) {
+	exportFile := s.enableOTLPExport(c)
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	for _, ensure := range []string{"auto-refresh", "seed"} {
+		timing := timings.New(map[string]string{"ensure": ensure})
+		timing.StartSpan("foo", "...").Stop()
+		timing.Save(s.st)
+	}
+	// nothing measured, nothing exported
+	timings.New(map[string]string{"ensure": "refresh-hints"}).Save(s.st)
+
+	lines := readExported(c, exportFile)
+	c.Assert(lines, HasLen, 2)
+	first := exportedSpans(c, lines[0])
+	second := exportedSpans(c, lines[1])
+	c.Assert(first, HasLen, 2)
+	c.Assert(second, HasLen, 2)
+	c.Check(first[0]["name"], Equals, "auto-refresh")
+	c.Check(second[0]["name"], Equals, "seed")
+	// unrelated activities are separate traces
+	c.Check(first[0]["traceId"], Matches, "[0-9a-f]{32}")
+	c.Check(first[0]["traceId"], Not(Equals), second[0]["traceId"])
+}
+
+func (s *timingsSuite) TestOTLPExportDisabled(c *C) {
+	exportFile := s.enableOTLPExport(c)
+	timings.EnableOTLPExport("")
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	timing := timings.New(nil)
+	timing.StartSpan("foo", "...").Stop()
+	timing.Save(s.st)
+
+	c.Check(exportFile, testutil.FileAbsent)
+}
+
+func (s *timingsSuite) TestOTLPExportError(c *C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+	s.enableOTLPExport(c)
+	timings.EnableOTLPExport(filepath.Join(c.MkDir(), "missing", "timings.otlp.json"))
+
+	s.st.Lock()
+	defer s.st.Unlock()
+
+	timing := timings.New(nil)
+	timing.StartSpan("foo", "...").Stop()
+	timing.Save(s.st)
+
+	c.Check(logbuf.String(), Matches, `(?s).*cannot export timings: open .*/missing/timings.otlp.json: no such file or directory\n`)
+	// the timings are still saved
+	var stateTimings []interface{}
+	c.Check(s.st.Get("timings", &stateTimings), IsNil)
+	c.Check(stateTimings, HasLen, 1)
+}
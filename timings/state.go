@@ -33,6 +33,8 @@ type TimingJSON struct {
 	Label    string        `json:"label,omitempty"`
 	Summary  string        `json:"summary,omitempty"`
 	Duration time.Duration `json:"duration"`
+	// Start is not known for timings saved by older versions of snapd
+	Start time.Time `json:"start,omitzero"`
 }
 
 type rootTimingsJSON struct {
@@ -48,6 +50,7 @@ type rootTimingsJSON struct {
 type TimingsInfo struct {
 	Tags          map[string]string
 	NestedTimings []*TimingJSON
+	StartTime     time.Time
 	Duration      time.Duration
 }
 
@@ -104,6 +107,7 @@ func flattenRecursive(data *rootTimingsJSON, timings []*Span, nestLevel int, max
 				Label:    tm.label,
 				Summary:  tm.summary,
 				Duration: dur,
+				Start:    tm.start,
 			})
 		}
 		if tm.stop.After(*maxStopTime) {
@@ -137,6 +141,10 @@ func (t *Timings) Save(s GetSaver) {
 		return
 	}
 
+	if err := exportOTLP(t); err != nil {
+		logger.Noticef("cannot export timings: %v", err)
+	}
+
 	data := t.flatten()
 	if data == nil {
 		return
@@ -172,8 +180,9 @@ func Get(s GetSaver, maxLevel int, filter func(tags map[string]string) bool) ([]
 			continue
 		}
 		res := &TimingsInfo{
-			Tags:     tm.Tags,
-			Duration: timeDuration(tm.StartTime, tm.StopTime),
+			Tags:      tm.Tags,
+			StartTime: tm.StartTime,
+			Duration:  timeDuration(tm.StartTime, tm.StopTime),
 		}
 		// negative maxLevel means no level filtering, take all nested timings
 		if maxLevel < 0 {
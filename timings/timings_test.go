@@ -120,17 +120,20 @@ func (s *timingsSuite) TestSave(c *C) {
 					"label":    "doing something-0",
 					"summary":  "...",
 					"duration": float64(1000000),
+					"start":    "2019-03-11T09:01:00.001Z",
 				},
 				map[string]interface{}{
 					"level":    float64(1),
 					"label":    "nested measurement",
 					"summary":  "...",
-					"duration": float64(2000000)},
+					"duration": float64(2000000),
+					"start":    "2019-03-11T09:01:00.002Z"},
 				map[string]interface{}{
 					"level":    float64(2),
 					"label":    "nested more",
 					"summary":  "...",
-					"duration": float64(3000000)},
+					"duration": float64(3000000),
+					"start":    "2019-03-11T09:01:00.003Z"},
 			}},
 		map[string]interface{}{
 			"tags":       map[string]interface{}{"change": "12", "task": "3"},
@@ -141,17 +144,20 @@ func (s *timingsSuite) TestSave(c *C) {
 					"label":    "doing something-1",
 					"summary":  "...",
 					"duration": float64(4000000),
+					"start":    "2019-03-11T09:01:00.007Z",
 				},
 				map[string]interface{}{
 					"level":    float64(1),
 					"label":    "nested measurement",
 					"summary":  "...",
-					"duration": float64(5000000)},
+					"duration": float64(5000000),
+					"start":    "2019-03-11T09:01:00.008Z"},
 				map[string]interface{}{
 					"level":    float64(2),
 					"label":    "nested more",
 					"summary":  "...",
-					"duration": float64(6000000)},
+					"duration": float64(6000000),
+					"start":    "2019-03-11T09:01:00.009Z"},
 			}}})
 }
 
@@ -193,18 +199,21 @@ func (s *timingsSuite) TestDuration(c *C) {
 					"label":    "foo",
 					"summary":  "...",
 					"duration": float64(5000000),
+					"start":    "2019-03-11T09:01:00.001Z",
 				},
 				map[string]interface{}{
 					"level":    float64(1),
 					"label":    "nested",
 					"summary":  "...",
 					"duration": float64(1000000),
+					"start":    "2019-03-11T09:01:00.002Z",
 				},
 				map[string]interface{}{
 					"level":    float64(1),
 					"label":    "nested sibling",
 					"summary":  "...",
 					"duration": float64(1000000),
+					"start":    "2019-03-11T09:01:00.004Z",
 				},
 			}}})
 }
@@ -245,18 +254,21 @@ func (s *timingsSuite) TestDurationThresholdAll(c *C) {
 					"label":    "main",
 					"summary":  "...",
 					"duration": float64(5000000),
+					"start":    "2019-03-11T09:01:00.001Z",
 				},
 				map[string]interface{}{
 					"level":    float64(1),
 					"label":    "nested",
 					"summary":  "...",
 					"duration": float64(3000000),
+					"start":    "2019-03-11T09:01:00.002Z",
 				},
 				map[string]interface{}{
 					"level":    float64(2),
 					"label":    "nested more",
 					"summary":  "...",
 					"duration": float64(1000000),
+					"start":    "2019-03-11T09:01:00.003Z",
 				},
 			}}})
 }
@@ -271,12 +283,14 @@ func (s *timingsSuite) TestDurationThreshold(c *C) {
 					"label":    "main",
 					"summary":  "...",
 					"duration": float64(5000000),
+					"start":    "2019-03-11T09:01:00.001Z",
 				},
 				map[string]interface{}{
 					"level":    float64(1),
 					"label":    "nested",
 					"summary":  "...",
 					"duration": float64(3000000),
+					"start":    "2019-03-11T09:01:00.002Z",
 				},
 			}}})
 }
@@ -291,6 +305,7 @@ func (s *timingsSuite) TestDurationThresholdRootOnly(c *C) {
 					"label":    "main",
 					"summary":  "...",
 					"duration": float64(5000000),
+					"start":    "2019-03-11T09:01:00.001Z",
 				},
 			}}})
 }
@@ -345,17 +360,22 @@ func (s *timingsSuite) TestGet(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(none, HasLen, 0)
 
+	at := func(ms int) time.Time {
+		return time.Date(2019, 3, 11, 9, 1, 0, ms*int(time.Millisecond), time.UTC)
+	}
+
 	tm, err := timings.Get(s.st, -1, func(tags map[string]string) bool {
 		return tags["foo"] == "1"
 	})
 	c.Assert(err, IsNil)
 	c.Check(tm, DeepEquals, []*timings.TimingsInfo{
 		{
-			Tags:     map[string]string{"foo": "1"},
-			Duration: 3000000,
+			Tags:      map[string]string{"foo": "1"},
+			StartTime: at(5),
+			Duration:  3000000,
 			NestedTimings: []*timings.TimingJSON{
-				{Level: 0, Label: "doing something-1", Summary: "...", Duration: 3000000},
-				{Level: 1, Label: "nested measurement", Summary: "...", Duration: 1000000},
+				{Level: 0, Label: "doing something-1", Summary: "...", Duration: 3000000, Start: at(5)},
+				{Level: 1, Label: "nested measurement", Summary: "...", Duration: 1000000, Start: at(6)},
 			},
 		},
 	})
@@ -364,24 +384,27 @@ func (s *timingsSuite) TestGet(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(tmOnlyLevel0, DeepEquals, []*timings.TimingsInfo{
 		{
-			Tags:     map[string]string{"foo": "0"},
-			Duration: 3000000,
+			Tags:      map[string]string{"foo": "0"},
+			StartTime: at(1),
+			Duration:  3000000,
 			NestedTimings: []*timings.TimingJSON{
-				{Level: 0, Label: "doing something-0", Summary: "...", Duration: 3000000},
+				{Level: 0, Label: "doing something-0", Summary: "...", Duration: 3000000, Start: at(1)},
 			},
 		},
 		{
-			Tags:     map[string]string{"foo": "1"},
-			Duration: 3000000,
+			Tags:      map[string]string{"foo": "1"},
+			StartTime: at(5),
+			Duration:  3000000,
 			NestedTimings: []*timings.TimingJSON{
-				{Level: 0, Label: "doing something-1", Summary: "...", Duration: 3000000},
+				{Level: 0, Label: "doing something-1", Summary: "...", Duration: 3000000, Start: at(5)},
 			},
 		},
 		{
-			Tags:     map[string]string{"foo": "2"},
-			Duration: 3000000,
+			Tags:      map[string]string{"foo": "2"},
+			StartTime: at(9),
+			Duration:  3000000,
 			NestedTimings: []*timings.TimingJSON{
-				{Level: 0, Label: "doing something-2", Summary: "...", Duration: 3000000},
+				{Level: 0, Label: "doing something-2", Summary: "...", Duration: 3000000, Start: at(9)},
 			},
 		},
 	})